@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// API key roles, each allowed everything the previous one is
+const (
+	RoleViewer   = "viewer"   // Status, history, statistics and metrics
+	RoleOperator = "operator" // Sending frames, clearing history and statistics, listener control
+	RoleAdmin    = "admin"    // Interface setup and teardown, bitrate changes, watchdog control
+)
+
+// roleRanks orders the roles from least to most privileged
+var roleRanks = map[string]int{RoleViewer: 1, RoleOperator: 2, RoleAdmin: 3}
+
+// publicPaths are served without an API key, so browsers can load the API documentation
+// and orchestrators can probe the service
+var publicPaths = map[string]bool{"/openapi.json": true, "/docs": true, "/healthz": true, "/readyz": true, "/livez": true}
+
+// isPublicPath reports whether path is served without an API key: a public path or a
+// file of the Swagger UI behind /docs
+func isPublicPath(path string) bool {
+	return publicPaths[path] || strings.HasPrefix(path, docsAssetsPrefix)
+}
+
+// principalKey is the gin context key holding the authenticated API key principal
+const principalKey = "principal"
+
+// APIKey grants a role to the holder of a token
+type APIKey struct {
+	Name  string `json:"name"` // Principal reported in denials and the access log
+	Role  string `json:"role"`
+	Token string `json:"token"`
+}
+
+// apiKeyFile is the format of the -api-keys file
+type apiKeyFile struct {
+	Keys []APIKey `json:"keys"`
+}
+
+// APIKeys maps token digests to their keys, so lookups do not compare secrets byte by byte
+type APIKeys map[[sha256.Size]byte]APIKey
+
+// LoadAPIKeys reads API keys from a JSON file ({"keys": [...]})
+func LoadAPIKeys(path string) (APIKeys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API keys: %w", err)
+	}
+
+	var file apiKeyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse API keys %s: %w", path, err)
+	}
+	if len(file.Keys) == 0 {
+		return nil, fmt.Errorf("%s: no API keys defined", path)
+	}
+
+	keys := make(APIKeys, len(file.Keys))
+	names := make(map[string]bool, len(file.Keys))
+	for _, key := range file.Keys {
+		switch {
+		case key.Name == "":
+			return nil, fmt.Errorf("%s: API key name is required", path)
+		case names[key.Name]:
+			return nil, fmt.Errorf("%s: duplicate API key name %q", path, key.Name)
+		case roleRanks[key.Role] == 0:
+			return nil, fmt.Errorf("%s: invalid role %q for API key %s. Valid options: viewer, operator, admin", path, key.Role, key.Name)
+		case len(key.Token) < 16:
+			return nil, fmt.Errorf("%s: token of API key %s must be at least 16 characters", path, key.Name)
+		}
+
+		digest := sha256.Sum256([]byte(key.Token))
+		if _, exists := keys[digest]; exists {
+			return nil, fmt.Errorf("%s: API key %s reuses the token of another key", path, key.Name)
+		}
+		names[key.Name] = true
+		keys[digest] = key
+	}
+	return keys, nil
+}
+
+// Lookup returns the key a token belongs to
+func (k APIKeys) Lookup(token string) (APIKey, bool) {
+	key, ok := k[sha256.Sum256([]byte(token))]
+	return key, ok
+}
+
+// requestToken returns the API key of a request, from "Authorization: Bearer <token>" or X-API-Key
+func requestToken(r *http.Request) string {
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// APIKeyMiddleware authenticates every request by its API key. Roles are enforced per
+// route by APIHandler.requireRole.
+func APIKeyMiddleware(keys APIKeys, logger Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isPublicPath(c.Request.URL.Path) {
+			c.Next()
+			return
+		}
+		if fromTrustedUnixSocket(c.Request) {
+			c.Set(principalKey, unixSocketPrincipal)
+			c.Next()
+			return
+		}
+
+		key, ok := keys.Lookup(requestToken(c.Request))
+		if !ok {
+			c.Header("WWW-Authenticate", `Bearer realm="can-bridge"`)
+			abortWithError(c, http.StatusUnauthorized, CodeUnauthorized, "Authentication required: a valid API key is required")
+			return
+		}
+
+		c.Set(principalKey, key)
+		c.Next()
+	}
+}
+
+// requireRole rejects requests whose API key lacks role and records mutating calls
+// with their principal. Without configured API keys every request is allowed.
+func (h *APIHandler) requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.apiKeys == nil {
+			c.Next()
+			return
+		}
+
+		key, _ := c.Get(principalKey)
+		principal, ok := key.(APIKey)
+		if !ok || roleRanks[principal.Role] < roleRanks[role] {
+			h.logger.Warnf("🔒 Denied %s %s for %q (role %s, requires %s)%s",
+				c.Request.Method, c.Request.URL.Path, principal.Name, principal.Role, role, requestIDSuffix(requestID(c)))
+			abortWithError(c, http.StatusForbidden, CodeForbidden, fmt.Sprintf("Permission denied: requires role %s", role))
+			return
+		}
+
+		c.Next()
+
+		if isWriteRequest(c.Request.Method) {
+			h.logger.Infof("📝 Audit: %s %s by %q (role %s) -> %d%s",
+				c.Request.Method, c.Request.URL.Path, principal.Name, principal.Role, c.Writer.Status(), requestIDSuffix(requestID(c)))
+		}
+	}
+}
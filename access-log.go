@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultAccessLogSkipPaths are not logged: status checks, probes and Prometheus scrapes
+var DefaultAccessLogSkipPaths = []string{"/api/v1/status", "/api/v1/health", "/api/status", "/api/health", "/healthz", "/readyz", "/livez", "/metrics"}
+
+// AccessLogSettings selects the HTTP requests the access log records. Failed requests
+// (status 400 and above) and slow ones are always logged unless their path is skipped.
+type AccessLogSettings struct {
+	SkipPaths       []string `json:"skipPaths"`       // Path prefixes never logged, e.g. /metrics
+	SampleRate      float64  `json:"sampleRate"`      // Share of successful requests logged, 0 to 1
+	SlowThresholdMs int64    `json:"slowThresholdMs"` // Requests taking this long are logged as warnings; 0 disables
+}
+
+// AccessLogRequest changes the access log settings at runtime; omitted fields are kept
+type AccessLogRequest struct {
+	SkipPaths       *[]string `json:"skipPaths,omitempty"`
+	SampleRate      *float64  `json:"sampleRate,omitempty" binding:"omitempty,min=0,max=1"`
+	SlowThresholdMs *int64    `json:"slowThresholdMs,omitempty" binding:"omitempty,min=0"`
+}
+
+// DefaultAccessLogSettings logs every request but status checks, probes and scrapes
+func DefaultAccessLogSettings() AccessLogSettings {
+	return AccessLogSettings{SkipPaths: append([]string(nil), DefaultAccessLogSkipPaths...), SampleRate: 1}
+}
+
+// apply returns the settings changed by a request
+func (s AccessLogSettings) apply(req *AccessLogRequest) AccessLogSettings {
+	if req == nil {
+		return s
+	}
+	if req.SkipPaths != nil {
+		s.SkipPaths = append([]string{}, *req.SkipPaths...)
+	}
+	if req.SampleRate != nil {
+		s.SampleRate = *req.SampleRate
+	}
+	if req.SlowThresholdMs != nil {
+		s.SlowThresholdMs = *req.SlowThresholdMs
+	}
+	return s
+}
+
+// validateAccessLogPaths checks that skipped paths are absolute
+func validateAccessLogPaths(paths []string) error {
+	for _, path := range paths {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("path prefix %q must start with /", path)
+		}
+	}
+	return nil
+}
+
+// skips reports whether a path is one of the skipped prefixes or below one. Prefixes
+// match whole path segments, so /metrics does not skip /metricsfoo.
+func (s AccessLogSettings) skips(path string) bool {
+	for _, prefix := range s.SkipPaths {
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// slow reports whether a request took long enough to be logged as a warning
+func (s AccessLogSettings) slow(latency time.Duration) bool {
+	return s.SlowThresholdMs > 0 && latency >= time.Duration(s.SlowThresholdMs)*time.Millisecond
+}
+
+// sampled reports whether a successful request is logged
+func (s AccessLogSettings) sampled() bool {
+	return s.SampleRate >= 1 || (s.SampleRate > 0 && rand.Float64() < s.SampleRate)
+}
+
+// accessLogUser returns the name of the API key or, with mutual TLS, the verified
+// client certificate identity of a request, or - for neither
+func accessLogUser(keys map[string]any) string {
+	user := "-"
+	if identity, ok := keys[clientIdentityKey].(ClientIdentity); ok {
+		user = identity.Name
+	}
+	if principal, ok := keys[principalKey].(APIKey); ok {
+		user = principal.Name
+	}
+	return user
+}
+
+// logAccess logs a request through the logger of the api component: in the combined log
+// layout as text, with its details as fields as JSON. Slow requests are logged as
+// warnings with their duration up front.
+func logAccess(c *gin.Context, logger Logger, settings *LogSettings, latency time.Duration) {
+	access := settings.accessLog()
+	path := c.Request.URL.Path
+	if access.skips(path) {
+		return
+	}
+	status := c.Writer.Status()
+	slow := access.slow(latency)
+	if status < 400 && !slow && !access.sampled() {
+		return
+	}
+	if c.Request.URL.RawQuery != "" {
+		path += "?" + c.Request.URL.RawQuery
+	}
+
+	prefix := ""
+	if slow {
+		prefix = fmt.Sprintf("🐢 Slow request (%s): ", latency.Round(time.Millisecond))
+	}
+	jsonFormat := settings.jsonFormat()
+	if jsonFormat {
+		logger = logger.With(accessLogFields(c, path, status, latency, slow)...)
+	}
+	logf := logger.Infof
+	if slow {
+		logf = logger.Warnf
+	}
+
+	if jsonFormat {
+		logf("%s%s %s %d", prefix, c.Request.Method, path, status)
+		return
+	}
+	logf("%s%s - %s \"%s %s %s %d %s \"%s\" %s\"%s", prefix,
+		c.ClientIP(),
+		accessLogUser(c.Keys),
+		c.Request.Method,
+		path,
+		c.Request.Proto,
+		status,
+		latency,
+		c.Request.UserAgent(),
+		c.Errors.ByType(gin.ErrorTypePrivate).String(),
+		requestIDSuffix(requestID(c)),
+	)
+}
+
+// accessLogFields are the details of a request as fields, for the JSON format
+func accessLogFields(c *gin.Context, path string, status int, latency time.Duration, slow bool) []interface{} {
+	bytes := c.Writer.Size()
+	if bytes < 0 {
+		bytes = 0
+	}
+	fields := []interface{}{
+		"method", c.Request.Method,
+		"path", path,
+		"status", status,
+		"duration_ms", float64(latency.Microseconds()) / 1000,
+		"bytes", bytes,
+		"client_ip", c.ClientIP(),
+		"user", accessLogUser(c.Keys),
+		"user_agent", c.Request.UserAgent(),
+	}
+	if slow {
+		fields = append(fields, "slow", true)
+	}
+	if id := requestID(c); id != "" {
+		fields = append(fields, "request_id", id)
+	}
+	if errs := c.Errors.ByType(gin.ErrorTypePrivate).String(); errs != "" {
+		fields = append(fields, "error", errs)
+	}
+	return fields
+}
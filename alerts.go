@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Alert rule metrics
+const (
+	AlertMetricBusLoad   = "bus_load"   // Percent of the bitrate used by received frames
+	AlertMetricErrorRate = "error_rate" // Error frames per second
+	AlertMetricIDRate    = "id_rate"    // Frames per second of one CAN ID
+	AlertMetricSilence   = "silence"    // Seconds since the last received frame
+)
+
+// defaultAlertWindow is the rate window of a rule that sets none; id_rate averages
+// over a longer window so periodic IDs slower than 1 Hz do not flap
+func defaultAlertWindow(metric string) time.Duration {
+	if metric == AlertMetricIDRate {
+		return 10 * time.Second
+	}
+	return time.Second
+}
+
+var alertMetrics = []string{AlertMetricBusLoad, AlertMetricErrorRate, AlertMetricIDRate, AlertMetricSilence}
+
+// Alert states
+const (
+	alertStateInactive = "inactive"
+	alertStatePending  = "pending" // Condition met, waiting for the for-duration
+	alertStateFiring   = "firing"
+)
+
+// alertEvaluationInterval is how often the monitor evaluates alert rules
+const alertEvaluationInterval = time.Second
+
+// AlertRule raises an alert when a metric of an interface compares true against the
+// threshold for at least the For duration
+type AlertRule struct {
+	Name      string  `json:"name"`
+	Interface string  `json:"interface"`
+	Metric    string  `json:"metric"`
+	ID        *uint32 `json:"id,omitempty"` // CAN ID, required by id_rate
+	Op        string  `json:"op"`           // >, >=, < or <=
+	Threshold float64 `json:"threshold"`
+	Window    string  `json:"window,omitempty"`   // Rate window of rate metrics: 1s, 10s or 60s
+	For       string  `json:"for,omitempty"`      // e.g. 30s; empty fires on the first match
+	Severity  string  `json:"severity,omitempty"` // Notification severity (default: warning)
+
+	window      time.Duration
+	forDuration time.Duration
+}
+
+// alertRuleFile is the format of the -alert-rules file
+type alertRuleFile struct {
+	Rules []AlertRule `json:"rules"`
+}
+
+// LoadAlertRules reads alert rules from a JSON file ({"rules": [...]})
+func LoadAlertRules(path string) ([]AlertRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert rules: %w", err)
+	}
+
+	var file alertRuleFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse alert rules %s: %w", path, err)
+	}
+	return file.Rules, nil
+}
+
+// normalize validates the rule, fills defaults and parses the window and for-duration
+func (r *AlertRule) normalize() error {
+	if r.Name == "" {
+		return fmt.Errorf("alert rule name is required")
+	}
+	if r.Interface == "" {
+		return fmt.Errorf("alert rule %s: interface is required", r.Name)
+	}
+
+	known := false
+	for _, metric := range alertMetrics {
+		known = known || metric == r.Metric
+	}
+	if !known {
+		return fmt.Errorf("alert rule %s: unknown metric %q. Valid options: %v", r.Name, r.Metric, alertMetrics)
+	}
+	if r.Metric == AlertMetricIDRate && r.ID == nil {
+		return fmt.Errorf("alert rule %s: id_rate requires an id", r.Name)
+	}
+
+	switch r.Op {
+	case ">", ">=", "<", "<=":
+	default:
+		return fmt.Errorf("alert rule %s: invalid op %q. Valid options: >, >=, <, <=", r.Name, r.Op)
+	}
+
+	r.window = defaultAlertWindow(r.Metric)
+	if r.Window != "" {
+		if r.Metric == AlertMetricSilence {
+			return fmt.Errorf("alert rule %s: silence does not take a window", r.Name)
+		}
+		window, err := parseRateWindow(r.Window)
+		if err != nil {
+			return fmt.Errorf("alert rule %s: %w", r.Name, err)
+		}
+		r.window = window
+	}
+
+	r.forDuration = 0
+	if r.For != "" {
+		duration, err := time.ParseDuration(r.For)
+		if err != nil || duration < 0 {
+			return fmt.Errorf("alert rule %s: invalid for duration %q", r.Name, r.For)
+		}
+		r.forDuration = duration
+	}
+
+	if r.Severity == "" {
+		r.Severity = SeverityWarning
+	}
+	if _, ok := severityRank[r.Severity]; !ok {
+		return fmt.Errorf("alert rule %s: invalid severity %q. Valid options: info, warning, critical", r.Name, r.Severity)
+	}
+
+	return nil
+}
+
+// matches compares a value against the rule threshold
+func (r AlertRule) matches(value float64) bool {
+	switch r.Op {
+	case ">":
+		return value > r.Threshold
+	case ">=":
+		return value >= r.Threshold
+	case "<":
+		return value < r.Threshold
+	case "<=":
+		return value <= r.Threshold
+	}
+	return false
+}
+
+// AlertStatus reports a rule and its current evaluation state
+type AlertStatus struct {
+	Rule          AlertRule `json:"rule"`
+	State         string    `json:"state"` // inactive, pending or firing
+	Value         float64   `json:"value"`
+	Error         string    `json:"error,omitempty"` // Reading the metric failed
+	PendingSince  time.Time `json:"pendingSince,omitempty"`
+	FiringSince   time.Time `json:"firingSince,omitempty"`
+	LastEvaluated time.Time `json:"lastEvaluated,omitempty"`
+}
+
+// ActiveAlert is a firing alert as reported in the system status
+type ActiveAlert struct {
+	Name      string    `json:"name"`
+	Interface string    `json:"interface"`
+	Metric    string    `json:"metric"`
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Severity  string    `json:"severity"`
+	Since     time.Time `json:"since"`
+}
+
+// alertTransition is a rule that started firing or resolved during an evaluation
+type alertTransition struct {
+	status   AlertStatus
+	resolved bool
+}
+
+// AlertEngine tracks the state of alert rules across evaluations
+type AlertEngine struct {
+	statuses []AlertStatus
+	mutex    sync.Mutex
+}
+
+// NewAlertEngine creates an engine for already normalized rules
+func NewAlertEngine(rules []AlertRule) *AlertEngine {
+	statuses := make([]AlertStatus, len(rules))
+	for i, rule := range rules {
+		statuses[i] = AlertStatus{Rule: rule, State: alertStateInactive}
+	}
+	return &AlertEngine{statuses: statuses}
+}
+
+// HasRules reports whether any rule is configured
+func (e *AlertEngine) HasRules() bool {
+	return len(e.statuses) > 0
+}
+
+// Evaluate reads the metric of every rule and returns the rules that started firing or resolved
+func (e *AlertEngine) Evaluate(now time.Time, value func(AlertRule) (float64, error)) []alertTransition {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	var transitions []alertTransition
+	for i := range e.statuses {
+		status := &e.statuses[i]
+		current, err := value(status.Rule)
+		status.Value = current
+		status.LastEvaluated = now
+		status.Error = ""
+		if err != nil {
+			status.Error = err.Error()
+		}
+
+		if err != nil || !status.Rule.matches(current) {
+			if status.State == alertStateFiring {
+				transitions = append(transitions, alertTransition{status: *status, resolved: true})
+			}
+			status.State = alertStateInactive
+			status.PendingSince = time.Time{}
+			status.FiringSince = time.Time{}
+			continue
+		}
+
+		if status.State == alertStateInactive {
+			status.State = alertStatePending
+			status.PendingSince = now
+		}
+		if status.State == alertStatePending && now.Sub(status.PendingSince) >= status.Rule.forDuration {
+			status.State = alertStateFiring
+			status.FiringSince = now
+			transitions = append(transitions, alertTransition{status: *status})
+		}
+	}
+	return transitions
+}
+
+// GetStatuses returns the state of every rule
+func (e *AlertEngine) GetStatuses() []AlertStatus {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	result := make([]AlertStatus, len(e.statuses))
+	copy(result, e.statuses)
+	return result
+}
+
+// GetActive returns the firing alerts
+func (e *AlertEngine) GetActive() []ActiveAlert {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	active := []ActiveAlert{}
+	for _, status := range e.statuses {
+		if status.State != alertStateFiring {
+			continue
+		}
+		active = append(active, ActiveAlert{
+			Name:      status.Rule.Name,
+			Interface: status.Rule.Interface,
+			Metric:    status.Rule.Metric,
+			Value:     status.Value,
+			Threshold: status.Rule.Threshold,
+			Severity:  status.Rule.Severity,
+			Since:     status.FiringSince,
+		})
+	}
+	return active
+}
+
+// FindRule returns a configured rule by name
+func (e *AlertEngine) FindRule(name string) (AlertRule, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	for _, status := range e.statuses {
+		if status.Rule.Name == name {
+			return status.Rule, true
+		}
+	}
+	return AlertRule{}, false
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// legacyAPIPrefix is the unversioned prefix of the routes before /api/v1
+const legacyAPIPrefix = "/api"
+
+// apiVersionKey is the gin context key holding the API version of the matched route group
+const apiVersionKey = "apiVersion"
+
+// apiVersion is one version of the REST API. Handlers hold the business logic and reply
+// through respondSuccess and respondError, which render with the version of the route
+// group, so a new version registers the same handlers with its own render function
+// instead of copying them.
+type apiVersion struct {
+	name   string
+	prefix string
+	render func(c *gin.Context, statusCode int, response ApiResponse)
+}
+
+// apiV1 is the current API: the ApiResponse envelope marshaled as is
+var apiV1 = &apiVersion{
+	name:   "v1",
+	prefix: "/api/v1",
+	render: func(c *gin.Context, statusCode int, response ApiResponse) {
+		c.JSON(statusCode, response)
+	},
+}
+
+// useAPIVersion tags the requests of a route group with its API version
+func useAPIVersion(version *apiVersion) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiVersionKey, version)
+		c.Next()
+	}
+}
+
+// requestAPIVersion returns the API version of a request. Routes outside a versioned
+// group (/, /metrics, the documentation) answer as the current version.
+func requestAPIVersion(c *gin.Context) *apiVersion {
+	if version, ok := c.Value(apiVersionKey).(*apiVersion); ok {
+		return version
+	}
+	return apiV1
+}
+
+// deprecatedAlias marks responses of the unversioned /api aliases as deprecated and
+// links the versioned path that replaces them
+func deprecatedAlias(successor *apiVersion) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := successor.prefix + strings.TrimPrefix(c.Request.URL.Path, legacyAPIPrefix)
+		c.Header("Deprecation", "true")
+		c.Header("Link", "<"+path+`>; rel="successor-version"`)
+		c.Next()
+	}
+}
+
+// render writes a response in the format of the request's API version
+func (h *APIHandler) render(c *gin.Context, statusCode int, response ApiResponse) {
+	requestAPIVersion(c).render(c, statusCode, response)
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,6 +19,28 @@ type APIHandler struct {
 	monitor         *Monitor
 	setupManager    *InterfaceSetupManager
 	messageListener *CanMessageListener
+	httpMetrics     *HTTPMetrics
+	simulator       *NodeSimulator
+	dbc             *DBC
+	namedMessages   map[string]*NamedMessage
+	frameCapture    *FrameCapture
+	j1939           *J1939Manager
+	apiKeys         APIKeys
+	docsEnabled     bool
+	legacyRoutes    bool
+	openAPISpec     map[string]interface{}
+	probes          *Probes
+	idempotency     *IdempotencyCache
+	ipc             *IPCServer
+	statsd          *StatsDEmitter
+	configManager   ConfigManager
+	logSettings     *LogSettings
+	logSampler      *LogSampler
+	logOutput       *LogOutput
+	logFile         *RotatingFile
+	blackbox        *Blackbox
+	audit           *AuditLog
+	maxBatchFrames  int // 0 for no limit
 	logger          Logger
 }
 
@@ -51,60 +77,293 @@ func NewAPIHandlerWithSetupAndListener(messageSender *MessageSender, monitor *Mo
 	}
 }
 
+// SetHTTPMetrics sets the API request metrics exposed on /metrics
+func (h *APIHandler) SetHTTPMetrics(metrics *HTTPMetrics) {
+	h.httpMetrics = metrics
+}
+
+// SetNodeSimulator sets the simulated nodes reported by the simulator endpoint
+func (h *APIHandler) SetNodeSimulator(simulator *NodeSimulator) {
+	h.simulator = simulator
+}
+
+// SetAPIKeys enables role checks on every route for the given API keys
+func (h *APIHandler) SetAPIKeys(keys APIKeys) {
+	h.apiKeys = keys
+}
+
+// SetProbes enables the /healthz, /readyz and /livez probe endpoints
+func (h *APIHandler) SetProbes(probes *Probes) {
+	h.probes = probes
+}
+
+// SetIdempotencyCache sets the cache that answers replayed Idempotency-Keys; nil disables them
+func (h *APIHandler) SetIdempotencyCache(cache *IdempotencyCache) {
+	h.idempotency = cache
+}
+
+// ConfigManager reads and replaces the running configuration; Service implements it
+type ConfigManager interface {
+	EffectiveConfig() (map[string]interface{}, string)
+	Reload(req ReloadRequest) (*ReloadResult, error)
+}
+
+// SetConfigManager sets what the /api/v1/config routes use; nil disables them
+func (h *APIHandler) SetConfigManager(manager ConfigManager) {
+	h.configManager = manager
+}
+
+// SetLogSampler sets the sampler whose counts /api/v1/metrics and /metrics report
+func (h *APIHandler) SetLogSampler(sampler *LogSampler) {
+	h.logSampler = sampler
+}
+
+// SetLogOutput sets the log output whose write errors /api/v1/metrics reports
+func (h *APIHandler) SetLogOutput(output *LogOutput) {
+	h.logOutput = output
+}
+
+// SetLogSettings sets the log levels /api/v1/logging reads and changes; nil disables the routes
+func (h *APIHandler) SetLogSettings(settings *LogSettings) {
+	h.logSettings = settings
+}
+
+// SetMaxBatchFrames sets the most frames one multi-interface send may carry
+func (h *APIHandler) SetMaxBatchFrames(maxFrames int) {
+	h.maxBatchFrames = maxFrames
+}
+
+// SetIPCServer sets the IPC server whose counters are reported; nil when disabled
+func (h *APIHandler) SetIPCServer(ipc *IPCServer) {
+	h.ipc = ipc
+}
+
+// SetStatsD sets the StatsD emitter whose counters are reported; nil when disabled
+func (h *APIHandler) SetStatsD(statsd *StatsDEmitter) {
+	h.statsd = statsd
+}
+
+// SetAPIDocs enables the OpenAPI document and the Swagger UI page
+func (h *APIHandler) SetAPIDocs(enabled bool) {
+	h.docsEnabled = enabled
+}
+
+// SetLegacyRoutes also serves the API at its deprecated unversioned /api paths
+func (h *APIHandler) SetLegacyRoutes(enabled bool) {
+	h.legacyRoutes = enabled
+}
+
+// SetDBC sets the message definitions used by signal-based sends
+func (h *APIHandler) SetDBC(db *DBC) {
+	h.dbc = db
+}
+
+// SetNamedMessages sets the messages sendable by name; nil disables the routes
+func (h *APIHandler) SetNamedMessages(messages map[string]*NamedMessage) {
+	h.namedMessages = messages
+}
+
+// SetFrameCapture sets the candump capture; nil disables the routes
+func (h *APIHandler) SetFrameCapture(capture *FrameCapture) {
+	h.frameCapture = capture
+}
+
+// SetJ1939 sets the J1939 sockets; nil disables the routes
+func (h *APIHandler) SetJ1939(j1939 *J1939Manager) {
+	h.j1939 = j1939
+}
+
+// SetLogFile sets the service log file reported by the metrics; nil when logging to
+// standard error
+func (h *APIHandler) SetLogFile(logFile *RotatingFile) {
+	h.logFile = logFile
+}
+
+// SetAuditLog sets the control-plane audit log served by GET /api/v1/audit
+func (h *APIHandler) SetAuditLog(audit *AuditLog) {
+	h.audit = audit
+}
+
+// SetBlackbox sets the blackbox written on demand; nil disables the route
+func (h *APIHandler) SetBlackbox(blackbox *Blackbox) {
+	h.blackbox = blackbox
+}
+
+// interfaceName returns the interface an alias from -interface-aliases stands for, or
+// name unchanged, so every interface parameter accepts either
+func (h *APIHandler) interfaceName(name string) string {
+	return h.messageSender.configProvider.ResolveInterfaceAlias(name)
+}
+
 // SetupRoutes configures all API routes
 func (h *APIHandler) SetupRoutes(r *gin.Engine) {
+	viewer := h.requireRole(RoleViewer)
+
 	// Simple status page
-	r.GET("/", h.handleRoot)
-
-	api := r.Group("/api")
-	{
-		// Message endpoints
-		api.POST("/can", h.handleCanMessage)
-
-		// Status and monitoring endpoints
-		api.GET("/status", h.handleSystemStatus)
-		api.GET("/interfaces", h.handleInterfacesList)
-		api.GET("/interfaces/:name/status", h.handleInterfaceStatus)
-		api.GET("/health", h.handleHealthSummary)
-		api.GET("/metrics", h.handleMetrics)
-
-		// Interface setup endpoints (new)
-		if h.setupManager != nil {
-			setup := api.Group("/setup")
-			{
-				setup.GET("/config", h.handleGetSetupConfig)
-				setup.PUT("/config", h.handleUpdateSetupConfig)
-				setup.GET("/available", h.handleGetAvailableInterfaces)
-				setup.POST("/interfaces/:name", h.handleSetupInterface)
-				setup.DELETE("/interfaces/:name", h.handleTeardownInterface)
-				setup.POST("/interfaces/:name/reset", h.handleResetInterface)
-				setup.GET("/interfaces/:name/state", h.handleGetInterfaceState)
-				setup.POST("/interfaces/setup-all", h.handleSetupAllInterfaces)
-				setup.POST("/interfaces/teardown-all", h.handleTeardownAllInterfaces)
-			}
+	r.GET("/", viewer, h.handleRoot)
+
+	// Prometheus scrape endpoint
+	r.GET("/metrics", viewer, h.handlePrometheusMetrics)
+
+	// Orchestrator probes, public like the documentation
+	if h.probes != nil {
+		r.GET("/healthz", h.handleHealthz)
+		r.GET("/readyz", h.handleReadyz)
+		r.GET("/livez", h.handleLivez)
+	}
+
+	// Blackbox dump on demand, e.g. while a problem is happening
+	if h.blackbox != nil {
+		r.POST("/debug/blackbox", h.requireRole(RoleAdmin), h.handleBlackboxDump)
+	}
+
+	// Unknown routes get the error envelope instead of gin's plain text
+	r.NoRoute(func(c *gin.Context) {
+		h.respondError(c, http.StatusNotFound, fmt.Sprintf("No route for %s %s", c.Request.Method, c.Request.URL.Path), nil)
+	})
+
+	h.registerAPIRoutes(r.Group(apiV1.prefix, useAPIVersion(apiV1)))
+
+	// API documentation, built from the routes registered above, so the deprecated
+	// aliases are left out. It is public, like the source.
+	if h.docsEnabled {
+		r.GET("/openapi.json", h.handleOpenAPI)
+		r.GET("/docs", h.handleDocs)
+		h.openAPISpec = buildOpenAPISpec(r.Routes(), h.apiKeys != nil)
+		r.GET(docsAssetsPrefix+"*file", h.handleDocsAsset) // Not an API route, so left out of the document
+	}
+
+	// Unversioned aliases for clients deployed before /api/v1
+	if h.legacyRoutes {
+		h.logger.Warnf("⚠️ Serving deprecated unversioned %s routes as aliases of %s (disable with -legacy-api-routes=false)",
+			legacyAPIPrefix, apiV1.prefix)
+		h.registerAPIRoutes(r.Group(legacyAPIPrefix, deprecatedAlias(apiV1), useAPIVersion(apiV1)))
+	}
+}
+
+// registerAPIRoutes registers the REST API on the route group of a version
+func (h *APIHandler) registerAPIRoutes(api *gin.RouterGroup) {
+	// Roles required per route; only enforced when API keys are configured
+	viewer, operator, admin := h.requireRole(RoleViewer), h.requireRole(RoleOperator), h.requireRole(RoleAdmin)
+	// Sends and control requests may carry an Idempotency-Key, so retries do not repeat them
+	idempotent := h.idempotent()
+
+	// Message endpoints
+	api.POST("/can", operator, idempotent, h.handleCanMessage)
+	api.POST("/can/multi", operator, idempotent, h.handleCanMessageMulti)
+	api.POST("/can/until", operator, h.handleSendUntil)
+	api.GET("/tasks", viewer, h.handleGetTasks)
+	api.DELETE("/tasks", operator, h.handleCancelTasks)
+	if h.dbc != nil {
+		api.POST("/send/signal", operator, idempotent, h.handleSendSignal)
+	}
+	if h.namedMessages != nil {
+		api.GET("/send/named", viewer, h.handleGetNamedMessages)
+		api.POST("/send/named/:name", operator, idempotent, h.handleSendNamedMessage)
+	}
+
+	// Status and monitoring endpoints
+	api.GET("/status", viewer, h.handleSystemStatus)
+	api.GET("/interfaces", viewer, h.handleInterfacesList)
+	api.GET("/interfaces/:name/status", viewer, h.handleInterfaceStatus)
+	api.GET("/interfaces/:name/load", viewer, h.handleInterfaceLoad)
+	api.POST("/interfaces/:name/tx", admin, idempotent, h.handleSetTxEnabled)
+	api.GET("/health", viewer, h.handleHealthSummary)
+	api.GET("/metrics", viewer, h.handleMetrics)
+	api.POST("/benchmark/tx", admin, h.handleTxBenchmark)
+
+	// Per-ID traffic statistics
+	api.GET("/stats/ids", viewer, h.handleGetIDWindowStats)
+	api.GET("/stats/:interface/ids", viewer, h.handleGetIDStats)
+	api.POST("/stats/:interface/ids/reset", operator, h.handleResetIDStats)
+	api.GET("/stats/:interface/errors", viewer, h.handleGetErrorStats)
+	api.GET("/stats/:interface/kernel", viewer, h.handleGetSocketStats)
+
+	// Simulated nodes (test mode)
+	if h.simulator != nil {
+		api.GET("/simulator/nodes", viewer, h.handleGetSimulatedNodes)
+	}
+
+	// Alert rules
+	api.GET("/alerts", viewer, h.handleGetAlerts)
+	api.POST("/alerts/test", operator, h.handleTestAlertRule)
+	api.GET("/triggers", viewer, h.handleGetFrameTriggers)
+	api.POST("/triggers", operator, h.handleAddFrameTrigger)
+	api.DELETE("/triggers/:name", operator, h.handleRemoveFrameTrigger)
+
+	// candump frame capture
+	if h.frameCapture != nil {
+		api.GET("/capture", viewer, h.handleGetCapture)
+		api.POST("/capture/:interface/start", operator, h.handleStartCapture)
+		api.POST("/capture/:interface/stop", operator, h.handleStopCapture)
+	}
+
+	// J1939 parameter groups, apart from the raw frame routes
+	if h.j1939 != nil {
+		api.GET("/j1939", viewer, h.handleGetJ1939)
+		api.GET("/j1939/:interface/messages", viewer, h.handleGetJ1939Messages)
+		api.POST("/j1939/:interface/send", operator, h.handleSendJ1939)
+	}
+
+	// Configuration
+	if h.configManager != nil {
+		api.GET("/config", viewer, h.handleGetConfig)
+		api.PUT("/config", admin, h.handlePutConfig)
+		api.POST("/config/reload", admin, h.handleConfigReload)
+	}
+	if h.logSettings != nil {
+		api.GET("/logging", viewer, h.handleGetLogging)
+		api.PUT("/logging", admin, h.handlePutLogging)
+	}
+	if h.audit != nil {
+		api.GET("/audit", admin, h.handleGetAudit)
+	}
+
+	// Watchdog control endpoints
+	api.POST("/watchdog/interfaces/:name/retry", admin, idempotent, h.handleWatchdogRetry)
+	api.GET("/watchdog/events", viewer, h.handleWatchdogEvents)
+	api.POST("/watchdog/pause", admin, idempotent, h.handleWatchdogPause)
+	api.POST("/watchdog/resume", admin, idempotent, h.handleWatchdogResume)
+
+	// Interface setup endpoints (new)
+	if h.setupManager != nil {
+		api.POST("/interfaces/:name/bitrate", admin, idempotent, h.handleSetInterfaceBitrate)
+
+		setup := api.Group("/setup")
+		{
+			setup.GET("/config", viewer, h.handleGetSetupConfig)
+			setup.PUT("/config", admin, h.handleUpdateSetupConfig)
+			setup.GET("/available", viewer, h.handleGetAvailableInterfaces)
+			setup.GET("/status", viewer, h.handleGetSetupStatus)
+			setup.POST("/interfaces/:name", admin, idempotent, h.handleSetupInterface)
+			setup.DELETE("/interfaces/:name", admin, h.handleTeardownInterface)
+			setup.POST("/interfaces/:name/reset", admin, idempotent, h.handleResetInterface)
+			setup.GET("/interfaces/:name/state", viewer, h.handleGetInterfaceState)
+			setup.POST("/interfaces/setup-all", admin, idempotent, h.handleSetupAllInterfaces)
+			setup.POST("/interfaces/teardown-all", admin, idempotent, h.handleTeardownAllInterfaces)
 		}
+	}
 
-		// Message listening endpoints (new)
-		if h.messageListener != nil {
-			messages := api.Group("/messages")
-			{
-				// Get messages from specific interface
-				messages.GET("/:interface", h.handleGetMessages)
-				messages.GET("/:interface/recent", h.handleGetRecentMessages)
-				messages.GET("/:interface/statistics", h.handleGetMessageStatistics)
-				messages.DELETE("/:interface", h.handleClearMessages)
-
-				// Global message operations
-				messages.GET("/", h.handleGetAllMessages)
-				messages.GET("/statistics", h.handleGetAllMessageStatistics)
-				messages.DELETE("/", h.handleClearAllMessages)
-
-				// Listener control
-				messages.POST("/:interface/listen/start", h.handleStartListening)
-				messages.POST("/:interface/listen/stop", h.handleStopListening)
-				messages.GET("/:interface/listen/status", h.handleGetListenStatus)
-				messages.GET("/listen/status", h.handleGetAllListenStatus)
-			}
+	// Message listening endpoints (new)
+	if h.messageListener != nil {
+		messages := api.Group("/messages")
+		{
+			// Get messages from specific interface
+			messages.GET("/:interface", viewer, h.handleGetMessages)
+			messages.GET("/:interface/recent", viewer, h.handleGetRecentMessages)
+			messages.GET("/:interface/statistics", viewer, h.handleGetMessageStatistics)
+			messages.DELETE("/:interface", operator, h.handleClearMessages)
+
+			// Global message operations
+			messages.GET("/", viewer, h.handleGetAllMessages)
+			messages.GET("/statistics", viewer, h.handleGetAllMessageStatistics)
+			messages.DELETE("/", operator, h.handleClearAllMessages)
+
+			// Listener control
+			messages.POST("/:interface/listen/start", operator, h.handleStartListening)
+			messages.POST("/:interface/listen/stop", operator, h.handleStopListening)
+			messages.GET("/:interface/listen/status", viewer, h.handleGetListenStatus)
+			messages.GET("/listen/status", viewer, h.handleGetAllListenStatus)
 		}
 	}
 }
@@ -114,10 +373,42 @@ func (h *APIHandler) handleRoot(c *gin.Context) {
 	c.String(http.StatusOK, "CAN Communication Service is running")
 }
 
+// handleHealthz answers as long as the process serves HTTP
+func (h *APIHandler) handleHealthz(c *gin.Context) {
+	h.respondSuccess(c, "ok", nil)
+}
+
+// handleReadyz reports whether the service can carry traffic
+func (h *APIHandler) handleReadyz(c *gin.Context) {
+	h.respondProbe(c, "ready", h.probes.Readiness())
+}
+
+// handleLivez reports whether the background loops still make progress
+func (h *APIHandler) handleLivez(c *gin.Context) {
+	h.respondProbe(c, "live", h.probes.Liveness())
+}
+
+// respondProbe answers a probe with 200, or 503 and the reasons it failed
+func (h *APIHandler) respondProbe(c *gin.Context, state string, result ProbeResult) {
+	if result.OK {
+		h.respondSuccess(c, state, result)
+		return
+	}
+	response := newErrorResponse(c, CodeUnavailable, "Not "+state+": "+strings.Join(result.Reasons, "; "), nil)
+	response.Data = result
+	h.render(c, http.StatusServiceUnavailable, response)
+}
+
 // handleCanMessage handles raw CAN message requests
 func (h *APIHandler) handleCanMessage(c *gin.Context) {
 	var req CanMessage
-	if err := c.ShouldBindJSON(&req); err != nil {
+	req.ctx, req.acceptedAt = c.Request.Context(), time.Now()
+	req.trace, req.requestID = requestSpanContext(c), requestID(c)
+	req.client, req.remoteAddr = requestClient(c), c.ClientIP()
+	if !h.bindRequest(c, &req, "Invalid CAN message request") {
+		return
+	}
+	if err := req.decodeDataHex(); err != nil {
 		h.respondError(c, http.StatusBadRequest, "Invalid CAN message request", err)
 		return
 	}
@@ -129,17 +420,287 @@ func (h *APIHandler) handleCanMessage(c *gin.Context) {
 	}
 
 	// Send the CAN message
-	if err := h.messageSender.SendCanMessage(req); err != nil {
+	result, err := h.messageSender.SendCanMessage(req)
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to send CAN message", err)
+		return
+	}
+
+	if result.DryRun {
+		h.respondSuccess(c, "Dry run: CAN message validated but not sent", result)
+		return
+	}
+
+	h.respondSuccess(c, "CAN message sent successfully", result)
+}
+
+// handleCanMessageMulti sends one frame on several interfaces concurrently
+func (h *APIHandler) handleCanMessageMulti(c *gin.Context) {
+	var req MultiSendRequest
+	req.ctx, req.acceptedAt = c.Request.Context(), time.Now()
+	req.trace, req.requestID = requestSpanContext(c), requestID(c)
+	req.client, req.remoteAddr = requestClient(c), c.ClientIP()
+	if !h.bindRequest(c, &req, "Invalid multi-interface send request") {
+		return
+	}
+	if h.maxBatchFrames > 0 && len(req.Interfaces) > h.maxBatchFrames {
+		h.respondError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("Too many frames: %d interfaces listed, at most %d allowed",
+			len(req.Interfaces), h.maxBatchFrames), nil)
+		return
+	}
+	if err := req.decodeDataHex(); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid multi-interface send request", err)
+		return
+	}
+
+	// An alias and the interface it stands for are the same interface
+	listed := make(map[string]bool, len(req.Interfaces))
+	for i, name := range req.Interfaces {
+		req.Interfaces[i] = h.interfaceName(name)
+		if listed[req.Interfaces[i]] {
+			h.respondError(c, http.StatusBadRequest, "Invalid multi-interface send request",
+				tagError(ErrValidation, fmt.Errorf("interfaces lists %s more than once, by name or alias", req.Interfaces[i])))
+			return
+		}
+		listed[req.Interfaces[i]] = true
+	}
+
+	// Every interface is validated before anything is sent
+	for _, ifName := range req.Interfaces {
+		msg := req.CanMessage
+		msg.Interface = ifName
+		if err := h.messageSender.ValidateMessage(msg); err != nil {
+			h.respondError(c, http.StatusBadRequest, "Message validation failed", err)
+			return
+		}
+	}
+
+	result, err := h.messageSender.SendCanMessageMulti(req.CanMessage, req.Interfaces)
+	if err != nil {
+		h.respondError(c, http.StatusServiceUnavailable, "Failed to send CAN message", err)
+		return
+	}
+	if result.Sent == 0 {
+		response := newErrorResponse(c, CodeSendFailed, "Failed to send CAN message on any interface", nil)
+		response.Data = result // Per-interface errors and codes
+		h.render(c, http.StatusInternalServerError, response)
+		return
+	}
+
+	h.respondSuccess(c, fmt.Sprintf("CAN message sent on %d of %d interfaces (spread %s)",
+		result.Sent, len(req.Interfaces), result.Spread), result)
+}
+
+// handleSendUntil sends a frame periodically until a matching frame is received or the
+// timeout elapses, answering with the number of sends and the matching frame
+func (h *APIHandler) handleSendUntil(c *gin.Context) {
+	var req SendUntilRequest
+	req.ctx = c.Request.Context()
+	req.trace, req.requestID = requestSpanContext(c), requestID(c)
+	req.client, req.remoteAddr = requestClient(c), c.ClientIP()
+	if !h.bindRequest(c, &req, "Invalid send-until request") {
+		return
+	}
+	if err := req.decodeDataHex(); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid send-until request", err)
+		return
+	}
+	if err := h.messageSender.ValidateMessage(req.CanMessage); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Message validation failed", err)
+		return
+	}
+
+	// The frame goes to one interface, which the condition watches unless it names another
+	ifName, err := h.messageSender.resolveInterface(req.Interface)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "Message validation failed", err)
+		return
+	}
+	req.Interface = ifName
+	if req.Until.Interface == "" {
+		req.Until.Interface = ifName
+	}
+	req.Until.Interface = h.interfaceName(req.Until.Interface)
+	if h.messageListener == nil || !h.messageListener.IsListening(req.Until.Interface) {
+		h.respondError(c, http.StatusConflict, fmt.Sprintf("Not listening on %s, so the stop condition cannot be observed", req.Until.Interface), nil)
+		return
+	}
+	if req.matchesOwnFrame() {
+		h.respondError(c, http.StatusBadRequest, "Invalid send-until request", tagError(ErrValidation,
+			fmt.Errorf("the stop condition matches the sent frame, whose loopback copy is received too: use another ID or dataMatch")))
+		return
+	}
+
+	// A run may outlast the server's write timeout, which would drop the response
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(req.timeout + req.interval + sendUntilWriteMargin))
+
+	result, err := h.messageSender.SendUntil(c.Request.Context(), req, h.monitor)
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Send-until failed", err)
+		return
+	}
+
+	if result.Matched {
+		h.respondSuccess(c, fmt.Sprintf("Stop condition met after %d sends", result.Sends), result)
+		return
+	}
+	h.respondSuccess(c, fmt.Sprintf("Stop condition not met after %d sends (%s)", result.Sends, result.StoppedBy), result)
+}
+
+// handleTxBenchmark sends a frame as fast as the interface takes it for a duration,
+// answering with the achieved rate and the latency of the write calls
+func (h *APIHandler) handleTxBenchmark(c *gin.Context) {
+	var req TxBenchmarkRequest
+	req.trace, req.requestID = requestSpanContext(c), requestID(c)
+	req.client, req.remoteAddr = requestClient(c), c.ClientIP()
+	if !h.bindRequest(c, &req, "Invalid TX benchmark request") {
+		return
+	}
+	if err := req.decodeDataHex(); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid TX benchmark request", err)
+		return
+	}
+	if err := h.messageSender.ValidateMessage(req.CanMessage); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Message validation failed", err)
+		return
+	}
+
+	// A run may outlast the server's write timeout, which would drop the response
+	http.NewResponseController(c.Writer).SetWriteDeadline(time.Now().Add(req.duration + txBenchmarkWriteMargin))
+
+	result, err := h.messageSender.TxBenchmark(c.Request.Context(), req)
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "TX benchmark failed", err)
+		return
+	}
+	h.respondSuccess(c, fmt.Sprintf("Sent %d frames in %s (%.0f frames/s)", result.Sent, result.Elapsed, result.Rate), result)
+}
+
+// handleGetTasks lists the running transmit tasks
+func (h *APIHandler) handleGetTasks(c *gin.Context) {
+	tasks := h.messageSender.Tasks().List()
+	h.respondSuccess(c, fmt.Sprintf("%d transmit tasks running", len(tasks)), map[string]interface{}{
+		"tasks": tasks,
+		"count": len(tasks),
+	})
+}
+
+// handleCancelTasks stops every running transmit task at once
+func (h *APIHandler) handleCancelTasks(c *gin.Context) {
+	cancelled := h.messageSender.Tasks().Cancel()
+	if len(cancelled) > 0 {
+		h.logger.Infof("🛑 Cancelled %d transmit tasks%s", len(cancelled), requestIDSuffix(requestID(c)))
+	}
+	h.respondSuccess(c, fmt.Sprintf("Cancelled %d transmit tasks", len(cancelled)), map[string]interface{}{
+		"cancelled": cancelled,
+		"count":     len(cancelled),
+	})
+}
+
+// handleSendSignal encodes signal values into a DBC message and sends it
+func (h *APIHandler) handleSendSignal(c *gin.Context) {
+	var req SignalSendRequest
+	if !h.bindRequest(c, &req, "Invalid signal send request") {
+		return
+	}
+
+	message, data, err := h.dbc.EncodeMessage(req.Message, req.Signals)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "Signal encoding failed", err)
+		return
+	}
+
+	msg := CanMessage{
+		Interface:  req.Interface,
+		ID:         message.ID,
+		Data:       data,
+		DryRun:     req.DryRun,
+		Priority:   req.Priority,
+		ctx:        c.Request.Context(),
+		acceptedAt: time.Now(),
+		trace:      requestSpanContext(c),
+		requestID:  requestID(c),
+		client:     requestClient(c),
+		remoteAddr: c.ClientIP(),
+	}
+	if err := h.messageSender.ValidateMessage(msg); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Message validation failed", err)
+		return
+	}
+
+	result, err := h.messageSender.SendCanMessage(msg)
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to send CAN message", err)
+		return
+	}
+
+	if result.DryRun {
+		h.respondSuccess(c, fmt.Sprintf("Dry run: %s encoded but not sent", message.Name), result)
+		return
+	}
+
+	h.respondSuccess(c, fmt.Sprintf("%s sent successfully", message.Name), result)
+}
+
+// handleGetNamedMessages lists the named messages by name
+func (h *APIHandler) handleGetNamedMessages(c *gin.Context) {
+	messages := make([]*NamedMessage, 0, len(h.namedMessages))
+	for _, message := range h.namedMessages {
+		messages = append(messages, message)
+	}
+	sort.Slice(messages, func(i, j int) bool { return messages[i].Name < messages[j].Name })
+	h.respondSuccess(c, "", messages)
+}
+
+// handleSendNamedMessage sends a named message. The body is optional.
+func (h *APIHandler) handleSendNamedMessage(c *gin.Context) {
+	name := c.Param("name")
+	definition, ok := h.namedMessages[name]
+	if !ok {
+		h.respondError(c, http.StatusNotFound, "Unknown message", fmt.Errorf("message %q: %w", name, ErrNamedMessageNotFound))
+		return
+	}
+
+	var req NamedSendRequest
+	if c.Request.ContentLength != 0 && !h.bindRequest(c, &req, "Invalid named message request") {
+		return
+	}
+
+	msg, err := definition.message(req.Bytes)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "Message validation failed", err)
+		return
+	}
+	msg.DryRun = req.DryRun
+	msg.Priority = req.Priority
+	msg.ctx = c.Request.Context()
+	msg.acceptedAt = time.Now()
+	msg.trace = requestSpanContext(c)
+	msg.requestID = requestID(c)
+	msg.client = requestClient(c)
+	msg.remoteAddr = c.ClientIP()
+	if err := h.messageSender.ValidateMessage(msg); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Message validation failed", err)
+		return
+	}
+
+	result, err := h.messageSender.SendCanMessage(msg)
+	if err != nil {
 		h.respondError(c, http.StatusInternalServerError, "Failed to send CAN message", err)
 		return
 	}
 
-	h.respondSuccess(c, "CAN message sent successfully", req)
+	if result.DryRun {
+		h.respondSuccess(c, fmt.Sprintf("Dry run: %s not sent", name), result)
+		return
+	}
+	h.respondSuccess(c, fmt.Sprintf("%s sent successfully", name), result)
 }
 
 // handleSystemStatus returns complete system status
 func (h *APIHandler) handleSystemStatus(c *gin.Context) {
 	status := h.monitor.GetSystemStatus()
+	status.APIVersion = requestAPIVersion(c).name
 	h.respondSuccess(c, "", status)
 }
 
@@ -147,105 +708,650 @@ func (h *APIHandler) handleSystemStatus(c *gin.Context) {
 func (h *APIHandler) handleInterfacesList(c *gin.Context) {
 	status := h.monitor.GetSystemStatus()
 
-	data := map[string]interface{}{
-		"configuredPorts": status.ConfiguredPorts,
-		"activePorts": func() []string {
-			var active []string
-			for name, ifStatus := range status.Interfaces {
-				if ifStatus.Active {
-					active = append(active, name)
-				}
-			}
-			return active
-		}(),
-		"totalInterfaces": len(status.Interfaces),
-		"activeCount":     status.ActiveInterfaces,
+	data := InterfaceList{
+		SchemaVersion:   StatusSchemaVersion,
+		ConfiguredPorts: status.ConfiguredPorts,
+		TotalInterfaces: len(status.Interfaces),
+		ActiveCount:     status.ActiveInterfaces,
+	}
+	for name, ifStatus := range status.Interfaces {
+		if ifStatus.Active {
+			data.ActivePorts = append(data.ActivePorts, name)
+		}
 	}
 
 	// Add listening status if message listener is available
 	if h.messageListener != nil {
-		data["listeningInterfaces"] = h.messageListener.GetListeningInterfaces()
+		data.ListeningInterfaces = h.messageListener.GetListeningInterfaces()
+	}
+
+	h.respondSuccess(c, "", data)
+}
+
+// handleInterfaceLoad returns the bus load of a specific interface
+func (h *APIHandler) handleInterfaceLoad(c *gin.Context) {
+	load, err := h.monitor.GetBusLoad(h.interfaceName(c.Param("name")))
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Interface not found", err)
+		return
+	}
+
+	h.respondSuccess(c, "", load)
+}
+
+// handleInterfaceStatus returns status for a specific interface
+func (h *APIHandler) handleInterfaceStatus(c *gin.Context) {
+	ifName := h.interfaceName(c.Param("name"))
+	if ifName == "" {
+		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+		return
+	}
+
+	status, err := h.monitor.GetInterfaceStatus(ifName)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Interface not found", err)
+		return
+	}
+
+	// Add listening status if message listener is available
+	if h.messageListener != nil {
+		detail := InterfaceDetail{
+			SchemaVersion:   StatusSchemaVersion,
+			InterfaceStatus: status,
+			IsListening:     h.messageListener.IsListening(ifName),
+		}
+
+		// Add message statistics if available
+		if stats, err := h.messageListener.GetInterfaceStatistics(ifName); err == nil {
+			detail.MessageStatistics = &stats
+		}
+
+		h.respondSuccess(c, "", detail)
+	} else {
+		h.respondSuccess(c, "", status)
+	}
+}
+
+// TxEnableRequest enables or disables transmission on an interface
+type TxEnableRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// handleSetTxEnabled enables or disables transmission on an interface without
+// reconfiguring it; receiving continues either way
+func (h *APIHandler) handleSetTxEnabled(c *gin.Context) {
+	ifName := h.interfaceName(c.Param("name"))
+
+	var req TxEnableRequest
+	if !h.bindRequest(c, &req, "Invalid transmission request") {
+		return
+	}
+
+	changed, err := h.messageSender.SetTxEnabled(ifName, *req.Enabled)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Failed to change transmission state", err)
+		return
+	}
+
+	state := "enabled"
+	if !*req.Enabled {
+		state = "disabled"
+	}
+	if changed {
+		h.logger.Infof("📴 Transmission on %s %s%s", ifName, state, requestIDSuffix(requestID(c)))
+	}
+
+	h.respondSuccess(c, fmt.Sprintf("Transmission on %s %s", ifName, state), map[string]interface{}{
+		"interface": ifName,
+		"txEnabled": *req.Enabled,
+		"changed":   changed,
+	})
+}
+
+// handleHealthSummary returns system health summary
+func (h *APIHandler) handleHealthSummary(c *gin.Context) {
+	summary := h.monitor.GetHealthSummary()
+	h.respondSuccess(c, "", summary)
+}
+
+// handleMetrics returns detailed metrics for monitoring systems
+func (h *APIHandler) handleMetrics(c *gin.Context) {
+	status := h.monitor.GetSystemStatus()
+
+	// Transform to metrics format
+	metrics := map[string]interface{}{
+		"system": map[string]interface{}{
+			"uptime_seconds":        status.SystemUptime.Seconds(),
+			"active_interfaces":     status.ActiveInterfaces,
+			"configured_interfaces": len(status.ConfiguredPorts),
+			"watchdog_enabled":      status.WatchdogStatus.Running,
+		},
+		"interfaces": make(map[string]interface{}),
+	}
+
+	// Add per-interface metrics
+	interfaceMetrics := make(map[string]interface{})
+	for name, ifStatus := range status.Interfaces {
+		interfaceMetrics[name] = map[string]interface{}{
+			"active":               ifStatus.Active,
+			"total_sent":           ifStatus.TotalSent,
+			"total_errors":         ifStatus.TotalErrors,
+			"confirmed_sends":      ifStatus.ConfirmedSends,
+			"unconfirmed_sends":    ifStatus.UnconfirmedSends,
+			"send_latency":         ifStatus.SendLatency,
+			"tx_queue_depth":       ifStatus.TxQueueDepth,
+			"max_tx_queue_depth":   ifStatus.MaxTxQueueDepth,
+			"tx_queue_bands":       ifStatus.TxQueueBands,
+			"buffer_full_errors":   ifStatus.BufferFullErrors,
+			"send_retries":         ifStatus.SendRetries,
+			"retries_exhausted":    ifStatus.RetriesExhausted,
+			"retry_time":           ifStatus.RetryTime,
+			"paced_sends":          ifStatus.PacedSends,
+			"paced_rate":           ifStatus.PacedRate,
+			"kernel":               ifStatus.KernelStats,
+			"success_rate":         parseSuccessRate(ifStatus.SuccessRate),
+			"health_status":        ifStatus.Health.Status,
+			"health_checks_passed": ifStatus.Health.ChecksPassed,
+			"health_checks_failed": ifStatus.Health.ChecksFailed,
+		}
+
+		// Add message listening metrics if available
+		if h.messageListener != nil {
+			if stats, err := h.messageListener.GetInterfaceStatistics(name); err == nil {
+				interfaceMetrics[name].(map[string]interface{})["message_listening"] = stats
+			}
+		}
+	}
+	metrics["interfaces"] = interfaceMetrics
+	metrics["notifications"] = h.monitor.GetNotificationStats()
+	metrics["sendAudit"] = h.messageSender.GetAuditStats()
+	metrics["idempotency"] = h.idempotency.GetStats()
+	metrics["ipc"] = h.ipc.GetStats()
+	if h.statsd != nil {
+		metrics["statsd"] = h.statsd.GetStats()
+	}
+	if h.logFile != nil {
+		metrics["logFile"] = h.logFile.GetStats()
+	}
+	metrics["audit"] = h.audit.GetStats()
+	metrics["logSampling"] = h.logSampler.GetStats()
+	metrics["logOutput"] = h.logOutput.GetStats()
+
+	h.respondSuccess(c, "", metrics)
+}
+
+// handlePrometheusMetrics serves send metrics in the Prometheus text format
+func (h *APIHandler) handlePrometheusMetrics(c *gin.Context) {
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	writePrometheusMetrics(c.Writer, h.monitor.GetSendStats())
+	writePrometheusErrorMetrics(c.Writer, h.monitor.GetAllErrorStats())
+	writePrometheusKernelMetrics(c.Writer, h.monitor.GetKernelStats())
+	if h.messageListener != nil {
+		writePrometheusSocketDropMetrics(c.Writer, h.messageListener.GetStatistics())
+	}
+	writePrometheusRateMetrics(c.Writer, h.monitor.GetInterfaceRates())
+	if h.httpMetrics != nil {
+		writePrometheusHTTPMetrics(c.Writer, h.httpMetrics.Snapshot())
+	}
+	if h.idempotency != nil {
+		writePrometheusIdempotencyMetrics(c.Writer, h.idempotency.GetStats())
+	}
+	writePrometheusLogDiskMetrics(c.Writer, h.logDiskUsage())
+	writePrometheusLogSamplingMetrics(c.Writer, h.logSampler.GetStats())
+}
+
+// logDiskUsage returns the bytes taken by the service log file and the capture files,
+// for those enabled
+func (h *APIHandler) logDiskUsage() map[string]int64 {
+	usage := make(map[string]int64)
+	if h.logFile != nil {
+		usage["service"] = h.logFile.DiskUsage()
+	}
+	if h.frameCapture != nil {
+		usage["capture"] = h.frameCapture.DiskUsage()
+	}
+	if stats := h.audit.GetStats(); stats.File != nil {
+		usage["audit"] = stats.File.DiskUsage
+	}
+	return usage
+}
+
+// handleGetIDStats returns per-ID traffic statistics sorted by frame rate
+func (h *APIHandler) handleGetIDStats(c *gin.Context) {
+	ifName := h.interfaceName(c.Param("interface"))
+
+	top := 0
+	if topStr := c.Query("top"); topStr != "" {
+		parsed, err := strconv.Atoi(topStr)
+		if err != nil || parsed <= 0 {
+			h.respondError(c, http.StatusBadRequest, "Invalid top parameter", fmt.Errorf("top must be a positive integer"))
+			return
+		}
+		top = parsed
+	}
+
+	stats, err := h.monitor.GetIDStats(ifName, top)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Failed to get ID statistics", err)
+		return
+	}
+
+	h.respondSuccess(c, "", stats)
+}
+
+// handleGetIDWindowStats returns per-ID rates and inter-frame gaps over a rolling window
+func (h *APIHandler) handleGetIDWindowStats(c *gin.Context) {
+	ifName := h.interfaceName(c.Query("interface"))
+	if ifName == "" {
+		h.respondError(c, http.StatusBadRequest, "Missing interface parameter", fmt.Errorf("interface query parameter is required"))
+		return
+	}
+
+	window := DefaultIDStatsWindow
+	if windowStr := c.Query("window"); windowStr != "" {
+		parsed, err := time.ParseDuration(windowStr)
+		if err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid window parameter", err)
+			return
+		}
+		window = parsed
+	}
+	if window < time.Second || window > MaxIDStatsWindow {
+		h.respondError(c, http.StatusBadRequest, "Invalid window parameter",
+			fmt.Errorf("window must be between 1s and %v", MaxIDStatsWindow))
+		return
+	}
+
+	stats, err := h.monitor.GetIDWindowStats(ifName, window)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Failed to get ID statistics", err)
+		return
+	}
+
+	h.respondSuccess(c, "", stats)
+}
+
+// handleGetErrorStats returns error frame statistics by class and location
+func (h *APIHandler) handleGetErrorStats(c *gin.Context) {
+	ifName := h.interfaceName(c.Param("interface"))
+
+	stats, err := h.monitor.GetErrorStats(ifName)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Failed to get error statistics", err)
+		return
+	}
+
+	h.respondSuccess(c, "", stats)
+}
+
+// handleGetSocketStats returns the kernel counters of an interface next to what the
+// listener read from it, and the frames dropped on the listening socket
+func (h *APIHandler) handleGetSocketStats(c *gin.Context) {
+	ifName := h.interfaceName(c.Param("interface"))
+	if !h.messageSender.configProvider.ValidateInterface(ifName) {
+		h.respondError(c, http.StatusNotFound, "Failed to get kernel statistics",
+			tagError(ErrInterfaceNotFound, fmt.Errorf("CAN interface %s is not configured", ifName)))
+		return
+	}
+
+	var listener MessageBufferStats
+	var listening bool
+	if h.messageListener != nil {
+		listener, _ = h.messageListener.GetInterfaceStatistics(ifName)
+		listening = h.messageListener.IsListening(ifName)
+	}
+	h.respondSuccess(c, "", newSocketStatsReport(ifName, h.monitor.GetInterfaceKernelStats(ifName), listener, listening))
+}
+
+// handleGetSimulatedNodes returns the counters of every simulated node
+func (h *APIHandler) handleGetSimulatedNodes(c *gin.Context) {
+	h.respondSuccess(c, "", h.simulator.GetStats())
+}
+
+// handleGetAlerts lists alert rules with their current state
+func (h *APIHandler) handleGetAlerts(c *gin.Context) {
+	data := map[string]interface{}{
+		"alerts": h.monitor.GetAlerts(),
+		"active": h.monitor.GetActiveAlerts(),
+	}
+	h.respondSuccess(c, "", data)
+}
+
+// handleTestAlertRule evaluates a rule once against current data
+func (h *APIHandler) handleTestAlertRule(c *gin.Context) {
+	var rule AlertRule
+	if err := c.ShouldBindJSON(&rule); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid alert rule", err)
+		return
+	}
+
+	result, err := h.monitor.TestAlertRule(rule)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "Failed to test alert rule", err)
+		return
+	}
+
+	h.respondSuccess(c, "", result)
+}
+
+// handleGetFrameTriggers lists the frame triggers with their counters and last change
+func (h *APIHandler) handleGetFrameTriggers(c *gin.Context) {
+	h.respondSuccess(c, "", map[string]interface{}{
+		"triggers": h.monitor.GetFrameTriggers(),
+	})
+}
+
+// handleAddFrameTrigger registers a trigger that notifies when a frame's payload changes
+func (h *APIHandler) handleAddFrameTrigger(c *gin.Context) {
+	var trigger FrameTrigger
+	if !h.bindRequest(c, &trigger, "Invalid trigger") {
+		return
+	}
+	trigger.Interface = h.interfaceName(trigger.Interface)
+
+	trigger, err := h.monitor.AddFrameTrigger(trigger)
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "Failed to add trigger", err)
+		return
+	}
+
+	h.logger.Infof("🎯 Trigger %s added: %s%s", trigger.Name, trigger.describe(), requestIDSuffix(requestID(c)))
+	h.respondSuccess(c, fmt.Sprintf("Trigger %s added", trigger.Name), trigger)
+}
+
+// handleRemoveFrameTrigger removes a frame trigger
+func (h *APIHandler) handleRemoveFrameTrigger(c *gin.Context) {
+	name := c.Param("name")
+
+	if err := h.monitor.RemoveFrameTrigger(name); err != nil {
+		h.respondError(c, http.StatusNotFound, "Failed to remove trigger", err)
+		return
+	}
+
+	h.logger.Infof("🎯 Trigger %s removed%s", name, requestIDSuffix(requestID(c)))
+	h.respondSuccess(c, fmt.Sprintf("Trigger %s removed", name), nil)
+}
+
+// handleGetJ1939 returns the J1939 interfaces with their socket state and counters
+func (h *APIHandler) handleGetJ1939(c *gin.Context) {
+	h.respondSuccess(c, "J1939 interfaces retrieved", map[string]interface{}{
+		"interfaces": h.j1939.Status(),
+	})
+}
+
+// handleGetJ1939Messages returns the last received J1939 messages of an interface,
+// optionally filtered by PGN, source and destination address
+func (h *APIHandler) handleGetJ1939Messages(c *gin.Context) {
+	ifName := h.interfaceName(c.Param("interface"))
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", "10"))
+	if err != nil || count <= 0 {
+		count = 10
+	}
+	filter, err := ParseJ1939Filter(c.Query("pgn"), c.Query("source"), c.Query("destination"))
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid J1939 filter", err)
+		return
+	}
+
+	messages, err := h.j1939.Messages(ifName, filter, count)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Failed to get J1939 messages", err)
+		return
+	}
+	h.respondSuccess(c, "", map[string]interface{}{
+		"interface":      ifName,
+		"messages":       messages,
+		"requestedCount": count,
+		"actualCount":    len(messages),
+	})
+}
+
+// handleSendJ1939 sends a J1939 parameter group, segmented by the kernel when it
+// exceeds one frame
+func (h *APIHandler) handleSendJ1939(c *gin.Context) {
+	ifName := h.interfaceName(c.Param("interface"))
+
+	var req J1939SendRequest
+	if !h.bindRequest(c, &req, "Invalid J1939 send request") {
+		return
+	}
+
+	result, err := h.j1939.Send(ifName, req)
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "J1939 send failed", err)
+		return
+	}
+	result.RequestID = requestID(c)
+
+	message := fmt.Sprintf("PGN 0x%05X sent on %s", result.PGN, ifName)
+	if result.DryRun {
+		message = fmt.Sprintf("PGN 0x%05X validated (dry run)", result.PGN)
+	}
+	h.respondSuccess(c, message, result)
+}
+
+// handleGetCapture returns the captured interfaces and the capture counters
+func (h *APIHandler) handleGetCapture(c *gin.Context) {
+	h.respondSuccess(c, "Frame capture retrieved", h.frameCapture.Status())
+}
+
+// handleStartCapture starts writing the frames of an interface to a candump log file
+func (h *APIHandler) handleStartCapture(c *gin.Context) {
+	ifName := h.interfaceName(c.Param("interface"))
+
+	started, err := h.frameCapture.Start(ifName)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Failed to start capture", err)
+		return
+	}
+
+	message := fmt.Sprintf("Capturing frames of %s", ifName)
+	if !started {
+		message = fmt.Sprintf("Frames of %s are already captured", ifName)
+	}
+	h.respondSuccess(c, message, map[string]interface{}{
+		"interface": ifName,
+		"capturing": true,
+		"changed":   started,
+	})
+}
+
+// handleStopCapture stops the capture of an interface and closes its file
+func (h *APIHandler) handleStopCapture(c *gin.Context) {
+	ifName := h.interfaceName(c.Param("interface"))
+
+	stopped, err := h.frameCapture.Stop(ifName)
+	if err != nil {
+		h.respondError(c, http.StatusNotFound, "Failed to stop capture", err)
+		return
+	}
+
+	message := fmt.Sprintf("Stopped capturing frames of %s", ifName)
+	if !stopped {
+		message = fmt.Sprintf("Frames of %s are not captured", ifName)
+	}
+	h.respondSuccess(c, message, map[string]interface{}{
+		"interface": ifName,
+		"capturing": false,
+		"changed":   stopped,
+	})
+}
+
+// handleResetIDStats clears per-ID traffic statistics to start a new measurement window
+func (h *APIHandler) handleResetIDStats(c *gin.Context) {
+	ifName := h.interfaceName(c.Param("interface"))
+
+	if err := h.monitor.ResetIDStats(ifName); err != nil {
+		h.respondError(c, http.StatusNotFound, "Failed to reset ID statistics", err)
+		return
 	}
 
-	h.respondSuccess(c, "", data)
+	h.respondSuccess(c, fmt.Sprintf("ID statistics for %s reset", ifName), nil)
+}
+
+// handleWatchdogRetry forces an immediate recovery attempt for an interface
+func (h *APIHandler) handleWatchdogRetry(c *gin.Context) {
+	ifName := h.interfaceName(c.Param("name"))
+	if ifName == "" {
+		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+		return
+	}
+
+	if err := h.monitor.ForceRecovery(ifName, requestID(c)); err != nil {
+		h.respondError(c, http.StatusConflict, "Failed to force recovery", err)
+		return
+	}
+
+	data := map[string]interface{}{
+		"interface": ifName,
+		"status":    "retry_scheduled",
+	}
+
+	h.respondSuccess(c, fmt.Sprintf("Recovery retry scheduled for %s", ifName), data)
+}
+
+// handleWatchdogEvents returns watchdog events filtered by interface and time range
+func (h *APIHandler) handleWatchdogEvents(c *gin.Context) {
+	filter := WatchdogEventFilter{
+		Interface: h.interfaceName(c.Query("interface")),
+	}
+
+	var err error
+	if filter.Since, err = parseTimeQuery(c.Query("since")); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid since parameter", err)
+		return
+	}
+	if filter.Until, err = parseTimeQuery(c.Query("until")); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid until parameter", err)
+		return
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if filter.Limit, err = strconv.Atoi(limitStr); err != nil || filter.Limit < 0 {
+			h.respondError(c, http.StatusBadRequest, "Invalid limit parameter", err)
+			return
+		}
+	}
+
+	events := h.monitor.GetWatchdogEvents(filter)
+
+	data := map[string]interface{}{
+		"events": events,
+		"count":  len(events),
+	}
+
+	h.respondSuccess(c, "", data)
+}
+
+// handleGetAudit returns control-plane operations filtered by principal, outcome and time
+func (h *APIHandler) handleGetAudit(c *gin.Context) {
+	filter := AuditFilter{
+		Principal: c.Query("principal"),
+		Outcome:   c.Query("outcome"),
+	}
+	switch filter.Outcome {
+	case "", AuditSuccess, AuditFailure, AuditDenied:
+	default:
+		h.respondError(c, http.StatusBadRequest, "Invalid outcome parameter",
+			fmt.Errorf("expected %s, %s or %s, got %q", AuditSuccess, AuditFailure, AuditDenied, filter.Outcome))
+		return
+	}
+
+	var err error
+	if filter.Since, err = parseTimeQuery(c.Query("since")); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid since parameter", err)
+		return
+	}
+	if filter.Until, err = parseTimeQuery(c.Query("until")); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid until parameter", err)
+		return
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if filter.Limit, err = strconv.Atoi(limitStr); err != nil || filter.Limit < 0 {
+			h.respondError(c, http.StatusBadRequest, "Invalid limit parameter", err)
+			return
+		}
+	}
+
+	records := h.audit.Query(filter)
+	h.respondSuccess(c, "", map[string]interface{}{
+		"records": records,
+		"count":   len(records),
+	})
+}
+
+// WatchdogPauseRequest represents a watchdog pause request
+type WatchdogPauseRequest struct {
+	Interface string `json:"interface,omitempty"` // If empty, pause the whole watchdog
+	Timeout   string `json:"timeout,omitempty"`   // Auto-resume timeout, e.g. "15m"
+	Reason    string `json:"reason,omitempty"`
 }
 
-// handleInterfaceStatus returns status for a specific interface
-func (h *APIHandler) handleInterfaceStatus(c *gin.Context) {
-	ifName := c.Param("name")
-	if ifName == "" {
-		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
-		return
+// handleWatchdogPause pauses watchdog recovery actions
+func (h *APIHandler) handleWatchdogPause(c *gin.Context) {
+	var req WatchdogPauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		// Allow empty body - pause everything with the default timeout
+		req = WatchdogPauseRequest{}
 	}
+	req.Interface = h.interfaceName(req.Interface)
 
-	status, err := h.monitor.GetInterfaceStatus(ifName)
+	var timeout time.Duration
+	if req.Timeout != "" {
+		var err error
+		if timeout, err = time.ParseDuration(req.Timeout); err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid timeout", err)
+			return
+		}
+	}
+
+	pause, err := h.monitor.PauseWatchdog(req.Interface, timeout, req.Reason, requestID(c))
 	if err != nil {
-		h.respondError(c, http.StatusNotFound, "Interface not found", err)
+		h.respondError(c, http.StatusBadRequest, "Failed to pause watchdog", err)
 		return
 	}
 
-	// Add listening status if message listener is available
-	if h.messageListener != nil {
-		statusMap := map[string]interface{}{
-			"interfaceStatus": status,
-			"isListening":     h.messageListener.IsListening(ifName),
-		}
-
-		// Add message statistics if available
-		if stats, err := h.messageListener.GetInterfaceStatistics(ifName); err == nil {
-			statusMap["messageStatistics"] = stats
-		}
+	target := req.Interface
+	if target == "" {
+		target = allInterfaces
+	}
 
-		h.respondSuccess(c, "", statusMap)
-	} else {
-		h.respondSuccess(c, "", status)
+	data := map[string]interface{}{
+		"interface":    target,
+		"status":       "paused",
+		"since":        pause.Since,
+		"autoResumeAt": pause.AutoResumeAt,
 	}
-}
 
-// handleHealthSummary returns system health summary
-func (h *APIHandler) handleHealthSummary(c *gin.Context) {
-	summary := h.monitor.GetHealthSummary()
-	h.respondSuccess(c, "", summary)
+	h.respondSuccess(c, fmt.Sprintf("Watchdog paused for %s", target), data)
 }
 
-// handleMetrics returns detailed metrics for monitoring systems
-func (h *APIHandler) handleMetrics(c *gin.Context) {
-	status := h.monitor.GetSystemStatus()
+// handleWatchdogResume resumes watchdog recovery actions
+func (h *APIHandler) handleWatchdogResume(c *gin.Context) {
+	var req WatchdogPauseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		// Allow empty body - resume everything
+		req = WatchdogPauseRequest{}
+	}
+	req.Interface = h.interfaceName(req.Interface)
 
-	// Transform to metrics format
-	metrics := map[string]interface{}{
-		"system": map[string]interface{}{
-			"uptime_seconds":        status.SystemUptime.Seconds(),
-			"active_interfaces":     status.ActiveInterfaces,
-			"configured_interfaces": len(status.ConfiguredPorts),
-			"watchdog_enabled":      status.WatchdogStatus.Running,
-		},
-		"interfaces": make(map[string]interface{}),
+	if err := h.monitor.ResumeWatchdog(req.Interface, requestID(c)); err != nil {
+		h.respondError(c, http.StatusConflict, "Failed to resume watchdog", err)
+		return
 	}
 
-	// Add per-interface metrics
-	interfaceMetrics := make(map[string]interface{})
-	for name, ifStatus := range status.Interfaces {
-		interfaceMetrics[name] = map[string]interface{}{
-			"active":               ifStatus.Active,
-			"total_sent":           ifStatus.TotalSent,
-			"total_errors":         ifStatus.TotalErrors,
-			"success_rate":         parseSuccessRate(ifStatus.SuccessRate),
-			"health_status":        ifStatus.Health.Status,
-			"health_checks_passed": ifStatus.Health.ChecksPassed,
-			"health_checks_failed": ifStatus.Health.ChecksFailed,
-		}
+	target := req.Interface
+	if target == "" {
+		target = allInterfaces
+	}
 
-		// Add message listening metrics if available
-		if h.messageListener != nil {
-			if stats, err := h.messageListener.GetInterfaceStatistics(name); err == nil {
-				interfaceMetrics[name].(map[string]interface{})["message_listening"] = stats
-			}
-		}
+	data := map[string]interface{}{
+		"interface": target,
+		"status":    "resumed",
 	}
-	metrics["interfaces"] = interfaceMetrics
 
-	h.respondSuccess(c, "", metrics)
+	h.respondSuccess(c, fmt.Sprintf("Watchdog resumed for %s", target), data)
 }
 
 // ====== Interface Setup Handlers (Existing) ======
@@ -339,10 +1445,49 @@ func (h *APIHandler) handleGetAvailableInterfaces(c *gin.Context) {
 
 // SetupInterfaceRequest represents an interface setup request
 type SetupInterfaceRequest struct {
-	Bitrate     *int    `json:"bitrate,omitempty"`
-	SamplePoint *string `json:"samplePoint,omitempty"`
-	RestartMs   *int    `json:"restartMs,omitempty"`
-	WithRetry   *bool   `json:"withRetry,omitempty"`
+	Bitrate        *int    `json:"bitrate,omitempty"`
+	SamplePoint    *string `json:"samplePoint,omitempty"`
+	TripleSampling *bool   `json:"tripleSampling,omitempty"`
+	OneShot        *bool   `json:"oneShot,omitempty"`
+	RestartMs      *int    `json:"restartMs,omitempty"`
+	WithRetry      *bool   `json:"withRetry,omitempty"`
+}
+
+// handleGetSetupStatus reports the last setup and current state of every configured interface
+func (h *APIHandler) handleGetSetupStatus(c *gin.Context) {
+	if h.setupManager == nil {
+		h.respondError(c, http.StatusServiceUnavailable, "Setup manager not available", nil)
+		return
+	}
+
+	interfaces := h.monitor.GetSystemStatus().ConfiguredPorts
+	reports := make([]SetupReport, 0, len(interfaces))
+	succeeded, failed := 0, 0
+	for _, ifName := range interfaces {
+		report := SetupReport{Interface: ifName}
+		if result, ok := h.setupManager.GetSetupResult(ifName); ok {
+			report.Setup = &result
+			if result.Succeeded {
+				succeeded++
+			} else {
+				failed++
+			}
+		}
+		if state, err := h.setupManager.GetInterfaceState(ifName); err == nil {
+			report.State = state
+		} else {
+			report.StateError = err.Error()
+		}
+		reports = append(reports, report)
+	}
+
+	h.respondSuccess(c, fmt.Sprintf("%d of %d interfaces set up", succeeded, len(interfaces)), map[string]interface{}{
+		"interfaces":     reports,
+		"totalCount":     len(interfaces),
+		"succeededCount": succeeded,
+		"failedCount":    failed,
+		"notSetUpCount":  len(interfaces) - succeeded - failed,
+	})
 }
 
 // handleSetupInterface sets up a specific CAN interface
@@ -352,7 +1497,7 @@ func (h *APIHandler) handleSetupInterface(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("name")
+	ifName := h.interfaceName(c.Param("name"))
 	if ifName == "" {
 		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
 		return
@@ -364,35 +1509,31 @@ func (h *APIHandler) handleSetupInterface(c *gin.Context) {
 		req = SetupInterfaceRequest{}
 	}
 
-	// If custom parameters provided, temporarily update config
-	originalConfig := h.setupManager.GetSetupConfig()
-	if req.Bitrate != nil || req.SamplePoint != nil || req.RestartMs != nil {
-		tempConfig := originalConfig
-		if req.Bitrate != nil {
-			tempConfig.Bitrate = *req.Bitrate
-		}
-		if req.SamplePoint != nil {
-			tempConfig.SamplePoint = *req.SamplePoint
-		}
-		if req.RestartMs != nil {
-			tempConfig.RestartMs = *req.RestartMs
-		}
-
-		// Temporarily update config
-		h.setupManager.UpdateSetupConfig(tempConfig)
-		defer h.setupManager.UpdateSetupConfig(originalConfig) // Restore original
+	// Custom parameters apply to this setup only, not to the setup configuration
+	config := h.setupManager.GetSetupConfig()
+	if req.Bitrate != nil {
+		config.InterfaceBitrates = withOverride(config.InterfaceBitrates, ifName, *req.Bitrate)
+	}
+	if req.SamplePoint != nil {
+		config.InterfaceSamplePoints = withOverride(config.InterfaceSamplePoints, ifName, *req.SamplePoint)
+	}
+	if req.TripleSampling != nil {
+		config.InterfaceTripleSampling = withOverride(config.InterfaceTripleSampling, ifName, *req.TripleSampling)
+	}
+	if req.OneShot != nil {
+		config.InterfaceOneShot = withOverride(config.InterfaceOneShot, ifName, *req.OneShot)
+	}
+	if req.RestartMs != nil {
+		config.RestartMs = *req.RestartMs
+	}
+	if err := config.validate(); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid setup parameters", err)
+		return
 	}
 
 	// Setup interface
-	var err error
 	withRetry := req.WithRetry != nil && *req.WithRetry
-	if withRetry {
-		err = h.setupManager.SetupInterfaceWithRetry(ifName)
-	} else {
-		err = h.setupManager.SetupInterface(ifName)
-	}
-
-	if err != nil {
+	if err := h.setupManager.SetupInterfaceWith(ifName, config, withRetry); err != nil {
 		h.respondError(c, http.StatusInternalServerError, "Failed to setup interface", err)
 		return
 	}
@@ -400,20 +1541,190 @@ func (h *APIHandler) handleSetupInterface(c *gin.Context) {
 	// Start listening if message listener is available
 	if h.messageListener != nil {
 		if err := h.messageListener.StartListening(ifName); err != nil {
-			h.logger.Printf("Warning: failed to start listening on %s: %v", ifName, err)
+			h.logger.Warnf("Warning: failed to start listening on %s: %v", ifName, err)
 		}
 	}
 
 	// Get interface state
 	state, err := h.setupManager.GetInterfaceState(ifName)
 	if err != nil {
-		h.logger.Printf("Warning: could not get interface state after setup: %v", err)
+		h.logger.Warnf("Warning: could not get interface state after setup: %v", err)
 		state = &InterfaceState{Name: ifName}
 	}
 
 	h.respondSuccess(c, fmt.Sprintf("Interface %s setup successfully", ifName), state)
 }
 
+// BitrateChangeRequest represents a runtime bitrate change request
+type BitrateChangeRequest struct {
+	Bitrate int `json:"bitrate" binding:"required"`
+}
+
+// handleSetInterfaceBitrate changes the bitrate of an interface at runtime
+func (h *APIHandler) handleSetInterfaceBitrate(c *gin.Context) {
+	if h.setupManager == nil {
+		h.respondError(c, http.StatusServiceUnavailable, "Setup manager not available", nil)
+		return
+	}
+
+	ifName := h.interfaceName(c.Param("name"))
+	if ifName == "" {
+		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
+		return
+	}
+
+	var req BitrateChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid bitrate request", err)
+		return
+	}
+	if !isValidBitrate(req.Bitrate) {
+		h.respondError(c, http.StatusBadRequest, "Invalid bitrate",
+			fmt.Errorf("bitrate %d is not a standard CAN bitrate. Valid options: %v", req.Bitrate, validBitrates))
+		return
+	}
+
+	// The listener socket is reopened along with the send socket
+	wasListening := h.messageListener != nil && h.messageListener.IsListening(ifName)
+	if wasListening {
+		if err := h.messageListener.StopListening(ifName); err != nil {
+			h.logger.Warnf("Warning: failed to stop listening on %s: %v", ifName, err)
+		}
+	}
+
+	err := h.monitor.ReconfigureInterface(ifName, fmt.Sprintf("bitrate change to %d", req.Bitrate), requestID(c), func() error {
+		return h.setupManager.SetInterfaceBitrate(ifName, req.Bitrate)
+	})
+
+	if wasListening {
+		if err := h.messageListener.StartListening(ifName); err != nil {
+			h.logger.Warnf("Warning: failed to restart listening on %s: %v", ifName, err)
+		}
+	}
+
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to change bitrate", err)
+		return
+	}
+
+	state, err := h.setupManager.GetInterfaceState(ifName)
+	if err != nil {
+		h.logger.Warnf("Warning: could not get interface state after bitrate change: %v", err)
+		state = &InterfaceState{Name: ifName, Bitrate: req.Bitrate}
+	}
+
+	h.respondSuccess(c, fmt.Sprintf("Interface %s bitrate changed to %d", ifName, req.Bitrate), state)
+}
+
+// handleGetConfig returns the effective configuration, secrets redacted, with its ETag
+func (h *APIHandler) handleGetConfig(c *gin.Context) {
+	summary, etag := h.configManager.EffectiveConfig()
+	c.Header("ETag", etag)
+	h.respondSuccess(c, "", summary)
+}
+
+// handlePutConfig applies a submitted configuration file, as a reload of the file on disk
+// would, and with persist=true writes it over that file. If-Match must name the ETag of
+// the configuration the change was based on, so concurrent changes do not overwrite each other.
+func (h *APIHandler) handlePutConfig(c *gin.Context) {
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch == "" {
+		h.respondError(c, http.StatusPreconditionRequired,
+			"If-Match header is required: use the ETag of GET /api/v1/config, or * to overwrite any configuration", nil)
+		return
+	}
+	persist, err := strconv.ParseBool(c.DefaultQuery("persist", "false"))
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid persist parameter", err)
+		return
+	}
+
+	body, err := c.GetRawData()
+	if maxBytes, ok := bodyTooLarge(err); ok {
+		h.respondError(c, http.StatusRequestEntityTooLarge, bodyTooLargeMessage(maxBytes), nil)
+		return
+	}
+	if err != nil || len(bytes.TrimSpace(body)) == 0 {
+		h.respondError(c, http.StatusBadRequest, "Request body must be a configuration file in YAML or JSON", err)
+		return
+	}
+
+	h.respondReload(c, ReloadRequest{
+		RequestID:  requestID(c),
+		Client:     requestClient(c),
+		RemoteAddr: c.ClientIP(),
+		File:       body,
+		Persist:    persist,
+		IfMatch:    ifMatch,
+	})
+}
+
+// handleConfigReload reloads the configuration, as SIGHUP does
+func (h *APIHandler) handleConfigReload(c *gin.Context) {
+	h.respondReload(c, ReloadRequest{
+		RequestID:  requestID(c),
+		Client:     requestClient(c),
+		RemoteAddr: c.ClientIP(),
+		IfMatch:    c.GetHeader("If-Match"),
+	})
+}
+
+// handleGetLogging returns the log levels in effect
+func (h *APIHandler) handleGetLogging(c *gin.Context) {
+	h.respondSuccess(c, "", h.logSettings.Status())
+}
+
+// handlePutLogging changes log levels until the next restart, or a reload that changes them
+func (h *APIHandler) handlePutLogging(c *gin.Context) {
+	var req LogLevelsRequest
+	if !h.bindRequest(c, &req, "Invalid logging request") {
+		return
+	}
+	for component := range req.Components {
+		if !validLogComponent(component) {
+			h.respondError(c, http.StatusBadRequest, "Invalid logging request",
+				tagError(ErrValidation, fmt.Errorf("unknown component %q. Valid options: %v", component, logComponents)))
+			return
+		}
+	}
+
+	if req.Access != nil && req.Access.SkipPaths != nil {
+		if err := validateAccessLogPaths(*req.Access.SkipPaths); err != nil {
+			h.respondError(c, http.StatusBadRequest, "Invalid logging request", tagError(ErrValidation, fmt.Errorf("access.skipPaths: %w", err)))
+			return
+		}
+	}
+
+	current := h.logSettings.Status()
+	level, components := current.Level, current.Components
+	if req.Level != nil {
+		level = *req.Level
+	}
+	if req.Components != nil {
+		components = req.Components
+	}
+	h.logSettings.SetLevels(level, components)
+	access := current.Access.apply(req.Access)
+	h.logSettings.SetAccessLog(access)
+
+	h.logger.Infof("🪵 Log levels changed: level=%s, components=%v, access skip=%v sample=%g slow=%dms%s", level, sortedLogComponents(components),
+		access.SkipPaths, access.SampleRate, access.SlowThresholdMs, requestIDSuffix(requestID(c)))
+	h.respondSuccess(c, "Log levels updated", h.logSettings.Status())
+}
+
+// respondReload reloads the configuration and reports what changed, with the new ETag
+func (h *APIHandler) respondReload(c *gin.Context, req ReloadRequest) {
+	result, err := h.configManager.Reload(req)
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Configuration reload failed", err)
+		return
+	}
+
+	c.Header("ETag", result.etag)
+	h.respondSuccess(c, fmt.Sprintf("Configuration reloaded: %d applied, %d skipped, %d rejected",
+		len(result.Applied), len(result.Skipped), len(result.Rejected)), result)
+}
+
 // handleTeardownInterface tears down a specific CAN interface
 func (h *APIHandler) handleTeardownInterface(c *gin.Context) {
 	if h.setupManager == nil {
@@ -421,7 +1732,7 @@ func (h *APIHandler) handleTeardownInterface(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("name")
+	ifName := h.interfaceName(c.Param("name"))
 	if ifName == "" {
 		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
 		return
@@ -430,7 +1741,7 @@ func (h *APIHandler) handleTeardownInterface(c *gin.Context) {
 	// Stop listening if message listener is available
 	if h.messageListener != nil {
 		if err := h.messageListener.StopListening(ifName); err != nil {
-			h.logger.Printf("Warning: failed to stop listening on %s: %v", ifName, err)
+			h.logger.Warnf("Warning: failed to stop listening on %s: %v", ifName, err)
 		}
 	}
 
@@ -454,7 +1765,7 @@ func (h *APIHandler) handleResetInterface(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("name")
+	ifName := h.interfaceName(c.Param("name"))
 	if ifName == "" {
 		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
 		return
@@ -468,7 +1779,7 @@ func (h *APIHandler) handleResetInterface(c *gin.Context) {
 	// Get interface state after reset
 	state, err := h.setupManager.GetInterfaceState(ifName)
 	if err != nil {
-		h.logger.Printf("Warning: could not get interface state after reset: %v", err)
+		h.logger.Warnf("Warning: could not get interface state after reset: %v", err)
 		state = &InterfaceState{Name: ifName}
 	}
 
@@ -482,7 +1793,7 @@ func (h *APIHandler) handleGetInterfaceState(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("name")
+	ifName := h.interfaceName(c.Param("name"))
 	if ifName == "" {
 		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
 		return
@@ -520,7 +1831,9 @@ func (h *APIHandler) handleSetupAllInterfaces(c *gin.Context) {
 	// Get interfaces to setup
 	var interfaces []string
 	if len(req.Interfaces) > 0 {
-		interfaces = req.Interfaces
+		for _, name := range req.Interfaces {
+			interfaces = append(interfaces, h.interfaceName(name))
+		}
 	} else {
 		// Use system status to get configured ports
 		status := h.monitor.GetSystemStatus()
@@ -544,12 +1857,13 @@ func (h *APIHandler) handleSetupAllInterfaces(c *gin.Context) {
 			results[ifName] = map[string]interface{}{
 				"success": false,
 				"error":   err.Error(),
+				"code":    errorCode(err),
 			}
 		} else {
 			// Start listening if message listener is available
 			if h.messageListener != nil {
 				if err := h.messageListener.StartListening(ifName); err != nil {
-					h.logger.Printf("Warning: failed to start listening on %s: %v", ifName, err)
+					h.logger.Warnf("Warning: failed to start listening on %s: %v", ifName, err)
 				}
 			}
 
@@ -601,7 +1915,7 @@ func (h *APIHandler) handleTeardownAllInterfaces(c *gin.Context) {
 		// Stop listening if message listener is available
 		if h.messageListener != nil {
 			if err := h.messageListener.StopListening(ifName); err != nil {
-				h.logger.Printf("Warning: failed to stop listening on %s: %v", ifName, err)
+				h.logger.Warnf("Warning: failed to stop listening on %s: %v", ifName, err)
 			}
 		}
 
@@ -610,6 +1924,7 @@ func (h *APIHandler) handleTeardownAllInterfaces(c *gin.Context) {
 			results[ifName] = map[string]interface{}{
 				"success": false,
 				"error":   err.Error(),
+				"code":    errorCode(err),
 			}
 		} else {
 			results[ifName] = map[string]interface{}{
@@ -661,6 +1976,17 @@ func MatchID(userHex string, id uint32) bool {
 	return uint32(parsedID) == id
 }
 
+// queryDataFilter parses the dataMask and dataMatch query parameters, responding with an
+// error when they are invalid
+func (h *APIHandler) queryDataFilter(c *gin.Context) (*DataFilter, bool) {
+	filter, err := ParseDataFilter(c.Query("dataMask"), c.Query("dataMatch"))
+	if err != nil {
+		h.respondError(c, http.StatusBadRequest, "Invalid data filter", err)
+		return nil, false
+	}
+	return filter, true
+}
+
 // handleGetMessages returns all messages for a specific interface
 func (h *APIHandler) handleGetMessages(c *gin.Context) {
 	if h.messageListener == nil {
@@ -668,17 +1994,23 @@ func (h *APIHandler) handleGetMessages(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("interface")
+	ifName := h.interfaceName(c.Param("interface"))
 	if ifName == "" {
 		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
 		return
 	}
 
+	filter, ok := h.queryDataFilter(c)
+	if !ok {
+		return
+	}
+
 	messages, err := h.messageListener.GetMessages(ifName)
 	if err != nil {
 		h.respondError(c, http.StatusNotFound, "Failed to get messages", err)
 		return
 	}
+	messages = filter.Filter(messages)
 
 	userId := c.Query("id")
 	if userId != "" {
@@ -708,7 +2040,7 @@ func (h *APIHandler) handleGetRecentMessages(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("interface")
+	ifName := h.interfaceName(c.Param("interface"))
 	if ifName == "" {
 		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
 		return
@@ -721,7 +2053,12 @@ func (h *APIHandler) handleGetRecentMessages(c *gin.Context) {
 		count = 10
 	}
 
-	messages, err := h.messageListener.GetRecentMessages(ifName, count)
+	filter, ok := h.queryDataFilter(c)
+	if !ok {
+		return
+	}
+
+	messages, err := h.messageListener.GetRecentMessages(ifName, count, filter)
 	if err != nil {
 		h.respondError(c, http.StatusNotFound, "Failed to get recent messages", err)
 		return
@@ -745,7 +2082,7 @@ func (h *APIHandler) handleGetMessageStatistics(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("interface")
+	ifName := h.interfaceName(c.Param("interface"))
 	if ifName == "" {
 		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
 		return
@@ -757,7 +2094,8 @@ func (h *APIHandler) handleGetMessageStatistics(c *gin.Context) {
 		return
 	}
 
-	stats["isListening"] = h.messageListener.IsListening(ifName)
+	isListening := h.messageListener.IsListening(ifName)
+	stats.IsListening = &isListening
 
 	h.respondSuccess(c, "", stats)
 }
@@ -769,7 +2107,7 @@ func (h *APIHandler) handleClearMessages(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("interface")
+	ifName := h.interfaceName(c.Param("interface"))
 	if ifName == "" {
 		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
 		return
@@ -795,7 +2133,15 @@ func (h *APIHandler) handleGetAllMessages(c *gin.Context) {
 		return
 	}
 
+	filter, ok := h.queryDataFilter(c)
+	if !ok {
+		return
+	}
+
 	allMessages := h.messageListener.GetAllMessages()
+	for ifName, messages := range allMessages {
+		allMessages[ifName] = filter.Filter(messages)
+	}
 
 	data := map[string]interface{}{
 		"interfaces":          allMessages,
@@ -869,22 +2215,45 @@ func (h *APIHandler) respondSuccess(c *gin.Context, message string, data interfa
 	if message != "" {
 		response.Message = message
 	}
-	c.JSON(http.StatusOK, response)
+	h.render(c, http.StatusOK, response)
 }
 
 // respondError sends an error JSON response
 func (h *APIHandler) respondError(c *gin.Context, statusCode int, message string, err error) {
-	response := ApiResponse{
-		Status: "error",
-		Error:  message,
-	}
+	statusCode, code := classifyError(err, statusCode)
 
+	var details interface{}
 	if err != nil {
-		response.Error = message + ": " + err.Error()
-		h.logger.Printf("API Error: %s - %v", message, err)
+		logger := h.logger.With("request_id", requestID(c), "status", statusCode, "code", code)
+		if statusCode >= http.StatusInternalServerError {
+			logger.Errorf("API Error: %s - %v", message, err)
+		} else {
+			logger.Infof("API Error: %s - %v", message, err)
+		}
+		message = message + ": " + err.Error()
+
+		// Configuration problems are listed one by one for clients to show
+		var configErrs ConfigErrors
+		if errors.As(err, &configErrs) {
+			details = map[string]interface{}{"errors": configErrs}
+		}
 	}
 
-	c.JSON(statusCode, response)
+	h.render(c, statusCode, newErrorResponse(c, code, message, details))
+}
+
+// parseTimeQuery parses an RFC3339 timestamp or a duration relative to now (e.g. "1h")
+func parseTimeQuery(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("expected RFC3339 timestamp or duration, got %q", value)
 }
 
 // parseSuccessRate converts success rate string to float
@@ -899,35 +2268,106 @@ func parseSuccessRate(rateStr string) float64 {
 
 // ====== Middleware functions ======
 
-// LoggingMiddleware provides request logging
-func LoggingMiddleware(logger Logger) gin.HandlerFunc {
-	return gin.LoggerWithConfig(gin.LoggerConfig{
-		SkipPaths: []string{"/api/status", "/api/health"}, // Skip status check logging
-		Formatter: func(param gin.LogFormatterParams) string {
-			return fmt.Sprintf("%s - [%s] \"%s %s %s %d %s \"%s\" %s\"\n",
-				param.ClientIP,
-				param.TimeStamp.Format("02/Jan/2006:15:04:05 -0700"),
-				param.Method,
-				param.Path,
-				param.Request.Proto,
-				param.StatusCode,
-				param.Latency,
-				param.Request.UserAgent(),
-				param.ErrorMessage,
-			)
-		},
-	})
+// LoggingMiddleware provides request logging and records per-route request metrics
+func LoggingMiddleware(logger Logger, settings *LogSettings, metrics *HTTPMetrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		latency := time.Since(start)
+		logAccess(c, logger, settings, latency)
+		if metrics != nil {
+			metrics.Observe(c.FullPath(), c.Request.Method, c.Writer.Status(), latency)
+		}
+	}
+}
+
+// traceContextKey is the gin context key holding the request span
+const traceContextKey = "traceSpan"
+
+// TracingMiddleware exports a server span for every API request. An incoming W3C
+// traceparent header makes the request part of the caller's trace.
+func TracingMiddleware(tracer *OTLPExporter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parent, _ := parseTraceparent(c.GetHeader("traceparent"))
+		span := newSpanContext(parent)
+		c.Set(traceContextKey, span)
+
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		status := c.Writer.Status()
+		attributes := []otlpKeyValue{
+			stringAttr("http.request.method", c.Request.Method),
+			stringAttr("http.route", route),
+			stringAttr("url.path", c.Request.URL.Path),
+			intAttr("http.response.status_code", int64(status)),
+			stringAttr("client.address", c.ClientIP()),
+			stringAttr("http.request.header.x-request-id", requestID(c)),
+		}
+
+		var errMsg string
+		if status >= http.StatusInternalServerError {
+			errMsg = http.StatusText(status)
+		}
+		tracer.RecordSpan(newOTLPSpan(span, parent, c.Request.Method+" "+route, otlpSpanKindServer,
+			start, time.Now(), attributes, errMsg))
+	}
+}
+
+// requestSpanContext returns the span of the current request, if tracing is enabled
+func requestSpanContext(c *gin.Context) spanContext {
+	if value, exists := c.Get(traceContextKey); exists {
+		if span, ok := value.(spanContext); ok {
+			return span
+		}
+	}
+	return spanContext{}
+}
+
+// requestClient returns the authenticated identity of a request: the API key principal,
+// else the client certificate identity, else ""
+func requestClient(c *gin.Context) string {
+	if value, exists := c.Get(principalKey); exists {
+		if principal, ok := value.(APIKey); ok {
+			return principal.Name
+		}
+	}
+	if value, exists := c.Get(clientIdentityKey); exists {
+		if identity, ok := value.(ClientIdentity); ok {
+			return identity.Name
+		}
+	}
+	return ""
 }
 
-// CORSMiddleware provides CORS support
-func CORSMiddleware() gin.HandlerFunc {
+// clientIdentityKey is the gin context key holding the verified client identity
+const clientIdentityKey = "clientIdentity"
+
+// ClientAuthMiddleware attaches the verified client identity to the request and rejects
+// write requests from read-only identities. The TLS handshake has already rejected
+// clients without a valid certificate.
+func ClientAuthMiddleware(permissions map[string]string, logger Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type, X-CSRF-Token")
+		if fromTrustedUnixSocket(c.Request) {
+			c.Set(clientIdentityKey, ClientIdentity{Name: unixSocketPrincipal.Name, Permission: PermissionFull})
+			c.Next()
+			return
+		}
+		if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+			abortWithError(c, http.StatusForbidden, CodeForbidden, "Permission denied: a verified client certificate is required")
+			return
+		}
+
+		identity := clientIdentity(c.Request.TLS.VerifiedChains[0][0], permissions)
+		c.Set(clientIdentityKey, identity)
 
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
+		if identity.Permission != PermissionFull && isWriteRequest(c.Request.Method) {
+			logger.Warnf("🔒 Denied %s %s for read-only client %q", c.Request.Method, c.Request.URL.Path, identity.Name)
+			abortWithError(c, http.StatusForbidden, CodeForbidden, fmt.Sprintf("Permission denied: client %q is read-only", identity.Name))
 			return
 		}
 
@@ -935,17 +2375,30 @@ func CORSMiddleware() gin.HandlerFunc {
 	}
 }
 
-// RecoveryMiddleware provides panic recovery
-func RecoveryMiddleware(logger Logger) gin.HandlerFunc {
+// RecoveryMiddleware provides panic recovery, writing the blackbox before answering
+func RecoveryMiddleware(logger Logger, blackbox *Blackbox) gin.HandlerFunc {
 	return gin.CustomRecovery(func(c *gin.Context, recovered interface{}) {
-		logger.Printf("Panic recovered: %v", recovered)
-		c.JSON(http.StatusInternalServerError, ApiResponse{
-			Status: "error",
-			Error:  "Internal server error",
-		})
+		logger.Errorf("Panic recovered: %v%s", recovered, requestIDSuffix(requestID(c)))
+		blackbox.DumpPanic(recovered, requestID(c))
+		abortWithError(c, http.StatusInternalServerError, CodeInternal, "Internal server error")
 	})
 }
 
+// handleBlackboxDump writes the blackbox and reports the file
+func (h *APIHandler) handleBlackboxDump(c *gin.Context) {
+	reason := "requested"
+	if custom := strings.TrimSpace(c.Query("reason")); custom != "" {
+		reason += ": " + custom
+	}
+	dump, err := h.blackbox.Dump(reason + requestIDSuffix(requestID(c)))
+	if err != nil {
+		h.respondError(c, http.StatusInternalServerError, "Failed to write blackbox", err)
+		return
+	}
+	h.logger.Printf("📦 Blackbox written to %s", dump.File)
+	h.respondSuccess(c, "Blackbox written", dump)
+}
+
 // handleStopListening stops message listening on a specific interface
 func (h *APIHandler) handleStopListening(c *gin.Context) {
 	if h.messageListener == nil {
@@ -953,7 +2406,7 @@ func (h *APIHandler) handleStopListening(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("interface")
+	ifName := h.interfaceName(c.Param("interface"))
 	if ifName == "" {
 		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
 		return
@@ -980,7 +2433,7 @@ func (h *APIHandler) handleGetListenStatus(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("interface")
+	ifName := h.interfaceName(c.Param("interface"))
 	if ifName == "" {
 		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
 		return
@@ -1016,7 +2469,7 @@ func (h *APIHandler) handleStartListening(c *gin.Context) {
 		return
 	}
 
-	ifName := c.Param("interface")
+	ifName := h.interfaceName(c.Param("interface"))
 	if ifName == "" {
 		h.respondError(c, http.StatusBadRequest, "Interface name is required", nil)
 		return
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Audit log defaults and limits
+const (
+	DefaultAuditLogEntries  = 1000 // Records kept in memory for GET /api/v1/audit
+	DefaultAuditLogMaxFiles = 10
+
+	auditBodySummary  = 512  // Request body bytes kept in a record
+	auditErrorCapture = 4096 // Error response bytes read for the error message
+)
+
+// Audit outcomes
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+	AuditDenied  = "denied" // Rejected by authentication, roles or the network allowlist
+)
+
+// AuditRecord is one control-plane operation: a mutating API call or a reload signal
+type AuditRecord struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	Principal  string            `json:"principal,omitempty"` // API key principal, client certificate identity or signal:SIGHUP
+	Role       string            `json:"role,omitempty"`      // Role of the API key, or permission of the client certificate
+	RemoteAddr string            `json:"remoteAddr,omitempty"`
+	RequestID  string            `json:"requestId,omitempty"`
+	Action     string            `json:"action"`           // Route, e.g. "POST /api/v1/interfaces/:name/setup"
+	Path       string            `json:"path,omitempty"`   // Path requested
+	Params     map[string]string `json:"params,omitempty"` // Path and query parameters
+	Body       string            `json:"body,omitempty"`   // JSON request body, cut at 512 bytes; size and type of others
+	Status     int               `json:"status,omitempty"` // HTTP status of the response
+	Outcome    string            `json:"outcome"`          // success, failure or denied
+	Code       ErrorCode         `json:"code,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	DurationMs float64           `json:"durationMs"`
+}
+
+// AuditFilter selects records from the audit log
+type AuditFilter struct {
+	Principal string
+	Outcome   string
+	Since     time.Time
+	Until     time.Time
+	Limit     int // Most recent records; 0 for all
+}
+
+// matches checks whether a record passes the filter
+func (f AuditFilter) matches(record AuditRecord) bool {
+	if f.Principal != "" && record.Principal != f.Principal {
+		return false
+	}
+	if f.Outcome != "" && record.Outcome != f.Outcome {
+		return false
+	}
+	if !f.Since.IsZero() && record.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && record.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// AuditLogStats reports the state of the audit log
+type AuditLogStats struct {
+	Recorded    uint64             `json:"recorded"`
+	WriteErrors uint64             `json:"writeErrors"`
+	InMemory    int                `json:"inMemory"`
+	File        *RotatingFileStats `json:"file,omitempty"`
+}
+
+// AuditLog records control-plane operations in memory and, when a file is set, as JSON
+// lines. Unlike the send audit log, a record is written to the file before the response
+// is sent, with one write per record and no buffering, so a crash right after an action
+// leaves its record on disk. A nil AuditLog is valid and records nothing.
+type AuditLog struct {
+	mu      sync.RWMutex
+	records []AuditRecord
+	maxSize int
+	file    *RotatingFile // nil keeps records in memory only
+	logger  Logger
+
+	recorded    atomic.Uint64
+	writeErrors atomic.Uint64
+}
+
+// NewAuditLog creates an audit log keeping maxSize records in memory. When path is set,
+// the records of the current file are loaded and new ones are appended to it, rotated by
+// policy.
+func NewAuditLog(path string, policy RotationPolicy, maxSize int, logger Logger) (*AuditLog, error) {
+	al := &AuditLog{
+		records: make([]AuditRecord, 0, maxSize),
+		maxSize: maxSize,
+		logger:  logger,
+	}
+	if path == "" {
+		return al, nil
+	}
+
+	if err := al.load(path); err != nil {
+		logger.Warnf("⚠️ Warning: could not load audit records from %s: %v", path, err)
+	}
+	file, err := OpenRotatingFile(path, policy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	al.file = file
+	logger.Infof("📝 Recording control-plane operations to %s", path)
+	return al, nil
+}
+
+// load reads the records of a previous run, keeping only the most recent maxSize
+func (al *AuditLog) load(path string) error {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue // Skip corrupted lines
+		}
+		al.append(record)
+	}
+	return scanner.Err()
+}
+
+// append adds a record to the in-memory buffer (caller holds mu or owns al)
+func (al *AuditLog) append(record AuditRecord) {
+	al.records = append(al.records, record)
+	if len(al.records) > al.maxSize {
+		al.records = al.records[1:]
+	}
+}
+
+// Record adds a record, writing it to the file before returning
+func (al *AuditLog) Record(record AuditRecord) {
+	if al == nil {
+		return
+	}
+	al.recorded.Add(1)
+
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	al.append(record)
+	if al.file == nil {
+		return
+	}
+
+	line, err := json.Marshal(record)
+	if err == nil {
+		_, err = al.file.Write(append(line, '\n'))
+	}
+	if err != nil {
+		al.writeErrors.Add(1)
+		al.logger.Warnf("⚠️ Warning: failed to write audit record of %s: %v", record.Action, err)
+	}
+}
+
+// Query returns records matching the filter, oldest first
+func (al *AuditLog) Query(filter AuditFilter) []AuditRecord {
+	if al == nil {
+		return []AuditRecord{}
+	}
+	al.mu.RLock()
+	defer al.mu.RUnlock()
+
+	result := []AuditRecord{}
+	for _, record := range al.records {
+		if filter.matches(record) {
+			result = append(result, record)
+		}
+	}
+
+	// Keep the most recent records when a limit is set
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[len(result)-filter.Limit:]
+	}
+	return result
+}
+
+// DiskUsage returns the bytes taken by the audit file and its rotated files
+func (al *AuditLog) DiskUsage() int64 {
+	if al == nil {
+		return 0
+	}
+	return al.file.DiskUsage()
+}
+
+// GetStats returns the audit log counters
+func (al *AuditLog) GetStats() AuditLogStats {
+	if al == nil {
+		return AuditLogStats{}
+	}
+	al.mu.RLock()
+	inMemory := len(al.records)
+	al.mu.RUnlock()
+
+	stats := AuditLogStats{
+		Recorded:    al.recorded.Load(),
+		WriteErrors: al.writeErrors.Load(),
+		InMemory:    inMemory,
+	}
+	if al.file != nil {
+		fileStats := al.file.GetStats()
+		stats.File = &fileStats
+	}
+	return stats
+}
+
+// Close closes the audit file
+func (al *AuditLog) Close() {
+	if al == nil {
+		return
+	}
+	al.mu.Lock()
+	defer al.mu.Unlock()
+	if err := al.file.Close(); err != nil {
+		al.logger.Warnf("⚠️ Warning: failed to close audit log: %v", err)
+	}
+}
+
+// auditOutcome classifies the HTTP status of an audited request
+func auditOutcome(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return AuditDenied
+	case status >= http.StatusBadRequest:
+		return AuditFailure
+	default:
+		return AuditSuccess
+	}
+}
+
+// auditBody keeps the first bytes of a request body as the handler reads it, so the body
+// is neither read twice nor held in full
+type auditBody struct {
+	io.ReadCloser
+	head []byte
+	size int64
+}
+
+func (b *auditBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if room := auditBodySummary - len(b.head); room > 0 {
+		b.head = append(b.head, p[:min(n, room)]...)
+	}
+	b.size += int64(n)
+	return n, err
+}
+
+// summary returns a JSON body, compacted and cut at auditBodySummary bytes, or the size
+// and type of other content, such as a configuration file that may hold secrets. Bodies
+// without a content type are taken as JSON when they look like it, as the API accepts.
+// A body the handler did not read, e.g. of a denied request, is summarized by its length.
+func (b *auditBody) summary(contentType string, contentLength int64) string {
+	if b.size == 0 {
+		if contentLength <= 0 {
+			return ""
+		}
+		if contentType == "" {
+			contentType = "unknown type"
+		}
+		return fmt.Sprintf("(%d bytes of %s, not read)", contentLength, contentType)
+	}
+	trimmed := bytes.TrimSpace(b.head)
+	looksJSON := len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+	if contentType != gin.MIMEJSON && !(contentType == "" && looksJSON) {
+		if contentType == "" {
+			contentType = "unknown type"
+		}
+		return fmt.Sprintf("(%d bytes of %s)", b.size, contentType)
+	}
+	if b.size > int64(len(b.head)) {
+		return fmt.Sprintf("%s... (%d bytes)", b.head, b.size)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, b.head); err == nil {
+		return compact.String()
+	}
+	return string(b.head)
+}
+
+// auditResponseWriter keeps the start of error responses, for their code and message
+type auditResponseWriter struct {
+	gin.ResponseWriter
+	errorBody []byte
+}
+
+func (w *auditResponseWriter) Write(p []byte) (int, error) {
+	w.capture(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *auditResponseWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *auditResponseWriter) capture(p []byte) {
+	if w.Status() < http.StatusBadRequest {
+		return
+	}
+	if room := auditErrorCapture - len(w.errorBody); room > 0 {
+		w.errorBody = append(w.errorBody, p[:min(len(p), room)]...)
+	}
+}
+
+// AuditMiddleware records every mutating request that matched a route in the audit log,
+// with its principal, parameters and outcome. It must run before authentication, so
+// denied requests are recorded too.
+func AuditMiddleware(audit *AuditLog) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if audit == nil || !isWriteRequest(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		body := &auditBody{ReadCloser: c.Request.Body}
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			c.Request.Body = body
+		}
+		writer := &auditResponseWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		c.Writer = writer.ResponseWriter
+		// Unknown routes change nothing
+		if c.FullPath() == "" {
+			return
+		}
+
+		record := AuditRecord{
+			Timestamp:  start,
+			Principal:  requestClient(c),
+			Role:       requestRole(c),
+			RemoteAddr: c.ClientIP(),
+			RequestID:  requestID(c),
+			Action:     c.Request.Method + " " + c.FullPath(),
+			Path:       c.Request.URL.Path,
+			Params:     auditParams(c),
+			Body:       body.summary(c.ContentType(), c.Request.ContentLength),
+			Status:     c.Writer.Status(),
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		}
+		record.Outcome = auditOutcome(record.Status)
+		if len(writer.errorBody) > 0 {
+			var response ApiResponse
+			if json.Unmarshal(writer.errorBody, &response) == nil {
+				record.Code, record.Error = response.Code, response.Message
+			}
+		}
+		audit.Record(record)
+	}
+}
+
+// auditParams returns the path and query parameters of a request; repeated query
+// parameters are joined with commas
+func auditParams(c *gin.Context) map[string]string {
+	query := c.Request.URL.Query()
+	if len(c.Params) == 0 && len(query) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(c.Params)+len(query))
+	for name, values := range query {
+		params[name] = strings.Join(values, ",")
+	}
+	for _, param := range c.Params {
+		params[param.Key] = param.Value
+	}
+	return params
+}
+
+// requestRole returns the role of the API key of a request, else the permission of its
+// client certificate, else ""
+func requestRole(c *gin.Context) string {
+	if value, exists := c.Get(principalKey); exists {
+		if principal, ok := value.(APIKey); ok {
+			return principal.Role
+		}
+	}
+	if value, exists := c.Get(clientIdentityKey); exists {
+		if identity, ok := value.(ClientIdentity); ok {
+			return identity.Permission
+		}
+	}
+	return ""
+}
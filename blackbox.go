@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Blackbox defaults and limits
+const (
+	DefaultBlackboxMaxFiles = 10
+
+	blackboxLogLines    = 2000 // Log lines kept
+	blackboxFrames      = 5000 // Received frames kept per interface
+	blackboxEvents      = 200  // Watchdog events written
+	blackboxTimeBudget  = 2 * time.Second
+	blackboxMinInterval = 10 * time.Second // Between dumps of panics, so a panic storm cannot fill the disk
+	blackboxMaxStack    = 16 << 20         // Goroutine stacks beyond this are cut off
+
+	blackboxFilePrefix = "blackbox-"
+	blackboxFileSuffix = ".txt"
+	blackboxCrashFile  = "crash.log" // Fatal errors the runtime reports, which no dump can catch
+)
+
+// BlackboxDump describes a written blackbox file
+type BlackboxDump struct {
+	File       string         `json:"file"`
+	Reason     string         `json:"reason"`
+	Size       int64          `json:"size"`
+	Duration   string         `json:"duration"`
+	LogLines   int            `json:"logLines"`
+	Frames     map[string]int `json:"frames"` // Frames written by interface
+	Events     int            `json:"events"`
+	Goroutines int            `json:"goroutines"`
+}
+
+// blackboxFrame is a received frame, kept compact since thousands are held per interface
+type blackboxFrame struct {
+	timestamp time.Time
+	id        uint32
+	length    uint8
+	rtr       bool
+	data      [8]byte
+}
+
+// blackboxFrameRing holds the last frames of one interface
+type blackboxFrameRing struct {
+	mu     sync.Mutex
+	frames []blackboxFrame
+	next   int // Slot the next frame goes to once the ring is full
+}
+
+// Blackbox keeps the last log lines and received frames in memory and writes them, with
+// the last watchdog events and every goroutine stack, to a file in its directory when a
+// request handler panics or on demand. Writing is best effort and bounded in time, so it
+// cannot turn a panic into a hang. Fatal runtime errors, which cannot be recovered, are
+// written by the runtime to crash.log in the same directory. A nil Blackbox is valid and
+// records nothing.
+type Blackbox struct {
+	dir      string
+	maxFiles int
+	logger   Logger
+	watchdog *Watchdog
+
+	logMu   sync.Mutex
+	logs    []string
+	logNext int
+
+	framesMu sync.RWMutex
+	frames   map[string]*blackboxFrameRing
+
+	dumpMu    sync.Mutex // One dump at a time
+	lastPanic atomic.Int64
+	dumps     atomic.Uint64
+	crashFile *os.File
+}
+
+// NewBlackbox creates the blackbox directory and sends fatal runtime errors to its
+// crash.log
+func NewBlackbox(dir string, maxFiles int, logger Logger) (*Blackbox, error) {
+	if err := os.MkdirAll(dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create blackbox directory: %w", err)
+	}
+	b := &Blackbox{
+		dir:      dir,
+		maxFiles: maxFiles,
+		logger:   logger,
+		logs:     make([]string, 0, blackboxLogLines),
+		frames:   make(map[string]*blackboxFrameRing),
+	}
+
+	crashFile, err := os.OpenFile(filepath.Join(dir, blackboxCrashFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open crash file: %w", err)
+	}
+	if err := debug.SetCrashOutput(crashFile, debug.CrashOptions{}); err != nil {
+		crashFile.Close()
+		return nil, fmt.Errorf("failed to set crash output: %w", err)
+	}
+	b.crashFile = crashFile
+	return b, nil
+}
+
+// SetWatchdog sets the watchdog whose last events are written
+func (b *Blackbox) SetWatchdog(watchdog *Watchdog) {
+	b.watchdog = watchdog
+}
+
+// Write keeps a log line. It is installed next to the log output, so it sees every line
+// in the format it was written in.
+func (b *Blackbox) Write(p []byte) (int, error) {
+	line := strings.TrimRight(string(p), "\n")
+	b.logMu.Lock()
+	if len(b.logs) < blackboxLogLines {
+		b.logs = append(b.logs, line)
+	} else {
+		b.logs[b.logNext] = line
+		b.logNext = (b.logNext + 1) % blackboxLogLines
+	}
+	b.logMu.Unlock()
+	return len(p), nil
+}
+
+// ObserveFrame keeps a received frame. Error frames are kept too, as they often explain
+// what went wrong.
+func (b *Blackbox) ObserveFrame(msg CanMessageLog) {
+	if b == nil {
+		return
+	}
+	b.framesMu.RLock()
+	ring := b.frames[msg.Interface]
+	b.framesMu.RUnlock()
+	if ring == nil {
+		b.framesMu.Lock()
+		if ring = b.frames[msg.Interface]; ring == nil {
+			ring = &blackboxFrameRing{frames: make([]blackboxFrame, 0, 256)}
+			b.frames[msg.Interface] = ring
+		}
+		b.framesMu.Unlock()
+	}
+
+	frame := blackboxFrame{timestamp: msg.Timestamp, id: msg.ID, length: msg.Length, rtr: msg.RTR}
+	copy(frame.data[:], msg.Data)
+	ring.mu.Lock()
+	if len(ring.frames) < blackboxFrames {
+		ring.frames = append(ring.frames, frame)
+	} else {
+		ring.frames[ring.next] = frame
+		ring.next = (ring.next + 1) % blackboxFrames
+	}
+	ring.mu.Unlock()
+}
+
+// snapshotLogs returns the kept log lines, oldest first
+func (b *Blackbox) snapshotLogs() []string {
+	b.logMu.Lock()
+	defer b.logMu.Unlock()
+	lines := make([]string, 0, len(b.logs))
+	lines = append(lines, b.logs[b.logNext:]...)
+	return append(lines, b.logs[:b.logNext]...)
+}
+
+// snapshotFrames returns the kept frames of every interface, oldest first
+func (b *Blackbox) snapshotFrames() map[string][]blackboxFrame {
+	b.framesMu.RLock()
+	rings := make(map[string]*blackboxFrameRing, len(b.frames))
+	for ifName, ring := range b.frames {
+		rings[ifName] = ring
+	}
+	b.framesMu.RUnlock()
+
+	frames := make(map[string][]blackboxFrame, len(rings))
+	for ifName, ring := range rings {
+		ring.mu.Lock()
+		snapshot := make([]blackboxFrame, 0, len(ring.frames))
+		snapshot = append(snapshot, ring.frames[ring.next:]...)
+		frames[ifName] = append(snapshot, ring.frames[:ring.next]...)
+		ring.mu.Unlock()
+	}
+	return frames
+}
+
+// DumpPanic writes a blackbox for a recovered panic, unless another panic was dumped
+// less than blackboxMinInterval ago. It returns within the time budget.
+func (b *Blackbox) DumpPanic(recovered interface{}, requestID string) {
+	if b == nil {
+		return
+	}
+	now := time.Now()
+	last := b.lastPanic.Load()
+	if now.UnixNano()-last < int64(blackboxMinInterval) || !b.lastPanic.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	reason := fmt.Sprintf("panic: %v%s", recovered, requestIDSuffix(requestID))
+	dump, err := b.Dump(reason)
+	if err != nil {
+		b.logger.Errorf("❌ Failed to write blackbox: %v", err)
+		return
+	}
+	b.logger.Errorf("📦 Blackbox written to %s", dump.File)
+}
+
+// Dump writes the blackbox file and returns what it holds. Writing continues in the
+// background when it exceeds the time budget, but Dump returns then with an error, so
+// a stuck disk or lock never holds up its caller for longer.
+func (b *Blackbox) Dump(reason string) (BlackboxDump, error) {
+	type result struct {
+		dump BlackboxDump
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		dump, err := b.write(reason)
+		done <- result{dump, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.dump, r.err
+	case <-time.After(blackboxTimeBudget):
+		return BlackboxDump{}, fmt.Errorf("blackbox not written within %v", blackboxTimeBudget)
+	}
+}
+
+// write writes the blackbox file: the reason, goroutine stacks, log lines, watchdog
+// events and frames in the candump log format, then deletes the oldest files beyond
+// maxFiles
+func (b *Blackbox) write(reason string) (BlackboxDump, error) {
+	b.dumpMu.Lock()
+	defer b.dumpMu.Unlock()
+
+	start := time.Now()
+	path := unusedFileName(start, func(at time.Time) string {
+		return filepath.Join(b.dir, blackboxFilePrefix+at.Format("2006-01-02_150405.000")+blackboxFileSuffix)
+	})
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+	if err != nil {
+		return BlackboxDump{}, err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+
+	dump := BlackboxDump{File: path, Reason: reason, Frames: make(map[string]int)}
+	fmt.Fprintf(w, "can-bridge %s blackbox\n", VERSION)
+	fmt.Fprintf(w, "time: %s\n", start.Format(time.RFC3339Nano))
+	fmt.Fprintf(w, "reason: %s\n", reason)
+
+	dump.Goroutines = runtime.NumGoroutine()
+	fmt.Fprintf(w, "\n=== goroutines (%d) ===\n", dump.Goroutines)
+	w.Write(goroutineStacks())
+
+	logs := b.snapshotLogs()
+	dump.LogLines = len(logs)
+	fmt.Fprintf(w, "\n=== log (last %d lines) ===\n", len(logs))
+	for _, line := range logs {
+		w.WriteString(line)
+		w.WriteByte('\n')
+	}
+
+	if b.watchdog != nil {
+		events := b.watchdog.GetEvents(WatchdogEventFilter{Limit: blackboxEvents})
+		dump.Events = len(events)
+		fmt.Fprintf(w, "\n=== watchdog events (last %d) ===\n", len(events))
+		for _, event := range events {
+			if line, err := json.Marshal(event); err == nil {
+				w.Write(line)
+				w.WriteByte('\n')
+			}
+		}
+	}
+
+	frames := b.snapshotFrames()
+	ifNames := make([]string, 0, len(frames))
+	for ifName := range frames {
+		ifNames = append(ifNames, ifName)
+	}
+	sort.Strings(ifNames)
+	line := make([]byte, 0, 64)
+	for _, ifName := range ifNames {
+		dump.Frames[ifName] = len(frames[ifName])
+		fmt.Fprintf(w, "\n=== frames %s (last %d, candump log format) ===\n", ifName, len(frames[ifName]))
+		for _, frame := range frames[ifName] {
+			line = appendCandumpLine(line[:0], CanMessageLog{
+				Interface: ifName,
+				ID:        frame.id,
+				Data:      frame.data[:min(int(frame.length), len(frame.data))],
+				Length:    frame.length,
+				RTR:       frame.rtr,
+				Timestamp: frame.timestamp,
+			})
+			w.Write(append(line, '\n'))
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return BlackboxDump{}, err
+	}
+	if info, err := file.Stat(); err == nil {
+		dump.Size = info.Size()
+	}
+	dump.Duration = time.Since(start).Round(time.Millisecond).String()
+	b.dumps.Add(1)
+
+	b.prune()
+	return dump, nil
+}
+
+// goroutineStacks returns the stacks of every goroutine, growing the buffer until they
+// fit or reach blackboxMaxStack
+func goroutineStacks() []byte {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= blackboxMaxStack {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// prune deletes the oldest blackbox files beyond maxFiles. Names sort by time.
+func (b *Blackbox) prune() {
+	matches, err := filepath.Glob(filepath.Join(b.dir, blackboxFilePrefix+"*"+blackboxFileSuffix))
+	if err != nil || len(matches) <= b.maxFiles {
+		return
+	}
+	sort.Strings(matches)
+	for _, path := range matches[:len(matches)-b.maxFiles] {
+		if err := os.Remove(path); err != nil {
+			b.logger.Warnf("⚠️ Warning: failed to delete old blackbox file %s: %v", path, err)
+		}
+	}
+}
+
+// Close stops sending fatal errors to crash.log, and deletes it when nothing crashed
+func (b *Blackbox) Close() {
+	if b == nil || b.crashFile == nil {
+		return
+	}
+	debug.SetCrashOutput(nil, debug.CrashOptions{})
+	if info, err := b.crashFile.Stat(); err == nil && info.Size() == 0 {
+		os.Remove(b.crashFile.Name())
+	}
+	b.crashFile.Close()
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// IDRule matches CAN IDs: one ID (0x7E8), a range (0x100-0x1FF) or an ID under a mask
+// (0x18DAF100/0x1FFFFF00), as kernel filters do. IDs are matched by value, whether the
+// frame is standard or extended.
+type IDRule struct {
+	ID   uint32
+	Last uint32 // Last ID of a range, ID otherwise
+	Mask uint32 // Bits compared with ID; 0 for a single ID or a range
+}
+
+// ParseIDRule parses an ID, a range or an ID under a mask
+func ParseIDRule(value string) (IDRule, error) {
+	value = strings.TrimSpace(value)
+	parseID := func(raw string) (uint32, error) {
+		n, err := strconv.ParseUint(strings.TrimSpace(raw), 0, 32)
+		if err != nil || n > unix.CAN_EFF_MASK {
+			return 0, fmt.Errorf("invalid CAN ID %q: expected 0x0 to 0x1FFFFFFF", strings.TrimSpace(raw))
+		}
+		return uint32(n), nil
+	}
+
+	if id, mask, found := strings.Cut(value, "/"); found {
+		rule := IDRule{}
+		var err error
+		if rule.ID, err = parseID(id); err != nil {
+			return IDRule{}, err
+		}
+		if rule.Mask, err = parseID(mask); err != nil {
+			return IDRule{}, err
+		}
+		if rule.Mask == 0 {
+			return IDRule{}, fmt.Errorf("mask of %q selects no bits", value)
+		}
+		rule.Last = rule.ID
+		return rule, nil
+	}
+	if first, last, found := strings.Cut(value, "-"); found {
+		rule := IDRule{}
+		var err error
+		if rule.ID, err = parseID(first); err != nil {
+			return IDRule{}, err
+		}
+		if rule.Last, err = parseID(last); err != nil {
+			return IDRule{}, err
+		}
+		if rule.Last < rule.ID {
+			return IDRule{}, fmt.Errorf("range %q ends before it starts", value)
+		}
+		return rule, nil
+	}
+	id, err := parseID(value)
+	if err != nil {
+		return IDRule{}, err
+	}
+	return IDRule{ID: id, Last: id}, nil
+}
+
+// Matches reports whether an ID, with or without CAN_EFF_FLAG, matches the rule
+func (r IDRule) Matches(id uint32) bool {
+	id &= unix.CAN_EFF_MASK
+	if r.Mask != 0 {
+		return id&r.Mask == r.ID&r.Mask
+	}
+	return id >= r.ID && id <= r.Last
+}
+
+// String formats the rule as ParseIDRule reads it
+func (r IDRule) String() string {
+	switch {
+	case r.Mask != 0:
+		return fmt.Sprintf("0x%X/0x%X", r.ID, r.Mask)
+	case r.Last != r.ID:
+		return fmt.Sprintf("0x%X-0x%X", r.ID, r.Last)
+	}
+	return fmt.Sprintf("0x%X", r.ID)
+}
+
+// MarshalText renders the rule as its string, e.g. in the configuration summary
+func (r IDRule) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// BufferFilter is the allowlist of the IDs kept in the receive buffer of an interface.
+// An empty filter keeps every frame.
+type BufferFilter []IDRule
+
+// Matches reports whether a frame with an ID is buffered
+func (f BufferFilter) Matches(id uint32) bool {
+	if len(f) == 0 {
+		return true
+	}
+	for _, rule := range f {
+		if rule.Matches(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBufferFilters parses the buffered IDs of interfaces ("can0=0x100-0x1FF,can0=0x7E8").
+// An interface is repeated for each rule, as interfaces are for their aliases.
+func (cp *ConfigParser) parseBufferFilters(value string) (map[string]BufferFilter, error) {
+	result := make(map[string]BufferFilter)
+	for _, entry := range cp.parseList(value) {
+		ifName, raw, found := strings.Cut(entry, "=")
+		ifName = strings.TrimSpace(ifName)
+		if !found || ifName == "" {
+			return nil, fmt.Errorf("expected interface=id, got %q", entry)
+		}
+		rule, err := ParseIDRule(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ifName, err)
+		}
+		result[ifName] = append(result[ifName], rule)
+	}
+	return result, nil
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Nominal frame overhead in bits (SOF, arbitration, control, CRC, ACK, EOF and
+// interframe space), excluding stuff bits
+const (
+	standardFrameOverheadBits = 47
+	extendedFrameOverheadBits = 67
+)
+
+// CAN FD frame overhead in bits: arbitration and control fields with FDF, BRS and ESI,
+// stuff count, CRC-17 with its fixed stuff bits, delimiters, ACK, EOF and interframe
+// space. Frames above 16 data bytes use CRC-21, which adds 4 CRC and 1 fixed stuff bit.
+const (
+	fdStandardFrameOverheadBits = 62
+	fdExtendedFrameOverheadBits = 81
+	fdCRC21ExtraBits            = 5
+)
+
+// can15Polynomial is the generator polynomial of the classic CAN CRC-15
+const can15Polynomial = 0x4599
+
+// isFDFrame reports whether a frame is CAN FD, the only kind carrying more than 8 bytes
+func isFDFrame(msg CanMessageLog) bool {
+	return len(msg.Data) > unix.CAN_MAX_DLEN
+}
+
+// frameBits estimates the bus time of a frame in bits. Stuff bits are not counted, so
+// bus load derived from it is a lower bound. CAN FD frames are counted at the nominal
+// bitrate; the faster data phase of bit rate switching is not accounted for.
+func frameBits(msg CanMessageLog) int {
+	extended := msg.ID&unix.CAN_EFF_FLAG != 0
+	if isFDFrame(msg) {
+		overhead := fdStandardFrameOverheadBits
+		if extended {
+			overhead = fdExtendedFrameOverheadBits
+		}
+		if len(msg.Data) > 16 {
+			overhead += fdCRC21ExtraBits
+		}
+		return overhead + 8*len(msg.Data)
+	}
+
+	overhead := standardFrameOverheadBits
+	if extended {
+		overhead = extendedFrameOverheadBits
+	}
+	if msg.RTR {
+		return overhead
+	}
+	return overhead + 8*len(msg.Data)
+}
+
+// bitStuffer follows a frame bitstream as the transmitter sends it, counting the stuff
+// bits inserted after five consecutive equal bits and the CRC-15 of the bits fed as data
+type bitStuffer struct {
+	last      uint32
+	run       int
+	stuffBits int
+	crc       uint32
+}
+
+// addField feeds the n low bits of value, most significant first, into CRC and stuffing
+func (s *bitStuffer) addField(value uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		bit := value >> i & 1
+		feedback := bit ^ (s.crc >> 14 & 1)
+		s.crc = s.crc << 1 & 0x7fff
+		if feedback != 0 {
+			s.crc ^= can15Polynomial
+		}
+		s.push(bit)
+	}
+}
+
+// addCRC sends the CRC-15 of the bits fed so far, which is stuffed but not checksummed
+func (s *bitStuffer) addCRC() {
+	for i := 14; i >= 0; i-- {
+		s.push(s.crc >> i & 1)
+	}
+}
+
+// push sends one bit, inserting a stuff bit of opposite value after five equal bits.
+// The stuff bit counts towards the next run.
+func (s *bitStuffer) push(bit uint32) {
+	if s.run > 0 && bit == s.last {
+		s.run++
+	} else {
+		s.last, s.run = bit, 1
+	}
+	if s.run == 5 {
+		s.stuffBits++
+		s.last, s.run = bit^1, 1
+	}
+}
+
+// fdDLC returns the DLC code of a CAN FD data length
+func fdDLC(length int) uint32 {
+	for code, limit := range []int{12, 16, 20, 24, 32, 48} {
+		if length <= limit {
+			return uint32(9 + code)
+		}
+	}
+	return 15
+}
+
+// frameStuffBits counts the stuff bits of a frame. The bitstream is rebuilt from ID,
+// DLC and data, so the count is exact for classic frames, CRC included. CAN FD frames
+// are stuffed dynamically up to the end of the data only (their CRC field uses fixed
+// stuff bits, counted in the overhead); BRS and ESI are assumed 0, as they are not
+// reported on receive.
+func frameStuffBits(msg CanMessageLog) int {
+	var s bitStuffer
+	fd := isFDFrame(msg)
+	rtr := uint32(0)
+	if msg.RTR && !fd {
+		rtr = 1
+	}
+
+	s.addField(0, 1) // SOF
+	if msg.ID&unix.CAN_EFF_FLAG != 0 {
+		id := msg.ID & unix.CAN_EFF_MASK
+		s.addField(id>>18, 11) // Base ID
+		s.addField(0b11, 2)    // SRR, IDE
+		s.addField(id, 18)     // ID extension
+		s.addField(rtr, 1)     // RTR (classic) or RRS (FD)
+		if !fd {
+			s.addField(0, 2) // r1, r0
+		}
+	} else {
+		s.addField(msg.ID&unix.CAN_SFF_MASK, 11)
+		s.addField(rtr, 1) // RTR (classic) or RRS (FD)
+		s.addField(0, 1)   // IDE
+		if !fd {
+			s.addField(0, 1) // r0
+		}
+	}
+
+	if fd {
+		s.addField(0b1000, 4) // FDF, res, BRS, ESI
+		s.addField(fdDLC(len(msg.Data)), 4)
+	} else if msg.RTR {
+		s.addField(uint32(msg.Length), 4)
+	} else {
+		s.addField(uint32(len(msg.Data)), 4)
+	}
+	for _, b := range msg.Data {
+		s.addField(uint32(b), 8)
+	}
+
+	if !fd {
+		s.addCRC()
+	}
+	return s.stuffBits
+}
+
+// busTrafficCounters holds the received frame and bit rates of one interface
+type busTrafficCounters struct {
+	frames    RateTracker
+	bits      RateTracker
+	stuffBits RateTracker
+	lastFrame time.Time
+}
+
+// busTrafficTracker keeps rolling frame and bit rates for bus load estimation and
+// remembers when each interface last received a frame
+type busTrafficTracker struct {
+	interfaces map[string]*busTrafficCounters
+	mutex      sync.Mutex
+}
+
+// newBusTrafficTracker creates an empty tracker
+func newBusTrafficTracker() *busTrafficTracker {
+	return &busTrafficTracker{
+		interfaces: make(map[string]*busTrafficCounters),
+	}
+}
+
+// observe counts a received frame
+func (t *busTrafficTracker) observe(msg CanMessageLog) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	counters, exists := t.interfaces[msg.Interface]
+	if !exists {
+		counters = &busTrafficCounters{}
+		t.interfaces[msg.Interface] = counters
+	}
+
+	counters.frames.Add(msg.Timestamp, 1)
+	counters.bits.Add(msg.Timestamp, uint64(frameBits(msg)))
+	counters.stuffBits.Add(msg.Timestamp, uint64(frameStuffBits(msg)))
+	counters.lastFrame = msg.Timestamp
+}
+
+// rates returns the received frames and bits per second of an interface
+func (t *busTrafficTracker) rates(ifName string, now time.Time) (frames, bits RollingRates) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if counters, exists := t.interfaces[ifName]; exists {
+		return counters.frames.Rates(now), counters.bits.Rates(now)
+	}
+	return RollingRates{}, RollingRates{}
+}
+
+// stuffBitRates returns the stuff bits per second of the frames received on an interface
+func (t *busTrafficTracker) stuffBitRates(ifName string, now time.Time) RollingRates {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if counters, exists := t.interfaces[ifName]; exists {
+		return counters.stuffBits.Rates(now)
+	}
+	return RollingRates{}
+}
+
+// bitRate returns the received bits per second of an interface over window
+func (t *busTrafficTracker) bitRate(ifName string, now time.Time, window time.Duration) float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if counters, exists := t.interfaces[ifName]; exists {
+		return counters.bits.Rate(now, window)
+	}
+	return 0
+}
+
+// lastFrame returns when an interface last received a frame (zero if never)
+func (t *busTrafficTracker) lastFrame(ifName string) time.Time {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if counters, exists := t.interfaces[ifName]; exists {
+		return counters.lastFrame
+	}
+	return time.Time{}
+}
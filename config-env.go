@@ -0,0 +1,85 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// envExpander resolves ${VAR} and ${VAR:-default} references in config values.
+// A ${VAR} reference to an unset variable expands to an empty string and is recorded
+// so ValidateConfig can report it; ${VAR:-default} falls back to the default when VAR
+// is unset or empty.
+type envExpander struct {
+	lookup     func(string) (string, bool)
+	unresolved map[string]bool
+}
+
+// newEnvExpander creates an expander reading variables through lookup (usually os.LookupEnv)
+func newEnvExpander(lookup func(string) (string, bool)) *envExpander {
+	return &envExpander{
+		lookup:     lookup,
+		unresolved: make(map[string]bool),
+	}
+}
+
+// getenv reads an environment variable and expands references inside its value
+func (e *envExpander) getenv(name string) string {
+	value, _ := e.lookup(name)
+	return e.expand(value)
+}
+
+// expand replaces every ${...} reference in value. Text without a closing brace is kept as is.
+func (e *envExpander) expand(value string) string {
+	if !strings.Contains(value, "${") {
+		return value
+	}
+
+	var result strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+
+		result.WriteString(value[:start])
+		result.WriteString(e.resolve(value[start+2 : end]))
+		value = value[end+1:]
+	}
+	result.WriteString(value)
+
+	return result.String()
+}
+
+// resolve returns the value of a single reference body ("VAR" or "VAR:-default")
+func (e *envExpander) resolve(reference string) string {
+	name, defaultValue, hasDefault := strings.Cut(reference, ":-")
+	name = strings.TrimSpace(name)
+
+	value, set := e.lookup(name)
+	if hasDefault {
+		if !set || value == "" {
+			return defaultValue
+		}
+		return value
+	}
+
+	if !set {
+		e.unresolved[name] = true
+	}
+	return value
+}
+
+// missing returns the referenced variables that were unset and had no default, sorted
+func (e *envExpander) missing() []string {
+	names := make([]string, 0, len(e.unresolved))
+	for name := range e.unresolved {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigError is one problem of a configuration
+type ConfigError struct {
+	Field   string `json:"field"`           // Setting, e.g. "bitrate", "bitrates[can1]" or "interfaces[1].bitrate"
+	Value   string `json:"value,omitempty"` // Offending value as configured
+	Message string `json:"message"`
+}
+
+func (e ConfigError) String() string {
+	if e.Value == "" {
+		return e.Field + ": " + e.Message
+	}
+	return fmt.Sprintf("%s=%s: %s", e.Field, e.Value, e.Message)
+}
+
+// ConfigErrors is every problem found in a configuration. Validation collects them all,
+// so a misconfiguration is fixed in one pass rather than one restart per mistake.
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].String()
+	}
+	problems := make([]string, len(e))
+	for i, problem := range e {
+		problems[i] = problem.String()
+	}
+	return fmt.Sprintf("%d configuration errors: %s", len(e), strings.Join(problems, "; "))
+}
+
+// add records a problem. A nil value records none, for problems not caused by one value.
+func (e *ConfigErrors) add(field string, value interface{}, format string, args ...interface{}) {
+	problem := ConfigError{Field: field, Message: fmt.Sprintf(format, args...)}
+	if value != nil {
+		problem.Value = fmt.Sprint(value)
+	}
+	*e = append(*e, problem)
+}
+
+// err returns the problems as an error, nil when there are none
+func (e ConfigErrors) err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
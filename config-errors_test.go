@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// parseConfigFile parses a configuration from a YAML file holding content, as -config does
+func parseConfigFile(t *testing.T, content string) (*ConfigParser, *Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "can-bridge.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cp := NewConfigParser()
+	config, err := cp.parseConfig(flag.NewFlagSet("can-bridge", flag.ContinueOnError), []string{"-config", path})
+	return cp, config, err
+}
+
+// configErrorFields returns the fields of the problems err reports, failing the test
+// unless it is a ConfigErrors
+func configErrorFields(t *testing.T, err error) []string {
+	t.Helper()
+	var errs ConfigErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("err = %v, want ConfigErrors", err)
+	}
+	fields := make([]string, len(errs))
+	for i, problem := range errs {
+		fields[i] = problem.Field
+	}
+	return fields
+}
+
+func TestConfigFileReportsEveryError(t *testing.T) {
+	_, _, err := parseConfigFile(t, `
+setup:
+  bitrate: 123456
+  sample_point: "1.5"
+integrations:
+  webhooks:
+    min_severity: urgent
+interfaces:
+  - name: can0
+    setup_delay: soon
+    watchdog:
+      failure_threshold: 0
+  - name: ""
+    tx_gap_us: -5
+  - name: can0
+    bitrate: 250000
+    fd: true
+`)
+
+	want := []string{
+		"setup.bitrate",
+		"setup.sample_point",
+		"integrations.webhooks.min_severity",
+		"interfaces[0].setup_delay",
+		"interfaces[0].watchdog.failure_threshold",
+		"interfaces[1].name",
+		"interfaces[1].tx_gap_us",
+		"interfaces[2].name",
+		"interfaces[2].fd",
+	}
+	if fields := configErrorFields(t, err); !reflect.DeepEqual(fields, want) {
+		t.Errorf("%d errors at %q,\nwant %d at %q", len(fields), fields, len(want), want)
+	}
+	if !strings.Contains(err.Error(), "9 configuration errors: setup.bitrate=123456: not a standard CAN bitrate") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestConfigFileValuesReportEveryError(t *testing.T) {
+	cp, config, err := parseConfigFile(t, `
+server:
+  port: "70000"
+setup:
+  retry_backoff: 0.5
+watchdog:
+  recovery_strategy: linear
+  failure_threshold: 0
+interfaces:
+  - name: can0
+  - name: can1
+    j1939_address: 300
+`)
+	if err != nil {
+		t.Fatalf("the file itself is valid: %v", err)
+	}
+
+	// Values that stand in for flags are named after the flags
+	err = cp.ValidateConfig(config)
+	want := []string{
+		"j1939",
+		"port",
+		"setup-retry-backoff",
+		"watchdog-failure-threshold",
+		"recovery-strategy",
+	}
+	if fields := configErrorFields(t, err); !reflect.DeepEqual(fields, want) {
+		t.Errorf("%d errors at %q,\nwant %d at %q", len(fields), fields, len(want), want)
+	}
+}
+
+func TestConfigErrorsMessage(t *testing.T) {
+	var errs ConfigErrors
+	if errs.err() != nil {
+		t.Fatal("no problems is not an error")
+	}
+
+	errs.add("bitrate", 123, "not a standard CAN bitrate")
+	if got, want := errs.err().Error(), "bitrate=123: not a standard CAN bitrate"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	errs.add("can-ports", nil, "at least one CAN port must be specified")
+	want := "2 configuration errors: bitrate=123: not a standard CAN bitrate; can-ports: at least one CAN port must be specified"
+	if got := errs.err().Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,709 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the YAML file given with -config or CONFIG_FILE. Its settings stand in
+// for the flags of the same names, in the same units; flags given on the command line
+// and environment variables override them. Unset settings keep the flag defaults.
+type ConfigFile struct {
+	Path string `yaml:"-"`
+
+	Server       ServerFileConfig         `yaml:"server"`
+	Setup        SetupFileConfig          `yaml:"setup"` // Defaults of every interface
+	Interfaces   []InterfaceFileConfig    `yaml:"interfaces"`
+	Watchdog     WatchdogFileConfig       `yaml:"watchdog"`
+	Logging      LoggingFileConfig        `yaml:"logging"`
+	Integrations IntegrationsFileConfig   `yaml:"integrations"`
+	Messages     map[string]*NamedMessage `yaml:"messages"`    // Frames sendable by name
+	OnStartup    []SequenceStep           `yaml:"on_startup"`  // Frames sent once interfaces are initialized
+	OnShutdown   []SequenceStep           `yaml:"on_shutdown"` // Frames sent on shutdown, before interfaces are closed
+}
+
+// ServerFileConfig holds the listeners, access control and shutdown settings
+type ServerFileConfig struct {
+	Port                 *string           `yaml:"port"`
+	ListenUnix           *string           `yaml:"listen_unix"`
+	IPCSocket            *string           `yaml:"ipc_socket"`
+	UnixSocketMode       *string           `yaml:"unix_socket_mode"`
+	UnixSocketOwner      *string           `yaml:"unix_socket_owner"`
+	UnixSocketTrusted    *bool             `yaml:"unix_socket_trusted"`
+	TLSCert              *string           `yaml:"tls_cert"`
+	TLSKey               *string           `yaml:"tls_key"`
+	TLSClientCA          *string           `yaml:"tls_client_ca"`
+	TLSClientPermissions map[string]string `yaml:"tls_client_permissions"` // CN or SAN to read or full
+	APIKeys              *string           `yaml:"api_keys"`               // JSON file of keys and roles
+	AllowedNetworks      []string          `yaml:"allowed_networks"`
+	TrustedProxies       []string          `yaml:"trusted_proxies"`
+	CORS                 CORSFileConfig    `yaml:"cors"`
+	APIDocs              *bool             `yaml:"api_docs"`
+	LegacyAPIRoutes      *bool             `yaml:"legacy_api_routes"`
+	EnableHealthCheck    *bool             `yaml:"enable_healthcheck"`
+	DefaultInterface     *string           `yaml:"default_interface"`
+	DryRun               *bool             `yaml:"dry_run"`
+	DrainTimeout         *int              `yaml:"drain_timeout"`    // Seconds
+	ShutdownTimeout      *int              `yaml:"shutdown_timeout"` // Seconds
+	IdempotencyCacheSize *int              `yaml:"idempotency_cache_size"`
+	IdempotencyTTL       *int              `yaml:"idempotency_ttl"` // Seconds
+	MaxBodySize          *int64            `yaml:"max_body_size"`   // Bytes
+	MaxBatchFrames       *int              `yaml:"max_batch_frames"`
+}
+
+// CORSFileConfig is the cross-origin policy
+type CORSFileConfig struct {
+	Origins     []string `yaml:"origins"`
+	Methods     []string `yaml:"methods"`
+	Headers     []string `yaml:"headers"`
+	Credentials *bool    `yaml:"credentials"`
+	MaxAge      *int     `yaml:"max_age"` // Seconds
+}
+
+// SetupFileConfig holds the interface setup defaults
+type SetupFileConfig struct {
+	AutoSetup           *bool             `yaml:"auto_setup"`
+	TeardownOnExit      *bool             `yaml:"teardown_on_exit"`
+	Bitrate             *int              `yaml:"bitrate"`
+	SamplePoint         *string           `yaml:"sample_point"`
+	RestartMs           *int              `yaml:"restart_ms"`
+	Retry               *int              `yaml:"retry"`
+	Delay               *int              `yaml:"delay"` // Seconds
+	RetryBackoff        *float64          `yaml:"retry_backoff"`
+	MaxDelay            *int              `yaml:"max_delay"`       // Seconds
+	CommandTimeout      *int              `yaml:"command_timeout"` // Seconds
+	EnableFinder        *bool             `yaml:"enable_finder"`
+	FinderInterval      *int              `yaml:"finder_interval"` // Seconds
+	TxConfirmTimeoutMs  *int              `yaml:"tx_confirm_timeout_ms"`
+	RcvbufSize          *int              `yaml:"rcvbuf_size"`
+	TxGapUs             *int              `yaml:"tx_gap_us"`
+	TxQueuePolicy       *string           `yaml:"tx_queue_policy"`   // strict or weighted
+	TxQueueWeights      map[string]string `yaml:"tx_queue_weights"`  // Band to weight, e.g. bulk: 1
+	TxQueueCapacity     map[string]string `yaml:"tx_queue_capacity"` // Band to waiting sends
+	TxRetryAttempts     *int              `yaml:"tx_retry_attempts"`
+	TxRetryDeadlineMs   *int              `yaml:"tx_retry_deadline_ms"`
+	TxRetryPoll         *bool             `yaml:"tx_retry_poll"`
+	ErrorBurstThreshold *int              `yaml:"error_burst_threshold"`
+}
+
+// InterfaceFileConfig is a CAN interface. The interfaces listed make up -can-ports, and
+// their settings override the setup and watchdog defaults for them.
+type InterfaceFileConfig struct {
+	Name           string                      `yaml:"name"`
+	Bitrate        *int                        `yaml:"bitrate"`
+	SamplePoint    *string                     `yaml:"sample_point"`
+	TripleSampling bool                        `yaml:"triple_sampling"`
+	OneShot        bool                        `yaml:"one_shot"` // Controller does not retransmit frames
+	Capture        bool                        `yaml:"capture"`  // Frames are captured from startup, see logging.capture_dir
+	SetupRetries   *int                        `yaml:"setup_retries"`
+	SetupDelay     *string                     `yaml:"setup_delay"`    // Duration, e.g. 5s
+	ExpectTraffic  *string                     `yaml:"expect_traffic"` // Duration, e.g. 5s
+	RcvbufSize     *int                        `yaml:"rcvbuf_size"`
+	TxGapUs        *int                        `yaml:"tx_gap_us"`     // Minimum inter-frame gap of sends
+	J1939Address   *int                        `yaml:"j1939_address"` // J1939 mode with this source address, e.g. 0x80
+	Aliases        []string                    `yaml:"aliases"`       // Logical names the API accepts for the interface
+	BufferIDs      []string                    `yaml:"buffer_ids"`    // IDs kept in the receive buffer, e.g. 0x100-0x1FF
+	Watchdog       InterfaceWatchdogFileConfig `yaml:"watchdog"`
+
+	// Not supported by the interface setup yet; accepted only when unset or false so a
+	// file written for them fails instead of silently configuring something else
+	FD         bool `yaml:"fd"`
+	ListenOnly bool `yaml:"listen_only"`
+}
+
+// InterfaceWatchdogFileConfig overrides the watchdog for one interface
+type InterfaceWatchdogFileConfig struct {
+	Interval         *string `yaml:"interval"` // Duration, e.g. 500ms
+	FailureThreshold *int    `yaml:"failure_threshold"`
+	SuccessThreshold *int    `yaml:"success_threshold"`
+	Cooldown         *string `yaml:"cooldown"` // Duration, e.g. 30s
+}
+
+// WatchdogFileConfig holds the watchdog and probe settings
+type WatchdogFileConfig struct {
+	IntervalMs          *int    `yaml:"interval_ms"`
+	FailureThreshold    *int    `yaml:"failure_threshold"`
+	SuccessThreshold    *int    `yaml:"success_threshold"`
+	Cooldown            *int    `yaml:"cooldown"`            // Seconds
+	RecoveryBaseDelay   *int    `yaml:"recovery_base_delay"` // Seconds
+	RecoveryMaxDelay    *int    `yaml:"recovery_max_delay"`  // Seconds
+	RecoveryStrategy    *string `yaml:"recovery_strategy"`   // exponential or fixed
+	RecoveryMaxAttempts *int    `yaml:"recovery_max_attempts"`
+	ReadyRequiresAll    *bool   `yaml:"ready_requires_all"`
+	LivenessTimeout     *int    `yaml:"liveness_timeout"` // Seconds
+}
+
+// LoggingFileConfig holds the log levels and the files events are recorded in
+type LoggingFileConfig struct {
+	Level               *string           `yaml:"level"`
+	Components          map[string]string `yaml:"components"` // Component to level, e.g. watchdog: warn
+	Format              *string           `yaml:"format"`
+	NoEmoji             *bool             `yaml:"no_emoji"`
+	TxFrames            *bool             `yaml:"tx_frames"`        // Log every frame sent at info level
+	RateLimit           *int              `yaml:"rate_limit"`       // Identical lines per second
+	SummaryInterval     *int              `yaml:"summary_interval"` // Seconds
+	SendAuditLog        *string           `yaml:"send_audit_log"`
+	AuditLog            *string           `yaml:"audit_log"`
+	AuditLogMaxSize     *int64            `yaml:"audit_log_max_size"` // Bytes
+	AuditLogMaxAge      *int              `yaml:"audit_log_max_age"`  // Hours
+	AuditLogMaxFiles    *int              `yaml:"audit_log_max_files"`
+	AuditLogCompress    *bool             `yaml:"audit_log_compress"`
+	CaptureDir          *string           `yaml:"capture_dir"`
+	CaptureTX           *bool             `yaml:"capture_tx"`
+	CaptureTXContext    *bool             `yaml:"capture_tx_context"`     // Sidecar with the request, principal and job of sent frames
+	CaptureMaxFileSize  *int64            `yaml:"capture_max_file_size"`  // Bytes
+	CaptureMaxTotalSize *int64            `yaml:"capture_max_total_size"` // Bytes
+	CaptureMaxAge       *int              `yaml:"capture_max_age"`        // Hours
+	CaptureMaxFiles     *int              `yaml:"capture_max_files"`
+	CaptureCompress     *bool             `yaml:"capture_compress"`
+	AccessSkipPaths     []string          `yaml:"access_skip_paths"` // Path prefixes left out of the access log
+	AccessSampleRate    *float64          `yaml:"access_sample_rate"`
+	AccessSlowMs        *int64            `yaml:"access_slow_ms"`
+	Output              []string          `yaml:"output"` // stderr, stdout, journald, syslog
+	SyslogAddress       *string           `yaml:"syslog_address"`
+	SyslogFacility      *string           `yaml:"syslog_facility"`
+	File                *string           `yaml:"file"`     // Service log file
+	MaxSize             *int64            `yaml:"max_size"` // Bytes
+	MaxAge              *int              `yaml:"max_age"`  // Hours
+	MaxFiles            *int              `yaml:"max_files"`
+	Compress            *bool             `yaml:"compress"`
+	BlackboxDir         *string           `yaml:"blackbox_dir"`
+	BlackboxMaxFiles    *int              `yaml:"blackbox_max_files"`
+	WatchdogEventLog    *string           `yaml:"watchdog_event_log"`
+}
+
+// IntegrationsFileConfig holds notifications and the files of other subsystems
+type IntegrationsFileConfig struct {
+	InstanceName   *string           `yaml:"instance_name"`
+	Webhooks       WebhookFileConfig `yaml:"webhooks"`
+	AlertRules     *string           `yaml:"alert_rules"`     // JSON file
+	SimulatedNodes *string           `yaml:"simulated_nodes"` // JSON file
+	DBC            *string           `yaml:"dbc"`
+	StatsD         StatsDFileConfig  `yaml:"statsd"`
+}
+
+// StatsDFileConfig holds the StatsD push settings
+type StatsDFileConfig struct {
+	Address  *string `yaml:"address"`
+	Interval *int    `yaml:"interval"` // Seconds
+	Prefix   *string `yaml:"prefix"`
+	Format   *string `yaml:"format"`
+}
+
+// WebhookFileConfig holds the webhook notification settings
+type WebhookFileConfig struct {
+	URLs        []string `yaml:"urls"`
+	Events      []string `yaml:"events"`
+	MinSeverity *string  `yaml:"min_severity"`
+}
+
+// LoadConfigFile reads a YAML configuration file. Unknown keys are errors, so a typo
+// does not silently leave a setting at its default.
+func LoadConfigFile(path string) (*ConfigFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	file, err := decodeConfigFile(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// decodeConfigFile decodes the YAML (or JSON) of a configuration file read from path
+func decodeConfigFile(path string, data []byte) (*ConfigFile, error) {
+	file := &ConfigFile{Path: path}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(file); err != nil && !errors.Is(err, io.EOF) {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			err = describeYAMLErrors(data, typeErr)
+		}
+		return nil, err
+	}
+	return file, nil
+}
+
+// writeConfigFile replaces the configuration file atomically: data is written to a
+// temporary file in the same directory, which is renamed over path. The mode of the
+// file replaced is kept.
+func writeConfigFile(path string, data []byte) error {
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir, name := filepath.Split(path)
+	temp, err := os.CreateTemp(dir, "."+name+".tmp*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary config file: %w", err)
+	}
+	defer os.Remove(temp.Name()) // Fails harmlessly once renamed
+
+	if _, err = temp.Write(data); err == nil {
+		err = temp.Sync()
+	}
+	if err == nil {
+		err = temp.Chmod(mode)
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write temporary config file: %w", err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace config file: %w", err)
+	}
+	return nil
+}
+
+// Decoding errors of yaml.v3, which name lines and Go types
+var (
+	yamlErrorPattern        = regexp.MustCompile(`^line (\d+): (.*)$`)
+	yamlUnknownFieldPattern = regexp.MustCompile(`^field (\S+) not found in type \S+$`)
+	yamlUnmarshalPattern    = regexp.MustCompile("^cannot unmarshal \\S+ (?:`(.*)` )?into (\\S+)$")
+)
+
+// yamlField is a key of a file with the scalar value it holds
+type yamlField struct {
+	path  string // e.g. interfaces[1].bitrate
+	key   string
+	value string
+}
+
+// collectYAMLFields records the field paths found on each line of a file
+func collectYAMLFields(node *yaml.Node, path string, fields map[int][]yamlField) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			collectYAMLFields(child, path, fields)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			field := yamlField{path: key.Value, key: key.Value, value: value.Value}
+			if path != "" {
+				field.path = path + "." + key.Value
+			}
+			fields[key.Line] = append(fields[key.Line], field)
+			if value.Line != key.Line {
+				fields[value.Line] = append(fields[value.Line], field)
+			}
+			collectYAMLFields(value, field.path, fields)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			itemPath := fmt.Sprintf("%s[%d]", path, i)
+			if child.Kind == yaml.ScalarNode {
+				fields[child.Line] = append(fields[child.Line], yamlField{path: itemPath, value: child.Value})
+			}
+			collectYAMLFields(child, itemPath, fields)
+		}
+	}
+}
+
+// describeYAMLErrors rewrites decoding errors to name the offending fields instead of
+// lines, e.g. "server.prot: unknown field" or "setup.bitrate: invalid value "fast",
+// expected int"
+func describeYAMLErrors(data []byte, typeErr *yaml.TypeError) error {
+	var root yaml.Node
+	if yaml.Unmarshal(data, &root) != nil {
+		return typeErr
+	}
+	fields := make(map[int][]yamlField)
+	collectYAMLFields(&root, "", fields)
+
+	messages := make([]string, 0, len(typeErr.Errors))
+	for _, message := range typeErr.Errors {
+		messages = append(messages, describeYAMLError(message, fields))
+	}
+	return errors.New(strings.Join(messages, "; "))
+}
+
+func describeYAMLError(message string, fields map[int][]yamlField) string {
+	match := yamlErrorPattern.FindStringSubmatch(message)
+	if match == nil {
+		return message
+	}
+	line, _ := strconv.Atoi(match[1])
+	candidates := fields[line]
+	if len(candidates) == 0 {
+		return message
+	}
+
+	// Several fields share a line in flow style such as {name: can0, bitrate: 5}
+	pick := func(matches func(yamlField) bool) string {
+		for _, field := range candidates {
+			if matches(field) {
+				return field.path
+			}
+		}
+		return candidates[0].path
+	}
+
+	if unknown := yamlUnknownFieldPattern.FindStringSubmatch(match[2]); unknown != nil {
+		return pick(func(f yamlField) bool { return f.key == unknown[1] }) + ": unknown field"
+	}
+	if invalid := yamlUnmarshalPattern.FindStringSubmatch(match[2]); invalid != nil {
+		expected := strings.TrimPrefix(invalid[2], "*")
+		switch {
+		case strings.HasPrefix(expected, "[]"):
+			expected = "a list"
+		case strings.HasPrefix(expected, "map[") || strings.HasPrefix(expected, "main."):
+			expected = "a mapping"
+		}
+		path := pick(func(f yamlField) bool { return f.value == invalid[1] })
+		if invalid[1] == "" {
+			return fmt.Sprintf("%s: expected %s", path, expected)
+		}
+		return fmt.Sprintf("%s: invalid value %q, expected %s", path, invalid[1], expected)
+	}
+	return candidates[0].path + ": " + match[2]
+}
+
+// validateConfigFile checks what the flags cannot express, naming the offending field,
+// e.g. "interfaces[1].bitrate". Values that map to a single flag are checked with the
+// rest of the configuration.
+func (cp *ConfigParser) validateConfigFile(file *ConfigFile) error {
+	var errs ConfigErrors
+	if file.Setup.Bitrate != nil && !isValidBitrate(*file.Setup.Bitrate) {
+		errs.add("setup.bitrate", *file.Setup.Bitrate, "not a standard CAN bitrate. Valid options: %v", validBitrates)
+	}
+	if file.Setup.SamplePoint != nil {
+		if err := validateSamplePoint(*file.Setup.SamplePoint); err != nil {
+			errs.add("setup.sample_point", *file.Setup.SamplePoint, "%v", err)
+		}
+	}
+	if severity := file.Integrations.Webhooks.MinSeverity; severity != nil {
+		if _, ok := severityRank[*severity]; !ok {
+			errs.add("integrations.webhooks.min_severity", *severity, "invalid severity. Valid options: info, warning, critical")
+		}
+	}
+
+	for name, message := range file.Messages {
+		if message == nil {
+			errs.add("messages."+name, nil, "is required")
+		}
+	}
+
+	seen := make(map[string]int)
+	for i, iface := range file.Interfaces {
+		path := fmt.Sprintf("interfaces[%d]", i)
+		switch first, exists := seen[iface.Name]; {
+		case iface.Name == "":
+			errs.add(path+".name", nil, "is required")
+		case strings.ContainsAny(iface.Name, ",= \t"):
+			errs.add(path+".name", iface.Name, "invalid interface name")
+		case exists && !reflect.DeepEqual(iface, file.Interfaces[first]):
+			errs.add(path+".name", iface.Name, "already configured by interfaces[%d], with conflicting settings; merge the two entries", first)
+		case exists:
+			errs.add(path+".name", iface.Name, "already configured by interfaces[%d]", first)
+		default:
+			seen[iface.Name] = i
+		}
+
+		if iface.Bitrate != nil && !isValidBitrate(*iface.Bitrate) {
+			errs.add(path+".bitrate", *iface.Bitrate, "not a standard CAN bitrate. Valid options: %v", validBitrates)
+		}
+		if iface.SamplePoint != nil {
+			if err := validateSamplePoint(*iface.SamplePoint); err != nil {
+				errs.add(path+".sample_point", *iface.SamplePoint, "%v", err)
+			}
+		}
+		if iface.SetupRetries != nil && *iface.SetupRetries < 1 {
+			errs.add(path+".setup_retries", *iface.SetupRetries, "must be at least 1")
+		}
+		if iface.RcvbufSize != nil && *iface.RcvbufSize < 0 {
+			errs.add(path+".rcvbuf_size", *iface.RcvbufSize, "must not be negative")
+		}
+		if iface.TxGapUs != nil && *iface.TxGapUs < 0 {
+			errs.add(path+".tx_gap_us", *iface.TxGapUs, "must not be negative")
+		}
+		for _, duration := range []struct {
+			field string
+			value *string
+		}{
+			{"setup_delay", iface.SetupDelay},
+			{"expect_traffic", iface.ExpectTraffic},
+			{"watchdog.interval", iface.Watchdog.Interval},
+			{"watchdog.cooldown", iface.Watchdog.Cooldown},
+		} {
+			if duration.value == nil {
+				continue
+			}
+			if d, err := time.ParseDuration(*duration.value); err != nil || d < 0 {
+				errs.add(path+"."+duration.field, *duration.value, "invalid duration, expected e.g. 500ms or 5s")
+			}
+		}
+		if threshold := iface.Watchdog.FailureThreshold; threshold != nil && *threshold < 1 {
+			errs.add(path+".watchdog.failure_threshold", *threshold, "must be at least 1")
+		}
+		if threshold := iface.Watchdog.SuccessThreshold; threshold != nil && *threshold < 1 {
+			errs.add(path+".watchdog.success_threshold", *threshold, "must be at least 1")
+		}
+
+		if iface.FD {
+			errs.add(path+".fd", true, "CAN FD setup is not supported")
+		}
+		if iface.ListenOnly {
+			errs.add(path+".listen_only", true, "listen-only setup is not supported; disable transmission with POST /api/v1/interfaces/%s/tx instead", iface.Name)
+		}
+	}
+
+	if err := errs.err(); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", file.Path, err)
+	}
+	return nil
+}
+
+// fileFlags collects the flag values a config file sets
+type fileFlags map[string]string
+
+// setFileFlag records a value when the file sets it
+func setFileFlag[T any](flags fileFlags, name string, value *T) {
+	if value != nil {
+		flags[name] = fmt.Sprint(*value)
+	}
+}
+
+// setList records a comma-separated list when the file sets it
+func (f fileFlags) setList(name string, values []string) {
+	if values != nil {
+		f[name] = strings.Join(values, ",")
+	}
+}
+
+// setPairs records name=value pairs, sorted for a stable result
+func (f fileFlags) setPairs(name string, values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+	pairs := make([]string, 0, len(values))
+	for key, value := range values {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	f[name] = strings.Join(pairs, ",")
+}
+
+// flags returns the values of the flags the file sets, by flag name
+func (file *ConfigFile) flags() fileFlags {
+	flags := make(fileFlags)
+
+	server := file.Server
+	setFileFlag(flags, "port", server.Port)
+	setFileFlag(flags, "listen-unix", server.ListenUnix)
+	setFileFlag(flags, "ipc-socket", server.IPCSocket)
+	setFileFlag(flags, "unix-socket-mode", server.UnixSocketMode)
+	setFileFlag(flags, "unix-socket-owner", server.UnixSocketOwner)
+	setFileFlag(flags, "unix-socket-trusted", server.UnixSocketTrusted)
+	setFileFlag(flags, "tls-cert", server.TLSCert)
+	setFileFlag(flags, "tls-key", server.TLSKey)
+	setFileFlag(flags, "tls-client-ca", server.TLSClientCA)
+	flags.setPairs("tls-client-permissions", server.TLSClientPermissions)
+	setFileFlag(flags, "api-keys", server.APIKeys)
+	flags.setList("allowed-networks", server.AllowedNetworks)
+	flags.setList("trusted-proxies", server.TrustedProxies)
+	flags.setList("cors-origins", server.CORS.Origins)
+	flags.setList("cors-methods", server.CORS.Methods)
+	flags.setList("cors-headers", server.CORS.Headers)
+	setFileFlag(flags, "cors-credentials", server.CORS.Credentials)
+	setFileFlag(flags, "cors-max-age", server.CORS.MaxAge)
+	setFileFlag(flags, "api-docs", server.APIDocs)
+	setFileFlag(flags, "legacy-api-routes", server.LegacyAPIRoutes)
+	setFileFlag(flags, "enable-healthcheck", server.EnableHealthCheck)
+	setFileFlag(flags, "default-interface", server.DefaultInterface)
+	setFileFlag(flags, "dry-run", server.DryRun)
+	setFileFlag(flags, "drain-timeout", server.DrainTimeout)
+	setFileFlag(flags, "shutdown-timeout", server.ShutdownTimeout)
+	setFileFlag(flags, "idempotency-cache-size", server.IdempotencyCacheSize)
+	setFileFlag(flags, "idempotency-ttl", server.IdempotencyTTL)
+	setFileFlag(flags, "max-body-size", server.MaxBodySize)
+	setFileFlag(flags, "max-batch-frames", server.MaxBatchFrames)
+
+	setup := file.Setup
+	setFileFlag(flags, "auto-setup", setup.AutoSetup)
+	setFileFlag(flags, "teardown-on-exit", setup.TeardownOnExit)
+	setFileFlag(flags, "bitrate", setup.Bitrate)
+	setFileFlag(flags, "sample-point", setup.SamplePoint)
+	setFileFlag(flags, "restart-ms", setup.RestartMs)
+	setFileFlag(flags, "setup-retry", setup.Retry)
+	setFileFlag(flags, "setup-delay", setup.Delay)
+	setFileFlag(flags, "setup-retry-backoff", setup.RetryBackoff)
+	setFileFlag(flags, "setup-max-delay", setup.MaxDelay)
+	setFileFlag(flags, "command-timeout", setup.CommandTimeout)
+	setFileFlag(flags, "enable-finder", setup.EnableFinder)
+	setFileFlag(flags, "finder-interval", setup.FinderInterval)
+	setFileFlag(flags, "tx-confirm-timeout-ms", setup.TxConfirmTimeoutMs)
+	setFileFlag(flags, "rcvbuf-size", setup.RcvbufSize)
+	setFileFlag(flags, "tx-gap-us", setup.TxGapUs)
+	setFileFlag(flags, "tx-queue-policy", setup.TxQueuePolicy)
+	flags.setPairs("tx-queue-weights", setup.TxQueueWeights)
+	flags.setPairs("tx-queue-capacity", setup.TxQueueCapacity)
+	setFileFlag(flags, "tx-retry-attempts", setup.TxRetryAttempts)
+	setFileFlag(flags, "tx-retry-deadline-ms", setup.TxRetryDeadlineMs)
+	setFileFlag(flags, "tx-retry-poll", setup.TxRetryPoll)
+	setFileFlag(flags, "error-burst-threshold", setup.ErrorBurstThreshold)
+
+	if len(file.Interfaces) > 0 {
+		names := make([]string, 0, len(file.Interfaces))
+		perInterface := make(map[string]map[string]string)
+		var tripleSampling, oneShot, capture, aliases, bufferIDs []string
+		add := func(flag, ifName string, value *string) {
+			if value == nil {
+				return
+			}
+			if perInterface[flag] == nil {
+				perInterface[flag] = make(map[string]string)
+			}
+			perInterface[flag][ifName] = *value
+		}
+		for _, iface := range file.Interfaces {
+			names = append(names, iface.Name)
+			add("bitrates", iface.Name, formatFileValue(iface.Bitrate))
+			add("sample-points", iface.Name, iface.SamplePoint)
+			add("setup-retries", iface.Name, formatFileValue(iface.SetupRetries))
+			add("setup-delays", iface.Name, iface.SetupDelay)
+			add("expect-traffic", iface.Name, iface.ExpectTraffic)
+			add("rcvbuf-sizes", iface.Name, formatFileValue(iface.RcvbufSize))
+			add("tx-gaps-us", iface.Name, formatFileValue(iface.TxGapUs))
+			add("j1939", iface.Name, formatFileValue(iface.J1939Address))
+			add("watchdog-intervals", iface.Name, iface.Watchdog.Interval)
+			add("watchdog-failure-thresholds", iface.Name, formatFileValue(iface.Watchdog.FailureThreshold))
+			add("watchdog-success-thresholds", iface.Name, formatFileValue(iface.Watchdog.SuccessThreshold))
+			add("watchdog-cooldowns", iface.Name, iface.Watchdog.Cooldown)
+			if iface.TripleSampling {
+				tripleSampling = append(tripleSampling, iface.Name)
+			}
+			if iface.OneShot {
+				oneShot = append(oneShot, iface.Name)
+			}
+			if iface.Capture {
+				capture = append(capture, iface.Name)
+			}
+			for _, alias := range iface.Aliases {
+				aliases = append(aliases, alias+"="+iface.Name)
+			}
+			for _, id := range iface.BufferIDs {
+				bufferIDs = append(bufferIDs, iface.Name+"="+id)
+			}
+		}
+		flags.setList("can-ports", names)
+		flags.setList("triple-sampling", tripleSampling)
+		flags.setList("one-shot", oneShot)
+		flags.setList("capture", capture)
+		flags.setList("interface-aliases", aliases)
+		flags.setList("buffer-ids", bufferIDs)
+		for name, values := range perInterface {
+			flags.setPairs(name, values)
+		}
+	}
+
+	watchdog := file.Watchdog
+	setFileFlag(flags, "watchdog-interval-ms", watchdog.IntervalMs)
+	setFileFlag(flags, "watchdog-failure-threshold", watchdog.FailureThreshold)
+	setFileFlag(flags, "watchdog-success-threshold", watchdog.SuccessThreshold)
+	setFileFlag(flags, "watchdog-cooldown", watchdog.Cooldown)
+	setFileFlag(flags, "recovery-base-delay", watchdog.RecoveryBaseDelay)
+	setFileFlag(flags, "recovery-max-delay", watchdog.RecoveryMaxDelay)
+	setFileFlag(flags, "recovery-strategy", watchdog.RecoveryStrategy)
+	setFileFlag(flags, "recovery-max-attempts", watchdog.RecoveryMaxAttempts)
+	setFileFlag(flags, "ready-requires-all", watchdog.ReadyRequiresAll)
+	setFileFlag(flags, "liveness-timeout", watchdog.LivenessTimeout)
+
+	setFileFlag(flags, "log-level", file.Logging.Level)
+	flags.setPairs("log-levels", file.Logging.Components)
+	setFileFlag(flags, "log-format", file.Logging.Format)
+	setFileFlag(flags, "log-no-emoji", file.Logging.NoEmoji)
+	setFileFlag(flags, "log-tx-frames", file.Logging.TxFrames)
+	setFileFlag(flags, "log-rate-limit", file.Logging.RateLimit)
+	setFileFlag(flags, "log-summary-interval", file.Logging.SummaryInterval)
+	flags.setList("log-access-skip", file.Logging.AccessSkipPaths)
+	setFileFlag(flags, "log-access-sample-rate", file.Logging.AccessSampleRate)
+	setFileFlag(flags, "log-access-slow-ms", file.Logging.AccessSlowMs)
+	flags.setList("log-output", file.Logging.Output)
+	setFileFlag(flags, "syslog-address", file.Logging.SyslogAddress)
+	setFileFlag(flags, "syslog-facility", file.Logging.SyslogFacility)
+	setFileFlag(flags, "send-audit-log", file.Logging.SendAuditLog)
+	setFileFlag(flags, "audit-log", file.Logging.AuditLog)
+	setFileFlag(flags, "audit-log-max-size", file.Logging.AuditLogMaxSize)
+	setFileFlag(flags, "audit-log-max-age", file.Logging.AuditLogMaxAge)
+	setFileFlag(flags, "audit-log-max-files", file.Logging.AuditLogMaxFiles)
+	setFileFlag(flags, "audit-log-compress", file.Logging.AuditLogCompress)
+	setFileFlag(flags, "capture-dir", file.Logging.CaptureDir)
+	setFileFlag(flags, "capture-tx", file.Logging.CaptureTX)
+	setFileFlag(flags, "capture-tx-context", file.Logging.CaptureTXContext)
+	setFileFlag(flags, "capture-max-file-size", file.Logging.CaptureMaxFileSize)
+	setFileFlag(flags, "capture-max-total-size", file.Logging.CaptureMaxTotalSize)
+	setFileFlag(flags, "capture-max-age", file.Logging.CaptureMaxAge)
+	setFileFlag(flags, "capture-max-files", file.Logging.CaptureMaxFiles)
+	setFileFlag(flags, "capture-compress", file.Logging.CaptureCompress)
+	setFileFlag(flags, "log-file", file.Logging.File)
+	setFileFlag(flags, "log-max-size", file.Logging.MaxSize)
+	setFileFlag(flags, "log-max-age", file.Logging.MaxAge)
+	setFileFlag(flags, "log-max-files", file.Logging.MaxFiles)
+	setFileFlag(flags, "log-compress", file.Logging.Compress)
+	setFileFlag(flags, "blackbox-dir", file.Logging.BlackboxDir)
+	setFileFlag(flags, "blackbox-max-files", file.Logging.BlackboxMaxFiles)
+	setFileFlag(flags, "watchdog-event-log", file.Logging.WatchdogEventLog)
+
+	integrations := file.Integrations
+	setFileFlag(flags, "instance-name", integrations.InstanceName)
+	flags.setList("webhook-urls", integrations.Webhooks.URLs)
+	flags.setList("webhook-events", integrations.Webhooks.Events)
+	setFileFlag(flags, "webhook-min-severity", integrations.Webhooks.MinSeverity)
+	setFileFlag(flags, "alert-rules", integrations.AlertRules)
+	setFileFlag(flags, "simulated-nodes", integrations.SimulatedNodes)
+	setFileFlag(flags, "dbc", integrations.DBC)
+	setFileFlag(flags, "statsd-address", integrations.StatsD.Address)
+	setFileFlag(flags, "statsd-interval", integrations.StatsD.Interval)
+	setFileFlag(flags, "statsd-prefix", integrations.StatsD.Prefix)
+	setFileFlag(flags, "statsd-format", integrations.StatsD.Format)
+
+	return flags
+}
+
+// formatFileValue formats an optional number, keeping nil for unset
+func formatFileValue(value *int) *string {
+	if value == nil {
+		return nil
+	}
+	s := fmt.Sprint(*value)
+	return &s
+}
+
+// applyConfigFile sets the flags the file configures, except those given on the command
+// line, so the command line keeps precedence over the file
+func applyConfigFile(flags *flag.FlagSet, file *ConfigFile) error {
+	explicit := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	for name, value := range file.flags() {
+		if explicit[name] {
+			continue
+		}
+		if err := flags.Set(name, value); err != nil {
+			return fmt.Errorf("invalid config file %s: %s: %w", file.Path, name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,519 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Reload errors
+var (
+	ErrInvalidConfig  = errors.New("invalid configuration")               // The configuration does not parse or validate
+	ErrConfigModified = errors.New("configuration modified")              // If-Match names an older configuration
+	ErrNoConfigFile   = errors.New("no configuration file to persist to") // Persisting without -config
+)
+
+// redactedValue stands in for a secret in configuration changes
+const redactedValue = "(redacted)"
+
+// ReloadRequest describes a reload: who asked for it and, for PUT /api/v1/config, the
+// configuration file submitted in place of the one on disk
+type ReloadRequest struct {
+	RequestID  string // API request, empty for SIGHUP
+	Client     string // API identity or signal:SIGHUP, for the audit log
+	RemoteAddr string
+	File       []byte // Configuration file content; nil reads the file on disk
+	Persist    bool   // Write File over the configuration file once validated
+	IfMatch    string // ETag of the configuration the change was based on; empty or * skips the check
+}
+
+// ConfigChange is a setting a reload changed, with its values as in the configuration
+// summary, so secrets stay redacted
+type ConfigChange struct {
+	Setting string      `json:"setting"`
+	Before  interface{} `json:"before"`
+	After   interface{} `json:"after"`
+}
+
+// reloadGroup is how a reload applies a changed setting
+type reloadGroup int
+
+const (
+	reloadRestart    reloadGroup = iota // Needs a restart; the running value is kept
+	reloadIgnored                       // Describes the invocation, not the running service
+	reloadInPlace                       // Read where it is used, so the new value applies as is
+	reloadReopen                        // Applies when an interface socket is next opened
+	reloadInterfaces                    // Interfaces added are set up, interfaces removed torn down
+	reloadSetup                         // Interface setup; bit timing changes bounce interfaces
+	reloadWebhooks                      // Webhook notifier, reconfigured in place
+	reloadAuditLog                      // Send audit log, reopened
+	reloadEventLog                      // Watchdog event log file, reopened
+	reloadLogging                       // Log levels and format, changed in place
+)
+
+// reloadGroups assigns the Config fields a reload can apply to their group. Fields not
+// listed, such as the listen address, TLS and access control, need a restart.
+var reloadGroups = map[string]reloadGroup{
+	"ConfigFile":        reloadIgnored,
+	"ValidateOnly":      reloadIgnored,
+	"UnresolvedEnvVars": reloadIgnored,
+
+	"AutoSetup":        reloadInPlace,
+	"TeardownOnExit":   reloadInPlace,
+	"DryRun":           reloadInPlace,
+	"LogTxFrames":      reloadInPlace,
+	"DefaultInterface": reloadInPlace,
+	"InterfaceAliases": reloadInPlace,
+	"TxGap":            reloadInPlace,
+	"TxGaps":           reloadInPlace,
+	"TxQueue":          reloadInPlace,
+	"TxRetry":          reloadInPlace,
+	"BufferFilters":    reloadInPlace,
+	"DrainTimeout":     reloadInPlace,
+	"ShutdownTimeout":  reloadInPlace,
+
+	"TxConfirmTimeout":   reloadReopen,
+	"ReceiveBufferSize":  reloadReopen,
+	"ReceiveBufferSizes": reloadReopen,
+
+	"CanPorts": reloadInterfaces,
+
+	"Bitrate":           reloadSetup,
+	"Bitrates":          reloadSetup,
+	"SamplePoint":       reloadSetup,
+	"SamplePoints":      reloadSetup,
+	"TripleSampling":    reloadSetup,
+	"OneShot":           reloadSetup,
+	"RestartMs":         reloadSetup,
+	"SetupRetry":        reloadSetup,
+	"SetupDelay":        reloadSetup,
+	"SetupRetryBackoff": reloadSetup,
+	"SetupMaxDelay":     reloadSetup,
+	"SetupRetries":      reloadSetup,
+	"SetupDelays":       reloadSetup,
+
+	"InstanceName":       reloadWebhooks,
+	"WebhookURLs":        reloadWebhooks,
+	"WebhookEvents":      reloadWebhooks,
+	"WebhookMinSeverity": reloadWebhooks,
+
+	"SendAuditLog":     reloadAuditLog,
+	"WatchdogEventLog": reloadEventLog,
+
+	"LogLevel":   reloadLogging,
+	"LogLevels":  reloadLogging,
+	"LogFormat":  reloadLogging,
+	"LogNoEmoji": reloadLogging,
+
+	"AccessLog":          reloadLogging,
+	"LogRateLimit":       reloadLogging,
+	"LogSummaryInterval": reloadLogging,
+}
+
+// ReloadItem is one change a reload found
+type ReloadItem struct {
+	Setting   string `json:"setting"`             // e.g. "bitrates", "canPorts" or "bitTiming"
+	Interface string `json:"interface,omitempty"` // Interface the action was taken on
+	Message   string `json:"message"`
+}
+
+func (i ReloadItem) String() string {
+	if i.Interface == "" {
+		return i.Setting + ": " + i.Message
+	}
+	return fmt.Sprintf("%s[%s]: %s", i.Setting, i.Interface, i.Message)
+}
+
+// ReloadResult reports what a reload did with every changed setting
+type ReloadResult struct {
+	Applied   []ReloadItem   `json:"applied"`
+	Skipped   []ReloadItem   `json:"skipped"`  // Changed, but the action failed; the running value is kept where one exists
+	Rejected  []ReloadItem   `json:"rejected"` // Need a restart; the running value is kept
+	Changes   []ConfigChange `json:"changes"`  // Effective settings before and after
+	Persisted bool           `json:"persisted,omitempty"`
+
+	etag string // Of the configuration in effect afterwards
+}
+
+func (r *ReloadResult) apply(setting, ifName, format string, args ...interface{}) {
+	r.Applied = append(r.Applied, ReloadItem{Setting: setting, Interface: ifName, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *ReloadResult) skip(setting, ifName, format string, args ...interface{}) {
+	r.Skipped = append(r.Skipped, ReloadItem{Setting: setting, Interface: ifName, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *ReloadResult) reject(setting, format string, args ...interface{}) {
+	r.Rejected = append(r.Rejected, ReloadItem{Setting: setting, Message: fmt.Sprintf(format, args...)})
+}
+
+// settingName turns a Config field name into the lower camel case of the configuration
+// summary, e.g. "WebhookURLs" into "webhookURLs" and "TLSCertFile" into "tlsCertFile"
+func settingName(field string) string {
+	runes := []rune(field)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) || (i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// bitTiming describes the bit timing setup gives an interface, e.g.
+// "bitrate 500000, sample point 0.875, restart-ms 100"
+func bitTiming(config InterfaceSetupConfig, ifName string) string {
+	bitrate, ok := config.InterfaceBitrates[ifName]
+	if !ok {
+		bitrate = config.Bitrate
+	}
+	timing := fmt.Sprintf("bitrate %d", bitrate)
+	if samplePoint := config.samplePointFor(ifName); samplePoint != "" {
+		timing += ", sample point " + samplePoint
+	}
+	if config.InterfaceTripleSampling[ifName] {
+		timing += ", triple sampling"
+	}
+	if config.InterfaceOneShot[ifName] {
+		timing += ", one-shot"
+	}
+	return fmt.Sprintf("%s, restart-ms %d", timing, config.RestartMs)
+}
+
+// configChanges lists the settings of the configuration summary that differ between two
+// configurations. Webhook URLs may carry tokens, so only the fact that they changed is.
+func configChanges(old, new *Config) []ConfigChange {
+	configParser := NewConfigParser()
+	before, after := configParser.GetConfigSummary(old), configParser.GetConfigSummary(new)
+
+	var changes []ConfigChange
+	for setting, value := range after {
+		if !reflect.DeepEqual(before[setting], value) {
+			changes = append(changes, ConfigChange{Setting: setting, Before: before[setting], After: value})
+		}
+	}
+	if !reflect.DeepEqual(old.WebhookURLs, new.WebhookURLs) {
+		changes = append(changes, ConfigChange{Setting: "webhookURLs", Before: redactedValue, After: redactedValue})
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Setting < changes[j].Setting })
+	return changes
+}
+
+// configETag identifies the configuration in effect. It changes whenever a reload changes
+// an effective setting, and across restarts. Callers hold reloadMu.
+func (s *Service) configETag() string {
+	return fmt.Sprintf(`"%x-%d"`, s.configEpoch, s.configGeneration)
+}
+
+// EffectiveConfig returns the configuration summary in effect and its ETag
+func (s *Service) EffectiveConfig() (map[string]interface{}, string) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	return NewConfigParser().GetConfigSummary(s.config), s.configETag()
+}
+
+// Reload parses and validates the configuration again and applies what changed. A
+// configuration that does not validate changes nothing. Settings that need a restart keep
+// their running value and are reported as rejected. Effective changes are logged and
+// recorded in the send audit log.
+func (s *Service) Reload(req ReloadRequest) (*ReloadResult, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	if s.stopped {
+		return nil, ErrShuttingDown
+	}
+	if req.IfMatch != "" && req.IfMatch != "*" && req.IfMatch != s.configETag() {
+		return nil, tagError(ErrConfigModified, fmt.Errorf("the configuration changed since it was read: ETag is %s, not %s",
+			s.configETag(), req.IfMatch))
+	}
+	requestID := req.RequestID
+	s.logger.Printf("🔁 Reloading configuration%s", requestIDSuffix(requestID))
+
+	configParser := NewConfigParser()
+	var config *Config
+	var err error
+	if req.File != nil {
+		config, err = configParser.ReparseConfigWith(req.File)
+	} else {
+		config, err = configParser.ReparseConfig()
+	}
+	if err == nil {
+		err = configParser.ValidateConfig(config)
+	}
+	if err == nil {
+		err = setupConfigFor(config).validate()
+	}
+	if err != nil {
+		s.logger.Printf("❌ Configuration reload rejected, keeping the running configuration: %v", err)
+		return nil, tagError(ErrInvalidConfig, err)
+	}
+
+	result := &ReloadResult{Applied: []ReloadItem{}, Skipped: []ReloadItem{}, Rejected: []ReloadItem{}}
+
+	// The file is written before anything is applied, so a failed write changes nothing
+	if req.Persist {
+		if config.ConfigFile == "" {
+			return nil, fmt.Errorf("cannot persist the configuration: %w", ErrNoConfigFile)
+		}
+		if err := writeConfigFile(config.ConfigFile, req.File); err != nil {
+			s.logger.Printf("❌ Configuration not applied: %v", err)
+			return nil, err
+		}
+		result.Persisted = true
+		s.logger.Printf("💾 Configuration written to %s%s", config.ConfigFile, requestIDSuffix(requestID))
+	}
+	old := s.config
+	effective := *config
+
+	// Settings that need a restart keep their running value in the effective configuration
+	changed := make(map[reloadGroup][]string)
+	oldValue, newValue := reflect.ValueOf(old).Elem(), reflect.ValueOf(&effective).Elem()
+	for i := 0; i < newValue.NumField(); i++ {
+		field := newValue.Type().Field(i)
+		if !field.IsExported() || reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			continue
+		}
+		switch group := reloadGroups[field.Name]; group {
+		case reloadIgnored:
+		case reloadRestart:
+			newValue.Field(i).Set(oldValue.Field(i))
+			result.reject(settingName(field.Name), "restart required")
+		default:
+			changed[group] = append(changed[group], settingName(field.Name))
+		}
+	}
+
+	// Logs are reopened first; one that fails to open keeps the running one
+	if len(changed[reloadAuditLog]) > 0 {
+		if err := s.reopenSendAudit(effective.SendAuditLog); err != nil {
+			effective.SendAuditLog = old.SendAuditLog
+			result.skip("sendAuditLog", "", "%v", err)
+		} else {
+			result.apply("sendAuditLog", "", "send audit log reopened")
+		}
+	}
+	if len(changed[reloadEventLog]) > 0 {
+		if err := s.watchdog.SetEventLogFile(effective.WatchdogEventLog); err != nil {
+			effective.WatchdogEventLog = old.WatchdogEventLog
+			result.skip("watchdogEventLog", "", "%v", err)
+		} else {
+			result.apply("watchdogEventLog", "", "watchdog event log reopened")
+		}
+	}
+
+	if len(changed[reloadLogging]) > 0 {
+		s.logSettings.SetLevels(effective.LogLevel, effective.LogLevels)
+		s.logSettings.SetFormat(effective.LogFormat)
+		s.logSettings.SetNoEmoji(effective.LogNoEmoji)
+		s.logSettings.SetAccessLog(effective.AccessLog)
+		s.logSampler.SetLimits(effective.LogRateLimit, effective.LogSummaryInterval)
+		for _, setting := range changed[reloadLogging] {
+			result.apply(setting, "", "applied")
+		}
+	}
+
+	if len(changed[reloadWebhooks]) > 0 {
+		s.notifier.Reconfigure(notifierConfigFor(&effective))
+		for _, setting := range changed[reloadWebhooks] {
+			result.apply(setting, "", "webhook notifier reconfigured")
+		}
+	}
+
+	// Removed interfaces go away while the old configuration still lists them
+	for _, ifName := range old.CanPorts {
+		if !slices.Contains(effective.CanPorts, ifName) {
+			s.removeInterface(ifName, effective.TeardownOnExit, result)
+		}
+	}
+
+	oldSetup, newSetup := s.setupManager.GetSetupConfig(), setupConfigFor(&effective)
+	if len(changed[reloadSetup]) > 0 {
+		if err := s.setupManager.UpdateSetupConfig(newSetup); err != nil {
+			// Validated above, so this cannot happen
+			s.logger.Printf("⚠️ Warning: failed to update setup configuration: %v", err)
+		}
+		for _, setting := range changed[reloadSetup] {
+			result.apply(setting, "", "applies to later setups")
+		}
+	}
+
+	s.setConfig(&effective)
+	s.configProvider.SetConfig(&effective)
+	for _, setting := range changed[reloadInPlace] {
+		result.apply(setting, "", "applied")
+	}
+	for _, setting := range changed[reloadReopen] {
+		result.apply(setting, "", "applies when an interface socket is next opened")
+	}
+
+	// Added interfaces are set up once the configuration lists them
+	for _, ifName := range effective.CanPorts {
+		if !slices.Contains(old.CanPorts, ifName) {
+			s.addInterface(ifName, result)
+		}
+	}
+
+	// Interfaces kept with a new bit timing are bounced to apply it
+	if len(changed[reloadSetup]) > 0 {
+		for _, ifName := range effective.CanPorts {
+			if !slices.Contains(old.CanPorts, ifName) {
+				continue
+			}
+			if from, to := bitTiming(oldSetup, ifName), bitTiming(newSetup, ifName); from != to {
+				s.bounceInterface(ifName, from, to, requestID, result)
+			}
+		}
+	}
+
+	for _, item := range result.Applied {
+		s.logger.Printf("   ✅ applied %s", item)
+	}
+	for _, item := range result.Skipped {
+		s.logger.Printf("   ⚠️ skipped %s", item)
+	}
+	for _, item := range result.Rejected {
+		s.logger.Printf("   🚫 rejected %s", item)
+	}
+	s.logger.Printf("🔁 Configuration reloaded: %d applied, %d skipped, %d rejected",
+		len(result.Applied), len(result.Skipped), len(result.Rejected))
+
+	result.Changes = configChanges(old, &effective)
+	if len(result.Changes) > 0 {
+		s.configGeneration++
+		for _, change := range result.Changes {
+			s.logger.Printf("   📝 %s: %v -> %v", change.Setting, change.Before, change.After)
+		}
+		s.sendAudit.RecordConfigChange(ConfigAuditRecord{
+			Timestamp:  time.Now(),
+			Client:     req.Client,
+			RemoteAddr: req.RemoteAddr,
+			RequestID:  requestID,
+			Persisted:  result.Persisted,
+			Changes:    result.Changes,
+		})
+	}
+	result.etag = s.configETag()
+	return result, nil
+}
+
+// ReloadOnSignal reloads the configuration for SIGHUP and records the reload in the audit
+// log, as the middleware does for reloads through the API
+func (s *Service) ReloadOnSignal() {
+	record := AuditRecord{Timestamp: time.Now(), Principal: "signal:SIGHUP", Action: "SIGHUP reload"}
+	result, err := s.Reload(ReloadRequest{Client: record.Principal}) // Logs what it applied, or why the configuration was rejected
+	record.DurationMs = float64(time.Since(record.Timestamp).Microseconds()) / 1000
+	if err != nil {
+		record.Outcome, record.Code, record.Error = AuditFailure, errorCode(err), err.Error()
+	} else {
+		record.Outcome = AuditSuccess
+		record.Params = map[string]string{
+			"applied":  strconv.Itoa(len(result.Applied)),
+			"skipped":  strconv.Itoa(len(result.Skipped)),
+			"rejected": strconv.Itoa(len(result.Rejected)),
+		}
+	}
+	s.audit.Record(record)
+}
+
+// reopenSendAudit replaces the send audit log with one writing to path, none when empty
+func (s *Service) reopenSendAudit(path string) error {
+	var auditLog *SendAuditLog
+	if path != "" {
+		var err error
+		if auditLog, err = NewSendAuditLog(path, s.logger.Named(LogComponentSender)); err != nil {
+			return err
+		}
+	}
+	s.messageSender.SetAuditLog(auditLog).Stop()
+	s.sendAudit = auditLog
+	return nil
+}
+
+// removeInterface stops using an interface no longer configured, tearing it down unless
+// another process owns its lifecycle
+func (s *Service) removeInterface(ifName string, teardown bool, result *ReloadResult) {
+	s.logger.Printf("➖ Removing interface %s", ifName)
+
+	if s.messageListener.IsListening(ifName) {
+		if err := s.messageListener.StopListening(ifName); err != nil {
+			s.logger.Printf("⚠️ Warning: failed to stop listening on %s: %v", ifName, err)
+		}
+	}
+	if s.interfaceManager.IsInterfaceActive(ifName) {
+		if err := s.interfaceManager.RemoveInterface(ifName); err != nil {
+			s.logger.Printf("⚠️ Warning: failed to remove interface %s: %v", ifName, err)
+		}
+	}
+	s.watchdog.ForgetInterface(ifName)
+	s.setSetupError(ifName, nil)
+
+	if !teardown {
+		result.apply("canPorts", ifName, "removed, left up (-teardown-on-exit=false)")
+		return
+	}
+	if err := s.setupManager.TeardownInterface(ifName); err != nil {
+		result.apply("canPorts", ifName, "removed, teardown failed: %v", err)
+		return
+	}
+	result.apply("canPorts", ifName, "removed and torn down")
+}
+
+// addInterface sets up, opens and listens on a newly configured interface. Like at
+// startup, an interface that fails stays configured and is reported degraded.
+func (s *Service) addInterface(ifName string, result *ReloadResult) {
+	s.logger.Printf("➕ Adding interface %s", ifName)
+
+	var problems []string
+	if err := s.setupManager.SetupInterfaceWithRetry(ifName); err != nil {
+		s.setSetupError(ifName, err)
+		problems = append(problems, fmt.Sprintf("setup failed: %v", err))
+	}
+	if err := s.interfaceManager.InitializeSingle(ifName); err != nil {
+		problems = append(problems, err.Error())
+	} else if err := s.messageListener.StartListening(ifName); err != nil {
+		problems = append(problems, fmt.Sprintf("failed to start listening: %v", err))
+	}
+
+	if len(problems) > 0 {
+		result.skip("canPorts", ifName, "added, but %s", strings.Join(problems, "; "))
+		return
+	}
+	result.apply("canPorts", ifName, "added and set up")
+}
+
+// bounceInterface sets an interface up again with its new bit timing under watchdog
+// coordination, reopening its sockets. The setup is forced, as a setup leaves an interface
+// that is up at the right bitrate alone whatever else of its bit timing changed.
+func (s *Service) bounceInterface(ifName, from, to, requestID string, result *ReloadResult) {
+	wasListening := s.messageListener.IsListening(ifName)
+	if wasListening {
+		if err := s.messageListener.StopListening(ifName); err != nil {
+			s.logger.Printf("⚠️ Warning: failed to stop listening on %s: %v", ifName, err)
+		}
+	}
+
+	err := s.monitor.ReconfigureInterface(ifName, "configuration reload: "+to, requestID, func() error {
+		return s.setupManager.ReconfigureInterface(ifName)
+	})
+
+	if wasListening {
+		if err := s.messageListener.StartListening(ifName); err != nil {
+			s.logger.Printf("⚠️ Warning: failed to restart listening on %s: %v", ifName, err)
+		}
+	}
+
+	if err != nil {
+		s.setSetupError(ifName, err)
+		result.skip("bitTiming", ifName, "%s -> %s failed: %v", from, to, err)
+		return
+	}
+	s.setSetupError(ifName, nil)
+	result.apply("bitTiming", ifName, "%s -> %s, interface bounced", from, to)
+}
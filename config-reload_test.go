@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newReloadTestService returns a service started from a configuration file holding
+// content, as with -config, with its interfaces open on a benchSocket and setup commands
+// run by executor. Reloads parse the same command line, so os.Args is replaced for the test.
+func newReloadTestService(t *testing.T, content string, executor CommandExecutor) (*Service, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "can-bridge.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	args := os.Args
+	os.Args = []string{"can-bridge", "-config", path}
+	t.Cleanup(func() { os.Args = args })
+
+	configParser := NewConfigParser()
+	config, err := configParser.ReparseConfig()
+	if err == nil {
+		err = configParser.ValidateConfig(config)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, logger, socket := NewService(), NewLogger(nil), &benchSocket{}
+	s.setConfig(config)
+	s.configProvider = NewDefaultConfigProvider(config)
+	s.setupManager = NewInterfaceSetupManager(setupConfigFor(config), executor, logger)
+	s.interfaceManager = NewInterfaceManager(s.configProvider, socket, logger)
+	s.messageSender = NewMessageSender(s.interfaceManager, s.configProvider, socket, logger)
+	s.messageListener = NewCanMessageListener(100, logger)
+	s.watchdog = NewWatchdog(s.interfaceManager, s.messageListener, DefaultWatchdogConfig(), logger)
+	s.monitor = NewMonitor(s.interfaceManager, s.watchdog, s.configProvider, logger)
+	for _, ifName := range config.CanPorts {
+		if err := s.interfaceManager.InitializeSingle(ifName); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(s.interfaceManager.Cleanup)
+	return s, path
+}
+
+// newReloadTestRouter returns a router serving the API of a service from newReloadTestService
+func newReloadTestRouter(s *Service) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewAPIHandlerWithSetupAndListener(s.messageSender, s.monitor, s.setupManager, s.messageListener, s.logger)
+	h.SetConfigManager(s)
+	r := gin.New()
+	h.SetupRoutes(r)
+	return r
+}
+
+func TestReloadDuringInterfaceSetup(t *testing.T) {
+	executor := NewMockCommandExecutor()
+	executor.AddResponse(cmdShow, ipDetailsUp, nil)
+	executor.AddResponse(cmdDetails, ipDetailsUp, nil)
+	s, _ := newReloadTestService(t, `
+setup:
+  bitrate: 1000000
+  sample_point: "0.875"
+  retry: 2
+interfaces:
+  - name: can0
+`, executor)
+	r := newReloadTestRouter(s)
+
+	// Setups with a bitrate of their own run while reloads change the setup configuration.
+	// Neither sees the other's configuration nor undoes it.
+	const rounds = 20
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < rounds; i++ {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/setup/interfaces/can0", strings.NewReader(`{"bitrate": 500000}`)))
+			if w.Code != http.StatusOK {
+				t.Errorf("POST /api/v1/setup/interfaces/can0: status %d: %s", w.Code, w.Body)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= rounds; i++ {
+			file := fmt.Sprintf("setup:\n  bitrate: 1000000\n  sample_point: \"0.875\"\n  retry: %d\ninterfaces:\n  - name: can0\n", i)
+			if _, err := s.Reload(ReloadRequest{File: []byte(file)}); err != nil {
+				t.Errorf("reload %d: %v", i, err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	config := s.setupManager.GetSetupConfig()
+	if config.RetryAttempts != rounds {
+		t.Errorf("retry attempts %d, want %d of the last reload", config.RetryAttempts, rounds)
+	}
+	if bitrate, ok := config.InterfaceBitrates["can0"]; ok {
+		t.Errorf("the bitrate of a setup request, %d, was left in the setup configuration", bitrate)
+	}
+
+	// The configuration returned is a copy
+	config.InterfaceSamplePoints = map[string]string{"can0": "0.5"}
+	config.InterfaceOneShot["can0"] = true
+	if got := s.setupManager.GetSetupConfig(); got.InterfaceOneShot["can0"] || got.InterfaceSamplePoints["can0"] != "" {
+		t.Errorf("changing a returned configuration changed the setup configuration: %+v", got)
+	}
+}
+
+func TestReloadAppliesBitTiming(t *testing.T) {
+	const before = `
+setup:
+  bitrate: 500000
+  sample_point: "0.875"
+  restart_ms: 100
+interfaces:
+  - name: can0
+`
+	tests := []struct {
+		name          string
+		after         string
+		wantConfigure string
+	}{
+		{
+			name:          "restart-ms",
+			after:         strings.Replace(before, "restart_ms: 100", "restart_ms: 200", 1),
+			wantConfigure: "ip link set can0 mtu 16 type can bitrate 500000 sample-point 0.875 restart-ms 200",
+		},
+		{
+			name:          "sample point",
+			after:         strings.Replace(before, `sample_point: "0.875"`, `sample_point: "0.8"`, 1),
+			wantConfigure: "ip link set can0 mtu 16 type can bitrate 500000 sample-point 0.8 restart-ms 100",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// can0 is up at the bitrate of both configurations
+			executor := NewMockCommandExecutor()
+			executor.AddResponse(cmdShow, ipDetailsUp, nil)
+			executor.AddResponse(cmdDetails, ipDetailsUp, nil)
+			executor.AddResponse(cmdDown, "", nil)
+			executor.AddResponse(tt.wantConfigure, "", nil)
+			executor.AddResponse(cmdUp, "", nil)
+			s, _ := newReloadTestService(t, before, executor)
+
+			result, err := s.Reload(ReloadRequest{File: []byte(tt.after)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Skipped) > 0 {
+				t.Errorf("skipped: %v", result.Skipped)
+			}
+			for _, command := range []string{cmdDown, tt.wantConfigure, cmdUp} {
+				if got := executor.CallCount(command); got != 1 {
+					t.Errorf("%q issued %d times, want once; commands: %q", command, got, executor.Commands())
+				}
+			}
+			if !strings.Contains(fmt.Sprint(result.Applied), "bitTiming[can0]") {
+				t.Errorf("applied %v, want the bounce of can0", result.Applied)
+			}
+		})
+	}
+}
+
+func TestFailedReloadKeepsRunningConfiguration(t *testing.T) {
+	const running = `{"setup": {"bitrate": 500000, "restart_ms": 100}, "interfaces": [{"name": "can0"}]}`
+	tests := []struct {
+		name   string
+		change func(t *testing.T, path string)
+	}{
+		{
+			name: "missing file",
+			change: func(t *testing.T, path string) {
+				if err := os.Remove(path); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "invalid JSON",
+			change: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, []byte(`{"setup": {"bitrate": 250000, "restart_ms": `), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "invalid value",
+			change: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, []byte(`{"setup": {"bitrate": 123456}, "interfaces": [{"name": "can1"}]}`), 0600); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+	reloads := []struct {
+		name   string
+		reload func(t *testing.T, s *Service)
+	}{
+		{
+			name: "SIGHUP",
+			reload: func(t *testing.T, s *Service) {
+				s.ReloadOnSignal()
+				records := s.audit.Query(AuditFilter{Principal: "signal:SIGHUP"})
+				if len(records) != 1 || records[0].Outcome != AuditFailure || records[0].Code != CodeInvalidConfig {
+					t.Errorf("audit records %+v, want one failure with %s", records, CodeInvalidConfig)
+				}
+			},
+		},
+		{
+			name: "POST /api/v1/config/reload",
+			reload: func(t *testing.T, s *Service) {
+				w := httptest.NewRecorder()
+				newReloadTestRouter(s).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/config/reload", nil))
+				if w.Code != http.StatusUnprocessableEntity || !strings.Contains(w.Body.String(), string(CodeInvalidConfig)) {
+					t.Errorf("status %d, want %d with %s: %s", w.Code, http.StatusUnprocessableEntity, CodeInvalidConfig, w.Body)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, reload := range reloads {
+			t.Run(tt.name+"/"+reload.name, func(t *testing.T) {
+				executor := NewMockCommandExecutor()
+				s, path := newReloadTestService(t, running, executor)
+				audit, err := NewAuditLog("", RotationPolicy{}, DefaultAuditLogEntries, s.logger)
+				if err != nil {
+					t.Fatal(err)
+				}
+				s.audit = audit
+				config, setupConfig := s.currentConfig(), s.setupManager.GetSetupConfig()
+				_, etag := s.EffectiveConfig()
+				canIf, _ := s.interfaceManager.GetInterface("can0")
+
+				tt.change(t, path)
+				reload.reload(t, s)
+
+				if s.currentConfig() != config {
+					t.Error("the running configuration was replaced")
+				}
+				if _, got := s.EffectiveConfig(); got != etag {
+					t.Errorf("ETag %s, want %s", got, etag)
+				}
+				if got := s.setupManager.GetSetupConfig(); !reflect.DeepEqual(got, setupConfig) {
+					t.Errorf("setup configuration %+v, want %+v", got, setupConfig)
+				}
+				if got, _ := s.interfaceManager.GetInterface("can0"); got != canIf {
+					t.Error("can0 was closed or reopened")
+				}
+				if commands := executor.Commands(); len(commands) > 0 {
+					t.Errorf("interfaces changed: %q", commands)
+				}
+			})
+		}
+	}
+}
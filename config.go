@@ -1,11 +1,19 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,14 +22,154 @@ type Config struct {
 	CanPorts            []string
 	Port                string
 	AutoSetup           bool          // Auto setup CAN interfaces on startup
+	TeardownOnExit      bool          // Tear down CAN interfaces on shutdown
 	Bitrate             int           // Default bitrate for CAN interfaces
 	SamplePoint         string        // Default sample point
 	RestartMs           int           // Default restart timeout
-	SetupRetry          int           // Number of setup retry attempts
+	SetupRetry          int           // Number of setup attempts, 1 disables retrying
 	SetupDelay          time.Duration // Delay between setup retries
+	SetupRetryBackoff   float64       // Setup retry delay multiplier per attempt (1 keeps it constant)
+	SetupMaxDelay       time.Duration // Cap of the backed off setup retry delay
 	EnableFinder        bool          // Enable service finder
 	SetupFinderInterval time.Duration // Interval for service finder
 	EnableHealthCheck   bool          // Enable health check endpoint
+	DryRun              bool          // Validate and log frames without writing them to the bus
+	RecoveryBaseDelay   time.Duration // Initial watchdog recovery backoff delay
+	RecoveryMaxDelay    time.Duration // Maximum watchdog recovery backoff delay
+	RecoveryStrategy    string        // exponential or fixed delays between watchdog recovery attempts
+	RecoveryMaxAttempts int           // Watchdog recovery attempts before giving up; 0 retries forever
+	CommandTimeout      time.Duration // Timeout for each system command (ip link, etc.)
+	WatchdogEventLog    string        // Optional file for persisting watchdog events
+
+	SetupRetries map[string]int           // Per-interface setup attempt overrides
+	SetupDelays  map[string]time.Duration // Per-interface setup retry delay overrides
+
+	Bitrates       map[string]int    // Per-interface bitrate overrides
+	SamplePoints   map[string]string // Per-interface sample point overrides
+	TripleSampling []string          // Interfaces whose controller samples each bit three times
+	OneShot        []string          // Interfaces whose controller does not retransmit frames
+
+	ExpectTraffic map[string]time.Duration // Per-interface RX silence threshold (interfaces that must see traffic)
+
+	WatchdogInterval          time.Duration            // Watchdog health check interval
+	WatchdogFailureThreshold  int                      // Consecutive failed checks before recovery
+	WatchdogCooldown          time.Duration            // Minimum time between recovery actions
+	WatchdogIntervals         map[string]time.Duration // Per-interface check interval overrides
+	WatchdogFailureThresholds map[string]int           // Per-interface failure threshold overrides
+	WatchdogSuccessThreshold  int                      // Consecutive passing checks before healthy again
+	WatchdogSuccessThresholds map[string]int           // Per-interface success threshold overrides
+	WatchdogCooldowns         map[string]time.Duration // Per-interface recovery cooldown overrides
+
+	InstanceName       string   // Service instance identity reported in notifications
+	WebhookURLs        []string // Webhook endpoints for event notifications
+	WebhookEvents      []string // Event types to notify (empty means all)
+	WebhookMinSeverity string   // Minimum notification severity
+
+	TxConfirmTimeout time.Duration // How long to wait for a sent frame's loopback echo (0 disables)
+
+	ErrorBurstThreshold int // Error frames per second that raise a warning
+
+	DefaultInterface string // Interface used by sends that omit one
+
+	ReceiveBufferSize  int            // Socket receive buffer (SO_RCVBUF) in bytes, 0 keeps the kernel default
+	ReceiveBufferSizes map[string]int // Per-interface receive buffer overrides
+
+	BufferFilters map[string]BufferFilter // IDs kept in the receive buffer per interface; others are not buffered
+
+	TxGap  time.Duration            // Minimum gap between frames written to an interface, 0 disables
+	TxGaps map[string]time.Duration // Per-interface gap overrides
+
+	TxQueue TxQueueConfig // Order and bounds of the sends waiting for an interface, by priority band
+	TxRetry TxRetryConfig // Retries of writes the kernel has no room for (ENOBUFS, EAGAIN)
+
+	J1939 map[string]uint8 // Interfaces in J1939 mode and their local source address
+
+	InterfaceAliases map[string]string // Logical name to interface, e.g. powertrain -> can0
+
+	AlertRules []AlertRule // Alert rules evaluated by the monitor
+
+	SimulatedNodes []SimulatedNodeConfig // Test-mode ECUs answering requests on vcan interfaces
+
+	DBC *DBC // Message and signal definitions for signal-based sends
+
+	NamedMessages map[string]*NamedMessage // Frames of the configuration file sendable by name
+
+	StartupSequence  []SequenceStep // Frames sent once interfaces are initialized
+	ShutdownSequence []SequenceStep // Frames sent on shutdown, before interfaces are closed
+
+	TLSCertFile       string            // Server certificate; enables HTTPS together with TLSKeyFile
+	TLSKeyFile        string            // Server private key
+	TLSClientCA       string            // CA bundle for client certificates; enables mutual TLS
+	ClientPermissions map[string]string // Client certificate CN or SAN to permission level (read, full)
+
+	APIKeys APIKeys // API keys and their roles; every request needs one when set
+
+	ListenUnix        string      // Unix socket serving the API besides TCP; empty disables
+	UnixSocketMode    os.FileMode // Permissions of the socket file
+	UnixSocketOwner   string      // "user", "user:group" or ":group" owning the socket; empty keeps the process's
+	UnixSocketTrusted bool        // Requests on the socket need no API key or client certificate
+	IPCSocket         string      // Unix socket of the binary IPC protocol; shares the mode and owner above
+
+	SendAuditLog string // File recording every frame sent as JSON lines; empty disables
+
+	AuditLog         string         // File recording every mutating API call as JSON lines; empty keeps them in memory only
+	AuditLogRotation RotationPolicy // Rotation and retention of AuditLog
+
+	Capture CaptureConfig // candump log files of received and sent frames; no Dir disables
+
+	LogLevel   LogLevel            // Level of log lines from components without their own
+	LogLevels  map[string]LogLevel // Per-component levels (setup, watchdog, sender, api, monitor)
+	LogFormat  string              // text or json
+	LogNoEmoji bool                // Remove emoji from log messages
+
+	LogTxFrames bool // Log every frame sent at info level, with its request, principal and job; debug otherwise
+
+	LogRateLimit       int           // Identical log lines per second before the rest are summarized; 0 disables
+	LogSummaryInterval time.Duration // How often lines held back are summarized
+
+	AccessLog AccessLogSettings // HTTP requests the access log records
+
+	LogOutputs     []string // Where the log goes: stderr, stdout, journald, syslog
+	SyslogAddress  string   // Syslog daemon, e.g. udp://host:514; empty is the local one
+	SyslogFacility string   // Facility of lines sent to syslog
+
+	LogFile     string         // Service log file; empty logs to standard error
+	LogRotation RotationPolicy // Rotation and retention of LogFile
+
+	BlackboxDir      string // Directory of blackbox dumps written on panics and on demand; empty disables
+	BlackboxMaxFiles int    // Blackbox dumps kept, the oldest are deleted
+
+	APIDocs bool // Serve the OpenAPI document at /openapi.json and Swagger UI at /docs
+
+	LegacyAPIRoutes bool // Also serve /api/v1 routes at their deprecated unversioned /api paths
+
+	ReadyRequiresAll bool          // /readyz requires every configured interface, not just one
+	LivenessTimeout  time.Duration // Heartbeat age at which /livez reports a background loop stuck
+
+	DrainTimeout    time.Duration // How long shutdown waits for sends in flight
+	ShutdownTimeout time.Duration // Upper bound of the whole shutdown, drain included
+
+	CORS CORSConfig // Cross-origin policy for browser clients
+
+	IdempotencyCacheSize int           // Responses kept for Idempotency-Key replays; 0 disables the header
+	IdempotencyTTL       time.Duration // How long a response is kept for replays
+
+	MaxBodySize    int64 // Largest request body in bytes; larger ones get 413
+	MaxBatchFrames int   // Most frames one multi-interface send may carry
+
+	AllowedNetworks []netip.Prefix // Client networks allowed to use the API; empty allows all
+	TrustedProxies  []netip.Prefix // Proxies whose X-Forwarded-For header is believed
+
+	OTLP OTLPConfig // OpenTelemetry export, from the standard OTEL_* environment variables
+
+	StatsD StatsDConfig // Metrics pushed to a StatsD or DogStatsD agent; disabled without an address
+
+	UnresolvedEnvVars []string // ${VAR} references without a default whose variable is unset
+
+	ConfigFile   string // YAML file the settings were read from; empty without one
+	ValidateOnly bool   // Validate and print the configuration, then exit
+
+	parseErrors ConfigErrors // Malformed values, reported by ValidateConfig with the other problems
 }
 
 // ConfigProvider interface for dependency injection
@@ -31,35 +179,59 @@ type ConfigProvider interface {
 	ValidateInterface(ifName string) bool
 	GetAutoSetup() bool
 	GetDefaultBitrate() int
+	GetBitrate(ifName string) int
 	GetDefaultSamplePoint() string
 	GetDefaultRestartMs() int
 	GetSetupRetry() int
 	GetSetupDelay() time.Duration
+	GetDryRun() bool
+	GetTxConfirmTimeout() time.Duration
+	GetDefaultInterface() string
+	GetReceiveBufferSize(ifName string) int
+	GetBufferFilter(ifName string) BufferFilter
+	GetTxGap(ifName string) time.Duration
+	GetTxQueue() TxQueueConfig
+	GetTxRetry() TxRetryConfig
+	GetLogTxFrames() bool
+	ResolveInterfaceAlias(name string) string
 }
 
-// DefaultConfigProvider implements ConfigProvider
+// DefaultConfigProvider implements ConfigProvider. The configuration is replaced as a
+// whole on reload, so every getter sees either the old or the new one.
 type DefaultConfigProvider struct {
-	config *Config
+	current atomic.Pointer[Config]
 }
 
 // NewDefaultConfigProvider creates a new default config provider
 func NewDefaultConfigProvider(config *Config) *DefaultConfigProvider {
-	return &DefaultConfigProvider{config: config}
+	p := &DefaultConfigProvider{}
+	p.current.Store(config)
+	return p
+}
+
+// SetConfig replaces the configuration the getters report
+func (p *DefaultConfigProvider) SetConfig(config *Config) {
+	p.current.Store(config)
+}
+
+// config returns the current configuration
+func (p *DefaultConfigProvider) config() *Config {
+	return p.current.Load()
 }
 
 // GetCanPorts returns configured CAN ports
 func (p *DefaultConfigProvider) GetCanPorts() []string {
-	return p.config.CanPorts
+	return p.config().CanPorts
 }
 
 // GetServerPort returns server port
 func (p *DefaultConfigProvider) GetServerPort() string {
-	return p.config.Port
+	return p.config().Port
 }
 
 // ValidateInterface checks if interface is in configured ports
 func (p *DefaultConfigProvider) ValidateInterface(ifName string) bool {
-	for _, port := range p.config.CanPorts {
+	for _, port := range p.config().CanPorts {
 		if port == ifName {
 			return true
 		}
@@ -69,302 +241,2708 @@ func (p *DefaultConfigProvider) ValidateInterface(ifName string) bool {
 
 // GetAutoSetup returns auto setup configuration
 func (p *DefaultConfigProvider) GetAutoSetup() bool {
-	return p.config.AutoSetup
+	return p.config().AutoSetup
 }
 
 // GetDefaultBitrate returns default bitrate
 func (p *DefaultConfigProvider) GetDefaultBitrate() int {
-	return p.config.Bitrate
+	return p.config().Bitrate
+}
+
+// GetBitrate returns the configured bitrate of an interface
+func (p *DefaultConfigProvider) GetBitrate(ifName string) int {
+	config := p.config()
+	if bitrate, ok := config.Bitrates[ifName]; ok {
+		return bitrate
+	}
+	return config.Bitrate
 }
 
 // GetDefaultSamplePoint returns default sample point
 func (p *DefaultConfigProvider) GetDefaultSamplePoint() string {
-	return p.config.SamplePoint
+	return p.config().SamplePoint
 }
 
 // GetDefaultRestartMs returns default restart timeout
 func (p *DefaultConfigProvider) GetDefaultRestartMs() int {
-	return p.config.RestartMs
+	return p.config().RestartMs
 }
 
 // GetSetupRetry returns setup retry count
 func (p *DefaultConfigProvider) GetSetupRetry() int {
-	return p.config.SetupRetry
+	return p.config().SetupRetry
 }
 
 // GetSetupDelay returns setup retry delay
 func (p *DefaultConfigProvider) GetSetupDelay() time.Duration {
-	return p.config.SetupDelay
+	return p.config().SetupDelay
+}
+
+// GetDryRun returns whether global dry-run mode is enabled
+func (p *DefaultConfigProvider) GetDryRun() bool {
+	return p.config().DryRun
 }
 
 func (p *DefaultConfigProvider) GetEnableFinder() bool {
-	return p.config.EnableFinder
+	return p.config().EnableFinder
 }
 
 func (p *DefaultConfigProvider) GetSetupFinderInterval() time.Duration {
-	return p.config.SetupFinderInterval
+	return p.config().SetupFinderInterval
 }
 
 func (p *DefaultConfigProvider) GetEnableHealthCheck() bool {
-	return p.config.EnableHealthCheck
+	return p.config().EnableHealthCheck
+}
+
+// GetTxQueue returns the dequeue policy, weights and capacities of the TX queues
+func (p *DefaultConfigProvider) GetTxQueue() TxQueueConfig {
+	return p.config().TxQueue
+}
+
+// GetTxRetry returns how writes the kernel has no room for are retried
+func (p *DefaultConfigProvider) GetTxRetry() TxRetryConfig {
+	return p.config().TxRetry
+}
+
+// GetLogTxFrames returns whether every frame sent is logged at info level
+func (p *DefaultConfigProvider) GetLogTxFrames() bool {
+	return p.config().LogTxFrames
+}
+
+// GetTxConfirmTimeout returns the transmit confirmation timeout (0 when disabled)
+func (p *DefaultConfigProvider) GetTxConfirmTimeout() time.Duration {
+	return p.config().TxConfirmTimeout
+}
+
+// GetDefaultInterface returns the interface used by sends that omit one. Without an
+// explicit default, a single configured port is the default; otherwise it is empty.
+func (p *DefaultConfigProvider) GetDefaultInterface() string {
+	config := p.config()
+	if config.DefaultInterface != "" {
+		return config.DefaultInterface
+	}
+	if len(config.CanPorts) == 1 {
+		return config.CanPorts[0]
+	}
+	return ""
+}
+
+// GetBufferFilter returns the IDs kept in the receive buffer of an interface, empty for all
+func (p *DefaultConfigProvider) GetBufferFilter(ifName string) BufferFilter {
+	return p.config().BufferFilters[ifName]
+}
+
+// GetReceiveBufferSize returns the socket receive buffer size for an interface
+// (0 keeps the kernel default)
+func (p *DefaultConfigProvider) GetReceiveBufferSize(ifName string) int {
+	config := p.config()
+	if size, ok := config.ReceiveBufferSizes[ifName]; ok {
+		return size
+	}
+	return config.ReceiveBufferSize
+}
+
+// GetTxGap returns the minimum gap between frames written to an interface (0 when
+// sends are not paced)
+func (p *DefaultConfigProvider) GetTxGap(ifName string) time.Duration {
+	config := p.config()
+	if gap, ok := config.TxGaps[ifName]; ok {
+		return gap
+	}
+	return config.TxGap
+}
+
+// ResolveInterfaceAlias returns the interface an alias stands for, or name unchanged when
+// it is not an alias
+func (p *DefaultConfigProvider) ResolveInterfaceAlias(name string) string {
+	if ifName, ok := p.config().InterfaceAliases[name]; ok {
+		return ifName
+	}
+	return name
+}
+
+// GetInterfaceAliases returns the aliases of an interface, sorted
+func (p *DefaultConfigProvider) GetInterfaceAliases(ifName string) []string {
+	var aliases []string
+	for alias, target := range p.config().InterfaceAliases {
+		if target == ifName {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
 }
 
 // ConfigParser handles parsing configuration from various sources
-type ConfigParser struct{}
+type ConfigParser struct {
+	fileData []byte // Stands in for the content of the configuration file when set
+}
 
 // NewConfigParser creates a new config parser
 func NewConfigParser() *ConfigParser {
 	return &ConfigParser{}
 }
 
-// ParseConfig parses configuration from command line and environment variables
+// ParseConfig parses configuration from command line and environment variables.
+// Malformed values are left at their zero value and reported by ValidateConfig.
 func (cp *ConfigParser) ParseConfig() (*Config, error) {
+	return cp.parseConfig(flag.CommandLine, os.Args[1:])
+}
+
+// ReparseConfig parses the configuration again, as a reload does: the command line the
+// service was started with, the environment and the configuration file it names
+func (cp *ConfigParser) ReparseConfig() (*Config, error) {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return cp.parseConfig(fs, os.Args[1:])
+}
+
+// ReparseConfigWith parses the configuration as ReparseConfig does, with data in place of
+// the configuration file, e.g. one submitted through the API. It applies even when no
+// configuration file is used.
+func (cp *ConfigParser) ReparseConfigWith(data []byte) (*Config, error) {
+	cp.fileData = data
+	defer func() { cp.fileData = nil }()
+	return cp.ReparseConfig()
+}
+
+// loadConfigFile reads the configuration file, or decodes the data standing in for it
+func (cp *ConfigParser) loadConfigFile(path string) (*ConfigFile, error) {
+	if cp.fileData == nil {
+		return LoadConfigFile(path)
+	}
+	file, err := decodeConfigFile(path, cp.fileData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid submitted configuration: %w", err)
+	}
+	return file, nil
+}
+
+// parseConfig defines the flags on fs and parses args with them
+func (cp *ConfigParser) parseConfig(fs *flag.FlagSet, args []string) (*Config, error) {
 	config := &Config{}
 
 	// Command line flags
 	var canPortsFlag string
 	var serverPort string
 	var autoSetup bool
+	var teardownOnExit bool
 	var bitrate int
 	var samplePoint string
 	var restartMs int
 	var setupRetry int
 	var setupDelaySeconds int
+	var setupRetryBackoff float64
+	var setupMaxDelaySeconds int
+	var setupRetries string
+	var setupDelays string
+	var bitrates string
+	var samplePoints string
+	var tripleSampling string
+	var oneShot string
 	var setupFinderEnabled bool
 	var setupFinderInterval int
 	var setupHealthCheck bool
+	var dryRun bool
+	var recoveryBaseDelaySeconds int
+	var recoveryStrategy string
+	var recoveryMaxAttempts int
+	var recoveryMaxDelaySeconds int
+	var commandTimeoutSeconds int
+	var watchdogEventLog string
+	var expectTraffic string
+	var watchdogIntervalMs int
+	var watchdogFailureThreshold int
+	var watchdogCooldownSeconds int
+	var watchdogIntervals string
+	var watchdogFailureThresholds string
+	var watchdogSuccessThreshold int
+	var watchdogSuccessThresholds string
+	var watchdogCooldowns string
+	var instanceName string
+	var webhookURLs string
+	var webhookEvents string
+	var webhookMinSeverity string
+	var statsdAddress string
+	var statsdIntervalSeconds int
+	var statsdPrefix string
+	var statsdFormat string
+	var txConfirmTimeoutMs int
+	var errorBurstThreshold int
+	var defaultInterface string
+	var receiveBufferSize int
+	var receiveBufferSizes string
+	var txGapUs int
+	var txGapsUs string
+	var txQueuePolicy string
+	var txQueueWeights string
+	var txQueueCapacity string
+	var txRetryAttempts int
+	var txRetryDeadlineMs int
+	var txRetryPoll bool
+	var j1939Addresses string
+	var interfaceAliases string
+	var bufferIDs string
+	var alertRulesFile string
+	var simulatedNodesFile string
+	var dbcFile string
+	var tlsCertFile string
+	var tlsKeyFile string
+	var tlsClientCA string
+	var clientPermissions string
+	var apiKeysFile string
+	var allowedNetworks string
+	var sendAuditLog string
+	var auditLog string
+	var auditLogMaxSize int64
+	var auditLogMaxAgeHours int
+	var auditLogMaxFiles int
+	var auditLogCompress bool
+	var captureDir string
+	var captureInterfaces string
+	var captureTX bool
+	var captureTXContext bool
+	var captureMaxFileSize int64
+	var captureMaxTotalSize int64
+	var captureMaxAgeHours int
+	var captureMaxFiles int
+	var captureCompress bool
+	var logLevel string
+	var logLevels string
+	var logFormat string
+	var logNoEmoji bool
+	var logTxFrames bool
+	var logRateLimit int
+	var logSummaryIntervalSeconds int
+	var accessSkip string
+	var accessSampleRate float64
+	var accessSlowMs int64
+	var logOutput string
+	var syslogAddress string
+	var syslogFacility string
+	var logFile string
+	var logMaxSize int64
+	var logMaxAgeHours int
+	var logMaxFiles int
+	var logCompress bool
+	var blackboxDir string
+	var blackboxMaxFiles int
+	var corsOrigins string
+	var corsMethods string
+	var corsHeaders string
+	var corsCredentials bool
+	var corsMaxAgeSeconds int
+	var listenUnix string
+	var ipcSocket string
+	var unixSocketMode string
+	var unixSocketOwner string
+	var unixSocketTrusted bool
+	var apiDocs bool
+	var legacyAPIRoutes bool
+	var readyRequiresAll bool
+	var livenessTimeoutSeconds int
+	var drainTimeoutSeconds int
+	var shutdownTimeoutSeconds int
+	var idempotencyCacheSize int
+	var idempotencyTTLSeconds int
+	var maxBodySize int64
+	var maxBatchFrames int
+	var trustedProxies string
+	var configFile string
+	var validateOnly bool
+
+	fs.StringVar(&canPortsFlag, "can-ports", "", "Comma-separated list of CAN interfaces (e.g., can0,can1)")
+	fs.StringVar(&serverPort, "port", "5260", "HTTP server port")
+	fs.BoolVar(&autoSetup, "auto-setup", true, "Automatically setup CAN interfaces on startup")
+	fs.BoolVar(&teardownOnExit, "teardown-on-exit", true, "Tear down CAN interfaces on shutdown; false leaves them up for another owner")
+	fs.IntVar(&bitrate, "bitrate", 1000000, "Default CAN bitrate (bps)")
+	fs.StringVar(&samplePoint, "sample-point", "0.75", "Default CAN sample point")
+	fs.IntVar(&restartMs, "restart-ms", 100, "Default CAN restart timeout (ms)")
+	fs.IntVar(&setupRetry, "setup-retry", 3, "Number of setup attempts, 1 disables retrying")
+	fs.IntVar(&setupDelaySeconds, "setup-delay", 2, "Delay between setup retries (seconds)")
+	fs.Float64Var(&setupRetryBackoff, "setup-retry-backoff", 1, "Setup retry delay multiplier per attempt (1 keeps the delay constant)")
+	fs.IntVar(&setupMaxDelaySeconds, "setup-max-delay", 60, "Maximum setup retry delay with backoff (seconds, 0 for no cap)")
+	fs.StringVar(&setupRetries, "setup-retries", "", "Per-interface setup attempts (e.g., vcan0=1,can1=10)")
+	fs.StringVar(&setupDelays, "setup-delays", "", "Per-interface setup retry delays (e.g., can1=5s)")
+	fs.StringVar(&bitrates, "bitrates", "", "Per-interface CAN bitrates (e.g., can1=250000)")
+	fs.StringVar(&samplePoints, "sample-points", "", "Per-interface CAN sample points (e.g., can1=0.875)")
+	fs.StringVar(&tripleSampling, "triple-sampling", "", "Comma-separated interfaces that sample each bit three times (e.g., can1)")
+	fs.StringVar(&oneShot, "one-shot", "", "Comma-separated interfaces whose controller does not retransmit frames (e.g., can1)")
+	fs.BoolVar(&setupFinderEnabled, "enable-finder", true, "Enable service finder")
+	fs.IntVar(&setupFinderInterval, "finder-interval", 5, "Interval for service finder in seconds")
+	fs.BoolVar(&setupHealthCheck, "enable-healthcheck", true, "Enable health check endpoint")
+	fs.BoolVar(&dryRun, "dry-run", false, "Validate and log CAN frames without sending them")
+	fs.IntVar(&recoveryBaseDelaySeconds, "recovery-base-delay", 1, "Initial watchdog recovery backoff delay (seconds)")
+	fs.IntVar(&recoveryMaxDelaySeconds, "recovery-max-delay", 300, "Maximum watchdog recovery backoff delay (seconds)")
+	fs.StringVar(&recoveryStrategy, "recovery-strategy", RecoveryStrategyExponential, "Delays between watchdog recovery attempts: exponential (doubling up to -recovery-max-delay) or fixed (-recovery-base-delay each time)")
+	fs.IntVar(&recoveryMaxAttempts, "recovery-max-attempts", DefaultRecoveryMaxAttempts, "Watchdog recovery attempts before giving up on an interface (0 retries forever)")
+	fs.IntVar(&commandTimeoutSeconds, "command-timeout", 5, "Timeout for each interface setup command (seconds)")
+	fs.StringVar(&watchdogEventLog, "watchdog-event-log", "", "File for persisting watchdog events as JSON lines")
+	fs.StringVar(&expectTraffic, "expect-traffic", "", "Per-interface RX silence thresholds (e.g., can0=5s,can1=10s)")
+	fs.IntVar(&watchdogIntervalMs, "watchdog-interval-ms", 10000, "Watchdog health check interval (milliseconds)")
+	fs.IntVar(&watchdogFailureThreshold, "watchdog-failure-threshold", 3, "Consecutive failed health checks before an interface is failed and recovered")
+	fs.IntVar(&watchdogSuccessThreshold, "watchdog-success-threshold", 3, "Consecutive passing health checks before an interface is healthy again")
+	fs.IntVar(&watchdogCooldownSeconds, "watchdog-cooldown", 0, "Minimum time between recovery actions on an interface (seconds)")
+	fs.StringVar(&watchdogIntervals, "watchdog-intervals", "", "Per-interface watchdog check intervals (e.g., can0=500ms,can1=5s)")
+	fs.StringVar(&watchdogFailureThresholds, "watchdog-failure-thresholds", "", "Per-interface failure thresholds (e.g., can0=3)")
+	fs.StringVar(&watchdogSuccessThresholds, "watchdog-success-thresholds", "", "Per-interface success thresholds (e.g., can0=5)")
+	fs.StringVar(&watchdogCooldowns, "watchdog-cooldowns", "", "Per-interface recovery cooldowns (e.g., can0=30s)")
+	fs.StringVar(&instanceName, "instance-name", "", "Service instance name reported in notifications (default: hostname)")
+	fs.StringVar(&webhookURLs, "webhook-urls", "", "Comma-separated webhook URLs for event notifications")
+	fs.StringVar(&webhookEvents, "webhook-events", "", "Comma-separated event types to notify (default: all)")
+	fs.StringVar(&webhookMinSeverity, "webhook-min-severity", SeverityInfo, "Minimum notification severity (info, warning, critical)")
+	fs.StringVar(&statsdAddress, "statsd-address", "", "StatsD agent (host:port) metrics are pushed to over UDP (default: disabled)")
+	fs.IntVar(&statsdIntervalSeconds, "statsd-interval", int(DefaultStatsDInterval/time.Second), "Seconds between pushes of metrics to StatsD")
+	fs.StringVar(&statsdPrefix, "statsd-prefix", "", "Prefix of the metric names pushed to StatsD, joined with a dot")
+	fs.StringVar(&statsdFormat, "statsd-format", StatsDFormatDog, "StatsD line format: dogstatsd (labels as tags) or statsd (labels in the name)")
+	fs.IntVar(&txConfirmTimeoutMs, "tx-confirm-timeout-ms", 100, "Wait for each sent frame's loopback echo up to this long (milliseconds, 0 disables)")
+	fs.IntVar(&errorBurstThreshold, "error-burst-threshold", DefaultErrorBurstThreshold, "Error frames per second that raise an error burst warning")
+	fs.StringVar(&defaultInterface, "default-interface", "", "Interface used by sends that omit one (default: the only configured port)")
+	fs.IntVar(&receiveBufferSize, "rcvbuf-size", 0, "Socket receive buffer size in bytes (default: kernel default)")
+	fs.StringVar(&receiveBufferSizes, "rcvbuf-sizes", "", "Per-interface socket receive buffer sizes in bytes (e.g., can0=1048576)")
+	fs.IntVar(&txGapUs, "tx-gap-us", 0, "Minimum gap between frames written to an interface in microseconds (0 disables)")
+	fs.StringVar(&txGapsUs, "tx-gaps-us", "", "Per-interface minimum inter-frame gaps in microseconds (e.g., can0=500)")
+	fs.StringVar(&txQueuePolicy, "tx-queue-policy", TxQueueStrict, "Order of sends waiting for an interface: strict (higher priority bands first) or weighted")
+	fs.StringVar(&txQueueWeights, "tx-queue-weights", formatTxBandInts(DefaultTxQueueWeights), "Share of writes per priority band under the weighted policy")
+	fs.StringVar(&txQueueCapacity, "tx-queue-capacity", formatTxBandInts(DefaultTxQueueCapacity), "Sends that may wait per priority band and interface; more are rejected")
+	fs.IntVar(&txRetryAttempts, "tx-retry-attempts", DefaultTxRetryAttempts, "Writes of a frame refused with ENOBUFS or EAGAIN, the first included (1 disables retrying)")
+	fs.IntVar(&txRetryDeadlineMs, "tx-retry-deadline-ms", int(DefaultTxRetryDeadline/time.Millisecond), "Total time a frame may spend retrying in milliseconds (0 for no limit)")
+	fs.BoolVar(&txRetryPoll, "tx-retry-poll", false, "Write without blocking and wait for a full socket send buffer with poll() before retrying")
+	fs.StringVar(&j1939Addresses, "j1939", "", "Interfaces in J1939 mode with their local source address (e.g., can0=0x80)")
+	fs.StringVar(&interfaceAliases, "interface-aliases", "", "Logical names the API accepts for interfaces (e.g., powertrain=can0,body=can1)")
+	fs.StringVar(&bufferIDs, "buffer-ids", "", "IDs kept in the receive buffer per interface, repeating the interface per ID, range or ID/mask (e.g., can0=0x100-0x1FF,can0=0x7E8)")
+	fs.StringVar(&alertRulesFile, "alert-rules", "", "JSON file with alert rules evaluated by the monitor")
+	fs.StringVar(&simulatedNodesFile, "simulated-nodes", "", "JSON file with simulated nodes answering requests on vcan interfaces (test mode)")
+	fs.StringVar(&dbcFile, "dbc", "", "DBC file with message and signal definitions for signal-based sends")
+	fs.StringVar(&tlsCertFile, "tls-cert", "", "TLS server certificate file (enables HTTPS)")
+	fs.StringVar(&tlsKeyFile, "tls-key", "", "TLS server private key file")
+	fs.StringVar(&tlsClientCA, "tls-client-ca", "", "CA file for client certificates (enables mutual TLS)")
+	fs.StringVar(&clientPermissions, "tls-client-permissions", "", "Client certificate CN/SAN permissions (e.g., ops=full,dashboard=read)")
+	fs.StringVar(&apiKeysFile, "api-keys", "", "JSON file with API keys and their roles (viewer, operator, admin)")
+	fs.StringVar(&allowedNetworks, "allowed-networks", "", "Comma-separated client CIDRs allowed to use the API (e.g., 10.20.0.0/16,fd00::/8)")
+	fs.StringVar(&trustedProxies, "trusted-proxies", "", "Comma-separated proxy CIDRs whose X-Forwarded-For header is trusted")
+	fs.StringVar(&sendAuditLog, "send-audit-log", "", "File recording every sent frame with client identity as JSON lines")
+	fs.StringVar(&auditLog, "audit-log", "", "File recording every mutating API call with principal and outcome as JSON lines")
+	fs.Int64Var(&auditLogMaxSize, "audit-log-max-size", DefaultLogMaxSize, "Size in bytes at which the audit log is rotated (0 disables)")
+	fs.IntVar(&auditLogMaxAgeHours, "audit-log-max-age", 0, "Hours after which the audit log is rotated (0 disables)")
+	fs.IntVar(&auditLogMaxFiles, "audit-log-max-files", DefaultAuditLogMaxFiles, "Rotated audit logs kept (0 keeps all)")
+	fs.BoolVar(&auditLogCompress, "audit-log-compress", false, "gzip rotated audit logs")
+	fs.StringVar(&captureDir, "capture-dir", "", "Directory of candump log files; enables POST /api/v1/capture/{interface}/start")
+	fs.StringVar(&captureInterfaces, "capture", "", "Comma-separated interfaces captured from startup (e.g., can0); needs -capture-dir")
+	fs.BoolVar(&captureTX, "capture-tx", false, "Also capture frames sent by the bridge, marking lines T (sent) or R (received)")
+	fs.BoolVar(&captureTXContext, "capture-tx-context", false, "Record the API request, principal and job of each sent frame in a JSON-lines file next to its capture file; needs -capture-tx")
+	fs.Int64Var(&captureMaxFileSize, "capture-max-file-size", DefaultCaptureMaxFileSize, "Size in bytes at which a capture file is rotated")
+	fs.Int64Var(&captureMaxTotalSize, "capture-max-total-size", DefaultCaptureMaxTotalSize, "Bytes of capture files kept; the oldest are deleted beyond it")
+	fs.IntVar(&captureMaxAgeHours, "capture-max-age", 0, "Hours after which a capture file is rotated (0 disables)")
+	fs.IntVar(&captureMaxFiles, "capture-max-files", 0, "Finished capture files kept; the oldest are deleted beyond it (0 for no limit)")
+	fs.BoolVar(&captureCompress, "capture-compress", false, "gzip finished capture files")
+	fs.StringVar(&logLevel, "log-level", "info", "Log level: debug, info, warn or error")
+	fs.StringVar(&logLevels, "log-levels", "", "Per-component log levels (e.g., watchdog=warn,sender=debug)")
+	fs.StringVar(&logFormat, "log-format", LogFormatText, "Log output format: text, or json for one object per line")
+	fs.BoolVar(&logNoEmoji, "log-no-emoji", false, "Remove emoji from log messages (e.g., for journald)")
+	fs.BoolVar(&logTxFrames, "log-tx-frames", true, "Log every frame sent at info level with its request ID, principal and job (false logs them at debug level)")
+	fs.IntVar(&logRateLimit, "log-rate-limit", DefaultLogRateLimit, "Identical log lines per second before the rest are counted and summarized (0 disables)")
+	fs.IntVar(&logSummaryIntervalSeconds, "log-summary-interval", int(DefaultLogSummaryInterval/time.Second), "Seconds between summaries of log lines held back by -log-rate-limit")
+	fs.StringVar(&accessSkip, "log-access-skip", strings.Join(DefaultAccessLogSkipPaths, ","), "Comma-separated path prefixes left out of the access log (empty logs every path)")
+	fs.Float64Var(&accessSampleRate, "log-access-sample-rate", 1, "Share of successful requests in the access log, 0 to 1; failed and slow requests are always logged")
+	fs.Int64Var(&accessSlowMs, "log-access-slow-ms", 0, "Milliseconds after which a request is logged as a slow warning (0 disables)")
+	fs.StringVar(&logOutput, "log-output", LogOutputStderr, "Comma-separated log outputs: stderr, stdout, journald and syslog (e.g., journald,stdout)")
+	fs.StringVar(&syslogAddress, "syslog-address", "", "Syslog daemon of the syslog output: udp://host:port, tcp://host:port or unix:///path (default: local)")
+	fs.StringVar(&syslogFacility, "syslog-facility", DefaultSyslogFacility, "Syslog facility of the syslog output (e.g., daemon, local0)")
+	fs.StringVar(&logFile, "log-file", "", "Write the service log to this file, rotated by -log-max-size and -log-max-age, instead of standard error")
+	fs.Int64Var(&logMaxSize, "log-max-size", DefaultLogMaxSize, "Size in bytes at which the log file is rotated (0 disables)")
+	fs.IntVar(&logMaxAgeHours, "log-max-age", 0, "Hours after which the log file is rotated (0 disables)")
+	fs.IntVar(&logMaxFiles, "log-max-files", DefaultLogMaxFiles, "Rotated log files kept (0 keeps all)")
+	fs.BoolVar(&logCompress, "log-compress", false, "gzip rotated log files")
+	fs.StringVar(&blackboxDir, "blackbox-dir", "", "Write recent logs, frames, watchdog events and goroutine stacks to this directory on panics and on demand")
+	fs.IntVar(&blackboxMaxFiles, "blackbox-max-files", DefaultBlackboxMaxFiles, "Blackbox dumps kept")
+	fs.StringVar(&corsOrigins, "cors-origins", "", "Comma-separated origins browsers may call the API from (exact, https://*.example.com, or *)")
+	fs.StringVar(&corsMethods, "cors-methods", strings.Join(defaultCORSMethods, ","), "Comma-separated methods allowed in cross-origin requests")
+	fs.StringVar(&corsHeaders, "cors-headers", strings.Join(defaultCORSHeaders, ","), "Comma-separated request headers allowed in cross-origin requests")
+	fs.BoolVar(&corsCredentials, "cors-credentials", false, "Allow cross-origin requests with credentials (cookies, authorization)")
+	fs.IntVar(&corsMaxAgeSeconds, "cors-max-age", 600, "Seconds browsers may cache a CORS preflight answer")
+	fs.StringVar(&listenUnix, "listen-unix", "", "Unix socket serving the API besides TCP (e.g., /run/can-bridge.sock)")
+	fs.StringVar(&ipcSocket, "ipc-socket", "", "Unix socket for the binary IPC protocol of local clients (e.g., /run/can-bridge.ipc)")
+	fs.StringVar(&unixSocketMode, "unix-socket-mode", "0660", "Octal permissions of the Unix socket")
+	fs.StringVar(&unixSocketOwner, "unix-socket-owner", "", "Owner of the Unix socket as user, user:group or :group")
+	fs.BoolVar(&unixSocketTrusted, "unix-socket-trusted", false, "Serve Unix socket requests without API key or client certificate")
+	fs.BoolVar(&apiDocs, "api-docs", true, "Serve the OpenAPI document at /openapi.json and Swagger UI at /docs")
+	fs.BoolVar(&legacyAPIRoutes, "legacy-api-routes", true, "Serve deprecated unversioned /api aliases of the /api/v1 routes")
+	fs.BoolVar(&readyRequiresAll, "ready-requires-all", false, "Report ready only when every configured interface is initialized, not just one")
+	fs.IntVar(&livenessTimeoutSeconds, "liveness-timeout", 30, "Seconds without a heartbeat before /livez reports a background loop stuck")
+	fs.IntVar(&drainTimeoutSeconds, "drain-timeout", 10, "Seconds shutdown waits for sends in flight before closing the interfaces")
+	fs.IntVar(&shutdownTimeoutSeconds, "shutdown-timeout", 30, "Seconds a graceful shutdown may take before the service exits anyway")
+	fs.IntVar(&idempotencyCacheSize, "idempotency-cache-size", 1000, "Responses kept to replay requests retried with the same Idempotency-Key (0 disables)")
+	fs.IntVar(&idempotencyTTLSeconds, "idempotency-ttl", 3600, "Seconds a response is kept for Idempotency-Key replays")
+	fs.Int64Var(&maxBodySize, "max-body-size", DefaultMaxBodySize, "Largest request body in bytes; larger requests are rejected with 413")
+	fs.IntVar(&maxBatchFrames, "max-batch-frames", DefaultMaxBatchFrames, "Most frames (interfaces) one multi-interface send may carry")
+	fs.StringVar(&configFile, "config", "", "YAML configuration file; command line flags and environment variables override it")
+	fs.BoolVar(&validateOnly, "validate-config", false, "Validate the configuration, print it and exit without touching any interface")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	env := newEnvExpander(os.LookupEnv)
+
+	// Settings of the configuration file stand in for flags not given on the command line
+	if envConfigFile := env.getenv("CONFIG_FILE"); envConfigFile != "" {
+		configFile = envConfigFile
+	}
+	if configFile = env.expand(configFile); configFile != "" || cp.fileData != nil {
+		file, err := cp.loadConfigFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := cp.validateConfigFile(file); err != nil {
+			return nil, err
+		}
+		if err := applyConfigFile(fs, file); err != nil {
+			return nil, err
+		}
+		for name, message := range file.Messages {
+			message.Name = name
+		}
+		config.NamedMessages = file.Messages
+		config.StartupSequence = file.OnStartup
+		config.ShutdownSequence = file.OnShutdown
+	}
+	config.ConfigFile = configFile
+	config.ValidateOnly = validateOnly
+
+	// Expand ${VAR} and ${VAR:-default} references in string settings
+	for _, value := range []*string{
+		&canPortsFlag, &serverPort, &samplePoint, &setupRetries, &setupDelays, &bitrates, &samplePoints, &tripleSampling, &oneShot, &watchdogEventLog, &expectTraffic,
+		&watchdogIntervals, &watchdogFailureThresholds, &watchdogSuccessThresholds, &watchdogCooldowns,
+		&instanceName, &webhookURLs, &webhookEvents, &webhookMinSeverity, &statsdAddress, &statsdPrefix, &statsdFormat, &defaultInterface,
+		&receiveBufferSizes, &txGapsUs, &txQueuePolicy, &txQueueWeights, &txQueueCapacity, &j1939Addresses, &interfaceAliases, &bufferIDs, &alertRulesFile, &simulatedNodesFile, &dbcFile,
+		&tlsCertFile, &tlsKeyFile, &tlsClientCA, &clientPermissions,
+		&apiKeysFile, &allowedNetworks, &trustedProxies, &sendAuditLog, &auditLog, &captureDir, &captureInterfaces, &logFile, &blackboxDir, &logLevel, &logLevels, &logFormat,
+		&logOutput, &syslogAddress, &syslogFacility, &accessSkip,
+		&listenUnix, &ipcSocket, &unixSocketMode, &unixSocketOwner, &corsOrigins, &corsMethods, &corsHeaders,
+	} {
+		*value = env.expand(*value)
+	}
 
-	flag.StringVar(&canPortsFlag, "can-ports", "", "Comma-separated list of CAN interfaces (e.g., can0,can1)")
-	flag.StringVar(&serverPort, "port", "5260", "HTTP server port")
-	flag.BoolVar(&autoSetup, "auto-setup", true, "Automatically setup CAN interfaces on startup")
-	flag.IntVar(&bitrate, "bitrate", 1000000, "Default CAN bitrate (bps)")
-	flag.StringVar(&samplePoint, "sample-point", "0.75", "Default CAN sample point")
-	flag.IntVar(&restartMs, "restart-ms", 100, "Default CAN restart timeout (ms)")
-	flag.IntVar(&setupRetry, "setup-retry", 3, "Number of setup retry attempts")
-	flag.IntVar(&setupDelaySeconds, "setup-delay", 2, "Delay between setup retries (seconds)")
-	flag.BoolVar(&setupFinderEnabled, "enable-finder", true, "Enable service finder")
-	flag.IntVar(&setupFinderInterval, "finder-interval", 5, "Interval for service finder in seconds")
-	flag.BoolVar(&setupHealthCheck, "enable-healthcheck", true, "Enable health check endpoint")
-	flag.Parse()
-
-	// Environment variables (override command line)
-	if envPorts := os.Getenv("CAN_PORTS"); envPorts != "" {
+	// Environment variables (override command line, references inside them are expanded too)
+	if envPorts := env.getenv("CAN_PORTS"); envPorts != "" {
 		canPortsFlag = envPorts
 	}
-	if envPort := os.Getenv("SERVER_PORT"); envPort != "" {
+	if envPort := env.getenv("SERVER_PORT"); envPort != "" {
 		serverPort = envPort
 	}
-	if envAutoSetup := os.Getenv("CAN_AUTO_SETUP"); envAutoSetup != "" {
+	if envAutoSetup := env.getenv("CAN_AUTO_SETUP"); envAutoSetup != "" {
 		if val, err := strconv.ParseBool(envAutoSetup); err == nil {
 			autoSetup = val
 		}
 	}
-	if envBitrate := os.Getenv("CAN_BITRATE"); envBitrate != "" {
+	if envTeardown := env.getenv("CAN_TEARDOWN_ON_EXIT"); envTeardown != "" {
+		if val, err := strconv.ParseBool(envTeardown); err == nil {
+			teardownOnExit = val
+		}
+	}
+	if envBitrate := env.getenv("CAN_BITRATE"); envBitrate != "" {
 		if val, err := strconv.Atoi(envBitrate); err == nil {
 			bitrate = val
 		}
 	}
-	if envSamplePoint := os.Getenv("CAN_SAMPLE_POINT"); envSamplePoint != "" {
+	if envSamplePoint := env.getenv("CAN_SAMPLE_POINT"); envSamplePoint != "" {
 		samplePoint = envSamplePoint
 	}
-	if envRestartMs := os.Getenv("CAN_RESTART_MS"); envRestartMs != "" {
+	if envRestartMs := env.getenv("CAN_RESTART_MS"); envRestartMs != "" {
 		if val, err := strconv.Atoi(envRestartMs); err == nil {
 			restartMs = val
 		}
 	}
-	if envSetupRetry := os.Getenv("CAN_SETUP_RETRY"); envSetupRetry != "" {
+	if envSetupRetry := env.getenv("CAN_SETUP_RETRY"); envSetupRetry != "" {
 		if val, err := strconv.Atoi(envSetupRetry); err == nil {
 			setupRetry = val
 		}
 	}
-	if envSetupDelay := os.Getenv("CAN_SETUP_DELAY"); envSetupDelay != "" {
+	if envSetupDelay := env.getenv("CAN_SETUP_DELAY"); envSetupDelay != "" {
 		if val, err := strconv.Atoi(envSetupDelay); err == nil {
 			setupDelaySeconds = val
 		}
 	}
-
-	// Parse CAN ports
-	if canPortsFlag != "" {
-		config.CanPorts = cp.parseCanPorts(canPortsFlag)
-	} else {
-		// Default to can0 if no ports specified
-		config.CanPorts = []string{"can0"}
+	if envBackoff := env.getenv("CAN_SETUP_RETRY_BACKOFF"); envBackoff != "" {
+		if val, err := strconv.ParseFloat(envBackoff, 64); err == nil {
+			setupRetryBackoff = val
+		}
 	}
-
-	// Validate and set configuration
-	if serverPort == "" {
-		return nil, fmt.Errorf("server port cannot be empty")
+	if envMaxDelay := env.getenv("CAN_SETUP_MAX_DELAY"); envMaxDelay != "" {
+		if val, err := strconv.Atoi(envMaxDelay); err == nil {
+			setupMaxDelaySeconds = val
+		}
 	}
-
-	if setupFinderEnabled {
-		if setupFinderInterval <= 0 {
-			return nil, fmt.Errorf("finder interval must be positive, got %d", config.SetupFinderInterval)
+	if envRetries := env.getenv("CAN_SETUP_RETRIES"); envRetries != "" {
+		setupRetries = envRetries
+	}
+	if envDelays := env.getenv("CAN_SETUP_DELAYS"); envDelays != "" {
+		setupDelays = envDelays
+	}
+	if envBitrates := env.getenv("CAN_BITRATES"); envBitrates != "" {
+		bitrates = envBitrates
+	}
+	if envSamplePoints := env.getenv("CAN_SAMPLE_POINTS"); envSamplePoints != "" {
+		samplePoints = envSamplePoints
+	}
+	if envTripleSampling := env.getenv("CAN_TRIPLE_SAMPLING"); envTripleSampling != "" {
+		tripleSampling = envTripleSampling
+	}
+	if envOneShot := env.getenv("CAN_ONE_SHOT"); envOneShot != "" {
+		oneShot = envOneShot
+	}
+	if envDryRun := env.getenv("CAN_DRY_RUN"); envDryRun != "" {
+		if val, err := strconv.ParseBool(envDryRun); err == nil {
+			dryRun = val
 		}
 	}
 
-	if setupHealthCheck {
-		config.EnableHealthCheck = true
-	} else {
-		config.EnableHealthCheck = false
+	if envBaseDelay := env.getenv("CAN_RECOVERY_BASE_DELAY"); envBaseDelay != "" {
+		if val, err := strconv.Atoi(envBaseDelay); err == nil {
+			recoveryBaseDelaySeconds = val
+		}
+	}
+	if envMaxDelay := env.getenv("CAN_RECOVERY_MAX_DELAY"); envMaxDelay != "" {
+		if val, err := strconv.Atoi(envMaxDelay); err == nil {
+			recoveryMaxDelaySeconds = val
+		}
+	}
+	if envStrategy := env.getenv("CAN_RECOVERY_STRATEGY"); envStrategy != "" {
+		recoveryStrategy = envStrategy
+	}
+	if envMaxAttempts := env.getenv("CAN_RECOVERY_MAX_ATTEMPTS"); envMaxAttempts != "" {
+		if val, err := strconv.Atoi(envMaxAttempts); err == nil {
+			recoveryMaxAttempts = val
+		}
 	}
 
-	config.Port = serverPort
-	config.AutoSetup = autoSetup
-	config.Bitrate = bitrate
-	config.SamplePoint = samplePoint
-	config.RestartMs = restartMs
-	config.SetupRetry = setupRetry
-	config.SetupDelay = time.Duration(setupDelaySeconds) * time.Second
-	config.EnableFinder = setupFinderEnabled
-	config.SetupFinderInterval = time.Duration(setupFinderInterval) * time.Second
+	if envCommandTimeout := env.getenv("CAN_COMMAND_TIMEOUT"); envCommandTimeout != "" {
+		if val, err := strconv.Atoi(envCommandTimeout); err == nil {
+			commandTimeoutSeconds = val
+		}
+	}
 
-	return config, nil
-}
+	if envEventLog := env.getenv("CAN_WATCHDOG_EVENT_LOG"); envEventLog != "" {
+		watchdogEventLog = envEventLog
+	}
 
-// parseCanPorts parses comma-separated CAN ports string
-func (cp *ConfigParser) parseCanPorts(portsStr string) []string {
-	ports := strings.Split(portsStr, ",")
-	// Trim whitespace from each port
-	for i, port := range ports {
-		ports[i] = strings.TrimSpace(port)
+	if envExpectTraffic := env.getenv("CAN_EXPECT_TRAFFIC"); envExpectTraffic != "" {
+		expectTraffic = envExpectTraffic
 	}
-	return ports
-}
 
-// ValidateConfig validates the configuration
-func (cp *ConfigParser) ValidateConfig(config *Config) error {
-	if len(config.CanPorts) == 0 {
-		return fmt.Errorf("at least one CAN port must be specified")
+	if envInterval := env.getenv("CAN_WATCHDOG_INTERVAL_MS"); envInterval != "" {
+		if val, err := strconv.Atoi(envInterval); err == nil {
+			watchdogIntervalMs = val
+		}
+	}
+	if envThreshold := env.getenv("CAN_WATCHDOG_FAILURE_THRESHOLD"); envThreshold != "" {
+		if val, err := strconv.Atoi(envThreshold); err == nil {
+			watchdogFailureThreshold = val
+		}
+	}
+	if envCooldown := env.getenv("CAN_WATCHDOG_COOLDOWN"); envCooldown != "" {
+		if val, err := strconv.Atoi(envCooldown); err == nil {
+			watchdogCooldownSeconds = val
+		}
+	}
+	if envThreshold := env.getenv("CAN_WATCHDOG_SUCCESS_THRESHOLD"); envThreshold != "" {
+		if val, err := strconv.Atoi(envThreshold); err == nil {
+			watchdogSuccessThreshold = val
+		}
+	}
+	if envIntervals := env.getenv("CAN_WATCHDOG_INTERVALS"); envIntervals != "" {
+		watchdogIntervals = envIntervals
+	}
+	if envThresholds := env.getenv("CAN_WATCHDOG_FAILURE_THRESHOLDS"); envThresholds != "" {
+		watchdogFailureThresholds = envThresholds
+	}
+	if envThresholds := env.getenv("CAN_WATCHDOG_SUCCESS_THRESHOLDS"); envThresholds != "" {
+		watchdogSuccessThresholds = envThresholds
+	}
+	if envCooldowns := env.getenv("CAN_WATCHDOG_COOLDOWNS"); envCooldowns != "" {
+		watchdogCooldowns = envCooldowns
 	}
 
-	for _, port := range config.CanPorts {
-		if strings.TrimSpace(port) == "" {
-			return fmt.Errorf("CAN port name cannot be empty")
+	if envInstance := env.getenv("CAN_INSTANCE_NAME"); envInstance != "" {
+		instanceName = envInstance
+	}
+	if envWebhooks := env.getenv("CAN_WEBHOOK_URLS"); envWebhooks != "" {
+		webhookURLs = envWebhooks
+	}
+	if envWebhookEvents := env.getenv("CAN_WEBHOOK_EVENTS"); envWebhookEvents != "" {
+		webhookEvents = envWebhookEvents
+	}
+	if envSeverity := env.getenv("CAN_WEBHOOK_MIN_SEVERITY"); envSeverity != "" {
+		webhookMinSeverity = envSeverity
+	}
+	if envStatsD := env.getenv("CAN_STATSD_ADDRESS"); envStatsD != "" {
+		statsdAddress = envStatsD
+	}
+	if envInterval := env.getenv("CAN_STATSD_INTERVAL"); envInterval != "" {
+		if val, err := strconv.Atoi(envInterval); err == nil {
+			statsdIntervalSeconds = val
 		}
 	}
+	if envPrefix := env.getenv("CAN_STATSD_PREFIX"); envPrefix != "" {
+		statsdPrefix = envPrefix
+	}
+	if envFormat := env.getenv("CAN_STATSD_FORMAT"); envFormat != "" {
+		statsdFormat = envFormat
+	}
 
-	if config.Port == "" {
-		return fmt.Errorf("server port cannot be empty")
+	if envConfirm := env.getenv("CAN_TX_CONFIRM_TIMEOUT_MS"); envConfirm != "" {
+		if val, err := strconv.Atoi(envConfirm); err == nil {
+			txConfirmTimeoutMs = val
+		}
 	}
 
-	// Validate CAN-specific settings
-	if config.Bitrate <= 0 {
-		return fmt.Errorf("bitrate must be positive, got %d", config.Bitrate)
+	if envBurst := env.getenv("CAN_ERROR_BURST_THRESHOLD"); envBurst != "" {
+		if val, err := strconv.Atoi(envBurst); err == nil {
+			errorBurstThreshold = val
+		}
 	}
 
-	// Common CAN bitrates validation
-	validBitrates := []int{
-		10000,   // 10 kbps
-		20000,   // 20 kbps
-		50000,   // 50 kbps
-		100000,  // 100 kbps
-		125000,  // 125 kbps
-		250000,  // 250 kbps
-		500000,  // 500 kbps
-		1000000, // 1 Mbps
+	if envDefault := env.getenv("CAN_DEFAULT_INTERFACE"); envDefault != "" {
+		defaultInterface = envDefault
 	}
 
-	validBitrate := false
-	for _, valid := range validBitrates {
-		if config.Bitrate == valid {
-			validBitrate = true
-			break
+	if envRcvbuf := env.getenv("CAN_RCVBUF_SIZE"); envRcvbuf != "" {
+		if val, err := strconv.Atoi(envRcvbuf); err == nil {
+			receiveBufferSize = val
 		}
 	}
-	if !validBitrate {
-		return fmt.Errorf("bitrate %d is not a standard CAN bitrate. Valid options: %v", config.Bitrate, validBitrates)
+	if envRcvbufs := env.getenv("CAN_RCVBUF_SIZES"); envRcvbufs != "" {
+		receiveBufferSizes = envRcvbufs
 	}
-
-	if config.SamplePoint != "" {
-		if point, err := strconv.ParseFloat(config.SamplePoint, 64); err != nil {
-			return fmt.Errorf("invalid sample point format: %s", config.SamplePoint)
-		} else if point <= 0 || point >= 1 {
-			return fmt.Errorf("sample point must be between 0 and 1, got %f", point)
+	if envGap := env.getenv("CAN_TX_GAP_US"); envGap != "" {
+		if val, err := strconv.Atoi(envGap); err == nil {
+			txGapUs = val
+		}
+	}
+	if envGaps := env.getenv("CAN_TX_GAPS_US"); envGaps != "" {
+		txGapsUs = envGaps
+	}
+	if envPolicy := env.getenv("CAN_TX_QUEUE_POLICY"); envPolicy != "" {
+		txQueuePolicy = envPolicy
+	}
+	if envWeights := env.getenv("CAN_TX_QUEUE_WEIGHTS"); envWeights != "" {
+		txQueueWeights = envWeights
+	}
+	if envCapacity := env.getenv("CAN_TX_QUEUE_CAPACITY"); envCapacity != "" {
+		txQueueCapacity = envCapacity
+	}
+	if envAttempts := env.getenv("CAN_TX_RETRY_ATTEMPTS"); envAttempts != "" {
+		if val, err := strconv.Atoi(envAttempts); err == nil {
+			txRetryAttempts = val
+		}
+	}
+	if envDeadline := env.getenv("CAN_TX_RETRY_DEADLINE_MS"); envDeadline != "" {
+		if val, err := strconv.Atoi(envDeadline); err == nil {
+			txRetryDeadlineMs = val
+		}
+	}
+	if envPoll := env.getenv("CAN_TX_RETRY_POLL"); envPoll != "" {
+		if val, err := strconv.ParseBool(envPoll); err == nil {
+			txRetryPoll = val
 		}
 	}
+	if envJ1939 := env.getenv("CAN_J1939"); envJ1939 != "" {
+		j1939Addresses = envJ1939
+	}
+	if envAliases := env.getenv("CAN_INTERFACE_ALIASES"); envAliases != "" {
+		interfaceAliases = envAliases
+	}
+	if envBufferIDs := env.getenv("CAN_BUFFER_IDS"); envBufferIDs != "" {
+		bufferIDs = envBufferIDs
+	}
 
-	if config.RestartMs < 0 {
-		return fmt.Errorf("restart timeout cannot be negative, got %d", config.RestartMs)
+	if envAlertRules := env.getenv("CAN_ALERT_RULES"); envAlertRules != "" {
+		alertRulesFile = envAlertRules
 	}
 
-	if config.SetupRetry <= 0 {
-		return fmt.Errorf("setup retry count must be positive, got %d", config.SetupRetry)
+	if envSimulatedNodes := env.getenv("CAN_SIMULATED_NODES"); envSimulatedNodes != "" {
+		simulatedNodesFile = envSimulatedNodes
 	}
 
-	if config.SetupDelay < 0 {
-		return fmt.Errorf("setup delay cannot be negative, got %v", config.SetupDelay)
+	if envDBC := env.getenv("CAN_DBC_FILE"); envDBC != "" {
+		dbcFile = envDBC
 	}
 
-	return nil
-}
+	if envCert := env.getenv("CAN_TLS_CERT"); envCert != "" {
+		tlsCertFile = envCert
+	}
+	if envKey := env.getenv("CAN_TLS_KEY"); envKey != "" {
+		tlsKeyFile = envKey
+	}
+	if envClientCA := env.getenv("CAN_TLS_CLIENT_CA"); envClientCA != "" {
+		tlsClientCA = envClientCA
+	}
+	if envPermissions := env.getenv("CAN_TLS_CLIENT_PERMISSIONS"); envPermissions != "" {
+		clientPermissions = envPermissions
+	}
 
-// GetConfigSummary returns a summary of the current configuration
-func (cp *ConfigParser) GetConfigSummary(config *Config) map[string]interface{} {
-	return map[string]interface{}{
-		"canPorts":    config.CanPorts,
-		"serverPort":  config.Port,
-		"autoSetup":   config.AutoSetup,
-		"bitrate":     config.Bitrate,
-		"samplePoint": config.SamplePoint,
-		"restartMs":   config.RestartMs,
-		"setupRetry":  config.SetupRetry,
-		"setupDelay":  config.SetupDelay.String(),
+	if envAPIKeys := env.getenv("CAN_API_KEYS"); envAPIKeys != "" {
+		apiKeysFile = envAPIKeys
 	}
-}
 
-// PrintUsage prints usage information
-func PrintUsage() {
-	fmt.Println("CAN Communication Service")
-	fmt.Println("Usage:")
-	fmt.Println("  -can-ports string       Comma-separated list of CAN interfaces (default: can0)")
-	fmt.Println("  -port string            HTTP server port (default: 5260)")
-	fmt.Println("  -auto-setup             Automatically setup CAN interfaces on startup (default: true)")
-	fmt.Println("  -bitrate int            Default CAN bitrate in bps (default: 1000000)")
-	fmt.Println("  -sample-point string    Default CAN sample point (default: 0.75)")
-	fmt.Println("  -restart-ms int         Default CAN restart timeout in ms (default: 100)")
-	fmt.Println("  -setup-retry int        Number of setup retry attempts (default: 3)")
-	fmt.Println("  -setup-delay int        Delay between setup retries in seconds (default: 2)")
-	fmt.Println("  -enable-finder          Enable service finder (default: true)")
-	fmt.Println("  -finder-interval int    Interval for service finder in seconds (default: 5)")
-	fmt.Println("  -enable-healthcheck     Enable health check endpoint (default: true)")
-	fmt.Println("")
-	fmt.Println("Environment Variables:")
-	fmt.Println("  CAN_PORTS              Comma-separated list of CAN interfaces")
-	fmt.Println("  SERVER_PORT            HTTP server port")
-	fmt.Println("  CAN_AUTO_SETUP         Automatically setup CAN interfaces (true/false)")
-	fmt.Println("  CAN_BITRATE            Default CAN bitrate in bps")
-	fmt.Println("  CAN_SAMPLE_POINT       Default CAN sample point")
-	fmt.Println("  CAN_RESTART_MS         Default CAN restart timeout in ms")
-	fmt.Println("  CAN_SETUP_RETRY        Number of setup retry attempts")
-	fmt.Println("  CAN_SETUP_DELAY        Delay between setup retries in seconds")
-	fmt.Println("")
-	fmt.Println("Examples:")
-	fmt.Println("  # Basic usage with default settings")
-	fmt.Println("  ./can-bridge -can-ports can0,can1")
-	fmt.Println("")
-	fmt.Println("  # Custom bitrate and sample point")
-	fmt.Println("  ./can-bridge -can-ports can0 -bitrate 500000 -sample-point 0.8")
-	fmt.Println("")
-	fmt.Println("  # Disable auto-setup (manual setup via API)")
-	fmt.Println("  ./can-bridge -can-ports can0,can1 -auto-setup=false")
-	fmt.Println("")
-	fmt.Println("  # Using environment variables")
-	fmt.Println("  CAN_PORTS=can0,can1 CAN_BITRATE=500000 ./can-bridge")
-	fmt.Println("")
-	fmt.Println("  # High availability setup with more retries")
-	fmt.Println("  ./can-bridge -can-ports can0,can1 -setup-retry 5 -setup-delay 3")
+	if envAuditLog := env.getenv("CAN_SEND_AUDIT_LOG"); envAuditLog != "" {
+		sendAuditLog = envAuditLog
+	}
+	if envAuditLog := env.getenv("CAN_AUDIT_LOG"); envAuditLog != "" {
+		auditLog = envAuditLog
+	}
+	if envSize := env.getenv("CAN_AUDIT_LOG_MAX_SIZE"); envSize != "" {
+		if val, err := strconv.ParseInt(envSize, 10, 64); err == nil {
+			auditLogMaxSize = val
+		}
+	}
+	if envAge := env.getenv("CAN_AUDIT_LOG_MAX_AGE"); envAge != "" {
+		if val, err := strconv.Atoi(envAge); err == nil {
+			auditLogMaxAgeHours = val
+		}
+	}
+	if envFiles := env.getenv("CAN_AUDIT_LOG_MAX_FILES"); envFiles != "" {
+		if val, err := strconv.Atoi(envFiles); err == nil {
+			auditLogMaxFiles = val
+		}
+	}
+	if envCompress := env.getenv("CAN_AUDIT_LOG_COMPRESS"); envCompress != "" {
+		if val, err := strconv.ParseBool(envCompress); err == nil {
+			auditLogCompress = val
+		}
+	}
+	if envCaptureDir := env.getenv("CAN_CAPTURE_DIR"); envCaptureDir != "" {
+		captureDir = envCaptureDir
+	}
+	if envCapture := env.getenv("CAN_CAPTURE"); envCapture != "" {
+		captureInterfaces = envCapture
+	}
+	if envCaptureTX := env.getenv("CAN_CAPTURE_TX"); envCaptureTX != "" {
+		if val, err := strconv.ParseBool(envCaptureTX); err == nil {
+			captureTX = val
+		}
+	}
+	if envContext := env.getenv("CAN_CAPTURE_TX_CONTEXT"); envContext != "" {
+		if val, err := strconv.ParseBool(envContext); err == nil {
+			captureTXContext = val
+		}
+	}
+	if envSize := env.getenv("CAN_CAPTURE_MAX_FILE_SIZE"); envSize != "" {
+		if val, err := strconv.ParseInt(envSize, 10, 64); err == nil {
+			captureMaxFileSize = val
+		}
+	}
+	if envSize := env.getenv("CAN_CAPTURE_MAX_TOTAL_SIZE"); envSize != "" {
+		if val, err := strconv.ParseInt(envSize, 10, 64); err == nil {
+			captureMaxTotalSize = val
+		}
+	}
+	if envAge := env.getenv("CAN_CAPTURE_MAX_AGE"); envAge != "" {
+		if val, err := strconv.Atoi(envAge); err == nil {
+			captureMaxAgeHours = val
+		}
+	}
+	if envFiles := env.getenv("CAN_CAPTURE_MAX_FILES"); envFiles != "" {
+		if val, err := strconv.Atoi(envFiles); err == nil {
+			captureMaxFiles = val
+		}
+	}
+	if envCompress := env.getenv("CAN_CAPTURE_COMPRESS"); envCompress != "" {
+		if val, err := strconv.ParseBool(envCompress); err == nil {
+			captureCompress = val
+		}
+	}
+
+	if envLevel := env.getenv("CAN_LOG_LEVEL"); envLevel != "" {
+		logLevel = envLevel
+	}
+	if envLevels := env.getenv("CAN_LOG_LEVELS"); envLevels != "" {
+		logLevels = envLevels
+	}
+	if envFormat := env.getenv("CAN_LOG_FORMAT"); envFormat != "" {
+		logFormat = envFormat
+	}
+	if envNoEmoji := env.getenv("CAN_LOG_NO_EMOJI"); envNoEmoji != "" {
+		if val, err := strconv.ParseBool(envNoEmoji); err == nil {
+			logNoEmoji = val
+		}
+	}
+	if envTxFrames := env.getenv("CAN_LOG_TX_FRAMES"); envTxFrames != "" {
+		if val, err := strconv.ParseBool(envTxFrames); err == nil {
+			logTxFrames = val
+		}
+	}
+	if envRateLimit := env.getenv("CAN_LOG_RATE_LIMIT"); envRateLimit != "" {
+		if val, err := strconv.Atoi(envRateLimit); err == nil {
+			logRateLimit = val
+		}
+	}
+	if envSummary := env.getenv("CAN_LOG_SUMMARY_INTERVAL"); envSummary != "" {
+		if val, err := strconv.Atoi(envSummary); err == nil {
+			logSummaryIntervalSeconds = val
+		}
+	}
+	if envSkip, ok := env.lookup("CAN_LOG_ACCESS_SKIP"); ok {
+		accessSkip = envSkip
+	}
+	if envRate := env.getenv("CAN_LOG_ACCESS_SAMPLE_RATE"); envRate != "" {
+		if val, err := strconv.ParseFloat(envRate, 64); err == nil {
+			accessSampleRate = val
+		}
+	}
+	if envSlow := env.getenv("CAN_LOG_ACCESS_SLOW_MS"); envSlow != "" {
+		if val, err := strconv.ParseInt(envSlow, 10, 64); err == nil {
+			accessSlowMs = val
+		}
+	}
+	if envOutput := env.getenv("CAN_LOG_OUTPUT"); envOutput != "" {
+		logOutput = envOutput
+	}
+	if envAddress := env.getenv("CAN_SYSLOG_ADDRESS"); envAddress != "" {
+		syslogAddress = envAddress
+	}
+	if envFacility := env.getenv("CAN_SYSLOG_FACILITY"); envFacility != "" {
+		syslogFacility = envFacility
+	}
+	if envLogFile := env.getenv("CAN_LOG_FILE"); envLogFile != "" {
+		logFile = envLogFile
+	}
+	if envSize := env.getenv("CAN_LOG_MAX_SIZE"); envSize != "" {
+		if val, err := strconv.ParseInt(envSize, 10, 64); err == nil {
+			logMaxSize = val
+		}
+	}
+	if envAge := env.getenv("CAN_LOG_MAX_AGE"); envAge != "" {
+		if val, err := strconv.Atoi(envAge); err == nil {
+			logMaxAgeHours = val
+		}
+	}
+	if envFiles := env.getenv("CAN_LOG_MAX_FILES"); envFiles != "" {
+		if val, err := strconv.Atoi(envFiles); err == nil {
+			logMaxFiles = val
+		}
+	}
+	if envCompress := env.getenv("CAN_LOG_COMPRESS"); envCompress != "" {
+		if val, err := strconv.ParseBool(envCompress); err == nil {
+			logCompress = val
+		}
+	}
+	if envDir := env.getenv("CAN_BLACKBOX_DIR"); envDir != "" {
+		blackboxDir = envDir
+	}
+	if envFiles := env.getenv("CAN_BLACKBOX_MAX_FILES"); envFiles != "" {
+		if val, err := strconv.Atoi(envFiles); err == nil {
+			blackboxMaxFiles = val
+		}
+	}
+
+	if envOrigins := env.getenv("CAN_CORS_ORIGINS"); envOrigins != "" {
+		corsOrigins = envOrigins
+	}
+	if envMethods := env.getenv("CAN_CORS_METHODS"); envMethods != "" {
+		corsMethods = envMethods
+	}
+	if envHeaders := env.getenv("CAN_CORS_HEADERS"); envHeaders != "" {
+		corsHeaders = envHeaders
+	}
+	if envCredentials := env.getenv("CAN_CORS_CREDENTIALS"); envCredentials != "" {
+		if val, err := strconv.ParseBool(envCredentials); err == nil {
+			corsCredentials = val
+		}
+	}
+	if envMaxAge := env.getenv("CAN_CORS_MAX_AGE"); envMaxAge != "" {
+		if val, err := strconv.Atoi(envMaxAge); err == nil {
+			corsMaxAgeSeconds = val
+		}
+	}
+
+	if envListenUnix := env.getenv("CAN_LISTEN_UNIX"); envListenUnix != "" {
+		listenUnix = envListenUnix
+	}
+
+	if envIPCSocket := env.getenv("CAN_IPC_SOCKET"); envIPCSocket != "" {
+		ipcSocket = envIPCSocket
+	}
+	if envSocketMode := env.getenv("CAN_UNIX_SOCKET_MODE"); envSocketMode != "" {
+		unixSocketMode = envSocketMode
+	}
+	if envSocketOwner := env.getenv("CAN_UNIX_SOCKET_OWNER"); envSocketOwner != "" {
+		unixSocketOwner = envSocketOwner
+	}
+	if envSocketTrusted := env.getenv("CAN_UNIX_SOCKET_TRUSTED"); envSocketTrusted != "" {
+		if val, err := strconv.ParseBool(envSocketTrusted); err == nil {
+			unixSocketTrusted = val
+		}
+	}
+
+	if envAPIDocs := env.getenv("CAN_API_DOCS"); envAPIDocs != "" {
+		if val, err := strconv.ParseBool(envAPIDocs); err == nil {
+			apiDocs = val
+		}
+	}
+
+	if envLegacy := env.getenv("CAN_LEGACY_API_ROUTES"); envLegacy != "" {
+		if val, err := strconv.ParseBool(envLegacy); err == nil {
+			legacyAPIRoutes = val
+		}
+	}
+
+	if envReadyAll := env.getenv("CAN_READY_REQUIRES_ALL"); envReadyAll != "" {
+		if val, err := strconv.ParseBool(envReadyAll); err == nil {
+			readyRequiresAll = val
+		}
+	}
+
+	if envLiveness := env.getenv("CAN_LIVENESS_TIMEOUT"); envLiveness != "" {
+		if val, err := strconv.Atoi(envLiveness); err == nil {
+			livenessTimeoutSeconds = val
+		}
+	}
+
+	if envDrain := env.getenv("CAN_DRAIN_TIMEOUT"); envDrain != "" {
+		if val, err := strconv.Atoi(envDrain); err == nil {
+			drainTimeoutSeconds = val
+		}
+	}
+
+	if envShutdown := env.getenv("CAN_SHUTDOWN_TIMEOUT"); envShutdown != "" {
+		if val, err := strconv.Atoi(envShutdown); err == nil {
+			shutdownTimeoutSeconds = val
+		}
+	}
+
+	if envSize := env.getenv("CAN_IDEMPOTENCY_CACHE_SIZE"); envSize != "" {
+		if val, err := strconv.Atoi(envSize); err == nil {
+			idempotencyCacheSize = val
+		}
+	}
+
+	if envSize := env.getenv("CAN_MAX_BODY_SIZE"); envSize != "" {
+		if val, err := strconv.ParseInt(envSize, 10, 64); err == nil {
+			maxBodySize = val
+		}
+	}
+
+	if envFrames := env.getenv("CAN_MAX_BATCH_FRAMES"); envFrames != "" {
+		if val, err := strconv.Atoi(envFrames); err == nil {
+			maxBatchFrames = val
+		}
+	}
+
+	if envTTL := env.getenv("CAN_IDEMPOTENCY_TTL"); envTTL != "" {
+		if val, err := strconv.Atoi(envTTL); err == nil {
+			idempotencyTTLSeconds = val
+		}
+	}
+
+	if envAllowed := env.getenv("CAN_ALLOWED_NETWORKS"); envAllowed != "" {
+		allowedNetworks = envAllowed
+	}
+	if envProxies := env.getenv("CAN_TRUSTED_PROXIES"); envProxies != "" {
+		trustedProxies = envProxies
+	}
+
+	// OpenTelemetry export is configured only through the standard OTEL_* variables
+	otlpConfig, otlpErr := cp.parseOTLPConfig(env)
+	if otlpErr != nil {
+		config.parseErrors.add("OTEL_EXPORTER_OTLP_HEADERS", nil, "%v", otlpErr)
+	}
+	config.OTLP = otlpConfig
+
+	// Unresolved references are reported by ValidateConfig
+	config.UnresolvedEnvVars = env.missing()
+
+	// Parse CAN ports
+	if canPortsFlag != "" {
+		config.CanPorts = cp.parseCanPorts(canPortsFlag)
+	} else {
+		// Default to can0 if no ports specified
+		config.CanPorts = []string{"can0"}
+	}
+
+	// Validate and set configuration
+	if setupFinderEnabled {
+		if setupFinderInterval <= 0 {
+			config.parseErrors.add("finder-interval", setupFinderInterval, "finder interval must be positive")
+		}
+	}
+
+	if setupHealthCheck {
+		config.EnableHealthCheck = true
+	} else {
+		config.EnableHealthCheck = false
+	}
+
+	config.Port = serverPort
+	config.AutoSetup = autoSetup
+	config.TeardownOnExit = teardownOnExit
+	config.Bitrate = bitrate
+	config.SamplePoint = samplePoint
+	config.RestartMs = restartMs
+	config.SetupRetry = setupRetry
+	config.SetupDelay = time.Duration(setupDelaySeconds) * time.Second
+	config.SetupRetryBackoff = setupRetryBackoff
+	config.SetupMaxDelay = time.Duration(setupMaxDelaySeconds) * time.Second
+	config.EnableFinder = setupFinderEnabled
+	config.SetupFinderInterval = time.Duration(setupFinderInterval) * time.Second
+	config.DryRun = dryRun
+	config.RecoveryBaseDelay = time.Duration(recoveryBaseDelaySeconds) * time.Second
+	config.RecoveryMaxDelay = time.Duration(recoveryMaxDelaySeconds) * time.Second
+	config.RecoveryStrategy = strings.ToLower(strings.TrimSpace(recoveryStrategy))
+	config.RecoveryMaxAttempts = recoveryMaxAttempts
+	config.CommandTimeout = time.Duration(commandTimeoutSeconds) * time.Second
+	config.WatchdogEventLog = watchdogEventLog
+	config.TxConfirmTimeout = time.Duration(txConfirmTimeoutMs) * time.Millisecond
+	config.ErrorBurstThreshold = errorBurstThreshold
+	config.DefaultInterface = strings.TrimSpace(defaultInterface)
+	config.ReceiveBufferSize = receiveBufferSize
+	config.TxGap = time.Duration(txGapUs) * time.Microsecond
+
+	var err error
+	if config.SetupRetries, err = cp.parseInterfaceInts(setupRetries); err != nil {
+		config.parseErrors.add("setup-retries", setupRetries, "%v", err)
+	}
+	if config.SetupDelays, err = cp.parseInterfaceDurations(setupDelays); err != nil {
+		config.parseErrors.add("setup-delays", setupDelays, "%v", err)
+	}
+	if config.Bitrates, err = cp.parseInterfaceInts(bitrates); err != nil {
+		config.parseErrors.add("bitrates", bitrates, "%v", err)
+	}
+	if config.SamplePoints, err = cp.parseInterfaceOverrides(samplePoints); err != nil {
+		config.parseErrors.add("sample-points", samplePoints, "%v", err)
+	}
+	config.TripleSampling = cp.parseList(tripleSampling)
+	config.OneShot = cp.parseList(oneShot)
+	if config.ExpectTraffic, err = cp.parseInterfaceDurations(expectTraffic); err != nil {
+		config.parseErrors.add("expect-traffic", expectTraffic, "%v", err)
+	}
+	if config.ReceiveBufferSizes, err = cp.parseInterfaceInts(receiveBufferSizes); err != nil {
+		config.parseErrors.add("rcvbuf-sizes", receiveBufferSizes, "%v", err)
+	}
+	if gaps, err := cp.parseInterfaceInts(txGapsUs); err != nil {
+		config.parseErrors.add("tx-gaps-us", txGapsUs, "%v", err)
+	} else {
+		config.TxGaps = make(map[string]time.Duration, len(gaps))
+		for ifName, us := range gaps {
+			config.TxGaps[ifName] = time.Duration(us) * time.Microsecond
+		}
+	}
+	config.TxQueue.Policy = strings.ToLower(strings.TrimSpace(txQueuePolicy))
+	if config.TxQueue.Weights, err = parseTxBandInts(txQueueWeights, DefaultTxQueueWeights); err != nil {
+		config.parseErrors.add("tx-queue-weights", txQueueWeights, "%v", err)
+	}
+	if config.TxQueue.Capacity, err = parseTxBandInts(txQueueCapacity, DefaultTxQueueCapacity); err != nil {
+		config.parseErrors.add("tx-queue-capacity", txQueueCapacity, "%v", err)
+	}
+	config.TxRetry = TxRetryConfig{
+		MaxAttempts: txRetryAttempts,
+		Deadline:    time.Duration(txRetryDeadlineMs) * time.Millisecond,
+		Poll:        txRetryPoll,
+	}
+	if config.J1939, err = cp.parseJ1939Addresses(j1939Addresses); err != nil {
+		config.parseErrors.add("j1939", j1939Addresses, "%v", err)
+	}
+	if config.InterfaceAliases, err = cp.parseInterfaceAliases(interfaceAliases); err != nil {
+		config.parseErrors.add("interface-aliases", interfaceAliases, "%v", err)
+	}
+	if config.BufferFilters, err = cp.parseBufferFilters(bufferIDs); err != nil {
+		config.parseErrors.add("buffer-ids", bufferIDs, "%v", err)
+	}
+	if alertRulesFile != "" {
+		if config.AlertRules, err = LoadAlertRules(alertRulesFile); err != nil {
+			config.parseErrors.add("alert-rules", alertRulesFile, "%v", err)
+		}
+	}
+	if simulatedNodesFile != "" {
+		if config.SimulatedNodes, err = LoadSimulatedNodes(simulatedNodesFile); err != nil {
+			config.parseErrors.add("simulated-nodes", simulatedNodesFile, "%v", err)
+		}
+	}
+	if dbcFile != "" {
+		if config.DBC, err = LoadDBC(dbcFile); err != nil {
+			config.parseErrors.add("dbc", dbcFile, "%v", err)
+		}
+	}
+
+	config.TLSCertFile = tlsCertFile
+	config.TLSKeyFile = tlsKeyFile
+	config.TLSClientCA = tlsClientCA
+	if config.ClientPermissions, err = cp.parseClientPermissions(clientPermissions); err != nil {
+		config.parseErrors.add("tls-client-permissions", clientPermissions, "%v", err)
+	}
+	if apiKeysFile != "" {
+		if config.APIKeys, err = LoadAPIKeys(apiKeysFile); err != nil {
+			config.parseErrors.add("api-keys", apiKeysFile, "%v", err)
+		}
+	}
+	config.SendAuditLog = sendAuditLog
+	config.AuditLog = auditLog
+	config.AuditLogRotation = RotationPolicy{
+		MaxSize:  auditLogMaxSize,
+		MaxAge:   time.Duration(auditLogMaxAgeHours) * time.Hour,
+		MaxFiles: auditLogMaxFiles,
+		Compress: auditLogCompress,
+	}
+	config.Capture = CaptureConfig{
+		Dir:          captureDir,
+		Interfaces:   cp.parseList(captureInterfaces),
+		TX:           captureTX,
+		TXContext:    captureTXContext,
+		MaxFileSize:  captureMaxFileSize,
+		MaxTotalSize: captureMaxTotalSize,
+		MaxAge:       time.Duration(captureMaxAgeHours) * time.Hour,
+		MaxFiles:     captureMaxFiles,
+		Compress:     captureCompress,
+	}
+	if config.LogLevel, err = ParseLogLevel(logLevel); err != nil {
+		config.parseErrors.add("log-level", logLevel, "%v", err)
+	}
+	if config.LogLevels, err = cp.parseLogLevels(logLevels); err != nil {
+		config.parseErrors.add("log-levels", logLevels, "%v", err)
+	}
+	config.LogFormat = strings.ToLower(strings.TrimSpace(logFormat))
+	config.LogNoEmoji = logNoEmoji
+	config.LogTxFrames = logTxFrames
+	config.LogRateLimit = logRateLimit
+	config.LogSummaryInterval = time.Duration(logSummaryIntervalSeconds) * time.Second
+	config.AccessLog = AccessLogSettings{
+		SkipPaths:       cp.parseList(accessSkip),
+		SampleRate:      accessSampleRate,
+		SlowThresholdMs: accessSlowMs,
+	}
+	config.LogOutputs = cp.parseList(strings.ToLower(logOutput))
+	config.SyslogAddress = strings.TrimSpace(syslogAddress)
+	config.SyslogFacility = strings.ToLower(strings.TrimSpace(syslogFacility))
+	config.LogFile = logFile
+	config.LogRotation = RotationPolicy{
+		MaxSize:  logMaxSize,
+		MaxAge:   time.Duration(logMaxAgeHours) * time.Hour,
+		MaxFiles: logMaxFiles,
+		Compress: logCompress,
+	}
+	config.BlackboxDir = blackboxDir
+	config.BlackboxMaxFiles = blackboxMaxFiles
+	config.CORS = CORSConfig{
+		AllowedOrigins:   cp.parseList(corsOrigins),
+		AllowedMethods:   cp.parseList(corsMethods),
+		AllowedHeaders:   cp.parseList(corsHeaders),
+		AllowCredentials: corsCredentials,
+		MaxAge:           time.Duration(corsMaxAgeSeconds) * time.Second,
+	}
+	config.ListenUnix = listenUnix
+	config.IPCSocket = ipcSocket
+	config.UnixSocketOwner = unixSocketOwner
+	config.UnixSocketTrusted = unixSocketTrusted
+	if config.UnixSocketMode, err = parseSocketMode(unixSocketMode); err != nil {
+		config.parseErrors.add("unix-socket-mode", unixSocketMode, "%v", err)
+	}
+	config.APIDocs = apiDocs
+	config.LegacyAPIRoutes = legacyAPIRoutes
+	config.ReadyRequiresAll = readyRequiresAll
+	config.LivenessTimeout = time.Duration(livenessTimeoutSeconds) * time.Second
+	config.DrainTimeout = time.Duration(drainTimeoutSeconds) * time.Second
+	config.ShutdownTimeout = time.Duration(shutdownTimeoutSeconds) * time.Second
+	config.IdempotencyCacheSize = idempotencyCacheSize
+	config.IdempotencyTTL = time.Duration(idempotencyTTLSeconds) * time.Second
+	config.MaxBodySize = maxBodySize
+	config.MaxBatchFrames = maxBatchFrames
+	if config.AllowedNetworks, err = cp.parseNetworks(allowedNetworks); err != nil {
+		config.parseErrors.add("allowed-networks", allowedNetworks, "%v", err)
+	}
+	if config.TrustedProxies, err = cp.parseNetworks(trustedProxies); err != nil {
+		config.parseErrors.add("trusted-proxies", trustedProxies, "%v", err)
+	}
+
+	if instanceName == "" {
+		if hostname, err := os.Hostname(); err == nil {
+			instanceName = hostname
+		}
+	}
+	config.InstanceName = instanceName
+	config.OTLP.Instance = instanceName
+	config.StatsD = StatsDConfig{
+		Address:  strings.TrimSpace(statsdAddress),
+		Interval: time.Duration(statsdIntervalSeconds) * time.Second,
+		Prefix:   strings.TrimSpace(statsdPrefix),
+		Format:   statsdFormat,
+		Instance: instanceName,
+	}
+	config.WebhookURLs = cp.parseList(webhookURLs)
+	config.WebhookEvents = cp.parseList(webhookEvents)
+	config.WebhookMinSeverity = webhookMinSeverity
+
+	config.WatchdogInterval = time.Duration(watchdogIntervalMs) * time.Millisecond
+	config.WatchdogFailureThreshold = watchdogFailureThreshold
+	config.WatchdogSuccessThreshold = watchdogSuccessThreshold
+	config.WatchdogCooldown = time.Duration(watchdogCooldownSeconds) * time.Second
+	if config.WatchdogIntervals, err = cp.parseInterfaceDurations(watchdogIntervals); err != nil {
+		config.parseErrors.add("watchdog-intervals", watchdogIntervals, "%v", err)
+	}
+	if config.WatchdogFailureThresholds, err = cp.parseInterfaceInts(watchdogFailureThresholds); err != nil {
+		config.parseErrors.add("watchdog-failure-thresholds", watchdogFailureThresholds, "%v", err)
+	}
+	if config.WatchdogSuccessThresholds, err = cp.parseInterfaceInts(watchdogSuccessThresholds); err != nil {
+		config.parseErrors.add("watchdog-success-thresholds", watchdogSuccessThresholds, "%v", err)
+	}
+	if config.WatchdogCooldowns, err = cp.parseInterfaceDurations(watchdogCooldowns); err != nil {
+		config.parseErrors.add("watchdog-cooldowns", watchdogCooldowns, "%v", err)
+	}
+
+	return config, nil
+}
+
+// parseOTLPConfig reads OTLP export settings. Signal-specific endpoints are used as is;
+// the generic endpoint gets the /v1/traces and /v1/metrics paths appended.
+func (cp *ConfigParser) parseOTLPConfig(env *envExpander) (OTLPConfig, error) {
+	config := DefaultOTLPConfig()
+	if strings.EqualFold(env.getenv("OTEL_SDK_DISABLED"), "true") {
+		return config, nil
+	}
+
+	endpoint := strings.TrimRight(env.getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "/")
+	config.TracesEndpoint = env.getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	if config.TracesEndpoint == "" && endpoint != "" {
+		config.TracesEndpoint = endpoint + "/v1/traces"
+	}
+	config.MetricsEndpoint = env.getenv("OTEL_EXPORTER_OTLP_METRICS_ENDPOINT")
+	if config.MetricsEndpoint == "" && endpoint != "" {
+		config.MetricsEndpoint = endpoint + "/v1/metrics"
+	}
+	if env.getenv("OTEL_TRACES_EXPORTER") == "none" {
+		config.TracesEndpoint = ""
+	}
+	if env.getenv("OTEL_METRICS_EXPORTER") == "none" {
+		config.MetricsEndpoint = ""
+	}
+
+	if protocol := env.getenv("OTEL_EXPORTER_OTLP_PROTOCOL"); protocol != "" {
+		config.Protocol = protocol
+	}
+	if serviceName := env.getenv("OTEL_SERVICE_NAME"); serviceName != "" {
+		config.ServiceName = serviceName
+	}
+	if timeout := env.getenv("OTEL_EXPORTER_OTLP_TIMEOUT"); timeout != "" {
+		if val, err := strconv.Atoi(timeout); err == nil {
+			config.Timeout = time.Duration(val) * time.Millisecond
+		}
+	}
+	if interval := env.getenv("OTEL_METRIC_EXPORT_INTERVAL"); interval != "" {
+		if val, err := strconv.Atoi(interval); err == nil {
+			config.MetricInterval = time.Duration(val) * time.Millisecond
+		}
+	}
+
+	// Headers are key=value pairs separated by commas, with URL-encoded values
+	if headers := env.getenv("OTEL_EXPORTER_OTLP_HEADERS"); headers != "" {
+		config.Headers = make(map[string]string)
+		for _, pair := range cp.parseList(headers) {
+			name, value, found := strings.Cut(pair, "=")
+			if !found || strings.TrimSpace(name) == "" {
+				return config, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS entry %q: expected key=value", pair)
+			}
+			decoded, err := url.QueryUnescape(strings.TrimSpace(value))
+			if err != nil {
+				return config, fmt.Errorf("invalid OTEL_EXPORTER_OTLP_HEADERS value for %s: %w", name, err)
+			}
+			config.Headers[strings.TrimSpace(name)] = decoded
+		}
+	}
+
+	return config, nil
+}
+
+// parseCanPorts parses comma-separated CAN ports string
+func (cp *ConfigParser) parseCanPorts(portsStr string) []string {
+	ports := strings.Split(portsStr, ",")
+	// Trim whitespace from each port
+	for i, port := range ports {
+		ports[i] = strings.TrimSpace(port)
+	}
+	return ports
+}
+
+// parseInterfaceOverrides parses a per-interface setting string ("can0=value,can1=value")
+func (cp *ConfigParser) parseInterfaceOverrides(value string) (map[string]string, error) {
+	result := make(map[string]string)
+	if strings.TrimSpace(value) == "" {
+		return result, nil
+	}
+
+	for _, entry := range strings.Split(value, ",") {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected interface=value, got %q", entry)
+		}
+		ifName := strings.TrimSpace(parts[0])
+		if ifName == "" {
+			return nil, fmt.Errorf("missing interface name in %q", entry)
+		}
+		setting := strings.TrimSpace(parts[1])
+		if previous, exists := result[ifName]; exists {
+			if previous != setting {
+				return nil, fmt.Errorf("interface %s specified more than once, with conflicting values %q and %q", ifName, previous, setting)
+			}
+			return nil, fmt.Errorf("interface %s specified more than once", ifName)
+		}
+		result[ifName] = setting
+	}
+
+	return result, nil
+}
+
+// parseClientPermissions parses client certificate permissions ("ops=full,dashboard=read")
+func (cp *ConfigParser) parseClientPermissions(value string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, entry := range cp.parseList(value) {
+		separator := strings.LastIndex(entry, "=")
+		if separator <= 0 {
+			return nil, fmt.Errorf("expected name=permission, got %q", entry)
+		}
+		name := strings.TrimSpace(entry[:separator])
+		if _, exists := result[name]; exists {
+			return nil, fmt.Errorf("client %s specified more than once", name)
+		}
+		result[name] = strings.TrimSpace(entry[separator+1:])
+	}
+	return result, nil
+}
+
+// parseLogLevels parses per-component log levels ("watchdog=warn,sender=debug")
+func (cp *ConfigParser) parseLogLevels(value string) (map[string]LogLevel, error) {
+	result := make(map[string]LogLevel)
+	for _, entry := range cp.parseList(value) {
+		component, levelName, found := strings.Cut(entry, "=")
+		component = strings.TrimSpace(component)
+		if !found || component == "" {
+			return nil, fmt.Errorf("expected component=level, got %q", entry)
+		}
+		if !validLogComponent(component) {
+			return nil, fmt.Errorf("unknown component %q. Valid options: %v", component, logComponents)
+		}
+		if _, exists := result[component]; exists {
+			return nil, fmt.Errorf("component %s specified more than once", component)
+		}
+		level, err := ParseLogLevel(levelName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", component, err)
+		}
+		result[component] = level
+	}
+	return result, nil
+}
+
+// parseNetworks parses a comma-separated list of CIDRs or addresses ("10.20.0.0/16,fd00::1")
+func (cp *ConfigParser) parseNetworks(value string) ([]netip.Prefix, error) {
+	var result []netip.Prefix
+	for _, entry := range cp.parseList(value) {
+		network, err := parseNetwork(entry)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, network)
+	}
+	return result, nil
+}
+
+// networkStrings formats networks in CIDR notation
+func networkStrings(networks []netip.Prefix) []string {
+	result := make([]string, 0, len(networks))
+	for _, network := range networks {
+		result = append(result, network.String())
+	}
+	return result
+}
+
+// parseList parses a comma-separated list, dropping empty entries
+func (cp *ConfigParser) parseList(value string) []string {
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// parseInterfaceDurations parses per-interface durations ("can0=5s,can1=500ms")
+func (cp *ConfigParser) parseInterfaceDurations(value string) (map[string]time.Duration, error) {
+	overrides, err := cp.parseInterfaceOverrides(value)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]time.Duration)
+	for ifName, raw := range overrides {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration for %s: %w", ifName, err)
+		}
+		result[ifName] = d
+	}
+	return result, nil
+}
+
+// parseJ1939Addresses parses the J1939 source addresses of interfaces ("can0=0x80,can1=128")
+func (cp *ConfigParser) parseJ1939Addresses(value string) (map[string]uint8, error) {
+	overrides, err := cp.parseInterfaceOverrides(value)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]uint8)
+	for ifName, raw := range overrides {
+		address, err := strconv.ParseUint(raw, 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid J1939 address for %s: expected 0-253 or 0x00-0xFD", ifName)
+		}
+		result[ifName] = uint8(address)
+	}
+	return result, nil
+}
+
+// parseInterfaceAliases parses logical interface names ("powertrain=can0,body=can1").
+// An interface may have several aliases, but an alias names one interface.
+func (cp *ConfigParser) parseInterfaceAliases(value string) (map[string]string, error) {
+	result := make(map[string]string)
+	for _, entry := range cp.parseList(value) {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("expected alias=interface, got %q", entry)
+		}
+		alias, ifName := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if alias == "" || ifName == "" {
+			return nil, fmt.Errorf("missing alias or interface name in %q", entry)
+		}
+		if previous, exists := result[alias]; exists {
+			if previous != ifName {
+				return nil, fmt.Errorf("alias %s specified more than once, for %s and %s", alias, previous, ifName)
+			}
+			return nil, fmt.Errorf("alias %s specified more than once", alias)
+		}
+		result[alias] = ifName
+	}
+	return result, nil
+}
+
+// parseInterfaceInts parses per-interface integers ("can0=3,can1=5")
+func (cp *ConfigParser) parseInterfaceInts(value string) (map[string]int, error) {
+	overrides, err := cp.parseInterfaceOverrides(value)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int)
+	for ifName, raw := range overrides {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number for %s: %w", ifName, err)
+		}
+		result[ifName] = n
+	}
+	return result, nil
+}
+
+// validateInterfaceAliases checks that aliases name configured ports and can be told apart
+// from interface names. Aliases are limited like interface names, since the API accepts
+// them wherever it accepts one.
+func (cp *ConfigParser) validateInterfaceAliases(config *Config, errs *ConfigErrors) {
+	aliases := make([]string, 0, len(config.InterfaceAliases))
+	for alias := range config.InterfaceAliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	targets := make([]string, 0, len(aliases))
+	for _, alias := range aliases {
+		setting := "interface-aliases[" + alias + "]"
+		if !isValidInterfaceAlias(alias) {
+			errs.add(setting, alias, "must be 1 to 15 letters, digits, '-', '_' or '.'")
+		}
+		for _, port := range config.CanPorts {
+			if port == alias {
+				errs.add(setting, alias, "is the name of a configured interface")
+			}
+		}
+		targets = append(targets, config.InterfaceAliases[alias])
+	}
+	cp.validateInterfaceKeys(config, "interface-aliases", targets, errs)
+}
+
+// isValidInterfaceAlias reports whether alias can stand for an interface name in paths
+// and request bodies
+func isValidInterfaceAlias(alias string) bool {
+	if alias == "" || len(alias) > 15 {
+		return false
+	}
+	for _, r := range alias {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// validateInterfaceKeys checks that per-interface settings only reference configured ports
+func (cp *ConfigParser) validateInterfaceKeys(config *Config, setting string, keys []string, errs *ConfigErrors) {
+	keys = append([]string(nil), keys...)
+	sort.Strings(keys)
+	for _, ifName := range keys {
+		configured := false
+		for _, port := range config.CanPorts {
+			if port == ifName {
+				configured = true
+				break
+			}
+		}
+		if !configured {
+			errs.add(setting, ifName, "references an unconfigured interface. Configured: %v", config.CanPorts)
+		}
+	}
+}
+
+// validateUniqueInterfaces reports each interface a list names more than once, once,
+// with the positions it appears at. Each listed interface gets its own socket and
+// state, so a repeated name would open the same interface twice.
+func (cp *ConfigParser) validateUniqueInterfaces(setting string, names []string, errs *ConfigErrors) {
+	positions := make(map[string][]int)
+	var repeated []string
+	for i, name := range names {
+		if name == "" {
+			continue
+		}
+		positions[name] = append(positions[name], i+1)
+		if len(positions[name]) == 2 {
+			repeated = append(repeated, name)
+		}
+	}
+	for _, name := range repeated {
+		errs.add(setting, name, "interface is listed %d times (at positions %v); list each interface once",
+			len(positions[name]), positions[name])
+	}
+}
+
+// validBitrates lists the standard CAN bitrates
+var validBitrates = []int{
+	10000,   // 10 kbps
+	20000,   // 20 kbps
+	50000,   // 50 kbps
+	100000,  // 100 kbps
+	125000,  // 125 kbps
+	250000,  // 250 kbps
+	500000,  // 500 kbps
+	1000000, // 1 Mbps
+}
+
+// isValidBitrate checks whether a bitrate is a standard CAN bitrate
+func isValidBitrate(bitrate int) bool {
+	for _, valid := range validBitrates {
+		if bitrate == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateConfig validates the configuration. It reports every problem at once, as
+// ConfigErrors, together with those ParseConfig found in malformed values.
+func (cp *ConfigParser) ValidateConfig(config *Config) error {
+	var errs ConfigErrors
+
+	// Reported first: an unset variable usually explains other invalid values
+	for _, name := range config.UnresolvedEnvVars {
+		errs.add("${"+name+"}", nil, "environment variable %s is referenced by the configuration but not set (use ${%s:-default} to provide a default)", name, name)
+	}
+	errs = append(errs, config.parseErrors...)
+
+	if len(config.CanPorts) == 0 {
+		errs.add("can-ports", nil, "at least one CAN port must be specified")
+	}
+	for _, port := range config.CanPorts {
+		if strings.TrimSpace(port) == "" {
+			errs.add("can-ports", nil, "CAN port name cannot be empty")
+		}
+	}
+	cp.validateUniqueInterfaces("can-ports", config.CanPorts, &errs)
+
+	if config.Port == "" {
+		errs.add("port", nil, "server port cannot be empty")
+	} else if port, err := strconv.Atoi(config.Port); err != nil || port < 1 || port > 65535 {
+		errs.add("port", config.Port, "server port must be a number between 1 and 65535")
+	}
+
+	// Validate CAN-specific settings
+	if !isValidBitrate(config.Bitrate) {
+		errs.add("bitrate", config.Bitrate, "not a standard CAN bitrate. Valid options: %v", validBitrates)
+	}
+
+	if config.SamplePoint != "" {
+		if err := validateSamplePoint(config.SamplePoint); err != nil {
+			errs.add("sample-point", config.SamplePoint, "%v", err)
+		}
+	}
+
+	if config.RestartMs < 0 {
+		errs.add("restart-ms", config.RestartMs, "restart timeout cannot be negative")
+	}
+
+	if config.SetupRetry <= 0 {
+		errs.add("setup-retry", config.SetupRetry, "setup retry count must be positive")
+	}
+
+	if config.SetupDelay < 0 {
+		errs.add("setup-delay", config.SetupDelay, "setup delay cannot be negative")
+	}
+
+	cp.validateSetupRetryConfig(config, &errs)
+	cp.validateBitTimingConfig(config, &errs)
+
+	if config.LivenessTimeout <= 0 {
+		errs.add("liveness-timeout", config.LivenessTimeout, "liveness timeout must be positive")
+	}
+
+	if config.DrainTimeout < 0 {
+		errs.add("drain-timeout", config.DrainTimeout, "drain timeout must not be negative")
+	}
+
+	if config.ShutdownTimeout <= 0 {
+		errs.add("shutdown-timeout", config.ShutdownTimeout, "shutdown timeout must be positive")
+	}
+
+	if config.IdempotencyCacheSize < 0 {
+		errs.add("idempotency-cache-size", config.IdempotencyCacheSize, "idempotency cache size must not be negative")
+	}
+
+	if config.IdempotencyTTL <= 0 {
+		errs.add("idempotency-ttl", config.IdempotencyTTL, "idempotency TTL must be positive")
+	}
+
+	if config.MaxBodySize <= 0 {
+		errs.add("max-body-size", config.MaxBodySize, "max body size must be positive")
+	}
+	if config.MaxBatchFrames <= 0 {
+		errs.add("max-batch-frames", config.MaxBatchFrames, "max batch frames must be positive")
+	}
+
+	if config.LogFormat != LogFormatText && config.LogFormat != LogFormatJSON {
+		errs.add("log-format", config.LogFormat, "must be %s or %s", LogFormatText, LogFormatJSON)
+	}
+	if config.LogRateLimit < 0 {
+		errs.add("log-rate-limit", config.LogRateLimit, "log rate limit cannot be negative")
+	}
+	if config.LogSummaryInterval < time.Second {
+		errs.add("log-summary-interval", config.LogSummaryInterval.Seconds(), "log summary interval must be at least 1 second")
+	}
+	cp.validateLogFileConfig(config, &errs)
+	if err := validateAccessLogPaths(config.AccessLog.SkipPaths); err != nil {
+		errs.add("log-access-skip", strings.Join(config.AccessLog.SkipPaths, ","), "%v", err)
+	}
+	if config.AccessLog.SampleRate < 0 || config.AccessLog.SampleRate > 1 {
+		errs.add("log-access-sample-rate", config.AccessLog.SampleRate, "access log sample rate must be between 0 and 1")
+	}
+	if config.AccessLog.SlowThresholdMs < 0 {
+		errs.add("log-access-slow-ms", config.AccessLog.SlowThresholdMs, "slow request threshold cannot be negative")
+	}
+	for _, output := range config.LogOutputs {
+		if !slices.Contains(logOutputs, output) {
+			errs.add("log-output", output, "unknown log output (valid: %s)", strings.Join(logOutputs, ", "))
+		}
+	}
+	if _, _, err := ParseSyslogAddress(config.SyslogAddress); err != nil {
+		errs.add("syslog-address", config.SyslogAddress, "%v", err)
+	}
+	if _, err := ParseSyslogFacility(config.SyslogFacility); err != nil {
+		errs.add("syslog-facility", config.SyslogFacility, "%v", err)
+	}
+
+	if config.DefaultInterface != "" {
+		cp.validateInterfaceKeys(config, "default-interface", []string{config.DefaultInterface}, &errs)
+	}
+
+	var silentIfaces []string
+	for ifName, threshold := range config.ExpectTraffic {
+		if threshold <= 0 {
+			errs.add("expect-traffic["+ifName+"]", threshold, "expect-traffic threshold must be positive")
+		}
+		silentIfaces = append(silentIfaces, ifName)
+	}
+	cp.validateInterfaceKeys(config, "expect-traffic", silentIfaces, &errs)
+
+	cp.validateWatchdogConfig(config, &errs)
+	cp.validateWebhookConfig(config, &errs)
+
+	if config.TxConfirmTimeout < 0 {
+		errs.add("tx-confirm-timeout-ms", config.TxConfirmTimeout, "transmit confirmation timeout cannot be negative")
+	}
+
+	if config.ErrorBurstThreshold <= 0 {
+		errs.add("error-burst-threshold", config.ErrorBurstThreshold, "error burst threshold must be positive")
+	}
+
+	if config.ReceiveBufferSize < 0 {
+		errs.add("rcvbuf-size", config.ReceiveBufferSize, "receive buffer size cannot be negative")
+	}
+	var rcvbufIfaces []string
+	for ifName, size := range config.ReceiveBufferSizes {
+		if size < 0 {
+			errs.add("rcvbuf-sizes["+ifName+"]", size, "receive buffer size cannot be negative")
+		}
+		rcvbufIfaces = append(rcvbufIfaces, ifName)
+	}
+	cp.validateInterfaceKeys(config, "rcvbuf-sizes", rcvbufIfaces, &errs)
+
+	// The gap is waited out holding the interface, so a long one stalls every sender
+	if config.TxGap < 0 || config.TxGap > maxTxGap {
+		errs.add("tx-gap-us", config.TxGap.Microseconds(), "inter-frame gap must be between 0 and %d microseconds", maxTxGap.Microseconds())
+	}
+	var gapIfaces []string
+	for ifName, gap := range config.TxGaps {
+		if gap < 0 || gap > maxTxGap {
+			errs.add("tx-gaps-us["+ifName+"]", gap.Microseconds(), "inter-frame gap must be between 0 and %d microseconds", maxTxGap.Microseconds())
+		}
+		gapIfaces = append(gapIfaces, ifName)
+	}
+	cp.validateInterfaceKeys(config, "tx-gaps-us", gapIfaces, &errs)
+
+	bufferIfaces := make([]string, 0, len(config.BufferFilters))
+	for ifName := range config.BufferFilters {
+		bufferIfaces = append(bufferIfaces, ifName)
+	}
+	cp.validateInterfaceKeys(config, "buffer-ids", bufferIfaces, &errs)
+
+	if config.TxQueue.Policy != TxQueueStrict && config.TxQueue.Policy != TxQueueWeighted {
+		errs.add("tx-queue-policy", config.TxQueue.Policy, "must be %s or %s", TxQueueStrict, TxQueueWeighted)
+	}
+	for _, band := range txPriorities {
+		if weight, ok := config.TxQueue.Weights[band]; ok && weight < 1 {
+			errs.add("tx-queue-weights["+band+"]", weight, "must be at least 1")
+		}
+		if capacity, ok := config.TxQueue.Capacity[band]; ok && capacity < 1 {
+			errs.add("tx-queue-capacity["+band+"]", capacity, "must be at least 1")
+		}
+	}
+	if config.TxRetry.MaxAttempts < 1 {
+		errs.add("tx-retry-attempts", config.TxRetry.MaxAttempts, "must be at least 1 (1 disables retrying)")
+	}
+	if config.TxRetry.Deadline < 0 {
+		errs.add("tx-retry-deadline-ms", config.TxRetry.Deadline, "cannot be negative")
+	}
+
+	// 0xFE is the null address of nodes without one and 0xFF the broadcast address
+	var j1939Ifaces []string
+	for ifName, address := range config.J1939 {
+		if address >= J1939NullAddress {
+			errs.add("j1939["+ifName+"]", fmt.Sprintf("0x%02X", address), "must be a source address from 0x00 to 0xFD")
+		}
+		j1939Ifaces = append(j1939Ifaces, ifName)
+	}
+	cp.validateInterfaceKeys(config, "j1939", j1939Ifaces, &errs)
+	cp.validateInterfaceAliases(config, &errs)
+
+	cp.validateAlertRules(config, &errs)
+	cp.validateSimulatedNodes(config, &errs)
+	cp.validateNamedMessages(config, &errs)
+	cp.validateCaptureConfig(config, &errs)
+	cp.validateSequence(config, "on_startup", config.StartupSequence, &errs)
+	cp.validateSequence(config, "on_shutdown", config.ShutdownSequence, &errs)
+	cp.validateOTLPConfig(config.OTLP, &errs)
+	cp.validateStatsDConfig(config.StatsD, &errs)
+	cp.validateTLSConfig(config, &errs)
+	cp.validateCORSConfig(config.CORS, &errs)
+
+	if config.ListenUnix == "" && config.UnixSocketTrusted {
+		errs.add("unix-socket-trusted", nil, "unix-socket-trusted requires listen-unix")
+	}
+	if config.ListenUnix == "" && config.IPCSocket == "" && config.UnixSocketOwner != "" {
+		errs.add("unix-socket-owner", nil, "unix-socket-owner requires listen-unix or ipc-socket")
+	}
+	if config.IPCSocket != "" && config.IPCSocket == config.ListenUnix {
+		errs.add("ipc-socket", config.IPCSocket, "ipc-socket and listen-unix must be different paths")
+	}
+	if config.UnixSocketOwner != "" {
+		if _, _, err := parseSocketOwner(config.UnixSocketOwner); err != nil {
+			errs.add("unix-socket-owner", config.UnixSocketOwner, "%v", err)
+		}
+	}
+
+	if config.CommandTimeout <= 0 {
+		errs.add("command-timeout", config.CommandTimeout, "command timeout must be positive")
+	}
+
+	if config.RecoveryBaseDelay <= 0 {
+		errs.add("recovery-base-delay", config.RecoveryBaseDelay, "recovery base delay must be positive")
+	} else if config.RecoveryStrategy == RecoveryStrategyExponential && config.RecoveryMaxDelay < config.RecoveryBaseDelay {
+		errs.add("recovery-max-delay", config.RecoveryMaxDelay, "recovery max delay cannot be less than base delay (%v)", config.RecoveryBaseDelay)
+	}
+	if config.RecoveryStrategy != RecoveryStrategyExponential && config.RecoveryStrategy != RecoveryStrategyFixed {
+		errs.add("recovery-strategy", config.RecoveryStrategy, "must be %s or %s", RecoveryStrategyExponential, RecoveryStrategyFixed)
+	}
+	if config.RecoveryMaxAttempts < 0 {
+		errs.add("recovery-max-attempts", config.RecoveryMaxAttempts, "recovery max attempts cannot be negative")
+	}
+
+	return errs.err()
+}
+
+// minWatchdogInterval is the shortest supported watchdog check interval
+const minWatchdogInterval = 100 * time.Millisecond
+
+// validateWatchdogConfig validates global and per-interface watchdog tuning
+func (cp *ConfigParser) validateWatchdogConfig(config *Config, errs *ConfigErrors) {
+	if config.WatchdogInterval < minWatchdogInterval {
+		errs.add("watchdog-interval-ms", config.WatchdogInterval, "watchdog interval must be at least %v", minWatchdogInterval)
+	}
+	if config.WatchdogFailureThreshold <= 0 {
+		errs.add("watchdog-failure-threshold", config.WatchdogFailureThreshold, "watchdog failure threshold must be positive")
+	}
+	if config.WatchdogSuccessThreshold <= 0 {
+		errs.add("watchdog-success-threshold", config.WatchdogSuccessThreshold, "watchdog success threshold must be positive")
+	}
+	if config.WatchdogCooldown < 0 {
+		errs.add("watchdog-cooldown", config.WatchdogCooldown, "watchdog cooldown cannot be negative")
+	}
+
+	var keys []string
+	for ifName, interval := range config.WatchdogIntervals {
+		if interval < minWatchdogInterval {
+			errs.add("watchdog-intervals["+ifName+"]", interval, "watchdog interval must be at least %v", minWatchdogInterval)
+		}
+		keys = append(keys, ifName)
+	}
+	cp.validateInterfaceKeys(config, "watchdog-intervals", keys, errs)
+
+	keys = nil
+	for ifName, threshold := range config.WatchdogFailureThresholds {
+		if threshold <= 0 {
+			errs.add("watchdog-failure-thresholds["+ifName+"]", threshold, "watchdog failure threshold must be positive")
+		}
+		keys = append(keys, ifName)
+	}
+	cp.validateInterfaceKeys(config, "watchdog-failure-thresholds", keys, errs)
+
+	keys = nil
+	for ifName, threshold := range config.WatchdogSuccessThresholds {
+		if threshold <= 0 {
+			errs.add("watchdog-success-thresholds["+ifName+"]", threshold, "watchdog success threshold must be positive")
+		}
+		keys = append(keys, ifName)
+	}
+	cp.validateInterfaceKeys(config, "watchdog-success-thresholds", keys, errs)
+
+	keys = nil
+	for ifName, cooldown := range config.WatchdogCooldowns {
+		if cooldown < 0 {
+			errs.add("watchdog-cooldowns["+ifName+"]", cooldown, "watchdog cooldown cannot be negative")
+		}
+		keys = append(keys, ifName)
+	}
+	cp.validateInterfaceKeys(config, "watchdog-cooldowns", keys, errs)
+}
+
+// validateWebhookConfig validates notification settings
+func (cp *ConfigParser) validateWebhookConfig(config *Config, errs *ConfigErrors) {
+	for _, rawURL := range config.WebhookURLs {
+		parsed, err := url.Parse(rawURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			errs.add("webhook-urls", rawURL, "must be an absolute http(s) URL")
+		}
+	}
+
+	for _, eventType := range config.WebhookEvents {
+		if !isValidEventType(eventType) {
+			errs.add("webhook-events", eventType, "unknown webhook event type. Valid options: %v", notificationEventTypes)
+		}
+	}
+
+	if _, ok := severityRank[config.WebhookMinSeverity]; !ok {
+		errs.add("webhook-min-severity", config.WebhookMinSeverity, "invalid severity. Valid options: info, warning, critical")
+	}
+}
+
+// validateAlertRules validates alert rules and fills their defaults
+func (cp *ConfigParser) validateAlertRules(config *Config, errs *ConfigErrors) {
+	names := make(map[string]bool)
+	var keys []string
+	for i := range config.AlertRules {
+		rule := &config.AlertRules[i]
+		field := fmt.Sprintf("alert-rules[%d]", i)
+		if err := rule.normalize(); err != nil {
+			errs.add(field, nil, "%v", err)
+			continue
+		}
+		if names[rule.Name] {
+			errs.add(field, nil, "duplicate alert rule name %q", rule.Name)
+		}
+		names[rule.Name] = true
+		keys = append(keys, rule.Interface)
+	}
+	cp.validateInterfaceKeys(config, "alert-rules", keys, errs)
+}
+
+// validateSimulatedNodes validates simulated nodes. A response ID that is also a request ID
+// on the same interface would make nodes answer each other forever, so it is rejected.
+func (cp *ConfigParser) validateSimulatedNodes(config *Config, errs *ConfigErrors) {
+	names := make(map[string]bool)
+	requestIDs := make(map[string]map[uint32]bool)
+	var keys []string
+	for i := range config.SimulatedNodes {
+		node := &config.SimulatedNodes[i]
+		field := fmt.Sprintf("simulated-nodes[%d]", i)
+		if err := node.normalize(); err != nil {
+			errs.add(field, nil, "%v", err)
+			continue
+		}
+		if names[node.Name] {
+			errs.add(field, nil, "duplicate simulated node name %q", node.Name)
+		}
+		names[node.Name] = true
+		keys = append(keys, node.Interface)
+
+		if requestIDs[node.Interface] == nil {
+			requestIDs[node.Interface] = make(map[uint32]bool)
+		}
+		for _, response := range node.Responses {
+			requestIDs[node.Interface][response.RequestID] = true
+		}
+	}
+
+	for i, node := range config.SimulatedNodes {
+		for _, response := range node.Responses {
+			if requestIDs[node.Interface][response.ResponseID] {
+				errs.add(fmt.Sprintf("simulated-nodes[%d]", i), nil, "simulated node %s: response ID 0x%X is also a request ID on %s",
+					node.Name, response.ResponseID, node.Interface)
+			}
+		}
+	}
+
+	cp.validateInterfaceKeys(config, "simulated-nodes", keys, errs)
+}
+
+// validateNamedMessages validates the named messages. A definition without interface is
+// sent on the default interface, or on every interface in a sequence.
+func (cp *ConfigParser) validateNamedMessages(config *Config, errs *ConfigErrors) {
+	names := make([]string, 0, len(config.NamedMessages))
+	for name := range config.NamedMessages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var keys []string
+	for _, name := range names {
+		message := config.NamedMessages[name]
+		field := fmt.Sprintf("messages[%s]", name)
+		if name == "" || strings.ContainsAny(name, "/ \t") {
+			errs.add(field, nil, "invalid message name %q", name)
+		}
+		if err := message.normalize(); err != nil {
+			errs.add(field, nil, "%v", err)
+		}
+		if message.Interface != "" {
+			keys = append(keys, message.Interface)
+		}
+	}
+
+	cp.validateInterfaceKeys(config, "messages", keys, errs)
+}
+
+// validateCaptureConfig validates the frame capture settings
+func (cp *ConfigParser) validateCaptureConfig(config *Config, errs *ConfigErrors) {
+	capture := config.Capture
+	if capture.Dir == "" {
+		if len(capture.Interfaces) > 0 {
+			errs.add("capture", strings.Join(capture.Interfaces, ","), "capturing interfaces requires -capture-dir")
+		}
+		return
+	}
+
+	cp.validateInterfaceKeys(config, "capture", capture.Interfaces, errs)
+	cp.validateUniqueInterfaces("capture", capture.Interfaces, errs)
+	if capture.MaxFileSize <= 0 {
+		errs.add("capture-max-file-size", capture.MaxFileSize, "must be positive")
+	}
+	if capture.MaxTotalSize < capture.MaxFileSize {
+		errs.add("capture-max-total-size", capture.MaxTotalSize, "must be at least capture-max-file-size (%d)", capture.MaxFileSize)
+	}
+	if capture.MaxAge < 0 {
+		errs.add("capture-max-age", int(capture.MaxAge/time.Hour), "must not be negative")
+	}
+	if capture.MaxFiles < 0 {
+		errs.add("capture-max-files", capture.MaxFiles, "must not be negative")
+	}
+	if capture.TXContext && !capture.TX {
+		errs.add("capture-tx-context", capture.TXContext, "requires -capture-tx")
+	}
+}
+
+// validateLogFileConfig validates the rotation settings of the service log file and the
+// audit log, and the retention of blackbox dumps
+func (cp *ConfigParser) validateLogFileConfig(config *Config, errs *ConfigErrors) {
+	cp.validateRotationPolicy("log", config.LogRotation, errs)
+	cp.validateRotationPolicy("audit-log", config.AuditLogRotation, errs)
+	if config.BlackboxMaxFiles < 1 {
+		errs.add("blackbox-max-files", config.BlackboxMaxFiles, "must be at least 1")
+	}
+}
+
+// validateRotationPolicy validates the -<prefix>-max-* settings of a rotated file
+func (cp *ConfigParser) validateRotationPolicy(prefix string, policy RotationPolicy, errs *ConfigErrors) {
+	if policy.MaxSize < 0 {
+		errs.add(prefix+"-max-size", policy.MaxSize, "must not be negative")
+	}
+	if policy.MaxAge < 0 {
+		errs.add(prefix+"-max-age", int(policy.MaxAge/time.Hour), "must not be negative")
+	}
+	if policy.MaxFiles < 0 {
+		errs.add(prefix+"-max-files", policy.MaxFiles, "must not be negative")
+	}
+}
+
+// validateSequence validates the steps of the startup or shutdown sequence. Named
+// messages must be validated first.
+func (cp *ConfigParser) validateSequence(config *Config, setting string, steps []SequenceStep, errs *ConfigErrors) {
+	var keys []string
+	for i := range steps {
+		field := fmt.Sprintf("%s[%d]", setting, i)
+		if err := steps[i].normalize(fmt.Sprintf("step %d", i), config.NamedMessages); err != nil {
+			errs.add(field, nil, "%v", err)
+		}
+		if steps[i].Interface != "" {
+			keys = append(keys, steps[i].Interface)
+		}
+	}
+
+	cp.validateInterfaceKeys(config, setting, keys, errs)
+}
+
+// validateOTLPConfig validates OpenTelemetry export settings
+func (cp *ConfigParser) validateOTLPConfig(config OTLPConfig, errs *ConfigErrors) {
+	if !config.Enabled() {
+		return
+	}
+
+	if config.Protocol != "http/json" {
+		errs.add("OTEL_EXPORTER_OTLP_PROTOCOL", config.Protocol, "unsupported protocol: only http/json is supported")
+	}
+	for _, rawURL := range []string{config.TracesEndpoint, config.MetricsEndpoint} {
+		if rawURL == "" {
+			continue
+		}
+		parsed, err := url.Parse(rawURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			errs.add("OTEL_EXPORTER_OTLP_ENDPOINT", rawURL, "must be an absolute http(s) URL")
+		}
+	}
+	if config.Timeout <= 0 {
+		errs.add("OTEL_EXPORTER_OTLP_TIMEOUT", config.Timeout, "OTLP export timeout must be positive")
+	}
+	if config.MetricInterval <= 0 {
+		errs.add("OTEL_METRIC_EXPORT_INTERVAL", config.MetricInterval, "OTLP metric export interval must be positive")
+	}
+}
+
+// validateStatsDConfig validates the StatsD push settings
+func (cp *ConfigParser) validateStatsDConfig(config StatsDConfig, errs *ConfigErrors) {
+	if config.Address == "" {
+		return
+	}
+
+	host, port, err := net.SplitHostPort(config.Address)
+	if err != nil {
+		errs.add("statsd-address", config.Address, "must be host:port: %v", err)
+	} else if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 || host == "" {
+		errs.add("statsd-address", config.Address, "must be host:port with a port from 1 to 65535")
+	}
+	if config.Interval < time.Second {
+		errs.add("statsd-interval", config.Interval.Seconds(), "StatsD push interval must be at least 1 second")
+	}
+	if err := validateStatsDPrefix(config.Prefix); err != nil {
+		errs.add("statsd-prefix", config.Prefix, "%v", err)
+	}
+	if !slices.Contains(statsdFormats, config.Format) {
+		errs.add("statsd-format", config.Format, "invalid format. Valid options: %v", statsdFormats)
+	}
+}
+
+// validateSetupRetryConfig checks the setup retry backoff and per-interface overrides
+func (cp *ConfigParser) validateSetupRetryConfig(config *Config, errs *ConfigErrors) {
+	if config.SetupRetryBackoff < 1 {
+		errs.add("setup-retry-backoff", config.SetupRetryBackoff, "setup retry backoff must be at least 1")
+	}
+
+	if config.SetupMaxDelay < 0 {
+		errs.add("setup-max-delay", config.SetupMaxDelay, "setup max delay cannot be negative")
+	}
+
+	var ifaces []string
+	for ifName, attempts := range config.SetupRetries {
+		if attempts <= 0 {
+			errs.add("setup-retries["+ifName+"]", attempts, "setup attempts must be positive")
+		}
+		ifaces = append(ifaces, ifName)
+	}
+	cp.validateInterfaceKeys(config, "setup-retries", ifaces, errs)
+
+	ifaces = nil
+	for ifName, delay := range config.SetupDelays {
+		if delay < 0 {
+			errs.add("setup-delays["+ifName+"]", delay, "setup delay cannot be negative")
+		}
+		ifaces = append(ifaces, ifName)
+	}
+	cp.validateInterfaceKeys(config, "setup-delays", ifaces, errs)
+}
+
+// validateBitTimingConfig checks the per-interface bitrates, sample points and triple
+// sampling
+func (cp *ConfigParser) validateBitTimingConfig(config *Config, errs *ConfigErrors) {
+	var ifaces []string
+	for ifName, bitrate := range config.Bitrates {
+		if !isValidBitrate(bitrate) {
+			errs.add("bitrates["+ifName+"]", bitrate, "not a standard CAN bitrate. Valid options: %v", validBitrates)
+		}
+		ifaces = append(ifaces, ifName)
+	}
+	cp.validateInterfaceKeys(config, "bitrates", ifaces, errs)
+
+	ifaces = nil
+	for ifName, samplePoint := range config.SamplePoints {
+		if err := validateSamplePoint(samplePoint); err != nil {
+			errs.add("sample-points["+ifName+"]", samplePoint, "%v", err)
+		}
+		ifaces = append(ifaces, ifName)
+	}
+	cp.validateInterfaceKeys(config, "sample-points", ifaces, errs)
+
+	cp.validateInterfaceKeys(config, "triple-sampling", config.TripleSampling, errs)
+	cp.validateUniqueInterfaces("triple-sampling", config.TripleSampling, errs)
+	cp.validateInterfaceKeys(config, "one-shot", config.OneShot, errs)
+	cp.validateUniqueInterfaces("one-shot", config.OneShot, errs)
+}
+
+// validateCORSConfig checks the allowed origins and that credentials are never allowed
+// for every origin
+func (cp *ConfigParser) validateCORSConfig(cors CORSConfig, errs *ConfigErrors) {
+	for _, origin := range cors.AllowedOrigins {
+		if origin == "*" {
+			if cors.AllowCredentials {
+				errs.add("cors-credentials", nil, "cors-credentials cannot be combined with cors-origins *; list the origins instead")
+			}
+			continue
+		}
+		if _, err := parseOriginPattern(origin); err != nil {
+			errs.add("cors-origins", origin, "%v", err)
+		}
+	}
+	if cors.MaxAge < 0 {
+		errs.add("cors-max-age", cors.MaxAge, "cors max age must not be negative")
+	}
+}
+
+// validateTLSConfig checks that TLS files come in usable combinations and that client
+// permissions are known levels
+func (cp *ConfigParser) validateTLSConfig(config *Config, errs *ConfigErrors) {
+	if (config.TLSCertFile == "") != (config.TLSKeyFile == "") {
+		errs.add("tls-cert", nil, "tls-cert and tls-key must be set together")
+	}
+	if config.TLSClientCA != "" && config.TLSCertFile == "" {
+		errs.add("tls-client-ca", nil, "tls-client-ca requires tls-cert and tls-key")
+	}
+	if len(config.ClientPermissions) > 0 && config.TLSClientCA == "" {
+		errs.add("tls-client-permissions", nil, "tls-client-permissions requires tls-client-ca")
+	}
+
+	for name, permission := range config.ClientPermissions {
+		if permission != PermissionRead && permission != PermissionFull {
+			errs.add("tls-client-permissions["+name+"]", permission, "invalid permission. Valid options: read, full")
+		}
+	}
+
+	if config.TLSCertFile != "" && config.TLSKeyFile != "" {
+		if _, err := tls.LoadX509KeyPair(config.TLSCertFile, config.TLSKeyFile); err != nil {
+			errs.add("tls-cert", config.TLSCertFile, "invalid TLS certificate: %v", err)
+		}
+	}
+	if _, err := buildTLSConfig(config.TLSClientCA); err != nil {
+		errs.add("tls-client-ca", config.TLSClientCA, "%v", err)
+	}
+}
+
+// dbcPath returns the file a DBC was loaded from, or "" when none is loaded
+func dbcPath(db *DBC) string {
+	if db == nil {
+		return ""
+	}
+	return db.Path
+}
+
+// GetConfigSummary returns a summary of the current configuration
+func (cp *ConfigParser) GetConfigSummary(config *Config) map[string]interface{} {
+	return map[string]interface{}{
+		"canPorts":                 config.CanPorts,
+		"serverPort":               config.Port,
+		"autoSetup":                config.AutoSetup,
+		"teardownOnExit":           config.TeardownOnExit,
+		"bitrate":                  config.Bitrate,
+		"samplePoint":              config.SamplePoint,
+		"restartMs":                config.RestartMs,
+		"setupRetry":               config.SetupRetry,
+		"setupDelay":               config.SetupDelay.String(),
+		"setupRetryBackoff":        config.SetupRetryBackoff,
+		"setupMaxDelay":            config.SetupMaxDelay.String(),
+		"setupRetries":             config.SetupRetries,
+		"setupDelays":              config.SetupDelays,
+		"bitrates":                 config.Bitrates,
+		"samplePoints":             config.SamplePoints,
+		"tripleSampling":           config.TripleSampling,
+		"oneShot":                  config.OneShot,
+		"dryRun":                   config.DryRun,
+		"recoveryBaseDelay":        config.RecoveryBaseDelay.String(),
+		"recoveryMaxDelay":         config.RecoveryMaxDelay.String(),
+		"recoveryStrategy":         config.RecoveryStrategy,
+		"recoveryMaxAttempts":      config.RecoveryMaxAttempts,
+		"commandTimeout":           config.CommandTimeout.String(),
+		"watchdogEventLog":         config.WatchdogEventLog,
+		"expectTraffic":            config.ExpectTraffic,
+		"watchdogInterval":         config.WatchdogInterval.String(),
+		"watchdogFailureThreshold": config.WatchdogFailureThreshold,
+		"watchdogSuccessThreshold": config.WatchdogSuccessThreshold,
+		"watchdogCooldown":         config.WatchdogCooldown.String(),
+		"instanceName":             config.InstanceName,
+		"webhookCount":             len(config.WebhookURLs),
+		"webhookEvents":            config.WebhookEvents,
+		"webhookMinSeverity":       config.WebhookMinSeverity,
+		"txConfirmTimeout":         config.TxConfirmTimeout.String(),
+		"errorBurstThreshold":      config.ErrorBurstThreshold,
+		"defaultInterface":         config.DefaultInterface,
+		"receiveBufferSize":        config.ReceiveBufferSize,
+		"receiveBufferSizes":       config.ReceiveBufferSizes,
+		"txGap":                    config.TxGap.String(),
+		"txGaps":                   config.TxGaps,
+		"txQueue":                  config.TxQueue,
+		"txRetryAttempts":          config.TxRetry.MaxAttempts,
+		"txRetryDeadline":          config.TxRetry.Deadline.String(),
+		"txRetryPoll":              config.TxRetry.Poll,
+		"j1939":                    config.J1939,
+		"interfaceAliases":         config.InterfaceAliases,
+		"bufferIds":                config.BufferFilters,
+		"alertRules":               len(config.AlertRules),
+		"simulatedNodes":           len(config.SimulatedNodes),
+		"dbcFile":                  dbcPath(config.DBC),
+		"namedMessages":            len(config.NamedMessages),
+		"startupSequence":          len(config.StartupSequence),
+		"shutdownSequence":         len(config.ShutdownSequence),
+		"tls":                      config.TLSCertFile != "",
+		"mutualTLS":                config.TLSClientCA != "",
+		"clientPermissions":        config.ClientPermissions,
+		"apiKeys":                  len(config.APIKeys),
+		"sendAuditLog":             config.SendAuditLog,
+		"auditLog":                 config.AuditLog,
+		"auditLogMaxSize":          config.AuditLogRotation.MaxSize,
+		"auditLogMaxAge":           config.AuditLogRotation.MaxAge.String(),
+		"auditLogMaxFiles":         config.AuditLogRotation.MaxFiles,
+		"auditLogCompress":         config.AuditLogRotation.Compress,
+		"captureDir":               config.Capture.Dir,
+		"capture":                  config.Capture.Interfaces,
+		"captureTx":                config.Capture.TX,
+		"captureTxContext":         config.Capture.TXContext,
+		"captureMaxFileSize":       config.Capture.MaxFileSize,
+		"captureMaxTotalSize":      config.Capture.MaxTotalSize,
+		"captureMaxAge":            config.Capture.MaxAge.String(),
+		"captureMaxFiles":          config.Capture.MaxFiles,
+		"captureCompress":          config.Capture.Compress,
+		"logLevel":                 config.LogLevel,
+		"logLevels":                config.LogLevels,
+		"logFormat":                config.LogFormat,
+		"logNoEmoji":               config.LogNoEmoji,
+		"logTxFrames":              config.LogTxFrames,
+		"logRateLimit":             config.LogRateLimit,
+		"logSummaryInterval":       config.LogSummaryInterval.String(),
+		"accessLog":                config.AccessLog,
+		"logOutputs":               config.LogOutputs,
+		"syslogAddress":            config.SyslogAddress,
+		"syslogFacility":           config.SyslogFacility,
+		"logFile":                  config.LogFile,
+		"logMaxSize":               config.LogRotation.MaxSize,
+		"logMaxAge":                config.LogRotation.MaxAge.String(),
+		"logMaxFiles":              config.LogRotation.MaxFiles,
+		"logCompress":              config.LogRotation.Compress,
+		"blackboxDir":              config.BlackboxDir,
+		"blackboxMaxFiles":         config.BlackboxMaxFiles,
+		"corsOrigins":              config.CORS.AllowedOrigins,
+		"corsMethods":              config.CORS.AllowedMethods,
+		"corsHeaders":              config.CORS.AllowedHeaders,
+		"corsCredentials":          config.CORS.AllowCredentials,
+		"corsMaxAge":               config.CORS.MaxAge.String(),
+		"listenUnix":               config.ListenUnix,
+		"ipcSocket":                config.IPCSocket,
+		"unixSocketMode":           fmt.Sprintf("%04o", config.UnixSocketMode),
+		"unixSocketOwner":          config.UnixSocketOwner,
+		"unixSocketTrusted":        config.UnixSocketTrusted,
+		"apiDocs":                  config.APIDocs,
+		"legacyAPIRoutes":          config.LegacyAPIRoutes,
+		"readyRequiresAll":         config.ReadyRequiresAll,
+		"livenessTimeout":          config.LivenessTimeout.String(),
+		"drainTimeout":             config.DrainTimeout.String(),
+		"shutdownTimeout":          config.ShutdownTimeout.String(),
+		"idempotencyCacheSize":     config.IdempotencyCacheSize,
+		"maxBodySize":              config.MaxBodySize,
+		"maxBatchFrames":           config.MaxBatchFrames,
+		"idempotencyTTL":           config.IdempotencyTTL.String(),
+		"allowedNetworks":          networkStrings(config.AllowedNetworks),
+		"trustedProxies":           networkStrings(config.TrustedProxies),
+		"otlpTracesEndpoint":       config.OTLP.TracesEndpoint,
+		"otlpMetricsEndpoint":      config.OTLP.MetricsEndpoint,
+		"statsdAddress":            config.StatsD.Address,
+		"statsdInterval":           config.StatsD.Interval.String(),
+		"statsdPrefix":             config.StatsD.Prefix,
+		"statsdFormat":             config.StatsD.Format,
+		"configFile":               config.ConfigFile,
+	}
+}
+
+// PrintUsage prints usage information
+func PrintUsage() {
+	fmt.Println("CAN Communication Service")
+	fmt.Println("Usage:")
+	fmt.Println("  -h, --help              Print this usage and exit")
+	fmt.Println("  --version               Print the version, commit and build date and exit")
+	fmt.Println("  -can-ports string       Comma-separated list of CAN interfaces (default: can0)")
+	fmt.Println("  -port string            HTTP server port (default: 5260)")
+	fmt.Println("  -auto-setup             Automatically setup CAN interfaces on startup (default: true)")
+	fmt.Println("  -teardown-on-exit       Tear down CAN interfaces on shutdown (default: true)")
+	fmt.Println("  -bitrate int            Default CAN bitrate in bps (default: 1000000)")
+	fmt.Println("  -sample-point string    Default CAN sample point (default: 0.75)")
+	fmt.Println("  -restart-ms int         Default CAN restart timeout in ms (default: 100)")
+	fmt.Println("  -setup-retry int        Number of setup attempts, 1 disables retrying (default: 3)")
+	fmt.Println("  -setup-delay int        Delay between setup retries in seconds (default: 2)")
+	fmt.Println("  -setup-retry-backoff float  Setup retry delay multiplier per attempt (default: 1, constant)")
+	fmt.Println("  -setup-max-delay int    Maximum setup retry delay with backoff in seconds, 0 for no cap (default: 60)")
+	fmt.Println("  -setup-retries string   Per-interface setup attempts, e.g. vcan0=1,can1=10")
+	fmt.Println("  -setup-delays string    Per-interface setup retry delays, e.g. can1=5s")
+	fmt.Println("  -bitrates string        Per-interface CAN bitrates, e.g. can1=250000")
+	fmt.Println("  -sample-points string   Per-interface CAN sample points, e.g. can1=0.875")
+	fmt.Println("  -triple-sampling string Comma-separated interfaces that sample each bit three times, e.g. can1")
+	fmt.Println("  -one-shot string        Comma-separated interfaces that do not retransmit frames, e.g. can1")
+	fmt.Println("  -enable-finder          Enable service finder (default: true)")
+	fmt.Println("  -finder-interval int    Interval for service finder in seconds (default: 5)")
+	fmt.Println("  -enable-healthcheck     Enable health check endpoint (default: true)")
+	fmt.Println("  -dry-run                Validate and log CAN frames without sending them (default: false)")
+	fmt.Println("  -recovery-base-delay int  Initial watchdog recovery backoff delay in seconds (default: 1)")
+	fmt.Println("  -recovery-max-delay int   Maximum watchdog recovery backoff delay in seconds (default: 300)")
+	fmt.Println("  -recovery-strategy string Delays between watchdog recovery attempts: exponential or fixed (default: exponential)")
+	fmt.Println("  -recovery-max-attempts int  Watchdog recovery attempts before giving up, 0 retries forever (default: 10)")
+	fmt.Println("  -command-timeout int    Timeout for each interface setup command in seconds (default: 5)")
+	fmt.Println("  -watchdog-event-log string  File for persisting watchdog events as JSON lines (default: memory only)")
+	fmt.Println("  -expect-traffic string  Per-interface RX silence thresholds, e.g. can0=5s (default: disabled)")
+	fmt.Println("  -watchdog-interval-ms int  Watchdog health check interval in ms (default: 10000)")
+	fmt.Println("  -watchdog-failure-threshold int  Consecutive failed checks before an interface is failed (default: 3)")
+	fmt.Println("  -watchdog-success-threshold int  Consecutive passing checks before an interface is healthy again (default: 3)")
+	fmt.Println("  -watchdog-cooldown int  Minimum seconds between recovery actions on an interface (default: 0)")
+	fmt.Println("  -watchdog-intervals string  Per-interface check intervals, e.g. can0=500ms,can1=5s")
+	fmt.Println("  -watchdog-failure-thresholds string  Per-interface failure thresholds, e.g. can0=3")
+	fmt.Println("  -watchdog-success-thresholds string  Per-interface success thresholds, e.g. can0=5")
+	fmt.Println("  -watchdog-cooldowns string  Per-interface recovery cooldowns, e.g. can0=30s")
+	fmt.Println("  -instance-name string   Instance name reported in notifications (default: hostname)")
+	fmt.Println("  -webhook-urls string    Comma-separated webhook URLs for event notifications (default: disabled)")
+	fmt.Println("  -webhook-events string  Comma-separated event types to notify (default: all)")
+	fmt.Println("  -webhook-min-severity string  Minimum notification severity: info, warning, critical (default: info)")
+	fmt.Println("  -statsd-address string  StatsD agent (host:port) metrics are pushed to over UDP (default: disabled)")
+	fmt.Println("  -statsd-interval int    Seconds between pushes of metrics to StatsD (default: 10)")
+	fmt.Println("  -statsd-prefix string   Prefix of the metric names pushed to StatsD, joined with a dot (default: none)")
+	fmt.Println("  -statsd-format string   dogstatsd (labels as tags) or statsd (labels in the name) (default: dogstatsd)")
+	fmt.Println("  -tx-confirm-timeout-ms int  Wait for each sent frame's loopback echo in ms, 0 disables (default: 100)")
+	fmt.Println("  -error-burst-threshold int  Error frames per second that raise an error burst warning (default: 50)")
+	fmt.Println("  -default-interface string  Interface used by sends that omit one (default: the only configured port)")
+	fmt.Println("  -rcvbuf-size int        Socket receive buffer size in bytes, 0 keeps the kernel default (default: 0)")
+	fmt.Println("  -rcvbuf-sizes string    Per-interface socket receive buffer sizes, e.g. can0=1048576")
+	fmt.Println("  -tx-gap-us int          Minimum gap between frames written to an interface in microseconds, 0 disables (default: 0)")
+	fmt.Println("  -tx-gaps-us string      Per-interface minimum inter-frame gaps in microseconds, e.g. can0=500")
+	fmt.Println("  -tx-queue-policy string Order of sends waiting for an interface: strict or weighted (default: strict)")
+	fmt.Println("  -tx-queue-weights string  Share of writes per priority band when weighted (default: high=8,normal=4,bulk=1)")
+	fmt.Println("  -tx-queue-capacity string Sends that may wait per priority band and interface (default: high=256,normal=1024,bulk=4096)")
+	fmt.Println("  -tx-retry-attempts int  Writes of a frame refused with ENOBUFS or EAGAIN, 1 disables retrying (default: 4)")
+	fmt.Println("  -tx-retry-deadline-ms int  Total time a frame may spend retrying in ms, 0 for no limit (default: 20)")
+	fmt.Println("  -tx-retry-poll          Wait for a full socket send buffer with poll() instead of sleeping (default: false)")
+	fmt.Println("  -j1939 string           Interfaces in J1939 mode with their source address, e.g. can0=0x80")
+	fmt.Println("  -interface-aliases string Logical names the API accepts for interfaces, e.g. powertrain=can0,body=can1")
+	fmt.Println("  -buffer-ids string      IDs kept in the receive buffer per interface, e.g. can0=0x100-0x1FF,can0=0x7E8,can1=0x18DAF100/0x1FFFFF00")
+	fmt.Println("  -simulated-nodes string JSON file with simulated nodes answering requests on vcan interfaces (test mode)")
+	fmt.Println("  -alert-rules string     JSON file with alert rules ({\"rules\": [...]}) (default: no alerts)")
+	fmt.Println("  -dbc string             DBC file enabling signal-based sends (default: disabled)")
+	fmt.Println("  -tls-cert string        TLS server certificate file; enables HTTPS with -tls-key (default: plain HTTP)")
+	fmt.Println("  -tls-key string         TLS server private key file")
+	fmt.Println("  -tls-client-ca string   CA file for client certificates; requires a valid client certificate (mutual TLS)")
+	fmt.Println("  -tls-client-permissions string  Client CN/SAN permissions, e.g. ops=full,dashboard=read (default: read)")
+	fmt.Println("  -api-keys string        JSON file with API keys and roles ({\"keys\": [...]}) (default: no authentication)")
+	fmt.Println("  -send-audit-log string  File recording every sent frame with client identity as JSON lines (default: disabled)")
+	fmt.Println("  -audit-log string       File recording every mutating API call as JSON lines (default: in memory only)")
+	fmt.Println("  -audit-log-max-size int Size in bytes at which the audit log is rotated, 0 disables (default: 10485760)")
+	fmt.Println("  -audit-log-max-age int  Hours after which the audit log is rotated, 0 disables (default: 0)")
+	fmt.Println("  -audit-log-max-files int  Rotated audit logs kept, 0 keeps all (default: 10)")
+	fmt.Println("  -audit-log-compress     gzip rotated audit logs (default: false)")
+	fmt.Println("  -capture-dir string     Directory of candump log files, enables the capture endpoints (default: disabled)")
+	fmt.Println("  -capture string         Interfaces captured from startup, e.g. can0 (needs -capture-dir)")
+	fmt.Println("  -capture-tx             Also capture frames sent by the bridge, marking lines T or R (default: false)")
+	fmt.Println("  -capture-tx-context     Record the request, principal and job of each sent frame next to its capture file (needs -capture-tx)")
+	fmt.Println("  -capture-max-file-size int   Size in bytes at which a capture file is rotated (default: 67108864)")
+	fmt.Println("  -capture-max-total-size int  Bytes of capture files kept, the oldest are deleted (default: 1073741824)")
+	fmt.Println("  -capture-max-age int    Hours after which a capture file is rotated, 0 disables (default: 0)")
+	fmt.Println("  -capture-max-files int  Finished capture files kept, 0 for no limit (default: 0)")
+	fmt.Println("  -capture-compress       gzip finished capture files (default: false)")
+	fmt.Println("  -log-level string       Log level: debug, info, warn or error (default: info)")
+	fmt.Println("  -log-levels string      Per-component log levels for setup, watchdog, sender, api and monitor, e.g. watchdog=warn,sender=debug")
+	fmt.Println("  -log-format string      Log output format: text, or json for one object per line (default: text)")
+	fmt.Println("  -log-no-emoji           Remove emoji from log messages, e.g. for journald (default: false)")
+	fmt.Println("  -log-tx-frames          Log every frame sent at info level with its request, principal and job (default: true)")
+	fmt.Println("  -log-rate-limit int     Identical log lines per second before the rest are summarized, 0 disables (default: 50)")
+	fmt.Println("  -log-summary-interval int  Seconds between summaries of log lines held back (default: 10)")
+	fmt.Println("  -log-access-skip string Comma-separated path prefixes left out of the access log (default: status, health, probes, /metrics)")
+	fmt.Println("  -log-access-sample-rate float  Share of successful requests in the access log, 0 to 1 (default: 1)")
+	fmt.Println("  -log-access-slow-ms int Milliseconds after which a request is logged as a slow warning, 0 disables (default: 0)")
+	fmt.Println("  -log-output string      Comma-separated log outputs: stderr, stdout, journald, syslog (default: stderr)")
+	fmt.Println("  -syslog-address string  Syslog daemon: udp://host:port, tcp://host:port or unix:///path (default: local)")
+	fmt.Println("  -syslog-facility string Syslog facility, e.g. daemon or local0 (default: daemon)")
+	fmt.Println("  -log-file string        Write the service log to a rotated file instead of standard error")
+	fmt.Println("  -log-max-size int       Size in bytes at which the log file is rotated, 0 disables (default: 10485760)")
+	fmt.Println("  -log-max-age int        Hours after which the log file is rotated, 0 disables (default: 0)")
+	fmt.Println("  -log-max-files int      Rotated log files kept, 0 keeps all (default: 5)")
+	fmt.Println("  -log-compress           gzip rotated log files (default: false)")
+	fmt.Println("  -blackbox-dir string    Write recent logs, frames, watchdog events and stacks here on panics and on demand (default: disabled)")
+	fmt.Println("  -blackbox-max-files int Blackbox dumps kept (default: 10)")
+	fmt.Println("  -cors-origins string    Origins browsers may call the API from: exact, https://*.example.com or * (default: same origin only)")
+	fmt.Println("  -cors-methods string    Methods allowed in cross-origin requests (default: GET,POST,PUT,DELETE,OPTIONS)")
+	fmt.Println("  -cors-headers string    Request headers allowed in cross-origin requests (default: Accept,Authorization,Content-Type,X-API-Key,X-CSRF-Token,X-Request-ID,Idempotency-Key)")
+	fmt.Println("  -cors-credentials       Allow cross-origin requests with credentials; not with * (default: false)")
+	fmt.Println("  -cors-max-age int       Seconds browsers may cache a preflight answer (default: 600)")
+	fmt.Println("  -listen-unix string     Unix socket serving the API besides TCP, e.g. /run/can-bridge.sock (default: disabled)")
+	fmt.Println("  -ipc-socket string      Unix socket for the binary IPC protocol, e.g. /run/can-bridge.ipc (default: disabled)")
+	fmt.Println("  -unix-socket-mode string  Octal permissions of the Unix sockets (default: 0660)")
+	fmt.Println("  -unix-socket-owner string Owner of the Unix sockets as user, user:group or :group (default: process owner)")
+	fmt.Println("  -unix-socket-trusted    Serve Unix socket requests without API key or client certificate (default: false)")
+	fmt.Println("  -api-docs               Serve the OpenAPI document at /openapi.json and Swagger UI at /docs (default: true)")
+	fmt.Println("  -legacy-api-routes      Serve deprecated unversioned /api aliases of the /api/v1 routes (default: true)")
+	fmt.Println("  -ready-requires-all     Report ready only when every configured interface is initialized (default: false)")
+	fmt.Println("  -liveness-timeout int   Seconds without a heartbeat before /livez reports a loop stuck (default: 30)")
+	fmt.Println("  -drain-timeout int      Seconds shutdown waits for sends in flight, 0 to not wait (default: 10)")
+	fmt.Println("  -shutdown-timeout int   Seconds a graceful shutdown may take, drain included (default: 30)")
+	fmt.Println("  -idempotency-cache-size int  Responses kept for Idempotency-Key replays, 0 to disable (default: 1000)")
+	fmt.Println("  -idempotency-ttl int    Seconds a response is kept for Idempotency-Key replays (default: 3600)")
+	fmt.Println("  -max-body-size int      Largest request body in bytes, larger ones get 413 (default: 1048576)")
+	fmt.Println("  -max-batch-frames int   Most frames one multi-interface send may carry (default: 64)")
+	fmt.Println("  -allowed-networks string  Client CIDRs allowed to use the API, e.g. 10.20.0.0/16,fd00::/8 (default: all)")
+	fmt.Println("  -trusted-proxies string   Proxy CIDRs whose X-Forwarded-For header is trusted (default: none)")
+	fmt.Println("  -config string          YAML configuration file; flags and environment variables override it")
+	fmt.Println("  -validate-config        Validate the configuration, print it and exit without touching any interface")
+	fmt.Println("")
+	fmt.Println("Environment Variables:")
+	fmt.Println("  CAN_PORTS              Comma-separated list of CAN interfaces")
+	fmt.Println("  SERVER_PORT            HTTP server port")
+	fmt.Println("  CAN_AUTO_SETUP         Automatically setup CAN interfaces (true/false)")
+	fmt.Println("  CAN_TEARDOWN_ON_EXIT   Tear down CAN interfaces on shutdown (true/false)")
+	fmt.Println("  CAN_BITRATE            Default CAN bitrate in bps")
+	fmt.Println("  CAN_SAMPLE_POINT       Default CAN sample point")
+	fmt.Println("  CAN_RESTART_MS         Default CAN restart timeout in ms")
+	fmt.Println("  CAN_SETUP_RETRY        Number of setup attempts (1 disables retrying)")
+	fmt.Println("  CAN_SETUP_DELAY        Delay between setup retries in seconds")
+	fmt.Println("  CAN_SETUP_RETRY_BACKOFF  Setup retry delay multiplier per attempt")
+	fmt.Println("  CAN_SETUP_MAX_DELAY    Maximum setup retry delay in seconds")
+	fmt.Println("  CAN_SETUP_RETRIES      Per-interface setup attempts (vcan0=1,can1=10)")
+	fmt.Println("  CAN_SETUP_DELAYS       Per-interface setup retry delays (can1=5s)")
+	fmt.Println("  CAN_BITRATES           Per-interface CAN bitrates (can1=250000)")
+	fmt.Println("  CAN_SAMPLE_POINTS      Per-interface CAN sample points (can1=0.875)")
+	fmt.Println("  CAN_TRIPLE_SAMPLING    Comma-separated interfaces that sample each bit three times")
+	fmt.Println("  CAN_ONE_SHOT           Comma-separated interfaces that do not retransmit frames")
+	fmt.Println("  CAN_DRY_RUN            Validate and log CAN frames without sending them (true/false)")
+	fmt.Println("  CAN_RECOVERY_BASE_DELAY  Initial watchdog recovery backoff delay in seconds")
+	fmt.Println("  CAN_RECOVERY_MAX_DELAY   Maximum watchdog recovery backoff delay in seconds")
+	fmt.Println("  CAN_RECOVERY_STRATEGY    Delays between watchdog recovery attempts (exponential, fixed)")
+	fmt.Println("  CAN_RECOVERY_MAX_ATTEMPTS  Watchdog recovery attempts before giving up, 0 retries forever")
+	fmt.Println("  CAN_COMMAND_TIMEOUT    Timeout for each interface setup command in seconds")
+	fmt.Println("  CAN_WATCHDOG_EVENT_LOG File for persisting watchdog events as JSON lines")
+	fmt.Println("  CAN_EXPECT_TRAFFIC     Per-interface RX silence thresholds (can0=5s,can1=10s)")
+	fmt.Println("  CAN_WATCHDOG_INTERVAL_MS         Watchdog health check interval in ms")
+	fmt.Println("  CAN_WATCHDOG_FAILURE_THRESHOLD   Consecutive failed checks before an interface is failed")
+	fmt.Println("  CAN_WATCHDOG_SUCCESS_THRESHOLD   Consecutive passing checks before healthy again")
+	fmt.Println("  CAN_WATCHDOG_COOLDOWN            Minimum seconds between recovery actions")
+	fmt.Println("  CAN_WATCHDOG_INTERVALS           Per-interface check intervals")
+	fmt.Println("  CAN_WATCHDOG_FAILURE_THRESHOLDS  Per-interface failure thresholds")
+	fmt.Println("  CAN_WATCHDOG_SUCCESS_THRESHOLDS  Per-interface success thresholds")
+	fmt.Println("  CAN_WATCHDOG_COOLDOWNS           Per-interface recovery cooldowns")
+	fmt.Println("  CAN_INSTANCE_NAME      Instance name reported in notifications")
+	fmt.Println("  CAN_WEBHOOK_URLS       Comma-separated webhook URLs")
+	fmt.Println("  CAN_WEBHOOK_EVENTS     Comma-separated event types to notify")
+	fmt.Println("  CAN_WEBHOOK_MIN_SEVERITY  Minimum notification severity")
+	fmt.Println("  CAN_STATSD_ADDRESS     StatsD agent (host:port) metrics are pushed to")
+	fmt.Println("  CAN_STATSD_INTERVAL    Seconds between pushes of metrics to StatsD")
+	fmt.Println("  CAN_STATSD_PREFIX      Prefix of the metric names pushed to StatsD")
+	fmt.Println("  CAN_STATSD_FORMAT      StatsD line format: dogstatsd or statsd")
+	fmt.Println("  CAN_TX_CONFIRM_TIMEOUT_MS  Transmit confirmation timeout in ms (0 disables)")
+	fmt.Println("  CAN_ERROR_BURST_THRESHOLD  Error frames per second that raise an error burst warning")
+	fmt.Println("  CAN_DEFAULT_INTERFACE  Interface used by sends that omit one")
+	fmt.Println("  CAN_RCVBUF_SIZE        Socket receive buffer size in bytes")
+	fmt.Println("  CAN_RCVBUF_SIZES       Per-interface socket receive buffer sizes (can0=1048576)")
+	fmt.Println("  CAN_TX_GAP_US          Minimum gap between frames written to an interface in microseconds")
+	fmt.Println("  CAN_TX_GAPS_US         Per-interface minimum inter-frame gaps in microseconds (can0=500)")
+	fmt.Println("  CAN_TX_QUEUE_POLICY    Order of sends waiting for an interface (strict, weighted)")
+	fmt.Println("  CAN_TX_QUEUE_WEIGHTS   Share of writes per priority band when weighted (high=8,normal=4,bulk=1)")
+	fmt.Println("  CAN_TX_QUEUE_CAPACITY  Sends that may wait per priority band and interface")
+	fmt.Println("  CAN_TX_RETRY_ATTEMPTS  Writes of a frame refused with ENOBUFS or EAGAIN (1 disables retrying)")
+	fmt.Println("  CAN_TX_RETRY_DEADLINE_MS  Total time a frame may spend retrying in ms (0 for no limit)")
+	fmt.Println("  CAN_TX_RETRY_POLL      Wait for a full socket send buffer with poll() (true/false)")
+	fmt.Println("  CAN_J1939              Interfaces in J1939 mode with their source address (can0=0x80)")
+	fmt.Println("  CAN_INTERFACE_ALIASES  Logical names the API accepts for interfaces (powertrain=can0)")
+	fmt.Println("  CAN_BUFFER_IDS         IDs kept in the receive buffer per interface (can0=0x100-0x1FF,can0=0x7E8)")
+	fmt.Println("  CAN_ALERT_RULES        JSON file with alert rules")
+	fmt.Println("  CAN_SIMULATED_NODES    JSON file with simulated nodes (test mode)")
+	fmt.Println("  CAN_DBC_FILE           DBC file enabling signal-based sends")
+	fmt.Println("  CAN_TLS_CERT / CAN_TLS_KEY  TLS server certificate and private key files")
+	fmt.Println("  CAN_TLS_CLIENT_CA      CA file for client certificates (mutual TLS)")
+	fmt.Println("  CAN_TLS_CLIENT_PERMISSIONS  Client CN/SAN permissions (ops=full,dashboard=read)")
+	fmt.Println("  CAN_API_KEYS           JSON file with API keys and roles")
+	fmt.Println("  CAN_SEND_AUDIT_LOG     File recording every sent frame as JSON lines")
+	fmt.Println("  CAN_AUDIT_LOG          File recording every mutating API call as JSON lines")
+	fmt.Println("  CAN_AUDIT_LOG_MAX_SIZE / CAN_AUDIT_LOG_MAX_AGE / CAN_AUDIT_LOG_MAX_FILES / CAN_AUDIT_LOG_COMPRESS  Audit log rotation")
+	fmt.Println("  CAN_CAPTURE_DIR        Directory of candump log files")
+	fmt.Println("  CAN_CAPTURE            Interfaces captured from startup")
+	fmt.Println("  CAN_CAPTURE_TX         Also capture sent frames (true/false)")
+	fmt.Println("  CAN_CAPTURE_TX_CONTEXT Record the request, principal and job of sent frames next to their capture file (true/false)")
+	fmt.Println("  CAN_CAPTURE_MAX_FILE_SIZE   Size in bytes at which a capture file is rotated")
+	fmt.Println("  CAN_CAPTURE_MAX_TOTAL_SIZE  Bytes of capture files kept")
+	fmt.Println("  CAN_CAPTURE_MAX_AGE    Hours after which a capture file is rotated")
+	fmt.Println("  CAN_CAPTURE_MAX_FILES  Finished capture files kept")
+	fmt.Println("  CAN_CAPTURE_COMPRESS   gzip finished capture files (true/false)")
+	fmt.Println("  CAN_LOG_LEVEL          Log level (debug, info, warn, error)")
+	fmt.Println("  CAN_LOG_LEVELS         Per-component log levels (watchdog=warn,sender=debug)")
+	fmt.Println("  CAN_LOG_FORMAT         Log output format (text, json)")
+	fmt.Println("  CAN_LOG_NO_EMOJI       Remove emoji from log messages (true/false)")
+	fmt.Println("  CAN_LOG_TX_FRAMES      Log every frame sent at info level (true/false)")
+	fmt.Println("  CAN_LOG_RATE_LIMIT     Identical log lines per second before the rest are summarized")
+	fmt.Println("  CAN_LOG_SUMMARY_INTERVAL  Seconds between summaries of log lines held back")
+	fmt.Println("  CAN_LOG_ACCESS_SKIP    Path prefixes left out of the access log (empty logs every path)")
+	fmt.Println("  CAN_LOG_ACCESS_SAMPLE_RATE  Share of successful requests in the access log")
+	fmt.Println("  CAN_LOG_ACCESS_SLOW_MS Milliseconds after which a request is logged as a slow warning")
+	fmt.Println("  CAN_LOG_OUTPUT         Comma-separated log outputs (stderr, stdout, journald, syslog)")
+	fmt.Println("  CAN_SYSLOG_ADDRESS     Syslog daemon of the syslog output (default: local)")
+	fmt.Println("  CAN_SYSLOG_FACILITY    Syslog facility of the syslog output (default: daemon)")
+	fmt.Println("  CAN_LOG_FILE           Service log file (default: standard error)")
+	fmt.Println("  CAN_LOG_MAX_SIZE       Size in bytes at which the log file is rotated")
+	fmt.Println("  CAN_LOG_MAX_AGE        Hours after which the log file is rotated")
+	fmt.Println("  CAN_LOG_MAX_FILES      Rotated log files kept")
+	fmt.Println("  CAN_LOG_COMPRESS       gzip rotated log files (true/false)")
+	fmt.Println("  CAN_BLACKBOX_DIR       Blackbox dump directory")
+	fmt.Println("  CAN_BLACKBOX_MAX_FILES Blackbox dumps kept")
+	fmt.Println("  CAN_CORS_ORIGINS       Origins browsers may call the API from")
+	fmt.Println("  CAN_CORS_METHODS       Methods allowed in cross-origin requests")
+	fmt.Println("  CAN_CORS_HEADERS       Request headers allowed in cross-origin requests")
+	fmt.Println("  CAN_CORS_CREDENTIALS   Allow cross-origin requests with credentials (true/false)")
+	fmt.Println("  CAN_CORS_MAX_AGE       Seconds browsers may cache a preflight answer")
+	fmt.Println("  CAN_LISTEN_UNIX        Unix socket serving the API besides TCP")
+	fmt.Println("  CAN_IPC_SOCKET         Unix socket for the binary IPC protocol")
+	fmt.Println("  CAN_UNIX_SOCKET_MODE   Octal permissions of the Unix sockets")
+	fmt.Println("  CAN_UNIX_SOCKET_OWNER  Owner of the Unix sockets (user, user:group or :group)")
+	fmt.Println("  CAN_UNIX_SOCKET_TRUSTED Serve Unix socket requests without credentials (true/false)")
+	fmt.Println("  CAN_API_DOCS           Serve the OpenAPI document and Swagger UI (true/false)")
+	fmt.Println("  CAN_LEGACY_API_ROUTES  Serve deprecated unversioned /api aliases (true/false)")
+	fmt.Println("  CAN_READY_REQUIRES_ALL Report ready only when every configured interface is initialized (true/false)")
+	fmt.Println("  CAN_LIVENESS_TIMEOUT   Seconds without a heartbeat before /livez reports a loop stuck")
+	fmt.Println("  CAN_DRAIN_TIMEOUT      Seconds shutdown waits for sends in flight")
+	fmt.Println("  CAN_SHUTDOWN_TIMEOUT   Seconds a graceful shutdown may take")
+	fmt.Println("  CAN_IDEMPOTENCY_CACHE_SIZE  Responses kept for Idempotency-Key replays")
+	fmt.Println("  CAN_IDEMPOTENCY_TTL    Seconds a response is kept for Idempotency-Key replays")
+	fmt.Println("  CAN_MAX_BODY_SIZE      Largest request body in bytes")
+	fmt.Println("  CAN_MAX_BATCH_FRAMES   Most frames one multi-interface send may carry")
+	fmt.Println("  CAN_ALLOWED_NETWORKS   Client CIDRs allowed to use the API")
+	fmt.Println("  CAN_TRUSTED_PROXIES    Proxy CIDRs whose X-Forwarded-For header is trusted")
+	fmt.Println("  CONFIG_FILE            YAML configuration file")
+	fmt.Println("  OTEL_EXPORTER_OTLP_ENDPOINT  OTLP/HTTP collector base URL; enables trace and metric export (http/json)")
+	fmt.Println("  OTEL_EXPORTER_OTLP_TRACES_ENDPOINT / _METRICS_ENDPOINT  Per-signal collector URLs")
+	fmt.Println("  OTEL_EXPORTER_OTLP_HEADERS   Export request headers (key=value,...)")
+	fmt.Println("  OTEL_EXPORTER_OTLP_TIMEOUT   Export request timeout in ms (default: 10000)")
+	fmt.Println("  OTEL_METRIC_EXPORT_INTERVAL  Metric export interval in ms (default: 60000)")
+	fmt.Println("  OTEL_SERVICE_NAME            Service name reported to the collector (default: can-bridge)")
+	fmt.Println("")
+	fmt.Println("  String settings and environment variable values may reference other variables")
+	fmt.Println("  as ${VAR} (must be set) or ${VAR:-default}.")
+	fmt.Println("")
+	fmt.Println("Examples:")
+	fmt.Println("  # Basic usage with default settings")
+	fmt.Println("  ./can-bridge -can-ports can0,can1")
+	fmt.Println("")
+	fmt.Println("  # Custom bitrate and sample point")
+	fmt.Println("  ./can-bridge -can-ports can0 -bitrate 500000 -sample-point 0.8")
+	fmt.Println("")
+	fmt.Println("  # Disable auto-setup (manual setup via API)")
+	fmt.Println("  ./can-bridge -can-ports can0,can1 -auto-setup=false")
+	fmt.Println("")
+	fmt.Println("  # Using environment variables")
+	fmt.Println("  CAN_PORTS=can0,can1 CAN_BITRATE=500000 ./can-bridge")
+	fmt.Println("")
+	fmt.Println("  # Templated configuration with defaults")
+	fmt.Println("  CAN_BITRATE='${BUS_BITRATE:-500000}' ./can-bridge -can-ports '${CAN_IFACE:-can0}'")
+	fmt.Println("")
+	fmt.Println("  # Settings from a file, checked before deployment")
+	fmt.Println("  ./can-bridge -config /etc/can-bridge.yaml -validate-config")
+	fmt.Println("")
+	fmt.Println("  # High availability setup with more retries")
+	fmt.Println("  ./can-bridge -can-ports can0,can1 -setup-retry 5 -setup-delay 3")
+	fmt.Println("")
+	fmt.Println("  # Lint automation scripts against production config without touching the bus")
+	fmt.Println("  ./can-bridge -can-ports can0,can1 -dry-run")
 	fmt.Println("")
 	fmt.Println("Valid CAN Bitrates:")
 	fmt.Println("  10000, 20000, 50000, 100000, 125000, 250000, 500000, 1000000 (bps)")
 	fmt.Println("")
-	fmt.Println("API Endpoints:")
-	fmt.Println("  GET  /api/setup/config                    - Get setup configuration")
-	fmt.Println("  PUT  /api/setup/config                    - Update setup configuration")
-	fmt.Println("  GET  /api/setup/available                 - List available CAN interfaces")
-	fmt.Println("  POST /api/setup/interfaces/{name}        - Setup specific interface")
-	fmt.Println("  DELETE /api/setup/interfaces/{name}      - Teardown specific interface")
-	fmt.Println("  POST /api/setup/interfaces/{name}/reset  - Reset specific interface")
-	fmt.Println("  GET  /api/setup/interfaces/{name}/state  - Get interface state")
-	fmt.Println("  POST /api/setup/interfaces/setup-all     - Setup all interfaces")
-	fmt.Println("  POST /api/setup/interfaces/teardown-all  - Teardown all interfaces")
+	fmt.Println("API Endpoints (unversioned /api paths are deprecated aliases, see -legacy-api-routes):")
+	fmt.Println("  GET  /api/v1/setup/config                 - Get setup configuration")
+	fmt.Println("  PUT  /api/v1/setup/config                 - Update setup configuration")
+	fmt.Println("  GET  /api/v1/setup/available              - List available CAN interfaces")
+	fmt.Println("  POST /api/v1/setup/interfaces/{name}     - Setup specific interface")
+	fmt.Println("  DELETE /api/v1/setup/interfaces/{name}   - Teardown specific interface")
+	fmt.Println("  POST /api/v1/setup/interfaces/{name}/reset  - Reset specific interface")
+	fmt.Println("  GET  /api/v1/setup/interfaces/{name}/state  - Get interface state")
+	fmt.Println("  POST /api/v1/setup/interfaces/setup-all  - Setup all interfaces")
+	fmt.Println("  POST /api/v1/setup/interfaces/teardown-all  - Teardown all interfaces")
+	fmt.Println("  GET  /metrics                             - Prometheus send latency, ENOBUFS, retry and TX queue metrics")
+	fmt.Println("  GET  /healthz                             - Health probe, 200 while serving")
+	fmt.Println("  GET  /readyz                              - Readiness probe, 503 with reasons until interfaces and watchdog are up")
+	fmt.Println("  GET  /livez                               - Liveness probe, 503 when the watchdog or a receive loop stopped ticking")
+	fmt.Println("  GET  /api/v1/interfaces/{name}/load       - Bus load in percent of the bitrate, stuff bits included")
+	fmt.Println("  POST /api/v1/interfaces/{name}/tx         - Enable or disable transmission without reconfiguring")
+	fmt.Println("  GET  /api/v1/stats/ids                    - Per-ID count, rate and inter-frame gaps over a window (interface, window)")
+	fmt.Println("  GET  /api/v1/stats/{interface}/ids        - Per-ID traffic statistics sorted by frame rate (top)")
+	fmt.Println("  GET  /api/v1/stats/{interface}/errors     - Error frame statistics by class and location in frame")
+	fmt.Println("  GET  /api/v1/stats/{interface}/kernel     - Kernel counters next to frames read and dropped on the listening socket")
+	fmt.Println("  POST /api/v1/stats/{interface}/ids/reset  - Reset per-ID traffic statistics")
+	fmt.Println("  GET  /api/v1/simulator/nodes              - Simulated node request/response counters (test mode)")
+	fmt.Println("  POST /api/v1/can/multi                    - Send one frame on several interfaces concurrently")
+	fmt.Println("  POST /api/v1/can/until                    - Send a frame periodically until a matching frame is received")
+	fmt.Println("  GET  /api/v1/tasks                        - Running transmit tasks (send-until runs)")
+	fmt.Println("  DELETE /api/v1/tasks                      - Cancel every running transmit task")
+	fmt.Println("  GET  /api/v1/capture                      - Captured interfaces and capture counters (-capture-dir)")
+	fmt.Println("  POST /api/v1/capture/{interface}/start    - Start writing an interface's frames to a candump log file")
+	fmt.Println("  POST /api/v1/capture/{interface}/stop     - Stop capturing an interface and close its file")
+	fmt.Println("  GET  /api/v1/j1939                        - J1939 interfaces, socket state and counters (-j1939)")
+	fmt.Println("  POST /api/v1/j1939/{interface}/send       - Send a J1939 PGN, segmented by the kernel beyond 8 bytes")
+	fmt.Println("  GET  /api/v1/j1939/{interface}/messages   - Last received J1939 messages (?pgn=&source=&destination=)")
+	fmt.Println("  POST /api/v1/send/signal                  - Encode DBC signal values into a message and send it (-dbc)")
+	fmt.Println("  GET  /api/v1/send/named                   - List the named messages of the configuration file")
+	fmt.Println("  POST /api/v1/send/named/{name}            - Send a named message, optionally replacing payload bytes")
+	fmt.Println("  GET  /api/v1/alerts                       - List alert rules and their state")
+	fmt.Println("  POST /api/v1/alerts/test                  - Evaluate a rule against current data")
+	fmt.Println("  GET  /api/v1/triggers                     - List frame change triggers")
+	fmt.Println("  POST /api/v1/triggers                     - Notify when a frame's payload or byte changes")
+	fmt.Println("  DELETE /api/v1/triggers/{name}            - Remove a frame trigger")
+	fmt.Println("  POST /api/v1/interfaces/{name}/bitrate   - Change interface bitrate at runtime")
+	fmt.Println("  POST /api/v1/watchdog/interfaces/{name}/retry - Force an immediate recovery attempt")
+	fmt.Println("  GET  /api/v1/watchdog/events              - Query watchdog events (interface, since, until, limit)")
+	fmt.Println("  POST /api/v1/watchdog/pause               - Pause watchdog recovery (interface, timeout, reason)")
+	fmt.Println("  POST /api/v1/watchdog/resume              - Resume watchdog recovery (interface)")
+	fmt.Println("  GET  /api/v1/config                       - Effective configuration with secrets redacted, and its ETag")
+	fmt.Println("  PUT  /api/v1/config                       - Apply a configuration file, If-Match required (persist)")
+	fmt.Println("  POST /api/v1/config/reload                - Reload the configuration, as SIGHUP does")
+	fmt.Println("  GET  /api/v1/logging                      - Default and per-component log levels")
+	fmt.Println("  PUT  /api/v1/logging                      - Change log levels at runtime (level, components)")
+	fmt.Println("  GET  /api/v1/audit                        - Mutating API calls with principal and outcome (principal, outcome, since, until, limit)")
+	fmt.Println("  GET  /openapi.json                        - OpenAPI 3 specification of the API (-api-docs)")
+	fmt.Println("  GET  /docs                                - Swagger UI for the API (-api-docs)")
 }
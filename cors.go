@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSConfig is the cross-origin policy of the API. Without allowed origins, browsers
+// may only call the API from its own origin.
+type CORSConfig struct {
+	AllowedOrigins   []string      // Exact origins, patterns such as https://*.example.com, or "*"
+	AllowedMethods   []string      // Methods a preflight may request
+	AllowedHeaders   []string      // Request headers a preflight may request
+	AllowCredentials bool          // Let browsers send cookies and authorization headers
+	MaxAge           time.Duration // How long browsers may cache a preflight answer
+}
+
+// Defaults of the CORS policy settings
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Accept", "Authorization", "Content-Type", "X-API-Key", "X-CSRF-Token", "X-Request-ID", "Idempotency-Key"}
+)
+
+// originPattern is an allowed origin. A host starting with "*." matches any subdomain
+// of the rest, but not the rest itself.
+type originPattern struct {
+	scheme     string
+	host       string // Without the "*." of a wildcard
+	port       string
+	subdomains bool
+}
+
+// parseOriginPattern parses an allowed origin such as "https://app.example.com",
+// "http://localhost:3000" or "https://*.example.com"
+func parseOriginPattern(value string) (originPattern, error) {
+	u, err := url.Parse(strings.ToLower(value))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return originPattern{}, fmt.Errorf("invalid origin %q: expected scheme://host[:port]", value)
+	}
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return originPattern{}, fmt.Errorf("invalid origin %q: an origin has no path, query or credentials", value)
+	}
+
+	pattern := originPattern{scheme: u.Scheme, host: u.Hostname(), port: u.Port()}
+	if rest, ok := strings.CutPrefix(pattern.host, "*."); ok {
+		pattern.host, pattern.subdomains = rest, true
+	}
+	if pattern.host == "" || strings.Contains(pattern.host, "*") {
+		return originPattern{}, fmt.Errorf("invalid origin %q: * may only stand for the leftmost labels, as in https://*.example.com", value)
+	}
+	return pattern, nil
+}
+
+// matches reports whether a request origin fits the pattern. Ports must match exactly,
+// so a pattern without port only matches the scheme's default port.
+func (p originPattern) matches(scheme, host, port string) bool {
+	if scheme != p.scheme || port != p.port {
+		return false
+	}
+	if p.subdomains {
+		return strings.HasSuffix(host, "."+p.host)
+	}
+	return host == p.host
+}
+
+// corsPolicy is a CORSConfig prepared for matching requests
+type corsPolicy struct {
+	anyOrigin        bool
+	origins          []originPattern
+	methods          map[string]bool
+	headers          map[string]bool // Canonical header names
+	allowMethods     string
+	allowHeaders     string
+	allowCredentials bool
+	maxAge           string
+}
+
+// newCORSPolicy prepares a policy. The origins were validated with the configuration.
+func newCORSPolicy(config CORSConfig) *corsPolicy {
+	policy := &corsPolicy{
+		methods:          make(map[string]bool),
+		headers:          make(map[string]bool),
+		allowMethods:     strings.Join(config.AllowedMethods, ", "),
+		allowHeaders:     strings.Join(config.AllowedHeaders, ", "),
+		allowCredentials: config.AllowCredentials,
+		maxAge:           strconv.Itoa(int(config.MaxAge / time.Second)),
+	}
+	for _, origin := range config.AllowedOrigins {
+		if origin == "*" {
+			policy.anyOrigin = true
+			continue
+		}
+		if pattern, err := parseOriginPattern(origin); err == nil {
+			policy.origins = append(policy.origins, pattern)
+		}
+	}
+	for _, method := range config.AllowedMethods {
+		policy.methods[strings.ToUpper(method)] = true
+	}
+	for _, header := range config.AllowedHeaders {
+		policy.headers[http.CanonicalHeaderKey(header)] = true
+	}
+	return policy
+}
+
+// allowOrigin reports whether a request origin is allowed
+func (p *corsPolicy) allowOrigin(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return false
+	}
+	for _, pattern := range p.origins {
+		if pattern.matches(u.Scheme, u.Hostname(), u.Port()) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowHeadersRequested reports whether every header a preflight asks for is allowed
+func (p *corsPolicy) allowHeadersRequested(requested string) bool {
+	for _, header := range strings.Split(requested, ",") {
+		if header = strings.TrimSpace(header); header != "" && !p.headers[http.CanonicalHeaderKey(header)] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameOrigin reports whether a browser sent the Origin header for a request to the API's
+// own origin, as it does for POST from the Swagger UI at /docs
+func sameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// CORSMiddleware applies the cross-origin policy. Requests from disallowed origins are
+// rejected with 403 instead of being served without CORS headers, so a browser page of
+// another site cannot trigger writes whose response it merely cannot read. Preflights
+// are answered here; other OPTIONS requests reach the routes.
+func CORSMiddleware(config CORSConfig, logger Logger) gin.HandlerFunc {
+	policy := newCORSPolicy(config)
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || (sameOrigin(c.Request, origin) && !policy.allowOrigin(origin)) {
+			c.Next()
+			return
+		}
+
+		c.Header("Vary", "Origin")
+		if !policy.allowOrigin(origin) {
+			logger.Warnf("🚫 Denied %s %s from origin %q: not in allowed CORS origins%s",
+				c.Request.Method, c.Request.URL.Path, origin, requestIDSuffix(requestID(c)))
+			abortWithError(c, http.StatusForbidden, CodeForbidden, fmt.Sprintf("Access denied: origin %s is not allowed", origin))
+			return
+		}
+
+		if policy.anyOrigin && !policy.allowCredentials {
+			c.Header("Access-Control-Allow-Origin", "*")
+		} else {
+			c.Header("Access-Control-Allow-Origin", origin)
+		}
+		if policy.allowCredentials {
+			c.Header("Access-Control-Allow-Credentials", "true")
+		}
+
+		requestedMethod := c.GetHeader("Access-Control-Request-Method")
+		if c.Request.Method != http.MethodOptions || requestedMethod == "" {
+			c.Header("Access-Control-Expose-Headers", requestIDHeader+", "+idempotencyReplayedHeader)
+			c.Next()
+			return
+		}
+
+		// Preflight
+		if !policy.methods[strings.ToUpper(requestedMethod)] {
+			abortWithError(c, http.StatusForbidden, CodeForbidden, fmt.Sprintf("Access denied: method %s is not allowed", requestedMethod))
+			return
+		}
+		if requested := c.GetHeader("Access-Control-Request-Headers"); !policy.allowHeadersRequested(requested) {
+			abortWithError(c, http.StatusForbidden, CodeForbidden, fmt.Sprintf("Access denied: headers %s are not all allowed", requested))
+			return
+		}
+		c.Header("Access-Control-Allow-Methods", policy.allowMethods)
+		c.Header("Access-Control-Allow-Headers", policy.allowHeaders)
+		c.Header("Access-Control-Max-Age", policy.maxAge)
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
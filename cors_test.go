@@ -0,0 +1,94 @@
+package main
+
+import "testing"
+
+func TestParseOriginPattern(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    originPattern
+		wantErr bool
+	}{
+		{value: "https://app.example.com", want: originPattern{scheme: "https", host: "app.example.com"}},
+		{value: "http://localhost:3000", want: originPattern{scheme: "http", host: "localhost", port: "3000"}},
+		{value: "https://*.Example.com:8443/", want: originPattern{scheme: "https", host: "example.com", port: "8443", subdomains: true}},
+		{value: "http://[::1]:8080", want: originPattern{scheme: "http", host: "::1", port: "8080"}},
+		{value: "example.com", wantErr: true},
+		{value: "https://", wantErr: true},
+		{value: "https://example.com/app", wantErr: true},
+		{value: "https://example.com?debug=1", wantErr: true},
+		{value: "https://user@example.com", wantErr: true},
+		{value: "https://*", wantErr: true},
+		{value: "https://*.", wantErr: true},
+		{value: "https://*example.com", wantErr: true},
+		{value: "https://app.*.example.com", wantErr: true},
+		{value: "https://*.*.example.com", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseOriginPattern(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOriginPattern() error = %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseOriginPattern() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSAllowOrigin(t *testing.T) {
+	tests := []struct {
+		pattern string
+		origin  string
+		want    bool
+	}{
+		// Wildcard subdomains
+		{"https://*.example.com", "https://app.example.com", true},
+		{"https://*.example.com", "https://eu.app.example.com", true},
+		{"https://*.example.com", "HTTPS://APP.EXAMPLE.COM", true},
+		{"https://*.example.com", "https://example.com", false},
+
+		// Suffix attacks
+		{"https://*.example.com", "https://evil-example.com", false},
+		{"https://*.example.com", "https://evilexample.com", false},
+		{"https://*.example.com", "https://app.example.com.evil.net", false},
+		{"https://example.com", "https://evil-example.com", false},
+		{"https://example.com", "https://app.example.com", false},
+		{"https://example.com", "https://example.com", true},
+
+		// Port mismatch
+		{"http://localhost:3000", "http://localhost:3000", true},
+		{"http://localhost:3000", "http://localhost:3001", false},
+		{"http://localhost:3000", "http://localhost", false},
+		{"https://*.example.com", "https://app.example.com:8443", false},
+		{"https://*.example.com:8443", "https://app.example.com:8443", true},
+		{"https://*.example.com:8443", "https://app.example.com", false},
+
+		// Scheme mismatch
+		{"https://*.example.com", "http://app.example.com", false},
+		{"http://localhost:3000", "https://localhost:3000", false},
+		{"https://example.com", "wss://example.com", false},
+
+		// Origins that are not URLs
+		{"https://example.com", "null", false},
+		{"https://example.com", "", false},
+		{"https://example.com", "example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.origin, func(t *testing.T) {
+			policy := newCORSPolicy(CORSConfig{AllowedOrigins: []string{tt.pattern}})
+			if got := policy.allowOrigin(tt.origin); got != tt.want {
+				t.Errorf("allowOrigin(%q) = %t, want %t", tt.origin, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCORSAnyOrigin(t *testing.T) {
+	policy := newCORSPolicy(CORSConfig{AllowedOrigins: []string{"https://example.com", "*"}})
+	for _, origin := range []string{"https://evil-example.com", "http://localhost:1234", "null"} {
+		if !policy.allowOrigin(origin) {
+			t.Errorf("allowOrigin(%q) = false with *", origin)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+)
+
+// DataFilter selects frames by payload, like a CAN acceptance filter on the data bytes:
+// a frame matches when data[i]&Mask[i] == Match[i]&Mask[i] for every mask byte. Frames
+// too short to cover a non-zero mask byte never match.
+type DataFilter struct {
+	Mask  []byte
+	Match []byte
+}
+
+// ParseDataFilter parses the hex mask and match of a data filter (e.g. mask "00FF",
+// match "0003" selects frames whose second byte is 0x03). A match without a mask
+// compares its bytes exactly. Returns nil when neither is set.
+func ParseDataFilter(maskHex, matchHex string) (*DataFilter, error) {
+	if maskHex == "" && matchHex == "" {
+		return nil, nil
+	}
+	if matchHex == "" {
+		return nil, fmt.Errorf("dataMatch is required with dataMask")
+	}
+
+	match, err := parseHexBytes(matchHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dataMatch %q: expected hex bytes such as \"00 03\": %w", matchHex, err)
+	}
+
+	mask := make([]byte, len(match))
+	for i := range mask {
+		mask[i] = 0xFF
+	}
+	if maskHex != "" {
+		if mask, err = parseHexBytes(maskHex); err != nil {
+			return nil, fmt.Errorf("invalid dataMask %q: expected hex bytes such as \"00 FF\": %w", maskHex, err)
+		}
+		if len(mask) != len(match) {
+			return nil, fmt.Errorf("dataMask has %d bytes but dataMatch has %d", len(mask), len(match))
+		}
+	}
+
+	if len(match) == 0 || len(match) > 64 {
+		return nil, fmt.Errorf("data filter must cover 1 to 64 bytes, got %d", len(match))
+	}
+	return &DataFilter{Mask: mask, Match: match}, nil
+}
+
+// Matches reports whether a payload passes the filter. A nil filter passes everything.
+func (f *DataFilter) Matches(data []byte) bool {
+	if f == nil {
+		return true
+	}
+	for i, mask := range f.Mask {
+		if mask == 0 {
+			continue
+		}
+		if i >= len(data) || data[i]&mask != f.Match[i]&mask {
+			return false
+		}
+	}
+	return true
+}
+
+// Filter returns the messages whose payload passes the filter, keeping their order
+func (f *DataFilter) Filter(messages []CanMessageLog) []CanMessageLog {
+	if f == nil {
+		return messages
+	}
+	filtered := make([]CanMessageLog, 0, len(messages))
+	for _, msg := range messages {
+		if f.Matches(msg.Data) {
+			filtered = append(filtered, msg)
+		}
+	}
+	return filtered
+}
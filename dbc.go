@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DBC line patterns. Only message (BO_) and signal (SG_) definitions are read; comments,
+// attributes and value tables are ignored.
+var (
+	dbcMessagePattern = regexp.MustCompile(`^BO_\s+(\d+)\s+(\w+)\s*:\s*(\d+)`)
+	dbcSignalPattern  = regexp.MustCompile(`^SG_\s+(\w+)\s*(M|m\d+)?\s*:\s*(\d+)\|(\d+)@([01])([+-])\s*\(([^,]+),([^)]+)\)\s*\[([^|]+)\|([^\]]+)\]\s*"([^"]*)"`)
+)
+
+// DBCSignal is a signal definition of a DBC message
+type DBCSignal struct {
+	Name           string  `json:"name"`
+	StartBit       int     `json:"startBit"`
+	Length         int     `json:"length"`
+	LittleEndian   bool    `json:"littleEndian"` // Intel byte order (@1); Motorola (@0) otherwise
+	Signed         bool    `json:"signed"`
+	Factor         float64 `json:"factor"`
+	Offset         float64 `json:"offset"`
+	Min            float64 `json:"min"`
+	Max            float64 `json:"max"` // Min and max both 0 means no range is defined
+	Unit           string  `json:"unit,omitempty"`
+	Multiplexer    bool    `json:"multiplexer,omitempty"`    // Selects which multiplexed signals are present
+	MultiplexValue *int    `json:"multiplexValue,omitempty"` // Multiplexer value this signal is present for
+}
+
+// DBCMessage is a message definition of a DBC file
+type DBCMessage struct {
+	ID      uint32      `json:"id"` // Extended IDs carry the CAN_EFF_FLAG bit, as in the DBC file
+	Name    string      `json:"name"`
+	Length  int         `json:"length"`
+	Signals []DBCSignal `json:"signals"`
+}
+
+// DBC holds the messages of a loaded DBC file
+type DBC struct {
+	Path     string
+	messages map[string]*DBCMessage
+}
+
+// LoadDBC reads message and signal definitions from a DBC file
+func LoadDBC(path string) (*DBC, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DBC file: %w", err)
+	}
+	defer file.Close()
+
+	db := &DBC{Path: path, messages: make(map[string]*DBCMessage)}
+	var current *DBCMessage
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if match := dbcMessagePattern.FindStringSubmatch(line); match != nil {
+			id, _ := strconv.ParseUint(match[1], 10, 32)
+			length, _ := strconv.Atoi(match[3])
+			if length > classicCANMaxDataLength {
+				return nil, fmt.Errorf("%s:%d: message %s is %d bytes; only classic CAN (8 bytes) is supported",
+					path, lineNumber, match[2], length)
+			}
+			if _, exists := db.messages[match[2]]; exists {
+				return nil, fmt.Errorf("%s:%d: duplicate message %s", path, lineNumber, match[2])
+			}
+			current = &DBCMessage{ID: uint32(id), Name: match[2], Length: length}
+			db.messages[current.Name] = current
+			continue
+		}
+
+		// Signals belong to the message above until a blank line or another section
+		if !strings.HasPrefix(line, "SG_ ") {
+			current = nil
+			continue
+		}
+		if current == nil {
+			return nil, fmt.Errorf("%s:%d: signal outside of a message", path, lineNumber)
+		}
+
+		signal, err := parseDBCSignal(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		for _, position := range signal.bitPositions() {
+			if position < 0 || position >= 8*current.Length {
+				return nil, fmt.Errorf("%s:%d: signal %s does not fit in %d bytes", path, lineNumber, signal.Name, current.Length)
+			}
+		}
+		current.Signals = append(current.Signals, signal)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read DBC file: %w", err)
+	}
+	if len(db.messages) == 0 {
+		return nil, fmt.Errorf("%s: no messages defined", path)
+	}
+
+	return db, nil
+}
+
+// parseDBCSignal parses an SG_ line
+func parseDBCSignal(line string) (DBCSignal, error) {
+	match := dbcSignalPattern.FindStringSubmatch(line)
+	if match == nil {
+		return DBCSignal{}, fmt.Errorf("invalid signal definition %q", line)
+	}
+
+	signal := DBCSignal{
+		Name:         match[1],
+		LittleEndian: match[5] == "1",
+		Signed:       match[6] == "-",
+		Unit:         match[11],
+	}
+	signal.StartBit, _ = strconv.Atoi(match[3])
+	signal.Length, _ = strconv.Atoi(match[4])
+	if signal.Length < 1 || signal.Length > 64 || signal.StartBit > 63 {
+		return DBCSignal{}, fmt.Errorf("signal %s: invalid position %d|%d", signal.Name, signal.StartBit, signal.Length)
+	}
+
+	switch {
+	case match[2] == "M":
+		signal.Multiplexer = true
+	case match[2] != "":
+		value, _ := strconv.Atoi(match[2][1:])
+		signal.MultiplexValue = &value
+	}
+
+	numbers := []*float64{&signal.Factor, &signal.Offset, &signal.Min, &signal.Max}
+	for i, raw := range match[7:11] {
+		value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil {
+			return DBCSignal{}, fmt.Errorf("signal %s: invalid number %q", signal.Name, raw)
+		}
+		*numbers[i] = value
+	}
+	if signal.Factor == 0 {
+		return DBCSignal{}, fmt.Errorf("signal %s: factor cannot be 0", signal.Name)
+	}
+
+	return signal, nil
+}
+
+// GetMessage returns a message definition by name
+func (db *DBC) GetMessage(name string) (*DBCMessage, bool) {
+	message, ok := db.messages[name]
+	return message, ok
+}
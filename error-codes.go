@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorCode identifies the kind of an API error. Codes are stable; clients match them
+// instead of the message.
+type ErrorCode string
+
+// API error codes
+const (
+	CodeInvalidRequest       ErrorCode = "INVALID_REQUEST"       // Malformed body or query parameters
+	CodeValidationFailed     ErrorCode = "VALIDATION_FAILED"     // Well-formed frame the interface cannot send
+	CodeInvalidID            ErrorCode = "INVALID_ID"            // CAN ID out of range
+	CodeInterfaceNotFound    ErrorCode = "INTERFACE_NOT_FOUND"   // Interface not configured or not present
+	CodeInterfaceDown        ErrorCode = "INTERFACE_DOWN"        // Interface not initialized or link down
+	CodeBusOff               ErrorCode = "BUS_OFF"               // Controller is bus-off
+	CodeTxBufferFull         ErrorCode = "TX_BUFFER_FULL"        // Kernel TX queue or socket buffer still full after retries
+	CodeTxQueueFull          ErrorCode = "TX_QUEUE_FULL"         // Priority band of the interface's TX queue full
+	CodeSendFailed           ErrorCode = "SEND_FAILED"           // Any other failed write
+	CodeTxDisabled           ErrorCode = "TX_DISABLED"           // Transmission on the interface is disabled
+	CodeUnauthorized         ErrorCode = "UNAUTHORIZED"          // Missing or unknown API key
+	CodeForbidden            ErrorCode = "FORBIDDEN"             // Role, client certificate or network not allowed
+	CodeNotFound             ErrorCode = "NOT_FOUND"             // Unknown route or resource
+	CodeConflict             ErrorCode = "CONFLICT"              // Request conflicts with the current state
+	CodeUnavailable          ErrorCode = "UNAVAILABLE"           // Component disabled in this configuration
+	CodeShuttingDown         ErrorCode = "SHUTTING_DOWN"         // Service is draining sends before exit
+	CodePermissionDenied     ErrorCode = "PERMISSION_DENIED"     // Service lacks CAP_NET_ADMIN to change interfaces
+	CodeInvalidConfig        ErrorCode = "INVALID_CONFIG"        // Reloaded configuration has problems; the running one is kept
+	CodeRequestTooLarge      ErrorCode = "REQUEST_TOO_LARGE"     // Body over -max-body-size or more frames than -max-batch-frames
+	CodePreconditionFailed   ErrorCode = "PRECONDITION_FAILED"   // If-Match names an outdated configuration
+	CodePreconditionRequired ErrorCode = "PRECONDITION_REQUIRED" // If-Match missing on a configuration change
+	CodeInternal             ErrorCode = "INTERNAL"              // Unexpected failure, including panics
+)
+
+// errorMapping assigns a code and HTTP status to a sentinel error
+type errorMapping struct {
+	err    error
+	code   ErrorCode
+	status int
+}
+
+// errorMappings maps the sentinel errors of the components to API errors. The first
+// sentinel an error matches wins, so more specific ones come first.
+var errorMappings = []errorMapping{
+	{ErrBusOff, CodeBusOff, http.StatusServiceUnavailable},
+	{ErrTxBufferFull, CodeTxBufferFull, http.StatusServiceUnavailable},
+	{ErrTxQueueFull, CodeTxQueueFull, http.StatusServiceUnavailable},
+	{ErrInterfaceNotFound, CodeInterfaceNotFound, http.StatusNotFound},
+	{ErrInterfaceDown, CodeInterfaceDown, http.StatusServiceUnavailable},
+	{ErrTxDisabled, CodeTxDisabled, http.StatusConflict},
+	{ErrInvalidID, CodeInvalidID, http.StatusBadRequest},
+	{ErrValidation, CodeValidationFailed, http.StatusBadRequest},
+	{ErrSendFailed, CodeSendFailed, http.StatusInternalServerError},
+	{ErrShuttingDown, CodeShuttingDown, http.StatusServiceUnavailable},
+	{ErrPermissionDenied, CodePermissionDenied, http.StatusInternalServerError},
+	{ErrInvalidConfig, CodeInvalidConfig, http.StatusUnprocessableEntity},
+	{ErrConfigModified, CodePreconditionFailed, http.StatusPreconditionFailed},
+	{ErrNoConfigFile, CodeConflict, http.StatusConflict},
+	{ErrTriggerNotFound, CodeNotFound, http.StatusNotFound},
+	{ErrTriggerExists, CodeConflict, http.StatusConflict},
+	{ErrNamedMessageNotFound, CodeNotFound, http.StatusNotFound},
+}
+
+// statusErrorCodes are the codes of errors no sentinel matches, by the HTTP status the
+// handler chose
+var statusErrorCodes = map[int]ErrorCode{
+	http.StatusBadRequest:            CodeInvalidRequest,
+	http.StatusUnauthorized:          CodeUnauthorized,
+	http.StatusForbidden:             CodeForbidden,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusConflict:              CodeConflict,
+	http.StatusRequestEntityTooLarge: CodeRequestTooLarge,
+	http.StatusPreconditionRequired:  CodePreconditionRequired,
+	http.StatusServiceUnavailable:    CodeUnavailable,
+}
+
+// classifyError returns the HTTP status and code of an error: those of the sentinel it
+// wraps, else the handler's status and its code
+func classifyError(err error, statusCode int) (int, ErrorCode) {
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.err) {
+			return mapping.status, mapping.code
+		}
+	}
+	if code, ok := statusErrorCodes[statusCode]; ok {
+		return statusCode, code
+	}
+	return statusCode, CodeInternal
+}
+
+// errorCode returns the code of an error reported outside a response, e.g. per interface
+func errorCode(err error) ErrorCode {
+	_, code := classifyError(err, http.StatusInternalServerError)
+	return code
+}
+
+// taggedError classifies an error by a sentinel without changing its message
+type taggedError struct {
+	kind error
+	err  error
+}
+
+func (e *taggedError) Error() string   { return e.err.Error() }
+func (e *taggedError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// tagError marks err as a kind of sentinel error, keeping its message and wrapped errors
+func tagError(kind, err error) error {
+	return &taggedError{kind: kind, err: err}
+}
+
+// newErrorResponse builds the error envelope. Error repeats the message for clients
+// written before codes existed.
+func newErrorResponse(c *gin.Context, code ErrorCode, message string, details interface{}) ApiResponse {
+	return ApiResponse{
+		Status:    "error",
+		Code:      code,
+		Message:   message,
+		Error:     message,
+		Details:   details,
+		RequestID: requestID(c),
+	}
+}
+
+// abortWithError rejects a request in a middleware with the error envelope
+func abortWithError(c *gin.Context, statusCode int, code ErrorCode, message string) {
+	c.AbortWithStatusJSON(statusCode, newErrorResponse(c, code, message, nil))
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// sentinelErrors lists every sentinel error of the package with the code and status the
+// API reports it with
+var sentinelErrors = map[string]struct {
+	err    error
+	code   ErrorCode
+	status int
+}{
+	"ErrBusOff":               {ErrBusOff, CodeBusOff, http.StatusServiceUnavailable},
+	"ErrTxBufferFull":         {ErrTxBufferFull, CodeTxBufferFull, http.StatusServiceUnavailable},
+	"ErrTxQueueFull":          {ErrTxQueueFull, CodeTxQueueFull, http.StatusServiceUnavailable},
+	"ErrInterfaceNotFound":    {ErrInterfaceNotFound, CodeInterfaceNotFound, http.StatusNotFound},
+	"ErrInterfaceDown":        {ErrInterfaceDown, CodeInterfaceDown, http.StatusServiceUnavailable},
+	"ErrTxDisabled":           {ErrTxDisabled, CodeTxDisabled, http.StatusConflict},
+	"ErrInvalidID":            {ErrInvalidID, CodeInvalidID, http.StatusBadRequest},
+	"ErrValidation":           {ErrValidation, CodeValidationFailed, http.StatusBadRequest},
+	"ErrSendFailed":           {ErrSendFailed, CodeSendFailed, http.StatusInternalServerError},
+	"ErrShuttingDown":         {ErrShuttingDown, CodeShuttingDown, http.StatusServiceUnavailable},
+	"ErrPermissionDenied":     {ErrPermissionDenied, CodePermissionDenied, http.StatusInternalServerError},
+	"ErrInvalidConfig":        {ErrInvalidConfig, CodeInvalidConfig, http.StatusUnprocessableEntity},
+	"ErrConfigModified":       {ErrConfigModified, CodePreconditionFailed, http.StatusPreconditionFailed},
+	"ErrNoConfigFile":         {ErrNoConfigFile, CodeConflict, http.StatusConflict},
+	"ErrTriggerNotFound":      {ErrTriggerNotFound, CodeNotFound, http.StatusNotFound},
+	"ErrTriggerExists":        {ErrTriggerExists, CodeConflict, http.StatusConflict},
+	"ErrNamedMessageNotFound": {ErrNamedMessageNotFound, CodeNotFound, http.StatusNotFound},
+}
+
+// declaredSentinels returns the names of the package-level variables initialized with
+// errors.New in the non-test sources of the package
+func declaredSentinels(t *testing.T) []string {
+	t.Helper()
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	fset := token.NewFileSet()
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				value := spec.(*ast.ValueSpec)
+				for i, name := range value.Names {
+					if i < len(value.Values) && isErrorsNew(value.Values[i]) {
+						names = append(names, name.Name)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isErrorsNew reports whether an expression is a call of errors.New
+func isErrorsNew(expr ast.Expr) bool {
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	fun, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	pkg, ok := fun.X.(*ast.Ident)
+	return ok && pkg.Name == "errors" && fun.Sel.Name == "New"
+}
+
+func TestEverySentinelErrorIsMapped(t *testing.T) {
+	declared := declaredSentinels(t)
+	if len(declared) == 0 {
+		t.Fatal("no sentinel errors found in the sources")
+	}
+	for _, name := range declared {
+		if _, ok := sentinelErrors[name]; !ok {
+			t.Errorf("%s has no API error: add it to errorMappings and to sentinelErrors here", name)
+		}
+	}
+	if len(errorMappings) != len(sentinelErrors) {
+		t.Errorf("errorMappings has %d entries, want one per sentinel error (%d)", len(errorMappings), len(sentinelErrors))
+	}
+}
+
+func TestClassifySentinelErrors(t *testing.T) {
+	for name, want := range sentinelErrors {
+		t.Run(name, func(t *testing.T) {
+			errs := map[string]error{
+				"bare":    want.err,
+				"wrapped": fmt.Errorf("sending on can0: %w", want.err),
+				"tagged":  tagError(want.err, errors.New("details")),
+			}
+			for form, err := range errs {
+				// The status the handler chose does not matter once a sentinel matches
+				status, code := classifyError(err, http.StatusTeapot)
+				if status != want.status || code != want.code {
+					t.Errorf("%s: classifyError() = %d %s, want %d %s", form, status, code, want.status, want.code)
+				}
+				if code := errorCode(err); code != want.code {
+					t.Errorf("%s: errorCode() = %s, want %s", form, code, want.code)
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyErrorPrecedence(t *testing.T) {
+	// A write that failed because the controller went bus-off is reported as bus-off
+	err := tagError(ErrSendFailed, fmt.Errorf("write can0: %w", ErrBusOff))
+	if status, code := classifyError(err, http.StatusInternalServerError); status != http.StatusServiceUnavailable || code != CodeBusOff {
+		t.Errorf("classifyError() = %d %s, want %d %s", status, code, http.StatusServiceUnavailable, CodeBusOff)
+	}
+}
+
+func TestClassifyErrorByStatus(t *testing.T) {
+	plain := errors.New("something went wrong")
+	for status, want := range statusErrorCodes {
+		if gotStatus, code := classifyError(plain, status); gotStatus != status || code != want {
+			t.Errorf("classifyError(%d) = %d %s, want %d %s", status, gotStatus, code, status, want)
+		}
+	}
+	if status, code := classifyError(plain, http.StatusTeapot); status != http.StatusTeapot || code != CodeInternal {
+		t.Errorf("classifyError(%d) = %d %s, want %d %s", http.StatusTeapot, status, code, http.StatusTeapot, CodeInternal)
+	}
+	if code := errorCode(plain); code != CodeInternal {
+		t.Errorf("errorCode() = %s, want %s", code, CodeInternal)
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// DefaultErrorBurstThreshold is the error frame rate (frames per second) above which an
+// interface is flagged. Such bursts almost always mean a bitrate mismatch or a shorted line.
+const DefaultErrorBurstThreshold = 50
+
+// errorClassNames maps error frame class bits (CAN ID) to names
+var errorClassNames = []struct {
+	bit  uint32
+	name string
+}{
+	{unix.CAN_ERR_TX_TIMEOUT, "tx_timeout"},
+	{unix.CAN_ERR_LOSTARB, "lost_arbitration"},
+	{unix.CAN_ERR_CRTL, "controller"},
+	{unix.CAN_ERR_PROT, "protocol"},
+	{unix.CAN_ERR_TRX, "transceiver"},
+	{unix.CAN_ERR_ACK, "no_ack"},
+	{unix.CAN_ERR_BUSOFF, "bus_off"},
+	{unix.CAN_ERR_BUSERROR, "bus_error"},
+	{unix.CAN_ERR_RESTARTED, "restarted"},
+}
+
+// controllerProblemNames maps controller status bits (data[1]) to names
+var controllerProblemNames = []struct {
+	bit  uint8
+	name string
+}{
+	{unix.CAN_ERR_CRTL_RX_OVERFLOW, "rx_overflow"},
+	{unix.CAN_ERR_CRTL_TX_OVERFLOW, "tx_overflow"},
+	{unix.CAN_ERR_CRTL_RX_WARNING, "rx_warning"},
+	{unix.CAN_ERR_CRTL_TX_WARNING, "tx_warning"},
+	{unix.CAN_ERR_CRTL_RX_PASSIVE, "rx_passive"},
+	{unix.CAN_ERR_CRTL_TX_PASSIVE, "tx_passive"},
+	{unix.CAN_ERR_CRTL_ACTIVE, "active"},
+}
+
+// protocolErrorNames maps protocol error type bits (data[2]) to names
+var protocolErrorNames = []struct {
+	bit  uint8
+	name string
+}{
+	{unix.CAN_ERR_PROT_BIT, "bit"},
+	{unix.CAN_ERR_PROT_FORM, "form"},
+	{unix.CAN_ERR_PROT_STUFF, "stuff"},
+	{unix.CAN_ERR_PROT_BIT0, "bit0"},
+	{unix.CAN_ERR_PROT_BIT1, "bit1"},
+	{unix.CAN_ERR_PROT_OVERLOAD, "overload"},
+	{unix.CAN_ERR_PROT_ACTIVE, "active"},
+}
+
+// protocolLocationNames maps protocol error locations (data[3]) to names
+var protocolLocationNames = map[uint8]string{
+	unix.CAN_ERR_PROT_LOC_UNSPEC:  "unspecified",
+	unix.CAN_ERR_PROT_LOC_SOF:     "sof",
+	unix.CAN_ERR_PROT_LOC_ID28_21: "id28_21",
+	unix.CAN_ERR_PROT_LOC_ID20_18: "id20_18",
+	unix.CAN_ERR_PROT_LOC_SRTR:    "srtr",
+	unix.CAN_ERR_PROT_LOC_IDE:     "ide",
+	unix.CAN_ERR_PROT_LOC_ID17_13: "id17_13",
+	unix.CAN_ERR_PROT_LOC_ID12_05: "id12_05",
+	unix.CAN_ERR_PROT_LOC_ID04_00: "id04_00",
+	unix.CAN_ERR_PROT_LOC_RTR:     "rtr",
+	unix.CAN_ERR_PROT_LOC_RES1:    "res1",
+	unix.CAN_ERR_PROT_LOC_RES0:    "res0",
+	unix.CAN_ERR_PROT_LOC_DLC:     "dlc",
+	unix.CAN_ERR_PROT_LOC_DATA:    "data",
+	unix.CAN_ERR_PROT_LOC_CRC_SEQ: "crc_seq",
+	unix.CAN_ERR_PROT_LOC_CRC_DEL: "crc_del",
+	unix.CAN_ERR_PROT_LOC_ACK:     "ack",
+	unix.CAN_ERR_PROT_LOC_ACK_DEL: "ack_del",
+	unix.CAN_ERR_PROT_LOC_EOF:     "eof",
+	unix.CAN_ERR_PROT_LOC_INTERM:  "intermission",
+}
+
+// isErrorFrame reports whether a received CAN ID carries the error frame flag
+func isErrorFrame(id uint32) bool {
+	return id&unix.CAN_ERR_FLAG != 0
+}
+
+// InterfaceErrorStats is a snapshot of error frame statistics of an interface
+type InterfaceErrorStats struct {
+	Interface        string            `json:"interface"`
+	TotalErrorFrames uint64            `json:"totalErrorFrames"`
+	ByClass          map[string]uint64 `json:"byClass"`
+	ByProtocolError  map[string]uint64 `json:"byProtocolError"` // "crc" counts protocol errors located in the CRC field
+	ByLocation       map[string]uint64 `json:"byLocation"`      // Where in the frame protocol errors occurred
+	TxProtocolErrors uint64            `json:"txProtocolErrors"`
+	Controller       map[string]uint64 `json:"controller"`
+	LostArbitration  map[int]uint64    `json:"lostArbitrationBits"` // Bit position where arbitration was lost
+	TxErrorCounter   uint8             `json:"txErrorCounter"`      // Last reported controller error counters
+	RxErrorCounter   uint8             `json:"rxErrorCounter"`
+	ControllerState  string            `json:"controllerState,omitempty"` // Last reported state, e.g. ERROR-PASSIVE or BUS-OFF
+	LastErrorTime    time.Time         `json:"lastErrorTime,omitempty"`
+	LastSecondFrames int               `json:"lastSecondFrames"` // Error frames in the last complete second
+	Rates            RollingRates      `json:"rates"`            // Error frames per second
+	BurstThreshold   int               `json:"burstThreshold"`
+	Burst            bool              `json:"burst"`
+	BurstSince       time.Time         `json:"burstSince,omitempty"`
+}
+
+// errorFrameCounters holds the error frame counters of one interface
+type errorFrameCounters struct {
+	stats           InterfaceErrorStats
+	rate            RateTracker
+	lastBurstSecond int64 // Last second whose rate exceeded the threshold
+}
+
+// ErrorFrameTracker classifies CAN error frames and detects error bursts per interface
+type ErrorFrameTracker struct {
+	interfaces map[string]*errorFrameCounters
+	threshold  int
+	mutex      sync.Mutex
+}
+
+// NewErrorFrameTracker creates a tracker flagging bursts above threshold frames per second
+func NewErrorFrameTracker(threshold int) *ErrorFrameTracker {
+	return &ErrorFrameTracker{
+		interfaces: make(map[string]*errorFrameCounters),
+		threshold:  threshold,
+	}
+}
+
+// SetBurstThreshold changes the burst threshold
+func (t *ErrorFrameTracker) SetBurstThreshold(threshold int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.threshold = threshold
+}
+
+// Observe classifies an error frame and returns the current error rate and whether it
+// started a burst
+func (t *ErrorFrameTracker) Observe(msg CanMessageLog) (int, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	counters := t.countersLocked(msg.Interface)
+	stats := &counters.stats
+	var data [8]byte
+	copy(data[:], msg.Data)
+
+	stats.TotalErrorFrames++
+	stats.LastErrorTime = msg.Timestamp
+
+	for _, class := range errorClassNames {
+		if msg.ID&class.bit != 0 {
+			stats.ByClass[class.name]++
+		}
+	}
+
+	if msg.ID&unix.CAN_ERR_LOSTARB != 0 {
+		stats.LostArbitration[int(data[0])]++
+	}
+
+	if msg.ID&unix.CAN_ERR_CRTL != 0 {
+		for _, problem := range controllerProblemNames {
+			if data[1]&problem.bit != 0 {
+				stats.Controller[problem.name]++
+			}
+		}
+	}
+
+	if msg.ID&unix.CAN_ERR_PROT != 0 {
+		for _, protocol := range protocolErrorNames {
+			if data[2]&protocol.bit != 0 {
+				stats.ByProtocolError[protocol.name]++
+			}
+		}
+		if data[2]&unix.CAN_ERR_PROT_TX != 0 {
+			stats.TxProtocolErrors++
+		}
+
+		location, known := protocolLocationNames[data[3]]
+		if !known {
+			location = "unknown"
+		}
+		stats.ByLocation[location]++
+		if data[3] == unix.CAN_ERR_PROT_LOC_CRC_SEQ || data[3] == unix.CAN_ERR_PROT_LOC_CRC_DEL {
+			stats.ByProtocolError["crc"]++
+		}
+	}
+
+	if msg.ID&unix.CAN_ERR_CNT != 0 {
+		stats.TxErrorCounter = data[6]
+		stats.RxErrorCounter = data[7]
+	}
+
+	if state := controllerState(msg.ID, data[1]); state != "" {
+		stats.ControllerState = state
+	}
+
+	// Per-second rate for burst detection
+	second := msg.Timestamp.Unix()
+	counters.rate.Add(msg.Timestamp, 1)
+	secondFrames := int(counters.rate.Count(second))
+
+	if secondFrames <= t.threshold {
+		return secondFrames, false
+	}
+	started := counters.lastBurstSecond < second-1
+	if started {
+		stats.BurstSince = msg.Timestamp
+	}
+	counters.lastBurstSecond = second
+	return secondFrames, started
+}
+
+// controllerState derives the controller state an error frame reports, or "" if it reports none.
+// The most severe state wins when several bits are set.
+func controllerState(id uint32, status byte) string {
+	switch {
+	case id&unix.CAN_ERR_BUSOFF != 0:
+		return "BUS-OFF"
+	case id&unix.CAN_ERR_CRTL == 0:
+		if id&unix.CAN_ERR_RESTARTED != 0 {
+			return "ERROR-ACTIVE"
+		}
+		return ""
+	case status&(unix.CAN_ERR_CRTL_RX_PASSIVE|unix.CAN_ERR_CRTL_TX_PASSIVE) != 0:
+		return "ERROR-PASSIVE"
+	case status&(unix.CAN_ERR_CRTL_RX_WARNING|unix.CAN_ERR_CRTL_TX_WARNING) != 0:
+		return "ERROR-WARNING"
+	case status&unix.CAN_ERR_CRTL_ACTIVE != 0 || id&unix.CAN_ERR_RESTARTED != 0:
+		return "ERROR-ACTIVE"
+	}
+	return ""
+}
+
+// GetStats returns the error frame statistics of an interface
+func (t *ErrorFrameTracker) GetStats(ifName string) InterfaceErrorStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.snapshotLocked(ifName, t.countersLocked(ifName))
+}
+
+// GetAllStats returns the error frame statistics of every interface that reported errors
+func (t *ErrorFrameTracker) GetAllStats() map[string]InterfaceErrorStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	result := make(map[string]InterfaceErrorStats)
+	for ifName, counters := range t.interfaces {
+		result[ifName] = t.snapshotLocked(ifName, counters)
+	}
+	return result
+}
+
+// IsBursting reports whether an interface exceeded the burst threshold within the last second
+func (t *ErrorFrameTracker) IsBursting(ifName string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	counters, exists := t.interfaces[ifName]
+	return exists && counters.lastBurstSecond >= time.Now().Unix()-1
+}
+
+// GetRate returns the error frames per second of an interface over window
+func (t *ErrorFrameTracker) GetRate(ifName string, window time.Duration) float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if counters, exists := t.interfaces[ifName]; exists {
+		return counters.rate.Rate(time.Now(), window)
+	}
+	return 0
+}
+
+// snapshotLocked copies the counters of an interface (caller holds mutex)
+func (t *ErrorFrameTracker) snapshotLocked(ifName string, counters *errorFrameCounters) InterfaceErrorStats {
+	snapshot := counters.stats
+	snapshot.Interface = ifName
+	snapshot.ByClass = copyCounts(counters.stats.ByClass)
+	snapshot.ByProtocolError = copyCounts(counters.stats.ByProtocolError)
+	snapshot.ByLocation = copyCounts(counters.stats.ByLocation)
+	snapshot.Controller = copyCounts(counters.stats.Controller)
+	snapshot.LostArbitration = make(map[int]uint64, len(counters.stats.LostArbitration))
+	for bit, count := range counters.stats.LostArbitration {
+		snapshot.LostArbitration[bit] = count
+	}
+
+	current := time.Now()
+	now := current.Unix()
+	snapshot.LastSecondFrames = int(counters.rate.Count(now - 1))
+	snapshot.Rates = counters.rate.Rates(current)
+
+	snapshot.BurstThreshold = t.threshold
+	snapshot.Burst = counters.lastBurstSecond >= now-1
+	if !snapshot.Burst {
+		snapshot.BurstSince = time.Time{}
+	}
+	return snapshot
+}
+
+// countersLocked returns the counters of an interface, creating them if needed (caller holds mutex)
+func (t *ErrorFrameTracker) countersLocked(ifName string) *errorFrameCounters {
+	counters, exists := t.interfaces[ifName]
+	if !exists {
+		counters = &errorFrameCounters{
+			stats: InterfaceErrorStats{
+				ByClass:         make(map[string]uint64),
+				ByProtocolError: make(map[string]uint64),
+				ByLocation:      make(map[string]uint64),
+				Controller:      make(map[string]uint64),
+				LostArbitration: make(map[int]uint64),
+			},
+		}
+		t.interfaces[ifName] = counters
+	}
+	return counters
+}
+
+// copyCounts copies a counter map
+func copyCounts(counts map[string]uint64) map[string]uint64 {
+	result := make(map[string]uint64, len(counts))
+	for key, count := range counts {
+		result[key] = count
+	}
+	return result
+}
+
+// sortedCountKeys returns the keys of a counter map in order
+func sortedCountKeys(counts map[string]uint64) []string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,718 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Frame capture defaults
+const (
+	DefaultCaptureMaxFileSize  = 64 << 20 // 64 MiB
+	DefaultCaptureMaxTotalSize = 1 << 30  // 1 GiB
+
+	captureQueueSize     = 8192 // Frames waiting to be written, shared by every interface
+	captureFilePrefix    = "candump-"
+	captureFileSuffix    = ".log"
+	captureSidecarSuffix = ".tx.jsonl" // Sent frames with their request, principal and job
+)
+
+// CaptureConfig configures the candump frame capture
+type CaptureConfig struct {
+	Dir          string        // Directory the capture files are written to
+	Interfaces   []string      // Captured from startup
+	TX           bool          // Also record frames sent by the bridge, marking every line T or R
+	TXContext    bool          // Record what each sent frame was sent for in a sidecar of its capture file
+	MaxFileSize  int64         // A file is rotated before it grows past this size
+	MaxTotalSize int64         // The oldest files are deleted while the directory holds more
+	MaxAge       time.Duration // A file is rotated once it has been written for this long; 0 disables
+	MaxFiles     int           // Finished files kept, the oldest are deleted; 0 for no limit
+	Compress     bool          // gzip finished files
+}
+
+// rotation returns the policy the capture files are rotated by
+func (c CaptureConfig) rotation() RotationPolicy {
+	return RotationPolicy{MaxSize: c.MaxFileSize, MaxAge: c.MaxAge, MaxFiles: c.MaxFiles, Compress: c.Compress}
+}
+
+// CaptureStatus reports the frame capture and its counters
+type CaptureStatus struct {
+	Dir          string                      `json:"dir"`
+	TX           bool                        `json:"tx"`
+	TXContext    bool                        `json:"txContext"`
+	MaxFileSize  int64                       `json:"maxFileSize"`
+	MaxTotalSize int64                       `json:"maxTotalSize"`
+	MaxAge       string                      `json:"maxAge,omitempty"`
+	MaxFiles     int                         `json:"maxFiles,omitempty"`
+	Compress     bool                        `json:"compress"`
+	DiskUsage    int64                       `json:"diskUsage"`  // Bytes of capture files in the directory
+	Interfaces   map[string]InterfaceCapture `json:"interfaces"` // Interfaces being captured
+	Captured     uint64                      `json:"captured"`
+	Written      uint64                      `json:"written"`
+	Dropped      uint64                      `json:"dropped"` // Frames lost because the queue was full
+	WriteErrors  uint64                      `json:"writeErrors"`
+	Rotations    uint64                      `json:"rotations"`
+	FilesDeleted uint64                      `json:"filesDeleted"` // Old files deleted to stay within maxTotalSize and maxFiles
+	QueueDepth   int                         `json:"queueDepth"`
+}
+
+// InterfaceCapture describes the capture of one interface
+type InterfaceCapture struct {
+	Since time.Time `json:"since"`
+	File  string    `json:"file,omitempty"` // Current file, once the first frame was written
+}
+
+// captureRecord is a frame to write, or with closeFile the end of an interface's capture
+type captureRecord struct {
+	msg       CanMessageLog
+	tx        bool
+	origin    captureOrigin // Of sent frames
+	closeFile bool
+}
+
+// captureOrigin is what a sent frame was sent for
+type captureOrigin struct {
+	RequestID  string `json:"requestId,omitempty"`  // ID of the API request
+	Client     string `json:"client,omitempty"`     // API key principal, client certificate identity or internal sender
+	RemoteAddr string `json:"remoteAddr,omitempty"` // Client address of the API request
+	JobID      string `json:"jobId,omitempty"`      // Transmit task or sequence
+}
+
+// captureSidecarLine is a line of the sidecar of a capture file: a sent frame, the line
+// it is on in the capture file and what it was sent for
+type captureSidecarLine struct {
+	Line  int64  `json:"line"`  // 1-based line number in the capture file
+	Frame string `json:"frame"` // The line in the capture file, without the T
+	captureOrigin
+}
+
+// captureFile is the file an interface is currently captured to. Only the writer
+// goroutine uses it.
+type captureFile struct {
+	path     string
+	file     *os.File
+	writer   *bufio.Writer
+	size     int64
+	lines    int64
+	openedAt time.Time
+
+	sidecar       *os.File // Opened with the first sent frame, with -capture-tx-context
+	sidecarWriter *bufio.Writer
+}
+
+// FrameCapture writes received and, optionally, sent frames to files in the candump log
+// format, "(1697461923.123456) can0 123#DEADBEEF", one file per interface, for offline
+// analysis with can-utils. Frames are queued and written by a background worker, so a
+// slow disk never delays the receive loop; when the queue is full the frame is dropped
+// and counted. A nil FrameCapture is valid and captures nothing.
+type FrameCapture struct {
+	config         CaptureConfig
+	configProvider ConfigProvider
+	logger         Logger
+	queue          chan captureRecord
+	stopChan       chan struct{}
+	wg             sync.WaitGroup
+	stopOnce       sync.Once
+
+	mu     sync.RWMutex
+	active map[string]time.Time // Captured interfaces and when their capture started
+
+	files     map[string]*captureFile // Owned by the writer goroutine
+	fileNames sync.Map                // Interface to the name of its current file, for Status
+
+	retention        sync.Mutex     // Serializes compressing and deleting finished files
+	retentionPending sync.WaitGroup // Background compress and delete runs
+
+	captured     atomic.Uint64
+	written      atomic.Uint64
+	dropped      atomic.Uint64
+	writeErrors  atomic.Uint64
+	rotations    atomic.Uint64
+	filesDeleted atomic.Uint64
+}
+
+// NewFrameCapture creates the capture directory and starts the writer. The interfaces
+// of config are captured at once.
+func NewFrameCapture(config CaptureConfig, configProvider ConfigProvider, logger Logger) (*FrameCapture, error) {
+	if err := os.MkdirAll(config.Dir, 0750); err != nil {
+		return nil, fmt.Errorf("failed to create capture directory: %w", err)
+	}
+
+	fc := &FrameCapture{
+		config:         config,
+		configProvider: configProvider,
+		logger:         logger,
+		queue:          make(chan captureRecord, captureQueueSize),
+		stopChan:       make(chan struct{}),
+		active:         make(map[string]time.Time),
+		files:          make(map[string]*captureFile),
+	}
+	now := time.Now()
+	for _, ifName := range config.Interfaces {
+		fc.active[ifName] = now
+	}
+	if len(config.Interfaces) > 0 {
+		logger.Infof("📼 Capturing frames of %v to %s", config.Interfaces, config.Dir)
+	}
+	fc.wg.Add(1)
+	go fc.writeLoop()
+	return fc, nil
+}
+
+// Start starts capturing a configured interface. It reports whether the capture
+// started, false when it was already running.
+func (fc *FrameCapture) Start(ifName string) (bool, error) {
+	if err := fc.checkInterface(ifName); err != nil {
+		return false, err
+	}
+
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if _, capturing := fc.active[ifName]; capturing {
+		return false, nil
+	}
+	fc.active[ifName] = time.Now()
+	fc.logger.Infof("📼 Capturing frames of %s to %s", ifName, fc.config.Dir)
+	return true, nil
+}
+
+// Stop stops capturing a configured interface and closes its file once the frames
+// queued before are written. It reports whether the capture stopped, false when it was
+// not running.
+func (fc *FrameCapture) Stop(ifName string) (bool, error) {
+	if err := fc.checkInterface(ifName); err != nil {
+		return false, err
+	}
+
+	fc.mu.Lock()
+	_, capturing := fc.active[ifName]
+	delete(fc.active, ifName)
+	fc.mu.Unlock()
+	if !capturing {
+		return false, nil
+	}
+
+	// Unlike frames, the end of a capture is never dropped
+	select {
+	case fc.queue <- captureRecord{msg: CanMessageLog{Interface: ifName}, closeFile: true}:
+	case <-fc.stopChan:
+	}
+	fc.logger.Infof("📼 Stopped capturing frames of %s", ifName)
+	return true, nil
+}
+
+// checkInterface rejects interfaces missing from the configured ports
+func (fc *FrameCapture) checkInterface(ifName string) error {
+	if !fc.configProvider.ValidateInterface(ifName) {
+		return tagError(ErrInterfaceNotFound, fmt.Errorf("CAN interface %s is not configured. Available interfaces: %v",
+			ifName, fc.configProvider.GetCanPorts()))
+	}
+	return nil
+}
+
+// Capturing reports whether an interface is being captured
+func (fc *FrameCapture) Capturing(ifName string) bool {
+	if fc == nil {
+		return false
+	}
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+	_, capturing := fc.active[ifName]
+	return capturing
+}
+
+// ObserveFrame queues a received frame of a captured interface without blocking
+func (fc *FrameCapture) ObserveFrame(msg CanMessageLog) {
+	if !fc.Capturing(msg.Interface) {
+		return
+	}
+	fc.enqueue(captureRecord{msg: msg})
+}
+
+// ObserveSent queues a frame the bridge wrote to a captured interface, when sent frames
+// are captured
+func (fc *FrameCapture) ObserveSent(msg CanMessage, sentAt time.Time) {
+	if fc == nil || !fc.config.TX || !fc.Capturing(msg.Interface) {
+		return
+	}
+
+	length := uint8(len(msg.Data))
+	if msg.RTR {
+		length = msg.Length
+	}
+	fc.enqueue(captureRecord{
+		msg: CanMessageLog{
+			Interface: msg.Interface,
+			ID:        msg.ID,
+			Data:      msg.Data,
+			Length:    length,
+			RTR:       msg.RTR,
+			Timestamp: sentAt,
+		},
+		tx: true,
+		origin: captureOrigin{
+			RequestID:  msg.requestID,
+			Client:     msg.client,
+			RemoteAddr: msg.remoteAddr,
+			JobID:      msg.jobID,
+		},
+	})
+}
+
+// enqueue queues a frame, dropping and counting it when the queue is full
+func (fc *FrameCapture) enqueue(record captureRecord) {
+	fc.captured.Add(1)
+	select {
+	case fc.queue <- record:
+	default:
+		if fc.dropped.Add(1) == 1 {
+			fc.logger.Warnf("⚠️ Warning: frame capture queue full, dropping frames (see GET /api/v1/capture)")
+		}
+	}
+}
+
+// Status returns the captured interfaces and the counters
+func (fc *FrameCapture) Status() CaptureStatus {
+	status := CaptureStatus{
+		Dir:          fc.config.Dir,
+		TX:           fc.config.TX,
+		TXContext:    fc.config.TXContext,
+		MaxFileSize:  fc.config.MaxFileSize,
+		MaxTotalSize: fc.config.MaxTotalSize,
+		MaxFiles:     fc.config.MaxFiles,
+		Compress:     fc.config.Compress,
+		DiskUsage:    fc.DiskUsage(),
+		Interfaces:   make(map[string]InterfaceCapture),
+		Captured:     fc.captured.Load(),
+		Written:      fc.written.Load(),
+		Dropped:      fc.dropped.Load(),
+		WriteErrors:  fc.writeErrors.Load(),
+		Rotations:    fc.rotations.Load(),
+		FilesDeleted: fc.filesDeleted.Load(),
+		QueueDepth:   len(fc.queue),
+	}
+	if fc.config.MaxAge > 0 {
+		status.MaxAge = fc.config.MaxAge.String()
+	}
+
+	fc.mu.RLock()
+	defer fc.mu.RUnlock()
+	for ifName, since := range fc.active {
+		capture := InterfaceCapture{Since: since}
+		if name, ok := fc.fileNames.Load(ifName); ok {
+			capture.File = name.(string)
+		}
+		status.Interfaces[ifName] = capture
+	}
+	return status
+}
+
+// DiskUsage returns the bytes taken by the capture files of the directory
+func (fc *FrameCapture) DiskUsage() int64 {
+	if fc == nil {
+		return 0
+	}
+	entries, err := os.ReadDir(fc.config.Dir)
+	if err != nil {
+		return 0
+	}
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() || !isCaptureFile(entry.Name()) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// Close writes the queued frames, closes the files and waits for finished files to be
+// compressed
+func (fc *FrameCapture) Close() {
+	if fc == nil {
+		return
+	}
+	fc.stopOnce.Do(func() {
+		close(fc.stopChan)
+		fc.wg.Wait()
+		fc.retentionPending.Wait()
+	})
+}
+
+// writeLoop writes queued frames, flushing whenever the queue runs empty so bursts are
+// written in batches and quiet periods leave nothing buffered
+func (fc *FrameCapture) writeLoop() {
+	defer fc.wg.Done()
+
+	line := make([]byte, 0, 128)
+	for {
+		select {
+		case record := <-fc.queue:
+			line = fc.write(record, line)
+			if len(fc.queue) == 0 {
+				fc.flush()
+			}
+		case <-fc.stopChan:
+			for {
+				select {
+				case record := <-fc.queue:
+					line = fc.write(record, line)
+				default:
+					for ifName := range fc.files {
+						fc.retire(fc.closeFile(ifName))
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// write appends one frame to the file of its interface, rotating the file first when
+// the frame would take it past the size limit or the file is older than the age limit.
+// line is reused between calls.
+func (fc *FrameCapture) write(record captureRecord, line []byte) []byte {
+	ifName := record.msg.Interface
+	if record.closeFile {
+		fc.retire(fc.closeFile(ifName))
+		return line
+	}
+
+	line = appendCandumpLine(line[:0], record.msg)
+	if fc.config.TX {
+		if record.tx {
+			line = append(line, " T"...)
+		} else {
+			line = append(line, " R"...)
+		}
+	}
+	line = append(line, '\n')
+
+	file := fc.files[ifName]
+	var rotated string
+	if file != nil && fc.config.rotation().due(file.size, file.openedAt, len(line)) {
+		rotated = fc.closeFile(ifName)
+		fc.rotations.Add(1)
+		file = nil
+	}
+	if file == nil {
+		var err error
+		file, err = fc.openFile(ifName, record.msg.Timestamp)
+		// The new file is registered by now, so cleaning up never deletes it
+		fc.retire(rotated)
+		if err != nil {
+			fc.writeErrors.Add(1)
+			fc.logger.Warnf("⚠️ Warning: failed to open capture file for %s: %v", ifName, err)
+			return line
+		}
+	}
+
+	if _, err := file.writer.Write(line); err != nil {
+		fc.writeErrors.Add(1)
+		fc.logger.Warnf("⚠️ Warning: failed to write capture file %s: %v", file.path, err)
+		file.writer.Reset(file.file)
+		return line
+	}
+	file.size += int64(len(line))
+	file.lines++
+	fc.written.Add(1)
+	if record.tx && fc.config.TXContext {
+		fc.writeSidecar(file, record, line)
+	}
+	return line
+}
+
+// writeSidecar records what a sent frame was sent for in the sidecar of the file its
+// line was just written to, opening the sidecar first if needed. The frame is matched to
+// its line by number, so the lines of a file and its sidecar never get out of step.
+func (fc *FrameCapture) writeSidecar(file *captureFile, record captureRecord, line []byte) {
+	if file.sidecar == nil {
+		path := captureSidecarPath(file.path)
+		sidecar, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+		if err != nil {
+			fc.writeErrors.Add(1)
+			fc.logger.Warnf("⚠️ Warning: failed to open capture sidecar %s: %v", path, err)
+			return
+		}
+		file.sidecar, file.sidecarWriter = sidecar, bufio.NewWriter(sidecar)
+	}
+
+	entry, err := json.Marshal(captureSidecarLine{
+		Line:          file.lines,
+		Frame:         strings.TrimSuffix(string(line), " T\n"),
+		captureOrigin: record.origin,
+	})
+	if err == nil {
+		_, err = file.sidecarWriter.Write(append(entry, '\n'))
+	}
+	if err != nil {
+		fc.writeErrors.Add(1)
+		fc.logger.Warnf("⚠️ Warning: failed to write capture sidecar of %s: %v", file.path, err)
+		file.sidecarWriter.Reset(file.sidecar)
+	}
+}
+
+// openFile creates the next capture file of an interface, named after the time of its
+// first frame
+func (fc *FrameCapture) openFile(ifName string, first time.Time) (*captureFile, error) {
+	if first.IsZero() {
+		first = time.Now()
+	}
+	path := unusedFileName(first, func(at time.Time) string {
+		return filepath.Join(fc.config.Dir, captureFilePrefix+ifName+"-"+at.Format("2006-01-02_150405.000")+captureFileSuffix)
+	})
+	name := filepath.Base(path)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, err
+	}
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+
+	captured := &captureFile{
+		path:     path,
+		file:     file,
+		writer:   bufio.NewWriterSize(file, 64<<10),
+		size:     size,
+		openedAt: time.Now(),
+	}
+	fc.files[ifName] = captured
+	fc.fileNames.Store(ifName, name)
+	return captured, nil
+}
+
+// closeFile flushes and closes the current file of an interface and returns its path,
+// empty when none was open
+func (fc *FrameCapture) closeFile(ifName string) string {
+	file := fc.files[ifName]
+	if file == nil {
+		return ""
+	}
+	delete(fc.files, ifName)
+	fc.fileNames.Delete(ifName)
+
+	if err := file.writer.Flush(); err != nil {
+		fc.writeErrors.Add(1)
+		fc.logger.Warnf("⚠️ Warning: failed to flush capture file %s: %v", file.path, err)
+	}
+	if err := file.file.Close(); err != nil {
+		fc.logger.Warnf("⚠️ Warning: failed to close capture file %s: %v", file.path, err)
+	}
+	if file.sidecar != nil {
+		if err := file.sidecarWriter.Flush(); err != nil {
+			fc.writeErrors.Add(1)
+			fc.logger.Warnf("⚠️ Warning: failed to flush capture sidecar of %s: %v", file.path, err)
+		}
+		file.sidecar.Close()
+	}
+	return file.path
+}
+
+// retire compresses a finished file and its sidecar, when configured, and deletes old
+// files beyond the limits. It runs in the background, so compressing never holds up the writer; the runs
+// are serialized, so a file is never deleted while it is being compressed. The files
+// open at the time of the call are kept.
+func (fc *FrameCapture) retire(finished string) {
+	open := make(map[string]bool, len(fc.files))
+	for _, file := range fc.files {
+		open[file.path] = true
+	}
+
+	fc.retentionPending.Add(1)
+	go func() {
+		defer fc.retentionPending.Done()
+		fc.retention.Lock()
+		defer fc.retention.Unlock()
+
+		if finished != "" && fc.config.Compress {
+			for _, path := range []string{finished, captureSidecarPath(finished)} {
+				if err := compressFile(path); err != nil && !os.IsNotExist(err) {
+					fc.writeErrors.Add(1)
+					fc.logger.Warnf("⚠️ Warning: failed to compress capture file %s: %v", path, err)
+				}
+			}
+		}
+		fc.pruneFiles(open)
+	}()
+}
+
+// flush writes the buffered frames of every open file. After a failed write the buffer
+// is reset, as bufio keeps failing once an error occurred; its frames are lost.
+func (fc *FrameCapture) flush() {
+	for _, file := range fc.files {
+		if err := file.writer.Flush(); err != nil {
+			fc.writeErrors.Add(1)
+			fc.logger.Warnf("⚠️ Warning: failed to flush capture file %s: %v", file.path, err)
+			file.writer.Reset(file.file)
+		}
+		if file.sidecar == nil {
+			continue
+		}
+		if err := file.sidecarWriter.Flush(); err != nil {
+			fc.writeErrors.Add(1)
+			fc.logger.Warnf("⚠️ Warning: failed to flush capture sidecar of %s: %v", file.path, err)
+			file.sidecarWriter.Reset(file.sidecar)
+		}
+	}
+}
+
+// pruneFiles deletes the oldest capture files of the directory while together they take
+// more than the total size limit, or there are more finished files than the file limit.
+// Files still being written are kept and counted at the size they rotate at, so the
+// directory stays within the limit as they grow. A sidecar goes with its capture file:
+// it is counted with it and deleted with it.
+func (fc *FrameCapture) pruneFiles(open map[string]bool) {
+	entries, err := os.ReadDir(fc.config.Dir)
+	if err != nil {
+		fc.logger.Warnf("⚠️ Warning: failed to list capture directory %s: %v", fc.config.Dir, err)
+		return
+	}
+	openStems := make(map[string]bool, len(open))
+	for path := range open {
+		openStems[captureStem(filepath.Base(path))] = true
+	}
+
+	type oldFile struct {
+		paths   []string // The capture file and its sidecar
+		sizes   []int64
+		modTime time.Time
+	}
+	var total int64
+	files := make(map[string]*oldFile)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !isCaptureFile(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		stem := captureStem(name)
+		if openStems[stem] {
+			if isCaptureSidecar(name) {
+				total += info.Size()
+			} else {
+				total += max(info.Size(), fc.config.MaxFileSize)
+			}
+			continue
+		}
+		total += info.Size()
+		old := files[stem]
+		if old == nil {
+			old = &oldFile{}
+			files[stem] = old
+		}
+		old.paths = append(old.paths, filepath.Join(fc.config.Dir, name))
+		old.sizes = append(old.sizes, info.Size())
+		if info.ModTime().After(old.modTime) {
+			old.modTime = info.ModTime()
+		}
+	}
+
+	candidates := make([]*oldFile, 0, len(files))
+	for _, old := range files {
+		candidates = append(candidates, old)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].modTime.Before(candidates[j].modTime) })
+	for i, old := range candidates {
+		tooMany := fc.config.MaxFiles > 0 && len(candidates)-i > fc.config.MaxFiles
+		if total <= fc.config.MaxTotalSize && !tooMany {
+			return
+		}
+		for j, path := range old.paths {
+			if err := os.Remove(path); err != nil {
+				fc.logger.Warnf("⚠️ Warning: failed to delete old capture file %s: %v", path, err)
+				continue
+			}
+			total -= old.sizes[j]
+			if isCaptureSidecar(filepath.Base(path)) {
+				continue
+			}
+			fc.filesDeleted.Add(1)
+			fc.logger.Infof("🧹 Deleted old capture file %s", filepath.Base(path))
+		}
+	}
+}
+
+// isCaptureFile reports whether a file name is that of a capture file or a sidecar,
+// compressed or not
+func isCaptureFile(name string) bool {
+	name = strings.TrimSuffix(name, gzipSuffix)
+	return strings.HasPrefix(name, captureFilePrefix) &&
+		(strings.HasSuffix(name, captureFileSuffix) || strings.HasSuffix(name, captureSidecarSuffix))
+}
+
+// isCaptureSidecar reports whether a capture file name is that of a sidecar
+func isCaptureSidecar(name string) bool {
+	return strings.HasSuffix(strings.TrimSuffix(name, gzipSuffix), captureSidecarSuffix)
+}
+
+// captureStem returns the name a capture file and its sidecar share,
+// candump-can0-2026-10-16_091203.418
+func captureStem(name string) string {
+	name = strings.TrimSuffix(name, gzipSuffix)
+	if stem, ok := strings.CutSuffix(name, captureSidecarSuffix); ok {
+		return stem
+	}
+	return strings.TrimSuffix(name, captureFileSuffix)
+}
+
+// captureSidecarPath returns the path of the sidecar of a capture file
+func captureSidecarPath(path string) string {
+	return strings.TrimSuffix(path, captureFileSuffix) + captureSidecarSuffix
+}
+
+// appendCandumpLine appends a frame as candump -l writes it: "(seconds.micros) interface
+// id#data". Standard IDs have 3 hex digits, extended and error frame IDs 8; remote
+// frames end in R and the requested length. Frames are classic CAN: the FD notation
+// (id##flags data) is not needed, as the bridge does not set interfaces up for FD.
+func appendCandumpLine(line []byte, msg CanMessageLog) []byte {
+	timestamp := msg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	line = append(line, '(')
+	line = strconv.AppendInt(line, timestamp.Unix(), 10)
+	line = append(line, '.')
+	micros := strconv.FormatInt(int64(timestamp.Nanosecond()/1000), 10)
+	line = append(line, "000000"[len(micros):]...)
+	line = append(line, micros...)
+	line = append(line, ") "...)
+	line = append(line, msg.Interface...)
+	line = append(line, ' ')
+
+	switch {
+	case msg.ID&unix.CAN_ERR_FLAG != 0:
+		line = fmt.Appendf(line, "%08X", msg.ID&(unix.CAN_ERR_MASK|unix.CAN_ERR_FLAG))
+	case msg.ID&unix.CAN_EFF_FLAG != 0:
+		line = fmt.Appendf(line, "%08X", msg.ID&unix.CAN_EFF_MASK)
+	default:
+		line = fmt.Appendf(line, "%03X", msg.ID&unix.CAN_SFF_MASK)
+	}
+	line = append(line, '#')
+
+	if msg.RTR {
+		line = append(line, 'R')
+		if msg.Length > 0 && msg.Length <= 8 {
+			line = append(line, '0'+msg.Length)
+		}
+		return line
+	}
+	return append(line, strings.ToUpper(hex.EncodeToString(msg.Data))...)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Payload encodings of send requests
+const (
+	DataEncodingAuto   = "auto"   // An array as bytes, a string of hex digits as hex, another string as base64
+	DataEncodingHex    = "hex"    // "0011AA" or "00 11 AA"
+	DataEncodingBase64 = "base64" // "ABGq"
+	DataEncodingBytes  = "bytes"  // [0, 17, 170]
+)
+
+var dataEncodings = []string{DataEncodingAuto, DataEncodingHex, DataEncodingBase64, DataEncodingBytes}
+
+// FrameData is the payload of a send request, given as a hex string, a base64 string or
+// an array of byte values. It is rendered as base64, as any []byte.
+type FrameData []byte
+
+// frameDataError is an invalid payload, reported as an error of its field
+type frameDataError struct {
+	field   string
+	message string
+}
+
+func (e *frameDataError) Error() string {
+	return e.field + " " + e.message
+}
+
+// UnmarshalJSON decodes a payload whose encoding the request does not name
+func (d *FrameData) UnmarshalJSON(value []byte) error {
+	data, err := decodeFrameData(value, DataEncodingAuto)
+	if err != nil {
+		return err
+	}
+	*d = data
+	return nil
+}
+
+// decodeFrameData decodes the JSON value of a data field in an encoding
+func decodeFrameData(value []byte, encoding string) (FrameData, error) {
+	value = bytes.TrimSpace(value)
+	if len(value) == 0 || string(value) == "null" {
+		return nil, nil
+	}
+
+	if value[0] == '[' {
+		if encoding != DataEncodingAuto && encoding != DataEncodingBytes {
+			return nil, &frameDataError{field: "data", message: fmt.Sprintf("must be a %s string, got an array", encoding)}
+		}
+		var numbers []json.Number
+		decoder := json.NewDecoder(bytes.NewReader(value))
+		decoder.UseNumber()
+		if err := decoder.Decode(&numbers); err != nil {
+			return nil, &frameDataError{field: "data", message: "must be an array of byte values from 0 to 255"}
+		}
+		data := make(FrameData, len(numbers))
+		for i, number := range numbers {
+			b, err := strconv.ParseUint(number.String(), 10, 8)
+			if err != nil {
+				return nil, &frameDataError{field: fmt.Sprintf("data[%d]", i), message: fmt.Sprintf("must be a byte value from 0 to 255, got %s", number)}
+			}
+			data[i] = byte(b)
+		}
+		return data, nil
+	}
+
+	var text string
+	if err := json.Unmarshal(value, &text); err != nil {
+		return nil, &frameDataError{field: "data", message: "must be a hex string, a base64 string or an array of byte values"}
+	}
+	if encoding == DataEncodingBytes {
+		return nil, &frameDataError{field: "data", message: "must be an array of byte values, got a string"}
+	}
+	if encoding == DataEncodingHex || (encoding == DataEncodingAuto && isHexText(text)) {
+		data, err := parseHexBytes(text)
+		if err != nil {
+			return nil, &frameDataError{field: "data", message: fmt.Sprintf("must be hex bytes such as \"02 10 01\": %v", err)}
+		}
+		return data, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		if data, err = base64.RawStdEncoding.DecodeString(text); err != nil {
+			message := "must be base64"
+			if encoding == DataEncodingAuto {
+				message = "must be hex bytes such as \"02 10 01\" or base64"
+			}
+			return nil, &frameDataError{field: "data", message: fmt.Sprintf("%s: %v", message, err)}
+		}
+	}
+	return data, nil
+}
+
+// isHexText reports whether a string reads as hex bytes: hex digit pairs, optionally
+// separated by spaces. Such strings are decoded as hex unless the request asks for
+// base64, so base64 payloads made of hex digits only, such as "AAAA", need "encoding".
+func isHexText(text string) bool {
+	digits := strings.ReplaceAll(text, " ", "")
+	if digits == "" || len(digits)%2 != 0 {
+		return false
+	}
+	for _, r := range digits {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// bodyDecoder is a request body with fields decoded again from the raw JSON, such as a
+// payload in the encoding another field names
+type bodyDecoder interface {
+	decodeBody(body []byte) []FieldError
+}
+
+// decodeDataEncoding decodes the data field of a body again in the encoding the request
+// names. Without one, data keeps the encoding it was detected in.
+func decodeDataEncoding(body []byte, encoding string, data *FrameData) []FieldError {
+	if encoding == "" || encoding == DataEncodingAuto || !slices.Contains(dataEncodings, encoding) {
+		return nil // An unknown encoding is reported by the binding
+	}
+	var raw struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil // Already reported by the binding
+	}
+	decoded, err := decodeFrameData(raw.Data, encoding)
+	if err != nil {
+		return []FieldError{frameDataFieldError(err)}
+	}
+	*data = decoded
+	return nil
+}
+
+// frameDataFieldError reports a payload error as a field error
+func frameDataFieldError(err error) FieldError {
+	if dataErr, ok := err.(*frameDataError); ok {
+		return FieldError{Field: dataErr.field, Message: dataErr.message}
+	}
+	return FieldError{Field: "data", Message: err.Error()}
+}
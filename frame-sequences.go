@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Frame sequence phases
+const (
+	SequenceStartup  = "startup"
+	SequenceShutdown = "shutdown"
+)
+
+// SequenceReportTTL is how long the report of a sequence stays in the system status
+const SequenceReportTTL = 5 * time.Minute
+
+// SequenceStep is one frame of the startup or shutdown sequence: a named message or an
+// inline frame, and the time to wait after it
+type SequenceStep struct {
+	Message   string  `yaml:"message" json:"message,omitempty"`     // Named message, instead of id and data
+	Interface string  `yaml:"interface" json:"interface,omitempty"` // Empty uses that of the named message, else every interface
+	ID        *uint32 `yaml:"id" json:"id,omitempty"`
+	Data      string  `yaml:"data" json:"data,omitempty"` // Hex bytes, e.g. "02 10 01"
+	Extended  bool    `yaml:"extended" json:"extended,omitempty"`
+	Delay     string  `yaml:"delay" json:"delay,omitempty"` // Wait after the frame, e.g. 100ms
+
+	frame *NamedMessage
+	delay time.Duration
+}
+
+// normalize validates the step and resolves its frame. name identifies the step in
+// errors, e.g. "step 1".
+func (s *SequenceStep) normalize(name string, messages map[string]*NamedMessage) error {
+	switch {
+	case s.Message != "" && (s.ID != nil || s.Data != "" || s.Extended):
+		return fmt.Errorf("%s: message cannot be combined with id, data or extended", name)
+	case s.Message != "":
+		frame, ok := messages[s.Message]
+		if !ok {
+			return fmt.Errorf("%s: unknown message %q", name, s.Message)
+		}
+		s.frame = frame
+	case s.ID == nil:
+		return fmt.Errorf("%s: message or id is required", name)
+	default:
+		s.frame = &NamedMessage{Name: name, ID: *s.ID, Data: s.Data, Extended: s.Extended}
+		if err := s.frame.normalize(); err != nil {
+			return err
+		}
+	}
+
+	if s.Delay != "" {
+		delay, err := time.ParseDuration(s.Delay)
+		if err != nil || delay < 0 {
+			return fmt.Errorf("%s: invalid delay %q", name, s.Delay)
+		}
+		s.delay = delay
+	}
+	return nil
+}
+
+// interfaces returns the interfaces the step sends on, out of the configured ones
+func (s *SequenceStep) interfaces(configured []string) []string {
+	switch {
+	case s.Interface != "":
+		return []string{s.Interface}
+	case s.frame.Interface != "":
+		return []string{s.frame.Interface}
+	}
+	return configured
+}
+
+// SequenceStepResult is the outcome of one frame of a sequence on one interface
+type SequenceStepResult struct {
+	Step      int       `json:"step"` // Index in the sequence
+	Interface string    `json:"interface"`
+	ID        uint32    `json:"id"`
+	Sent      bool      `json:"sent"`
+	DryRun    bool      `json:"dryRun,omitempty"`
+	Skipped   bool      `json:"skipped,omitempty"` // Interface failed setup or is not initialized
+	Error     string    `json:"error,omitempty"`
+	ErrorCode ErrorCode `json:"errorCode,omitempty"`
+	SentAt    time.Time `json:"sentAt,omitempty"`
+}
+
+// SequenceReport is the outcome of the startup or shutdown sequence
+type SequenceReport struct {
+	Phase       string               `json:"phase"` // startup or shutdown
+	StartedAt   time.Time            `json:"startedAt"`
+	CompletedAt time.Time            `json:"completedAt"`
+	Sent        int                  `json:"sent"`
+	Failed      int                  `json:"failed"`
+	Skipped     int                  `json:"skipped"`
+	Aborted     bool                 `json:"aborted,omitempty"` // Shutdown timed out before the last step
+	Steps       []SequenceStepResult `json:"steps"`
+}
+
+// SequenceRunner sends the startup and shutdown sequences and keeps their last reports
+type SequenceRunner struct {
+	sender  *MessageSender
+	reports map[string]SequenceReport // Phase to last report
+	mu      sync.Mutex
+	logger  Logger
+}
+
+// NewSequenceRunner creates a runner sending through sender
+func NewSequenceRunner(sender *MessageSender, logger Logger) *SequenceRunner {
+	return &SequenceRunner{
+		sender:  sender,
+		reports: make(map[string]SequenceReport),
+		logger:  logger,
+	}
+}
+
+// Run sends the steps of a sequence in order. Frames for interfaces not ready are skipped,
+// and failed sends are logged and counted; neither stops the sequence. When ctx is done
+// the current delay ends and the remaining steps are dropped.
+func (r *SequenceRunner) Run(ctx context.Context, phase string, steps []SequenceStep, configured, ready []string) SequenceReport {
+	report := SequenceReport{Phase: phase, StartedAt: time.Now(), Steps: []SequenceStepResult{}}
+	isReady := make(map[string]bool, len(ready))
+	for _, ifName := range ready {
+		isReady[ifName] = true
+	}
+
+	r.logger.Infof("🎬 Running %s sequence: %d steps on %v", phase, len(steps), ready)
+	for i := range steps {
+		if ctx.Err() != nil {
+			report.Aborted = true
+			r.logger.Warnf("⚠️ %s sequence aborted before step %d: %v", phase, i, ctx.Err())
+			break
+		}
+
+		step := &steps[i]
+		for _, ifName := range step.interfaces(configured) {
+			result := SequenceStepResult{Step: i, Interface: ifName, ID: step.frame.ID}
+			if !isReady[ifName] {
+				result.Skipped = true
+				report.Skipped++
+				r.logger.Infof("⏭️ %s sequence step %d skipped on %s: interface not set up", phase, i, ifName)
+			} else if r.send(phase, step, &result) {
+				report.Sent++
+			} else {
+				report.Failed++
+			}
+			report.Steps = append(report.Steps, result)
+		}
+
+		if step.delay > 0 {
+			select {
+			case <-time.After(step.delay):
+			case <-ctx.Done():
+			}
+		}
+	}
+	report.CompletedAt = time.Now()
+
+	r.logger.Infof("🎬 %s sequence finished in %v: %d sent, %d failed, %d skipped",
+		phase, report.CompletedAt.Sub(report.StartedAt).Round(time.Millisecond), report.Sent, report.Failed, report.Skipped)
+
+	r.mu.Lock()
+	r.reports[phase] = report
+	r.mu.Unlock()
+	return report
+}
+
+// send sends the frame of a step on the interface of result and records the outcome
+func (r *SequenceRunner) send(phase string, step *SequenceStep, result *SequenceStepResult) bool {
+	msg, err := step.frame.message(nil)
+	if err == nil {
+		msg.Interface = result.Interface
+		msg.acceptedAt = time.Now()
+		msg.client = "sequence:" + phase
+		msg.jobID = "sequence:" + phase
+
+		var sent *SendResult
+		if sent, err = r.sender.SendCanMessage(msg); err == nil {
+			result.Sent, result.DryRun, result.SentAt = true, sent.DryRun, sent.SentAt
+			return true
+		}
+	}
+
+	result.Error, result.ErrorCode = err.Error(), errorCode(err)
+	r.logger.Errorf("❌ %s sequence step %d failed on %s: %v", phase, result.Step, result.Interface, err)
+	return false
+}
+
+// RecentReports returns the reports of sequences completed within SequenceReportTTL,
+// startup first
+func (r *SequenceRunner) RecentReports() []SequenceReport {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var reports []SequenceReport
+	for _, phase := range []string{SequenceStartup, SequenceShutdown} {
+		if report, ok := r.reports[phase]; ok && time.Since(report.CompletedAt) < SequenceReportTTL {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
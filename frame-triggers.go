@@ -0,0 +1,267 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Frame trigger limits and defaults
+const (
+	MaxFrameTriggers          = 100
+	DefaultTriggerMinInterval = time.Second
+)
+
+// Frame trigger errors
+var (
+	ErrTriggerNotFound = errors.New("trigger not found")
+	ErrTriggerExists   = errors.New("trigger already exists")
+)
+
+// FrameTrigger watches the payload of a CAN ID and fires when it changes. Without Byte
+// the whole payload is watched; with it only the bits of Mask in that byte.
+type FrameTrigger struct {
+	Name        string  `json:"name" binding:"required,max=64"`
+	Interface   string  `json:"interface,omitempty"` // Empty watches every interface separately
+	ID          *uint32 `json:"id" binding:"required,max=536870911"`
+	Byte        *int    `json:"byte,omitempty" binding:"omitempty,min=0,max=63"`
+	Mask        *uint8  `json:"mask,omitempty"`        // Bits of Byte to watch (default: 0xFF)
+	MinInterval string  `json:"minInterval,omitempty"` // Least time between notifications, e.g. 1s (default: 1s)
+	Severity    string  `json:"severity,omitempty"`    // Notification severity (default: info)
+
+	minInterval time.Duration
+}
+
+// normalize validates the trigger and fills defaults
+func (t *FrameTrigger) normalize() error {
+	if t.ID == nil {
+		return fmt.Errorf("trigger %s: id is required", t.Name)
+	}
+	if t.Mask != nil && t.Byte == nil {
+		return fmt.Errorf("trigger %s: mask requires byte", t.Name)
+	}
+	if t.Mask != nil && *t.Mask == 0 {
+		return fmt.Errorf("trigger %s: mask must select at least one bit", t.Name)
+	}
+
+	t.minInterval = DefaultTriggerMinInterval
+	if t.MinInterval != "" {
+		interval, err := time.ParseDuration(t.MinInterval)
+		if err != nil || interval < 0 {
+			return fmt.Errorf("trigger %s: invalid minInterval %q", t.Name, t.MinInterval)
+		}
+		t.minInterval = interval
+	}
+
+	if t.Severity == "" {
+		t.Severity = SeverityInfo
+	}
+	if _, ok := severityRank[t.Severity]; !ok {
+		return fmt.Errorf("trigger %s: invalid severity %q. Valid options: info, warning, critical", t.Name, t.Severity)
+	}
+	return nil
+}
+
+// value extracts the watched part of a frame. It reports false for frames that do not
+// carry it: remote frames, and frames too short for the watched byte.
+func (t *FrameTrigger) value(msg CanMessageLog) ([]byte, bool) {
+	if msg.RTR {
+		return nil, false
+	}
+	if t.Byte == nil {
+		return append([]byte(nil), msg.Data...), true
+	}
+	if *t.Byte >= len(msg.Data) {
+		return nil, false
+	}
+	mask := byte(0xFF)
+	if t.Mask != nil {
+		mask = *t.Mask
+	}
+	return []byte{msg.Data[*t.Byte] & mask}, true
+}
+
+// describe names what the trigger watches, e.g. "0x123 byte 2 mask 0x0F"
+func (t *FrameTrigger) describe() string {
+	switch {
+	case t.Byte == nil:
+		return fmt.Sprintf("0x%X payload", *t.ID)
+	case t.Mask == nil:
+		return fmt.Sprintf("0x%X byte %d", *t.ID, *t.Byte)
+	}
+	return fmt.Sprintf("0x%X byte %d mask 0x%02X", *t.ID, *t.Byte, *t.Mask)
+}
+
+// FrameTriggerChange is a change a trigger saw
+type FrameTriggerChange struct {
+	Interface string    `json:"interface"`
+	OldValue  string    `json:"oldValue"` // Hex bytes of the watched value
+	NewValue  string    `json:"newValue"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// FrameTriggerStatus is a trigger with its counters
+type FrameTriggerStatus struct {
+	FrameTrigger
+	CreatedAt  time.Time           `json:"createdAt"`
+	Changes    uint64              `json:"changes"`  // Changes seen
+	Notified   uint64              `json:"notified"` // Changes notified; the rest fell within minInterval
+	LastChange *FrameTriggerChange `json:"lastChange,omitempty"`
+}
+
+// frameTriggerState is a registered trigger with its last values per interface
+type frameTriggerState struct {
+	trigger      FrameTrigger
+	createdAt    time.Time
+	values       map[string][]byte    // Interface to last watched value
+	lastNotified map[string]time.Time // Interface to last notification
+	changes      uint64
+	notified     uint64
+	lastChange   *FrameTriggerChange
+}
+
+// frameTriggerFiring is a change to be notified
+type frameTriggerFiring struct {
+	trigger FrameTrigger
+	change  FrameTriggerChange
+}
+
+// FrameTriggerEngine holds the triggers registered through the API and checks received
+// frames against them. The first frame of an ID on an interface sets the baseline.
+type FrameTriggerEngine struct {
+	mu       sync.Mutex
+	triggers map[string]*frameTriggerState
+	byID     map[uint32][]*frameTriggerState
+}
+
+// NewFrameTriggerEngine creates an engine without triggers
+func NewFrameTriggerEngine() *FrameTriggerEngine {
+	return &FrameTriggerEngine{
+		triggers: make(map[string]*frameTriggerState),
+		byID:     make(map[uint32][]*frameTriggerState),
+	}
+}
+
+// Add registers a trigger. It must be normalized.
+func (e *FrameTriggerEngine) Add(trigger FrameTrigger) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if _, exists := e.triggers[trigger.Name]; exists {
+		return fmt.Errorf("trigger %q: %w", trigger.Name, ErrTriggerExists)
+	}
+	if len(e.triggers) >= MaxFrameTriggers {
+		return fmt.Errorf("at most %d triggers can be registered", MaxFrameTriggers)
+	}
+
+	state := &frameTriggerState{
+		trigger:      trigger,
+		createdAt:    time.Now(),
+		values:       make(map[string][]byte),
+		lastNotified: make(map[string]time.Time),
+	}
+	e.triggers[trigger.Name] = state
+	e.byID[*trigger.ID] = append(e.byID[*trigger.ID], state)
+	return nil
+}
+
+// Remove unregisters a trigger
+func (e *FrameTriggerEngine) Remove(name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	state, exists := e.triggers[name]
+	if !exists {
+		return fmt.Errorf("trigger %q: %w", name, ErrTriggerNotFound)
+	}
+	delete(e.triggers, name)
+
+	id := *state.trigger.ID
+	remaining := e.byID[id][:0]
+	for _, other := range e.byID[id] {
+		if other != state {
+			remaining = append(remaining, other)
+		}
+	}
+	if len(remaining) == 0 {
+		delete(e.byID, id)
+	} else {
+		e.byID[id] = remaining
+	}
+	return nil
+}
+
+// Observe checks a received frame and returns the changes to notify
+func (e *FrameTriggerEngine) Observe(msg CanMessageLog) []frameTriggerFiring {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var firings []frameTriggerFiring
+	for _, state := range e.byID[msg.ID] {
+		trigger := &state.trigger
+		if trigger.Interface != "" && trigger.Interface != msg.Interface {
+			continue
+		}
+		value, ok := trigger.value(msg)
+		if !ok {
+			continue
+		}
+
+		old, seen := state.values[msg.Interface]
+		state.values[msg.Interface] = value
+		if !seen || string(old) == string(value) {
+			continue
+		}
+
+		change := FrameTriggerChange{
+			Interface: msg.Interface,
+			OldValue:  formatTriggerValue(old),
+			NewValue:  formatTriggerValue(value),
+			Timestamp: msg.Timestamp,
+		}
+		state.changes++
+		state.lastChange = &change
+
+		if last := state.lastNotified[msg.Interface]; !last.IsZero() && msg.Timestamp.Sub(last) < trigger.minInterval {
+			continue
+		}
+		state.lastNotified[msg.Interface] = msg.Timestamp
+		state.notified++
+		firings = append(firings, frameTriggerFiring{trigger: *trigger, change: change})
+	}
+	return firings
+}
+
+// GetStatuses returns every trigger with its counters, by name
+func (e *FrameTriggerEngine) GetStatuses() []FrameTriggerStatus {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	statuses := make([]FrameTriggerStatus, 0, len(e.triggers))
+	for _, state := range e.triggers {
+		status := FrameTriggerStatus{
+			FrameTrigger: state.trigger,
+			CreatedAt:    state.createdAt,
+			Changes:      state.changes,
+			Notified:     state.notified,
+		}
+		if state.lastChange != nil {
+			change := *state.lastChange
+			status.LastChange = &change
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// formatTriggerValue formats watched bytes as hex, e.g. "01 A2", or "empty"
+func formatTriggerValue(value []byte) string {
+	if len(value) == 0 {
+		return "empty"
+	}
+	return strings.Join(bytesToHexArray(value), " ")
+}
@@ -0,0 +1,105 @@
+package main
+
+import "time"
+
+// Interface health states tracked by the watchdog
+const (
+	healthStateHealthy     = "healthy"     // Checks passing
+	healthStateDegraded    = "degraded"    // Some consecutive failures, below the failure threshold
+	healthStateFailed      = "failed"      // Failure threshold reached, recovery is due
+	healthStateRecovering  = "recovering"  // Reinitialized, waiting for enough passing checks
+	healthStateQuarantined = "quarantined" // Recovery gave up, waiting for an operator
+)
+
+// HealthStateStatus is a snapshot of an interface's health state
+type HealthStateStatus struct {
+	State                string    `json:"state"`
+	Since                time.Time `json:"since"`
+	TimeInState          string    `json:"timeInState"`
+	ConsecutiveFailures  int       `json:"consecutiveFailures"`
+	ConsecutiveSuccesses int       `json:"consecutiveSuccesses"`
+}
+
+// healthStateMachine applies hysteresis to health check results so a single failed or
+// passing check does not flip an interface between healthy and failed:
+//   - a failed check moves healthy to degraded, or straight to failed when the failure
+//     threshold is 1
+//   - failureThreshold consecutive failures move healthy, degraded or recovering to failed
+//   - successThreshold consecutive passes move any other state back to healthy
+//   - recovery moves failed to recovering, and back to failed or to quarantined when it
+//     fails or gives up; an operator retry moves quarantined back to failed
+type healthStateMachine struct {
+	state                string
+	since                time.Time
+	consecutiveFailures  int
+	consecutiveSuccesses int
+	now                  func() time.Time
+}
+
+// newHealthStateMachine creates a state machine starting healthy
+func newHealthStateMachine(now func() time.Time) *healthStateMachine {
+	return &healthStateMachine{
+		state: healthStateHealthy,
+		since: now(),
+		now:   now,
+	}
+}
+
+// observe records a health check result and returns the previous state and whether
+// the state changed
+func (m *healthStateMachine) observe(passed bool, failureThreshold, successThreshold int) (string, bool) {
+	oldState := m.state
+
+	if passed {
+		m.consecutiveFailures = 0
+		m.consecutiveSuccesses++
+		if m.state != healthStateHealthy && m.consecutiveSuccesses >= successThreshold {
+			m.enter(healthStateHealthy)
+		}
+		return oldState, m.state != oldState
+	}
+
+	m.consecutiveSuccesses = 0
+	m.consecutiveFailures++
+	switch m.state {
+	case healthStateHealthy, healthStateDegraded, healthStateRecovering:
+		if m.consecutiveFailures >= failureThreshold {
+			m.enter(healthStateFailed)
+		} else if m.state == healthStateHealthy {
+			m.enter(healthStateDegraded)
+		}
+	}
+	return oldState, m.state != oldState
+}
+
+// transition moves to a state driven by recovery rather than check results
+// and reports whether the state changed
+func (m *healthStateMachine) transition(state string) bool {
+	if m.state == state {
+		return false
+	}
+	m.enter(state)
+	return true
+}
+
+// enter switches state, restarting the time-in-state clock. Counters are kept when
+// escalating from degraded so they keep counting towards the failure threshold.
+func (m *healthStateMachine) enter(state string) {
+	m.state = state
+	m.since = m.now()
+	if state != healthStateDegraded && state != healthStateFailed {
+		m.consecutiveFailures = 0
+		m.consecutiveSuccesses = 0
+	}
+}
+
+// status returns a snapshot of the state machine
+func (m *healthStateMachine) status() HealthStateStatus {
+	return HealthStateStatus{
+		State:                m.state,
+		Since:                m.since,
+		TimeInState:          m.now().Sub(m.since).Round(time.Second).String(),
+		ConsecutiveFailures:  m.consecutiveFailures,
+		ConsecutiveSuccesses: m.consecutiveSuccesses,
+	}
+}
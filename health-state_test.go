@@ -0,0 +1,131 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// steppedClock is a clock that only moves when the test advances it
+type steppedClock struct {
+	now time.Time
+}
+
+func (c *steppedClock) Now() time.Time { return c.now }
+
+func (c *steppedClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// healthStep is a health check result, or with transition set a recovery transition,
+// one second after the previous step, and the state the machine is in afterwards
+type healthStep struct {
+	passed     bool
+	transition string
+
+	wantState     string
+	wantChanged   bool
+	wantSince     int // Step that entered the state; 0 for the start
+	wantFailures  int
+	wantSuccesses int
+}
+
+// runHealthSteps feeds the steps to a state machine with the given thresholds
+func runHealthSteps(t *testing.T, failureThreshold, successThreshold int, steps []healthStep) {
+	t.Helper()
+	clock := &steppedClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	start := clock.now
+	m := newHealthStateMachine(clock.Now)
+
+	for i, step := range steps {
+		clock.advance(time.Second)
+		var changed bool
+		if step.transition != "" {
+			changed = m.transition(step.transition)
+		} else {
+			oldState, c := m.observe(step.passed, failureThreshold, successThreshold)
+			changed = c
+			if changed && oldState == step.wantState {
+				t.Errorf("step %d: changed from %s to itself", i+1, oldState)
+			}
+		}
+
+		status := m.status()
+		wantSince := start.Add(time.Duration(step.wantSince) * time.Second)
+		if status.State != step.wantState || changed != step.wantChanged || !status.Since.Equal(wantSince) {
+			t.Errorf("step %d: state %s (changed %t) since %v, want %s (changed %t) since %v",
+				i+1, status.State, changed, status.Since.Sub(start), step.wantState, step.wantChanged, wantSince.Sub(start))
+		}
+		if status.ConsecutiveFailures != step.wantFailures || status.ConsecutiveSuccesses != step.wantSuccesses {
+			t.Errorf("step %d: %d failures and %d successes, want %d and %d",
+				i+1, status.ConsecutiveFailures, status.ConsecutiveSuccesses, step.wantFailures, step.wantSuccesses)
+		}
+		if want := clock.now.Sub(wantSince).String(); status.TimeInState != want {
+			t.Errorf("step %d: time in state %s, want %s", i+1, status.TimeInState, want)
+		}
+	}
+}
+
+func TestHealthStateHysteresis(t *testing.T) {
+	runHealthSteps(t, 3, 2, []healthStep{
+		// A failure degrades, passes below the success threshold do not heal
+		{passed: false, wantState: healthStateDegraded, wantChanged: true, wantSince: 1, wantFailures: 1},
+		{passed: false, wantState: healthStateDegraded, wantSince: 1, wantFailures: 2},
+		{passed: true, wantState: healthStateDegraded, wantSince: 1, wantSuccesses: 1},
+		{passed: true, wantState: healthStateHealthy, wantChanged: true, wantSince: 4},
+
+		// Failures count on from degraded to the failure threshold
+		{passed: false, wantState: healthStateDegraded, wantChanged: true, wantSince: 5, wantFailures: 1},
+		{passed: false, wantState: healthStateDegraded, wantSince: 5, wantFailures: 2},
+		{passed: false, wantState: healthStateFailed, wantChanged: true, wantSince: 7, wantFailures: 3},
+		{passed: false, wantState: healthStateFailed, wantSince: 7, wantFailures: 4},
+
+		// Recovery starts counting afresh and heals after the success threshold
+		{transition: healthStateRecovering, wantState: healthStateRecovering, wantChanged: true, wantSince: 9},
+		{passed: false, wantState: healthStateRecovering, wantSince: 9, wantFailures: 1},
+		{passed: true, wantState: healthStateRecovering, wantSince: 9, wantSuccesses: 1},
+		{passed: true, wantState: healthStateHealthy, wantChanged: true, wantSince: 12},
+		{passed: true, wantState: healthStateHealthy, wantSince: 12, wantSuccesses: 1},
+	})
+}
+
+func TestHealthStateFailedRecovery(t *testing.T) {
+	runHealthSteps(t, 2, 2, []healthStep{
+		{passed: false, wantState: healthStateDegraded, wantChanged: true, wantSince: 1, wantFailures: 1},
+		{passed: false, wantState: healthStateFailed, wantChanged: true, wantSince: 2, wantFailures: 2},
+
+		// Recovery fails: failures in recovering reach the threshold again
+		{transition: healthStateRecovering, wantState: healthStateRecovering, wantChanged: true, wantSince: 3},
+		{passed: false, wantState: healthStateRecovering, wantSince: 3, wantFailures: 1},
+		{passed: false, wantState: healthStateFailed, wantChanged: true, wantSince: 5, wantFailures: 2},
+
+		// Recovery gives up; an operator retry moves back to failed
+		{transition: healthStateQuarantined, wantState: healthStateQuarantined, wantChanged: true, wantSince: 6},
+		{transition: healthStateQuarantined, wantState: healthStateQuarantined, wantSince: 6},
+		{passed: false, wantState: healthStateQuarantined, wantSince: 6, wantFailures: 1},
+		{transition: healthStateFailed, wantState: healthStateFailed, wantChanged: true, wantSince: 9, wantFailures: 1},
+
+		// Passing checks heal a failed interface too, once the success threshold is reached
+		{passed: true, wantState: healthStateFailed, wantSince: 9, wantSuccesses: 1},
+		{passed: true, wantState: healthStateHealthy, wantChanged: true, wantSince: 11},
+	})
+}
+
+func TestHealthStateFailureThresholdOne(t *testing.T) {
+	runHealthSteps(t, 1, 1, []healthStep{
+		{passed: false, wantState: healthStateFailed, wantChanged: true, wantSince: 1, wantFailures: 1},
+		{passed: true, wantState: healthStateHealthy, wantChanged: true, wantSince: 2},
+	})
+}
+
+func TestHealthStateTimeInState(t *testing.T) {
+	clock := &steppedClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	m := newHealthStateMachine(clock.Now)
+
+	clock.advance(90*time.Second + 400*time.Millisecond)
+	if got := m.status().TimeInState; got != "1m30s" {
+		t.Errorf("time in state %s, want 1m30s", got)
+	}
+	m.observe(false, 3, 2)
+	clock.advance(1600 * time.Millisecond)
+	if status := m.status(); status.State != healthStateDegraded || status.TimeInState != "2s" {
+		t.Errorf("%s for %s, want degraded for 2s", status.State, status.TimeInState)
+	}
+}
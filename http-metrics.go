@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// unmatchedRoute labels requests that did not match any route, so probing random
+// paths cannot create unbounded label values
+const unmatchedRoute = "unmatched"
+
+// httpRouteKey identifies a route and method
+type httpRouteKey struct {
+	route  string
+	method string
+}
+
+// httpRouteMetrics holds the counters of one route and method
+type httpRouteMetrics struct {
+	latency  LatencyHistogram
+	statuses map[int]uint64
+}
+
+// HTTPRouteStats is a snapshot of the metrics of one route and method
+type HTTPRouteStats struct {
+	Route    string
+	Method   string
+	Requests uint64
+	Latency  LatencyHistogramSnapshot
+	Statuses map[int]uint64
+}
+
+// HTTPMetrics collects request counts, latencies and status codes per API route
+type HTTPMetrics struct {
+	routes map[httpRouteKey]*httpRouteMetrics
+	mutex  sync.Mutex
+}
+
+// NewHTTPMetrics creates an empty HTTP metrics collector
+func NewHTTPMetrics() *HTTPMetrics {
+	return &HTTPMetrics{
+		routes: make(map[httpRouteKey]*httpRouteMetrics),
+	}
+}
+
+// Observe records a finished request. route is the route pattern (e.g. /api/stats/:interface/ids),
+// not the raw path, to keep the number of label values bounded.
+func (m *HTTPMetrics) Observe(route, method string, status int, latency time.Duration) {
+	if route == "" {
+		route = unmatchedRoute
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := httpRouteKey{route: route, method: method}
+	metrics, exists := m.routes[key]
+	if !exists {
+		metrics = &httpRouteMetrics{statuses: make(map[int]uint64)}
+		m.routes[key] = metrics
+	}
+	metrics.latency.Observe(latency)
+	metrics.statuses[status]++
+}
+
+// Snapshot returns the metrics of every route seen so far, sorted by route and method
+func (m *HTTPMetrics) Snapshot() []HTTPRouteStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	result := make([]HTTPRouteStats, 0, len(m.routes))
+	for key, metrics := range m.routes {
+		statuses := make(map[int]uint64, len(metrics.statuses))
+		for status, count := range metrics.statuses {
+			statuses[status] = count
+		}
+		latency := metrics.latency.Snapshot()
+		result = append(result, HTTPRouteStats{
+			Route:    key.route,
+			Method:   key.method,
+			Requests: latency.Count,
+			Latency:  latency,
+			Statuses: statuses,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Route != result[j].Route {
+			return result[i].Route < result[j].Route
+		}
+		return result[i].Method < result[j].Method
+	})
+	return result
+}
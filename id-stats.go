@@ -0,0 +1,371 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultMaxTrackedIDs caps the number of CAN IDs tracked per interface
+const DefaultMaxTrackedIDs = 4096
+
+// idEvictionCandidates is how many of the least recently seen IDs are considered for eviction
+const idEvictionCandidates = 16
+
+// Rolling window limits for per-ID statistics. Frames are aggregated into one-second
+// buckets, so windows are rounded up to whole seconds.
+const (
+	DefaultIDStatsWindow = 10 * time.Second
+	MaxIDStatsWindow     = 60 * time.Second
+	idStatsBucketCount   = int(MaxIDStatsWindow / time.Second)
+)
+
+// idPeriodSmoothing is the weight of each new inter-arrival sample in the period estimate
+const idPeriodSmoothing = 0.125
+
+// FrameObserver receives every frame read by the message listener, including error frames
+type FrameObserver interface {
+	ObserveFrame(msg CanMessageLog)
+}
+
+// frameObservers passes every frame to several observers in order
+type frameObservers []FrameObserver
+
+// ObserveFrame implements FrameObserver
+func (observers frameObservers) ObserveFrame(msg CanMessageLog) {
+	for _, observer := range observers {
+		observer.ObserveFrame(msg)
+	}
+}
+
+// CanIDStats is a snapshot of the traffic seen for a single CAN ID
+type CanIDStats struct {
+	ID              uint32       `json:"id"`
+	HexID           string       `json:"hexId"`
+	Frames          uint64       `json:"frames"`
+	Bytes           uint64       `json:"bytes"`
+	RTRFrames       uint64       `json:"rtrFrames"` // Remote transmission requests seen for this ID
+	FirstSeen       time.Time    `json:"firstSeen"`
+	LastSeen        time.Time    `json:"lastSeen"`
+	FrameRate       float64      `json:"frameRate"`                 // Frames per second since first seen
+	Rates           RollingRates `json:"rates"`                     // Frames per second over recent windows
+	EstimatedPeriod string       `json:"estimatedPeriod,omitempty"` // Smoothed inter-arrival time
+}
+
+// InterfaceIDStats is a snapshot of per-ID traffic on an interface
+type InterfaceIDStats struct {
+	Interface   string       `json:"interface"`
+	WindowStart time.Time    `json:"windowStart"`
+	TrackedIDs  int          `json:"trackedIds"`
+	MaxIDs      int          `json:"maxIds"`
+	EvictedIDs  uint64       `json:"evictedIds"` // IDs dropped to stay within MaxIDs
+	IDs         []CanIDStats `json:"ids"`        // Sorted by frame rate, highest first
+}
+
+// CanIDWindowStats summarizes the traffic of a single CAN ID over a rolling window
+type CanIDWindowStats struct {
+	ID       uint32  `json:"id"`
+	HexID    string  `json:"hexId"`
+	Count    uint64  `json:"count"`
+	RateHz   float64 `json:"rateHz"`
+	MinGapMs float64 `json:"minGapMs"` // Inter-frame gaps, 0 when fewer than two frames were seen
+	MaxGapMs float64 `json:"maxGapMs"`
+	AvgGapMs float64 `json:"avgGapMs"`
+}
+
+// InterfaceIDWindowStats is a snapshot of per-ID traffic on an interface over a rolling window
+type InterfaceIDWindowStats struct {
+	Interface string             `json:"interface"`
+	Window    string             `json:"window"`
+	IDs       []CanIDWindowStats `json:"ids"` // Sorted by rate, highest first
+}
+
+// idStatsEntry holds the counters of a single CAN ID
+type idStatsEntry struct {
+	id        uint32
+	frames    uint64
+	bytes     uint64
+	rtrFrames uint64
+	firstSeen time.Time
+	lastSeen  time.Time
+	period    time.Duration
+	rate      RateTracker
+	buckets   [idStatsBucketCount]idStatsBucket // Ring of one-second gap buckets indexed by Unix second
+}
+
+// idStatsBucket aggregates the inter-frame gaps of one ID that ended during one second.
+// A gap is counted in the bucket of the frame that ends it.
+type idStatsBucket struct {
+	second int64
+	gaps   uint64
+	minGap time.Duration
+	maxGap time.Duration
+	sumGap time.Duration
+}
+
+// record counts a frame and the gap it ends, discarding data from an older lap of the ring
+func (e *idStatsEntry) record(timestamp time.Time, gap time.Duration, hasGap bool) {
+	e.rate.Add(timestamp, 1)
+	if !hasGap {
+		return
+	}
+
+	second := timestamp.Unix()
+	bucket := &e.buckets[second%int64(idStatsBucketCount)]
+	if bucket.second != second {
+		*bucket = idStatsBucket{second: second}
+	}
+	if bucket.gaps == 0 || gap < bucket.minGap {
+		bucket.minGap = gap
+	}
+	if gap > bucket.maxGap {
+		bucket.maxGap = gap
+	}
+	bucket.sumGap += gap
+	bucket.gaps++
+}
+
+// interfaceIDCounters tracks per-ID counters of one interface. Entries are kept in
+// least-recently-seen order; when the cap is reached the entry with the fewest frames
+// among the stalest few is evicted, so one-off IDs from a fuzzer churn among themselves
+// instead of pushing out established periodic IDs.
+type interfaceIDCounters struct {
+	entries     map[uint32]*list.Element
+	order       *list.List // Front is most recently seen
+	evicted     uint64
+	windowStart time.Time
+}
+
+// CanIDStatsTracker maintains bounded per-interface, per-ID traffic counters
+type CanIDStatsTracker struct {
+	interfaces map[string]*interfaceIDCounters
+	maxIDs     int
+	mutex      sync.Mutex
+}
+
+// NewCanIDStatsTracker creates a tracker keeping at most maxIDs IDs per interface
+func NewCanIDStatsTracker(maxIDs int) *CanIDStatsTracker {
+	return &CanIDStatsTracker{
+		interfaces: make(map[string]*interfaceIDCounters),
+		maxIDs:     maxIDs,
+	}
+}
+
+// ObserveFrame updates the counters for a received frame
+func (t *CanIDStatsTracker) ObserveFrame(msg CanMessageLog) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	counters := t.countersLocked(msg.Interface)
+
+	element, exists := counters.entries[msg.ID]
+	if !exists {
+		if counters.order.Len() >= t.maxIDs {
+			victim := evictionVictim(counters.order)
+			delete(counters.entries, victim.Value.(*idStatsEntry).id)
+			counters.order.Remove(victim)
+			counters.evicted++
+		}
+		element = counters.order.PushFront(&idStatsEntry{id: msg.ID, firstSeen: msg.Timestamp})
+		counters.entries[msg.ID] = element
+	} else {
+		counters.order.MoveToFront(element)
+	}
+
+	entry := element.Value.(*idStatsEntry)
+	var delta time.Duration
+	if entry.frames > 0 {
+		delta = msg.Timestamp.Sub(entry.lastSeen)
+		if entry.period == 0 {
+			entry.period = delta
+		} else {
+			entry.period += time.Duration(idPeriodSmoothing * float64(delta-entry.period))
+		}
+	}
+	entry.record(msg.Timestamp, delta, entry.frames > 0)
+	entry.frames++
+	entry.bytes += uint64(len(msg.Data))
+	if msg.RTR {
+		entry.rtrFrames++
+	}
+	entry.lastSeen = msg.Timestamp
+}
+
+// evictionVictim picks the entry with the fewest frames among the least recently seen
+func evictionVictim(order *list.List) *list.Element {
+	victim := order.Back()
+	candidate := victim
+	for i := 0; i < idEvictionCandidates && candidate != nil; i++ {
+		if candidate.Value.(*idStatsEntry).frames < victim.Value.(*idStatsEntry).frames {
+			victim = candidate
+		}
+		candidate = candidate.Prev()
+	}
+	return victim
+}
+
+// GetStats returns per-ID statistics sorted by frame rate. top limits the result when positive.
+func (t *CanIDStatsTracker) GetStats(ifName string, top int) InterfaceIDStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	counters := t.countersLocked(ifName)
+	now := time.Now()
+
+	ids := make([]CanIDStats, 0, counters.order.Len())
+	for element := counters.order.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*idStatsEntry)
+
+		// Rates are measured over at least one second so a single frame does not look like a flood
+		elapsed := now.Sub(entry.firstSeen).Seconds()
+		if elapsed < 1 {
+			elapsed = 1
+		}
+
+		stats := CanIDStats{
+			ID:        entry.id,
+			HexID:     fmt.Sprintf("%08x", entry.id),
+			Frames:    entry.frames,
+			Bytes:     entry.bytes,
+			RTRFrames: entry.rtrFrames,
+			FirstSeen: entry.firstSeen,
+			LastSeen:  entry.lastSeen,
+			FrameRate: float64(entry.frames) / elapsed,
+			Rates:     entry.rate.Rates(now),
+		}
+		if entry.period > 0 {
+			stats.EstimatedPeriod = entry.period.Round(time.Microsecond).String()
+		}
+		ids = append(ids, stats)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].FrameRate > ids[j].FrameRate
+	})
+	if top > 0 && len(ids) > top {
+		ids = ids[:top]
+	}
+
+	return InterfaceIDStats{
+		Interface:   ifName,
+		WindowStart: counters.windowStart,
+		TrackedIDs:  counters.order.Len(),
+		MaxIDs:      t.maxIDs,
+		EvictedIDs:  counters.evicted,
+		IDs:         ids,
+	}
+}
+
+// GetWindowStats returns per-ID statistics over the most recent window, sorted by rate.
+// The window must be between one second and MaxIDStatsWindow.
+func (t *CanIDStatsTracker) GetWindowStats(ifName string, window time.Duration) InterfaceIDWindowStats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	counters := t.countersLocked(ifName)
+	seconds := int64((window + time.Second - 1) / time.Second)
+	now := time.Now()
+	newest := now.Unix()
+	oldest := newest - seconds + 1
+
+	// The newest bucket is still filling, so rates are taken over the time actually covered
+	covered := now.Sub(time.Unix(oldest, 0)).Seconds()
+
+	ids := make([]CanIDWindowStats, 0)
+	for element := counters.order.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*idStatsEntry)
+		if entry.lastSeen.Unix() < oldest {
+			continue
+		}
+
+		frames := entry.rate.Sum(oldest, newest)
+		var gaps uint64
+		var minGap, maxGap, sumGap time.Duration
+		for i := range entry.buckets {
+			bucket := &entry.buckets[i]
+			if bucket.second < oldest || bucket.second > newest || bucket.gaps == 0 {
+				continue
+			}
+			if gaps == 0 || bucket.minGap < minGap {
+				minGap = bucket.minGap
+			}
+			if bucket.maxGap > maxGap {
+				maxGap = bucket.maxGap
+			}
+			sumGap += bucket.sumGap
+			gaps += bucket.gaps
+		}
+		if frames == 0 {
+			continue
+		}
+
+		stats := CanIDWindowStats{
+			ID:     entry.id,
+			HexID:  fmt.Sprintf("%08x", entry.id),
+			Count:  frames,
+			RateHz: float64(frames) / covered,
+		}
+		if gaps > 0 {
+			stats.MinGapMs = durationMs(minGap)
+			stats.MaxGapMs = durationMs(maxGap)
+			stats.AvgGapMs = durationMs(sumGap / time.Duration(gaps))
+		}
+		ids = append(ids, stats)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].RateHz > ids[j].RateHz
+	})
+
+	return InterfaceIDWindowStats{
+		Interface: ifName,
+		Window:    window.String(),
+		IDs:       ids,
+	}
+}
+
+// GetIDRate returns the frames per second of one ID over the complete seconds of window
+func (t *CanIDStatsTracker) GetIDRate(ifName string, id uint32, window time.Duration) float64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	counters, exists := t.interfaces[ifName]
+	if !exists {
+		return 0
+	}
+	element, exists := counters.entries[id]
+	if !exists {
+		return 0
+	}
+	return element.Value.(*idStatsEntry).rate.Rate(time.Now(), window)
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// Reset clears the counters of an interface and starts a new measurement window
+func (t *CanIDStatsTracker) Reset(ifName string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.interfaces, ifName)
+	t.countersLocked(ifName)
+}
+
+// countersLocked returns the counters of an interface, creating them if needed (caller holds mutex)
+func (t *CanIDStatsTracker) countersLocked(ifName string) *interfaceIDCounters {
+	counters, exists := t.interfaces[ifName]
+	if !exists {
+		counters = &interfaceIDCounters{
+			entries:     make(map[uint32]*list.Element),
+			order:       list.New(),
+			windowStart: time.Now(),
+		}
+		t.interfaces[ifName] = counters
+	}
+	return counters
+}
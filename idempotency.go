@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Idempotency-Key handling
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotent-Replayed"
+)
+
+// DefaultIdempotencyTTL is how long a response is kept for replays by default
+const DefaultIdempotencyTTL = time.Hour
+
+// IdempotencyStats reports how clients use idempotency keys
+type IdempotencyStats struct {
+	Entries   int    `json:"entries"`
+	Hits      uint64 `json:"hits"`      // Replays answered from the cache
+	Misses    uint64 `json:"misses"`    // Keys seen for the first time, or again after expiry
+	Conflicts uint64 `json:"conflicts"` // Keys reused with another request, or while still in progress
+	Evictions uint64 `json:"evictions"` // Entries dropped before expiry to stay within the size
+}
+
+// idempotencyEntry is the outcome of the first request with a key
+type idempotencyEntry struct {
+	key         string
+	fingerprint [sha256.Size]byte
+	done        bool // False while the first request is still executing
+	status      int
+	contentType string
+	body        []byte
+	expires     time.Time
+	element     *list.Element
+}
+
+// idempotencyOutcome is what to do with a request carrying a key
+type idempotencyOutcome int
+
+const (
+	idempotencyExecute    idempotencyOutcome = iota // First use: execute and store the result
+	idempotencyReplay                               // Answer with the stored result
+	idempotencyConflict                             // Key used for a different request
+	idempotencyInProgress                           // First request with the key has not finished
+)
+
+// IdempotencyCache remembers the responses of requests sent with an Idempotency-Key, so
+// a client retrying after a timeout gets the first result instead of a second send. It
+// holds at most maxEntries responses for ttl each; the oldest go first when it is full.
+type IdempotencyCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	ttl        time.Duration
+	entries    map[string]*idempotencyEntry
+	order      *list.List // Entries by creation, hence by expiry
+	stats      IdempotencyStats
+}
+
+// NewIdempotencyCache creates a cache. Returns nil, which disables idempotency keys,
+// when maxEntries is not positive.
+func NewIdempotencyCache(maxEntries int, ttl time.Duration) *IdempotencyCache {
+	if maxEntries <= 0 {
+		return nil
+	}
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+	return &IdempotencyCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		entries:    make(map[string]*idempotencyEntry),
+		order:      list.New(),
+	}
+}
+
+// begin looks up a key. On first use it reserves the key for the caller, who must then
+// call complete or release.
+func (ic *IdempotencyCache) begin(key string, fingerprint [sha256.Size]byte) (idempotencyOutcome, *idempotencyEntry) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	now := time.Now()
+	ic.expire(now)
+
+	if entry, exists := ic.entries[key]; exists {
+		switch {
+		case entry.fingerprint != fingerprint:
+			ic.stats.Conflicts++
+			return idempotencyConflict, nil
+		case !entry.done:
+			ic.stats.Conflicts++
+			return idempotencyInProgress, nil
+		}
+		ic.stats.Hits++
+		replay := *entry
+		return idempotencyReplay, &replay
+	}
+
+	for len(ic.entries) >= ic.maxEntries {
+		ic.remove(ic.order.Front().Value.(*idempotencyEntry))
+		ic.stats.Evictions++
+	}
+	entry := &idempotencyEntry{key: key, fingerprint: fingerprint, expires: now.Add(ic.ttl)}
+	entry.element = ic.order.PushBack(entry)
+	ic.entries[key] = entry
+	ic.stats.Misses++
+	return idempotencyExecute, nil
+}
+
+// complete stores the response of the request that reserved a key
+func (ic *IdempotencyCache) complete(key string, fingerprint [sha256.Size]byte, status int, contentType string, body []byte) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	// The reservation may have been evicted meanwhile
+	if entry, exists := ic.entries[key]; exists && entry.fingerprint == fingerprint && !entry.done {
+		entry.done, entry.status, entry.contentType, entry.body = true, status, contentType, body
+	}
+}
+
+// release drops the reservation of a request whose response is not kept, so the key
+// can be retried
+func (ic *IdempotencyCache) release(key string, fingerprint [sha256.Size]byte) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	if entry, exists := ic.entries[key]; exists && entry.fingerprint == fingerprint && !entry.done {
+		ic.remove(entry)
+	}
+}
+
+// expire drops the entries whose TTL passed. Entries expire in creation order.
+func (ic *IdempotencyCache) expire(now time.Time) {
+	for front := ic.order.Front(); front != nil; front = ic.order.Front() {
+		entry := front.Value.(*idempotencyEntry)
+		if now.Before(entry.expires) {
+			return
+		}
+		ic.remove(entry)
+	}
+}
+
+func (ic *IdempotencyCache) remove(entry *idempotencyEntry) {
+	ic.order.Remove(entry.element)
+	delete(ic.entries, entry.key)
+}
+
+// GetStats returns the cache counters. A nil cache reports zeros.
+func (ic *IdempotencyCache) GetStats() IdempotencyStats {
+	if ic == nil {
+		return IdempotencyStats{}
+	}
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+
+	stats := ic.stats
+	stats.Entries = len(ic.entries)
+	return stats
+}
+
+// responseRecorder keeps a copy of the response body written to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotent makes a route honor the Idempotency-Key header. A replay of a key gets the
+// stored response with Idempotent-Replayed: true and is not executed again; the same key
+// with another body or route gets 409. Keys are scoped to the client, so clients cannot
+// read each other's results. Responses with a 5xx status are not kept, so a request that
+// failed on the bus can be retried with the same key.
+func (h *APIHandler) idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyKeyHeader)
+		if h.idempotency == nil || key == "" {
+			c.Next()
+			return
+		}
+		if !validRequestID(key) {
+			abortWithError(c, http.StatusBadRequest, CodeInvalidRequest,
+				"Invalid Idempotency-Key: expected at most 128 printable ASCII characters without spaces")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if maxBytes, ok := bodyTooLarge(err); ok {
+			abortWithError(c, http.StatusRequestEntityTooLarge, CodeRequestTooLarge, bodyTooLargeMessage(maxBytes))
+			return
+		}
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, CodeInvalidRequest, "Failed to read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		var fingerprint [sha256.Size]byte
+		copy(fingerprint[:], hash.Sum(nil))
+
+		scopedKey := requestClient(c) + "\x00" + key
+		outcome, entry := h.idempotency.begin(scopedKey, fingerprint)
+		switch outcome {
+		case idempotencyReplay:
+			h.logger.Infof("🔁 Replayed %s %s for Idempotency-Key %q%s",
+				c.Request.Method, c.Request.URL.Path, key, requestIDSuffix(requestID(c)))
+			c.Header(idempotencyReplayedHeader, "true")
+			c.Data(entry.status, entry.contentType, entry.body)
+			c.Abort()
+			return
+		case idempotencyConflict:
+			abortWithError(c, http.StatusConflict, CodeConflict,
+				"Idempotency-Key was already used for a different request")
+			return
+		case idempotencyInProgress:
+			abortWithError(c, http.StatusConflict, CodeConflict,
+				"A request with this Idempotency-Key is still being processed")
+			return
+		}
+
+		// A panicking handler must not leave the key reserved
+		kept := false
+		defer func() {
+			if !kept {
+				h.idempotency.release(scopedKey, fingerprint)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if status := recorder.Status(); status < http.StatusInternalServerError {
+			h.idempotency.complete(scopedKey, fingerprint, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+			kept = true
+		}
+	}
+}
@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"os"
 	"os/exec"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -17,8 +22,18 @@ type InterfaceSetupConfig struct {
 	RestartMs      int           `json:"restartMs,omitempty"`
 	AutoRecovery   bool          `json:"autoRecovery"`
 	TimeoutSeconds int           `json:"timeoutSeconds"`
-	RetryAttempts  int           `json:"retryAttempts"`
+	RetryAttempts  int           `json:"retryAttempts"` // Setup attempts including the first; 1 disables retrying
 	RetryDelay     time.Duration `json:"retryDelay"`
+	RetryBackoff   float64       `json:"retryBackoff,omitempty"`  // Delay multiplier per retry; 0 or 1 keeps it constant
+	MaxRetryDelay  time.Duration `json:"maxRetryDelay,omitempty"` // Cap of the backed off delay, 0 for none
+
+	InterfaceRetryAttempts map[string]int           `json:"interfaceRetryAttempts,omitempty"` // Per-interface overrides
+	InterfaceRetryDelays   map[string]time.Duration `json:"interfaceRetryDelays,omitempty"`
+
+	InterfaceBitrates       map[string]int    `json:"interfaceBitrates,omitempty"`       // Per-interface bitrate overrides
+	InterfaceSamplePoints   map[string]string `json:"interfaceSamplePoints,omitempty"`   // Per-interface sample point overrides
+	InterfaceTripleSampling map[string]bool   `json:"interfaceTripleSampling,omitempty"` // Interfaces sampling each bit three times; false turns it off explicitly
+	InterfaceOneShot        map[string]bool   `json:"interfaceOneShot,omitempty"`        // Interfaces not retransmitting frames; false turns it off explicitly
 }
 
 // DefaultInterfaceSetupConfig returns default setup configuration
@@ -31,7 +46,75 @@ func DefaultInterfaceSetupConfig() InterfaceSetupConfig {
 		TimeoutSeconds: 10,
 		RetryAttempts:  3,
 		RetryDelay:     2 * time.Second,
+		RetryBackoff:   1,
+		MaxRetryDelay:  time.Minute,
+	}
+}
+
+// retryPolicy returns the setup attempts and initial retry delay of an interface
+func (c InterfaceSetupConfig) retryPolicy(ifName string) (int, time.Duration) {
+	attempts, delay := c.RetryAttempts, c.RetryDelay
+	if override, ok := c.InterfaceRetryAttempts[ifName]; ok {
+		attempts = override
+	}
+	if override, ok := c.InterfaceRetryDelays[ifName]; ok {
+		delay = override
+	}
+	return attempts, delay
+}
+
+// samplePointFor returns the sample point an interface is configured with, "" for the
+// driver default
+func (c InterfaceSetupConfig) samplePointFor(ifName string) string {
+	if override, ok := c.InterfaceSamplePoints[ifName]; ok {
+		return override
+	}
+	return c.SamplePoint
+}
+
+// clone returns a copy of the configuration that shares no maps with it
+func (c InterfaceSetupConfig) clone() InterfaceSetupConfig {
+	c.InterfaceRetryAttempts = maps.Clone(c.InterfaceRetryAttempts)
+	c.InterfaceRetryDelays = maps.Clone(c.InterfaceRetryDelays)
+	c.InterfaceBitrates = maps.Clone(c.InterfaceBitrates)
+	c.InterfaceSamplePoints = maps.Clone(c.InterfaceSamplePoints)
+	c.InterfaceTripleSampling = maps.Clone(c.InterfaceTripleSampling)
+	c.InterfaceOneShot = maps.Clone(c.InterfaceOneShot)
+	return c
+}
+
+// withOverride returns a copy of per-interface overrides with the one of ifName replaced
+func withOverride[V any](overrides map[string]V, ifName string, value V) map[string]V {
+	result := make(map[string]V, len(overrides)+1)
+	for name, v := range overrides {
+		result[name] = v
+	}
+	result[ifName] = value
+	return result
+}
+
+// validateSamplePoint checks that a sample point is a fraction of the bit time
+func validateSamplePoint(value string) error {
+	point, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid sample point format: %s", value)
+	}
+	if point <= 0 || point >= 1 {
+		return fmt.Errorf("sample point must be between 0 and 1, got %s", value)
+	}
+	return nil
+}
+
+// retryDelay returns the delay before the retry that follows a failed attempt (1-based)
+func (c InterfaceSetupConfig) retryDelay(base time.Duration, attempt int) time.Duration {
+	delay := float64(base)
+	if c.RetryBackoff > 1 {
+		delay *= math.Pow(c.RetryBackoff, float64(attempt-1))
+	}
+	if c.MaxRetryDelay > 0 && delay > float64(c.MaxRetryDelay) {
+		return c.MaxRetryDelay
 	}
+	return time.Duration(delay)
 }
 
 // InterfaceState represents the current state of a CAN interface
@@ -45,27 +128,106 @@ type InterfaceState struct {
 	RestartMs int       `json:"restartMs"`
 	LastError string    `json:"lastError,omitempty"`
 	SetupTime time.Time `json:"setupTime,omitempty"`
+
+	SetupError string `json:"setupError,omitempty"` // Startup setup failure, if any
+
+	CanState       string `json:"canState,omitempty"` // Controller state: ERROR-ACTIVE, ERROR-WARNING, ERROR-PASSIVE, BUS-OFF, ...
+	TxErrorCounter int    `json:"txErrorCounter"`     // Controller transmit error counter (TEC)
+	RxErrorCounter int    `json:"rxErrorCounter"`     // Controller receive error counter (REC)
+	Source         string `json:"source"`             // netlink, or ip when netlink was unavailable
+
+	SamplePoint    float64    `json:"samplePoint,omitempty"` // Sample point the driver chose, as a fraction of the bit time
+	TripleSampling bool       `json:"tripleSampling"`        // Controller samples each bit three times
+	OneShot        bool       `json:"oneShot"`               // Controller does not retransmit frames that lose arbitration or get no ACK
+	MTU            int        `json:"mtu"`                   // 16 for classic CAN, 72 in CAN FD mode
+	BitTiming      *BitTiming `json:"bitTiming,omitempty"`   // Timing segments the driver chose; absent on vcan
 }
 
+// BitTiming is the nominal bit timing of a CAN controller. A bit lasts
+// 1 + propSeg + phaseSeg1 + phaseSeg2 time quanta and is sampled after 1 + propSeg + phaseSeg1.
+type BitTiming struct {
+	TimeQuantumNs int `json:"tqNs"` // Time quantum in nanoseconds
+	PropSeg       int `json:"propSeg"`
+	PhaseSeg1     int `json:"phaseSeg1"`
+	PhaseSeg2     int `json:"phaseSeg2"`
+	SJW           int `json:"sjw"`           // Synchronization jump width in time quanta
+	BRP           int `json:"brp,omitempty"` // Bitrate prescaler, not printed by older ip versions
+}
+
+// Link MTUs of CAN interfaces: the size of a classic and of a CAN FD frame. Sockets
+// only carry frames of the mode the MTU selects.
+const (
+	classicCANMTU = 16
+	canFDMTU      = 72
+)
+
+// Interface state sources
+const (
+	StateSourceNetlink = "netlink"
+	StateSourceIP      = "ip"
+)
+
 // CommandExecutor interface for dependency injection
 type CommandExecutor interface {
 	Execute(name string, args ...string) ([]byte, error)
 	ExecuteWithTimeout(timeout time.Duration, name string, args ...string) ([]byte, error)
 }
 
+// CommandTimeoutError is returned when a system command exceeds its timeout
+type CommandTimeoutError struct {
+	Command string
+	Timeout time.Duration
+	Elapsed time.Duration
+}
+
+func (e *CommandTimeoutError) Error() string {
+	return fmt.Sprintf("command %q timed out after %v (timeout %v)", e.Command, e.Elapsed.Round(time.Millisecond), e.Timeout)
+}
+
+// ErrPermissionDenied is returned when changing an interface is refused because the
+// service lacks privileges. Retrying cannot help.
+var ErrPermissionDenied = errors.New("needs CAP_NET_ADMIN or root")
+
+// CommandPermissionError is returned when a system command is refused for lack of privileges
+type CommandPermissionError struct {
+	Command string
+	Output  string // What the command printed, e.g. "RTNETLINK answers: Operation not permitted"
+}
+
+func (e *CommandPermissionError) Error() string {
+	return fmt.Sprintf("command %q not permitted: %v (run as root or grant the capability, e.g. AmbientCapabilities=CAP_NET_ADMIN in the systemd unit)",
+		e.Command, ErrPermissionDenied)
+}
+
+func (e *CommandPermissionError) Unwrap() error { return ErrPermissionDenied }
+
+// permissionFailure reports whether a failed command was refused for lack of privileges.
+// ip and ifconfig print the EPERM or EACCES message rather than exiting with a distinct code.
+func permissionFailure(output []byte, err error) bool {
+	if errors.Is(err, os.ErrPermission) {
+		return true
+	}
+	text := strings.ToLower(string(output))
+	return strings.Contains(text, "operation not permitted") || strings.Contains(text, "permission denied")
+}
+
 // SystemCommandExecutor implements CommandExecutor using real system commands
-type SystemCommandExecutor struct{}
+type SystemCommandExecutor struct {
+	timeout time.Duration
+	logger  Logger
+}
 
-// NewSystemCommandExecutor creates a new system command executor
-func NewSystemCommandExecutor() *SystemCommandExecutor {
-	return &SystemCommandExecutor{}
+// NewSystemCommandExecutor creates a new system command executor with a default per-command timeout
+func NewSystemCommandExecutor(timeout time.Duration, logger Logger) *SystemCommandExecutor {
+	return &SystemCommandExecutor{
+		timeout: timeout,
+		logger:  logger,
+	}
 }
 
-// Execute executes a system command
+// Execute executes a system command using the default timeout
 func (e *SystemCommandExecutor) Execute(name string, args ...string) ([]byte, error) {
-	cmd := exec.Command(name, args...)
-	output, err := cmd.CombinedOutput()
-	return output, err
+	return e.ExecuteWithTimeout(e.timeout, name, args...)
 }
 
 // ExecuteWithTimeout executes a system command with timeout
@@ -73,55 +235,164 @@ func (e *SystemCommandExecutor) ExecuteWithTimeout(timeout time.Duration, name s
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	startTime := time.Now()
 	cmd := exec.CommandContext(ctx, name, args...)
 	output, err := cmd.CombinedOutput()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		timeoutErr := &CommandTimeoutError{
+			Command: strings.Join(append([]string{name}, args...), " "),
+			Timeout: timeout,
+			Elapsed: time.Since(startTime),
+		}
+		e.logger.Warnf("⏱️ %v", timeoutErr)
+		return output, timeoutErr
+	}
+
 	return output, err
 }
 
 // InterfaceSetupManager manages CAN interface setup and configuration
 type InterfaceSetupManager struct {
-	config          InterfaceSetupConfig
+	config          InterfaceSetupConfig // Replaced as a whole, never modified in place
+	configMutex     sync.RWMutex
 	commandExecutor CommandExecutor
 	logger          Logger
+	notifier        *Notifier
+	bitrates        map[string]int // Per-interface bitrates set at runtime, overriding config.Bitrate
+	bitratesMutex   sync.RWMutex
+
+	stateReader   InterfaceStateReader // Preferred over parsing ip output when set
+	stateFallback sync.Once            // Logs the first fallback to ip output
+
+	results      map[string]SetupResult // Last setup of each interface
+	resultsMutex sync.RWMutex
+}
+
+// SetupResult is the outcome of the last setup of an interface
+type SetupResult struct {
+	Succeeded   bool      `json:"succeeded"`
+	Attempts    int       `json:"attempts"`    // Attempts used, the first included
+	MaxAttempts int       `json:"maxAttempts"` // Attempts allowed; 1 for setups without retry
+	Error       string    `json:"error,omitempty"`
+	ErrorCode   ErrorCode `json:"errorCode,omitempty"` // e.g. PERMISSION_DENIED
+	CompletedAt time.Time `json:"completedAt"`
 }
 
 // NewInterfaceSetupManager creates a new interface setup manager
 func NewInterfaceSetupManager(config InterfaceSetupConfig, commandExecutor CommandExecutor, logger Logger) *InterfaceSetupManager {
 	return &InterfaceSetupManager{
-		config:          config,
+		config:          config.clone(),
 		commandExecutor: commandExecutor,
 		logger:          logger,
+		bitrates:        make(map[string]int),
+		results:         make(map[string]SetupResult),
 	}
 }
 
-// SetupInterface configures and brings up a CAN interface
+// SetNotifier sets the sink that receives setup failure notifications
+func (ism *InterfaceSetupManager) SetNotifier(notifier *Notifier) {
+	ism.notifier = notifier
+}
+
+// SetStateReader sets the reader used for interface state before falling back to ip output
+func (ism *InterfaceSetupManager) SetStateReader(reader InterfaceStateReader) {
+	ism.stateReader = reader
+}
+
+// SetupInterface configures and brings up a CAN interface in a single attempt
 func (ism *InterfaceSetupManager) SetupInterface(ifName string) error {
-	ism.logger.Printf("🔧 Setting up CAN interface %s...", ifName)
+	err := ism.setupInterface(ifName, ism.currentConfig(), false)
+	ism.recordResult(ifName, 1, 1, err)
+	return err
+}
+
+// ReconfigureInterface sets up an interface in a single attempt even when it already runs
+// with its configuration: it is brought down, configured and brought up again, so settings
+// setup does not compare, or the driver does not report, are applied too
+func (ism *InterfaceSetupManager) ReconfigureInterface(ifName string) error {
+	err := ism.setupInterface(ifName, ism.currentConfig(), true)
+	ism.recordResult(ifName, 1, 1, err)
+	return err
+}
+
+// SetupInterfaceWith sets up an interface with config in place of the setup
+// configuration, for a setup with parameters of its own. The setup configuration is left
+// as it is, so other setups and reloads neither see nor undo the parameters.
+func (ism *InterfaceSetupManager) SetupInterfaceWith(ifName string, config InterfaceSetupConfig, withRetry bool) error {
+	if err := config.validate(); err != nil {
+		return err
+	}
+	if withRetry {
+		return ism.setupInterfaceWithRetry(ifName, config)
+	}
+	err := ism.setupInterface(ifName, config, false)
+	ism.recordResult(ifName, 1, 1, err)
+	return err
+}
+
+// recordResult keeps the outcome of a setup for GetSetupResult
+func (ism *InterfaceSetupManager) recordResult(ifName string, attempts, maxAttempts int, err error) {
+	result := SetupResult{
+		Succeeded:   err == nil,
+		Attempts:    attempts,
+		MaxAttempts: maxAttempts,
+		CompletedAt: time.Now(),
+	}
+	if err != nil {
+		result.Error, result.ErrorCode = err.Error(), errorCode(err)
+	}
+
+	ism.resultsMutex.Lock()
+	defer ism.resultsMutex.Unlock()
+	ism.results[ifName] = result
+}
+
+// GetSetupResult returns the outcome of the last setup of an interface, false when it
+// was never set up
+func (ism *InterfaceSetupManager) GetSetupResult(ifName string) (SetupResult, bool) {
+	ism.resultsMutex.RLock()
+	defer ism.resultsMutex.RUnlock()
+	result, ok := ism.results[ifName]
+	return result, ok
+}
+
+// setupInterface runs one setup attempt with config. Unless forced, an interface already
+// up with the configuration is left as it is.
+func (ism *InterfaceSetupManager) setupInterface(ifName string, config InterfaceSetupConfig, force bool) error {
+	ism.logger.Infof("🔧 Setting up CAN interface %s...", ifName)
 
 	// First, check if interface exists
-	if !ism.interfaceExists(ifName) {
+	exists, err := ism.interfaceExists(ifName)
+	if err != nil {
+		return fmt.Errorf("failed to check interface %s: %w", ifName, err)
+	}
+	if !exists {
 		return fmt.Errorf("CAN interface %s does not exist", ifName)
 	}
 
 	// Get current state to see if interface is already up
 	currentState, err := ism.GetInterfaceState(ifName)
 	if err != nil {
-		ism.logger.Printf("⚠️ Warning: could not get current state of %s: %v", ifName, err)
+		ism.logger.Warnf("⚠️ Warning: could not get current state of %s: %v", ifName, err)
 	}
 
 	// If interface is already up and configured correctly, skip setup
-	if currentState != nil && currentState.IsUp && currentState.Bitrate == ism.config.Bitrate {
-		ism.logger.Printf("✅ Interface %s is already configured correctly (bitrate=%d)", ifName, currentState.Bitrate)
+	if !force && currentState != nil && ism.configuredAs(currentState, ifName, config) {
+		ism.logger.Infof("✅ Interface %s is already configured correctly (bitrate=%d, sample-point=%.3f, triple-sampling=%t, one-shot=%t, mtu=%d, restart-ms=%d)",
+			ifName, currentState.Bitrate, currentState.SamplePoint, currentState.TripleSampling, currentState.OneShot, currentState.MTU, currentState.RestartMs)
 		return nil
 	}
 
 	// Bring interface down first (only if it's up)
 	if currentState != nil && currentState.IsUp {
-		if err := ism.bringInterfaceDown(ifName); err != nil {
-			ism.logger.Printf("⚠️ Warning: failed to bring %s down: %v", ifName, err)
+		if err := ism.bringInterfaceDown(ifName); errors.Is(err, ErrPermissionDenied) {
+			return fmt.Errorf("failed to bring %s down: %w", ifName, err)
+		} else if err != nil {
+			ism.logger.Warnf("⚠️ Warning: failed to bring %s down: %v", ifName, err)
 			// Try to force down
 			if err := ism.forceInterfaceDown(ifName); err != nil {
-				ism.logger.Printf("⚠️ Warning: failed to force %s down: %v", ifName, err)
+				ism.logger.Warnf("⚠️ Warning: failed to force %s down: %v", ifName, err)
 			}
 		}
 		// Brief pause after bringing down
@@ -129,7 +400,7 @@ func (ism *InterfaceSetupManager) SetupInterface(ifName string) error {
 	}
 
 	// Configure interface parameters
-	if err := ism.configureInterface(ifName); err != nil {
+	if err := ism.configureInterface(ifName, config); err != nil {
 		return fmt.Errorf("failed to configure %s: %w", ifName, err)
 	}
 
@@ -139,131 +410,247 @@ func (ism *InterfaceSetupManager) SetupInterface(ifName string) error {
 	}
 
 	// Verify interface is working
-	if err := ism.verifyInterface(ifName); err != nil {
+	if err := ism.verifyInterface(ifName, config); err != nil {
 		return fmt.Errorf("interface %s verification failed: %w", ifName, err)
 	}
 
-	ism.logger.Printf("✅ CAN interface %s successfully configured and activated", ifName)
+	ism.logger.Infof("✅ CAN interface %s successfully configured and activated", ifName)
 	return nil
 }
 
+// configuredAs reports whether an interface runs as config would configure it, as far as
+// its state shows
+func (ism *InterfaceSetupManager) configuredAs(state *InterfaceState, ifName string, config InterfaceSetupConfig) bool {
+	if !state.IsUp || state.Bitrate != ism.bitrateFor(ifName, config) || state.MTU != classicCANMTU {
+		return false
+	}
+
+	// Without restart-ms the driver keeps the one it has
+	if config.RestartMs > 0 && state.RestartMs != config.RestartMs {
+		return false
+	}
+
+	if samplePoint := config.samplePointFor(ifName); samplePoint != "" && !samplePointMatches(state, samplePoint) {
+		return false
+	}
+
+	// Controller modes are only changed for the interfaces they are configured for
+	if tripleSampling, configured := config.InterfaceTripleSampling[ifName]; configured && state.TripleSampling != tripleSampling {
+		return false
+	}
+	if oneShot, configured := config.InterfaceOneShot[ifName]; configured && state.OneShot != oneShot {
+		return false
+	}
+	return true
+}
+
+// samplePointMatches reports whether an interface samples at the configured sample point.
+// The driver picks the closest point the controller can sample at, up to half a time
+// quantum away; ip prints it to a thousandth of the bit time.
+func samplePointMatches(state *InterfaceState, samplePoint string) bool {
+	want, err := strconv.ParseFloat(samplePoint, 64)
+	if err != nil || state.SamplePoint == 0 {
+		return false
+	}
+	tolerance := 0.0005
+	if timing := state.BitTiming; timing != nil {
+		tolerance += 0.5 / float64(1+timing.PropSeg+timing.PhaseSeg1+timing.PhaseSeg2)
+	}
+	return math.Abs(state.SamplePoint-want) <= tolerance
+}
+
 // SetupInterfaceWithRetry sets up interface with retry logic
 func (ism *InterfaceSetupManager) SetupInterfaceWithRetry(ifName string) error {
+	return ism.setupInterfaceWithRetry(ifName, ism.currentConfig())
+}
+
+// setupInterfaceWithRetry sets up an interface with config, retrying as it specifies
+func (ism *InterfaceSetupManager) setupInterfaceWithRetry(ifName string, config InterfaceSetupConfig) error {
 	var lastErr error
+	attempts, baseDelay := config.retryPolicy(ifName)
+	used := 0
 
-	for attempt := 1; attempt <= ism.config.RetryAttempts; attempt++ {
-		err := ism.SetupInterface(ifName)
+	for attempt := 1; attempt <= attempts; attempt++ {
+		used = attempt
+		err := ism.setupInterface(ifName, config, false)
 		if err == nil {
+			ism.recordResult(ifName, used, attempts, nil)
 			return nil
 		}
 
 		lastErr = err
-		ism.logger.Printf("❌ Setup attempt %d/%d failed for %s: %v",
-			attempt, ism.config.RetryAttempts, ifName, err)
+		var timeoutErr *CommandTimeoutError
+		var permissionErr *CommandPermissionError
+		if errors.As(err, &permissionErr) {
+			// Retrying cannot grant privileges
+			ism.logger.Warnf("🔒 Setup of %s refused running %q: %v, not retrying",
+				ifName, permissionErr.Command, ErrPermissionDenied)
+			break
+		} else if errors.As(err, &timeoutErr) {
+			ism.logger.Warnf("⏱️ Setup attempt %d/%d for %s timed out running %q after %v, will retry",
+				attempt, attempts, ifName, timeoutErr.Command, timeoutErr.Elapsed.Round(time.Millisecond))
+		} else {
+			ism.logger.Errorf("❌ Setup attempt %d/%d failed for %s: %v",
+				attempt, attempts, ifName, err)
+		}
 
-		if attempt < ism.config.RetryAttempts {
-			ism.logger.Printf("⏳ Retrying in %v...", ism.config.RetryDelay)
-			time.Sleep(ism.config.RetryDelay)
+		if attempt < attempts {
+			delay := config.retryDelay(baseDelay, attempt)
+			ism.logger.Infof("⏳ Retrying in %v...", delay)
+			time.Sleep(delay)
 		}
 	}
 
-	return fmt.Errorf("failed to setup %s after %d attempts: %w",
-		ifName, ism.config.RetryAttempts, lastErr)
+	err := fmt.Errorf("failed to setup %s after %d attempts: %w",
+		ifName, attempts, lastErr)
+	message := fmt.Sprintf("interface setup failed after %d attempts", attempts)
+	if errors.Is(lastErr, ErrPermissionDenied) {
+		err = fmt.Errorf("failed to setup %s: %w", ifName, lastErr)
+		message = "interface setup not permitted: " + ErrPermissionDenied.Error()
+	}
+	ism.notifier.Publish(Notification{
+		Interface: ifName,
+		EventType: NotifySetupFailed,
+		Severity:  SeverityCritical,
+		Message:   message,
+		Error:     err.Error(),
+	})
+	ism.recordResult(ifName, used, attempts, err)
+	return err
 }
 
-// interfaceExists checks if a CAN interface exists in the system
-func (ism *InterfaceSetupManager) interfaceExists(ifName string) bool {
+// interfaceExists checks if a CAN interface exists in the system.
+// Only command timeouts are reported as errors; any other failure means the interface is missing.
+func (ism *InterfaceSetupManager) interfaceExists(ifName string) (bool, error) {
 	output, err := ism.commandExecutor.Execute("ip", "link", "show", ifName)
 	if err != nil {
-		ism.logger.Printf("🔍 Interface check failed for %s: %v", ifName, err)
-		return false
+		var timeoutErr *CommandTimeoutError
+		if errors.As(err, &timeoutErr) {
+			return false, err
+		}
+		ism.logger.Infof("🔍 Interface check failed for %s: %v", ifName, err)
+		return false, nil
 	}
 	exists := strings.Contains(string(output), ifName)
-	ism.logger.Printf("🔍 Interface %s exists: %t", ifName, exists)
-	return exists
+	ism.logger.Debugf("🔍 Interface %s exists: %t", ifName, exists)
+	return exists, nil
+}
+
+// runPrivileged runs a command that changes an interface with the setup timeout. A
+// refusal for lack of privileges is returned as *CommandPermissionError.
+func (ism *InterfaceSetupManager) runPrivileged(name string, args ...string) ([]byte, error) {
+	timeout := time.Duration(ism.currentConfig().TimeoutSeconds) * time.Second
+	output, err := ism.commandExecutor.ExecuteWithTimeout(timeout, name, args...)
+	if err == nil || !permissionFailure(output, err) {
+		return output, err
+	}
+
+	detail := strings.TrimSpace(string(output))
+	if detail == "" {
+		detail = err.Error()
+	}
+	return output, &CommandPermissionError{
+		Command: strings.Join(append([]string{name}, args...), " "),
+		Output:  detail,
+	}
 }
 
 // bringInterfaceDown brings CAN interface down
 func (ism *InterfaceSetupManager) bringInterfaceDown(ifName string) error {
-	ism.logger.Printf("🔽 Bringing %s down...", ifName)
-	timeout := time.Duration(ism.config.TimeoutSeconds) * time.Second
-	output, err := ism.commandExecutor.ExecuteWithTimeout(timeout, "ip", "link", "set", ifName, "down")
+	ism.logger.Infof("🔽 Bringing %s down...", ifName)
+	output, err := ism.runPrivileged("ip", "link", "set", ifName, "down")
 	if err != nil {
-		ism.logger.Printf("❌ Failed to bring %s down: %v, output: %s", ifName, err, string(output))
+		ism.logger.Errorf("❌ Failed to bring %s down: %v, output: %s", ifName, err, string(output))
 		return err
 	}
-	ism.logger.Printf("✅ Successfully brought %s down", ifName)
+	ism.logger.Infof("✅ Successfully brought %s down", ifName)
 	return nil
 }
 
 // forceInterfaceDown forces interface down using different approach
 func (ism *InterfaceSetupManager) forceInterfaceDown(ifName string) error {
-	ism.logger.Printf("🔽 Force bringing %s down...", ifName)
+	ism.logger.Infof("🔽 Force bringing %s down...", ifName)
 
 	// Try using ifconfig as alternative
-	timeout := time.Duration(ism.config.TimeoutSeconds) * time.Second
-	output, err := ism.commandExecutor.ExecuteWithTimeout(timeout, "ifconfig", ifName, "down")
+	output, err := ism.runPrivileged("ifconfig", ifName, "down")
 	if err != nil {
-		ism.logger.Printf("❌ Failed to force %s down with ifconfig: %v, output: %s", ifName, err, string(output))
+		ism.logger.Errorf("❌ Failed to force %s down with ifconfig: %v, output: %s", ifName, err, string(output))
 		return err
 	}
-	ism.logger.Printf("✅ Successfully forced %s down with ifconfig", ifName)
+	ism.logger.Infof("✅ Successfully forced %s down with ifconfig", ifName)
 	return nil
 }
 
-// configureInterface configures CAN interface parameters
-func (ism *InterfaceSetupManager) configureInterface(ifName string) error {
-	ism.logger.Printf("⚙️ Configuring %s parameters...", ifName)
+// configureInterface configures CAN interface parameters from config
+func (ism *InterfaceSetupManager) configureInterface(ifName string, config InterfaceSetupConfig) error {
+	ism.logger.Infof("⚙️ Configuring %s parameters...", ifName)
 
-	args := []string{"link", "set", ifName, "type", "can"}
+	// CAN FD is not supported, so every interface gets the MTU of classic frames
+	args := []string{"link", "set", ifName, "mtu", strconv.Itoa(classicCANMTU), "type", "can"}
 
 	// Add bitrate
-	args = append(args, "bitrate", strconv.Itoa(ism.config.Bitrate))
+	bitrate := ism.bitrateFor(ifName, config)
+	args = append(args, "bitrate", strconv.Itoa(bitrate))
 
 	// Add sample point if specified
-	if ism.config.SamplePoint != "" {
-		args = append(args, "sample-point", ism.config.SamplePoint)
+	samplePoint := config.samplePointFor(ifName)
+	if samplePoint != "" {
+		args = append(args, "sample-point", samplePoint)
+	}
+
+	// Add triple sampling if configured for the interface
+	tripleSampling, configured := config.InterfaceTripleSampling[ifName]
+	if tripleSampling {
+		args = append(args, "triple-sampling", "on")
+	} else if configured {
+		args = append(args, "triple-sampling", "off")
+	}
+
+	// Add one-shot mode if configured for the interface
+	oneShot, configured := config.InterfaceOneShot[ifName]
+	if oneShot {
+		args = append(args, "one-shot", "on")
+	} else if configured {
+		args = append(args, "one-shot", "off")
 	}
 
 	// Add restart-ms if specified
-	if ism.config.RestartMs > 0 {
-		args = append(args, "restart-ms", strconv.Itoa(ism.config.RestartMs))
+	if config.RestartMs > 0 {
+		args = append(args, "restart-ms", strconv.Itoa(config.RestartMs))
 	}
 
-	ism.logger.Printf("📝 Executing: ip %s", strings.Join(args, " "))
+	ism.logger.Debugf("📝 Executing: ip %s", strings.Join(args, " "))
 
-	timeout := time.Duration(ism.config.TimeoutSeconds) * time.Second
-	output, err := ism.commandExecutor.ExecuteWithTimeout(timeout, "ip", args...)
+	output, err := ism.runPrivileged("ip", args...)
 
 	if err != nil {
-		ism.logger.Printf("❌ Configuration failed for %s: %v, output: %s", ifName, err, string(output))
-		return fmt.Errorf("configuration failed: %v, output: %s", err, string(output))
+		ism.logger.Errorf("❌ Configuration failed for %s: %v, output: %s", ifName, err, string(output))
+		return fmt.Errorf("configuration failed: %w, output: %s", err, string(output))
 	}
 
-	ism.logger.Printf("✅ Successfully configured %s: bitrate=%d, sample-point=%s, restart-ms=%d",
-		ifName, ism.config.Bitrate, ism.config.SamplePoint, ism.config.RestartMs)
+	ism.logger.Infof("✅ Successfully configured %s: mtu=%d, bitrate=%d, sample-point=%s, triple-sampling=%t, one-shot=%t, restart-ms=%d",
+		ifName, classicCANMTU, bitrate, samplePoint, tripleSampling, oneShot, config.RestartMs)
 
 	return nil
 }
 
 // bringInterfaceUp brings CAN interface up
 func (ism *InterfaceSetupManager) bringInterfaceUp(ifName string) error {
-	ism.logger.Printf("🚀 Bringing %s up...", ifName)
-	timeout := time.Duration(ism.config.TimeoutSeconds) * time.Second
-	output, err := ism.commandExecutor.ExecuteWithTimeout(timeout, "ip", "link", "set", ifName, "up")
+	ism.logger.Infof("🚀 Bringing %s up...", ifName)
+	output, err := ism.runPrivileged("ip", "link", "set", ifName, "up")
 
 	if err != nil {
-		ism.logger.Printf("❌ Failed to bring %s up: %v, output: %s", ifName, err, string(output))
-		return fmt.Errorf("failed to bring interface up: %v, output: %s", err, string(output))
+		ism.logger.Errorf("❌ Failed to bring %s up: %v, output: %s", ifName, err, string(output))
+		return fmt.Errorf("failed to bring interface up: %w, output: %s", err, string(output))
 	}
 
-	ism.logger.Printf("✅ Successfully brought %s up", ifName)
+	ism.logger.Infof("✅ Successfully brought %s up", ifName)
 	return nil
 }
 
-// verifyInterface verifies that the interface is working properly
-func (ism *InterfaceSetupManager) verifyInterface(ifName string) error {
-	ism.logger.Printf("🔍 Verifying %s configuration...", ifName)
+// verifyInterface verifies that the interface is working properly with config
+func (ism *InterfaceSetupManager) verifyInterface(ifName string, config InterfaceSetupConfig) error {
+	ism.logger.Infof("🔍 Verifying %s configuration...", ifName)
 
 	state, err := ism.GetInterfaceState(ifName)
 	if err != nil {
@@ -274,23 +661,47 @@ func (ism *InterfaceSetupManager) verifyInterface(ifName string) error {
 		return fmt.Errorf("interface is not up")
 	}
 
-	if state.Bitrate != ism.config.Bitrate {
+	if expected := ism.bitrateFor(ifName, config); state.Bitrate != expected {
 		return fmt.Errorf("bitrate mismatch: expected %d, got %d",
-			ism.config.Bitrate, state.Bitrate)
+			expected, state.Bitrate)
+	}
+
+	// A socket on an interface in the other mode drops the frames it sends, so a
+	// mismatch fails here rather than on the first send
+	if state.MTU == canFDMTU {
+		return fmt.Errorf("mtu mismatch: interface is in CAN FD mode (mtu %d), which is not supported; expected classic CAN (mtu %d)",
+			state.MTU, classicCANMTU)
+	}
+	if state.MTU != classicCANMTU {
+		return fmt.Errorf("mtu mismatch: expected %d for classic CAN, got %d", classicCANMTU, state.MTU)
 	}
 
 	if strings.Contains(strings.ToUpper(state.State), "ERROR") && !strings.Contains(strings.ToUpper(state.State), "ERROR-ACTIVE") {
 		return fmt.Errorf("interface is in error state: %s", state.State)
 	}
 
-	ism.logger.Printf("✅ Interface %s verification passed: up=%t, bitrate=%d, state=%s",
-		ifName, state.IsUp, state.Bitrate, state.State)
+	ism.logger.Infof("✅ Interface %s verification passed: up=%t, bitrate=%d, mtu=%d, state=%s",
+		ifName, state.IsUp, state.Bitrate, state.MTU, state.State)
 
 	return nil
 }
 
-// GetInterfaceState gets current state of a CAN interface
+// GetInterfaceState gets current state of a CAN interface, from the state reader when
+// one is set and otherwise, or when it fails, from ip output
 func (ism *InterfaceSetupManager) GetInterfaceState(ifName string) (*InterfaceState, error) {
+	if ism.stateReader != nil {
+		state, err := ism.stateReader.ReadInterfaceState(ifName)
+		if err == nil {
+			return state, nil
+		}
+		if errors.Is(err, ErrInterfaceNotFound) {
+			return nil, fmt.Errorf("failed to get interface details: %w", err)
+		}
+		ism.stateFallback.Do(func() {
+			ism.logger.Warnf("⚠️ Reading interface state via netlink failed, falling back to ip output: %v", err)
+		})
+	}
+
 	output, err := ism.commandExecutor.Execute("ip", "-details", "link", "show", ifName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get interface details: %w", err)
@@ -302,7 +713,8 @@ func (ism *InterfaceSetupManager) GetInterfaceState(ifName string) (*InterfaceSt
 // parseInterfaceState parses interface state from ip command output
 func (ism *InterfaceSetupManager) parseInterfaceState(ifName, output string) (*InterfaceState, error) {
 	state := &InterfaceState{
-		Name: ifName,
+		Name:   ifName,
+		Source: StateSourceIP,
 	}
 
 	// Check if interface is UP
@@ -317,6 +729,11 @@ func (ism *InterfaceSetupManager) parseInterfaceState(ifName, output string) (*I
 		state.State = match[1]
 	}
 
+	// Extract the link MTU ("<NOARP,UP,LOWER_UP,ECHO> mtu 16 qdisc ...")
+	if match := regexp.MustCompile(`\bmtu (\d+)`).FindStringSubmatch(output); len(match) > 1 {
+		state.MTU, _ = strconv.Atoi(match[1])
+	}
+
 	// Extract bitrate
 	if match := regexp.MustCompile(`bitrate (\d+)`).FindStringSubmatch(output); len(match) > 1 {
 		if bitrate, err := strconv.Atoi(match[1]); err == nil {
@@ -324,6 +741,33 @@ func (ism *InterfaceSetupManager) parseInterfaceState(ifName, output string) (*I
 		}
 	}
 
+	// Extract the controller state and error counters ("can <FLAGS> state ERROR-ACTIVE (berr-counter tx 0 rx 0)")
+	if match := regexp.MustCompile(`can (?:<([^>]*)> )?state ([\w-]+)`).FindStringSubmatch(output); len(match) > 2 {
+		state.TripleSampling = strings.Contains(match[1], "TRIPLE-SAMPLING")
+		state.OneShot = strings.Contains(match[1], "ONE-SHOT")
+		state.CanState = match[2]
+	}
+	if match := regexp.MustCompile(`berr-counter tx (\d+) rx (\d+)`).FindStringSubmatch(output); len(match) > 2 {
+		state.TxErrorCounter, _ = strconv.Atoi(match[1])
+		state.RxErrorCounter, _ = strconv.Atoi(match[2])
+	}
+
+	// Extract the sample point and timing segments ("bitrate 500000 sample-point 0.875
+	// tq 125 prop-seg 6 phase-seg1 7 phase-seg2 2 sjw 1 brp 1"), not those of the data phase
+	if match := regexp.MustCompile(`\bsample-point ([\d.]+)`).FindStringSubmatch(output); len(match) > 1 {
+		state.SamplePoint, _ = strconv.ParseFloat(match[1], 64)
+	}
+	if match := regexp.MustCompile(`\btq (\d+) prop-seg (\d+) phase-seg1 (\d+) phase-seg2 (\d+) sjw (\d+)(?: brp (\d+))?`).FindStringSubmatch(output); len(match) > 6 {
+		timing := &BitTiming{}
+		timing.TimeQuantumNs, _ = strconv.Atoi(match[1])
+		timing.PropSeg, _ = strconv.Atoi(match[2])
+		timing.PhaseSeg1, _ = strconv.Atoi(match[3])
+		timing.PhaseSeg2, _ = strconv.Atoi(match[4])
+		timing.SJW, _ = strconv.Atoi(match[5])
+		timing.BRP, _ = strconv.Atoi(match[6])
+		state.BitTiming = timing
+	}
+
 	// Extract restart-ms
 	if match := regexp.MustCompile(`restart-ms (\d+)`).FindStringSubmatch(output); len(match) > 1 {
 		if restartMs, err := strconv.Atoi(match[1]); err == nil {
@@ -395,7 +839,7 @@ func (ism *InterfaceSetupManager) parseIpStatistics(state *InterfaceState, outpu
 
 // ResetInterface resets a CAN interface (down and up)
 func (ism *InterfaceSetupManager) ResetInterface(ifName string) error {
-	ism.logger.Printf("🔄 Resetting CAN interface %s", ifName)
+	ism.logger.Infof("🔄 Resetting CAN interface %s", ifName)
 
 	if err := ism.bringInterfaceDown(ifName); err != nil {
 		return fmt.Errorf("failed to bring interface down: %w", err)
@@ -407,19 +851,62 @@ func (ism *InterfaceSetupManager) ResetInterface(ifName string) error {
 		return fmt.Errorf("failed to bring interface up: %w", err)
 	}
 
-	ism.logger.Printf("✅ Interface %s reset successfully", ifName)
+	ism.logger.Infof("✅ Interface %s reset successfully", ifName)
+	return nil
+}
+
+// SetInterfaceBitrate reconfigures an interface to a new bitrate, bringing it down and
+// back up. The bitrate is kept for later setups of the interface until restart.
+func (ism *InterfaceSetupManager) SetInterfaceBitrate(ifName string, bitrate int) error {
+	if !isValidBitrate(bitrate) {
+		return fmt.Errorf("bitrate %d is not a standard CAN bitrate. Valid options: %v", bitrate, validBitrates)
+	}
+
+	ism.bitratesMutex.Lock()
+	previous, hadPrevious := ism.bitrates[ifName]
+	ism.bitrates[ifName] = bitrate
+	ism.bitratesMutex.Unlock()
+
+	ism.logger.Infof("🎚️ Changing %s bitrate to %d", ifName, bitrate)
+
+	if err := ism.SetupInterface(ifName); err != nil {
+		ism.bitratesMutex.Lock()
+		if hadPrevious {
+			ism.bitrates[ifName] = previous
+		} else {
+			delete(ism.bitrates, ifName)
+		}
+		ism.bitratesMutex.Unlock()
+		return fmt.Errorf("failed to change %s bitrate to %d: %w", ifName, bitrate, err)
+	}
+
 	return nil
 }
 
+// bitrateFor returns the bitrate an interface should be configured with: the one set at
+// runtime, else its override in config, else the default of config
+func (ism *InterfaceSetupManager) bitrateFor(ifName string, config InterfaceSetupConfig) int {
+	ism.bitratesMutex.RLock()
+	defer ism.bitratesMutex.RUnlock()
+
+	if bitrate, exists := ism.bitrates[ifName]; exists {
+		return bitrate
+	}
+	if bitrate, exists := config.InterfaceBitrates[ifName]; exists {
+		return bitrate
+	}
+	return config.Bitrate
+}
+
 // TeardownInterface brings down a CAN interface
 func (ism *InterfaceSetupManager) TeardownInterface(ifName string) error {
-	ism.logger.Printf("🔽 Tearing down CAN interface %s", ifName)
+	ism.logger.Infof("🔽 Tearing down CAN interface %s", ifName)
 
 	if err := ism.bringInterfaceDown(ifName); err != nil {
 		return fmt.Errorf("failed to teardown interface: %w", err)
 	}
 
-	ism.logger.Printf("✅ Interface %s teardown complete", ifName)
+	ism.logger.Infof("✅ Interface %s teardown complete", ifName)
 	return nil
 }
 
@@ -439,40 +926,97 @@ func (ism *InterfaceSetupManager) GetAvailableInterfaces() ([]string, error) {
 		}
 	}
 
-	ism.logger.Printf("🔍 Found %d CAN interfaces: %v", len(interfaces), interfaces)
+	ism.logger.Debugf("🔍 Found %d CAN interfaces: %v", len(interfaces), interfaces)
 	return interfaces, nil
 }
 
 // ValidateSetupConfig validates the setup configuration
 func (ism *InterfaceSetupManager) ValidateSetupConfig() error {
-	if ism.config.Bitrate <= 0 {
-		return fmt.Errorf("bitrate must be positive")
+	return ism.currentConfig().validate()
+}
+
+// validate reports every problem of a setup configuration at once, as ConfigErrors
+// named after the JSON fields
+func (c InterfaceSetupConfig) validate() error {
+	var errs ConfigErrors
+	if c.Bitrate <= 0 {
+		errs.add("bitrate", c.Bitrate, "bitrate must be positive")
 	}
 
-	if ism.config.TimeoutSeconds <= 0 {
-		return fmt.Errorf("timeout must be positive")
+	if c.TimeoutSeconds <= 0 {
+		errs.add("timeoutSeconds", c.TimeoutSeconds, "timeout must be positive")
 	}
 
-	if ism.config.RetryAttempts <= 0 {
-		return fmt.Errorf("retry attempts must be positive")
+	if c.RetryAttempts <= 0 {
+		errs.add("retryAttempts", c.RetryAttempts, "retry attempts must be positive")
 	}
 
-	if ism.config.SamplePoint != "" {
-		if point, err := strconv.ParseFloat(ism.config.SamplePoint, 64); err != nil || point <= 0 || point >= 1 {
-			return fmt.Errorf("sample point must be between 0 and 1")
+	if c.RetryDelay < 0 {
+		errs.add("retryDelay", c.RetryDelay, "retry delay cannot be negative")
+	}
+
+	if c.RetryBackoff != 0 && c.RetryBackoff < 1 {
+		errs.add("retryBackoff", c.RetryBackoff, "retry backoff must be at least 1")
+	}
+
+	if c.MaxRetryDelay < 0 {
+		errs.add("maxRetryDelay", c.MaxRetryDelay, "max retry delay cannot be negative")
+	}
+
+	for ifName, attempts := range c.InterfaceRetryAttempts {
+		if attempts <= 0 {
+			errs.add("interfaceRetryAttempts["+ifName+"]", attempts, "retry attempts must be positive")
 		}
 	}
 
-	return nil
+	for ifName, delay := range c.InterfaceRetryDelays {
+		if delay < 0 {
+			errs.add("interfaceRetryDelays["+ifName+"]", delay, "retry delay cannot be negative")
+		}
+	}
+
+	for ifName, bitrate := range c.InterfaceBitrates {
+		if bitrate <= 0 {
+			errs.add("interfaceBitrates["+ifName+"]", bitrate, "bitrate must be positive")
+		}
+	}
+
+	if c.SamplePoint != "" {
+		if err := validateSamplePoint(c.SamplePoint); err != nil {
+			errs.add("samplePoint", c.SamplePoint, "%v", err)
+		}
+	}
+
+	for ifName, samplePoint := range c.InterfaceSamplePoints {
+		if err := validateSamplePoint(samplePoint); err != nil {
+			errs.add("interfaceSamplePoints["+ifName+"]", samplePoint, "%v", err)
+		}
+	}
+
+	return errs.err()
 }
 
-// GetSetupConfig returns current setup configuration
+// GetSetupConfig returns a copy of the current setup configuration
 func (ism *InterfaceSetupManager) GetSetupConfig() InterfaceSetupConfig {
+	return ism.currentConfig().clone()
+}
+
+// currentConfig returns the setup configuration in effect. The configuration is replaced
+// rather than modified, so its maps can be read without holding the lock.
+func (ism *InterfaceSetupManager) currentConfig() InterfaceSetupConfig {
+	ism.configMutex.RLock()
+	defer ism.configMutex.RUnlock()
 	return ism.config
 }
 
-// UpdateSetupConfig updates the setup configuration
+// UpdateSetupConfig updates the setup configuration. An invalid configuration is
+// rejected and the current one kept.
 func (ism *InterfaceSetupManager) UpdateSetupConfig(config InterfaceSetupConfig) error {
-	ism.config = config
-	return ism.ValidateSetupConfig()
+	if err := config.validate(); err != nil {
+		return err
+	}
+	ism.configMutex.Lock()
+	defer ism.configMutex.Unlock()
+	ism.config = config.clone()
+	return nil
 }
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ip -details link show output of a controller that is down and of one that is up
+const (
+	ipDetailsDown = `3: can0: <NOARP,ECHO> mtu 16 qdisc pfifo_fast state DOWN mode DEFAULT group default qlen 10
+    link/can  promiscuity 0 minmtu 0 maxmtu 0
+    can state STOPPED restart-ms 0
+	  mcp251x: tseg1 3..16 tseg2 2..8 sjw 1..4 brp 1..64 brp-inc 1
+	  clock 8000000 numtxqueues 1 numrxqueues 1 gso_max_size 65536 gso_max_segs 65535
+`
+	ipDetailsUp = `3: can0: <NOARP,UP,LOWER_UP,ECHO> mtu 16 qdisc pfifo_fast state UP mode DEFAULT group default qlen 10
+    link/can  promiscuity 0 minmtu 0 maxmtu 0
+    can state ERROR-ACTIVE (berr-counter tx 0 rx 0) restart-ms 100
+	  bitrate 500000 sample-point 0.875
+	  tq 125 prop-seg 6 phase-seg1 7 phase-seg2 2 sjw 1 brp 1
+	  mcp251x: tseg1 3..16 tseg2 2..8 sjw 1..4 brp 1..64 brp-inc 1
+	  clock 8000000 numtxqueues 1 numrxqueues 1 gso_max_size 65536 gso_max_segs 65535
+`
+)
+
+// Commands a setup of can0 issues
+const (
+	cmdShow      = "ip link show can0"
+	cmdDetails   = "ip -details link show can0"
+	cmdConfigure = "ip link set can0 mtu 16 type can bitrate 500000 sample-point 0.875 restart-ms 100"
+	cmdUp        = "ip link set can0 up"
+	cmdDown      = "ip link set can0 down"
+)
+
+// newTestSetupManager returns a setup manager for can0 at 500 kbit/s that runs its
+// commands through executor and retries without waiting
+func newTestSetupManager(executor CommandExecutor) *InterfaceSetupManager {
+	config := DefaultInterfaceSetupConfig()
+	config.Bitrate = 500000
+	config.SamplePoint = "0.875"
+	config.RetryDelay = 0
+	return NewInterfaceSetupManager(config, executor, NewLogger(nil))
+}
+
+func TestSetupInterfaceWithRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       func(m *MockCommandExecutor)
+		wantErr      string // Part of the error; "" for success
+		wantCode     ErrorCode
+		wantAttempts int
+		wantUpCalls  int
+	}{
+		{
+			name: "first attempt",
+			script: func(m *MockCommandExecutor) {
+				m.AddResponse(cmdDetails, ipDetailsDown, nil)
+				m.AddResponse(cmdDetails, ipDetailsUp, nil)
+				m.AddResponse(cmdConfigure, "", nil)
+				m.AddResponse(cmdUp, "", nil)
+			},
+			wantAttempts: 1,
+			wantUpCalls:  1,
+		},
+		{
+			name: "bringing the link up fails once",
+			script: func(m *MockCommandExecutor) {
+				m.AddResponse(cmdDetails, ipDetailsDown, nil)
+				m.AddResponse(cmdDetails, ipDetailsDown, nil)
+				m.AddResponse(cmdDetails, ipDetailsUp, nil)
+				m.AddResponse(cmdConfigure, "", nil)
+				m.AddResponse(cmdUp, "RTNETLINK answers: Device or resource busy", errors.New("exit status 2"))
+				m.AddResponse(cmdUp, "", nil)
+			},
+			wantAttempts: 2,
+			wantUpCalls:  2,
+		},
+		{
+			name: "link never comes up",
+			script: func(m *MockCommandExecutor) {
+				m.AddResponse(cmdDetails, ipDetailsDown, nil)
+				m.AddResponse(cmdConfigure, "", nil)
+				m.AddResponse(cmdUp, "", nil)
+			},
+			wantErr:      "failed to setup can0 after 3 attempts: interface can0 verification failed: interface is not up",
+			wantAttempts: 3,
+			wantUpCalls:  3,
+		},
+		{
+			name: "not permitted is not retried",
+			script: func(m *MockCommandExecutor) {
+				m.AddResponse(cmdDetails, ipDetailsDown, nil)
+				m.AddResponse(cmdConfigure, "RTNETLINK answers: Operation not permitted", errors.New("exit status 2"))
+			},
+			wantErr:      ErrPermissionDenied.Error(),
+			wantCode:     CodePermissionDenied,
+			wantAttempts: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewMockCommandExecutor()
+			executor.AddResponse(cmdShow, ipDetailsDown, nil)
+			tt.script(executor)
+			ism := newTestSetupManager(executor)
+
+			err := ism.SetupInterfaceWithRetry("can0")
+			checkError(t, err, tt.wantErr)
+
+			result, ok := ism.GetSetupResult("can0")
+			if !ok {
+				t.Fatal("no setup result recorded")
+			}
+			if result.Succeeded != (err == nil) || result.Attempts != tt.wantAttempts || result.MaxAttempts != 3 {
+				t.Errorf("result = %+v, want %d of 3 attempts, succeeded %t", result, tt.wantAttempts, err == nil)
+			}
+			if got := executor.CallCount(cmdUp); got != tt.wantUpCalls {
+				t.Errorf("%q issued %d times, want %d", cmdUp, got, tt.wantUpCalls)
+			}
+			if tt.wantCode != "" && result.ErrorCode != tt.wantCode {
+				t.Errorf("error code %q, want %q", result.ErrorCode, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestSetupInterfaceAlreadyUp(t *testing.T) {
+	// can0 is up at 500 kbit/s, sampling at 0.875 with 16 time quanta per bit, in no
+	// controller mode
+	tests := []struct {
+		name          string
+		configure     func(config *InterfaceSetupConfig)
+		wantConfigure string // Configuration issued; "" when can0 is left as it is
+	}{
+		{
+			name:      "configured",
+			configure: func(config *InterfaceSetupConfig) {},
+		},
+		{
+			name:      "sample point within half a time quantum",
+			configure: func(config *InterfaceSetupConfig) { config.SamplePoint = "0.85" },
+		},
+		{
+			name:      "no sample point configured",
+			configure: func(config *InterfaceSetupConfig) { config.SamplePoint = "" },
+		},
+		{
+			name:          "wrong sample point",
+			configure:     func(config *InterfaceSetupConfig) { config.SamplePoint = "0.75" },
+			wantConfigure: "ip link set can0 mtu 16 type can bitrate 500000 sample-point 0.75 restart-ms 100",
+		},
+		{
+			name: "wrong sample point of the interface",
+			configure: func(config *InterfaceSetupConfig) {
+				config.InterfaceSamplePoints = map[string]string{"can0": "0.8"}
+			},
+			wantConfigure: "ip link set can0 mtu 16 type can bitrate 500000 sample-point 0.8 restart-ms 100",
+		},
+		{
+			name: "one-shot",
+			configure: func(config *InterfaceSetupConfig) {
+				config.InterfaceOneShot = map[string]bool{"can0": true}
+			},
+			wantConfigure: "ip link set can0 mtu 16 type can bitrate 500000 sample-point 0.875 one-shot on restart-ms 100",
+		},
+		{
+			name: "triple sampling",
+			configure: func(config *InterfaceSetupConfig) {
+				config.InterfaceTripleSampling = map[string]bool{"can0": true}
+			},
+			wantConfigure: "ip link set can0 mtu 16 type can bitrate 500000 sample-point 0.875 triple-sampling on restart-ms 100",
+		},
+		{
+			name: "modes off as configured",
+			configure: func(config *InterfaceSetupConfig) {
+				config.InterfaceTripleSampling = map[string]bool{"can0": false}
+				config.InterfaceOneShot = map[string]bool{"can0": false}
+			},
+		},
+		{
+			name: "modes of another interface",
+			configure: func(config *InterfaceSetupConfig) {
+				config.InterfaceOneShot = map[string]bool{"can1": true}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewMockCommandExecutor()
+			executor.AddResponse(cmdShow, ipDetailsUp, nil)
+			executor.AddResponse(cmdDetails, ipDetailsUp, nil)
+			executor.AddResponse(cmdDown, "", nil)
+			if tt.wantConfigure != "" {
+				executor.AddResponse(tt.wantConfigure, "", nil)
+			}
+			executor.AddResponse(cmdUp, "", nil)
+			ism := newTestSetupManager(executor)
+			config := ism.GetSetupConfig()
+			tt.configure(&config)
+			if err := ism.UpdateSetupConfig(config); err != nil {
+				t.Fatal(err)
+			}
+
+			checkError(t, ism.SetupInterface("can0"), "")
+
+			wantCalls := 0
+			if tt.wantConfigure != "" {
+				wantCalls = 1
+			}
+			for _, command := range []string{cmdDown, tt.wantConfigure, cmdUp} {
+				if got := executor.CallCount(command); command != "" && got != wantCalls {
+					t.Errorf("%q issued %d times, want %d; commands: %q", command, got, wantCalls, executor.Commands())
+				}
+			}
+		})
+	}
+}
+
+// checkError fails the test unless err contains want, or is nil when want is empty
+func checkError(t *testing.T, err error, want string) {
+	t.Helper()
+	switch {
+	case want == "" && err != nil:
+		t.Fatalf("unexpected error: %v", err)
+	case want != "" && err == nil:
+		t.Fatalf("no error, want %q", want)
+	case want != "" && !strings.Contains(err.Error(), want):
+		t.Fatalf("err = %v, want %q", err, want)
+	}
+}
+
+func TestSetupRetryDelay(t *testing.T) {
+	config := InterfaceSetupConfig{RetryBackoff: 2, MaxRetryDelay: 5 * time.Second}
+	for attempt, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		if got := config.retryDelay(time.Second, attempt+1); got != want {
+			t.Errorf("delay after attempt %d = %v, want %v", attempt+1, got, want)
+		}
+	}
+
+	config.RetryBackoff = 0
+	if got := config.retryDelay(time.Second, 4); got != time.Second {
+		t.Errorf("delay without backoff = %v, want 1s", got)
+	}
+}
+
+func TestTeardownInterface(t *testing.T) {
+	tests := []struct {
+		name    string
+		output  string
+		err     error
+		wantErr string
+	}{
+		{name: "down"},
+		{name: "failed", output: "Cannot find device \"can0\"", err: errors.New("exit status 1"), wantErr: "failed to teardown interface: exit status 1"},
+		{name: "not permitted", output: "RTNETLINK answers: Operation not permitted", err: errors.New("exit status 2"), wantErr: ErrPermissionDenied.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := NewMockCommandExecutor()
+			executor.AddResponse(cmdDown, tt.output, tt.err)
+			ism := newTestSetupManager(executor)
+
+			checkError(t, ism.TeardownInterface("can0"), tt.wantErr)
+
+			want := []MockCommandCall{{Command: cmdDown, Timeout: 10 * time.Second}}
+			if calls := executor.Calls(); !reflect.DeepEqual(calls, want) {
+				t.Errorf("calls = %+v, want %+v", calls, want)
+			}
+		})
+	}
+}
+
+func TestParseInterfaceState(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		stats  string // ip -s link show output; "" when it fails
+		want   InterfaceState
+	}{
+		{
+			name:   "down",
+			output: ipDetailsDown,
+			want:   InterfaceState{State: "DOWN", CanState: "STOPPED", MTU: classicCANMTU},
+		},
+		{
+			name:   "up",
+			output: ipDetailsUp,
+			want: InterfaceState{
+				IsUp: true, State: "UP", CanState: "ERROR-ACTIVE", MTU: classicCANMTU,
+				Bitrate: 500000, SamplePoint: 0.875, RestartMs: 100,
+				BitTiming: &BitTiming{TimeQuantumNs: 125, PropSeg: 6, PhaseSeg1: 7, PhaseSeg2: 2, SJW: 1, BRP: 1},
+			},
+		},
+		{
+			name: "error passive with flags, older ip without brp",
+			output: `4: can1: <NOARP,UP,LOWER_UP,ECHO> mtu 16 qdisc pfifo_fast state UP mode DEFAULT group default qlen 10
+    link/can  promiscuity 0
+    can <TRIPLE-SAMPLING,ONE-SHOT> state ERROR-PASSIVE (berr-counter tx 128 rx 7) restart-ms 0
+	  bitrate 250000 sample-point 0.750
+	  tq 250 prop-seg 5 phase-seg1 6 phase-seg2 4 sjw 1
+`,
+			want: InterfaceState{
+				IsUp: true, State: "UP", CanState: "ERROR-PASSIVE", MTU: classicCANMTU,
+				TripleSampling: true, OneShot: true, TxErrorCounter: 128, RxErrorCounter: 7,
+				Bitrate: 250000, SamplePoint: 0.75,
+				BitTiming: &BitTiming{TimeQuantumNs: 250, PropSeg: 5, PhaseSeg1: 6, PhaseSeg2: 4, SJW: 1},
+			},
+		},
+		{
+			name: "bus-off in CAN FD mode",
+			output: `3: can0: <NOARP,UP,LOWER_UP,ECHO> mtu 72 qdisc pfifo_fast state UP mode DEFAULT group default qlen 10
+    link/can  promiscuity 0
+    can <FD> state BUS-OFF (berr-counter tx 248 rx 0) restart-ms 0
+	  bitrate 500000 sample-point 0.800
+	  tq 25 prop-seg 31 phase-seg1 32 phase-seg2 16 sjw 1 brp 1
+	  dbitrate 2000000 dsample-point 0.750
+	  dtq 25 dprop-seg 7 dphase-seg1 7 dphase-seg2 5 dsjw 1 dbrp 1
+`,
+			want: InterfaceState{
+				IsUp: true, State: "UP", CanState: "BUS-OFF", MTU: canFDMTU, TxErrorCounter: 248,
+				Bitrate: 500000, SamplePoint: 0.8,
+				BitTiming: &BitTiming{TimeQuantumNs: 25, PropSeg: 31, PhaseSeg1: 32, PhaseSeg2: 16, SJW: 1, BRP: 1},
+			},
+		},
+		{
+			name: "vcan with error statistics",
+			output: `5: vcan0: <NOARP,UP,LOWER_UP> mtu 72 qdisc noqueue state UNKNOWN mode DEFAULT group default qlen 1000
+    link/can  promiscuity 0 minmtu 0 maxmtu 0
+    vcan numtxqueues 1 numrxqueues 1 gso_max_size 65536 gso_max_segs 65535
+`,
+			stats: `5: vcan0: <NOARP,UP,LOWER_UP> mtu 72 qdisc noqueue state UNKNOWN mode DEFAULT group default qlen 1000
+    link/can
+    RX:  bytes packets errors dropped  missed   mcast
+          1024     128      2       0       0       0
+    TX:  bytes packets errors dropped carrier collsns
+           512      64      1       0       0       0
+`,
+			want: InterfaceState{State: "UNKNOWN", MTU: canFDMTU, RxErrors: 2, TxErrors: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ifName := strings.TrimSuffix(strings.Fields(tt.output)[1], ":")
+			executor := NewMockCommandExecutor()
+			executor.AddResponse("ip -details link show "+ifName, tt.output, nil)
+			if tt.stats != "" {
+				executor.AddResponse("ip -s link show "+ifName, tt.stats, nil)
+			}
+			ism := newTestSetupManager(executor)
+
+			state, err := ism.GetInterfaceState(ifName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.want
+			want.Name, want.Source = ifName, StateSourceIP
+			if !reflect.DeepEqual(*state, want) {
+				t.Errorf("state = %+v\nwant    %+v", *state, want)
+			}
+		})
+	}
+}
@@ -1,20 +1,37 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"sync"
 	"time"
 	"unsafe"
 
 	"golang.org/x/sys/unix"
 )
 
+// Errors returned by InterfaceManager, also used by the components above it
+var (
+	ErrInterfaceNotFound = errors.New("interface not found")
+	ErrInterfaceDown     = errors.New("interface down")
+)
+
+// notConfiguredError reports an interface missing from the configured ports
+func notConfiguredError(ifName string) error {
+	return tagError(ErrInterfaceNotFound, fmt.Errorf("interface %s is not configured", ifName))
+}
+
 // SocketProvider interface for dependency injection
 type SocketProvider interface {
 	CreateSocket() (int, error)
 	GetIfIndex(fd int, ifname string) (int, error)
 	Bind(fd int, addr *unix.SockaddrCAN) error
 	SendTo(fd int, buf []byte, addr *unix.SockaddrCAN) error
+	SendToNoWait(fd int, buf []byte, addr *unix.SockaddrCAN) error
+	WaitWritable(fd int, timeout time.Duration) (bool, error)
+	EnableRecvOwnMsgs(fd int) error
+	SetReceiveBuffer(fd int, size int) (effective int, err error)
+	Recv(fd int, buf []byte, timeout time.Duration) (n int, flags int, err error)
 	Close(fd int) error
 }
 
@@ -52,9 +69,90 @@ func (p *UnixSocketProvider) Bind(fd int, addr *unix.SockaddrCAN) error {
 	return unix.Bind(fd, addr)
 }
 
+// ignoringEINTR retries a syscall interrupted by a signal (EINTR). An interrupted call is
+// not a device error, so it must never reach callers that would fail a health check or
+// stop reading because of it.
+func ignoringEINTR(call func() error) error {
+	for {
+		if err := call(); err != unix.EINTR {
+			return err
+		}
+	}
+}
+
 // SendTo sends data to CAN interface
 func (p *UnixSocketProvider) SendTo(fd int, buf []byte, addr *unix.SockaddrCAN) error {
-	return unix.Sendto(fd, buf, 0, addr)
+	return ignoringEINTR(func() error {
+		return unix.Sendto(fd, buf, 0, addr)
+	})
+}
+
+// SendToNoWait sends data without blocking: a full send buffer fails with EAGAIN
+func (p *UnixSocketProvider) SendToNoWait(fd int, buf []byte, addr *unix.SockaddrCAN) error {
+	return ignoringEINTR(func() error {
+		return unix.Sendto(fd, buf, unix.MSG_DONTWAIT, addr)
+	})
+}
+
+// WaitWritable waits at most timeout for the socket's send buffer to have room and
+// reports whether it has
+func (p *UnixSocketProvider) WaitWritable(fd int, timeout time.Duration) (bool, error) {
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLOUT}}
+	ts := unix.NsecToTimespec(timeout.Nanoseconds())
+	var n int
+	err := ignoringEINTR(func() error {
+		var err error
+		n, err = unix.Ppoll(fds, &ts, nil)
+		return err
+	})
+	return n > 0 && fds[0].Revents&unix.POLLOUT != 0, err
+}
+
+// EnableRecvOwnMsgs makes the socket receive the loopback echo of its own frames
+func (p *UnixSocketProvider) EnableRecvOwnMsgs(fd int) error {
+	return unix.SetsockoptInt(fd, unix.SOL_CAN_RAW, unix.CAN_RAW_RECV_OWN_MSGS, 1)
+}
+
+// SetReceiveBuffer sets the socket receive buffer size and returns the size the kernel
+// applied. SO_RCVBUFFORCE is tried first so net.core.rmem_max does not clamp the size
+// when running with CAP_NET_ADMIN. The kernel reports twice the requested size, as it
+// reserves half for bookkeeping.
+func (p *UnixSocketProvider) SetReceiveBuffer(fd int, size int) (int, error) {
+	if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RCVBUFFORCE, size); err != nil {
+		if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RCVBUF, size); err != nil {
+			return 0, err
+		}
+	}
+	return unix.GetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RCVBUF)
+}
+
+// applyReceiveBuffer sets the configured receive buffer size of a socket and logs the
+// effective size. Failing to resize is not fatal: the socket keeps the kernel default.
+func applyReceiveBuffer(provider SocketProvider, fd int, ifName string, size int, logger Logger) {
+	if size <= 0 {
+		return
+	}
+	effective, err := provider.SetReceiveBuffer(fd, size)
+	if err != nil {
+		logger.Printf("⚠️ Warning: failed to set %s receive buffer to %d bytes: %v", ifName, size, err)
+		return
+	}
+	logger.Printf("📥 %s receive buffer: requested %d bytes, effective %d bytes", ifName, size, effective)
+}
+
+// Recv reads a frame, waiting at most timeout, and returns the message flags
+func (p *UnixSocketProvider) Recv(fd int, buf []byte, timeout time.Duration) (int, int, error) {
+	tv := unix.NsecToTimeval(timeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		return 0, 0, err
+	}
+	var n, flags int
+	err := ignoringEINTR(func() error {
+		var err error
+		n, _, flags, _, err = unix.Recvmsg(fd, buf, nil, 0)
+		return err
+	})
+	return n, flags, err
 }
 
 // Close closes the socket
@@ -64,22 +162,12 @@ func (p *UnixSocketProvider) Close(fd int) error {
 
 // InterfaceManager manages CAN interfaces
 type InterfaceManager struct {
-	interfaces     map[string]*CanInterface
+	interfaces     map[string]*CanInterface // Guarded by mu: reloads and recovery change it at runtime
+	mu             sync.RWMutex
 	configProvider ConfigProvider
 	socketProvider SocketProvider
 	logger         Logger
-}
-
-// Logger interface for dependency injection
-type Logger interface {
-	Printf(format string, v ...interface{})
-}
-
-// DefaultLogger implements Logger using standard log package
-type DefaultLogger struct{}
-
-func (l *DefaultLogger) Printf(format string, v ...interface{}) {
-	log.Printf(format, v...)
+	sender         *MessageSender // Writes health probes through the TX workers; set by NewMessageSender
 }
 
 // NewInterfaceManager creates a new interface manager
@@ -125,20 +213,24 @@ func (im *InterfaceManager) InitializeSingle(ifName string) error {
 	retries := 5
 	retryDelay := 2 * time.Second
 
+	var lastErr error
 	for i := 0; i < retries; i++ {
 		canIf, err := im.createInterface(ifName)
 		if err == nil {
+			im.mu.Lock()
 			im.interfaces[ifName] = canIf
+			im.mu.Unlock()
 			im.logger.Printf("✅ %s initialization successful", ifName)
 			return nil
 		}
 
 		im.logger.Printf("⚠️ %s initialization attempt %d failed: %v. Retrying in %v...",
 			ifName, i+1, err, retryDelay)
+		lastErr = err
 		time.Sleep(retryDelay)
 	}
 
-	return fmt.Errorf("failed to initialize %s after %d attempts", ifName, retries)
+	return fmt.Errorf("failed to initialize %s after %d attempts: %w", ifName, retries, lastErr)
 }
 
 // createInterface creates a single CAN interface
@@ -153,7 +245,7 @@ func (im *InterfaceManager) createInterface(ifName string) (*CanInterface, error
 	ifindex, err := im.socketProvider.GetIfIndex(fd, ifName)
 	if err != nil {
 		im.socketProvider.Close(fd)
-		return nil, fmt.Errorf("failed to get interface index: %w", err)
+		return nil, tagError(ErrInterfaceNotFound, fmt.Errorf("failed to get interface index: %w", err))
 	}
 
 	// Bind to CAN interface
@@ -163,19 +255,37 @@ func (im *InterfaceManager) createInterface(ifName string) (*CanInterface, error
 		return nil, fmt.Errorf("failed to bind to interface: %w", err)
 	}
 
+	// The send socket also receives loopback echoes for transmit confirmation
+	applyReceiveBuffer(im.socketProvider, fd, ifName, im.configProvider.GetReceiveBufferSize(ifName), im.logger)
+
 	// Create interface struct
 	canIf := NewCanInterface(ifName, fd, addr)
+
+	// Track loopback echoes to confirm transmitted frames
+	if im.configProvider.GetTxConfirmTimeout() > 0 {
+		echo, err := newTxEchoTracker(ifName, fd, im.socketProvider, im.logger)
+		if err != nil {
+			im.logger.Printf("⚠️ %s transmit confirmation unavailable: %v", ifName, err)
+		} else {
+			canIf.echo = echo
+		}
+	}
+
 	return canIf, nil
 }
 
 // GetInterface returns a CAN interface by name
 func (im *InterfaceManager) GetInterface(name string) (*CanInterface, bool) {
+	im.mu.RLock()
+	defer im.mu.RUnlock()
 	canIf, ok := im.interfaces[name]
 	return canIf, ok
 }
 
 // GetAllInterfaces returns all interfaces
 func (im *InterfaceManager) GetAllInterfaces() map[string]*CanInterface {
+	im.mu.RLock()
+	defer im.mu.RUnlock()
 	result := make(map[string]*CanInterface)
 	for k, v := range im.interfaces {
 		result[k] = v
@@ -185,9 +295,19 @@ func (im *InterfaceManager) GetAllInterfaces() map[string]*CanInterface {
 
 // RemoveInterface removes an interface from the manager
 func (im *InterfaceManager) RemoveInterface(name string) error {
+	// Out of the map first, so no new send finds it while its worker stops
+	im.mu.Lock()
 	canIf, ok := im.interfaces[name]
+	delete(im.interfaces, name)
+	im.mu.Unlock()
 	if !ok {
-		return fmt.Errorf("interface %s not found", name)
+		return tagError(ErrInterfaceNotFound, fmt.Errorf("interface %s not found", name))
+	}
+
+	// Stop writing and echo tracking before the socket goes away
+	canIf.txQueue.stop()
+	if canIf.echo != nil {
+		canIf.echo.stop()
 	}
 
 	// Close the socket
@@ -195,45 +315,37 @@ func (im *InterfaceManager) RemoveInterface(name string) error {
 	if err != nil {
 		im.logger.Printf("Warning: failed to close socket for %s: %v", name, err)
 	}
-
-	// Remove from map
-	delete(im.interfaces, name)
 	return nil
 }
 
 // Cleanup closes all interfaces
 func (im *InterfaceManager) Cleanup() {
 	im.logger.Printf("🧹 Cleaning up CAN interfaces...")
-	for name, canIf := range im.interfaces {
+	im.mu.Lock()
+	interfaces := im.interfaces
+	im.interfaces = make(map[string]*CanInterface)
+	im.mu.Unlock()
+	for name, canIf := range interfaces {
+		canIf.txQueue.stop()
+		if canIf.echo != nil {
+			canIf.echo.stop()
+		}
 		err := im.socketProvider.Close(canIf.FD)
 		if err != nil {
 			im.logger.Printf("Warning: failed to close %s: %v", name, err)
 		}
 	}
-	im.interfaces = make(map[string]*CanInterface)
 }
 
-// CheckHealth performs a health check on an interface
+// CheckHealth performs a health check on an interface: a probe frame written by its TX
+// worker, as every send is
 func (im *InterfaceManager) CheckHealth(ifName string) bool {
-	canIf, ok := im.interfaces[ifName]
-	if !ok {
+	canIf, ok := im.GetInterface(ifName)
+	if !ok || im.sender == nil {
 		return false
 	}
 
-	canIf.Lock()
-	defer canIf.Unlock()
-
-	// Simple probe message (0x00 is typically a diagnostic/echo ID)
-	frame := CanFrame{
-		ID:     0x00,
-		Length: 1,
-		Data:   [8]byte{0x00},
-	}
-
-	buf := (*[16]byte)(unsafe.Pointer(&frame))[:]
-	err := im.socketProvider.SendTo(canIf.FD, buf, canIf.Addr)
-
-	if err != nil {
+	if err := im.sender.probe(canIf); err != nil {
 		im.logger.Printf("⚠️ %s health check failed: %v", ifName, err)
 		return false
 	}
@@ -243,11 +355,18 @@ func (im *InterfaceManager) CheckHealth(ifName string) bool {
 
 // GetInterfaceCount returns the number of active interfaces
 func (im *InterfaceManager) GetInterfaceCount() int {
+	im.mu.RLock()
+	defer im.mu.RUnlock()
 	return len(im.interfaces)
 }
 
 // IsInterfaceActive checks if an interface is active
 func (im *InterfaceManager) IsInterfaceActive(name string) bool {
-	_, ok := im.interfaces[name]
+	_, ok := im.GetInterface(name)
 	return ok
 }
+
+// IsConfigured checks if an interface is part of the configured ports
+func (im *InterfaceManager) IsConfigured(name string) bool {
+	return im.configProvider.ValidateInterface(name)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseNetwork parses a CIDR ("10.20.0.0/16", "fd00::/8") or a single address, which
+// becomes a host prefix. IPv4-mapped IPv6 addresses are treated as IPv4.
+func parseNetwork(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid network %q: %w", value, err)
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid address %q: %w", value, err)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// networksContain reports whether any of the networks contains addr
+func networksContain(networks []netip.Prefix, addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, network := range networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IPAllowlist admits requests whose client address lies in one of its networks
+type IPAllowlist struct {
+	allowed        []netip.Prefix
+	trustedProxies []netip.Prefix // Peers whose X-Forwarded-For is believed
+}
+
+// NewIPAllowlist creates an allowlist. Without trusted proxies X-Forwarded-For is
+// ignored and the TCP peer is the client.
+func NewIPAllowlist(allowed, trustedProxies []netip.Prefix) *IPAllowlist {
+	return &IPAllowlist{allowed: allowed, trustedProxies: trustedProxies}
+}
+
+// ClientAddr returns the address of the client behind a request. Only a trusted proxy
+// peer makes X-Forwarded-For count: its entries are walked from the right, each one
+// appended by the hop before, and the first address that is not a trusted proxy is the
+// client. A malformed entry stops the walk at the last trusted hop, since nothing left
+// of it can be verified.
+func (a *IPAllowlist) ClientAddr(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	client, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	client = client.WithZone("").Unmap()
+
+	if !networksContain(a.trustedProxies, client) {
+		return client, true
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			return client, true
+		}
+		client = hop.WithZone("").Unmap()
+		if !networksContain(a.trustedProxies, client) {
+			return client, true
+		}
+	}
+	return client, true
+}
+
+// Allowed reports whether a client address lies in an allowed network
+func (a *IPAllowlist) Allowed(addr netip.Addr) bool {
+	return networksContain(a.allowed, addr)
+}
+
+// IPAllowlistMiddleware rejects requests from clients outside the allowed networks
+// before any authentication runs. Requests on the Unix socket have no address; its file
+// permissions guard it instead.
+func IPAllowlistMiddleware(allowlist *IPAllowlist, logger Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if fromUnixSocket(c.Request) {
+			c.Next()
+			return
+		}
+		client, ok := allowlist.ClientAddr(c.Request)
+		if !ok || !allowlist.Allowed(client) {
+			logger.Warnf("🚫 Denied %s %s from %s (peer %s): not in allowed networks",
+				c.Request.Method, c.Request.URL.Path, client, c.Request.RemoteAddr)
+			abortWithError(c, http.StatusForbidden, CodeForbidden, "Access denied: client address is not allowed")
+			return
+		}
+
+		c.Next()
+	}
+}
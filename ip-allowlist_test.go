@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestIPAllowlistClientAddr(t *testing.T) {
+	allowlist := NewIPAllowlist(nil, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("::1/128"),
+	})
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string // X-Forwarded-For header lines
+		want         string
+		wantUnparsed bool
+	}{
+		{name: "direct client", remoteAddr: "203.0.113.7:40000", want: "203.0.113.7"},
+		{name: "spoofed header from an untrusted peer", remoteAddr: "203.0.113.7:40000", forwardedFor: []string{"192.168.1.10"}, want: "203.0.113.7"},
+		{name: "spoofed trusted address from an untrusted peer", remoteAddr: "203.0.113.7:40000", forwardedFor: []string{"10.0.0.5"}, want: "203.0.113.7"},
+		{name: "trusted proxy without header", remoteAddr: "10.0.0.1:40000", want: "10.0.0.1"},
+		{name: "one trusted proxy", remoteAddr: "10.0.0.1:40000", forwardedFor: []string{"198.51.100.4"}, want: "198.51.100.4"},
+		{name: "chain of trusted proxies", remoteAddr: "10.0.0.1:40000", forwardedFor: []string{"198.51.100.4, 10.1.2.3, 10.0.0.2"}, want: "198.51.100.4"},
+		{name: "chain across header lines", remoteAddr: "10.0.0.1:40000", forwardedFor: []string{"198.51.100.4", "10.1.2.3"}, want: "198.51.100.4"},
+		{name: "client prepends a forged hop", remoteAddr: "10.0.0.1:40000", forwardedFor: []string{"192.0.2.99, 198.51.100.4, 10.1.2.3"}, want: "198.51.100.4"},
+		{name: "only trusted hops", remoteAddr: "10.0.0.1:40000", forwardedFor: []string{"10.1.2.3, 10.2.3.4"}, want: "10.1.2.3"},
+		{name: "malformed header", remoteAddr: "10.0.0.1:40000", forwardedFor: []string{"not-an-address"}, want: "10.0.0.1"},
+		{name: "malformed hop behind trusted ones", remoteAddr: "10.0.0.1:40000", forwardedFor: []string{"198.51.100.4, bogus, 10.1.2.3"}, want: "10.1.2.3"},
+		{name: "hop with a port", remoteAddr: "10.0.0.1:40000", forwardedFor: []string{"198.51.100.4:5555"}, want: "10.0.0.1"},
+		{name: "empty header", remoteAddr: "10.0.0.1:40000", forwardedFor: []string{""}, want: "10.0.0.1"},
+		{name: "IPv6 proxy and client", remoteAddr: "[::1]:40000", forwardedFor: []string{"2001:db8::1"}, want: "2001:db8::1"},
+		{name: "IPv4-mapped peer", remoteAddr: "[::ffff:203.0.113.7]:40000", want: "203.0.113.7"},
+		{name: "IPv4-mapped hop", remoteAddr: "10.0.0.1:40000", forwardedFor: []string{"::ffff:198.51.100.4"}, want: "198.51.100.4"},
+		{name: "zoned peer", remoteAddr: "[fe80::1%eth0]:40000", want: "fe80::1"},
+		{name: "peer without port", remoteAddr: "203.0.113.7", want: "203.0.113.7"},
+		{name: "unparsable peer", remoteAddr: "@", wantUnparsed: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwardedFor {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+
+			got, ok := allowlist.ClientAddr(r)
+			if ok == tt.wantUnparsed {
+				t.Fatalf("ClientAddr() = %v, %t", got, ok)
+			}
+			if !tt.wantUnparsed && got != netip.MustParseAddr(tt.want) {
+				t.Errorf("ClientAddr() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPAllowlistWithoutTrustedProxies(t *testing.T) {
+	allowlist := NewIPAllowlist([]netip.Prefix{netip.MustParsePrefix("192.168.0.0/16")}, nil)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.7:40000"
+	r.Header.Set("X-Forwarded-For", "192.168.1.10")
+	client, ok := allowlist.ClientAddr(r)
+	if !ok || client != netip.MustParseAddr("203.0.113.7") {
+		t.Fatalf("ClientAddr() = %v, %t, want the peer", client, ok)
+	}
+	if allowlist.Allowed(client) {
+		t.Errorf("%v is allowed through a forged X-Forwarded-For", client)
+	}
+	if !allowlist.Allowed(netip.MustParseAddr("::ffff:192.168.1.10")) {
+		t.Error("IPv4-mapped address of an allowed network is not allowed")
+	}
+}
@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// IPC message types. Every message is a 4-byte big-endian length followed by the type
+// byte and its fields; integers are big-endian, strings are prefixed by a length byte.
+const (
+	ipcMsgSend        byte = 0x01 // seq u32, flags u8, id u32, dlc u8, interface, data (rest)
+	ipcMsgSubscribe   byte = 0x02 // interface; empty subscribes to every interface
+	ipcMsgUnsubscribe byte = 0x03 // no fields
+	ipcMsgSendResult  byte = 0x81 // seq u32, status u8, flags u8, sentAt i64 (Unix ns), code, message (rest)
+	ipcMsgFrame       byte = 0x82 // timestamp i64 (Unix ns), id u32, flags u8, dlc u8, interface, data (rest)
+	ipcMsgError       byte = 0xFF // message (rest); the server closes the connection after it
+)
+
+// IPC message flags
+const (
+	ipcFlagRTR       byte = 0x01 // Send and frame: remote transmission request
+	ipcFlagDryRun    byte = 0x02 // Send: validate and log without writing
+	ipcFlagConfirmed byte = 0x01 // Send result: frame was echoed back after transmission
+)
+
+// IPC send result statuses
+const (
+	ipcStatusOK    byte = 0
+	ipcStatusError byte = 1
+)
+
+// IPC connection limits
+const (
+	maxIPCMessageSize = 512             // Longest message a client may send
+	ipcQueueSize      = 1024            // Messages queued per connection before frames are dropped
+	ipcWriteTimeout   = 5 * time.Second // A client not reading for this long is disconnected
+)
+
+// IPCStats reports the traffic of the IPC socket
+type IPCStats struct {
+	Enabled         bool   `json:"enabled"`
+	Connections     int    `json:"connections"`
+	Accepted        uint64 `json:"accepted"`
+	Sends           uint64 `json:"sends"`
+	SendErrors      uint64 `json:"sendErrors"`
+	FramesDelivered uint64 `json:"framesDelivered"`
+	FramesDropped   uint64 `json:"framesDropped"` // Received frames a slow subscriber missed
+}
+
+// ipcConn is a client connection. Only the reader goroutine queues send results; frames
+// are queued by the listener goroutines while the connection is registered.
+type ipcConn struct {
+	conn         net.Conn
+	out          chan []byte
+	subscription atomic.Pointer[string] // Subscribed interface, "" for all; nil when not subscribed
+}
+
+// IPCServer serves a length-prefixed binary protocol on a Unix socket for local clients
+// that send and receive too many frames for HTTP. Sends go through the MessageSender like
+// API sends; received frames come from the message listener. The socket's file
+// permissions are its only access control.
+type IPCServer struct {
+	sender   *MessageSender
+	logger   Logger
+	path     string
+	listener net.Listener
+	mu       sync.RWMutex
+	conns    map[*ipcConn]struct{}
+	stopped  bool
+	wg       sync.WaitGroup
+
+	accepted        atomic.Uint64
+	sends           atomic.Uint64
+	sendErrors      atomic.Uint64
+	framesDelivered atomic.Uint64
+	framesDropped   atomic.Uint64
+}
+
+// NewIPCServer creates an IPC server sending through sender
+func NewIPCServer(sender *MessageSender, logger Logger) *IPCServer {
+	return &IPCServer{
+		sender: sender,
+		logger: logger,
+		conns:  make(map[*ipcConn]struct{}),
+	}
+}
+
+// Start creates the socket at path and accepts connections
+func (s *IPCServer) Start(path string, mode os.FileMode, owner string) error {
+	listener, err := listenUnixSocket(path, mode, owner)
+	if err != nil {
+		return err
+	}
+	s.path, s.listener = path, listener
+
+	s.logger.Printf("🔗 IPC socket listening on %s (mode %04o)", path, mode)
+	s.wg.Add(1)
+	go s.acceptLoop()
+	return nil
+}
+
+// Stop closes the socket and every connection, then removes the socket file
+func (s *IPCServer) Stop() {
+	if s == nil || s.listener == nil {
+		return
+	}
+	s.listener.Close()
+
+	s.mu.Lock()
+	s.stopped = true
+	for c := range s.conns {
+		c.conn.Close()
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.logger.Printf("Warning: failed to remove IPC socket %s: %v", s.path, err)
+	}
+}
+
+// GetStats returns the IPC counters. A nil server reports it is disabled.
+func (s *IPCServer) GetStats() IPCStats {
+	if s == nil {
+		return IPCStats{}
+	}
+	s.mu.RLock()
+	connections := len(s.conns)
+	s.mu.RUnlock()
+
+	return IPCStats{
+		Enabled:         true,
+		Connections:     connections,
+		Accepted:        s.accepted.Load(),
+		Sends:           s.sends.Load(),
+		SendErrors:      s.sendErrors.Load(),
+		FramesDelivered: s.framesDelivered.Load(),
+		FramesDropped:   s.framesDropped.Load(),
+	}
+}
+
+// ObserveFrame passes a received frame to the subscribed connections. It never blocks
+// the listener: a connection whose queue is full misses the frame.
+func (s *IPCServer) ObserveFrame(msg CanMessageLog) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var encoded []byte
+	for c := range s.conns {
+		subscription := c.subscription.Load()
+		if subscription == nil || (*subscription != "" && *subscription != msg.Interface) {
+			continue
+		}
+		if encoded == nil {
+			encoded = encodeIPCFrame(msg)
+		}
+		select {
+		case c.out <- encoded:
+			s.framesDelivered.Add(1)
+		default:
+			s.framesDropped.Add(1)
+		}
+	}
+}
+
+// acceptLoop accepts connections until the socket is closed
+func (s *IPCServer) acceptLoop() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.logger.Printf("❌ IPC socket accept error: %v", err)
+			}
+			return
+		}
+
+		c := &ipcConn{conn: conn, out: make(chan []byte, ipcQueueSize)}
+		s.mu.Lock()
+		if s.stopped {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[c] = struct{}{}
+		s.mu.Unlock()
+		s.accepted.Add(1)
+
+		s.wg.Add(2)
+		go s.writeLoop(c)
+		go s.readLoop(c)
+	}
+}
+
+// readLoop handles the requests of a connection in order. When it ends, the connection
+// is unregistered so no more frames are queued, and the writer finishes.
+func (s *IPCServer) readLoop(c *ipcConn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		close(c.out)
+	}()
+
+	reader := bufio.NewReader(c.conn)
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(reader, header); err != nil {
+			return
+		}
+		length := binary.BigEndian.Uint32(header)
+		if length == 0 || length > maxIPCMessageSize {
+			c.out <- encodeIPCError(fmt.Sprintf("message length %d outside 1..%d", length, maxIPCMessageSize))
+			return
+		}
+		body := make([]byte, length)
+		if _, err := io.ReadFull(reader, body); err != nil {
+			return
+		}
+
+		if err := s.handleMessage(c, body); err != nil {
+			c.out <- encodeIPCError(err.Error())
+			return
+		}
+	}
+}
+
+// writeLoop writes queued messages, flushing whenever the queue runs empty. After a
+// failed write it closes the connection and discards the rest.
+func (s *IPCServer) writeLoop(c *ipcConn) {
+	defer s.wg.Done()
+	defer c.conn.Close()
+
+	writer := bufio.NewWriter(c.conn)
+	header := make([]byte, 4)
+	failed := false
+	for message := range c.out {
+		if failed {
+			continue
+		}
+		binary.BigEndian.PutUint32(header, uint32(len(message)))
+		writer.Write(header)
+		writer.Write(message)
+
+		if len(c.out) > 0 {
+			continue
+		}
+		c.conn.SetWriteDeadline(time.Now().Add(ipcWriteTimeout))
+		if err := writer.Flush(); err != nil {
+			failed = true
+			c.conn.Close()
+		}
+	}
+}
+
+// handleMessage executes one client message. An error is a protocol violation that
+// ends the connection.
+func (s *IPCServer) handleMessage(c *ipcConn, body []byte) error {
+	fields := body[1:]
+	switch body[0] {
+	case ipcMsgSend:
+		return s.handleSend(c, fields)
+	case ipcMsgSubscribe:
+		ifName, _, ok := readIPCString(fields)
+		if !ok {
+			return fmt.Errorf("truncated subscribe message")
+		}
+		c.subscription.Store(&ifName)
+		return nil
+	case ipcMsgUnsubscribe:
+		c.subscription.Store(nil)
+		return nil
+	}
+	return fmt.Errorf("unknown message type 0x%02X", body[0])
+}
+
+// handleSend sends a frame and queues its result. Send failures are results, not
+// protocol errors.
+func (s *IPCServer) handleSend(c *ipcConn, fields []byte) error {
+	if len(fields) < 10 {
+		return fmt.Errorf("truncated send message")
+	}
+	seq := binary.BigEndian.Uint32(fields[0:4])
+	flags := fields[4]
+	id := binary.BigEndian.Uint32(fields[5:9])
+	dlc := fields[9]
+	ifName, data, ok := readIPCString(fields[10:])
+	if !ok {
+		return fmt.Errorf("truncated send message")
+	}
+
+	msg := CanMessage{
+		Interface:  ifName,
+		ID:         id,
+		Data:       append([]byte(nil), data...),
+		RTR:        flags&ipcFlagRTR != 0,
+		DryRun:     flags&ipcFlagDryRun != 0,
+		client:     "ipc",
+		remoteAddr: "unix:" + s.path,
+	}
+	if msg.RTR {
+		msg.Length = dlc
+	}
+
+	s.sends.Add(1)
+	result, err := s.send(msg)
+	if err != nil {
+		s.sendErrors.Add(1)
+	}
+	c.out <- encodeIPCSendResult(seq, result, err)
+	return nil
+}
+
+// send validates and sends a frame the way the API does
+func (s *IPCServer) send(msg CanMessage) (*SendResult, error) {
+	if msg.ID > unix.CAN_EFF_MASK {
+		return nil, tagError(ErrInvalidID, fmt.Errorf("CAN ID 0x%X exceeds 29 bits", msg.ID))
+	}
+	if err := s.sender.ValidateMessage(msg); err != nil {
+		return nil, err
+	}
+	return s.sender.SendCanMessage(msg)
+}
+
+// readIPCString reads a length-prefixed string and returns it with the bytes after it
+func readIPCString(fields []byte) (string, []byte, bool) {
+	if len(fields) < 1 || len(fields) < 1+int(fields[0]) {
+		return "", nil, false
+	}
+	end := 1 + int(fields[0])
+	return string(fields[1:end]), fields[end:], true
+}
+
+// appendIPCString appends a length-prefixed string, cut to 255 bytes
+func appendIPCString(buf []byte, value string) []byte {
+	if len(value) > 255 {
+		value = value[:255]
+	}
+	buf = append(buf, byte(len(value)))
+	return append(buf, value...)
+}
+
+// encodeIPCFrame encodes a received frame
+func encodeIPCFrame(msg CanMessageLog) []byte {
+	var flags byte
+	if msg.RTR {
+		flags |= ipcFlagRTR
+	}
+	buf := make([]byte, 0, 17+len(msg.Interface)+len(msg.Data))
+	buf = append(buf, ipcMsgFrame)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(msg.Timestamp.UnixNano()))
+	buf = binary.BigEndian.AppendUint32(buf, msg.ID)
+	buf = append(buf, flags, msg.Length)
+	buf = appendIPCString(buf, msg.Interface)
+	return append(buf, msg.Data...)
+}
+
+// encodeIPCSendResult encodes the outcome of a send
+func encodeIPCSendResult(seq uint32, result *SendResult, err error) []byte {
+	buf := []byte{ipcMsgSendResult}
+	buf = binary.BigEndian.AppendUint32(buf, seq)
+	if err != nil {
+		buf = append(buf, ipcStatusError, 0)
+		buf = binary.BigEndian.AppendUint64(buf, 0)
+		buf = appendIPCString(buf, string(errorCode(err)))
+		return append(buf, err.Error()...)
+	}
+
+	var flags byte
+	var sentAt int64
+	if result.Confirmed {
+		flags |= ipcFlagConfirmed
+	}
+	if !result.SentAt.IsZero() {
+		sentAt = result.SentAt.UnixNano()
+	}
+	buf = append(buf, ipcStatusOK, flags)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(sentAt))
+	return appendIPCString(buf, "")
+}
+
+// encodeIPCError encodes a protocol error
+func encodeIPCError(message string) []byte {
+	return append([]byte{ipcMsgError}, message...)
+}
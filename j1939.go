@@ -0,0 +1,565 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// J1939 socket options and control messages of linux/can/j1939.h, which x/sys/unix
+// does not define
+const (
+	solCANJ1939      = unix.SOL_CAN_BASE + unix.CAN_J1939
+	soJ1939Promisc   = 2
+	soJ1939SendPrio  = 3
+	scmJ1939DestAddr = 1
+	scmJ1939Prio     = 3
+
+	j1939NoName = 0       // Static addressing: no NAME, no address claiming
+	j1939NoPGN  = 0x40000 // Bind without a PGN, receiving and sending every PGN
+)
+
+// J1939 limits and defaults
+const (
+	J1939MaxPGN          = 0x3FFFF
+	J1939GlobalAddress   = 0xFF // Destination of broadcasts
+	J1939NullAddress     = 0xFE // Source of nodes without an address
+	J1939DefaultPriority = 6
+	J1939MaxPriority     = 7
+	J1939MaxDataLength   = 1785 // Largest message of the transport protocol (TP)
+
+	j1939PDU1FormatLimit = 240 // PDU formats below are destination specific (PDU1)
+	j1939SendTimeout     = 5 * time.Second
+)
+
+// J1939Message is a parameter group received or sent on a J1939 interface. The
+// kernel reassembles and segments transport protocol messages, so Data holds the whole
+// payload.
+type J1939Message struct {
+	Interface       string    `json:"interface"`
+	PGN             uint32    `json:"pgn"`
+	Source          uint8     `json:"source"`
+	Destination     uint8     `json:"destination"` // 255 for broadcasts
+	Priority        uint8     `json:"priority"`
+	Data            []byte    `json:"data"`
+	Length          int       `json:"length"`
+	Timestamp       time.Time `json:"timestamp"`
+	TimestampSource string    `json:"timestampSource,omitempty"` // "hardware", "kernel" or "software"
+	Direction       string    `json:"direction"`                 // "RX" or "TX"
+
+	HEX_PGN  string   `json:"hex_pgn"`  // Hexadecimal representation of PGN
+	HEX_Data []string `json:"hex_data"` // Hexadecimal representation of data
+}
+
+// J1939SendRequest sends a parameter group from the local address of an interface
+type J1939SendRequest struct {
+	PGN         uint32    `json:"pgn" binding:"max=262143"`
+	Destination *uint8    `json:"destination,omitempty"` // Default 255 (broadcast); PDU1 PGNs only
+	Priority    *uint8    `json:"priority,omitempty" binding:"omitempty,max=7"`
+	Data        FrameData `json:"data"`                                                               // Hex string, base64 string or array of byte values
+	Encoding    string    `json:"encoding,omitempty" binding:"omitempty,oneof=auto hex base64 bytes"` // Encoding of data; detected when empty
+	DataHex     string    `json:"dataHex,omitempty"`                                                  // Payload as hex bytes, e.g. "02 10 01"; alternative to data
+	DryRun      bool      `json:"dryRun,omitempty"`
+}
+
+// decodeBody decodes data in the encoding the request names
+func (req *J1939SendRequest) decodeBody(body []byte) []FieldError {
+	return decodeDataEncoding(body, req.Encoding, &req.Data)
+}
+
+// J1939SendResult describes a sent parameter group
+type J1939SendResult struct {
+	J1939Message
+	DryRun    bool   `json:"dryRun"`
+	RequestID string `json:"requestId,omitempty"` // ID of the API request that sent the message
+}
+
+// J1939Filter selects received messages; nil fields match everything
+type J1939Filter struct {
+	PGN         *uint32
+	Source      *uint8
+	Destination *uint8
+}
+
+// ParseJ1939Filter parses the pgn, source and destination query parameters, decimal or
+// 0x-prefixed hex; empty ones match everything
+func ParseJ1939Filter(pgn, source, destination string) (J1939Filter, error) {
+	var filter J1939Filter
+	if pgn != "" {
+		value, err := strconv.ParseUint(pgn, 0, 32)
+		if err != nil || value > J1939MaxPGN {
+			return filter, tagError(ErrValidation, fmt.Errorf("invalid pgn %q: expected 0 to 0x%X", pgn, J1939MaxPGN))
+		}
+		p := uint32(value)
+		filter.PGN = &p
+	}
+	parseAddress := func(name, raw string) (*uint8, error) {
+		if raw == "" {
+			return nil, nil
+		}
+		value, err := strconv.ParseUint(raw, 0, 8)
+		if err != nil {
+			return nil, tagError(ErrValidation, fmt.Errorf("invalid %s %q: expected an address from 0 to 0xFF", name, raw))
+		}
+		address := uint8(value)
+		return &address, nil
+	}
+	var err error
+	if filter.Source, err = parseAddress("source", source); err != nil {
+		return filter, err
+	}
+	if filter.Destination, err = parseAddress("destination", destination); err != nil {
+		return filter, err
+	}
+	return filter, nil
+}
+
+func (f J1939Filter) matches(msg J1939Message) bool {
+	return (f.PGN == nil || *f.PGN == msg.PGN) &&
+		(f.Source == nil || *f.Source == msg.Source) &&
+		(f.Destination == nil || *f.Destination == msg.Destination)
+}
+
+// J1939InterfaceStatus reports the J1939 socket of an interface and its counters
+type J1939InterfaceStatus struct {
+	Address    uint8     `json:"address"` // Local source address
+	Open       bool      `json:"open"`
+	OpenSince  time.Time `json:"openSince"`
+	Error      string    `json:"error,omitempty"` // Why the socket is not open
+	Received   uint64    `json:"received"`
+	Sent       uint64    `json:"sent"`
+	SendErrors uint64    `json:"sendErrors"`
+	Truncated  uint64    `json:"truncated"` // Messages longer than the TP limit, cut off
+	Buffered   int       `json:"buffered"`
+}
+
+// J1939Manager runs a J1939 socket on each interface in J1939 mode, next to the raw
+// socket of the listener. The kernel stack handles the transport protocol, so messages
+// up to 1785 bytes are sent and received whole. Sockets use the static address of their
+// interface and are promiscuous, receiving traffic between other nodes too. A nil
+// J1939Manager is valid and has no interfaces.
+type J1939Manager struct {
+	sender      *MessageSender // Transmission gate, dry run and write error classification
+	maxMessages int
+	logger      Logger
+	stopOnce    sync.Once
+	wg          sync.WaitGroup
+
+	interfaces map[string]*j1939Interface // Fixed at creation
+}
+
+// j1939Interface is the J1939 socket of one interface
+type j1939Interface struct {
+	name    string
+	address uint8
+
+	mu        sync.Mutex // Guards the socket state; held while sending, as priority is a socket option
+	socket    int        // -1 while closed
+	closed    chan struct{}
+	ifIndex   int
+	openSince time.Time
+	openErr   error
+
+	bufferMu sync.RWMutex
+	messages []J1939Message // Received messages, oldest first
+
+	received   atomic.Uint64
+	sent       atomic.Uint64
+	sendErrors atomic.Uint64
+	truncated  atomic.Uint64
+}
+
+// NewJ1939Manager creates the J1939 interfaces with their local addresses. Sockets are
+// opened by Start.
+func NewJ1939Manager(addresses map[string]uint8, sender *MessageSender, maxMessages int, logger Logger) *J1939Manager {
+	m := &J1939Manager{
+		sender:      sender,
+		maxMessages: maxMessages,
+		logger:      logger,
+		interfaces:  make(map[string]*j1939Interface, len(addresses)),
+	}
+	for ifName, address := range addresses {
+		m.interfaces[ifName] = &j1939Interface{name: ifName, address: address, socket: -1}
+	}
+	return m
+}
+
+// Start opens the socket of every J1939 interface. An interface that is not up yet is
+// opened on its first send.
+func (m *J1939Manager) Start() {
+	if m == nil {
+		return
+	}
+	for _, iface := range m.interfaces {
+		iface.mu.Lock()
+		if err := m.openLocked(iface); err != nil {
+			m.logger.Warnf("⚠️ Warning: failed to open J1939 socket on %s: %v", iface.name, err)
+		}
+		iface.mu.Unlock()
+	}
+}
+
+// openLocked opens, binds and starts receiving on the socket of an interface
+func (m *J1939Manager) openLocked(iface *j1939Interface) (err error) {
+	defer func() { iface.openErr = err }()
+
+	socket, err := unix.Socket(unix.AF_CAN, unix.SOCK_DGRAM, unix.CAN_J1939)
+	if err != nil {
+		return fmt.Errorf("failed to create J1939 socket (is the can-j1939 module loaded?): %w", err)
+	}
+	fail := func(format string, err error) error {
+		unix.Close(socket)
+		return fmt.Errorf(format, err)
+	}
+
+	var ifr ifreq
+	copy(ifr.Name[:], iface.name)
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(socket), uintptr(unix.SIOCGIFINDEX), uintptr(unsafe.Pointer(&ifr))); errno != 0 {
+		return fail("failed to get interface index: %w", errno)
+	}
+
+	// Receive every PGN, including traffic between other nodes, and allow broadcasts
+	if err := unix.SetsockoptInt(socket, solCANJ1939, soJ1939Promisc, 1); err != nil {
+		return fail("failed to enable promiscuous mode: %w", err)
+	}
+	if err := unix.SetsockoptInt(socket, unix.SOL_SOCKET, unix.SO_BROADCAST, 1); err != nil {
+		return fail("failed to enable broadcasts: %w", err)
+	}
+	addr := &unix.SockaddrCANJ1939{Ifindex: int(ifr.Index), Name: j1939NoName, PGN: j1939NoPGN, Addr: iface.address}
+	if err := unix.Bind(socket, addr); err != nil {
+		return fail("failed to bind J1939 socket: %w", err)
+	}
+
+	// Receives time out so the loop notices Close; a transport session that stalls
+	// must not hold a request forever
+	tv := unix.Timeval{Sec: 1}
+	if err := unix.SetsockoptTimeval(socket, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		return fail("failed to set receive timeout: %w", err)
+	}
+	sendTimeout := unix.NsecToTimeval(j1939SendTimeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(socket, unix.SOL_SOCKET, unix.SO_SNDTIMEO, &sendTimeout); err != nil {
+		return fail("failed to set send timeout: %w", err)
+	}
+	timestampMode := enableRxTimestamping(socket)
+
+	iface.socket, iface.closed = socket, make(chan struct{})
+	iface.ifIndex, iface.openSince = int(ifr.Index), time.Now()
+	m.logger.Infof("🚛 J1939 on %s with address 0x%02X (receive timestamping: %s)", iface.name, iface.address, timestampMode)
+
+	m.wg.Add(1)
+	go m.receiveLoop(iface, socket, iface.closed)
+	return nil
+}
+
+// closeLocked stops using the socket of an interface. Its receive loop closes it on the
+// next read, so the descriptor is never reused under a pending read.
+func (m *J1939Manager) closeLocked(iface *j1939Interface, reason error) {
+	if iface.socket < 0 {
+		return
+	}
+	close(iface.closed)
+	iface.socket, iface.openErr = -1, reason
+}
+
+// receiveLoop reads messages from a socket until it is closed or the interface goes
+// away, then closes the socket
+func (m *J1939Manager) receiveLoop(iface *j1939Interface, socket int, closed chan struct{}) {
+	defer m.wg.Done()
+	defer unix.Close(socket)
+
+	buffer := make([]byte, J1939MaxDataLength)
+	oob := make([]byte, rxTimestampOOBSize+2*unix.CmsgSpace(1))
+	for {
+		select {
+		case <-closed:
+			return
+		default:
+		}
+
+		var n, oobn, flags int
+		var from unix.Sockaddr
+		err := ignoringEINTR(func() error {
+			var err error
+			n, oobn, flags, from, err = unix.Recvmsg(socket, buffer, oob, 0)
+			return err
+		})
+		if err != nil {
+			if errors.Is(err, unix.EAGAIN) {
+				continue
+			}
+			if errors.Is(err, unix.ENODEV) {
+				m.logger.Warnf("⚠️ J1939 interface %s went away; its socket reopens on the next send", iface.name)
+				iface.mu.Lock()
+				if iface.socket == socket {
+					m.closeLocked(iface, err)
+				}
+				iface.mu.Unlock()
+				return
+			}
+			m.logger.Warnf("⚠️ J1939 read error on %s: %v", iface.name, err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		source, ok := from.(*unix.SockaddrCANJ1939)
+		if !ok {
+			continue
+		}
+		if flags&unix.MSG_TRUNC != 0 {
+			iface.truncated.Add(1)
+		}
+
+		msg := newJ1939Message(iface.name, source.PGN, source.Addr, J1939GlobalAddress, J1939DefaultPriority, buffer[:n])
+		msg.Direction = "RX"
+		msg.Timestamp, msg.TimestampSource = parseRxTimestamp(oob[:oobn])
+		parseJ1939ControlMessages(oob[:oobn], &msg)
+
+		iface.received.Add(1)
+		iface.bufferMu.Lock()
+		iface.messages = append(iface.messages, msg)
+		if len(iface.messages) > m.maxMessages {
+			iface.messages = iface.messages[len(iface.messages)-m.maxMessages:]
+		}
+		iface.bufferMu.Unlock()
+	}
+}
+
+// parseJ1939ControlMessages sets the destination address and priority of a received
+// message from the control messages the kernel attaches
+func parseJ1939ControlMessages(oob []byte, msg *J1939Message) {
+	messages, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return
+	}
+	for _, cmsg := range messages {
+		if cmsg.Header.Level != solCANJ1939 || len(cmsg.Data) < 1 {
+			continue
+		}
+		switch cmsg.Header.Type {
+		case scmJ1939DestAddr:
+			msg.Destination = cmsg.Data[0]
+		case scmJ1939Prio:
+			msg.Priority = cmsg.Data[0]
+		}
+	}
+}
+
+func newJ1939Message(ifName string, pgn uint32, source, destination, priority uint8, data []byte) J1939Message {
+	payload := make([]byte, len(data))
+	copy(payload, data)
+	return J1939Message{
+		Interface:   ifName,
+		PGN:         pgn,
+		Source:      source,
+		Destination: destination,
+		Priority:    priority,
+		Data:        payload,
+		Length:      len(payload),
+		HEX_PGN:     fmt.Sprintf("%05X", pgn),
+		HEX_Data:    bytesToHexArray(payload),
+	}
+}
+
+// interfaceFor returns a J1939 interface, or an error naming those in J1939 mode
+func (m *J1939Manager) interfaceFor(ifName string) (*j1939Interface, error) {
+	if m != nil {
+		if iface, ok := m.interfaces[ifName]; ok {
+			return iface, nil
+		}
+	}
+	return nil, tagError(ErrInterfaceNotFound, fmt.Errorf("J1939 is not enabled on %s. J1939 interfaces: %v", ifName, m.Interfaces()))
+}
+
+// Interfaces returns the interfaces in J1939 mode, sorted
+func (m *J1939Manager) Interfaces() []string {
+	if m == nil {
+		return []string{}
+	}
+	names := make([]string, 0, len(m.interfaces))
+	for ifName := range m.interfaces {
+		names = append(names, ifName)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validate checks a send request and returns its payload, destination and priority.
+// PDU1 PGNs carry the destination in their low byte, which must therefore be 0 in the
+// PGN; PDU2 PGNs are always broadcast.
+func (req J1939SendRequest) validate() ([]byte, uint8, uint8, error) {
+	data := req.Data
+	if req.DataHex != "" {
+		if len(req.Data) > 0 {
+			return nil, 0, 0, tagError(ErrValidation, fmt.Errorf("data and dataHex are mutually exclusive"))
+		}
+		var err error
+		if data, err = parseHexBytes(req.DataHex); err != nil {
+			return nil, 0, 0, tagError(ErrValidation, fmt.Errorf("invalid dataHex %q: expected hex bytes such as \"02 10 01\": %w", req.DataHex, err))
+		}
+	}
+	if len(data) == 0 {
+		return nil, 0, 0, tagError(ErrValidation, fmt.Errorf("data is required (or dataHex)"))
+	}
+	if len(data) > J1939MaxDataLength {
+		return nil, 0, 0, tagError(ErrValidation, fmt.Errorf("%d data bytes exceed the J1939 transport protocol limit of %d", len(data), J1939MaxDataLength))
+	}
+	if req.PGN > J1939MaxPGN {
+		return nil, 0, 0, tagError(ErrValidation, fmt.Errorf("PGN 0x%X exceeds 0x%X", req.PGN, J1939MaxPGN))
+	}
+
+	destination := uint8(J1939GlobalAddress)
+	if req.Destination != nil {
+		destination = *req.Destination
+	}
+	if (req.PGN>>8)&0xFF < j1939PDU1FormatLimit {
+		if req.PGN&0xFF != 0 {
+			return nil, 0, 0, tagError(ErrValidation, fmt.Errorf("PGN 0x%05X is destination specific (PDU1): its low byte must be 0, set destination instead", req.PGN))
+		}
+	} else if destination != J1939GlobalAddress {
+		return nil, 0, 0, tagError(ErrValidation, fmt.Errorf("PGN 0x%05X is a broadcast PGN (PDU2) and cannot have destination %d", req.PGN, destination))
+	}
+	if destination == J1939NullAddress {
+		return nil, 0, 0, tagError(ErrValidation, fmt.Errorf("destination %d is the null address", J1939NullAddress))
+	}
+
+	priority := uint8(J1939DefaultPriority)
+	if req.Priority != nil {
+		priority = *req.Priority
+	}
+	if priority > J1939MaxPriority {
+		return nil, 0, 0, tagError(ErrValidation, fmt.Errorf("priority %d exceeds %d", priority, J1939MaxPriority))
+	}
+	return data, destination, priority, nil
+}
+
+// Send sends a parameter group from the local address of an interface. Messages over 8
+// bytes go out through the kernel's transport protocol, BAM for broadcasts and RTS/CTS
+// otherwise; Send returns once the kernel accepted the whole message. Dry runs and
+// disabled transmission apply as for raw frames.
+func (m *J1939Manager) Send(ifName string, req J1939SendRequest) (J1939SendResult, error) {
+	iface, err := m.interfaceFor(ifName)
+	if err != nil {
+		return J1939SendResult{}, err
+	}
+	data, destination, priority, err := req.validate()
+	if err != nil {
+		return J1939SendResult{}, err
+	}
+
+	result := J1939SendResult{J1939Message: newJ1939Message(ifName, req.PGN, iface.address, destination, priority, data)}
+	result.Direction, result.Timestamp = "TX", time.Now()
+	if req.DryRun || m.sender.configProvider.GetDryRun() {
+		result.DryRun = true
+		m.logger.Infof("🧪 %s J1939 dry run: would send PGN=0x%05X, DA=0x%02X, Priority=%d, Length=%d",
+			ifName, req.PGN, destination, priority, len(data))
+		return result, nil
+	}
+	if err := m.sender.checkTxEnabled(ifName); err != nil {
+		return J1939SendResult{}, err
+	}
+
+	iface.mu.Lock()
+	defer iface.mu.Unlock()
+	if iface.socket < 0 {
+		if err := m.openLocked(iface); err != nil {
+			iface.sendErrors.Add(1)
+			return J1939SendResult{}, tagError(ErrInterfaceDown, fmt.Errorf("J1939 socket on %s is not open: %w", ifName, err))
+		}
+	}
+
+	if err := unix.SetsockoptInt(iface.socket, solCANJ1939, soJ1939SendPrio, int(priority)); err != nil {
+		iface.sendErrors.Add(1)
+		return J1939SendResult{}, tagError(ErrSendFailed, fmt.Errorf("failed to set priority %d: %w", priority, err))
+	}
+	to := &unix.SockaddrCANJ1939{Ifindex: iface.ifIndex, Name: j1939NoName, PGN: req.PGN, Addr: destination}
+	err = ignoringEINTR(func() error {
+		return unix.Sendto(iface.socket, data, 0, to)
+	})
+	if err != nil {
+		iface.sendErrors.Add(1)
+		if errors.Is(err, unix.ENODEV) {
+			m.closeLocked(iface, err)
+		}
+		return J1939SendResult{}, m.sender.classifyWriteError(ifName, fmt.Errorf("J1939 send on %s failed: %w", ifName, err))
+	}
+
+	iface.sent.Add(1)
+	m.logger.Infof("✅ %s J1939 message sent: PGN=0x%05X, SA=0x%02X, DA=0x%02X, Priority=%d, Length=%d",
+		ifName, req.PGN, iface.address, destination, priority, len(data))
+	return result, nil
+}
+
+// Messages returns the last count received messages of an interface matching filter,
+// oldest first; count 0 returns all
+func (m *J1939Manager) Messages(ifName string, filter J1939Filter, count int) ([]J1939Message, error) {
+	iface, err := m.interfaceFor(ifName)
+	if err != nil {
+		return nil, err
+	}
+
+	iface.bufferMu.RLock()
+	defer iface.bufferMu.RUnlock()
+	matched := make([]J1939Message, 0)
+	for _, msg := range iface.messages {
+		if filter.matches(msg) {
+			matched = append(matched, msg)
+		}
+	}
+	if count > 0 && len(matched) > count {
+		matched = matched[len(matched)-count:]
+	}
+	return matched, nil
+}
+
+// Status returns the socket state and counters of every J1939 interface
+func (m *J1939Manager) Status() map[string]J1939InterfaceStatus {
+	status := make(map[string]J1939InterfaceStatus)
+	if m == nil {
+		return status
+	}
+	for ifName, iface := range m.interfaces {
+		iface.mu.Lock()
+		s := J1939InterfaceStatus{
+			Address:    iface.address,
+			Open:       iface.socket >= 0,
+			Received:   iface.received.Load(),
+			Sent:       iface.sent.Load(),
+			SendErrors: iface.sendErrors.Load(),
+			Truncated:  iface.truncated.Load(),
+		}
+		if s.Open {
+			s.OpenSince = iface.openSince
+		} else if iface.openErr != nil {
+			s.Error = iface.openErr.Error()
+		}
+		iface.mu.Unlock()
+
+		iface.bufferMu.RLock()
+		s.Buffered = len(iface.messages)
+		iface.bufferMu.RUnlock()
+		status[ifName] = s
+	}
+	return status
+}
+
+// Close closes every J1939 socket and waits for the receive loops to end
+func (m *J1939Manager) Close() {
+	if m == nil {
+		return
+	}
+	m.stopOnce.Do(func() {
+		for _, iface := range m.interfaces {
+			iface.mu.Lock()
+			m.closeLocked(iface, errors.New("closed"))
+			iface.mu.Unlock()
+		}
+		m.wg.Wait()
+	})
+}
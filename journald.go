@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// journaldSocket is where journald takes entries in its native protocol
+const journaldSocket = "/run/systemd/journal/socket"
+
+// journalFieldNames maps the field keys of log lines to the journal fields operators
+// filter on, e.g. journalctl CAN_INTERFACE=can0. Other keys are upper-cased.
+var journalFieldNames = map[string]string{
+	"interface":  "CAN_INTERFACE",
+	"can_id":     "CAN_ID",
+	"request_id": "REQUEST_ID",
+}
+
+// journalPriorities are the syslog priorities of the log levels
+var journalPriorities = map[LogLevel]int{
+	LogLevelDebug: 7,
+	LogLevelInfo:  6,
+	LogLevelWarn:  4,
+	LogLevelError: 3,
+}
+
+// journaldSink sends lines to the systemd journal, each field of a line a journal field
+type journaldSink struct {
+	conn *net.UnixConn
+}
+
+func openJournald(path string) (*journaldSink, error) {
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+	return &journaldSink{conn: conn}, nil
+}
+
+func (j *journaldSink) writeEntry(entry logEntry) error {
+	var data bytes.Buffer
+	appendJournalField(&data, "MESSAGE", entry.message)
+	appendJournalField(&data, "PRIORITY", strconv.Itoa(journalPriority(entry.level)))
+	appendJournalField(&data, "SYSLOG_IDENTIFIER", logIdentifier)
+	if entry.component != "" {
+		appendJournalField(&data, "CAN_COMPONENT", entry.component)
+	}
+	for i := 0; i+1 < len(entry.fields); i += 2 {
+		value := entry.fields[i+1]
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		appendJournalField(&data, journalFieldName(fmt.Sprint(entry.fields[i])), fmt.Sprint(value))
+	}
+
+	_, err := j.conn.Write(data.Bytes())
+	if errors.Is(err, unix.EMSGSIZE) || errors.Is(err, unix.ENOBUFS) {
+		return j.writeLarge(data.Bytes())
+	}
+	return err
+}
+
+// writeLarge passes an entry too large for a datagram in a sealed memory file, as
+// journald expects
+func (j *journaldSink) writeLarge(data []byte) error {
+	fd, err := unix.MemfdCreate("journal-entry", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return err
+	}
+	defer unix.Close(fd)
+
+	for written := 0; written < len(data); {
+		n, err := unix.Write(fd, data[written:])
+		if err != nil {
+			return err
+		}
+		written += n
+	}
+	if _, err := unix.FcntlInt(uintptr(fd), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL); err != nil {
+		return err
+	}
+	// WriteMsgUnix refuses connected datagram sockets, so the descriptor is sent directly
+	raw, err := j.conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sendErr error
+	if err := raw.Write(func(socket uintptr) bool {
+		sendErr = unix.Sendmsg(int(socket), nil, unix.UnixRights(fd), nil, 0)
+		return sendErr != unix.EAGAIN
+	}); err != nil {
+		return err
+	}
+	return sendErr
+}
+
+func (j *journaldSink) Close() error {
+	return j.conn.Close()
+}
+
+func journalPriority(level LogLevel) int {
+	if priority, ok := journalPriorities[level]; ok {
+		return priority
+	}
+	return journalPriorities[LogLevelInfo]
+}
+
+// journalFieldName turns a field key into a valid journal field name: upper-case letters,
+// digits and underscores, not starting with an underscore or digit, at most 64 long
+func journalFieldName(key string) string {
+	if name, ok := journalFieldNames[key]; ok {
+		return name
+	}
+	var name strings.Builder
+	for _, r := range strings.ToUpper(key) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			name.WriteRune(r)
+		} else {
+			name.WriteByte('_')
+		}
+	}
+	field := strings.TrimLeft(name.String(), "_")
+	if field == "" || (field[0] >= '0' && field[0] <= '9') {
+		field = "FIELD_" + field
+	}
+	if len(field) > 64 {
+		field = field[:64]
+	}
+	return field
+}
+
+// appendJournalField appends a field in the native protocol: NAME=value on a line, or
+// for values with newlines, the name, a newline, the value's length and the value
+func appendJournalField(data *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		data.WriteString(name + "=" + value + "\n")
+		return
+	}
+	data.WriteString(name + "\n")
+	binary.Write(data, binary.LittleEndian, uint64(len(value)))
+	data.WriteString(value + "\n")
+}
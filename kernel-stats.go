@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSysfsNetRoot is where the kernel exposes network device statistics
+const DefaultSysfsNetRoot = "/sys/class/net"
+
+// minKernelStatsInterval is the shortest time between two reads of an interface's
+// counters; more frequent status requests reuse the previous sample so rates stay meaningful
+const minKernelStatsInterval = time.Second
+
+// kernelStatCounters are the counters read from /sys/class/net/<if>/statistics. CAN bus
+// errors are not exposed there; they are counted from error frames instead.
+var kernelStatCounters = []string{
+	"rx_packets",
+	"tx_packets",
+	"rx_bytes",
+	"tx_bytes",
+	"rx_errors",
+	"tx_errors",
+	"rx_dropped",
+	"tx_dropped",
+	"rx_over_errors",
+}
+
+// KernelInterfaceStats holds kernel counters of an interface and their rates since the
+// previous read
+type KernelInterfaceStats struct {
+	Counters  map[string]uint64  `json:"counters"`
+	Rates     map[string]float64 `json:"rates"`  // Per second since the previous read
+	Resets    int                `json:"resets"` // Times the counters restarted (interface recreated)
+	SampledAt time.Time          `json:"sampledAt"`
+	Error     string             `json:"error,omitempty"`
+}
+
+// kernelStatsSample is the last successful read of an interface's counters
+type kernelStatsSample struct {
+	ifIndex string
+	stats   KernelInterfaceStats
+}
+
+// KernelStatsReader reads per-interface kernel statistics from sysfs and derives rates
+type KernelStatsReader struct {
+	root    string
+	samples map[string]*kernelStatsSample
+	now     func() time.Time
+	mutex   sync.Mutex
+}
+
+// NewKernelStatsReader creates a reader for the given sysfs net directory
+func NewKernelStatsReader(root string) *KernelStatsReader {
+	return &KernelStatsReader{
+		root:    root,
+		samples: make(map[string]*kernelStatsSample),
+		now:     time.Now,
+	}
+}
+
+// Read returns the current kernel statistics of an interface. Counters that went
+// backwards, or a changed interface index, mean the device was recreated (e.g. after
+// hotplug): the new values are then counted from zero instead of producing negative rates.
+func (r *KernelStatsReader) Read(ifName string) KernelInterfaceStats {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+	previous, hasPrevious := r.samples[ifName]
+	if hasPrevious && now.Sub(previous.stats.SampledAt) < minKernelStatsInterval {
+		return copyKernelStats(previous.stats)
+	}
+
+	counters, ifIndex, err := r.readCounters(ifName)
+	if err != nil {
+		// The last good sample is kept so a recreated device is detected by its new index
+		stats := KernelInterfaceStats{SampledAt: now, Error: err.Error()}
+		if hasPrevious {
+			stats.Resets = previous.stats.Resets
+		}
+		return stats
+	}
+
+	stats := KernelInterfaceStats{
+		Counters:  counters,
+		Rates:     make(map[string]float64),
+		SampledAt: now,
+	}
+
+	if hasPrevious {
+		stats.Resets = previous.stats.Resets
+		reset := previous.ifIndex != ifIndex
+		for name, value := range counters {
+			if value < previous.stats.Counters[name] {
+				reset = true
+			}
+		}
+		if reset {
+			stats.Resets++
+		}
+
+		elapsed := now.Sub(previous.stats.SampledAt).Seconds()
+		for name, value := range counters {
+			delta := value
+			if !reset {
+				delta -= previous.stats.Counters[name]
+			}
+			if elapsed > 0 {
+				stats.Rates[name] = float64(delta) / elapsed
+			}
+		}
+	}
+
+	r.samples[ifName] = &kernelStatsSample{ifIndex: ifIndex, stats: stats}
+	return copyKernelStats(stats)
+}
+
+// readCounters reads the statistics files and interface index of an interface
+func (r *KernelStatsReader) readCounters(ifName string) (map[string]uint64, string, error) {
+	deviceDir := filepath.Join(r.root, ifName)
+
+	ifIndex, err := os.ReadFile(filepath.Join(deviceDir, "ifindex"))
+	if err != nil {
+		return nil, "", fmt.Errorf("interface %s not present: %w", ifName, err)
+	}
+
+	counters := make(map[string]uint64, len(kernelStatCounters))
+	for _, name := range kernelStatCounters {
+		raw, err := os.ReadFile(filepath.Join(deviceDir, "statistics", name))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read %s statistics: %w", ifName, err)
+		}
+		value, err := strconv.ParseUint(strings.TrimSpace(string(raw)), 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid %s counter for %s: %w", name, ifName, err)
+		}
+		counters[name] = value
+	}
+
+	return counters, strings.TrimSpace(string(ifIndex)), nil
+}
+
+// copyKernelStats copies a sample so callers cannot modify the stored maps
+func copyKernelStats(stats KernelInterfaceStats) KernelInterfaceStats {
+	result := stats
+	if stats.Counters != nil {
+		result.Counters = copyCounts(stats.Counters)
+	}
+	if stats.Rates != nil {
+		result.Rates = make(map[string]float64, len(stats.Rates))
+		for name, rate := range stats.Rates {
+			result.Rates[name] = rate
+		}
+	}
+	return result
+}
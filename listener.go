@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
@@ -16,9 +17,12 @@ type CanMessageLog struct {
 	ID        uint32    `json:"id"`
 	Data      []byte    `json:"data"`
 	Length    uint8     `json:"length"`
+	RTR       bool      `json:"rtr,omitempty"` // Remote transmission request; Length is the requested DLC
 	Timestamp time.Time `json:"timestamp"`
 	Direction string    `json:"direction"` // "RX" for received messages
 
+	TimestampSource string `json:"timestampSource"` // "hardware", "kernel" or "software"
+
 	HEX_ID   string   `json:"hex_id"`   // Hexadecimal representation of ID
 	HEX_Data []string `json:"hex_data"` // Hexadecimal representation of data
 }
@@ -30,6 +34,13 @@ type InterfaceMessageBuffer struct {
 	maxSize       int
 	mutex         sync.RWMutex
 	totalReceived uint64
+	notBuffered   uint64 // Frames received but left out by the buffer filter
+	createdAt     time.Time
+	lastReceived  time.Time
+
+	socketDrops    uint64    // Frames the kernel dropped on the listening socket
+	dropCounter    uint32    // Last SO_RXQ_OVFL counter of the socket
+	lastSocketDrop time.Time // When dropCounter last grew
 }
 
 // NewInterfaceMessageBuffer creates a new message buffer for an interface
@@ -38,6 +49,7 @@ func NewInterfaceMessageBuffer(interfaceName string, maxSize int) *InterfaceMess
 		interfaceName: interfaceName,
 		messages:      make([]CanMessageLog, 0, maxSize),
 		maxSize:       maxSize,
+		createdAt:     time.Now(),
 	}
 }
 
@@ -47,6 +59,7 @@ func (buf *InterfaceMessageBuffer) AddMessage(msg CanMessageLog) {
 	defer buf.mutex.Unlock()
 
 	buf.totalReceived++
+	buf.lastReceived = msg.Timestamp
 
 	// Add message to buffer
 	buf.messages = append(buf.messages, msg)
@@ -58,6 +71,16 @@ func (buf *InterfaceMessageBuffer) AddMessage(msg CanMessageLog) {
 	}
 }
 
+// RecordUnbuffered counts a received frame the buffer filter leaves out
+func (buf *InterfaceMessageBuffer) RecordUnbuffered(msg CanMessageLog) {
+	buf.mutex.Lock()
+	defer buf.mutex.Unlock()
+
+	buf.totalReceived++
+	buf.notBuffered++
+	buf.lastReceived = msg.Timestamp
+}
+
 // GetMessages returns a copy of all messages
 func (buf *InterfaceMessageBuffer) GetMessages() []CanMessageLog {
 	buf.mutex.RLock()
@@ -69,8 +92,9 @@ func (buf *InterfaceMessageBuffer) GetMessages() []CanMessageLog {
 	return result
 }
 
-// GetRecentMessages returns the last N messages
-func (buf *InterfaceMessageBuffer) GetRecentMessages(count int) []CanMessageLog {
+// GetRecentMessages returns the last N messages, counting only those passing filter
+// when it is set
+func (buf *InterfaceMessageBuffer) GetRecentMessages(count int, filter *DataFilter) []CanMessageLog {
 	buf.mutex.RLock()
 	defer buf.mutex.RUnlock()
 
@@ -78,6 +102,19 @@ func (buf *InterfaceMessageBuffer) GetRecentMessages(count int) []CanMessageLog
 		return []CanMessageLog{}
 	}
 
+	if filter != nil {
+		// Walk back from the newest message until count messages matched
+		start := len(buf.messages)
+		matched := 0
+		for start > 0 && matched < count {
+			start--
+			if filter.Matches(buf.messages[start].Data) {
+				matched++
+			}
+		}
+		return filter.Filter(buf.messages[start:])
+	}
+
 	if count >= len(buf.messages) {
 		// Return all messages
 		result := make([]CanMessageLog, len(buf.messages))
@@ -92,18 +129,62 @@ func (buf *InterfaceMessageBuffer) GetRecentMessages(count int) []CanMessageLog
 	return result
 }
 
+// MessageBufferStats reports the receive buffer of an interface
+type MessageBufferStats struct {
+	Interface     string    `json:"interface"`
+	TotalReceived uint64    `json:"totalReceived"`
+	NotBuffered   uint64    `json:"notBuffered"` // Received frames left out by -buffer-ids
+	BufferedCount int       `json:"bufferedCount"`
+	MaxBufferSize int       `json:"maxBufferSize"`
+	BufferUsage   float64   `json:"bufferUsage"` // Buffer occupancy in percent
+	LastReceived  time.Time `json:"lastReceived"`
+	IsListening   *bool     `json:"isListening,omitempty"` // Only set by the per-interface statistics endpoint
+
+	SocketDrops    uint64    `json:"socketDrops"` // Frames the kernel dropped on the listening socket, its receive buffer full
+	LastSocketDrop time.Time `json:"lastSocketDrop,omitempty"`
+}
+
 // GetStatistics returns buffer statistics
-func (buf *InterfaceMessageBuffer) GetStatistics() map[string]interface{} {
+func (buf *InterfaceMessageBuffer) GetStatistics() MessageBufferStats {
 	buf.mutex.RLock()
 	defer buf.mutex.RUnlock()
 
-	return map[string]interface{}{
-		"interface":     buf.interfaceName,
-		"totalReceived": buf.totalReceived,
-		"bufferedCount": len(buf.messages),
-		"maxBufferSize": buf.maxSize,
-		"bufferUsage":   float64(len(buf.messages)) / float64(buf.maxSize) * 100,
+	return MessageBufferStats{
+		Interface:     buf.interfaceName,
+		TotalReceived: buf.totalReceived,
+		NotBuffered:   buf.notBuffered,
+		BufferedCount: len(buf.messages),
+		MaxBufferSize: buf.maxSize,
+		BufferUsage:   float64(len(buf.messages)) / float64(buf.maxSize) * 100,
+		LastReceived:  buf.lastReceived,
+
+		SocketDrops:    buf.socketDrops,
+		LastSocketDrop: buf.lastSocketDrop,
+	}
+}
+
+// RecordSocketDrops takes the SO_RXQ_OVFL counter of the listening socket, which the
+// kernel only increases, and counts the frames dropped since it was last seen. It
+// returns how many that was.
+func (buf *InterfaceMessageBuffer) RecordSocketDrops(counter uint32) uint32 {
+	buf.mutex.Lock()
+	defer buf.mutex.Unlock()
+
+	dropped := counter - buf.dropCounter
+	if dropped > 0 {
+		buf.socketDrops += uint64(dropped)
+		buf.dropCounter = counter
+		buf.lastSocketDrop = time.Now()
 	}
+	return dropped
+}
+
+// GetLastActivity returns the time of the last received frame and when buffering started
+func (buf *InterfaceMessageBuffer) GetLastActivity() (lastReceived time.Time, createdAt time.Time) {
+	buf.mutex.RLock()
+	defer buf.mutex.RUnlock()
+
+	return buf.lastReceived, buf.createdAt
 }
 
 // Clear clears all messages from the buffer
@@ -113,6 +194,9 @@ func (buf *InterfaceMessageBuffer) Clear() {
 
 	buf.messages = buf.messages[:0] // Clear slice but keep capacity
 	buf.totalReceived = 0
+	buf.notBuffered = 0
+	buf.socketDrops = 0
+	buf.lastSocketDrop = time.Time{}
 }
 
 // CanMessageListener manages listening to CAN messages on multiple interfaces
@@ -124,6 +208,10 @@ type CanMessageListener struct {
 	logger       Logger
 	ctx          context.Context
 	cancel       context.CancelFunc
+	observer     FrameObserver
+
+	socketProvider SocketProvider // Sets receive buffer sizes when both are set
+	configProvider ConfigProvider
 }
 
 // interfaceListener manages listening for a single interface
@@ -134,6 +222,8 @@ type interfaceListener struct {
 	stopChan      chan bool
 	buffer        *InterfaceMessageBuffer
 	logger        Logger
+	timestampMode string
+	heartbeat     atomic.Int64 // UnixNano of the last loop iteration, for the liveness probe
 }
 
 // NewCanMessageListener creates a new CAN message listener
@@ -149,6 +239,28 @@ func NewCanMessageListener(maxMessages int, logger Logger) *CanMessageListener {
 	}
 }
 
+// SetFrameObserver sets an observer notified of every received frame.
+// It must be called before listening starts.
+func (cml *CanMessageListener) SetFrameObserver(observer FrameObserver) {
+	cml.observer = observer
+}
+
+// SetReceiveBufferConfig makes listening sockets use the configured receive buffer sizes,
+// and receive buffers keep only the IDs configured for them. It must be called before
+// listening starts.
+func (cml *CanMessageListener) SetReceiveBufferConfig(socketProvider SocketProvider, configProvider ConfigProvider) {
+	cml.socketProvider = socketProvider
+	cml.configProvider = configProvider
+}
+
+// bufferFilter returns the IDs kept in the receive buffer of an interface, empty for all
+func (cml *CanMessageListener) bufferFilter(interfaceName string) BufferFilter {
+	if cml.configProvider == nil {
+		return nil
+	}
+	return cml.configProvider.GetBufferFilter(interfaceName)
+}
+
 // StartListening starts listening on a specific CAN interface
 func (cml *CanMessageListener) StartListening(interfaceName string) error {
 	cml.buffersMutex.Lock()
@@ -193,6 +305,25 @@ func (cml *CanMessageListener) StartListening(interfaceName string) error {
 		return fmt.Errorf("failed to bind listening socket: %w", err)
 	}
 
+	// A larger receive buffer absorbs bursts until the listener catches up
+	if cml.socketProvider != nil && cml.configProvider != nil {
+		applyReceiveBuffer(cml.socketProvider, socket, interfaceName, cml.configProvider.GetReceiveBufferSize(interfaceName), cml.logger)
+	}
+
+	// Receive all error frames so they can be classified
+	if err := unix.SetsockoptInt(socket, unix.SOL_CAN_RAW, unix.CAN_RAW_ERR_FILTER, unix.CAN_ERR_MASK); err != nil {
+		cml.logger.Printf("⚠️ Warning: failed to enable error frames on %s: %v", interfaceName, err)
+	}
+
+	// Request kernel/hardware receive timestamps
+	timestampMode := enableRxTimestamping(socket)
+	cml.logger.Printf("🕒 %s receive timestamping: %s", interfaceName, timestampMode)
+
+	// Have the kernel report frames dropped on the socket when the listener falls behind
+	if err := enableRxQueueOverflow(socket); err != nil {
+		cml.logger.Printf("⚠️ Warning: failed to enable socket drop counting on %s: %v", interfaceName, err)
+	}
+
 	// Create listener
 	listener := &interfaceListener{
 		interfaceName: interfaceName,
@@ -201,6 +332,7 @@ func (cml *CanMessageListener) StartListening(interfaceName string) error {
 		stopChan:      make(chan bool, 1),
 		buffer:        buffer,
 		logger:        cml.logger,
+		timestampMode: timestampMode,
 	}
 
 	cml.listeners[interfaceName] = listener
@@ -259,8 +391,10 @@ func (cml *CanMessageListener) listenOnInterface(listener *interfaceListener) {
 	cml.logger.Printf("👂 Listening thread started for %s", listener.interfaceName)
 
 	buffer := make([]byte, 16) // Size of CAN frame
+	oob := make([]byte, rxTimestampOOBSize+rxQueueOverflowOOBSize)
 
 	for {
+		listener.heartbeat.Store(time.Now().UnixNano())
 		select {
 		case <-listener.stopChan:
 			cml.logger.Printf("🛑 Stop signal received for %s", listener.interfaceName)
@@ -275,8 +409,13 @@ func (cml *CanMessageListener) listenOnInterface(listener *interfaceListener) {
 				cml.logger.Printf("⚠️ Failed to set socket timeout for %s: %v", listener.interfaceName, err)
 			}
 
-			// Try to read CAN frame
-			n, err := unix.Read(listener.socket, buffer)
+			// Try to read CAN frame along with its receive timestamp
+			var n, oobn int
+			err := ignoringEINTR(func() error {
+				var err error
+				n, oobn, _, _, err = unix.Recvmsg(listener.socket, buffer, oob, 0)
+				return err
+			})
 			if err != nil {
 				// Check if it's a timeout (expected) or real error
 				if errno, ok := err.(unix.Errno); ok && errno == unix.EAGAIN {
@@ -290,24 +429,59 @@ func (cml *CanMessageListener) listenOnInterface(listener *interfaceListener) {
 				// Parse CAN frame
 				frame := (*CanFrame)(unsafe.Pointer(&buffer[0]))
 
+				// Remote frames request data: their length is the requested DLC and they carry no data
+				rtr := frame.ID&unix.CAN_RTR_FLAG != 0
+				id := frame.ID &^ unix.CAN_RTR_FLAG
+				dataLength := frame.Length
+				if rtr {
+					dataLength = 0
+				}
+
 				// Create message log entry
-				data := make([]byte, frame.Length)
-				copy(data, frame.Data[:frame.Length])
+				data := make([]byte, dataLength)
+				copy(data, frame.Data[:dataLength])
+
+				timestamp, timestampSource := parseRxTimestamp(oob[:oobn])
+				if counter, ok := parseRxQueueOverflow(oob[:oobn]); ok {
+					if dropped := listener.buffer.RecordSocketDrops(counter); dropped > 0 {
+						cml.logger.With("interface", listener.interfaceName).Warnf("⚠️ %s: kernel dropped %d frame(s) on the listening socket, its receive buffer was full",
+							listener.interfaceName, dropped)
+					}
+				}
 
 				msg := CanMessageLog{
 					Interface: listener.interfaceName,
-					ID:        frame.ID,
+					ID:        id,
 					Data:      data,
 					Length:    frame.Length,
-					Timestamp: time.Now(),
+					RTR:       rtr,
+					Timestamp: timestamp,
 					Direction: "RX",
 
-					HEX_ID:   fmt.Sprintf("%08x", frame.ID),
+					TimestampSource: timestampSource,
+
+					HEX_ID:   fmt.Sprintf("%08x", id),
 					HEX_Data: bytesToHexArray(data),
 				}
 
-				// Add to buffer
-				listener.buffer.AddMessage(msg)
+				// Error frames only feed the error statistics, they are not bus traffic
+				if isErrorFrame(frame.ID) {
+					if cml.observer != nil {
+						cml.observer.ObserveFrame(msg)
+					}
+					continue
+				}
+
+				// Frames the buffer filter leaves out still count as received and reach the
+				// observer, so statistics and silence detection see the whole bus
+				if cml.bufferFilter(listener.interfaceName).Matches(msg.ID) {
+					listener.buffer.AddMessage(msg)
+				} else {
+					listener.buffer.RecordUnbuffered(msg)
+				}
+				if cml.observer != nil {
+					cml.observer.ObserveFrame(msg)
+				}
 
 				// Log received message (with rate limiting to avoid spam)
 				if listener.buffer.totalReceived%100 == 1 || listener.buffer.totalReceived <= 10 {
@@ -332,8 +506,9 @@ func (cml *CanMessageListener) GetMessages(interfaceName string) ([]CanMessageLo
 	return buffer.GetMessages(), nil
 }
 
-// GetRecentMessages returns the last N messages for a specific interface
-func (cml *CanMessageListener) GetRecentMessages(interfaceName string, count int) ([]CanMessageLog, error) {
+// GetRecentMessages returns the last N messages for a specific interface, optionally
+// only those passing a data filter
+func (cml *CanMessageListener) GetRecentMessages(interfaceName string, count int, filter *DataFilter) ([]CanMessageLog, error) {
 	cml.buffersMutex.RLock()
 	defer cml.buffersMutex.RUnlock()
 
@@ -342,7 +517,22 @@ func (cml *CanMessageListener) GetRecentMessages(interfaceName string, count int
 		return nil, fmt.Errorf("no message buffer for interface %s", interfaceName)
 	}
 
-	return buffer.GetRecentMessages(count), nil
+	return buffer.GetRecentMessages(count, filter), nil
+}
+
+// GetLastActivity returns the time of the last received frame on an interface and when
+// listening started, so callers can measure silence even before the first frame arrives
+func (cml *CanMessageListener) GetLastActivity(interfaceName string) (lastReceived time.Time, listeningSince time.Time, err error) {
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	buffer, exists := cml.buffers[interfaceName]
+	if !exists {
+		return time.Time{}, time.Time{}, fmt.Errorf("no message buffer for interface %s", interfaceName)
+	}
+
+	lastReceived, listeningSince = buffer.GetLastActivity()
+	return lastReceived, listeningSince, nil
 }
 
 // GetAllMessages returns messages for all interfaces
@@ -358,11 +548,11 @@ func (cml *CanMessageListener) GetAllMessages() map[string][]CanMessageLog {
 }
 
 // GetStatistics returns statistics for all interfaces
-func (cml *CanMessageListener) GetStatistics() map[string]interface{} {
+func (cml *CanMessageListener) GetStatistics() map[string]MessageBufferStats {
 	cml.buffersMutex.RLock()
 	defer cml.buffersMutex.RUnlock()
 
-	result := make(map[string]interface{})
+	result := make(map[string]MessageBufferStats)
 	for ifName, buffer := range cml.buffers {
 		result[ifName] = buffer.GetStatistics()
 	}
@@ -370,13 +560,13 @@ func (cml *CanMessageListener) GetStatistics() map[string]interface{} {
 }
 
 // GetInterfaceStatistics returns statistics for a specific interface
-func (cml *CanMessageListener) GetInterfaceStatistics(interfaceName string) (map[string]interface{}, error) {
+func (cml *CanMessageListener) GetInterfaceStatistics(interfaceName string) (MessageBufferStats, error) {
 	cml.buffersMutex.RLock()
 	defer cml.buffersMutex.RUnlock()
 
 	buffer, exists := cml.buffers[interfaceName]
 	if !exists {
-		return nil, fmt.Errorf("no message buffer for interface %s", interfaceName)
+		return MessageBufferStats{}, fmt.Errorf("no message buffer for interface %s", interfaceName)
 	}
 
 	return buffer.GetStatistics(), nil
@@ -417,6 +607,25 @@ func (cml *CanMessageListener) IsListening(interfaceName string) bool {
 	return exists && listener.isRunning
 }
 
+// heartbeats returns when the receive loop of each listening interface last iterated.
+// The loops wake up at least every second, with or without traffic.
+func (cml *CanMessageListener) heartbeats() map[string]time.Time {
+	result := make(map[string]time.Time)
+	if cml == nil {
+		return result
+	}
+
+	cml.buffersMutex.RLock()
+	defer cml.buffersMutex.RUnlock()
+
+	for ifName, listener := range cml.listeners {
+		if tick := listener.heartbeat.Load(); listener.isRunning && tick != 0 {
+			result[ifName] = time.Unix(0, tick)
+		}
+	}
+	return result
+}
+
 // GetListeningInterfaces returns list of interfaces currently being listened to
 func (cml *CanMessageListener) GetListeningInterfaces() []string {
 	cml.buffersMutex.RLock()
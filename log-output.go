@@ -0,0 +1,299 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"log/syslog"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Log outputs
+const (
+	LogOutputStderr   = "stderr"   // Standard error, or -log-file when one is set
+	LogOutputStdout   = "stdout"   // Standard output, for container runtimes collecting it
+	LogOutputJournald = "journald" // The systemd journal, with priorities and fields of its own
+	LogOutputSyslog   = "syslog"   // A syslog daemon, local or at -syslog-address
+)
+
+var logOutputs = []string{LogOutputStderr, LogOutputStdout, LogOutputJournald, LogOutputSyslog}
+
+// DefaultSyslogFacility is the facility of lines sent to syslog
+const DefaultSyslogFacility = "daemon"
+
+// logIdentifier names the service in the journal and in syslog
+const logIdentifier = "can-bridge"
+
+var syslogFacilities = map[string]syslog.Priority{
+	"kern": syslog.LOG_KERN, "user": syslog.LOG_USER, "mail": syslog.LOG_MAIL, "daemon": syslog.LOG_DAEMON,
+	"auth": syslog.LOG_AUTH, "syslog": syslog.LOG_SYSLOG, "lpr": syslog.LOG_LPR, "news": syslog.LOG_NEWS,
+	"uucp": syslog.LOG_UUCP, "cron": syslog.LOG_CRON, "authpriv": syslog.LOG_AUTHPRIV, "ftp": syslog.LOG_FTP,
+	"local0": syslog.LOG_LOCAL0, "local1": syslog.LOG_LOCAL1, "local2": syslog.LOG_LOCAL2, "local3": syslog.LOG_LOCAL3,
+	"local4": syslog.LOG_LOCAL4, "local5": syslog.LOG_LOCAL5, "local6": syslog.LOG_LOCAL6, "local7": syslog.LOG_LOCAL7,
+}
+
+// ParseSyslogFacility parses a facility name such as daemon or local0
+func ParseSyslogFacility(value string) (syslog.Priority, error) {
+	facility, ok := syslogFacilities[strings.ToLower(strings.TrimSpace(value))]
+	if !ok {
+		names := make([]string, 0, len(syslogFacilities))
+		for name := range syslogFacilities {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return 0, fmt.Errorf("unknown syslog facility %q (valid: %s)", value, strings.Join(names, ", "))
+	}
+	return facility, nil
+}
+
+// ParseSyslogAddress parses a syslog address such as udp://host:514, tcp://host:514 or
+// unix:///dev/log. An empty address is the local syslog daemon.
+func ParseSyslogAddress(value string) (network, address string, err error) {
+	if value == "" {
+		return "", "", nil
+	}
+	u, err := url.Parse(value)
+	if err != nil {
+		return "", "", err
+	}
+	switch u.Scheme {
+	case "udp", "tcp":
+		if u.Host == "" || u.Port() == "" {
+			return "", "", fmt.Errorf("%s address needs a host and port, e.g. %s://logs.example.com:514", u.Scheme, u.Scheme)
+		}
+		return u.Scheme, u.Host, nil
+	case "unix", "unixgram":
+		if u.Path == "" {
+			return "", "", fmt.Errorf("%s address needs a socket path, e.g. %s:///dev/log", u.Scheme, u.Scheme)
+		}
+		return u.Scheme, u.Path, nil
+	}
+	return "", "", fmt.Errorf("unsupported scheme %q (use udp, tcp, unix or unixgram)", u.Scheme)
+}
+
+// logEntry is a log line with the parts structured outputs keep apart
+type logEntry struct {
+	level     LogLevel
+	component string
+	message   string
+	fields    []interface{} // Key and value pairs
+	text      string        // The line as the configured format renders it, without prefix
+}
+
+// logSink is an output taking the level and fields of each line rather than its text
+type logSink interface {
+	writeEntry(entry logEntry) error
+	Close() error
+}
+
+// logSinkState is a sink with the errors writing to it
+type logSinkState struct {
+	name   string
+	sink   logSink
+	errors atomic.Uint64
+}
+
+// LogOutputStats reports where the log goes
+type LogOutputStats struct {
+	Outputs     []string          `json:"outputs"`
+	Fallback    bool              `json:"fallback"`    // An output could not be opened and standard error took its place
+	WriteErrors map[string]uint64 `json:"writeErrors"` // Lines an output failed to take
+}
+
+// LogOutput writes the log to every configured output. The standard streams, the log file
+// and the blackbox take the rendered line; the journal and syslog take each line with its
+// level, so lines keep their severity there. Lines of the standard log package reach them
+// at info level.
+type LogOutput struct {
+	mu      sync.Mutex
+	streams io.Writer   // Standard streams, log file and blackbox
+	lines   *log.Logger // Prefixes text lines as the standard logger does
+	sinks   []*logSinkState
+
+	outputs  []string
+	fallback bool
+}
+
+// lockedWriter serializes the writes of the standard logger and of LogOutput to the streams
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (w lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// OpenLogOutput opens the outputs of the configuration. stderr is where the stderr
+// output writes, the log file when one is set; copies, such as the blackbox, take every
+// line whatever the outputs. An output that cannot be opened is replaced by standard
+// error; the warnings returned say so and are meant to be logged once the output is set.
+func OpenLogOutput(config *Config, stderr io.Writer, copies ...io.Writer) (*LogOutput, []string) {
+	o := &LogOutput{outputs: config.LogOutputs}
+	var warnings []string
+	var streams []io.Writer
+	seen := make(map[string]bool)
+	addStream := func(name string, w io.Writer) {
+		if !seen[name] {
+			seen[name] = true
+			streams = append(streams, w)
+		}
+	}
+
+	for _, output := range config.LogOutputs {
+		switch output {
+		case LogOutputStderr:
+			addStream(LogOutputStderr, stderr)
+		case LogOutputStdout:
+			addStream(LogOutputStdout, os.Stdout)
+		case LogOutputJournald, LogOutputSyslog:
+			if seen[output] {
+				continue
+			}
+			seen[output] = true
+			var sink logSink
+			var err error
+			if output == LogOutputJournald {
+				sink, err = openJournald(journaldSocket)
+			} else {
+				sink, err = openSyslog(config.SyslogAddress, config.SyslogFacility)
+			}
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("⚠️ Log output %s unavailable, logging to standard error instead: %v", output, err))
+				o.fallback = true
+				addStream(LogOutputStderr, stderr)
+				continue
+			}
+			o.sinks = append(o.sinks, &logSinkState{name: output, sink: sink})
+		}
+	}
+	if len(streams) == 0 && len(o.sinks) == 0 {
+		addStream(LogOutputStderr, stderr)
+	}
+	for _, w := range copies {
+		streams = append(streams, w)
+	}
+
+	o.streams = lockedWriter{mu: &o.mu, w: io.MultiWriter(streams...)}
+	o.lines = log.New(o.streams, log.Prefix(), log.Flags())
+	return o, warnings
+}
+
+// Write takes the lines of the standard log package
+func (o *LogOutput) Write(p []byte) (int, error) {
+	n, err := o.streams.Write(p)
+	if len(o.sinks) > 0 {
+		message := strings.TrimSuffix(stripLogPrefix(string(p)), "\n")
+		o.writeSinks(logEntry{level: LogLevelInfo, message: message, text: message})
+	}
+	return n, err
+}
+
+// writeEntry writes a line of a Logger. JSON lines go to the streams as they are, text
+// lines with the standard prefix.
+func (o *LogOutput) writeEntry(entry logEntry, jsonFormat bool) {
+	if jsonFormat {
+		o.streams.Write([]byte(entry.text + "\n"))
+	} else {
+		o.lines.Print(entry.text)
+	}
+	o.writeSinks(entry)
+}
+
+func (o *LogOutput) writeSinks(entry logEntry) {
+	for _, state := range o.sinks {
+		if err := state.sink.writeEntry(entry); err != nil {
+			state.errors.Add(1)
+		}
+	}
+}
+
+// stripLogPrefix removes the date and time the standard logger puts before a line
+func stripLogPrefix(line string) string {
+	flags := log.Flags()
+	line = strings.TrimPrefix(line, log.Prefix())
+	for _, flag := range []int{log.Ldate, log.Ltime} {
+		if flags&flag == 0 {
+			continue
+		}
+		if i := strings.IndexByte(line, ' '); i >= 0 {
+			line = line[i+1:]
+		}
+	}
+	return line
+}
+
+// GetStats returns the outputs and their write errors
+func (o *LogOutput) GetStats() LogOutputStats {
+	if o == nil {
+		return LogOutputStats{Outputs: []string{LogOutputStderr}, WriteErrors: map[string]uint64{}}
+	}
+	stats := LogOutputStats{
+		Outputs:     append([]string(nil), o.outputs...),
+		Fallback:    o.fallback,
+		WriteErrors: make(map[string]uint64, len(o.sinks)),
+	}
+	for _, state := range o.sinks {
+		stats.WriteErrors[state.name] = state.errors.Load()
+	}
+	return stats
+}
+
+// Close closes the journal and syslog connections. The log must no longer write to the
+// output.
+func (o *LogOutput) Close() error {
+	if o == nil {
+		return nil
+	}
+	var firstErr error
+	for _, state := range o.sinks {
+		if err := state.sink.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// syslogSink sends lines to a syslog daemon at the priority of their level
+type syslogSink struct {
+	writer *syslog.Writer
+}
+
+func openSyslog(address, facility string) (*syslogSink, error) {
+	network, raddr, err := ParseSyslogAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	priority, err := ParseSyslogFacility(facility)
+	if err != nil {
+		return nil, err
+	}
+	writer, err := syslog.Dial(network, raddr, priority|syslog.LOG_INFO, logIdentifier)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogSink{writer: writer}, nil
+}
+
+func (s *syslogSink) writeEntry(entry logEntry) error {
+	switch entry.level {
+	case LogLevelDebug:
+		return s.writer.Debug(entry.text)
+	case LogLevelWarn:
+		return s.writer.Warning(entry.text)
+	case LogLevelError:
+		return s.writer.Err(entry.text)
+	}
+	return s.writer.Info(entry.text)
+}
+
+func (s *syslogSink) Close() error {
+	return s.writer.Close()
+}
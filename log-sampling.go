@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Log sampling defaults
+const (
+	DefaultLogRateLimit       = 50               // Identical lines per second before the rest are summarized
+	DefaultLogSummaryInterval = 10 * time.Second // How often suppressed lines are summarized
+
+	logSamplerMaxKeys = 4096 // Messages tracked at once; lines of further ones are never held back
+)
+
+// logSampleKey identifies identical messages. Lines below error level are keyed by their
+// format, so "message send failed" counts as one message whatever the frame. Error lines
+// are keyed by their text, so a one-off error such as a state transition or a failure
+// with new details is never held back by earlier errors of the same kind.
+type logSampleKey struct {
+	component string
+	level     LogLevel
+	message   string
+}
+
+// logSampleEntry counts the lines of one message in the current second and those held
+// back since the last summary
+type logSampleEntry struct {
+	second     int64 // Unix second count refers to
+	count      int
+	suppressed uint64
+	since      time.Time      // First line held back since the last summary
+	last       string         // Text of the last line held back
+	logger     *DefaultLogger // Logger of the last line held back, whose fields the summary keeps
+}
+
+// logSummary is a summary line waiting to be written
+type logSummary struct {
+	logger  *DefaultLogger
+	level   LogLevel
+	message string
+}
+
+// LogSamplingStats reports the limits and how many lines were held back
+type LogSamplingStats struct {
+	RateLimit       int               `json:"rateLimit"` // Identical lines per second, 0 when sampling is off
+	SummaryInterval string            `json:"summaryInterval"`
+	Suppressed      uint64            `json:"suppressed"` // Lines held back since startup
+	Summaries       uint64            `json:"summaries"`  // Summary lines written for them
+	Components      map[string]uint64 `json:"components"` // Lines held back per component
+}
+
+// LogSampler keeps floods of identical log lines from drowning the log, and the gateway
+// writing it. Beyond the rate limit per second, the lines of a message are counted and
+// written as one summary per interval ("previous message repeated 4312 times in 10s").
+// A nil LogSampler lets every line through.
+type LogSampler struct {
+	mu         sync.Mutex
+	limit      int
+	interval   time.Duration
+	entries    map[logSampleKey]*logSampleEntry
+	components map[string]uint64 // Lines held back per component
+
+	suppressed atomic.Uint64
+	summaries  atomic.Uint64
+
+	reset    chan struct{} // Wakes the summary loop after the interval changed
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// NewLogSampler creates a sampler letting limit identical lines per second through, 0
+// disabling it, and summarizing the rest every interval. Start runs the summaries.
+func NewLogSampler(limit int, interval time.Duration) *LogSampler {
+	return &LogSampler{
+		limit:      limit,
+		interval:   interval,
+		entries:    make(map[logSampleKey]*logSampleEntry),
+		components: make(map[string]uint64),
+		reset:      make(chan struct{}, 1),
+		stopChan:   make(chan struct{}),
+	}
+}
+
+// Start starts writing summaries
+func (s *LogSampler) Start() {
+	if s == nil {
+		return
+	}
+	s.wg.Add(1)
+	go s.summaryLoop()
+}
+
+// Stop stops the summaries and writes those pending, so no count is lost on shutdown
+func (s *LogSampler) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+		s.wg.Wait()
+		s.flush(true)
+	})
+}
+
+// SetLimits changes the rate limit and summary interval, as a configuration reload does
+func (s *LogSampler) SetLimits(limit int, interval time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	changed := interval != s.interval
+	s.limit, s.interval = limit, interval
+	s.mu.Unlock()
+
+	if changed {
+		select {
+		case s.reset <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// allow reports whether a line is written, counting it as held back otherwise
+func (s *LogSampler) allow(logger *DefaultLogger, level LogLevel, format, message string) bool {
+	if s == nil {
+		return true
+	}
+	key := logSampleKey{component: logger.component, level: level, message: format}
+	if level >= LogLevelError {
+		key.message = message
+	}
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.limit <= 0 {
+		return true
+	}
+	entry, ok := s.entries[key]
+	if !ok {
+		if len(s.entries) >= logSamplerMaxKeys {
+			return true
+		}
+		entry = &logSampleEntry{}
+		s.entries[key] = entry
+	}
+
+	if second := now.Unix(); entry.second != second {
+		entry.second, entry.count = second, 0
+	}
+	entry.count++
+	if entry.count <= s.limit {
+		return true
+	}
+
+	if entry.suppressed == 0 {
+		entry.since = now
+	}
+	entry.suppressed++
+	entry.last, entry.logger = message, logger
+	s.components[logComponentLabel(logger.component)]++
+	s.suppressed.Add(1)
+	return false
+}
+
+// summaryLoop writes the summaries every interval
+func (s *LogSampler) summaryLoop() {
+	defer s.wg.Done()
+
+	for {
+		timer := time.NewTimer(s.summaryInterval())
+		select {
+		case <-s.stopChan:
+			timer.Stop()
+			return
+		case <-s.reset:
+			timer.Stop()
+		case <-timer.C:
+			s.flush(false)
+		}
+	}
+}
+
+func (s *LogSampler) summaryInterval() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.interval <= 0 {
+		return DefaultLogSummaryInterval
+	}
+	return s.interval
+}
+
+// flush writes a summary for every message with lines held back and forgets messages no
+// longer logged. The summaries are written outside the lock, past the sampler.
+func (s *LogSampler) flush(all bool) {
+	now := time.Now()
+	var summaries []logSummary
+
+	s.mu.Lock()
+	for key, entry := range s.entries {
+		if entry.suppressed > 0 {
+			summaries = append(summaries, logSummary{
+				logger: entry.logger,
+				level:  key.level,
+				message: fmt.Sprintf("🔁 previous message repeated %d times in %s: %s",
+					entry.suppressed, summaryElapsed(now.Sub(entry.since)), entry.last),
+			})
+			entry.suppressed, entry.last, entry.logger = 0, "", nil
+		}
+		if all || entry.second < now.Unix()-1 {
+			delete(s.entries, key)
+		}
+	}
+	s.mu.Unlock()
+
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].message < summaries[j].message })
+	for _, summary := range summaries {
+		summary.logger.write(summary.level, summary.message)
+		s.summaries.Add(1)
+	}
+}
+
+// GetStats returns the limits and the lines held back
+func (s *LogSampler) GetStats() LogSamplingStats {
+	if s == nil {
+		return LogSamplingStats{Components: map[string]uint64{}}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := LogSamplingStats{
+		RateLimit:       s.limit,
+		SummaryInterval: s.interval.String(),
+		Suppressed:      s.suppressed.Load(),
+		Summaries:       s.summaries.Load(),
+		Components:      make(map[string]uint64, len(s.components)),
+	}
+	if stats.RateLimit < 0 {
+		stats.RateLimit = 0
+	}
+	for component, count := range s.components {
+		stats.Components[component] = count
+	}
+	return stats
+}
+
+// logComponentLabel names the component of a line, "default" for lines of no component
+func logComponentLabel(component string) string {
+	if component == "" {
+		return "default"
+	}
+	return component
+}
+
+// summaryElapsed rounds the time a summary covers for reading
+func summaryElapsed(elapsed time.Duration) time.Duration {
+	if elapsed < time.Second {
+		return elapsed.Round(time.Millisecond)
+	}
+	return elapsed.Round(time.Second)
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogLevel is the severity of a log line
+type LogLevel int
+
+// Log levels, from the most verbose
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+// Components with a level of their own. Lines from other parts of the service use the
+// default level.
+const (
+	LogComponentSetup    = "setup"
+	LogComponentWatchdog = "watchdog"
+	LogComponentSender   = "sender"
+	LogComponentAPI      = "api"
+	LogComponentMonitor  = "monitor"
+)
+
+var logComponents = []string{LogComponentSetup, LogComponentWatchdog, LogComponentSender, LogComponentAPI, LogComponentMonitor}
+
+// Log output formats
+const (
+	LogFormatText = "text" // Standard log lines, fields appended as key=value
+	LogFormatJSON = "json" // One JSON object per line
+)
+
+func (l LogLevel) String() string {
+	if l < LogLevelDebug || int(l) >= len(logLevelNames) {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return logLevelNames[l]
+}
+
+// ParseLogLevel parses debug, info, warn (or warning) or error, in any case
+func ParseLogLevel(value string) (LogLevel, error) {
+	name := strings.ToLower(strings.TrimSpace(value))
+	if name == "warning" {
+		name = "warn"
+	}
+	for i, levelName := range logLevelNames {
+		if name == levelName {
+			return LogLevel(i), nil
+		}
+	}
+	return LogLevelInfo, fmt.Errorf("invalid log level %q. Valid options: %v", value, logLevelNames)
+}
+
+// MarshalText writes the level by name, so JSON shows "warn" rather than 2
+func (l LogLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText reads a level by name
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	level, err := ParseLogLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = level
+	return nil
+}
+
+// validLogComponent reports whether a component has a level of its own
+func validLogComponent(component string) bool {
+	for _, name := range logComponents {
+		if name == component {
+			return true
+		}
+	}
+	return false
+}
+
+// LogSettings is what every logger of the service shares: the default level, the level
+// of each component that has one, and the output format. Changing it affects loggers
+// already handed out.
+type LogSettings struct {
+	mu         sync.RWMutex
+	level      LogLevel
+	components map[string]LogLevel
+	format     string
+	noEmoji    bool
+	sampler    *LogSampler // Holds back floods of identical lines; nil lets every line through
+	access     AccessLogSettings
+}
+
+// LogLevelsStatus describes the log levels in effect
+type LogLevelsStatus struct {
+	Level      LogLevel            `json:"level"`      // Of components without their own
+	Components map[string]LogLevel `json:"components"` // Levels set per component
+	Effective  map[string]LogLevel `json:"effective"`  // Level of every component
+	Format     string              `json:"format"`
+	NoEmoji    bool                `json:"noEmoji"`
+	Access     AccessLogSettings   `json:"access"` // HTTP requests logged
+}
+
+// LogLevelsRequest changes log levels at runtime. Omitted fields are kept; components
+// replaces every per-component level, so {} clears them.
+type LogLevelsRequest struct {
+	Level      *LogLevel           `json:"level,omitempty"`
+	Components map[string]LogLevel `json:"components,omitempty"`
+	Access     *AccessLogRequest   `json:"access,omitempty"`
+}
+
+// NewLogSettings creates settings logging info and above as text
+func NewLogSettings() *LogSettings {
+	return &LogSettings{level: LogLevelInfo, components: make(map[string]LogLevel), format: LogFormatText, access: DefaultAccessLogSettings()}
+}
+
+// SetLevels sets the default level and replaces the per-component levels
+func (s *LogSettings) SetLevels(level LogLevel, components map[string]LogLevel) {
+	copied := make(map[string]LogLevel, len(components))
+	for component, componentLevel := range components {
+		copied[component] = componentLevel
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.level, s.components = level, copied
+}
+
+// SetFormat sets the output format, text when empty
+func (s *LogSettings) SetFormat(format string) {
+	if format == "" {
+		format = LogFormatText
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.format = format
+}
+
+// SetNoEmoji sets whether emoji are removed from messages, for terminals and journald
+// setups that do not render them
+func (s *LogSettings) SetNoEmoji(noEmoji bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.noEmoji = noEmoji
+}
+
+// SetAccessLog sets the HTTP requests the access log records
+func (s *LogSettings) SetAccessLog(access AccessLogSettings) {
+	access.SkipPaths = append([]string{}, access.SkipPaths...)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.access = access
+}
+
+// SetSampler sets the sampler every line passes, nil to let every line through
+func (s *LogSettings) SetSampler(sampler *LogSampler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sampler = sampler
+}
+
+// Status returns the levels and format in effect
+func (s *LogSettings) Status() LogLevelsStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := LogLevelsStatus{
+		Level:      s.level,
+		Components: make(map[string]LogLevel, len(s.components)),
+		Effective:  make(map[string]LogLevel, len(logComponents)),
+		Format:     s.format,
+		NoEmoji:    s.noEmoji,
+		Access:     s.access,
+	}
+	status.Access.SkipPaths = append([]string{}, s.access.SkipPaths...)
+	for component, level := range s.components {
+		status.Components[component] = level
+	}
+	for _, component := range logComponents {
+		status.Effective[component] = s.levelLocked(component)
+	}
+	return status
+}
+
+// enabled reports whether a line of the level is logged for the component. Nil
+// settings log info and above.
+func (s *LogSettings) enabled(component string, level LogLevel) bool {
+	if s == nil {
+		return level >= LogLevelInfo
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return level >= s.levelLocked(component)
+}
+
+func (s *LogSettings) levelLocked(component string) LogLevel {
+	if level, ok := s.components[component]; ok {
+		return level
+	}
+	return s.level
+}
+
+func (s *LogSettings) logSampler() *LogSampler {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sampler
+}
+
+// accessLog returns the access log settings; nil settings log every request but those
+// skipped by default
+func (s *LogSettings) accessLog() AccessLogSettings {
+	if s == nil {
+		return DefaultAccessLogSettings()
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.access
+}
+
+func (s *LogSettings) jsonFormat() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.format == LogFormatJSON
+}
+
+func (s *LogSettings) stripEmoji() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.noEmoji
+}
+
+// Logger interface for dependency injection. Printf logs at info level.
+type Logger interface {
+	Printf(format string, v ...interface{})
+	Debugf(format string, v ...interface{})
+	Infof(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+
+	// With returns a logger adding key/value fields to every line, e.g.
+	// With("interface", "can0", "request_id", id)
+	With(keyvals ...interface{}) Logger
+
+	// Named returns the logger of a component, which logs at the component's level
+	Named(component string) Logger
+}
+
+// DefaultLogger implements Logger using standard log package. The zero value logs info
+// and above as text.
+type DefaultLogger struct {
+	settings  *LogSettings
+	component string
+	fields    []interface{} // Alternating keys and values
+}
+
+// NewLogger creates a root logger following settings
+func NewLogger(settings *LogSettings) *DefaultLogger {
+	return &DefaultLogger{settings: settings}
+}
+
+func (l *DefaultLogger) Printf(format string, v ...interface{}) {
+	l.log(LogLevelInfo, format, v)
+}
+
+func (l *DefaultLogger) Debugf(format string, v ...interface{}) {
+	l.log(LogLevelDebug, format, v)
+}
+
+func (l *DefaultLogger) Infof(format string, v ...interface{}) {
+	l.log(LogLevelInfo, format, v)
+}
+
+func (l *DefaultLogger) Warnf(format string, v ...interface{}) {
+	l.log(LogLevelWarn, format, v)
+}
+
+func (l *DefaultLogger) Errorf(format string, v ...interface{}) {
+	l.log(LogLevelError, format, v)
+}
+
+func (l *DefaultLogger) With(keyvals ...interface{}) Logger {
+	if len(keyvals)%2 != 0 {
+		keyvals = append(keyvals, "(missing)")
+	}
+	fields := make([]interface{}, 0, len(l.fields)+len(keyvals))
+	fields = append(append(fields, l.fields...), keyvals...)
+	return &DefaultLogger{settings: l.settings, component: l.component, fields: fields}
+}
+
+func (l *DefaultLogger) Named(component string) Logger {
+	return &DefaultLogger{settings: l.settings, component: component, fields: l.fields}
+}
+
+func (l *DefaultLogger) log(level LogLevel, format string, v []interface{}) {
+	if !l.settings.enabled(l.component, level) {
+		return
+	}
+	message := fmt.Sprintf(format, v...)
+	if !l.settings.logSampler().allow(l, level, format, message) {
+		return
+	}
+	l.write(level, message)
+}
+
+// write writes a line that passed the level and the sampler
+func (l *DefaultLogger) write(level LogLevel, message string) {
+	if l.settings.stripEmoji() {
+		message = stripEmoji(message)
+	}
+
+	jsonFormat := l.settings.jsonFormat()
+	if jsonFormat {
+		line := l.jsonLine(level, message)
+		if output, ok := log.Writer().(*LogOutput); ok {
+			output.writeEntry(l.entry(level, message, string(bytes.TrimSuffix(line, []byte("\n")))), true)
+			return
+		}
+		log.Writer().Write(line)
+		return
+	}
+
+	var line strings.Builder
+	line.WriteString(message)
+	if l.component != "" {
+		line.WriteString(" component=" + l.component)
+	}
+	for i := 0; i+1 < len(l.fields); i += 2 {
+		line.WriteString(" " + fmt.Sprint(l.fields[i]) + "=" + textFieldValue(l.fields[i+1]))
+	}
+	if output, ok := log.Writer().(*LogOutput); ok {
+		output.writeEntry(l.entry(level, message, line.String()), false)
+		return
+	}
+	log.Print(line.String())
+}
+
+// entry is a line with its level, component and fields, for outputs keeping them apart
+func (l *DefaultLogger) entry(level LogLevel, message, text string) logEntry {
+	return logEntry{level: level, component: l.component, message: message, fields: l.fields, text: text}
+}
+
+// jsonLine renders a line as a JSON object: timestamp, level, component, message, then
+// the fields
+func (l *DefaultLogger) jsonLine(level LogLevel, message string) []byte {
+	entry := map[string]interface{}{
+		"timestamp": time.Now().Format(time.RFC3339Nano),
+		"level":     level.String(),
+		"message":   message,
+	}
+	if l.component != "" {
+		entry["component"] = l.component
+	}
+	for i := 0; i+1 < len(l.fields); i += 2 {
+		value := l.fields[i+1]
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		entry[fmt.Sprint(l.fields[i])] = value
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		line, _ = json.Marshal(map[string]interface{}{"timestamp": entry["timestamp"], "level": entry["level"], "message": message})
+	}
+	return append(line, '\n')
+}
+
+// textFieldValue formats a field value, quoted when it would not read back as one word
+func textFieldValue(value interface{}) string {
+	text := fmt.Sprint(value)
+	if text == "" || strings.ContainsAny(text, " =\"\t\n") {
+		return strconv.Quote(text)
+	}
+	return text
+}
+
+// stripEmoji removes the emoji of a message and the space following each
+func stripEmoji(message string) string {
+	var stripped strings.Builder
+	afterEmoji := false
+	for _, r := range message {
+		switch {
+		case isEmoji(r):
+			afterEmoji = true
+			continue
+		case afterEmoji && r == ' ':
+			afterEmoji = false
+			continue
+		}
+		afterEmoji = false
+		stripped.WriteRune(r)
+	}
+	return stripped.String()
+}
+
+// isEmoji reports whether r is a pictograph, symbol or emoji modifier
+func isEmoji(r rune) bool {
+	return (r >= 0x2300 && r <= 0x23FF) || // Miscellaneous technical: ⏱ ⏳ ⏸
+		(r >= 0x25A0 && r <= 0x27BF) || // Shapes, symbols and dingbats: ▶ ⚠ ✅ ❌
+		(r >= 0x1F000 && r <= 0x1FAFF) || // Pictographs
+		r == 0xFE0F || r == 0x200D // Variation selector and joiner
+}
+
+// sortedLogComponents returns the per-component levels as component=level, sorted
+func sortedLogComponents(components map[string]LogLevel) []string {
+	pairs := make([]string, 0, len(components))
+	for component, level := range components {
+		pairs = append(pairs, component+"="+level.String())
+	}
+	sort.Strings(pairs)
+	return pairs
+}
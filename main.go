@@ -2,11 +2,16 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -16,22 +21,50 @@ import (
 // Service represents the main CAN communication service
 type Service struct {
 	config           *Config
-	configProvider   ConfigProvider
+	configProvider   *DefaultConfigProvider
 	setupManager     *InterfaceSetupManager
 	interfaceManager *InterfaceManager
 	messageSender    *MessageSender
+	sendAudit        *SendAuditLog
+	audit            *AuditLog // Control-plane operations, in memory and in -audit-log
+	frameCapture     *FrameCapture
+	j1939            *J1939Manager // J1939 sockets of the interfaces in -j1939; nil when none
 	messageListener  *CanMessageListener
 	watchdog         *Watchdog
+	notifier         *Notifier
+	otlpExporter     *OTLPExporter
+	statsd           *StatsDEmitter // nil without -statsd-address
+	simulator        *NodeSimulator
+	sequences        *SequenceRunner
+	httpMetrics      *HTTPMetrics
 	monitor          *Monitor
 	apiHandler       *APIHandler
 	server           *http.Server
+	unixServer       *http.Server // Serves the same engine on -listen-unix; nil when disabled
+	unixListener     net.Listener // Socket of unixServer once started
+	ipcServer        *IPCServer   // Binary protocol on -ipc-socket; nil when disabled
 	logger           Logger
+	logSettings      *LogSettings     // Levels and format shared by every logger of the service
+	logSampler       *LogSampler      // Summarizes floods of identical log lines; nil until initialized
+	logOutput        *LogOutput       // Writes the log to the configured outputs; nil until initialized
+	logFile          *RotatingFile    // Service log on -log-file; nil logs to standard error
+	blackbox         *Blackbox        // Dumps recent logs and frames to -blackbox-dir; nil when disabled
+	setupErrors      map[string]error // Setup failures by interface, at startup or reload
+	reloadMu         sync.Mutex       // Serializes configuration reloads with each other and Stop
+	stateMu          sync.RWMutex     // Guards config and setupErrors for readers such as GET /status; held by writers together with reloadMu
+	configEpoch      int64            // Start time, so configuration ETags differ across restarts
+	configGeneration uint64           // Reloads that changed an effective setting
+	stopped          bool             // Stop began; reloads are refused
 }
 
 // NewService creates a new CAN communication service
 func NewService() *Service {
+	logSettings := NewLogSettings()
 	return &Service{
-		logger: &DefaultLogger{},
+		logger:      NewLogger(logSettings),
+		logSettings: logSettings,
+		setupErrors: make(map[string]error),
+		configEpoch: time.Now().UnixNano(),
 	}
 }
 
@@ -49,13 +82,55 @@ func (s *Service) Initialize() error {
 		return fmt.Errorf("configuration validation failed: %w", err)
 	}
 
-	s.config = config
+	s.setConfig(config)
 	s.configProvider = NewDefaultConfigProvider(config)
+	s.logSettings.SetLevels(config.LogLevel, config.LogLevels)
+	s.logSettings.SetFormat(config.LogFormat)
+	s.logSettings.SetNoEmoji(config.LogNoEmoji)
+	s.logSettings.SetAccessLog(config.AccessLog)
+	if config.ValidateOnly {
+		return nil
+	}
+	s.logSampler = NewLogSampler(config.LogRateLimit, config.LogSummaryInterval)
+	s.logSampler.Start()
+	s.logSettings.SetSampler(s.logSampler)
+	if config.LogFile != "" {
+		logFile, err := OpenRotatingFile(config.LogFile, config.LogRotation)
+		if err != nil {
+			return err
+		}
+		s.logFile = logFile
+		log.SetOutput(logFile)
+	}
+	if config.BlackboxDir != "" {
+		blackbox, err := NewBlackbox(config.BlackboxDir, config.BlackboxMaxFiles, s.logger)
+		if err != nil {
+			return err
+		}
+		s.blackbox = blackbox
+	}
+	var stderr io.Writer = os.Stderr
+	if s.logFile != nil {
+		stderr = s.logFile
+	}
+	var copies []io.Writer
+	if s.blackbox != nil {
+		copies = append(copies, s.blackbox)
+	}
+	logOutput, warnings := OpenLogOutput(config, stderr, copies...)
+	s.logOutput = logOutput
+	log.SetOutput(logOutput)
+	for _, warning := range warnings {
+		s.logger.Warnf("%s", warning)
+	}
 
-	s.logger.Printf("🚀 Starting CAN Communication Service")
+	s.logger.Printf("🚀 Starting CAN Communication Service, %s", GetBuildInfo())
 	s.logger.Printf("📋 Configuration:")
 	s.logger.Printf("   - CAN Ports: %v", config.CanPorts)
 	s.logger.Printf("   - Server Port: %s", config.Port)
+	if config.DryRun {
+		s.logger.Printf("   - Dry Run: enabled (frames will not be written to the bus)")
+	}
 
 	// Initialize components
 	if err := s.initializeComponents(); err != nil {
@@ -80,6 +155,9 @@ func (s *Service) Initialize() error {
 		// We continue even if some listeners failed to start
 	}
 
+	// Wake up devices once they can be talked to; replies are already listened for
+	s.runSequence(context.Background(), SequenceStartup, s.config.StartupSequence)
+
 	// Setup HTTP server
 	s.setupHTTPServer()
 
@@ -88,12 +166,20 @@ func (s *Service) Initialize() error {
 
 // initializeComponents initializes all service components
 func (s *Service) initializeComponents() error {
+	// Components with their own log level
+	setupLogger := s.logger.Named(LogComponentSetup)
+	senderLogger := s.logger.Named(LogComponentSender)
+	watchdogLogger := s.logger.Named(LogComponentWatchdog)
+	monitorLogger := s.logger.Named(LogComponentMonitor)
+	apiLogger := s.logger.Named(LogComponentAPI)
+
 	// Create command executor for interface setup
-	commandExecutor := NewSystemCommandExecutor()
+	commandExecutor := NewSystemCommandExecutor(s.config.CommandTimeout, setupLogger)
 
 	// Create interface setup manager
-	setupConfig := DefaultInterfaceSetupConfig()
-	s.setupManager = NewInterfaceSetupManager(setupConfig, commandExecutor, s.logger)
+	stateReader := NewNetlinkStateReader()
+	s.setupManager = NewInterfaceSetupManager(setupConfigFor(s.config), commandExecutor, setupLogger)
+	s.setupManager.SetStateReader(stateReader)
 
 	// Validate setup configuration
 	if err := s.setupManager.ValidateSetupConfig(); err != nil {
@@ -107,18 +193,129 @@ func (s *Service) initializeComponents() error {
 	s.interfaceManager = NewInterfaceManager(s.configProvider, socketProvider, s.logger)
 
 	// Create message sender
-	s.messageSender = NewMessageSender(s.interfaceManager, s.configProvider, socketProvider, s.logger)
+	s.messageSender = NewMessageSender(s.interfaceManager, s.configProvider, socketProvider, senderLogger)
+	s.messageSender.SetStateReader(stateReader)
+	if s.config.SendAuditLog != "" {
+		auditLog, err := NewSendAuditLog(s.config.SendAuditLog, senderLogger)
+		if err != nil {
+			return err
+		}
+		s.sendAudit = auditLog
+		s.messageSender.SetAuditLog(auditLog)
+	}
+	audit, err := NewAuditLog(s.config.AuditLog, s.config.AuditLogRotation, DefaultAuditLogEntries, apiLogger)
+	if err != nil {
+		return err
+	}
+	s.audit = audit
+	if s.config.Capture.Dir != "" {
+		frameCapture, err := NewFrameCapture(s.config.Capture, s.configProvider, s.logger)
+		if err != nil {
+			return err
+		}
+		s.frameCapture = frameCapture
+		s.messageSender.SetFrameCapture(frameCapture)
+	}
 
 	// Create message listener (new component)
 	maxMessages := 100 // Configure maximum messages per interface
 	s.messageListener = NewCanMessageListener(maxMessages, s.logger)
+	s.messageListener.SetReceiveBufferConfig(socketProvider, s.configProvider)
+
+	// J1939 sockets run next to the raw listener and send through the sender's checks
+	if len(s.config.J1939) > 0 {
+		s.j1939 = NewJ1939Manager(s.config.J1939, s.messageSender, maxMessages, senderLogger)
+	}
 
 	// Create watchdog
 	watchdogConfig := DefaultWatchdogConfig()
-	s.watchdog = NewWatchdog(s.interfaceManager, watchdogConfig, s.logger)
+	watchdogConfig.RecoveryBaseDelay = s.config.RecoveryBaseDelay
+	watchdogConfig.RecoveryMaxDelay = s.config.RecoveryMaxDelay
+	watchdogConfig.RecoveryStrategy = s.config.RecoveryStrategy
+	watchdogConfig.MaxRecoveryAttempts = s.config.RecoveryMaxAttempts
+	watchdogConfig.EventLogFile = s.config.WatchdogEventLog
+	watchdogConfig.SilenceThresholds = s.config.ExpectTraffic
+	watchdogConfig.CheckInterval = s.config.WatchdogInterval
+	watchdogConfig.FailureThreshold = s.config.WatchdogFailureThreshold
+	watchdogConfig.SuccessThreshold = s.config.WatchdogSuccessThreshold
+	watchdogConfig.RecoveryCooldown = s.config.WatchdogCooldown
+	watchdogConfig.InterfaceOverrides = make(map[string]WatchdogInterfaceConfig)
+	for _, ifName := range s.config.CanPorts {
+		override := WatchdogInterfaceConfig{
+			CheckInterval:    s.config.WatchdogIntervals[ifName],
+			FailureThreshold: s.config.WatchdogFailureThresholds[ifName],
+			SuccessThreshold: s.config.WatchdogSuccessThresholds[ifName],
+			RecoveryCooldown: s.config.WatchdogCooldowns[ifName],
+		}
+		if override != (WatchdogInterfaceConfig{}) {
+			watchdogConfig.InterfaceOverrides[ifName] = override
+		}
+	}
+	s.watchdog = NewWatchdog(s.interfaceManager, s.messageListener, watchdogConfig, watchdogLogger)
+	if s.config.EnableHealthCheck {
+		s.messageSender.SetWatchdog(s.watchdog)
+	}
 
-	// Create monitor
-	s.monitor = NewMonitor(s.interfaceManager, s.watchdog, s.configProvider)
+	// Create monitor, fed with received frames for per-ID and error frame statistics
+	s.monitor = NewMonitor(s.interfaceManager, s.watchdog, s.configProvider, monitorLogger)
+	s.monitor.SetErrorBurstThreshold(s.config.ErrorBurstThreshold)
+	s.monitor.SetSetupManager(s.setupManager)
+	s.monitor.SetTxGate(s.messageSender.TxGate())
+	s.sequences = NewSequenceRunner(s.messageSender, senderLogger)
+	s.monitor.SetSequenceRunner(s.sequences)
+	s.monitor.SetAlertRules(s.config.AlertRules)
+	observers := frameObservers{s.monitor}
+
+	// The blackbox only copies frames into its ring
+	if s.blackbox != nil {
+		s.blackbox.SetWatchdog(s.watchdog)
+		observers = append(observers, s.blackbox)
+	}
+
+	// The capture only queues frames, so it never holds up the observers after it
+	if s.frameCapture != nil {
+		observers = append(observers, s.frameCapture)
+	}
+
+	// Simulated nodes see received frames after the monitor and answer through the sender
+	if len(s.config.SimulatedNodes) > 0 {
+		s.simulator = NewNodeSimulator(s.config.SimulatedNodes, s.messageSender, s.logger)
+		observers = append(observers, s.simulator)
+	}
+
+	// IPC clients subscribe to received frames and send through the sender
+	if s.config.IPCSocket != "" {
+		s.ipcServer = NewIPCServer(s.messageSender, s.logger)
+		observers = append(observers, s.ipcServer)
+	}
+	s.messageListener.SetFrameObserver(observers)
+
+	// Create webhook notifier; without URLs it discards everything until a reload adds some
+	s.notifier = NewNotifier(notifierConfigFor(s.config), s.logger)
+	s.notifier.Start()
+	s.watchdog.SetNotifier(s.notifier)
+	s.setupManager.SetNotifier(s.notifier)
+	s.monitor.SetNotifier(s.notifier)
+
+	// Create OTLP exporter; without a configured endpoint it is nil and nothing runs
+	s.httpMetrics = NewHTTPMetrics()
+	s.otlpExporter = NewOTLPExporter(s.config.OTLP, s.logger)
+	if s.otlpExporter != nil {
+		s.otlpExporter.SetMetricsSource(func(start, now time.Time) []otlpMetric {
+			return collectOTLPMetrics(s.monitor, s.httpMetrics, start, now)
+		})
+		s.otlpExporter.Start()
+		s.messageSender.SetTracer(s.otlpExporter)
+	}
+
+	// Create StatsD emitter; it pushes the same metrics as /metrics and coexists with it
+	s.statsd = NewStatsDEmitter(s.config.StatsD, s.logger)
+	if s.statsd != nil {
+		s.statsd.SetMetricsSource(func(start, now time.Time) []otlpMetric {
+			return collectOTLPMetrics(s.monitor, s.httpMetrics, start, now)
+		})
+		s.statsd.Start()
+	}
 
 	// Create API handler with setup manager and message listener
 	s.apiHandler = NewAPIHandlerWithSetupAndListener(
@@ -126,12 +323,86 @@ func (s *Service) initializeComponents() error {
 		s.monitor,
 		s.setupManager,
 		s.messageListener,
-		s.logger,
+		apiLogger,
 	)
+	if s.simulator != nil {
+		s.apiHandler.SetNodeSimulator(s.simulator)
+	}
+	if s.config.DBC != nil {
+		s.apiHandler.SetDBC(s.config.DBC)
+	}
+	if len(s.config.NamedMessages) > 0 {
+		s.apiHandler.SetNamedMessages(s.config.NamedMessages)
+	}
+	if s.frameCapture != nil {
+		s.apiHandler.SetFrameCapture(s.frameCapture)
+	}
+	s.apiHandler.SetAPIDocs(s.config.APIDocs)
+	s.apiHandler.SetLegacyRoutes(s.config.LegacyAPIRoutes)
+	s.apiHandler.SetIPCServer(s.ipcServer)
+	s.apiHandler.SetStatsD(s.statsd)
+	s.apiHandler.SetConfigManager(s)
+	s.apiHandler.SetLogSettings(s.logSettings)
+	s.apiHandler.SetLogSampler(s.logSampler)
+	s.apiHandler.SetLogOutput(s.logOutput)
+	s.apiHandler.SetLogFile(s.logFile)
+	s.apiHandler.SetAuditLog(s.audit)
+	if s.blackbox != nil {
+		s.apiHandler.SetBlackbox(s.blackbox)
+	}
+	if s.j1939 != nil {
+		s.apiHandler.SetJ1939(s.j1939)
+	}
+	s.apiHandler.SetMaxBatchFrames(s.config.MaxBatchFrames)
+	s.apiHandler.SetIdempotencyCache(NewIdempotencyCache(s.config.IdempotencyCacheSize, s.config.IdempotencyTTL))
+
+	// The watchdog only runs with health checks enabled, so readiness only requires it then
+	var probedWatchdog *Watchdog
+	if s.config.EnableHealthCheck {
+		probedWatchdog = s.watchdog
+	}
+	s.apiHandler.SetProbes(NewProbes(s.interfaceManager, s.configProvider, probedWatchdog,
+		s.messageListener, s.config.ReadyRequiresAll, s.config.LivenessTimeout))
 
 	return nil
 }
 
+// setupConfigFor returns the interface setup configuration of a service configuration
+func setupConfigFor(config *Config) InterfaceSetupConfig {
+	setupConfig := DefaultInterfaceSetupConfig()
+	setupConfig.TimeoutSeconds = int(config.CommandTimeout / time.Second)
+	setupConfig.RetryAttempts = config.SetupRetry
+	setupConfig.RetryDelay = config.SetupDelay
+	setupConfig.RetryBackoff = config.SetupRetryBackoff
+	setupConfig.MaxRetryDelay = config.SetupMaxDelay
+	setupConfig.InterfaceRetryAttempts = config.SetupRetries
+	setupConfig.InterfaceRetryDelays = config.SetupDelays
+	setupConfig.Bitrate = config.Bitrate
+	setupConfig.RestartMs = config.RestartMs
+	setupConfig.InterfaceBitrates = config.Bitrates
+	setupConfig.SamplePoint = config.SamplePoint
+	setupConfig.InterfaceSamplePoints = config.SamplePoints
+	setupConfig.InterfaceTripleSampling = make(map[string]bool, len(config.TripleSampling))
+	for _, ifName := range config.TripleSampling {
+		setupConfig.InterfaceTripleSampling[ifName] = true
+	}
+	setupConfig.InterfaceOneShot = make(map[string]bool, len(config.OneShot))
+	for _, ifName := range config.OneShot {
+		setupConfig.InterfaceOneShot[ifName] = true
+	}
+	return setupConfig
+}
+
+// notifierConfigFor returns the webhook notifier configuration of a service configuration
+func notifierConfigFor(config *Config) NotifierConfig {
+	notifierConfig := DefaultNotifierConfig()
+	notifierConfig.URLs = config.WebhookURLs
+	notifierConfig.EventTypes = config.WebhookEvents
+	notifierConfig.MinSeverity = config.WebhookMinSeverity
+	notifierConfig.Instance = config.InstanceName
+	return notifierConfig
+}
+
 // setupCanInterfaces sets up all configured CAN interfaces
 func (s *Service) setupCanInterfaces() error {
 	s.logger.Printf("🔧 Setting up CAN interfaces...")
@@ -147,12 +418,14 @@ func (s *Service) setupCanInterfaces() error {
 	var setupErrors []string
 	successCount := 0
 
-	for _, ifName := range s.config.CanPorts {
+	canPorts := s.currentConfig().CanPorts
+	for _, ifName := range canPorts {
 		s.logger.Printf("🔧 Setting up interface %s...", ifName)
 
 		err := s.setupManager.SetupInterfaceWithRetry(ifName)
 		if err != nil {
 			setupErrors = append(setupErrors, fmt.Sprintf("%s: %v", ifName, err))
+			s.setSetupError(ifName, err)
 			s.logger.Printf("❌ Failed to setup %s: %v", ifName, err)
 		} else {
 			successCount++
@@ -170,7 +443,7 @@ func (s *Service) setupCanInterfaces() error {
 		return fmt.Errorf("failed to setup any CAN interfaces: %v", setupErrors)
 	}
 
-	s.logger.Printf("🎯 Successfully set up %d/%d CAN interfaces", successCount, len(s.config.CanPorts))
+	s.logger.Printf("🎯 Successfully set up %d/%d CAN interfaces", successCount, len(canPorts))
 
 	if len(setupErrors) > 0 {
 		return fmt.Errorf("partial setup failure: %v", setupErrors)
@@ -203,7 +476,7 @@ func (s *Service) startMessageListening() error {
 	}
 
 	// Also try to start listening on configured ports that might become active later
-	for _, ifName := range s.config.CanPorts {
+	for _, ifName := range s.currentConfig().CanPorts {
 		// Skip if already handled above
 		if _, exists := activeInterfaces[ifName]; exists {
 			continue
@@ -234,10 +507,31 @@ func (s *Service) setupHTTPServer() {
 	gin.SetMode(gin.ReleaseMode)
 
 	// Create Gin engine with custom middleware
+	apiLogger := s.logger.Named(LogComponentAPI)
 	r := gin.New()
-	r.Use(RecoveryMiddleware(s.logger))
-	r.Use(LoggingMiddleware(s.logger))
-	r.Use(CORSMiddleware())
+	r.Use(RequestIDMiddleware())
+	// Only trusted proxies may change the client address gin reports; the list was validated in ParseConfig
+	_ = r.SetTrustedProxies(networkStrings(s.config.TrustedProxies))
+	r.Use(RecoveryMiddleware(apiLogger, s.blackbox))
+	s.apiHandler.SetHTTPMetrics(s.httpMetrics)
+	r.Use(LoggingMiddleware(apiLogger, s.logSettings, s.httpMetrics))
+	r.Use(BodyLimitMiddleware(s.config.MaxBodySize, apiLogger))
+	// Before the access checks, so denied calls are audited too
+	r.Use(AuditMiddleware(s.audit))
+	if len(s.config.AllowedNetworks) > 0 {
+		r.Use(IPAllowlistMiddleware(NewIPAllowlist(s.config.AllowedNetworks, s.config.TrustedProxies), apiLogger))
+	}
+	if s.otlpExporter.TracesEnabled() {
+		r.Use(TracingMiddleware(s.otlpExporter))
+	}
+	r.Use(CORSMiddleware(s.config.CORS, apiLogger))
+	if s.config.TLSClientCA != "" {
+		r.Use(ClientAuthMiddleware(s.config.ClientPermissions, apiLogger))
+	}
+	if s.config.APIKeys != nil {
+		r.Use(APIKeyMiddleware(s.config.APIKeys, apiLogger))
+		s.apiHandler.SetAPIKeys(s.config.APIKeys)
+	}
 
 	// Setup API routes
 	s.apiHandler.SetupRoutes(r)
@@ -252,27 +546,87 @@ func (s *Service) setupHTTPServer() {
 		IdleTimeout:  120 * time.Second,
 	}
 
-	s.logger.Printf("🌐 CAN Communication Service will run at http://localhost%s", serverAddr)
+	scheme := "http"
+	if s.config.TLSCertFile != "" {
+		// Validated in ValidateConfig, so building the TLS configuration cannot fail here
+		s.server.TLSConfig, _ = buildTLSConfig(s.config.TLSClientCA)
+		scheme = "https"
+	}
+
+	s.logger.Printf("🌐 CAN Communication Service will run at %s://localhost%s", scheme, serverAddr)
+
+	// The Unix socket serves plain HTTP; its file permissions control who connects
+	if s.config.ListenUnix != "" {
+		s.unixServer = &http.Server{
+			Handler:      r,
+			ReadTimeout:  5 * time.Second,
+			WriteTimeout: 10 * time.Second,
+			IdleTimeout:  120 * time.Second,
+			ConnContext:  unixSocketConnContext(s.config.UnixSocketTrusted),
+		}
+		s.logger.Printf("🔌 CAN Communication Service will also run at unix:%s", s.config.ListenUnix)
+	}
 }
 
 // Start starts the service
 func (s *Service) Start(ctx context.Context) error {
+	config := s.currentConfig()
+
 	// Start watchdog
-	if s.config.EnableHealthCheck {
+	if config.EnableHealthCheck {
 		if err := s.watchdog.Start(ctx); err != nil {
 			return fmt.Errorf("failed to start watchdog: %w", err)
 		}
 	}
 
+	// Start alert rule evaluation (no-op without rules)
+	s.monitor.StartAlerting()
+
+	// Start simulated nodes (test mode)
+	if s.simulator != nil {
+		s.simulator.Start()
+	}
+
+	// Open the J1939 sockets once the interfaces are set up
+	s.j1939.Start()
+
 	// Start Node Finder in a separate goroutine
-	if s.config.EnableFinder {
-		go NodeFinder(s.config.SetupFinderInterval)
+	if config.EnableFinder {
+		go NodeFinder(config.SetupFinderInterval)
+	}
+
+	// Create the Unix socket before serving, so a socket in use fails the start
+	if s.unixServer != nil {
+		listener, err := listenUnixSocket(config.ListenUnix, config.UnixSocketMode, config.UnixSocketOwner)
+		if err != nil {
+			return fmt.Errorf("failed to create unix socket: %w", err)
+		}
+		s.unixListener = listener
+		go func() {
+			s.logger.Printf("🔌 Starting HTTP server on unix:%s (mode %04o, trusted=%t)",
+				config.ListenUnix, config.UnixSocketMode, config.UnixSocketTrusted)
+			if err := s.unixServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+				s.logger.Printf("❌ Unix socket server error: %v", err)
+			}
+		}()
+	}
+
+	if s.ipcServer != nil {
+		if err := s.ipcServer.Start(config.IPCSocket, config.UnixSocketMode, config.UnixSocketOwner); err != nil {
+			return fmt.Errorf("failed to create IPC socket: %w", err)
+		}
 	}
 
 	// Start HTTP server in a goroutine
 	go func() {
 		s.logger.Printf("🌐 Starting HTTP server on %s", s.server.Addr)
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if config.TLSCertFile != "" {
+			err = s.server.ListenAndServeTLS(config.TLSCertFile, config.TLSKeyFile)
+		} else {
+			err = s.server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			s.logger.Printf("❌ HTTP server error: %v", err)
 		}
 	}()
@@ -286,19 +640,54 @@ func (s *Service) Start(ctx context.Context) error {
 func (s *Service) Stop(ctx context.Context) error {
 	s.logger.Printf("🛑 Stopping CAN Communication Service...")
 
-	// Stop message listening first
+	// A reload in progress finishes first; later ones are refused
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	s.stopped = true
+	config := s.currentConfig()
+
+	// Stop simulated nodes before the interfaces they answer on
+	if s.simulator != nil {
+		s.simulator.Stop()
+	}
+
+	// Say goodbye while sends are still accepted; ctx bounds the delays
+	s.runSequence(ctx, SequenceShutdown, config.ShutdownSequence)
+
+	// Stop transmit tasks, refuse new sends and let those in flight finish before anything
+	// they use is closed. The drain timeout cannot outlast ctx, so the overall shutdown
+	// timeout still wins.
+	if s.messageSender != nil {
+		s.messageSender.Tasks().Cancel()
+		s.logger.Printf("📤 Draining sends (up to %v)...", config.DrainTimeout)
+		drained := s.messageSender.Drain(ctx, config.DrainTimeout)
+		s.logger.Printf("📤 Send drain finished in %v: %d flushed, %d dropped, %d rejected",
+			drained.Elapsed.Round(time.Millisecond), drained.Flushed, drained.Dropped, drained.Rejected)
+	}
+
+	// Disconnect IPC clients once their sends finished, and remove the socket
+	s.ipcServer.Stop()
+
+	// Stop message listening
 	if s.messageListener != nil {
 		s.logger.Printf("🛑 Stopping message listener...")
 		if err := s.messageListener.Shutdown(); err != nil {
 			s.logger.Printf("Warning: failed to stop message listener: %v", err)
 		}
 	}
+	s.j1939.Close()
 
 	// Stop watchdog
 	if err := s.watchdog.Stop(); err != nil {
 		s.logger.Printf("Warning: failed to stop watchdog: %v", err)
 	}
 
+	// Stop alerting before the notifier it publishes to
+	s.monitor.StopAlerting()
+
+	// Stop webhook notifier
+	s.notifier.Stop()
+
 	// Stop HTTP server
 	if s.server != nil {
 		if err := s.server.Shutdown(ctx); err != nil {
@@ -306,25 +695,121 @@ func (s *Service) Stop(ctx context.Context) error {
 		}
 	}
 
+	// Stop the Unix socket server and remove its socket, never one another process created
+	if s.unixListener != nil {
+		if err := s.unixServer.Shutdown(ctx); err != nil {
+			s.logger.Printf("Warning: unix socket server shutdown error: %v", err)
+		}
+		_ = s.unixListener.Close() // In case Serve had not taken it over yet
+		if err := os.Remove(config.ListenUnix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			s.logger.Printf("Warning: failed to remove unix socket %s: %v", config.ListenUnix, err)
+		}
+	}
+
+	// Write the remaining audit records once no more frames are sent
+	s.sendAudit.Stop()
+	s.audit.Close()
+
+	// Write the remaining captured frames once no more frames are sent or received
+	s.frameCapture.Close()
+
+	// Flush remaining spans and metrics once no more requests arrive
+	s.otlpExporter.Stop()
+	s.statsd.Stop()
+
 	// Cleanup CAN interfaces
 	if s.interfaceManager != nil {
 		s.interfaceManager.Cleanup()
 	}
 
-	// Teardown CAN interfaces (new step)
+	// Teardown CAN interfaces (new step), unless another process owns their lifecycle
 	if s.setupManager != nil {
-		s.teardownCanInterfaces()
+		if config.TeardownOnExit {
+			s.teardownCanInterfaces(config.CanPorts)
+		} else {
+			s.logger.Printf("🔼 Leaving CAN interfaces up on exit (-teardown-on-exit=false)")
+		}
 	}
 
 	s.logger.Printf("✅ CAN Communication Service stopped")
+
+	// Close the log file last, so it holds every line of the shutdown and the summaries
+	// of lines held back
+	s.logSettings.SetSampler(nil)
+	s.logSampler.Stop()
+	log.SetOutput(os.Stderr)
+	if err := s.logOutput.Close(); err != nil {
+		s.logger.Printf("Warning: failed to close log output: %v", err)
+	}
+	s.blackbox.Close()
+	if s.logFile != nil {
+		if err := s.logFile.Close(); err != nil {
+			s.logger.Printf("Warning: failed to close log file: %v", err)
+		}
+	}
 	return nil
 }
 
-// teardownCanInterfaces tears down all CAN interfaces
-func (s *Service) teardownCanInterfaces() {
+// runSequence sends the startup or shutdown sequence on the interfaces that were set up
+// and initialized. Failures are logged and reported in the system status only.
+func (s *Service) runSequence(ctx context.Context, phase string, steps []SequenceStep) {
+	if s.sequences == nil || len(steps) == 0 {
+		return
+	}
+
+	config, setupErrors := s.setupState()
+	var ready []string
+	for _, ifName := range config.CanPorts {
+		if _, ok := s.interfaceManager.GetInterface(ifName); ok && setupErrors[ifName] == nil {
+			ready = append(ready, ifName)
+		}
+	}
+	s.sequences.Run(ctx, phase, steps, config.CanPorts, ready)
+}
+
+// setupState returns the configuration in effect and a copy of the setup failures by
+// interface. A reload replaces the configuration rather than changing it, so the one
+// returned stays consistent.
+func (s *Service) setupState() (*Config, map[string]error) {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	setupErrors := make(map[string]error, len(s.setupErrors))
+	for ifName, err := range s.setupErrors {
+		setupErrors[ifName] = err
+	}
+	return s.config, setupErrors
+}
+
+// currentConfig returns the configuration in effect
+func (s *Service) currentConfig() *Config {
+	s.stateMu.RLock()
+	defer s.stateMu.RUnlock()
+	return s.config
+}
+
+// setConfig puts a configuration in effect
+func (s *Service) setConfig(config *Config) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.config = config
+}
+
+// setSetupError records the setup failure of an interface, or clears it for nil
+func (s *Service) setSetupError(ifName string, err error) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	if err == nil {
+		delete(s.setupErrors, ifName)
+		return
+	}
+	s.setupErrors[ifName] = err
+}
+
+// teardownCanInterfaces tears down the CAN interfaces of the configuration in effect
+func (s *Service) teardownCanInterfaces(interfaces []string) {
 	s.logger.Printf("🔽 Tearing down CAN interfaces...")
 
-	for _, ifName := range s.config.CanPorts {
+	for _, ifName := range interfaces {
 		if err := s.setupManager.TeardownInterface(ifName); err != nil {
 			s.logger.Printf("⚠️ Warning: failed to teardown %s: %v", ifName, err)
 		}
@@ -334,48 +819,64 @@ func (s *Service) teardownCanInterfaces() {
 }
 
 // GetStatus returns current service status
-func (s *Service) GetStatus() map[string]interface{} {
+func (s *Service) GetStatus() ServiceStatus {
 	if s.monitor == nil {
-		return map[string]interface{}{
-			"status": "not_initialized",
+		return ServiceStatus{
+			SchemaVersion: StatusSchemaVersion,
+			BuildInfo:     GetBuildInfo(),
+			Status:        "not_initialized",
 		}
 	}
 
 	systemStatus := s.monitor.GetSystemStatus()
+	config, setupErrors := s.setupState()
 
 	// Add setup manager status
-	setupStatus := make(map[string]interface{})
+	setupStatus := &SetupStatus{}
 	if s.setupManager != nil {
-		setupStatus["config"] = s.setupManager.GetSetupConfig()
+		setupConfig := s.setupManager.GetSetupConfig()
+		setupStatus.Config = &setupConfig
 
 		// Get interface states
-		interfaceStates := make(map[string]interface{})
-		for _, ifName := range s.config.CanPorts {
+		setupStatus.InterfaceStates = make(map[string]SetupInterfaceStatus)
+		for _, ifName := range config.CanPorts {
+			entry := SetupInterfaceStatus{
+				Aliases: s.configProvider.GetInterfaceAliases(ifName),
+				TxGapUs: s.configProvider.GetTxGap(ifName).Microseconds(),
+			}
+			if setupErr := setupErrors[ifName]; setupErr != nil {
+				entry.SetupError, entry.SetupErrorCode = setupErr.Error(), errorCode(setupErr)
+			}
 			if state, err := s.setupManager.GetInterfaceState(ifName); err == nil {
-				interfaceStates[ifName] = state
+				entry.InterfaceState = state
 			} else {
-				interfaceStates[ifName] = map[string]interface{}{
-					"error": err.Error(),
-				}
+				entry.Error = err.Error()
 			}
+			setupStatus.InterfaceStates[ifName] = entry
 		}
-		setupStatus["interfaceStates"] = interfaceStates
 	}
 
 	// Add message listener status
-	messageListenerStatus := make(map[string]interface{})
+	messageListenerStatus := &MessageListenerStatus{}
 	if s.messageListener != nil {
-		messageListenerStatus["listeningInterfaces"] = s.messageListener.GetListeningInterfaces()
-		messageListenerStatus["statistics"] = s.messageListener.GetStatistics()
+		messageListenerStatus.ListeningInterfaces = s.messageListener.GetListeningInterfaces()
+		messageListenerStatus.Statistics = s.messageListener.GetStatistics()
 	}
 
-	return map[string]interface{}{
-		"status":           "running",
-		"uptime":           systemStatus.SystemUptime.String(),
-		"activeInterfaces": systemStatus.ActiveInterfaces,
-		"watchdogRunning":  systemStatus.WatchdogStatus.Running,
-		"setup":            setupStatus,
-		"messageListener":  messageListenerStatus,
+	// Some configured interfaces failed to set up or are not active
+	degraded := len(setupErrors) > 0 || systemStatus.ActiveInterfaces < len(config.CanPorts)
+
+	return ServiceStatus{
+		SchemaVersion:    StatusSchemaVersion,
+		BuildInfo:        GetBuildInfo(),
+		Status:           "running",
+		Degraded:         degraded,
+		Uptime:           systemStatus.SystemUptime.String(),
+		ActiveInterfaces: systemStatus.ActiveInterfaces,
+		WatchdogRunning:  systemStatus.WatchdogStatus.Running,
+		InterfaceAliases: config.InterfaceAliases,
+		Setup:            setupStatus,
+		MessageListener:  messageListenerStatus,
 	}
 }
 
@@ -425,14 +926,8 @@ func (s *Service) GetMessageSummary() map[string]interface{} {
 	totalBuffered := 0
 
 	for _, stats := range allStats {
-		if statsMap, ok := stats.(map[string]interface{}); ok {
-			if totalRx, ok := statsMap["totalReceived"].(uint64); ok {
-				totalReceived += totalRx
-			}
-			if buffered, ok := statsMap["bufferedCount"].(int); ok {
-				totalBuffered += buffered
-			}
-		}
+		totalReceived += stats.TotalReceived
+		totalBuffered += stats.BufferedCount
 	}
 
 	return map[string]interface{}{
@@ -444,12 +939,37 @@ func (s *Service) GetMessageSummary() map[string]interface{} {
 	}
 }
 
+// commandLineRequest returns "help" or "version" when the command line asks for usage or
+// the build version rather than to run the service: -h, -help, --help, -version,
+// --version anywhere before --, or a first argument of help or version
+func commandLineRequest(args []string) string {
+	if len(args) > 0 && (args[0] == "help" || args[0] == "version") {
+		return args[0]
+	}
+	for _, arg := range args {
+		switch arg {
+		case "--":
+			return ""
+		case "-h", "-help", "--help":
+			return "help"
+		case "-version", "--version":
+			return "version"
+		}
+	}
+	return ""
+}
+
 // main function
 func main() {
-	// Check if help was requested
-	if len(os.Args) > 1 && (os.Args[1] == "-h" || os.Args[1] == "--help") {
+	// Help and version requests are answered before the configuration is parsed, so they
+	// work whatever else is on the command line
+	switch commandLineRequest(os.Args[1:]) {
+	case "help":
 		PrintUsage()
 		return
+	case "version":
+		fmt.Println(GetBuildInfo())
+		return
 	}
 
 	// Create service
@@ -457,9 +977,28 @@ func main() {
 
 	// Initialize service
 	if err := service.Initialize(); err != nil {
+		var configErrs ConfigErrors
+		if errors.As(err, &configErrs) && len(configErrs) > 1 {
+			log.Printf("❌ Invalid configuration, %d problems:", len(configErrs))
+			for _, problem := range configErrs {
+				log.Printf("   - %s", problem)
+			}
+			os.Exit(1)
+		}
 		log.Fatalf("Failed to initialize service: %v", err)
 	}
 
+	// -validate-config stops after validation, before any interface is touched
+	if config := service.currentConfig(); config.ValidateOnly {
+		summary, err := json.MarshalIndent(NewConfigParser().GetConfigSummary(config), "", "  ")
+		if err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		fmt.Println(string(summary))
+		log.Printf("✅ Configuration is valid")
+		return
+	}
+
 	// Create context for graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -472,25 +1011,34 @@ func main() {
 	// Print startup summary
 	status := service.GetStatus()
 	log.Printf("🎯 Service startup summary:")
-	log.Printf("   - Active interfaces: %v", status["activeInterfaces"])
-	log.Printf("   - Watchdog running: %v", status["watchdogRunning"])
+	log.Printf("   - Active interfaces: %v", status.ActiveInterfaces)
+	log.Printf("   - Watchdog running: %v", status.WatchdogRunning)
+	if status.Degraded {
+		log.Printf("   - Degraded: some interfaces failed to start, see setup errors")
+	}
 
-	if messageListener, ok := status["messageListener"].(map[string]interface{}); ok {
-		if listeningInterfaces, ok := messageListener["listeningInterfaces"].([]string); ok {
-			log.Printf("   - Listening on: %v", listeningInterfaces)
-		}
+	if status.MessageListener != nil && status.MessageListener.ListeningInterfaces != nil {
+		log.Printf("   - Listening on: %v", status.MessageListener.ListeningInterfaces)
 	}
+	config := service.currentConfig()
+	log.Printf("   - Shutdown timeout: %v (send drain up to %v)", config.ShutdownTimeout, config.DrainTimeout)
 
-	// Wait for interrupt signal for graceful shutdown
+	// Wait for interrupt signal for graceful shutdown; SIGHUP reloads the configuration
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-	// Block until signal received
-	<-sigChan
+	// Block until a shutdown signal is received
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			break
+		}
+		log.Println("Reload signal received")
+		service.ReloadOnSignal()
+	}
 	log.Println("Shutdown signal received")
 
-	// Create shutdown context with timeout
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Create shutdown context with timeout, as the last reload left it
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), service.currentConfig().ShutdownTimeout)
 	defer shutdownCancel()
 
 	// Stop service
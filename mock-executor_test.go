@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MockCommandResponse is a scripted result for a command
+type MockCommandResponse struct {
+	Output string
+	Err    error
+}
+
+// MockCommandCall records a command issued through MockCommandExecutor
+type MockCommandCall struct {
+	Command string
+	Timeout time.Duration // Zero when executed without timeout
+}
+
+// MockCommandExecutor implements CommandExecutor with scripted responses.
+// It lets InterfaceSetupManager run without root privileges or real hardware.
+type MockCommandExecutor struct {
+	responses       map[string][]MockCommandResponse
+	defaultResponse MockCommandResponse
+	calls           []MockCommandCall
+	mutex           sync.Mutex
+}
+
+// NewMockCommandExecutor creates a new mock command executor.
+// Commands without a scripted response fail with an "unexpected command" error.
+func NewMockCommandExecutor() *MockCommandExecutor {
+	return &MockCommandExecutor{
+		responses: make(map[string][]MockCommandResponse),
+	}
+}
+
+// AddResponse queues a response for a command line (e.g. "ip link set can0 up").
+// Queued responses are consumed in order; the last one is repeated once the queue is drained.
+func (m *MockCommandExecutor) AddResponse(command string, output string, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.responses[command] = append(m.responses[command], MockCommandResponse{Output: output, Err: err})
+}
+
+// SetDefaultResponse sets the response for commands without a scripted response
+func (m *MockCommandExecutor) SetDefaultResponse(output string, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.defaultResponse = MockCommandResponse{Output: output, Err: err}
+}
+
+// Execute records the command and returns its scripted response
+func (m *MockCommandExecutor) Execute(name string, args ...string) ([]byte, error) {
+	return m.execute(0, name, args...)
+}
+
+// ExecuteWithTimeout records the command and timeout and returns its scripted response
+func (m *MockCommandExecutor) ExecuteWithTimeout(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	return m.execute(timeout, name, args...)
+}
+
+// execute records a call and pops the next scripted response
+func (m *MockCommandExecutor) execute(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	command := strings.Join(append([]string{name}, args...), " ")
+	m.calls = append(m.calls, MockCommandCall{Command: command, Timeout: timeout})
+
+	queue, exists := m.responses[command]
+	if !exists || len(queue) == 0 {
+		if m.defaultResponse.Output == "" && m.defaultResponse.Err == nil {
+			return nil, fmt.Errorf("unexpected command: %s", command)
+		}
+		return []byte(m.defaultResponse.Output), m.defaultResponse.Err
+	}
+
+	response := queue[0]
+	if len(queue) > 1 {
+		m.responses[command] = queue[1:]
+	}
+	return []byte(response.Output), response.Err
+}
+
+// Calls returns all recorded calls in order
+func (m *MockCommandExecutor) Calls() []MockCommandCall {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	result := make([]MockCommandCall, len(m.calls))
+	copy(result, m.calls)
+	return result
+}
+
+// Commands returns all recorded command lines in order
+func (m *MockCommandExecutor) Commands() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	result := make([]string, len(m.calls))
+	for i, call := range m.calls {
+		result[i] = call.Command
+	}
+	return result
+}
+
+// CallCount returns how many times a command line was issued
+func (m *MockCommandExecutor) CallCount(command string) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	count := 0
+	for _, call := range m.calls {
+		if call.Command == command {
+			count++
+		}
+	}
+	return count
+}
+
+// Reset clears recorded calls and scripted responses
+func (m *MockCommandExecutor) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.responses = make(map[string][]MockCommandResponse)
+	m.defaultResponse = MockCommandResponse{}
+	m.calls = nil
+}
@@ -2,17 +2,23 @@ package main
 
 import (
 	"fmt"
+	"sync"
 	"time"
 )
 
 // SystemStatus represents overall system status
 type SystemStatus struct {
+	SchemaVersion       int                        `json:"schema_version"`
+	APIVersion          string                     `json:"apiVersion,omitempty"` // REST API version the status was requested through
+	BuildInfo                                      // Version, commit and build date of the binary
 	Interfaces          map[string]InterfaceStatus `json:"interfaces"`
 	ActiveInterfaces    int                        `json:"activeInterfaces"`
 	ConfiguredPorts     []string                   `json:"configuredPorts"`
 	AvailableInterfaces []string                   `json:"availableInterfaces"`
 	WatchdogStatus      WatchdogStatus             `json:"watchdogStatus"`
 	SystemUptime        time.Duration              `json:"systemUptime"`
+	ActiveAlerts        []ActiveAlert              `json:"activeAlerts"`
+	Sequences           []SequenceReport           `json:"sequences,omitempty"` // Startup and shutdown sequences completed within SequenceReportTTL
 	Timestamp           time.Time                  `json:"timestamp"`
 }
 
@@ -29,6 +35,68 @@ type InterfaceStatus struct {
 	LastErrorMsg  string       `json:"lastErrorMsg"`
 	AvgLatency    string       `json:"avgLatency"`
 	Health        HealthStatus `json:"health"`
+	BusSilent     bool         `json:"busSilent"`
+	SilentSince   time.Time    `json:"silentSince,omitempty"`
+
+	ConfirmedSends   uint64 `json:"confirmedSends"`
+	UnconfirmedSends uint64 `json:"unconfirmedSends"`
+
+	SendLatency      LatencySummary         `json:"sendLatency"`     // Request acceptance to successful write()
+	TxQueueDepth     int64                  `json:"txQueueDepth"`    // Sends currently waiting for the socket
+	MaxTxQueueDepth  int64                  `json:"maxTxQueueDepth"` // Highest TX queue depth seen
+	TxQueueBands     map[string]TxBandStats `json:"txQueueBands"`    // Waiting and rejected sends per priority band
+	BufferFullErrors uint64                 `json:"bufferFullErrors"`
+	SendRetries      uint64                 `json:"sendRetries"`
+	RetriesExhausted uint64                 `json:"retriesExhausted"` // Frames given up after retrying
+	RetryTime        string                 `json:"retryTime"`        // Total time frames spent retrying
+	PacedSends       uint64                 `json:"pacedSends"`       // Writes delayed for the minimum inter-frame gap
+	PacingDelay      string                 `json:"pacingDelay"`      // Total time they waited
+	PacedRate        float64                `json:"pacedRate"`        // Frames per second achieved by the latest run of paced writes
+
+	ErrorBurst      bool   `json:"errorBurst"` // Error frame rate above the burst threshold
+	ErrorFrames     uint64 `json:"errorFrames"`
+	TxErrorCounter  uint8  `json:"txErrorCounter"` // Last reported controller error counters
+	RxErrorCounter  uint8  `json:"rxErrorCounter"`
+	ControllerState string `json:"controllerState,omitempty"` // From error frames; empty until the controller reports one
+
+	Rates       InterfaceRates       `json:"rates"`       // Rolling receive rates over 1s, 10s and 60s
+	KernelStats KernelInterfaceStats `json:"kernelStats"` // Kernel counters, including traffic never seen in userspace
+
+	WatchdogState string    `json:"watchdogState,omitempty"` // healthy, degraded, failed, recovering, quarantined
+	StateSince    time.Time `json:"stateSince,omitempty"`
+	TimeInState   string    `json:"timeInState,omitempty"`
+
+	// Set while recovery is paused for the interface, so a stale failed state is not mistaken for a hang
+	WatchdogPaused *PauseStatus `json:"watchdogPaused,omitempty"`
+
+	TxEnabled       bool      `json:"txEnabled"`                 // Sends allowed; see POST /interfaces/{name}/tx
+	TxDisabledSince time.Time `json:"txDisabledSince,omitempty"` // When transmission was disabled
+}
+
+// InterfaceRates are the rolling receive rates of an interface
+type InterfaceRates struct {
+	RxFrames    RollingRates `json:"rxFrames"`    // Frames per second
+	RxBits      RollingRates `json:"rxBits"`      // Bits per second, stuff bits excluded
+	BusLoad     RollingRates `json:"busLoad"`     // Percent of the default bitrate, 0 when it is unknown
+	ErrorFrames RollingRates `json:"errorFrames"` // Error frames per second
+}
+
+// Bitrate sources of a bus load figure
+const (
+	BitrateSourceInterface = "interface" // Read from the interface
+	BitrateSourceDefault   = "default"   // -bitrate or -bitrates, when the interface reports none (e.g. vcan)
+)
+
+// BusLoad is the share of the bitrate used by the frames on a bus over rolling windows
+type BusLoad struct {
+	Interface     string       `json:"interface"`
+	Bitrate       int          `json:"bitrate"`
+	BitrateSource string       `json:"bitrateSource"` // interface or default
+	Frames        RollingRates `json:"frames"`        // Frames per second
+	Bits          RollingRates `json:"bits"`          // Bits per second, stuff bits included
+	StuffBits     RollingRates `json:"stuffBits"`     // Stuff bits per second
+	Load          RollingRates `json:"load"`          // Percent of the bitrate
+	Timestamp     time.Time    `json:"timestamp"`
 }
 
 // HealthStatus represents health information
@@ -41,11 +109,20 @@ type HealthStatus struct {
 
 // WatchdogStatus represents watchdog status
 type WatchdogStatus struct {
-	Running          bool           `json:"running"`
-	CheckInterval    time.Duration  `json:"checkInterval"`
-	RecoveryEnabled  bool           `json:"recoveryEnabled"`
-	RecoveryAttempts map[string]int `json:"recoveryAttempts"`
-	LastCheck        time.Time      `json:"lastCheck"`
+	Running          bool                               `json:"running"`
+	CheckInterval    time.Duration                      `json:"checkInterval"`
+	RecoveryEnabled  bool                               `json:"recoveryEnabled"`
+	RecoveryAttempts map[string]int                     `json:"recoveryAttempts"`
+	Recovery         map[string]RecoveryStatus          `json:"recovery"`
+	EventsLastHour   int                                `json:"eventsLastHour"`
+	SilentInterfaces map[string]time.Time               `json:"silentInterfaces"`
+	Paused           bool                               `json:"paused"`
+	PausedUntil      time.Time                          `json:"pausedUntil,omitempty"`
+	Pauses           map[string]PauseStatus             `json:"pauses"`
+	FailureThreshold int                                `json:"failureThreshold"`
+	RecoveryCooldown string                             `json:"recoveryCooldown"`
+	EffectiveConfig  map[string]EffectiveWatchdogConfig `json:"effectiveConfig"`
+	LastCheck        time.Time                          `json:"lastCheck"`
 }
 
 // Monitor handles system monitoring and status reporting
@@ -55,6 +132,20 @@ type Monitor struct {
 	configProvider   ConfigProvider
 	startTime        time.Time
 	healthChecks     map[string]*HealthTracker
+	notifier         *Notifier
+	setupManager     *InterfaceSetupManager
+	txGate           *TxGate
+	idStats          *CanIDStatsTracker
+	errorStats       *ErrorFrameTracker
+	kernelStats      *KernelStatsReader
+	traffic          *busTrafficTracker
+	alerts           *AlertEngine
+	triggers         *FrameTriggerEngine
+	waiters          frameWaiters
+	sequences        *SequenceRunner
+	alertStop        chan struct{}
+	alertWG          sync.WaitGroup
+	logger           Logger
 }
 
 // HealthTracker tracks health check results for an interface
@@ -65,27 +156,302 @@ type HealthTracker struct {
 }
 
 // NewMonitor creates a new monitor
-func NewMonitor(interfaceManager *InterfaceManager, watchdog *Watchdog, configProvider ConfigProvider) *Monitor {
+func NewMonitor(interfaceManager *InterfaceManager, watchdog *Watchdog, configProvider ConfigProvider, logger Logger) *Monitor {
 	return &Monitor{
 		interfaceManager: interfaceManager,
 		watchdog:         watchdog,
 		configProvider:   configProvider,
 		startTime:        time.Now(),
 		healthChecks:     make(map[string]*HealthTracker),
+		idStats:          NewCanIDStatsTracker(DefaultMaxTrackedIDs),
+		errorStats:       NewErrorFrameTracker(DefaultErrorBurstThreshold),
+		kernelStats:      NewKernelStatsReader(DefaultSysfsNetRoot),
+		traffic:          newBusTrafficTracker(),
+		alerts:           NewAlertEngine(nil),
+		triggers:         NewFrameTriggerEngine(),
+		logger:           logger,
+	}
+}
+
+// ObserveFrame feeds a received frame into the per-ID traffic or error frame statistics
+func (m *Monitor) ObserveFrame(msg CanMessageLog) {
+	if !isErrorFrame(msg.ID) {
+		m.idStats.ObserveFrame(msg)
+		m.traffic.observe(msg)
+		m.observeTriggers(msg)
+		m.waiters.observe(msg)
+		return
+	}
+
+	rate, burstStarted := m.errorStats.Observe(msg)
+	if !burstStarted {
+		return
+	}
+
+	message := fmt.Sprintf("error frame burst: %d error frames within one second, check bitrate and wiring", rate)
+	m.logger.Warnf("⚠️ %s %s", msg.Interface, message)
+	m.notifier.Publish(Notification{
+		Interface: msg.Interface,
+		EventType: NotifyErrorBurst,
+		Severity:  SeverityWarning,
+		Message:   message,
+		Timestamp: msg.Timestamp,
+	})
+}
+
+// WaitForFrame registers a condition on received frames. The channel receives the first
+// frame meeting it; cancel unregisters the condition.
+func (m *Monitor) WaitForFrame(match func(CanMessageLog) bool) (<-chan CanMessageLog, func()) {
+	return m.waiters.add(match)
+}
+
+// observeTriggers reports the changes frame triggers saw in a received frame
+func (m *Monitor) observeTriggers(msg CanMessageLog) {
+	for _, firing := range m.triggers.Observe(msg) {
+		message := fmt.Sprintf("trigger %s: %s changed from %s to %s",
+			firing.trigger.Name, firing.trigger.describe(), firing.change.OldValue, firing.change.NewValue)
+		m.logger.Infof("🎯 %s %s", msg.Interface, message)
+		m.notifier.Publish(Notification{
+			Interface: msg.Interface,
+			EventType: NotifyFrameChanged,
+			Severity:  firing.trigger.Severity,
+			Message:   message,
+			OldState:  firing.change.OldValue,
+			NewState:  firing.change.NewValue,
+			Timestamp: firing.change.Timestamp,
+		})
+	}
+}
+
+// AddFrameTrigger validates and registers a frame trigger
+func (m *Monitor) AddFrameTrigger(trigger FrameTrigger) (FrameTrigger, error) {
+	if err := trigger.normalize(); err != nil {
+		return FrameTrigger{}, err
+	}
+	if trigger.Interface != "" && !m.configProvider.ValidateInterface(trigger.Interface) {
+		return FrameTrigger{}, notConfiguredError(trigger.Interface)
+	}
+	if err := m.triggers.Add(trigger); err != nil {
+		return FrameTrigger{}, err
+	}
+	return trigger, nil
+}
+
+// RemoveFrameTrigger unregisters a frame trigger
+func (m *Monitor) RemoveFrameTrigger(name string) error {
+	return m.triggers.Remove(name)
+}
+
+// GetFrameTriggers returns every frame trigger with its counters
+func (m *Monitor) GetFrameTriggers() []FrameTriggerStatus {
+	return m.triggers.GetStatuses()
+}
+
+// SetSetupManager sets where the bitrate of an interface is read for bus load
+func (m *Monitor) SetSetupManager(setupManager *InterfaceSetupManager) {
+	m.setupManager = setupManager
+}
+
+// SetSequenceRunner sets where the reports of the startup and shutdown sequences are read
+func (m *Monitor) SetSequenceRunner(sequences *SequenceRunner) {
+	m.sequences = sequences
+}
+
+// SetTxGate sets where the transmission state of interfaces is read
+func (m *Monitor) SetTxGate(txGate *TxGate) {
+	m.txGate = txGate
+}
+
+// SetErrorBurstThreshold sets the error frame rate (frames per second) that raises a warning
+func (m *Monitor) SetErrorBurstThreshold(threshold int) {
+	m.errorStats.SetBurstThreshold(threshold)
+}
+
+// SetAlertRules replaces the alert rules. Rules must be normalized; it must be called
+// before alerting starts.
+func (m *Monitor) SetAlertRules(rules []AlertRule) {
+	m.alerts = NewAlertEngine(rules)
+}
+
+// StartAlerting starts evaluating alert rules every second. Without rules nothing is started.
+func (m *Monitor) StartAlerting() {
+	if !m.alerts.HasRules() || m.alertStop != nil {
+		return
+	}
+
+	m.logger.Infof("🚨 Evaluating %d alert rule(s)", len(m.alerts.GetStatuses()))
+	m.alertStop = make(chan struct{})
+	m.alertWG.Add(1)
+	go func() {
+		defer m.alertWG.Done()
+		ticker := time.NewTicker(alertEvaluationInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.alertStop:
+				return
+			case now := <-ticker.C:
+				m.evaluateAlerts(now)
+			}
+		}
+	}()
+}
+
+// StopAlerting stops alert rule evaluation
+func (m *Monitor) StopAlerting() {
+	if m.alertStop == nil {
+		return
+	}
+	close(m.alertStop)
+	m.alertWG.Wait()
+	m.alertStop = nil
+}
+
+// evaluateAlerts evaluates every rule and reports alerts that fired or resolved
+func (m *Monitor) evaluateAlerts(now time.Time) {
+	for _, transition := range m.alerts.Evaluate(now, func(rule AlertRule) (float64, error) {
+		return m.alertValue(rule, now)
+	}) {
+		rule := transition.status.Rule
+		notification := Notification{
+			Interface: rule.Interface,
+			EventType: NotifyAlertFiring,
+			Severity:  rule.Severity,
+			Message: fmt.Sprintf("alert %s firing: %s %s %g (value %.2f)",
+				rule.Name, rule.Metric, rule.Op, rule.Threshold, transition.status.Value),
+			Timestamp: now,
+		}
+		if transition.resolved {
+			notification.EventType = NotifyAlertResolved
+			notification.Severity = SeverityInfo
+			notification.Message = fmt.Sprintf("alert %s resolved: %s is %.2f", rule.Name, rule.Metric, transition.status.Value)
+		}
+
+		m.logger.Warnf("🚨 %s %s", rule.Interface, notification.Message)
+		m.notifier.Publish(notification)
 	}
 }
 
+// alertValue reads the current value of the metric a rule watches
+func (m *Monitor) alertValue(rule AlertRule, now time.Time) (float64, error) {
+	switch rule.Metric {
+	case AlertMetricBusLoad:
+		bitrate := m.configProvider.GetBitrate(rule.Interface)
+		if bitrate <= 0 {
+			return 0, fmt.Errorf("bitrate of %s unknown", rule.Interface)
+		}
+		return m.traffic.bitRate(rule.Interface, now, rule.window) / float64(bitrate) * 100, nil
+	case AlertMetricErrorRate:
+		return m.errorStats.GetRate(rule.Interface, rule.window), nil
+	case AlertMetricIDRate:
+		return m.idStats.GetIDRate(rule.Interface, *rule.ID, rule.window), nil
+	case AlertMetricSilence:
+		lastFrame := m.traffic.lastFrame(rule.Interface)
+		if lastFrame.IsZero() {
+			lastFrame = m.startTime
+		}
+		return now.Sub(lastFrame).Seconds(), nil
+	}
+	return 0, fmt.Errorf("unknown metric %q", rule.Metric)
+}
+
+// GetAlerts returns every alert rule with its current state
+func (m *Monitor) GetAlerts() []AlertStatus {
+	return m.alerts.GetStatuses()
+}
+
+// GetActiveAlerts returns the firing alerts
+func (m *Monitor) GetActiveAlerts() []ActiveAlert {
+	return m.alerts.GetActive()
+}
+
+// AlertTestResult reports how a rule evaluates against current data
+type AlertTestResult struct {
+	Rule         AlertRule `json:"rule"`
+	Value        float64   `json:"value"`
+	ConditionMet bool      `json:"conditionMet"` // The rule would start its for-duration now
+}
+
+// TestAlertRule evaluates a rule once against current data without changing alert
+// state or sending notifications. A rule with only a name tests the configured rule.
+func (m *Monitor) TestAlertRule(rule AlertRule) (AlertTestResult, error) {
+	if rule.Metric == "" {
+		configured, found := m.alerts.FindRule(rule.Name)
+		if !found {
+			return AlertTestResult{}, fmt.Errorf("alert rule %q not found", rule.Name)
+		}
+		rule = configured
+	}
+	if err := rule.normalize(); err != nil {
+		return AlertTestResult{}, err
+	}
+	if !m.configProvider.ValidateInterface(rule.Interface) {
+		return AlertTestResult{}, notConfiguredError(rule.Interface)
+	}
+
+	value, err := m.alertValue(rule, time.Now())
+	if err != nil {
+		return AlertTestResult{}, err
+	}
+	return AlertTestResult{Rule: rule, Value: value, ConditionMet: rule.matches(value)}, nil
+}
+
+// GetErrorStats returns the error frame statistics of an interface
+func (m *Monitor) GetErrorStats(ifName string) (InterfaceErrorStats, error) {
+	if !m.configProvider.ValidateInterface(ifName) {
+		return InterfaceErrorStats{}, notConfiguredError(ifName)
+	}
+	return m.errorStats.GetStats(ifName), nil
+}
+
+// GetAllErrorStats returns the error frame statistics of every interface that reported errors
+func (m *Monitor) GetAllErrorStats() map[string]InterfaceErrorStats {
+	return m.errorStats.GetAllStats()
+}
+
+// GetIDStats returns per-ID traffic statistics of an interface sorted by frame rate.
+// top limits the number of IDs returned when positive.
+func (m *Monitor) GetIDStats(ifName string, top int) (InterfaceIDStats, error) {
+	if !m.configProvider.ValidateInterface(ifName) {
+		return InterfaceIDStats{}, notConfiguredError(ifName)
+	}
+	return m.idStats.GetStats(ifName, top), nil
+}
+
+// GetIDWindowStats returns per-ID counts, rates and inter-frame gaps of an interface
+// over the most recent window (between one second and MaxIDStatsWindow)
+func (m *Monitor) GetIDWindowStats(ifName string, window time.Duration) (InterfaceIDWindowStats, error) {
+	if !m.configProvider.ValidateInterface(ifName) {
+		return InterfaceIDWindowStats{}, notConfiguredError(ifName)
+	}
+	return m.idStats.GetWindowStats(ifName, window), nil
+}
+
+// ResetIDStats clears the per-ID traffic statistics of an interface
+func (m *Monitor) ResetIDStats(ifName string) error {
+	if !m.configProvider.ValidateInterface(ifName) {
+		return notConfiguredError(ifName)
+	}
+	m.idStats.Reset(ifName)
+	return nil
+}
+
 // GetSystemStatus returns complete system status
 func (m *Monitor) GetSystemStatus() SystemStatus {
 	interfaces := m.getInterfaceStatuses()
 
 	return SystemStatus{
+		SchemaVersion:       StatusSchemaVersion,
+		BuildInfo:           GetBuildInfo(),
 		Interfaces:          interfaces,
 		ActiveInterfaces:    m.interfaceManager.GetInterfaceCount(),
 		ConfiguredPorts:     m.configProvider.GetCanPorts(),
 		AvailableInterfaces: m.getAvailableInterfaces(),
 		WatchdogStatus:      m.getWatchdogStatus(),
 		SystemUptime:        time.Since(m.startTime),
+		ActiveAlerts:        m.GetActiveAlerts(),
+		Sequences:           m.sequences.RecentReports(),
 		Timestamp:           time.Now(),
 	}
 }
@@ -94,11 +460,22 @@ func (m *Monitor) GetSystemStatus() SystemStatus {
 func (m *Monitor) getInterfaceStatuses() map[string]InterfaceStatus {
 	result := make(map[string]InterfaceStatus)
 	interfaces := m.interfaceManager.GetAllInterfaces()
+	silent := m.watchdog.GetSilentInterfaces()
+	healthStates := m.watchdog.GetHealthStates()
+	errorStats := m.errorStats.GetAllStats()
 
 	for name, canIf := range interfaces {
 		stats := canIf.GetStats()
 		health := m.checkInterfaceHealth(name)
 
+		// An error frame burst is a warning even while the socket itself is healthy
+		errorBurst := m.errorStats.IsBursting(name)
+		if errorBurst && health.Status == "healthy" {
+			health.Status = "warning"
+		}
+
+		silentSince, busSilent := silent[name]
+		errors := errorStats[name]
 		result[name] = InterfaceStatus{
 			Name:          name,
 			Active:        true,
@@ -111,6 +488,29 @@ func (m *Monitor) getInterfaceStatuses() map[string]InterfaceStatus {
 			LastErrorMsg:  stats.LastErrorMsg,
 			AvgLatency:    stats.AvgLatency.String(),
 			Health:        health,
+			BusSilent:     busSilent,
+			SilentSince:   silentSince,
+
+			ConfirmedSends:   stats.TotalConfirmed,
+			UnconfirmedSends: stats.TotalUnconfirmed,
+
+			SendLatency:      stats.SendLatency.Summary(),
+			TxQueueDepth:     stats.TxQueueDepth,
+			MaxTxQueueDepth:  stats.MaxTxQueueDepth,
+			TxQueueBands:     stats.TxQueueBands,
+			BufferFullErrors: stats.BufferFullErrors,
+			SendRetries:      stats.SendRetries,
+			RetriesExhausted: stats.RetriesExhausted,
+			RetryTime:        stats.RetryTime.String(),
+			PacedSends:       stats.PacedSends,
+			PacingDelay:      stats.PacingDelay.String(),
+			PacedRate:        stats.PacedRate,
+
+			ErrorBurst:      errorBurst,
+			ErrorFrames:     errors.TotalErrorFrames,
+			TxErrorCounter:  errors.TxErrorCounter,
+			RxErrorCounter:  errors.RxErrorCounter,
+			ControllerState: errors.ControllerState,
 		}
 	}
 
@@ -128,6 +528,108 @@ func (m *Monitor) getInterfaceStatuses() map[string]InterfaceStatus {
 		}
 	}
 
+	// Attach kernel counters, which exist as long as the device does, receive rates and
+	// the transmission state
+	now := time.Now()
+	for name, status := range result {
+		status.KernelStats = m.kernelStats.Read(name)
+		status.Rates = m.interfaceRates(name, now, errorStats[name])
+		status.TxEnabled, status.TxDisabledSince = m.txGate.Enabled(name)
+		result[name] = status
+	}
+
+	// Attach watchdog health states, including those of inactive interfaces
+	for name, healthState := range healthStates {
+		if status, exists := result[name]; exists {
+			status.WatchdogState = healthState.State
+			status.StateSince = healthState.Since
+			status.TimeInState = healthState.TimeInState
+			if pause, paused := m.watchdog.GetPause(name); paused {
+				status.WatchdogPaused = &pause
+			}
+			result[name] = status
+		}
+	}
+
+	return result
+}
+
+// GetSendStats returns the send metrics of every active interface
+func (m *Monitor) GetSendStats() map[string]InterfaceStats {
+	result := make(map[string]InterfaceStats)
+	for name, canIf := range m.interfaceManager.GetAllInterfaces() {
+		result[name] = canIf.GetStats()
+	}
+	return result
+}
+
+// GetInterfaceRates returns the rolling receive rates of every configured interface
+func (m *Monitor) GetInterfaceRates() map[string]InterfaceRates {
+	now := time.Now()
+	errorStats := m.errorStats.GetAllStats()
+	result := make(map[string]InterfaceRates)
+	for _, port := range m.configProvider.GetCanPorts() {
+		result[port] = m.interfaceRates(port, now, errorStats[port])
+	}
+	return result
+}
+
+// interfaceRates combines the traffic and error frame rates of an interface
+func (m *Monitor) interfaceRates(ifName string, now time.Time, errors InterfaceErrorStats) InterfaceRates {
+	frames, bits := m.traffic.rates(ifName, now)
+	rates := InterfaceRates{
+		RxFrames:    frames,
+		RxBits:      bits,
+		ErrorFrames: errors.Rates,
+	}
+	if bitrate := m.configProvider.GetBitrate(ifName); bitrate > 0 {
+		rates.BusLoad = bits.scaled(100 / float64(bitrate))
+	}
+	return rates
+}
+
+// GetBusLoad returns the bus load of an interface: the bits of the frames received,
+// stuff bits included, as a percentage of the bitrate the interface reports. Frames sent
+// by the bridge are received through loopback and counted too; error frames are not.
+func (m *Monitor) GetBusLoad(ifName string) (BusLoad, error) {
+	if !m.configProvider.ValidateInterface(ifName) {
+		return BusLoad{}, notConfiguredError(ifName)
+	}
+
+	now := time.Now()
+	load := BusLoad{
+		Interface:     ifName,
+		Bitrate:       m.configProvider.GetBitrate(ifName),
+		BitrateSource: BitrateSourceDefault,
+		Timestamp:     now,
+	}
+	if m.setupManager != nil {
+		if state, err := m.setupManager.GetInterfaceState(ifName); err == nil && state.Bitrate > 0 {
+			load.Bitrate, load.BitrateSource = state.Bitrate, BitrateSourceInterface
+		}
+	}
+
+	frames, bits := m.traffic.rates(ifName, now)
+	load.Frames = frames
+	load.StuffBits = m.traffic.stuffBitRates(ifName, now)
+	load.Bits = bits.plus(load.StuffBits)
+	if load.Bitrate > 0 {
+		load.Load = load.Bits.scaled(100 / float64(load.Bitrate))
+	}
+	return load, nil
+}
+
+// GetInterfaceKernelStats returns the kernel counters of an interface
+func (m *Monitor) GetInterfaceKernelStats(ifName string) KernelInterfaceStats {
+	return m.kernelStats.Read(ifName)
+}
+
+// GetKernelStats returns the kernel counters of every configured interface
+func (m *Monitor) GetKernelStats() map[string]KernelInterfaceStats {
+	result := make(map[string]KernelInterfaceStats)
+	for _, port := range m.configProvider.GetCanPorts() {
+		result[port] = m.kernelStats.Read(port)
+	}
 	return result
 }
 
@@ -183,16 +685,63 @@ func (m *Monitor) determineHealthStatus(tracker *HealthTracker) string {
 // getWatchdogStatus returns watchdog status
 func (m *Monitor) getWatchdogStatus() WatchdogStatus {
 	config := m.watchdog.GetConfig()
+	pauses := m.watchdog.GetPauses()
+	globalPause, paused := pauses[allInterfaces]
 
 	return WatchdogStatus{
 		Running:          m.watchdog.IsRunning(),
 		CheckInterval:    config.CheckInterval,
 		RecoveryEnabled:  config.RecoveryEnabled,
 		RecoveryAttempts: m.watchdog.GetRecoveryStatus(),
+		Recovery:         m.watchdog.GetRecoveryDetails(),
+		EventsLastHour:   m.watchdog.CountEventsSince(time.Now().Add(-time.Hour)),
+		SilentInterfaces: m.watchdog.GetSilentInterfaces(),
+		Paused:           paused,
+		PausedUntil:      globalPause.AutoResumeAt,
+		Pauses:           pauses,
+		FailureThreshold: config.FailureThreshold,
+		RecoveryCooldown: config.RecoveryCooldown.String(),
+		EffectiveConfig:  m.watchdog.GetEffectiveConfig(m.configProvider.GetCanPorts()),
 		LastCheck:        time.Now(), // This could be enhanced to track actual last check
 	}
 }
 
+// SetNotifier sets the notifier whose delivery stats are reported
+func (m *Monitor) SetNotifier(notifier *Notifier) {
+	m.notifier = notifier
+}
+
+// GetNotificationStats returns webhook delivery counters
+func (m *Monitor) GetNotificationStats() NotifierStats {
+	return m.notifier.GetStats()
+}
+
+// ReconfigureInterface runs a planned reconfiguration of an interface under watchdog
+// coordination, reopening its socket afterwards. requestID is the API request asking for it.
+func (m *Monitor) ReconfigureInterface(ifName, reason, requestID string, reconfigure func() error) error {
+	return m.watchdog.Reconfigure(ifName, reason, requestID, reconfigure)
+}
+
+// ForceRecovery asks the watchdog to retry recovery of an interface immediately
+func (m *Monitor) ForceRecovery(ifName, requestID string) error {
+	return m.watchdog.ForceRecovery(ifName, requestID)
+}
+
+// PauseWatchdog pauses watchdog recovery for an interface, or all interfaces when ifName is empty
+func (m *Monitor) PauseWatchdog(ifName string, timeout time.Duration, reason, requestID string) (PauseStatus, error) {
+	return m.watchdog.Pause(ifName, timeout, reason, requestID)
+}
+
+// ResumeWatchdog resumes watchdog recovery for an interface, or all interfaces when ifName is empty
+func (m *Monitor) ResumeWatchdog(ifName, requestID string) error {
+	return m.watchdog.Resume(ifName, requestID)
+}
+
+// GetWatchdogEvents returns watchdog events matching the filter
+func (m *Monitor) GetWatchdogEvents(filter WatchdogEventFilter) []WatchdogEvent {
+	return m.watchdog.GetEvents(filter)
+}
+
 // getAvailableInterfaces returns list of available interface names
 func (m *Monitor) getAvailableInterfaces() []string {
 	return m.configProvider.GetCanPorts()
@@ -203,13 +752,13 @@ func (m *Monitor) GetInterfaceStatus(ifName string) (InterfaceStatus, error) {
 	statuses := m.getInterfaceStatuses()
 	status, exists := statuses[ifName]
 	if !exists {
-		return InterfaceStatus{}, fmt.Errorf("interface %s not found", ifName)
+		return InterfaceStatus{}, tagError(ErrInterfaceNotFound, fmt.Errorf("interface %s not found", ifName))
 	}
 	return status, nil
 }
 
 // GetHealthSummary returns a summary of system health
-func (m *Monitor) GetHealthSummary() map[string]interface{} {
+func (m *Monitor) GetHealthSummary() HealthSummary {
 	status := m.GetSystemStatus()
 
 	healthySummary := map[string]int{
@@ -230,13 +779,14 @@ func (m *Monitor) GetHealthSummary() map[string]interface{} {
 		overallHealth = "warning"
 	}
 
-	return map[string]interface{}{
-		"overallHealth":      overallHealth,
-		"totalInterfaces":    len(status.Interfaces),
-		"activeInterfaces":   status.ActiveInterfaces,
-		"healthDistribution": healthySummary,
-		"systemUptime":       status.SystemUptime.String(),
-		"watchdogActive":     status.WatchdogStatus.Running,
+	return HealthSummary{
+		SchemaVersion:      StatusSchemaVersion,
+		OverallHealth:      overallHealth,
+		TotalInterfaces:    len(status.Interfaces),
+		ActiveInterfaces:   status.ActiveInterfaces,
+		HealthDistribution: healthySummary,
+		SystemUptime:       status.SystemUptime.String(),
+		WatchdogActive:     status.WatchdogStatus.Running,
 	}
 }
 
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// netlinkAttrTypeMask strips the nested and byte-order flags from an attribute type
+const netlinkAttrTypeMask = 0x3fff
+
+// operStateNames are the RFC 2863 operational states (IFLA_OPERSTATE) as printed by ip
+var operStateNames = []string{"UNKNOWN", "NOTPRESENT", "DOWN", "LOWERLAYERDOWN", "TESTING", "DORMANT", "UP"}
+
+// canStateNames are the CAN controller states (IFLA_CAN_STATE) as printed by ip
+var canStateNames = []string{"ERROR-ACTIVE", "ERROR-WARNING", "ERROR-PASSIVE", "BUS-OFF", "STOPPED", "SLEEPING"}
+
+// InterfaceStateReader reads the state of an interface without running commands
+type InterfaceStateReader interface {
+	ReadInterfaceState(ifName string) (*InterfaceState, error)
+}
+
+// NetlinkStateReader reads link state, bit timing, CAN controller state and error
+// counters from rtnetlink, the same source ip uses, without depending on its output format
+type NetlinkStateReader struct{}
+
+// NewNetlinkStateReader creates a netlink state reader
+func NewNetlinkStateReader() *NetlinkStateReader {
+	return &NetlinkStateReader{}
+}
+
+// ReadInterfaceState dumps the links (RTM_GETLINK) and decodes the one named ifName
+func (r *NetlinkStateReader) ReadInterfaceState(ifName string) (*InterfaceState, error) {
+	rib, err := syscall.NetlinkRIB(syscall.RTM_GETLINK, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, fmt.Errorf("netlink link dump failed: %w", err)
+	}
+	messages, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		return nil, fmt.Errorf("invalid netlink response: %w", err)
+	}
+
+	for i := range messages {
+		if messages[i].Header.Type != syscall.RTM_NEWLINK {
+			continue
+		}
+		routeAttrs, err := syscall.ParseNetlinkRouteAttr(&messages[i])
+		if err != nil {
+			continue
+		}
+		attrs := make(map[uint16][]byte, len(routeAttrs))
+		for _, attr := range routeAttrs {
+			attrs[attr.Attr.Type&netlinkAttrTypeMask] = attr.Value
+		}
+		if netlinkString(attrs[unix.IFLA_IFNAME]) == ifName {
+			return decodeNetlinkLink(ifName, attrs), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrInterfaceNotFound, ifName)
+}
+
+// decodeNetlinkLink builds an InterfaceState from the attributes of an RTM_NEWLINK message.
+// Bit timing and CAN state are only present on real CAN devices, not on vcan.
+func decodeNetlinkLink(ifName string, attrs map[uint16][]byte) *InterfaceState {
+	state := &InterfaceState{Name: ifName, Source: StateSourceNetlink}
+
+	if value := attrs[unix.IFLA_OPERSTATE]; len(value) >= 1 && int(value[0]) < len(operStateNames) {
+		state.State = operStateNames[value[0]]
+		state.IsUp = state.State == "UP"
+	}
+
+	if value := attrs[unix.IFLA_MTU]; len(value) >= 4 {
+		state.MTU = int(binary.NativeEndian.Uint32(value))
+	}
+
+	// rtnl_link_stats64: rx/tx packets, rx/tx bytes, then rx/tx errors
+	if value := attrs[unix.IFLA_STATS64]; len(value) >= 48 {
+		state.RxErrors = int(binary.NativeEndian.Uint64(value[32:40]))
+		state.TxErrors = int(binary.NativeEndian.Uint64(value[40:48]))
+	}
+
+	linkInfo := parseNestedAttrs(attrs[unix.IFLA_LINKINFO])
+	if netlinkString(linkInfo[unix.IFLA_INFO_KIND]) != "can" {
+		return state
+	}
+	data := parseNestedAttrs(linkInfo[unix.IFLA_INFO_DATA])
+
+	// can_bittiming: bitrate, sample point in tenths of a percent, tq in ns, prop_seg,
+	// phase_seg1, phase_seg2, sjw, brp
+	if value := data[unix.IFLA_CAN_BITTIMING]; len(value) >= 4 {
+		state.Bitrate = int(binary.NativeEndian.Uint32(value))
+	}
+	if value := data[unix.IFLA_CAN_BITTIMING]; len(value) >= 32 {
+		field := func(i int) int { return int(binary.NativeEndian.Uint32(value[i*4:])) }
+		state.SamplePoint = float64(field(1)) / 1000
+		state.BitTiming = &BitTiming{
+			TimeQuantumNs: field(2),
+			PropSeg:       field(3),
+			PhaseSeg1:     field(4),
+			PhaseSeg2:     field(5),
+			SJW:           field(6),
+			BRP:           field(7),
+		}
+	}
+	// can_ctrlmode: mask, flags
+	if value := data[unix.IFLA_CAN_CTRLMODE]; len(value) >= 8 {
+		flags := binary.NativeEndian.Uint32(value[4:8])
+		state.TripleSampling = flags&unix.CAN_CTRLMODE_3_SAMPLES != 0
+		state.OneShot = flags&unix.CAN_CTRLMODE_ONE_SHOT != 0
+	}
+	if value := data[unix.IFLA_CAN_STATE]; len(value) >= 4 {
+		if canState := binary.NativeEndian.Uint32(value); int(canState) < len(canStateNames) {
+			state.CanState = canStateNames[canState]
+		}
+	}
+	if value := data[unix.IFLA_CAN_RESTART_MS]; len(value) >= 4 {
+		state.RestartMs = int(binary.NativeEndian.Uint32(value))
+	}
+	if value := data[unix.IFLA_CAN_BERR_COUNTER]; len(value) >= 4 {
+		state.TxErrorCounter = int(binary.NativeEndian.Uint16(value[0:2]))
+		state.RxErrorCounter = int(binary.NativeEndian.Uint16(value[2:4]))
+	}
+	return state
+}
+
+// parseNestedAttrs splits the payload of a nested attribute into its attributes
+func parseNestedAttrs(payload []byte) map[uint16][]byte {
+	attrs := make(map[uint16][]byte)
+	for len(payload) >= unix.SizeofRtAttr {
+		length := int(binary.NativeEndian.Uint16(payload[0:2]))
+		attrType := binary.NativeEndian.Uint16(payload[2:4])
+		if length < unix.SizeofRtAttr || length > len(payload) {
+			break
+		}
+		attrs[attrType&netlinkAttrTypeMask] = payload[unix.SizeofRtAttr:length]
+
+		aligned := (length + unix.NLA_ALIGNTO - 1) &^ (unix.NLA_ALIGNTO - 1)
+		if aligned > len(payload) {
+			break
+		}
+		payload = payload[aligned:]
+	}
+	return attrs
+}
+
+// netlinkString decodes a NUL-terminated string attribute
+func netlinkString(value []byte) string {
+	return strings.TrimRight(string(value), "\x00")
+}
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Notification severities, from least to most severe
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Notification event types
+const (
+	NotifyInterfaceDegraded = "interface_degraded"
+	NotifyInterfaceFailed   = "interface_failed"
+	NotifyInterfaceHealthy  = "interface_healthy"
+	NotifyRecoverySucceeded = "recovery_succeeded"
+	NotifyRecoveryFailed    = "recovery_failed"
+	NotifyRecoveryGaveUp    = "recovery_gave_up"
+	NotifyBusSilent         = "bus_silent"
+	NotifyTrafficResumed    = "traffic_resumed"
+	NotifyWatchdogPaused    = "watchdog_paused"
+	NotifyWatchdogResumed   = "watchdog_resumed"
+	NotifyForcedRetry       = "forced_retry"
+	NotifySetupFailed       = "setup_failed"
+	NotifyReconfigured      = "interface_reconfigured"
+	NotifyErrorBurst        = "error_burst"
+	NotifyAlertFiring       = "alert_firing"
+	NotifyAlertResolved     = "alert_resolved"
+	NotifyFrameChanged      = "frame_changed"
+)
+
+// notificationSchemaVersion is bumped whenever the payload changes incompatibly
+const notificationSchemaVersion = 1
+
+var severityRank = map[string]int{
+	SeverityInfo:     0,
+	SeverityWarning:  1,
+	SeverityCritical: 2,
+}
+
+var notificationEventTypes = []string{
+	NotifyInterfaceDegraded,
+	NotifyInterfaceFailed,
+	NotifyInterfaceHealthy,
+	NotifyRecoverySucceeded,
+	NotifyRecoveryFailed,
+	NotifyRecoveryGaveUp,
+	NotifyBusSilent,
+	NotifyTrafficResumed,
+	NotifyWatchdogPaused,
+	NotifyWatchdogResumed,
+	NotifyForcedRetry,
+	NotifySetupFailed,
+	NotifyReconfigured,
+	NotifyErrorBurst,
+	NotifyAlertFiring,
+	NotifyAlertResolved,
+	NotifyFrameChanged,
+}
+
+// isValidEventType checks whether an event type is known
+func isValidEventType(eventType string) bool {
+	for _, known := range notificationEventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Notification is the JSON payload delivered to webhooks
+type Notification struct {
+	Version   int       `json:"version"`
+	Instance  string    `json:"instance"`
+	Interface string    `json:"interface"`
+	EventType string    `json:"eventType"`
+	Severity  string    `json:"severity"`
+	Message   string    `json:"message"`
+	OldState  string    `json:"oldState,omitempty"`
+	NewState  string    `json:"newState,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"` // When the event happened
+	SentAt    time.Time `json:"sentAt"`    // When this delivery attempt was made
+}
+
+// NotifierConfig holds webhook notification configuration
+type NotifierConfig struct {
+	URLs        []string
+	EventTypes  []string // Empty means all event types
+	MinSeverity string
+	Instance    string
+	QueueSize   int
+	MaxRetries  int
+	RetryDelay  time.Duration
+	Timeout     time.Duration
+}
+
+// DefaultNotifierConfig returns default notifier configuration
+func DefaultNotifierConfig() NotifierConfig {
+	return NotifierConfig{
+		MinSeverity: SeverityInfo,
+		QueueSize:   100,
+		MaxRetries:  3,
+		RetryDelay:  2 * time.Second,
+		Timeout:     5 * time.Second,
+	}
+}
+
+// NotifierStats reports notification delivery counters
+type NotifierStats struct {
+	Enabled    bool   `json:"enabled"`
+	Published  uint64 `json:"published"`
+	Delivered  uint64 `json:"delivered"`
+	Failed     uint64 `json:"failed"`
+	Dropped    uint64 `json:"dropped"`
+	Retries    uint64 `json:"retries"`
+	QueueDepth int    `json:"queueDepth"`
+}
+
+// Notifier delivers notifications to webhooks asynchronously. Publishing never blocks:
+// when the queue is full the notification is dropped and counted. A nil Notifier, or
+// one without URLs, is valid and discards everything.
+type Notifier struct {
+	mu         sync.RWMutex // Guards the URLs, filters and instance of config, and eventTypes
+	config     NotifierConfig
+	eventTypes map[string]bool
+	queue      chan Notification
+	client     *http.Client
+	logger     Logger
+	stopChan   chan struct{}
+	wg         sync.WaitGroup
+	startOnce  sync.Once
+	stopOnce   sync.Once
+
+	published uint64
+	delivered uint64
+	failed    uint64
+	dropped   uint64
+	retries   uint64
+}
+
+// NewNotifier creates a new webhook notifier
+func NewNotifier(config NotifierConfig, logger Logger) *Notifier {
+	eventTypes := make(map[string]bool)
+	for _, eventType := range config.EventTypes {
+		eventTypes[eventType] = true
+	}
+
+	return &Notifier{
+		config:     config,
+		eventTypes: eventTypes,
+		queue:      make(chan Notification, config.QueueSize),
+		client:     &http.Client{Timeout: config.Timeout},
+		logger:     logger,
+		stopChan:   make(chan struct{}),
+	}
+}
+
+// Start starts the delivery worker
+func (n *Notifier) Start() {
+	if n == nil {
+		return
+	}
+	n.startOnce.Do(func() {
+		if urls := n.urls(); len(urls) > 0 {
+			n.logger.Printf("📣 Webhook notifications enabled for %d endpoint(s)", len(urls))
+		}
+		n.wg.Add(1)
+		go n.deliveryLoop()
+	})
+}
+
+// Stop stops the delivery worker. Queued notifications that were not yet sent are discarded.
+func (n *Notifier) Stop() {
+	if n == nil {
+		return
+	}
+	n.stopOnce.Do(func() {
+		close(n.stopChan)
+		n.wg.Wait()
+	})
+}
+
+// Publish queues a notification if it passes the event type and severity filters
+func (n *Notifier) Publish(notification Notification) {
+	if n == nil {
+		return
+	}
+	n.mu.RLock()
+	wanted := len(n.config.URLs) > 0 &&
+		(len(n.eventTypes) == 0 || n.eventTypes[notification.EventType]) &&
+		severityRank[notification.Severity] >= severityRank[n.config.MinSeverity]
+	instance := n.config.Instance
+	n.mu.RUnlock()
+	if !wanted {
+		return
+	}
+
+	notification.Version = notificationSchemaVersion
+	notification.Instance = instance
+	if notification.Timestamp.IsZero() {
+		notification.Timestamp = time.Now()
+	}
+
+	atomic.AddUint64(&n.published, 1)
+	select {
+	case n.queue <- notification:
+	default:
+		atomic.AddUint64(&n.dropped, 1)
+		n.logger.Printf("⚠️ Warning: notification queue full, dropping %s for %s", notification.EventType, notification.Interface)
+	}
+}
+
+// Reconfigure replaces the webhook URLs, event type and severity filters and instance
+// name with those of config, as a configuration reload does. Queued notifications go
+// to the new URLs.
+func (n *Notifier) Reconfigure(config NotifierConfig) {
+	if n == nil {
+		return
+	}
+	eventTypes := make(map[string]bool)
+	for _, eventType := range config.EventTypes {
+		eventTypes[eventType] = true
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.config.URLs = config.URLs
+	n.config.EventTypes = config.EventTypes
+	n.config.MinSeverity = config.MinSeverity
+	n.config.Instance = config.Instance
+	n.eventTypes = eventTypes
+}
+
+// urls returns the webhooks notifications are delivered to
+func (n *Notifier) urls() []string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.config.URLs
+}
+
+// GetStats returns delivery counters
+func (n *Notifier) GetStats() NotifierStats {
+	if n == nil {
+		return NotifierStats{}
+	}
+	return NotifierStats{
+		Enabled:    len(n.urls()) > 0,
+		Published:  atomic.LoadUint64(&n.published),
+		Delivered:  atomic.LoadUint64(&n.delivered),
+		Failed:     atomic.LoadUint64(&n.failed),
+		Dropped:    atomic.LoadUint64(&n.dropped),
+		Retries:    atomic.LoadUint64(&n.retries),
+		QueueDepth: len(n.queue),
+	}
+}
+
+// deliveryLoop sends queued notifications to every webhook
+func (n *Notifier) deliveryLoop() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case <-n.stopChan:
+			return
+		case notification := <-n.queue:
+			for _, url := range n.urls() {
+				n.deliverWithRetry(url, notification)
+			}
+		}
+	}
+}
+
+// deliverWithRetry posts a notification to one webhook, retrying with a linear delay
+func (n *Notifier) deliverWithRetry(url string, notification Notification) {
+	var lastErr error
+
+	for attempt := 0; attempt <= n.config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			atomic.AddUint64(&n.retries, 1)
+			select {
+			case <-n.stopChan:
+				return
+			case <-time.After(time.Duration(attempt) * n.config.RetryDelay):
+			}
+		}
+
+		notification.SentAt = time.Now()
+		if lastErr = n.post(url, notification); lastErr == nil {
+			atomic.AddUint64(&n.delivered, 1)
+			return
+		}
+	}
+
+	atomic.AddUint64(&n.failed, 1)
+	n.logger.Printf("❌ Failed to deliver %s notification to %s after %d attempts: %v",
+		notification.EventType, url, n.config.MaxRetries+1, lastErr)
+}
+
+// post sends a single webhook request
+func (n *Notifier) post(url string, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// watchdogNotification maps a watchdog event to a notification
+func watchdogNotification(event WatchdogEvent) (Notification, bool) {
+	notification := Notification{
+		Interface: event.Interface,
+		Message:   event.Reason,
+		OldState:  event.OldState,
+		NewState:  event.NewState,
+		Error:     event.Error,
+		Timestamp: event.Timestamp,
+	}
+
+	switch {
+	case event.Action == "reinitialize" && event.Success:
+		notification.EventType, notification.Severity = NotifyRecoverySucceeded, SeverityInfo
+	case event.Action == "reinitialize" && event.NewState == healthStateQuarantined:
+		notification.EventType, notification.Severity = NotifyRecoveryGaveUp, SeverityCritical
+	case event.Action == "reinitialize":
+		notification.EventType, notification.Severity = NotifyRecoveryFailed, SeverityWarning
+	case event.Action == "pause":
+		notification.EventType, notification.Severity = NotifyWatchdogPaused, SeverityInfo
+	case event.Action == "resume":
+		notification.EventType, notification.Severity = NotifyWatchdogResumed, SeverityInfo
+	case event.Action == "reconfigure" && event.Success:
+		notification.EventType, notification.Severity = NotifyReconfigured, SeverityInfo
+	case event.Action == "reconfigure":
+		notification.EventType, notification.Severity = NotifyReconfigured, SeverityCritical
+	case event.Action == "force_retry":
+		notification.EventType, notification.Severity = NotifyForcedRetry, SeverityInfo
+	case event.NewState == healthStateDegraded:
+		notification.EventType, notification.Severity = NotifyInterfaceDegraded, SeverityWarning
+	case event.NewState == healthStateFailed:
+		notification.EventType, notification.Severity = NotifyInterfaceFailed, SeverityCritical
+	case event.NewState == healthStateHealthy:
+		notification.EventType, notification.Severity = NotifyInterfaceHealthy, SeverityInfo
+	case event.NewState == watchdogStateBusSilent:
+		notification.EventType, notification.Severity = NotifyBusSilent, SeverityWarning
+	case event.NewState == watchdogStateReceiving:
+		notification.EventType, notification.Severity = NotifyTrafficResumed, SeverityInfo
+	default:
+		return notification, false
+	}
+
+	return notification, true
+}
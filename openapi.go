@@ -0,0 +1,442 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// apiFields describes a response built as a map in its handler: each value's type is the
+// type of that field
+type apiFields map[string]interface{}
+
+// apiParameter is a query parameter of an operation
+type apiParameter struct {
+	Name        string
+	Description string
+	Required    bool
+}
+
+// apiOperation documents a route. Request and Response are values of the types the
+// handler binds and returns, so the schemas follow the Go structs.
+type apiOperation struct {
+	Summary  string
+	Request  interface{} // Body type; nil for no body
+	Response interface{} // Type of ApiResponse.data; nil for none
+	Query    []apiParameter
+	Raw      bool // Response is not an ApiResponse (text, HTML or this document)
+}
+
+// Shapes shared by several map responses
+var (
+	interfaceStatusFields = apiFields{"interface": "", "status": ""}
+	listenFields          = apiFields{"interface": "", "status": "", "isListening": false}
+	batchFields           = apiFields{"results": map[string]apiFields{}, "totalCount": 0, "successCount": 0, "errorCount": 0, "errors": map[string]string{}}
+)
+
+// dataFilterParameters are the payload filter parameters of the message queries
+var dataFilterParameters = []apiParameter{
+	{Name: "dataMatch", Description: "Only messages whose payload matches these hex bytes under dataMask"},
+	{Name: "dataMask", Description: "Hex mask of the payload bytes compared with dataMatch (default: all bits of dataMatch)"},
+}
+
+// apiOperations documents the routes registered in SetupRoutes, keyed by "METHOD path".
+// Routes missing here are still listed in the specification, without schemas.
+var apiOperations = map[string]apiOperation{
+	"GET /":        {Summary: "Service liveness text", Raw: true},
+	"GET /metrics": {Summary: "Prometheus metrics in the text exposition format", Raw: true},
+	"GET /healthz": {Summary: "Health probe: 200 while the process serves HTTP"},
+	"GET /readyz":  {Summary: "Readiness probe: 503 with reasons until interfaces are initialized and the watchdog runs", Response: ProbeResult{}},
+	"GET /livez":   {Summary: "Liveness probe: 503 with reasons when the watchdog or a receive loop stopped ticking", Response: ProbeResult{}},
+	"POST /debug/blackbox": {Summary: "Write recent logs, frames, watchdog events and goroutine stacks to a blackbox file", Response: BlackboxDump{}, Query: []apiParameter{
+		{Name: "reason", Description: "Note written in the file header"},
+	}},
+
+	"POST /api/v1/can":              {Summary: "Send a CAN message", Request: CanMessage{}, Response: SendResult{}},
+	"POST /api/v1/can/multi":        {Summary: "Send one frame on several interfaces concurrently", Request: MultiSendRequest{}, Response: MultiSendResult{}},
+	"POST /api/v1/can/until":        {Summary: "Send a frame periodically until a matching frame is received", Request: SendUntilRequest{}, Response: SendUntilResult{}},
+	"POST /api/v1/benchmark/tx":     {Summary: "Send a frame as fast as possible for a duration and report the achieved rate", Request: TxBenchmarkRequest{}, Response: TxBenchmarkResult{}},
+	"GET /api/v1/tasks":             {Summary: "Running transmit tasks, such as send-until runs", Response: apiFields{"tasks": []TransmitTask{}, "count": 0}},
+	"DELETE /api/v1/tasks":          {Summary: "Cancel every running transmit task", Response: apiFields{"cancelled": []TransmitTask{}, "count": 0}},
+	"POST /api/v1/send/signal":      {Summary: "Encode DBC signal values into a message and send it", Request: SignalSendRequest{}, Response: SendResult{}},
+	"GET /api/v1/send/named":        {Summary: "Named messages of the configuration file", Response: []NamedMessage{}},
+	"POST /api/v1/send/named/:name": {Summary: "Send a named message, optionally replacing payload bytes", Request: NamedSendRequest{}, Response: SendResult{}},
+
+	"GET /api/v1/status":                  {Summary: "Complete system status", Response: SystemStatus{}},
+	"GET /api/v1/interfaces":              {Summary: "Configured, active and listening interfaces", Response: InterfaceList{}},
+	"GET /api/v1/interfaces/:name/status": {Summary: "Status of one interface", Response: InterfaceDetail{}},
+	"GET /api/v1/interfaces/:name/load":   {Summary: "Bus load of one interface over rolling windows, stuff bits included", Response: BusLoad{}},
+	"POST /api/v1/interfaces/:name/tx":    {Summary: "Enable or disable transmission on an interface", Request: TxEnableRequest{}, Response: apiFields{"interface": "", "txEnabled": false, "changed": false}},
+	"GET /api/v1/health":                  {Summary: "Health summary of all interfaces", Response: HealthSummary{}},
+	"GET /api/v1/metrics":                 {Summary: "Metrics of all interfaces", Response: apiFields{}},
+
+	"GET /api/v1/stats/ids": {Summary: "Per-ID statistics over a time window", Response: InterfaceIDWindowStats{}, Query: []apiParameter{
+		{Name: "interface", Description: "Interface name", Required: true},
+		{Name: "window", Description: "Window such as 10s (default: 60s)"},
+	}},
+	"GET /api/v1/stats/:interface/ids": {Summary: "Per-ID statistics of an interface", Response: InterfaceIDStats{}, Query: []apiParameter{
+		{Name: "top", Description: "Only the busiest IDs"},
+	}},
+	"POST /api/v1/stats/:interface/ids/reset": {Summary: "Reset per-ID statistics of an interface"},
+	"GET /api/v1/stats/:interface/errors":     {Summary: "Error frame statistics of an interface", Response: InterfaceErrorStats{}},
+	"GET /api/v1/stats/:interface/kernel":     {Summary: "Kernel counters of an interface next to the frames the listener read", Response: SocketStatsReport{}},
+
+	"GET /api/v1/simulator/nodes": {Summary: "Counters of the simulated nodes", Response: []SimulatedNodeStats{}},
+
+	"GET /api/v1/alerts":       {Summary: "Alert rules and their state", Response: apiFields{"alerts": []AlertStatus{}, "active": []AlertStatus{}}},
+	"POST /api/v1/alerts/test": {Summary: "Evaluate an alert rule once", Request: AlertRule{}, Response: AlertTestResult{}},
+
+	"GET /api/v1/triggers":          {Summary: "Frame triggers with their counters and last change", Response: apiFields{"triggers": []FrameTriggerStatus{}}},
+	"POST /api/v1/triggers":         {Summary: "Notify when the payload, or a masked byte, of a CAN ID changes", Request: FrameTrigger{}, Response: FrameTrigger{}},
+	"DELETE /api/v1/triggers/:name": {Summary: "Remove a frame trigger"},
+
+	"GET /api/v1/capture":                   {Summary: "Captured interfaces and frame capture counters", Response: CaptureStatus{}},
+	"POST /api/v1/capture/:interface/start": {Summary: "Start writing the frames of an interface to a candump log file", Response: apiFields{"interface": "", "capturing": false, "changed": false}},
+	"POST /api/v1/capture/:interface/stop":  {Summary: "Stop the capture of an interface and close its file", Response: apiFields{"interface": "", "capturing": false, "changed": false}},
+
+	"GET /api/v1/j1939":                  {Summary: "J1939 interfaces with their source address, socket state and counters", Response: apiFields{"interfaces": map[string]J1939InterfaceStatus{}}},
+	"POST /api/v1/j1939/:interface/send": {Summary: "Send a J1939 parameter group, using the transport protocol beyond 8 bytes", Request: J1939SendRequest{}, Response: J1939SendResult{}},
+	"GET /api/v1/j1939/:interface/messages": {Summary: "Last J1939 messages received on an interface", Response: apiFields{"interface": "", "messages": []J1939Message{}, "requestedCount": 0, "actualCount": 0}, Query: []apiParameter{
+		{Name: "count", Description: "Number of messages (default 10)"},
+		{Name: "pgn", Description: "PGN, decimal or 0x-prefixed hex"},
+		{Name: "source", Description: "Source address"},
+		{Name: "destination", Description: "Destination address, 255 for broadcasts"},
+	}},
+
+	"POST /api/v1/watchdog/interfaces/:name/retry": {Summary: "Retry recovery of an interface immediately", Response: interfaceStatusFields},
+	"GET /api/v1/watchdog/events": {Summary: "Watchdog state transitions and recovery actions", Response: apiFields{"events": []WatchdogEvent{}, "count": 0}, Query: []apiParameter{
+		{Name: "interface", Description: "Interface name"},
+		{Name: "since", Description: "RFC3339 timestamp or a duration such as 1h"},
+		{Name: "until", Description: "RFC3339 timestamp or a duration such as 1h"},
+		{Name: "limit", Description: "Maximum number of events"},
+	}},
+	"GET /api/v1/audit": {Summary: "Mutating API calls and reload signals with principal, parameters and outcome", Response: apiFields{"records": []AuditRecord{}, "count": 0}, Query: []apiParameter{
+		{Name: "principal", Description: "API key name, client certificate identity or signal:SIGHUP"},
+		{Name: "outcome", Description: "success, failure or denied"},
+		{Name: "since", Description: "RFC3339 timestamp or a duration such as 1h"},
+		{Name: "until", Description: "RFC3339 timestamp or a duration such as 1h"},
+		{Name: "limit", Description: "Maximum number of records, the most recent"},
+	}},
+	"GET /api/v1/config":           {Summary: "Effective configuration, secrets redacted; the ETag header identifies it", Response: map[string]interface{}{}},
+	"PUT /api/v1/config":           {Summary: "Apply a configuration file sent as the body; If-Match is required", Response: ReloadResult{}, Query: []apiParameter{{Name: "persist", Description: "Also write the file over the -config file (default: false)"}}},
+	"POST /api/v1/config/reload":   {Summary: "Reload the configuration", Response: ReloadResult{}},
+	"GET /api/v1/logging":          {Summary: "Log levels in effect", Response: LogLevelsStatus{}},
+	"PUT /api/v1/logging":          {Summary: "Change log levels until restart", Request: LogLevelsRequest{}, Response: LogLevelsStatus{}},
+	"POST /api/v1/watchdog/pause":  {Summary: "Pause watchdog recovery", Request: WatchdogPauseRequest{}, Response: apiFields{"interface": "", "status": "", "since": time.Time{}, "autoResumeAt": time.Time{}}},
+	"POST /api/v1/watchdog/resume": {Summary: "Resume watchdog recovery", Request: WatchdogPauseRequest{}, Response: interfaceStatusFields},
+
+	"POST /api/v1/interfaces/:name/bitrate":         {Summary: "Change the bitrate of an interface", Request: BitrateChangeRequest{}, Response: InterfaceState{}},
+	"GET /api/v1/setup/status":                      {Summary: "Setup result and state of every configured interface", Response: apiFields{"interfaces": []SetupReport{}, "totalCount": 0, "succeededCount": 0, "failedCount": 0, "notSetUpCount": 0}},
+	"GET /api/v1/setup/config":                      {Summary: "Interface setup configuration", Response: InterfaceSetupConfig{}},
+	"PUT /api/v1/setup/config":                      {Summary: "Update the interface setup configuration", Request: SetupConfigRequest{}, Response: InterfaceSetupConfig{}},
+	"GET /api/v1/setup/available":                   {Summary: "CAN interfaces present on the system", Response: apiFields{"interfaces": []string{}, "count": 0}},
+	"POST /api/v1/setup/interfaces/:name":           {Summary: "Set up and bring up an interface", Request: SetupInterfaceRequest{}, Response: InterfaceState{}},
+	"DELETE /api/v1/setup/interfaces/:name":         {Summary: "Tear down an interface", Response: interfaceStatusFields},
+	"POST /api/v1/setup/interfaces/:name/reset":     {Summary: "Reset an interface", Response: InterfaceState{}},
+	"GET /api/v1/setup/interfaces/:name/state":      {Summary: "Kernel state of an interface", Response: InterfaceState{}},
+	"POST /api/v1/setup/interfaces/setup-all":       {Summary: "Set up all or the listed interfaces", Request: SetupAllInterfacesRequest{}, Response: batchFields},
+	"POST /api/v1/setup/interfaces/teardown-all":    {Summary: "Tear down all configured interfaces", Response: batchFields},
+	"GET /api/v1/messages/:interface":               {Summary: "Received messages of an interface", Response: apiFields{"interface": "", "messages": []CanMessageLog{}, "count": 0, "isListening": false}, Query: append([]apiParameter{{Name: "id", Description: "Only messages matching this ID"}}, dataFilterParameters...)},
+	"GET /api/v1/messages/:interface/recent":        {Summary: "Most recent messages of an interface", Response: apiFields{"interface": "", "messages": []CanMessageLog{}, "requestedCount": 0, "actualCount": 0, "isListening": false}, Query: append([]apiParameter{{Name: "count", Description: "Number of messages (default: 10)"}}, dataFilterParameters...)},
+	"GET /api/v1/messages/:interface/statistics":    {Summary: "Receive buffer statistics of an interface", Response: MessageBufferStats{}},
+	"DELETE /api/v1/messages/:interface":            {Summary: "Clear the received messages of an interface", Response: interfaceStatusFields},
+	"GET /api/v1/messages/":                         {Summary: "Received messages of all interfaces", Response: apiFields{"interfaces": map[string][]CanMessageLog{}, "interfaceCount": 0, "listeningInterfaces": []string{}}, Query: dataFilterParameters},
+	"GET /api/v1/messages/statistics":               {Summary: "Receive buffer statistics of all interfaces", Response: apiFields{"statistics": map[string]MessageBufferStats{}, "listeningInterfaces": []string{}}},
+	"DELETE /api/v1/messages/":                      {Summary: "Clear the received messages of all interfaces", Response: apiFields{"status": ""}},
+	"POST /api/v1/messages/:interface/listen/start": {Summary: "Start listening on an interface", Response: listenFields},
+	"POST /api/v1/messages/:interface/listen/stop":  {Summary: "Stop listening on an interface", Response: listenFields},
+	"GET /api/v1/messages/:interface/listen/status": {Summary: "Listening state of an interface", Response: apiFields{"interface": "", "status": "", "isListening": false, "statistics": MessageBufferStats{}}},
+	"GET /api/v1/messages/listen/status":            {Summary: "Listening state of all interfaces", Response: apiFields{"listeningInterfaces": []string{}, "listeningCount": 0, "allStatistics": map[string]MessageBufferStats{}}},
+	"GET /openapi.json":                             {Summary: "This OpenAPI document", Raw: true},
+	"GET /docs":                                     {Summary: "Swagger UI for this API", Raw: true},
+}
+
+// ginPathParam matches gin path parameters such as :name
+var ginPathParam = regexp.MustCompile(`:(\w+)`)
+
+// openAPISchemas builds JSON schemas from Go types, collecting named structs as components
+type openAPISchemas struct {
+	components map[string]interface{}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	durationType   = reflect.TypeOf(time.Duration(0))
+	logLevelType   = reflect.TypeOf(LogLevel(0))
+	apiFieldsType  = reflect.TypeOf(apiFields{})
+	byteSliceType  = reflect.TypeOf([]byte(nil))
+	frameDataType  = reflect.TypeOf(FrameData(nil))
+	emptyInterface = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+// schemaOf returns the schema of a value; apiFields values describe an object field by field
+func (s *openAPISchemas) schemaOf(value interface{}) map[string]interface{} {
+	if fields, ok := value.(apiFields); ok {
+		return s.fieldsSchema(fields)
+	}
+	return s.schema(reflect.TypeOf(value))
+}
+
+// fieldsSchema returns the object schema of a map response
+func (s *openAPISchemas) fieldsSchema(fields apiFields) map[string]interface{} {
+	if len(fields) == 0 {
+		return map[string]interface{}{"type": "object"}
+	}
+	properties := make(map[string]interface{}, len(fields))
+	for name, value := range fields {
+		properties[name] = s.schemaOf(value)
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// schema returns the JSON schema of a type as encoding/json renders it
+func (s *openAPISchemas) schema(t reflect.Type) map[string]interface{} {
+	switch t {
+	case timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case durationType:
+		return map[string]interface{}{"type": "integer", "format": "int64", "description": "Nanoseconds"}
+	case byteSliceType:
+		return map[string]interface{}{"type": "string", "format": "byte"}
+	case frameDataType:
+		return map[string]interface{}{
+			"oneOf": []interface{}{
+				map[string]interface{}{"type": "string", "description": "Hex bytes such as \"02 10 01\", or base64"},
+				map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "integer", "minimum": 0, "maximum": 255}},
+			},
+			"description": "Payload; responses render it as base64",
+		}
+	case logLevelType:
+		return map[string]interface{}{"type": "string", "enum": logLevelNames}
+	case emptyInterface:
+		return map[string]interface{}{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.schema(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": s.schema(t.Elem())}
+	case reflect.Map:
+		if t == apiFieldsType {
+			return map[string]interface{}{"type": "object"}
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		if _, exists := s.components[t.Name()]; !exists {
+			s.components[t.Name()] = map[string]interface{}{} // Placeholder for recursive types
+			s.components[t.Name()] = s.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+// structSchema returns the object schema of a struct. Embedded structs without a JSON
+// name are flattened, as encoding/json does; binding:"required" fields are required.
+func (s *openAPISchemas) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	s.addFields(t, properties, &required)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields adds the JSON fields of a struct to properties
+func (s *openAPISchemas) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			s.addFields(fieldType, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = s.schema(field.Type)
+		if strings.Contains(field.Tag.Get("binding"), "required") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// buildOpenAPISpec documents every registered route. Request and response schemas come
+// from apiOperations; the route list itself comes from gin, so no route can be left out.
+func buildOpenAPISpec(routes gin.RoutesInfo, apiKeys bool) map[string]interface{} {
+	schemas := &openAPISchemas{components: make(map[string]interface{})}
+	paths := make(map[string]interface{})
+
+	for _, route := range routes {
+		path := ginPathParam.ReplaceAllString(route.Path, "{$1}")
+		operation := apiOperations[route.Method+" "+route.Path]
+
+		summary := operation.Summary
+		if summary == "" {
+			summary = route.Method + " " + route.Path
+		}
+		spec := map[string]interface{}{"summary": summary}
+
+		var parameters []interface{}
+		for _, match := range ginPathParam.FindAllStringSubmatch(route.Path, -1) {
+			parameters = append(parameters, map[string]interface{}{
+				"name": match[1], "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, query := range operation.Query {
+			parameters = append(parameters, map[string]interface{}{
+				"name": query.Name, "in": "query", "required": query.Required, "description": query.Description,
+				"schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		if len(parameters) > 0 {
+			spec["parameters"] = parameters
+		}
+
+		if operation.Request != nil {
+			spec["requestBody"] = map[string]interface{}{
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemas.schemaOf(operation.Request)},
+				},
+			}
+		}
+
+		if operation.Raw {
+			spec["responses"] = map[string]interface{}{"200": map[string]interface{}{"description": "Success"}}
+		} else {
+			envelope := schemas.schema(reflect.TypeOf(ApiResponse{}))
+			if operation.Response != nil {
+				envelope = map[string]interface{}{
+					"allOf": []interface{}{
+						envelope,
+						map[string]interface{}{"type": "object", "properties": map[string]interface{}{"data": schemas.schemaOf(operation.Response)}},
+					},
+				}
+			}
+			spec["responses"] = map[string]interface{}{
+				"200":     map[string]interface{}{"description": "Success", "content": map[string]interface{}{"application/json": map[string]interface{}{"schema": envelope}}},
+				"default": map[string]interface{}{"description": "Error", "content": map[string]interface{}{"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/ApiResponse"}}}},
+			}
+		}
+
+		methods, _ := paths[path].(map[string]interface{})
+		if methods == nil {
+			methods = make(map[string]interface{})
+			paths[path] = methods
+		}
+		methods[strings.ToLower(route.Method)] = spec
+	}
+
+	components := map[string]interface{}{"schemas": schemas.components}
+	spec := map[string]interface{}{
+		"openapi":    "3.0.3",
+		"info":       map[string]interface{}{"title": "CAN Bridge API", "version": VERSION},
+		"paths":      paths,
+		"components": components,
+	}
+	if apiKeys {
+		components["securitySchemes"] = map[string]interface{}{
+			"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			"apiKey":     map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+		}
+		spec["security"] = []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{"apiKey": []string{}},
+		}
+	}
+	return spec
+}
+
+// docsAssetsPrefix is the path the files of Swagger UI are served under
+const docsAssetsPrefix = "/docs/assets/"
+
+// swaggerUIAssets are the files of Swagger UI, embedded so /docs works without internet
+// access. See swagger-ui/README.md for their version and how to update them.
+//
+//go:embed swagger-ui/swagger-ui-bundle.js swagger-ui/swagger-ui.css swagger-ui/favicon-32x32.png
+var swaggerUIAssets embed.FS
+
+// swaggerUIPage loads the embedded Swagger UI and points it at /openapi.json
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>CAN Bridge API</title>
+  <link rel="icon" type="image/png" href="` + docsAssetsPrefix + `favicon-32x32.png">
+  <link rel="stylesheet" href="` + docsAssetsPrefix + `swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="` + docsAssetsPrefix + `swagger-ui-bundle.js"></script>
+  <script>SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});</script>
+</body>
+</html>
+`
+
+// handleOpenAPI serves the OpenAPI document
+func (h *APIHandler) handleOpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, h.openAPISpec)
+}
+
+// handleDocs serves the Swagger UI page
+func (h *APIHandler) handleDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// handleDocsAsset serves a file of the embedded Swagger UI. The files change only with
+// the binary, so browsers may cache them for a day.
+func (h *APIHandler) handleDocsAsset(c *gin.Context) {
+	name := strings.TrimPrefix(c.Param("file"), "/")
+	data, err := fs.ReadFile(swaggerUIAssets, path.Join("swagger-ui", name))
+	if name == "" || err != nil {
+		h.respondError(c, http.StatusNotFound, fmt.Sprintf("No route for %s %s", c.Request.Method, c.Request.URL.Path), nil)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, mime.TypeByExtension(path.Ext(name)), data)
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newDocsRouter returns a router with every route of the API and its documentation
+// registered. Routes are registered by whether their feature is set, not used, so zero
+// values stand in for the features.
+func newDocsRouter(t *testing.T) (*gin.Engine, *APIHandler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h := NewAPIHandlerWithSetupAndListener(nil, nil, &InterfaceSetupManager{}, &CanMessageListener{}, NewLogger(nil))
+	h.probes, h.blackbox, h.dbc, h.namedMessages = &Probes{}, &Blackbox{}, &DBC{}, map[string]*NamedMessage{}
+	h.simulator, h.frameCapture, h.j1939 = &NodeSimulator{}, &FrameCapture{}, &J1939Manager{}
+	h.configManager, h.logSettings, h.audit = &Service{}, &LogSettings{}, &AuditLog{}
+	h.SetAPIDocs(true)
+	r := gin.New()
+	h.SetupRoutes(r)
+	return r, h
+}
+
+// servedSpec fetches /openapi.json and decodes it as a client would
+func servedSpec(t *testing.T, r *gin.Engine) (map[string]interface{}, []byte) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status %d", w.Code)
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("GET /openapi.json: %v", err)
+	}
+	return spec, w.Body.Bytes()
+}
+
+// resolveSchema follows a $ref into the components of the document
+func resolveSchema(t *testing.T, spec, schema map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return schema
+	}
+	name := strings.TrimPrefix(ref, "#/components/schemas/")
+	component, ok := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})[name].(map[string]interface{})
+	if !ok {
+		t.Fatalf("%s does not resolve to a component", ref)
+	}
+	return component
+}
+
+func TestOpenAPISpecRoundTrip(t *testing.T) {
+	r, _ := newDocsRouter(t)
+	spec, served := servedSpec(t, r)
+
+	// Encoding the decoded document again gives the same bytes: nothing in it is lost
+	// or changed by JSON, such as a value encoding/json cannot represent
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, served); err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded, compact.Bytes()) {
+		t.Errorf("document changed in a JSON round trip:\nserved:    %.200s\nre-encoded: %.200s", compact.Bytes(), encoded)
+	}
+
+	if spec["openapi"] != "3.0.3" {
+		t.Errorf("openapi = %v, want 3.0.3", spec["openapi"])
+	}
+
+	// Every route is documented, but the files behind the Swagger UI page
+	paths := spec["paths"].(map[string]interface{})
+	for _, route := range r.Routes() {
+		if strings.HasPrefix(route.Path, docsAssetsPrefix) {
+			if _, ok := paths[ginPathParam.ReplaceAllString(route.Path, "{$1}")]; ok {
+				t.Errorf("%s is documented", route.Path)
+			}
+			continue
+		}
+		methods, _ := paths[ginPathParam.ReplaceAllString(route.Path, "{$1}")].(map[string]interface{})
+		if _, ok := methods[strings.ToLower(route.Method)]; !ok {
+			t.Errorf("%s %s is not documented", route.Method, route.Path)
+		}
+	}
+
+	// Every reference resolves
+	var walk func(value interface{})
+	walk = func(value interface{}) {
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if _, ok := value["$ref"]; ok {
+				resolveSchema(t, spec, value)
+			}
+			for _, v := range value {
+				walk(v)
+			}
+		case []interface{}:
+			for _, v := range value {
+				walk(v)
+			}
+		}
+	}
+	walk(spec)
+}
+
+func TestOpenAPISchemasMatchJSON(t *testing.T) {
+	r, _ := newDocsRouter(t)
+	spec, _ := servedSpec(t, r)
+	paths := spec["paths"].(map[string]interface{})
+
+	// The fields encoding/json writes for a request or response are the properties the
+	// document declares for it
+	checkFields := func(t *testing.T, value interface{}, schema map[string]interface{}) {
+		t.Helper()
+		if reflect.ValueOf(value).Kind() != reflect.Struct && reflect.TypeOf(value) != apiFieldsType {
+			return
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(encoded, &fields); err != nil {
+			t.Fatal(err)
+		}
+		schema = resolveSchema(t, spec, schema)
+		properties, _ := schema["properties"].(map[string]interface{})
+		for name := range fields {
+			if _, ok := properties[name]; !ok {
+				t.Errorf("field %q of %T is not in its schema", name, value)
+			}
+		}
+		if reflect.TypeOf(value) == apiFieldsType && len(properties) != len(fields) {
+			t.Errorf("schema of %T has %d properties, want %d", value, len(properties), len(fields))
+		}
+	}
+
+	for key, operation := range apiOperations {
+		method, path, _ := strings.Cut(key, " ")
+		methods, _ := paths[ginPathParam.ReplaceAllString(path, "{$1}")].(map[string]interface{})
+		documented, ok := methods[strings.ToLower(method)].(map[string]interface{})
+		if !ok {
+			t.Errorf("%s is described but not registered", key)
+			continue
+		}
+		t.Run(key, func(t *testing.T) {
+			if operation.Request != nil {
+				body := documented["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})
+				checkFields(t, operation.Request, body["schema"].(map[string]interface{}))
+			}
+			if operation.Response != nil && !operation.Raw {
+				ok := documented["responses"].(map[string]interface{})["200"].(map[string]interface{})
+				envelope := ok["content"].(map[string]interface{})["application/json"].(map[string]interface{})["schema"].(map[string]interface{})
+				data := envelope["allOf"].([]interface{})[1].(map[string]interface{})["properties"].(map[string]interface{})["data"].(map[string]interface{})
+				checkFields(t, operation.Response, data)
+			}
+		})
+	}
+}
+
+func TestDocsServeEmbeddedSwaggerUI(t *testing.T) {
+	r, _ := newDocsRouter(t)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /docs: status %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "https://") {
+		t.Errorf("GET /docs loads from another host:\n%s", w.Body.String())
+	}
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+	}{
+		{docsAssetsPrefix + "swagger-ui-bundle.js", http.StatusOK, "javascript"},
+		{docsAssetsPrefix + "swagger-ui.css", http.StatusOK, "text/css"},
+		{docsAssetsPrefix + "favicon-32x32.png", http.StatusOK, "image/png"},
+		{docsAssetsPrefix, http.StatusNotFound, "application/json"},
+		{docsAssetsPrefix + "index.html", http.StatusNotFound, "application/json"},
+		{docsAssetsPrefix + "../openapi.go", http.StatusNotFound, "application/json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if tt.status == http.StatusOK && !strings.Contains(w.Body.String(), `"`+tt.path+`"`) {
+				t.Errorf("GET /docs does not reference %s", tt.path)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.status {
+				t.Errorf("status %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get("Content-Type"); !strings.Contains(got, tt.contentType) {
+				t.Errorf("Content-Type %q, want %s", got, tt.contentType)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// otlpTemporalityCumulative marks sums and histograms that count from the exporter start
+const otlpTemporalityCumulative = 2
+
+// otlpMetric is a metric in OTLP JSON form; exactly one of Sum, Gauge and Histogram is set
+type otlpMetric struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Unit        string         `json:"unit,omitempty"`
+	Sum         *otlpSum       `json:"sum,omitempty"`
+	Gauge       *otlpGauge     `json:"gauge,omitempty"`
+	Histogram   *otlpHistogram `json:"histogram,omitempty"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpNumberPoint `json:"dataPoints"`
+	AggregationTemporality int               `json:"aggregationTemporality"`
+	IsMonotonic            bool              `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpNumberPoint `json:"dataPoints"`
+}
+
+type otlpNumberPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	AsDouble          float64        `json:"asDouble"`
+}
+
+type otlpHistogram struct {
+	DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+}
+
+type otlpHistogramPoint struct {
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	TimeUnixNano      string         `json:"timeUnixNano"`
+	Count             string         `json:"count"`
+	Sum               float64        `json:"sum"`
+	BucketCounts      []string       `json:"bucketCounts"`
+	ExplicitBounds    []float64      `json:"explicitBounds"`
+}
+
+// otlpMetricSet accumulates the metrics of one export
+type otlpMetricSet struct {
+	start   string
+	now     string
+	metrics []otlpMetric
+}
+
+// point creates a number data point at the export time
+func (s *otlpMetricSet) point(value float64, attributes ...otlpKeyValue) otlpNumberPoint {
+	return otlpNumberPoint{Attributes: attributes, StartTimeUnixNano: s.start, TimeUnixNano: s.now, AsDouble: value}
+}
+
+// counter adds a cumulative monotonic sum
+func (s *otlpMetricSet) counter(name, description string, points []otlpNumberPoint) {
+	s.metrics = append(s.metrics, otlpMetric{
+		Name:        name,
+		Description: description,
+		Sum:         &otlpSum{DataPoints: points, AggregationTemporality: otlpTemporalityCumulative, IsMonotonic: true},
+	})
+}
+
+// gauge adds a gauge; gauges carry no start time
+func (s *otlpMetricSet) gauge(name, description string, points []otlpNumberPoint) {
+	for i := range points {
+		points[i].StartTimeUnixNano = ""
+	}
+	s.metrics = append(s.metrics, otlpMetric{
+		Name:        name,
+		Description: description,
+		Gauge:       &otlpGauge{DataPoints: points},
+	})
+}
+
+// histogramPoint converts a latency histogram snapshot into seconds-based buckets
+func (s *otlpMetricSet) histogramPoint(snapshot LatencyHistogramSnapshot, attributes ...otlpKeyValue) otlpHistogramPoint {
+	point := otlpHistogramPoint{
+		Attributes:        attributes,
+		StartTimeUnixNano: s.start,
+		TimeUnixNano:      s.now,
+		Count:             strconv.FormatUint(snapshot.Count, 10),
+		Sum:               snapshot.Sum.Seconds(),
+		BucketCounts:      make([]string, len(snapshot.Counts)),
+		ExplicitBounds:    make([]float64, len(sendLatencyBounds)),
+	}
+	for i, count := range snapshot.Counts {
+		point.BucketCounts[i] = strconv.FormatUint(count, 10)
+	}
+	for i, bound := range sendLatencyBounds {
+		point.ExplicitBounds[i] = bound.Seconds()
+	}
+	return point
+}
+
+// histogram adds a cumulative histogram
+func (s *otlpMetricSet) histogram(name, description string, points []otlpHistogramPoint) {
+	s.metrics = append(s.metrics, otlpMetric{
+		Name:        name,
+		Description: description,
+		Unit:        "s",
+		Histogram:   &otlpHistogram{DataPoints: points, AggregationTemporality: otlpTemporalityCumulative},
+	})
+}
+
+// collectOTLPMetrics gathers the counters exposed on /metrics. Names match the Prometheus
+// families without the _total suffix, which Prometheus-compatible backends add to sums.
+func collectOTLPMetrics(monitor *Monitor, httpMetrics *HTTPMetrics, start, now time.Time) []otlpMetric {
+	set := &otlpMetricSet{start: unixNano(start), now: unixNano(now)}
+
+	sendStats := monitor.GetSendStats()
+	names := make([]string, 0, len(sendStats))
+	for name := range sendStats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var latencies []otlpHistogramPoint
+	for _, name := range names {
+		latencies = append(latencies, set.histogramPoint(sendStats[name].SendLatency, stringAttr("interface", name)))
+	}
+	set.histogram("can_bridge_send_latency_seconds", "Time from API request acceptance to successful write().", latencies)
+
+	sendFamilies := []struct {
+		name        string
+		description string
+		monotonic   bool
+		value       func(InterfaceStats) float64
+	}{
+		{"can_bridge_frames_sent", "Frames written successfully.", true,
+			func(s InterfaceStats) float64 { return float64(s.TotalSent) }},
+		{"can_bridge_send_errors", "Failed frame writes.", true,
+			func(s InterfaceStats) float64 { return float64(s.TotalErrors) }},
+		{"can_bridge_tx_buffer_full", "Writes rejected with ENOBUFS or EAGAIN.", true,
+			func(s InterfaceStats) float64 { return float64(s.BufferFullErrors) }},
+		{"can_bridge_send_retries", "Writes retried after ENOBUFS or EAGAIN.", true,
+			func(s InterfaceStats) float64 { return float64(s.SendRetries) }},
+		{"can_bridge_send_retries_exhausted", "Frames given up when their retries ran out.", true,
+			func(s InterfaceStats) float64 { return float64(s.RetriesExhausted) }},
+		{"can_bridge_send_retry_seconds", "Time frames spent retrying writes.", true,
+			func(s InterfaceStats) float64 { return s.RetryTime.Seconds() }},
+		{"can_bridge_tx_paced", "Writes delayed for the minimum inter-frame gap.", true,
+			func(s InterfaceStats) float64 { return float64(s.PacedSends) }},
+		{"can_bridge_tx_pacing_delay_seconds", "Time writes waited for the minimum inter-frame gap.", true,
+			func(s InterfaceStats) float64 { return s.PacingDelay.Seconds() }},
+		{"can_bridge_tx_paced_rate", "Frames per second achieved by the latest run of paced writes.", false,
+			func(s InterfaceStats) float64 { return s.PacedRate }},
+		{"can_bridge_tx_queue_depth", "Sends currently waiting for the interface socket.", false,
+			func(s InterfaceStats) float64 { return float64(s.TxQueueDepth) }},
+		{"can_bridge_tx_queue_depth_max", "Highest TX queue depth seen.", false,
+			func(s InterfaceStats) float64 { return float64(s.MaxTxQueueDepth) }},
+	}
+	for _, family := range sendFamilies {
+		points := make([]otlpNumberPoint, 0, len(names))
+		for _, name := range names {
+			points = append(points, set.point(family.value(sendStats[name]), stringAttr("interface", name)))
+		}
+		if family.monotonic {
+			set.counter(family.name, family.description, points)
+		} else {
+			set.gauge(family.name, family.description, points)
+		}
+	}
+	var bandDepths, bandRejected []otlpNumberPoint
+	for _, name := range names {
+		for _, band := range txPriorities {
+			bands := sendStats[name].TxQueueBands
+			bandDepths = append(bandDepths, set.point(float64(bands[band].Depth), stringAttr("interface", name), stringAttr("band", band)))
+			bandRejected = append(bandRejected, set.point(float64(bands[band].Rejected), stringAttr("interface", name), stringAttr("band", band)))
+		}
+	}
+	set.gauge("can_bridge_tx_queue_band_depth", "Sends waiting for the interface socket per priority band.", bandDepths)
+	set.counter("can_bridge_tx_queue_rejected", "Sends rejected because their priority band of the TX queue was full.", bandRejected)
+
+	errorStats := monitor.GetAllErrorStats()
+	errorNames := make([]string, 0, len(errorStats))
+	for name := range errorStats {
+		errorNames = append(errorNames, name)
+	}
+	sort.Strings(errorNames)
+
+	errorFamilies := []struct {
+		name        string
+		description string
+		label       string
+		counts      func(InterfaceErrorStats) map[string]uint64
+	}{
+		{"can_bridge_error_frames", "Error frames by error class.", "class",
+			func(s InterfaceErrorStats) map[string]uint64 { return s.ByClass }},
+		{"can_bridge_protocol_errors", "Protocol errors by type.", "type",
+			func(s InterfaceErrorStats) map[string]uint64 { return s.ByProtocolError }},
+		{"can_bridge_protocol_error_locations", "Protocol errors by location in the frame.", "location",
+			func(s InterfaceErrorStats) map[string]uint64 { return s.ByLocation }},
+		{"can_bridge_controller_problems", "Controller problems by kind.", "problem",
+			func(s InterfaceErrorStats) map[string]uint64 { return s.Controller }},
+	}
+	for _, family := range errorFamilies {
+		var points []otlpNumberPoint
+		for _, name := range errorNames {
+			counts := family.counts(errorStats[name])
+			for _, key := range sortedCountKeys(counts) {
+				points = append(points, set.point(float64(counts[key]), stringAttr("interface", name), stringAttr(family.label, key)))
+			}
+		}
+		set.counter(family.name, family.description, points)
+	}
+
+	var bursts []otlpNumberPoint
+	for _, name := range errorNames {
+		burst := 0.0
+		if errorStats[name].Burst {
+			burst = 1
+		}
+		bursts = append(bursts, set.point(burst, stringAttr("interface", name)))
+	}
+	set.gauge("can_bridge_error_burst", "Whether the error frame rate is above the burst threshold.", bursts)
+
+	kernelStats := monitor.GetKernelStats()
+	kernelNames := make([]string, 0, len(kernelStats))
+	for name := range kernelStats {
+		kernelNames = append(kernelNames, name)
+	}
+	sort.Strings(kernelNames)
+	for _, counter := range kernelStatCounters {
+		var points []otlpNumberPoint
+		for _, name := range kernelNames {
+			if value, ok := kernelStats[name].Counters[counter]; ok {
+				points = append(points, set.point(float64(value), stringAttr("interface", name)))
+			}
+		}
+		set.counter("can_bridge_kernel_"+counter, "Kernel "+counter+" counter from sysfs.", points)
+	}
+
+	rates := monitor.GetInterfaceRates()
+	rateNames := make([]string, 0, len(rates))
+	for name := range rates {
+		rateNames = append(rateNames, name)
+	}
+	sort.Strings(rateNames)
+	for _, family := range interfaceRateFamilies {
+		var points []otlpNumberPoint
+		for _, name := range rateNames {
+			for _, rate := range family.rates(rates[name]).windows() {
+				points = append(points, set.point(rate.value, stringAttr("interface", name), stringAttr("window", rate.window)))
+			}
+		}
+		set.gauge(family.metric, family.help, points)
+	}
+
+	if httpMetrics != nil {
+		routes := httpMetrics.Snapshot()
+		var requests []otlpNumberPoint
+		var durations []otlpHistogramPoint
+		for _, route := range routes {
+			statuses := make([]int, 0, len(route.Statuses))
+			for status := range route.Statuses {
+				statuses = append(statuses, status)
+			}
+			sort.Ints(statuses)
+			for _, status := range statuses {
+				requests = append(requests, set.point(float64(route.Statuses[status]),
+					stringAttr("route", route.Route), stringAttr("method", route.Method), intAttr("status", int64(status))))
+			}
+			durations = append(durations, set.histogramPoint(route.Latency,
+				stringAttr("route", route.Route), stringAttr("method", route.Method)))
+		}
+		set.counter("can_bridge_http_requests", "API requests by route, method and status code.", requests)
+		set.histogram("can_bridge_http_request_duration_seconds", "API request handling time.", durations)
+	}
+
+	return set.metrics
+}
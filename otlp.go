@@ -0,0 +1,416 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OTLP span kinds and status codes
+const (
+	otlpSpanKindServer   = 2
+	otlpSpanKindProducer = 4
+
+	otlpStatusError = 2
+)
+
+// OTLPConfig holds OpenTelemetry export configuration, read from the standard
+// OTEL_EXPORTER_OTLP_* environment variables. Only the http/json protocol is supported.
+type OTLPConfig struct {
+	TracesEndpoint  string // Full URL spans are posted to (empty disables traces)
+	MetricsEndpoint string // Full URL metrics are posted to (empty disables metrics)
+	Protocol        string
+	Headers         map[string]string
+	Timeout         time.Duration // Per export request
+	MetricInterval  time.Duration
+	ServiceName     string
+	Instance        string
+	QueueSize       int           // Spans buffered for export; more are dropped
+	BatchSize       int           // Spans per export request
+	BatchDelay      time.Duration // Longest time a span waits for its batch
+}
+
+// DefaultOTLPConfig returns default OTLP export configuration (disabled)
+func DefaultOTLPConfig() OTLPConfig {
+	return OTLPConfig{
+		Protocol:       "http/json",
+		Timeout:        10 * time.Second,
+		MetricInterval: 60 * time.Second,
+		ServiceName:    "can-bridge",
+		QueueSize:      2048,
+		BatchSize:      512,
+		BatchDelay:     5 * time.Second,
+	}
+}
+
+// Enabled reports whether any OTLP signal is configured
+func (c OTLPConfig) Enabled() bool {
+	return c.TracesEndpoint != "" || c.MetricsEndpoint != ""
+}
+
+// spanContext identifies a span within a trace
+type spanContext struct {
+	traceID [16]byte
+	spanID  [8]byte
+}
+
+// newSpanContext starts a span, continuing the trace of parent when it is set
+func newSpanContext(parent spanContext) spanContext {
+	span := spanContext{traceID: parent.traceID}
+	if !parent.valid() {
+		binary.BigEndian.PutUint64(span.traceID[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(span.traceID[8:], rand.Uint64())
+	}
+	binary.BigEndian.PutUint64(span.spanID[:], rand.Uint64())
+	return span
+}
+
+// valid reports whether the context belongs to a trace
+func (sc spanContext) valid() bool {
+	return sc.traceID != [16]byte{}
+}
+
+// parseTraceparent reads a W3C traceparent header ("00-<trace id>-<parent id>-<flags>")
+func parseTraceparent(header string) (spanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) != 4 || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return spanContext{}, false
+	}
+
+	var sc spanContext
+	if _, err := hex.Decode(sc.traceID[:], []byte(parts[1])); err != nil {
+		return spanContext{}, false
+	}
+	if _, err := hex.Decode(sc.spanID[:], []byte(parts[2])); err != nil {
+		return spanContext{}, false
+	}
+	return sc, sc.valid()
+}
+
+// otlpKeyValue is an OTLP attribute
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// otlpAnyValue is an OTLP attribute value; exactly one field is set. 64-bit integers
+// are encoded as strings, as the OTLP JSON encoding requires.
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+func stringAttr(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+func intAttr(key string, value int64) otlpKeyValue {
+	encoded := strconv.FormatInt(value, 10)
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: &encoded}}
+}
+
+func boolAttr(key string, value bool) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{BoolValue: &value}}
+}
+
+// unixNano encodes a timestamp the way OTLP JSON expects
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// otlpStatus is the status of a span
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// otlpSpan is a finished span in OTLP JSON form
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+// newOTLPSpan builds a finished span. err, when not empty, marks the span as failed.
+func newOTLPSpan(sc, parent spanContext, name string, kind int, start, end time.Time, attributes []otlpKeyValue, err string) otlpSpan {
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(sc.traceID[:]),
+		SpanID:            hex.EncodeToString(sc.spanID[:]),
+		Name:              name,
+		Kind:              kind,
+		StartTimeUnixNano: unixNano(start),
+		EndTimeUnixNano:   unixNano(end),
+		Attributes:        attributes,
+	}
+	if parent.valid() {
+		span.ParentSpanID = hex.EncodeToString(parent.spanID[:])
+	}
+	if err != "" {
+		span.Status = otlpStatus{Code: otlpStatusError, Message: err}
+	}
+	return span
+}
+
+// OTLPExporterStats reports export counters
+type OTLPExporterStats struct {
+	ExportedSpans uint64 `json:"exportedSpans"`
+	DroppedSpans  uint64 `json:"droppedSpans"`
+	FailedExports uint64 `json:"failedExports"`
+}
+
+// OTLPExporter exports spans and metrics over OTLP/HTTP with JSON encoding. Recording a
+// span never blocks: when the queue is full the span is dropped and counted. A nil
+// OTLPExporter is valid and discards everything.
+type OTLPExporter struct {
+	config    OTLPConfig
+	spans     chan otlpSpan // nil when traces are disabled
+	collect   func(start, now time.Time) []otlpMetric
+	client    *http.Client
+	logger    Logger
+	startTime time.Time
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	exportedSpans atomic.Uint64
+	droppedSpans  atomic.Uint64
+	failedExports atomic.Uint64
+}
+
+// NewOTLPExporter creates an exporter, or returns nil when no OTLP endpoint is configured
+func NewOTLPExporter(config OTLPConfig, logger Logger) *OTLPExporter {
+	if !config.Enabled() {
+		return nil
+	}
+
+	exporter := &OTLPExporter{
+		config:    config,
+		client:    &http.Client{Timeout: config.Timeout},
+		logger:    logger,
+		startTime: time.Now(),
+		stopChan:  make(chan struct{}),
+	}
+	if config.TracesEndpoint != "" {
+		exporter.spans = make(chan otlpSpan, config.QueueSize)
+	}
+	return exporter
+}
+
+// TracesEnabled reports whether spans are exported
+func (e *OTLPExporter) TracesEnabled() bool {
+	return e != nil && e.spans != nil
+}
+
+// SetMetricsSource sets the function collecting the metrics exported every interval
+func (e *OTLPExporter) SetMetricsSource(collect func(start, now time.Time) []otlpMetric) {
+	if e == nil {
+		return
+	}
+	e.collect = collect
+}
+
+// Start starts the export worker
+func (e *OTLPExporter) Start() {
+	if e == nil {
+		return
+	}
+	e.startOnce.Do(func() {
+		e.logger.Printf("🔭 OTLP export enabled (traces: %s, metrics: %s)",
+			endpointOrDisabled(e.config.TracesEndpoint), endpointOrDisabled(e.config.MetricsEndpoint))
+		e.wg.Add(1)
+		go e.exportLoop()
+	})
+}
+
+// Stop stops the export worker after a final export of queued spans and current metrics
+func (e *OTLPExporter) Stop() {
+	if e == nil {
+		return
+	}
+	e.stopOnce.Do(func() {
+		close(e.stopChan)
+		e.wg.Wait()
+	})
+}
+
+// RecordSpan queues a finished span for export
+func (e *OTLPExporter) RecordSpan(span otlpSpan) {
+	if !e.TracesEnabled() {
+		return
+	}
+	select {
+	case e.spans <- span:
+	default:
+		e.droppedSpans.Add(1)
+	}
+}
+
+// GetStats returns export counters
+func (e *OTLPExporter) GetStats() OTLPExporterStats {
+	if e == nil {
+		return OTLPExporterStats{}
+	}
+	return OTLPExporterStats{
+		ExportedSpans: e.exportedSpans.Load(),
+		DroppedSpans:  e.droppedSpans.Load(),
+		FailedExports: e.failedExports.Load(),
+	}
+}
+
+// exportLoop batches spans and exports metrics periodically
+func (e *OTLPExporter) exportLoop() {
+	defer e.wg.Done()
+
+	batchTicker := time.NewTicker(e.config.BatchDelay)
+	defer batchTicker.Stop()
+
+	var metricTick <-chan time.Time
+	if e.config.MetricsEndpoint != "" {
+		metricTicker := time.NewTicker(e.config.MetricInterval)
+		defer metricTicker.Stop()
+		metricTick = metricTicker.C
+	}
+
+	batch := make([]otlpSpan, 0, e.config.BatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		e.exportSpans(batch)
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case <-e.stopChan:
+			for drained := false; !drained; {
+				select {
+				case span := <-e.spans:
+					batch = append(batch, span)
+				default:
+					drained = true
+				}
+			}
+			flush()
+			e.exportMetrics()
+			return
+		case span := <-e.spans:
+			batch = append(batch, span)
+			if len(batch) >= e.config.BatchSize {
+				flush()
+			}
+		case <-batchTicker.C:
+			flush()
+		case <-metricTick:
+			e.exportMetrics()
+		}
+	}
+}
+
+// resource returns the OTLP resource describing this service instance
+func (e *OTLPExporter) resource() map[string]interface{} {
+	attributes := []otlpKeyValue{stringAttr("service.name", e.config.ServiceName)}
+	if e.config.Instance != "" {
+		attributes = append(attributes, stringAttr("service.instance.id", e.config.Instance))
+	}
+	return map[string]interface{}{"attributes": attributes}
+}
+
+// exportSpans posts a batch of spans
+func (e *OTLPExporter) exportSpans(spans []otlpSpan) {
+	payload := map[string]interface{}{
+		"resourceSpans": []map[string]interface{}{{
+			"resource": e.resource(),
+			"scopeSpans": []map[string]interface{}{{
+				"scope": map[string]string{"name": e.config.ServiceName},
+				"spans": spans,
+			}},
+		}},
+	}
+
+	if err := e.post(e.config.TracesEndpoint, payload); err != nil {
+		e.failedExports.Add(1)
+		e.logger.Printf("⚠️ Warning: failed to export %d spans: %v", len(spans), err)
+		return
+	}
+	e.exportedSpans.Add(uint64(len(spans)))
+}
+
+// exportMetrics collects and posts the current metrics
+func (e *OTLPExporter) exportMetrics() {
+	if e.config.MetricsEndpoint == "" || e.collect == nil {
+		return
+	}
+
+	payload := map[string]interface{}{
+		"resourceMetrics": []map[string]interface{}{{
+			"resource": e.resource(),
+			"scopeMetrics": []map[string]interface{}{{
+				"scope":   map[string]string{"name": e.config.ServiceName},
+				"metrics": e.collect(e.startTime, time.Now()),
+			}},
+		}},
+	}
+
+	if err := e.post(e.config.MetricsEndpoint, payload); err != nil {
+		e.failedExports.Add(1)
+		e.logger.Printf("⚠️ Warning: failed to export metrics: %v", err)
+	}
+}
+
+// post sends a single export request
+func (e *OTLPExporter) post(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode export request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range e.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// endpointOrDisabled formats an endpoint for logging
+func endpointOrDisabled(endpoint string) string {
+	if endpoint == "" {
+		return "disabled"
+	}
+	return endpoint
+}
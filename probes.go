@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultLivenessTimeout is how long a background loop may go without a heartbeat
+// before /livez reports it stuck
+const DefaultLivenessTimeout = 30 * time.Second
+
+// ProbeResult is the outcome of a readiness or liveness check
+type ProbeResult struct {
+	OK      bool     `json:"ok"`
+	Reasons []string `json:"reasons,omitempty"` // Why the check failed
+}
+
+// Probes answers the readiness and liveness checks of orchestrators such as Kubernetes
+// and systemd. The checks only read heartbeats and interface presence, never the locks
+// of the send path, so they stay fast while interfaces misbehave.
+type Probes struct {
+	interfaceManager *InterfaceManager
+	configProvider   ConfigProvider
+	watchdog         *Watchdog // nil when the watchdog is disabled
+	messageListener  *CanMessageListener
+	requireAll       bool
+	livenessTimeout  time.Duration
+}
+
+// NewProbes creates the probes. The watchdog is nil when it is disabled; readiness then
+// does not require it. With requireAll every configured interface must be initialized
+// for readiness, otherwise one is enough.
+func NewProbes(interfaceManager *InterfaceManager, configProvider ConfigProvider, watchdog *Watchdog,
+	messageListener *CanMessageListener, requireAll bool, livenessTimeout time.Duration) *Probes {
+	if livenessTimeout <= 0 {
+		livenessTimeout = DefaultLivenessTimeout
+	}
+	return &Probes{
+		interfaceManager: interfaceManager,
+		configProvider:   configProvider,
+		watchdog:         watchdog,
+		messageListener:  messageListener,
+		requireAll:       requireAll,
+		livenessTimeout:  livenessTimeout,
+	}
+}
+
+// Readiness reports whether the service can carry traffic: enough interfaces are
+// initialized and the watchdog, when enabled, is running
+func (p *Probes) Readiness() ProbeResult {
+	var reasons, missing []string
+	ports := p.configProvider.GetCanPorts()
+	for _, ifName := range ports {
+		if !p.interfaceManager.IsInterfaceActive(ifName) {
+			missing = append(missing, ifName)
+		}
+	}
+	switch {
+	case len(ports) == 0:
+		reasons = append(reasons, "no CAN interfaces configured")
+	case len(missing) == len(ports):
+		reasons = append(reasons, fmt.Sprintf("no CAN interface initialized (configured: %v)", ports))
+	case p.requireAll && len(missing) > 0:
+		for _, ifName := range missing {
+			reasons = append(reasons, fmt.Sprintf("interface %s not initialized", ifName))
+		}
+	}
+
+	if p.watchdog != nil && p.watchdog.lastTick().IsZero() {
+		reasons = append(reasons, "watchdog not running")
+	}
+
+	return ProbeResult{OK: len(reasons) == 0, Reasons: reasons}
+}
+
+// Liveness reports whether the background loops still make progress: the watchdog
+// loop and every receive loop must have ticked within the liveness timeout. A loop that
+// is not running is not stuck; readiness covers that.
+func (p *Probes) Liveness() ProbeResult {
+	var reasons []string
+	now := time.Now()
+
+	if p.watchdog != nil {
+		if last := p.watchdog.lastTick(); !last.IsZero() {
+			// The loop ticks every check interval and may spend a while recovering an interface
+			if age := now.Sub(last); age > p.watchdog.tickEvery()+p.livenessTimeout {
+				reasons = append(reasons, fmt.Sprintf("watchdog loop stalled for %v", age.Round(time.Millisecond)))
+			}
+		}
+	}
+
+	heartbeats := p.messageListener.heartbeats()
+	ifNames := make([]string, 0, len(heartbeats))
+	for ifName := range heartbeats {
+		ifNames = append(ifNames, ifName)
+	}
+	sort.Strings(ifNames)
+	for _, ifName := range ifNames {
+		if age := now.Sub(heartbeats[ifName]); age > p.livenessTimeout {
+			reasons = append(reasons, fmt.Sprintf("receive loop of %s stalled for %v", ifName, age.Round(time.Millisecond)))
+		}
+	}
+
+	return ProbeResult{OK: len(reasons) == 0, Reasons: reasons}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// writePrometheusMetrics writes per-interface send metrics in the Prometheus text exposition format
+func writePrometheusMetrics(w io.Writer, stats map[string]InterfaceStats) {
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "# HELP can_bridge_send_latency_seconds Time from API request acceptance to successful write().")
+	fmt.Fprintln(w, "# TYPE can_bridge_send_latency_seconds histogram")
+	for _, name := range names {
+		writePrometheusHistogram(w, "can_bridge_send_latency_seconds", fmt.Sprintf("interface=%q", name), stats[name].SendLatency)
+	}
+
+	writePrometheusFamily(w, names, "can_bridge_frames_sent_total", "counter", "Frames written successfully.",
+		func(s InterfaceStats) float64 { return float64(s.TotalSent) }, stats)
+	writePrometheusFamily(w, names, "can_bridge_send_errors_total", "counter", "Failed frame writes.",
+		func(s InterfaceStats) float64 { return float64(s.TotalErrors) }, stats)
+	writePrometheusFamily(w, names, "can_bridge_tx_buffer_full_total", "counter", "Writes rejected with ENOBUFS or EAGAIN.",
+		func(s InterfaceStats) float64 { return float64(s.BufferFullErrors) }, stats)
+	writePrometheusFamily(w, names, "can_bridge_send_retries_total", "counter", "Writes retried after ENOBUFS or EAGAIN.",
+		func(s InterfaceStats) float64 { return float64(s.SendRetries) }, stats)
+	writePrometheusFamily(w, names, "can_bridge_send_retries_exhausted_total", "counter", "Frames given up when their retries ran out.",
+		func(s InterfaceStats) float64 { return float64(s.RetriesExhausted) }, stats)
+	writePrometheusFamily(w, names, "can_bridge_send_retry_seconds_total", "counter", "Time frames spent retrying writes.",
+		func(s InterfaceStats) float64 { return s.RetryTime.Seconds() }, stats)
+	writePrometheusFamily(w, names, "can_bridge_tx_paced_total", "counter", "Writes delayed for the minimum inter-frame gap.",
+		func(s InterfaceStats) float64 { return float64(s.PacedSends) }, stats)
+	writePrometheusFamily(w, names, "can_bridge_tx_pacing_delay_seconds_total", "counter", "Time writes waited for the minimum inter-frame gap.",
+		func(s InterfaceStats) float64 { return s.PacingDelay.Seconds() }, stats)
+	writePrometheusFamily(w, names, "can_bridge_tx_paced_rate", "gauge", "Frames per second achieved by the latest run of paced writes.",
+		func(s InterfaceStats) float64 { return s.PacedRate }, stats)
+	writePrometheusFamily(w, names, "can_bridge_tx_queue_depth", "gauge", "Sends currently waiting for the interface socket.",
+		func(s InterfaceStats) float64 { return float64(s.TxQueueDepth) }, stats)
+	writePrometheusFamily(w, names, "can_bridge_tx_queue_depth_max", "gauge", "Highest TX queue depth seen.",
+		func(s InterfaceStats) float64 { return float64(s.MaxTxQueueDepth) }, stats)
+
+	fmt.Fprintln(w, "# HELP can_bridge_tx_queue_band_depth Sends waiting for the interface socket per priority band.")
+	fmt.Fprintln(w, "# TYPE can_bridge_tx_queue_band_depth gauge")
+	for _, name := range names {
+		for _, band := range txPriorities {
+			fmt.Fprintf(w, "can_bridge_tx_queue_band_depth{interface=%q,band=%q} %d\n", name, band, stats[name].TxQueueBands[band].Depth)
+		}
+	}
+	fmt.Fprintln(w, "# HELP can_bridge_tx_queue_rejected_total Sends rejected because their priority band of the TX queue was full.")
+	fmt.Fprintln(w, "# TYPE can_bridge_tx_queue_rejected_total counter")
+	for _, name := range names {
+		for _, band := range txPriorities {
+			fmt.Fprintf(w, "can_bridge_tx_queue_rejected_total{interface=%q,band=%q} %d\n", name, band, stats[name].TxQueueBands[band].Rejected)
+		}
+	}
+}
+
+// writePrometheusHTTPMetrics writes API request counts, latencies and status codes per route and method
+func writePrometheusHTTPMetrics(w io.Writer, routes []HTTPRouteStats) {
+	fmt.Fprintln(w, "# HELP can_bridge_http_requests_total API requests by route, method and status code.")
+	fmt.Fprintln(w, "# TYPE can_bridge_http_requests_total counter")
+	for _, route := range routes {
+		statuses := make([]int, 0, len(route.Statuses))
+		for status := range route.Statuses {
+			statuses = append(statuses, status)
+		}
+		sort.Ints(statuses)
+		for _, status := range statuses {
+			fmt.Fprintf(w, "can_bridge_http_requests_total{route=%q,method=%q,status=\"%d\"} %d\n",
+				route.Route, route.Method, status, route.Statuses[status])
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP can_bridge_http_request_duration_seconds API request handling time.")
+	fmt.Fprintln(w, "# TYPE can_bridge_http_request_duration_seconds histogram")
+	for _, route := range routes {
+		writePrometheusHistogram(w, "can_bridge_http_request_duration_seconds",
+			fmt.Sprintf("route=%q,method=%q", route.Route, route.Method), route.Latency)
+	}
+}
+
+// writePrometheusIdempotencyMetrics writes the idempotency cache counters
+func writePrometheusIdempotencyMetrics(w io.Writer, stats IdempotencyStats) {
+	fmt.Fprintln(w, "# HELP can_bridge_idempotency_requests_total Requests with an Idempotency-Key by cache result.")
+	fmt.Fprintln(w, "# TYPE can_bridge_idempotency_requests_total counter")
+	fmt.Fprintf(w, "can_bridge_idempotency_requests_total{result=\"hit\"} %d\n", stats.Hits)
+	fmt.Fprintf(w, "can_bridge_idempotency_requests_total{result=\"miss\"} %d\n", stats.Misses)
+	fmt.Fprintf(w, "can_bridge_idempotency_requests_total{result=\"conflict\"} %d\n", stats.Conflicts)
+
+	fmt.Fprintln(w, "# HELP can_bridge_idempotency_evictions_total Cached responses dropped before expiry to bound the cache.")
+	fmt.Fprintln(w, "# TYPE can_bridge_idempotency_evictions_total counter")
+	fmt.Fprintf(w, "can_bridge_idempotency_evictions_total %d\n", stats.Evictions)
+
+	fmt.Fprintln(w, "# HELP can_bridge_idempotency_entries Responses currently cached for replay.")
+	fmt.Fprintln(w, "# TYPE can_bridge_idempotency_entries gauge")
+	fmt.Fprintf(w, "can_bridge_idempotency_entries %d\n", stats.Entries)
+}
+
+// writePrometheusLogSamplingMetrics writes the log lines held back by the sampler per
+// component, so elided information shows
+func writePrometheusLogSamplingMetrics(w io.Writer, stats LogSamplingStats) {
+	fmt.Fprintln(w, "# HELP can_bridge_log_suppressed_total Identical log lines held back by the rate limit and summarized.")
+	fmt.Fprintln(w, "# TYPE can_bridge_log_suppressed_total counter")
+	components := make([]string, 0, len(stats.Components))
+	for component := range stats.Components {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	for _, component := range components {
+		fmt.Fprintf(w, "can_bridge_log_suppressed_total{component=%q} %d\n", component, stats.Components[component])
+	}
+	fmt.Fprintln(w, "# HELP can_bridge_log_summaries_total Summary lines written for log lines held back.")
+	fmt.Fprintln(w, "# TYPE can_bridge_log_summaries_total counter")
+	fmt.Fprintf(w, "can_bridge_log_summaries_total %d\n", stats.Summaries)
+}
+
+// writePrometheusLogDiskMetrics writes the bytes taken by each kind of log file, with
+// their rotated and compressed files
+func writePrometheusLogDiskMetrics(w io.Writer, usage map[string]int64) {
+	if len(usage) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "# HELP can_bridge_log_disk_usage_bytes Bytes on disk of log files, rotated files included.")
+	fmt.Fprintln(w, "# TYPE can_bridge_log_disk_usage_bytes gauge")
+	names := make([]string, 0, len(usage))
+	for name := range usage {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "can_bridge_log_disk_usage_bytes{log=%q} %d\n", name, usage[name])
+	}
+}
+
+// writePrometheusHistogram writes the bucket, sum and count samples of one histogram series
+func writePrometheusHistogram(w io.Writer, metric, labels string, snapshot LatencyHistogramSnapshot) {
+	var cumulative uint64
+	for i, bound := range sendLatencyBounds {
+		cumulative += snapshot.Counts[i]
+		fmt.Fprintf(w, "%s_bucket{%s,le=%q} %d\n",
+			metric, labels, strconv.FormatFloat(bound.Seconds(), 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", metric, labels, snapshot.Count)
+	fmt.Fprintf(w, "%s_sum{%s} %g\n", metric, labels, snapshot.Sum.Seconds())
+	fmt.Fprintf(w, "%s_count{%s} %d\n", metric, labels, snapshot.Count)
+}
+
+// writePrometheusErrorMetrics writes error frame counters by class, protocol error type and location
+func writePrometheusErrorMetrics(w io.Writer, errorStats map[string]InterfaceErrorStats) {
+	names := make([]string, 0, len(errorStats))
+	for name := range errorStats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	families := []struct {
+		metric string
+		help   string
+		label  string
+		counts func(InterfaceErrorStats) map[string]uint64
+	}{
+		{"can_bridge_error_frames_total", "Error frames by error class.", "class",
+			func(s InterfaceErrorStats) map[string]uint64 { return s.ByClass }},
+		{"can_bridge_protocol_errors_total", "Protocol errors by type.", "type",
+			func(s InterfaceErrorStats) map[string]uint64 { return s.ByProtocolError }},
+		{"can_bridge_protocol_error_locations_total", "Protocol errors by location in the frame.", "location",
+			func(s InterfaceErrorStats) map[string]uint64 { return s.ByLocation }},
+		{"can_bridge_controller_problems_total", "Controller problems by kind.", "problem",
+			func(s InterfaceErrorStats) map[string]uint64 { return s.Controller }},
+	}
+
+	for _, family := range families {
+		fmt.Fprintf(w, "# HELP %s %s\n", family.metric, family.help)
+		fmt.Fprintf(w, "# TYPE %s counter\n", family.metric)
+		for _, name := range names {
+			counts := family.counts(errorStats[name])
+			for _, key := range sortedCountKeys(counts) {
+				fmt.Fprintf(w, "%s{interface=%q,%s=%q} %d\n", family.metric, name, family.label, key, counts[key])
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "# HELP can_bridge_error_burst Whether the error frame rate is above the burst threshold.")
+	fmt.Fprintln(w, "# TYPE can_bridge_error_burst gauge")
+	for _, name := range names {
+		burst := 0
+		if errorStats[name].Burst {
+			burst = 1
+		}
+		fmt.Fprintf(w, "can_bridge_error_burst{interface=%q} %d\n", name, burst)
+	}
+}
+
+// writePrometheusFamily writes a single-value metric family with one sample per interface
+func writePrometheusFamily(w io.Writer, names []string, metric, metricType, help string,
+	value func(InterfaceStats) float64, stats map[string]InterfaceStats) {
+	fmt.Fprintf(w, "# HELP %s %s\n", metric, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", metric, metricType)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s{interface=%q} %g\n", metric, name, value(stats[name]))
+	}
+}
+
+// interfaceRateFamilies are the rolling rate gauges exported per interface and window
+var interfaceRateFamilies = []struct {
+	metric string
+	help   string
+	rates  func(InterfaceRates) RollingRates
+}{
+	{"can_bridge_rx_frame_rate", "Received frames per second over the window.",
+		func(r InterfaceRates) RollingRates { return r.RxFrames }},
+	{"can_bridge_rx_bit_rate", "Received bits per second over the window, stuff bits excluded.",
+		func(r InterfaceRates) RollingRates { return r.RxBits }},
+	{"can_bridge_bus_load_percent", "Percent of the bitrate used by received frames over the window.",
+		func(r InterfaceRates) RollingRates { return r.BusLoad }},
+	{"can_bridge_error_frame_rate", "Error frames per second over the window.",
+		func(r InterfaceRates) RollingRates { return r.ErrorFrames }},
+}
+
+// writePrometheusRateMetrics writes rolling receive rates with a window label
+func writePrometheusRateMetrics(w io.Writer, rates map[string]InterfaceRates) {
+	names := make([]string, 0, len(rates))
+	for name := range rates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, family := range interfaceRateFamilies {
+		fmt.Fprintf(w, "# HELP %s %s\n", family.metric, family.help)
+		fmt.Fprintf(w, "# TYPE %s gauge\n", family.metric)
+		for _, name := range names {
+			for _, rate := range family.rates(rates[name]).windows() {
+				fmt.Fprintf(w, "%s{interface=%q,window=%q} %g\n", family.metric, name, rate.window, rate.value)
+			}
+		}
+	}
+}
+
+// writePrometheusSocketDropMetrics writes the frames the kernel dropped on each
+// listening socket
+func writePrometheusSocketDropMetrics(w io.Writer, stats map[string]MessageBufferStats) {
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "# HELP can_bridge_socket_drops_total Frames the kernel dropped on the listening socket because its receive buffer was full.")
+	fmt.Fprintln(w, "# TYPE can_bridge_socket_drops_total counter")
+	for _, name := range names {
+		fmt.Fprintf(w, "can_bridge_socket_drops_total{interface=%q} %d\n", name, stats[name].SocketDrops)
+	}
+}
+
+// writePrometheusKernelMetrics writes the kernel's per-device counters
+func writePrometheusKernelMetrics(w io.Writer, kernelStats map[string]KernelInterfaceStats) {
+	names := make([]string, 0, len(kernelStats))
+	for name := range kernelStats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, counter := range kernelStatCounters {
+		metric := "can_bridge_kernel_" + counter + "_total"
+		fmt.Fprintf(w, "# HELP %s Kernel %s counter from sysfs.\n", metric, counter)
+		fmt.Fprintf(w, "# TYPE %s counter\n", metric)
+		for _, name := range names {
+			if value, ok := kernelStats[name].Counters[counter]; ok {
+				fmt.Fprintf(w, "%s{interface=%q} %d\n", metric, name, value)
+			}
+		}
+	}
+}
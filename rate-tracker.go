@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaxRateWindow is the longest window a RateTracker can report
+const MaxRateWindow = 60 * time.Second
+
+// rateTrackerBuckets holds one bucket per second of the longest window plus the second
+// currently being filled
+const rateTrackerBuckets = int(MaxRateWindow/time.Second) + 1
+
+// RateWindows are the windows reported in rolling rates, shortest first
+var RateWindows = []time.Duration{time.Second, 10 * time.Second, MaxRateWindow}
+
+// RollingRates are per-second rates averaged over the complete seconds of each window
+type RollingRates struct {
+	Last1s  float64 `json:"1s"`
+	Last10s float64 `json:"10s"`
+	Last60s float64 `json:"60s"`
+}
+
+// windowRate is one window of RollingRates with its label (e.g. "10s")
+type windowRate struct {
+	window string
+	value  float64
+}
+
+// windows returns the rates with their window labels, shortest first
+func (r RollingRates) windows() []windowRate {
+	return []windowRate{{"1s", r.Last1s}, {"10s", r.Last10s}, {"60s", r.Last60s}}
+}
+
+// scaled returns the rates multiplied by factor
+func (r RollingRates) scaled(factor float64) RollingRates {
+	return RollingRates{Last1s: r.Last1s * factor, Last10s: r.Last10s * factor, Last60s: r.Last60s * factor}
+}
+
+// plus returns the sum of two rates per window
+func (r RollingRates) plus(other RollingRates) RollingRates {
+	return RollingRates{Last1s: r.Last1s + other.Last1s, Last10s: r.Last10s + other.Last10s, Last60s: r.Last60s + other.Last60s}
+}
+
+// rateBucket counts the events of one Unix second
+type rateBucket struct {
+	second int64
+	count  uint64
+}
+
+// RateTracker counts events in a fixed ring of one-second buckets, so memory stays
+// constant regardless of traffic. It is not safe for concurrent use; owners guard it
+// with their own mutex.
+type RateTracker struct {
+	buckets [rateTrackerBuckets]rateBucket
+}
+
+// Add counts n events at timestamp, discarding data from an older lap of the ring
+func (r *RateTracker) Add(timestamp time.Time, n uint64) {
+	second := timestamp.Unix()
+	bucket := &r.buckets[r.index(second)]
+	if bucket.second != second {
+		*bucket = rateBucket{second: second}
+	}
+	bucket.count += n
+}
+
+// Count returns the events counted during one Unix second, or 0 once it left the ring
+func (r *RateTracker) Count(second int64) uint64 {
+	bucket := &r.buckets[r.index(second)]
+	if bucket.second != second {
+		return 0
+	}
+	return bucket.count
+}
+
+// Sum returns the events counted from the oldest to the newest Unix second, inclusive
+func (r *RateTracker) Sum(oldest, newest int64) uint64 {
+	var total uint64
+	for i := range r.buckets {
+		bucket := &r.buckets[i]
+		if bucket.second >= oldest && bucket.second <= newest {
+			total += bucket.count
+		}
+	}
+	return total
+}
+
+// Rate returns the events per second over the complete seconds of window before now.
+// The window is rounded up to whole seconds and must not exceed MaxRateWindow.
+func (r *RateTracker) Rate(now time.Time, window time.Duration) float64 {
+	seconds := int64((window + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	newest := now.Unix() - 1
+	return float64(r.Sum(newest-seconds+1, newest)) / float64(seconds)
+}
+
+// Rates returns the rates over every window in RateWindows
+func (r *RateTracker) Rates(now time.Time) RollingRates {
+	return RollingRates{
+		Last1s:  r.Rate(now, RateWindows[0]),
+		Last10s: r.Rate(now, RateWindows[1]),
+		Last60s: r.Rate(now, RateWindows[2]),
+	}
+}
+
+// index maps a Unix second to its bucket; negative seconds only occur with bogus clocks
+func (r *RateTracker) index(second int64) int {
+	index := second % int64(rateTrackerBuckets)
+	if index < 0 {
+		index += int64(rateTrackerBuckets)
+	}
+	return int(index)
+}
+
+// parseRateWindow parses a window label such as "10s", accepting only RateWindows
+func parseRateWindow(value string) (time.Duration, error) {
+	window, err := time.ParseDuration(value)
+	if err == nil {
+		for _, known := range RateWindows {
+			if window == known {
+				return window, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid rate window %q. Valid options: 1s, 10s, 60s", value)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// checkRates compares rolling rates with a small tolerance for the division
+func checkRates(t *testing.T, step string, got, want RollingRates) {
+	t.Helper()
+	for i, rate := range got.windows() {
+		if wantRate := want.windows()[i].value; math.Abs(rate.value-wantRate) > 1e-9 {
+			t.Errorf("%s: %s rate %v, want %v", step, rate.window, rate.value, wantRate)
+		}
+	}
+}
+
+func TestRateTrackerWindows(t *testing.T) {
+	clock := &steppedClock{now: time.Date(2024, 1, 1, 0, 0, 0, 300*int(time.Millisecond), time.UTC)}
+	var tracker RateTracker
+
+	// The second being filled is not reported until it is complete
+	tracker.Add(clock.Now(), 5)
+	checkRates(t, "second being filled", tracker.Rates(clock.Now()), RollingRates{})
+	clock.advance(time.Second)
+	checkRates(t, "one second", tracker.Rates(clock.Now()), RollingRates{Last1s: 5, Last10s: 0.5, Last60s: 5.0 / 60})
+
+	// Steady traffic fills every window
+	for i := 0; i < 70; i++ {
+		tracker.Add(clock.Now(), 10)
+		clock.advance(time.Second)
+	}
+	checkRates(t, "steady", tracker.Rates(clock.Now()), RollingRates{Last1s: 10, Last10s: 10, Last60s: 10})
+
+	// Once the traffic stops, the windows empty one second at a time
+	clock.advance(4 * time.Second)
+	checkRates(t, "4s silent", tracker.Rates(clock.Now()), RollingRates{Last1s: 0, Last10s: 6, Last60s: 56.0 * 10 / 60})
+	clock.advance(10 * time.Second)
+	checkRates(t, "14s silent", tracker.Rates(clock.Now()), RollingRates{Last1s: 0, Last10s: 0, Last60s: 46.0 * 10 / 60})
+	clock.advance(46 * time.Second)
+	checkRates(t, "60s silent", tracker.Rates(clock.Now()), RollingRates{})
+}
+
+func TestRateTrackerRingRollover(t *testing.T) {
+	start := time.Unix(1_700_000_000, 0)
+	var tracker RateTracker
+
+	tracker.Add(start, 3)
+	tracker.Add(start.Add(500*time.Millisecond), 4)
+	if got := tracker.Count(start.Unix()); got != 7 {
+		t.Fatalf("Count() = %d, want 7", got)
+	}
+
+	// A lap later the same bucket holds the new second only
+	lap := start.Add(time.Duration(rateTrackerBuckets) * time.Second)
+	tracker.Add(lap, 2)
+	if got := tracker.Count(start.Unix()); got != 0 {
+		t.Errorf("Count() of the overwritten second = %d, want 0", got)
+	}
+	if got := tracker.Count(lap.Unix()); got != 2 {
+		t.Errorf("Count() = %d, want 2", got)
+	}
+	if got := tracker.Sum(start.Unix(), lap.Unix()); got != 2 {
+		t.Errorf("Sum() = %d, want 2", got)
+	}
+
+	// Data an older lap left in a bucket no later second wrote to is not counted either
+	var stale RateTracker
+	stale.Add(start, 100)
+	if got := stale.Rate(lap.Add(time.Second), MaxRateWindow); got != 0 {
+		t.Errorf("Rate() = %v, want 0", got)
+	}
+}
+
+func TestRateTrackerWindowRounding(t *testing.T) {
+	now := time.Unix(1_700_000_100, 0)
+	var tracker RateTracker
+	for i := 1; i <= 3; i++ {
+		tracker.Add(now.Add(-time.Duration(i)*time.Second), 6)
+	}
+
+	tests := []struct {
+		window time.Duration
+		want   float64
+	}{
+		{0, 6},                       // At least one second
+		{time.Second, 6},             // The last complete second
+		{1500 * time.Millisecond, 6}, // Rounded up to 2s: 12 events
+		{3 * time.Second, 6},
+		{6 * time.Second, 3},
+		{MaxRateWindow, 0.3},
+	}
+	for _, tt := range tests {
+		if got := tracker.Rate(now, tt.window); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Rate(%v) = %v, want %v", tt.window, got, tt.want)
+		}
+	}
+}
+
+func TestRateTrackerBogusClock(t *testing.T) {
+	var tracker RateTracker
+	before := time.Unix(-5, 0)
+	tracker.Add(before, 4)
+	if got := tracker.Count(before.Unix()); got != 4 {
+		t.Errorf("Count() = %d, want 4", got)
+	}
+	if got := tracker.Rate(before.Add(time.Second), time.Second); got != 4 {
+		t.Errorf("Rate() = %v, want 4", got)
+	}
+}
+
+func TestParseRateWindow(t *testing.T) {
+	for _, value := range []string{"1s", "10s", "60s", "1m"} {
+		if _, err := parseRateWindow(value); err != nil {
+			t.Errorf("parseRateWindow(%q): %v", value, err)
+		}
+	}
+	for _, value := range []string{"", "5s", "2m", "10"} {
+		if _, err := parseRateWindow(value); err == nil {
+			t.Errorf("parseRateWindow(%q) accepted", value)
+		}
+	}
+}
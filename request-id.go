@@ -0,0 +1,68 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestIDHeader carries the request ID in requests and responses
+const requestIDHeader = "X-Request-ID"
+
+// requestIDKey is the gin context key holding the request ID
+const requestIDKey = "requestID"
+
+// maxRequestIDLength bounds the length of a request ID accepted from a client
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware assigns every request an ID: the caller's X-Request-ID when it is
+// usable, else a new UUID. The ID is echoed in the response header and carried into the
+// access log, error responses, sent frames and the watchdog events the request causes.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// validRequestID accepts IDs of printable ASCII without spaces, so a client cannot
+// inject line breaks or forge fields in the logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random (version 4) UUID
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0F | 0x40
+	b[8] = b[8]&0x3F | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// requestID returns the ID of a request, or "" outside RequestIDMiddleware
+func requestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// requestIDSuffix formats a request ID for the end of a log line, "" when there is none
+func requestIDSuffix(id string) string {
+	if id == "" {
+		return ""
+	}
+	return " [request " + id + "]"
+}
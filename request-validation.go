@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError is one invalid field of a request body
+type FieldError struct {
+	Field   string `json:"field,omitempty"` // JSON name, e.g. "interfaces[1]"; empty for the body as a whole
+	Message string `json:"message"`
+
+	invalidID bool // The field is a CAN ID given out of range
+}
+
+// requestValidator is a request body with rules its binding tags cannot express, such
+// as rules between fields
+type requestValidator interface {
+	validateRequest() []FieldError
+}
+
+// jsonFieldNames makes the binding validator report fields by their JSON names
+var jsonFieldNames sync.Once
+
+// bindRequest decodes a JSON body and checks it against its binding tags and its own
+// rules. An invalid body is answered with 400 listing every field error at once, so
+// clients can fix a request in one round trip.
+func (h *APIHandler) bindRequest(c *gin.Context, req interface{}, message string) bool {
+	jsonFieldNames.Do(func() {
+		if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			engine.RegisterTagNameFunc(jsonFieldName)
+		}
+	})
+
+	var fields []FieldError
+	err := c.ShouldBindBodyWith(req, binding.JSON)
+	if maxBytes, ok := bodyTooLarge(err); ok {
+		h.respondError(c, http.StatusRequestEntityTooLarge, bodyTooLargeMessage(maxBytes), nil)
+		return false
+	}
+	var validationErrors validator.ValidationErrors
+	switch {
+	case err == nil:
+	case errors.As(err, &validationErrors):
+		for _, fieldErr := range validationErrors {
+			fields = append(fields, FieldError{
+				Field:     fieldErr.Field(),
+				Message:   validationMessage(fieldErr),
+				invalidID: fieldErr.StructField() == "ID" && fieldErr.Tag() != "required",
+			})
+		}
+	default:
+		// The body could not be decoded, so its fields cannot be checked
+		fields = append(fields, decodeFieldError(err))
+	}
+
+	if bd, ok := req.(bodyDecoder); ok && (err == nil || len(validationErrors) > 0) {
+		if body, ok := c.Get(gin.BodyBytesKey); ok {
+			fields = append(fields, bd.decodeBody(body.([]byte))...)
+		}
+	}
+
+	// Rules between fields need a decoded body; failed tags still leave one
+	if rv, ok := req.(requestValidator); ok && (err == nil || len(validationErrors) > 0) {
+		fields = append(fields, rv.validateRequest()...)
+	}
+	if len(fields) == 0 {
+		return true
+	}
+
+	// A body whose only fault is its CAN ID gets the specific code
+	code := CodeInvalidID
+	for _, field := range fields {
+		if !field.invalidID {
+			code = CodeInvalidRequest
+		}
+	}
+
+	summaries := make([]string, len(fields))
+	for i, field := range fields {
+		summaries[i] = field.Message
+		if field.Field != "" {
+			summaries[i] = field.Field + " " + field.Message
+		}
+	}
+	h.logger.Infof("API Error: %s - %s%s", message, strings.Join(summaries, "; "), requestIDSuffix(requestID(c)))
+	h.render(c, http.StatusBadRequest, newErrorResponse(c, code,
+		message+": "+strings.Join(summaries, "; "), map[string]interface{}{"fields": fields}))
+	return false
+}
+
+// jsonFieldName names a struct field as it appears in JSON
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// validationMessage describes a failed binding rule
+func validationMessage(fieldErr validator.FieldError) string {
+	// Sizes of strings are in characters, of slices and maps in elements
+	unit := ""
+	switch fieldErr.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Map:
+		unit = " elements"
+	}
+	if fieldErr.Param() == "1" {
+		unit = strings.TrimSuffix(unit, "s")
+	}
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "max":
+		if unit != "" {
+			return fmt.Sprintf("must have at most %s%s", fieldErr.Param(), unit)
+		}
+		return fmt.Sprintf("must be at most %s", fieldErr.Param())
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fieldErr.Param()), ", ")
+	case "min":
+		if unit != "" {
+			return fmt.Sprintf("must have at least %s%s", fieldErr.Param(), unit)
+		}
+		return fmt.Sprintf("must be at least %s", fieldErr.Param())
+	default:
+		return fmt.Sprintf("fails the %s rule", fieldErr.Tag())
+	}
+}
+
+// decodeFieldError describes a body that is not valid JSON for the request type
+func decodeFieldError(err error) FieldError {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	var dataErr *frameDataError
+	switch {
+	case errors.As(err, &dataErr):
+		return frameDataFieldError(dataErr)
+	case errors.Is(err, io.EOF):
+		return FieldError{Message: "request body is required"}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return FieldError{Message: "invalid JSON: body ends early"}
+	case errors.As(err, &typeErr):
+		return FieldError{
+			Field:     typeErr.Field,
+			Message:   fmt.Sprintf("must be a %s, got %s", typeErr.Type, typeErr.Value),
+			invalidID: typeErr.Field == "id",
+		}
+	case errors.As(err, &syntaxErr):
+		return FieldError{Message: fmt.Sprintf("invalid JSON at offset %d: %v", syntaxErr.Offset, err)}
+	default:
+		return FieldError{Message: err.Error()}
+	}
+}
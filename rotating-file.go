@@ -0,0 +1,271 @@
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Log file rotation defaults
+const (
+	DefaultLogMaxSize  = 10 << 20 // 10 MiB
+	DefaultLogMaxFiles = 5
+
+	rotatedTimeFormat = "2006-01-02T15-04-05.000" // Suffix of rotated files; sorts by time
+	gzipSuffix        = ".gz"
+)
+
+// RotationPolicy decides when a file is rotated and how many rotated files are kept
+type RotationPolicy struct {
+	MaxSize  int64         // Rotate before the file grows past this size; 0 disables
+	MaxAge   time.Duration // Rotate once the file has been written for this long; 0 disables
+	MaxFiles int           // Rotated files kept, the oldest are deleted; 0 keeps all
+	Compress bool          // gzip rotated files
+}
+
+// due reports whether a file of size, opened at openedAt, must be rotated before n more
+// bytes are written
+func (p RotationPolicy) due(size int64, openedAt time.Time, n int) bool {
+	if size == 0 {
+		return false
+	}
+	return (p.MaxSize > 0 && size+int64(n) > p.MaxSize) || (p.MaxAge > 0 && time.Since(openedAt) >= p.MaxAge)
+}
+
+// RotatingFileStats reports the state of a rotating file
+type RotatingFileStats struct {
+	Path        string `json:"path"`
+	DiskUsage   int64  `json:"diskUsage"` // Bytes of the file and its rotated files
+	Rotations   uint64 `json:"rotations"`
+	WriteErrors uint64 `json:"writeErrors"`
+}
+
+// RotatingFile is an append-only file that rotates itself by size and age. A rotated
+// file is renamed to path.<time>, compressed in the background when the policy says so,
+// and the oldest rotated files beyond MaxFiles are deleted. Writes are safe from several
+// goroutines; each is written whole to one file.
+type RotatingFile struct {
+	path   string
+	policy RotationPolicy
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	retention sync.Mutex     // Serializes compressing and pruning rotated files
+	pending   sync.WaitGroup // Rotated files still being compressed
+
+	rotations   atomic.Uint64
+	writeErrors atomic.Uint64
+}
+
+// OpenRotatingFile opens path for appending, creating it and its directory if needed
+func OpenRotatingFile(path string, policy RotationPolicy) (*RotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	f := &RotatingFile{path: path, policy: policy}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	var size int64
+	if info, err := file.Stat(); err == nil {
+		size = info.Size()
+	}
+	f.file, f.size, f.openedAt = file, size, time.Now()
+	return nil
+}
+
+// Write appends p, rotating the file first when the policy says so. When rotating fails
+// the write goes to the current file, so no line is lost for it.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		f.writeErrors.Add(1)
+		return 0, os.ErrClosed
+	}
+	if f.policy.due(f.size, f.openedAt, len(p)) {
+		if err := f.rotateLocked(); err != nil {
+			f.writeErrors.Add(1)
+			fmt.Fprintf(os.Stderr, "⚠️ Warning: failed to rotate %s: %v\n", f.path, err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err != nil {
+		f.writeErrors.Add(1)
+	}
+	return n, err
+}
+
+// rotateLocked renames the current file and opens a new one. Compressing and pruning
+// the rotated files happen in the background.
+func (f *RotatingFile) rotateLocked() error {
+	rotated := unusedFileName(time.Now(), func(at time.Time) string {
+		return f.path + "." + at.Format(rotatedTimeFormat)
+	})
+	if err := os.Rename(f.path, rotated); err != nil {
+		return err
+	}
+	previous := f.file
+	if err := f.open(); err != nil {
+		// Keep writing to the renamed file rather than losing lines
+		f.openedAt = time.Now()
+		return err
+	}
+	previous.Close()
+	f.rotations.Add(1)
+
+	f.pending.Add(1)
+	go func() {
+		defer f.pending.Done()
+		f.retention.Lock()
+		defer f.retention.Unlock()
+		if f.policy.Compress {
+			if err := compressFile(rotated); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "⚠️ Warning: failed to compress %s: %v\n", rotated, err)
+			}
+		}
+		f.prune()
+	}()
+	return nil
+}
+
+// rotatedFiles returns the rotated files of path, oldest first
+func (f *RotatingFile) rotatedFiles() []string {
+	matches, _ := filepath.Glob(f.path + ".*")
+	var rotated []string
+	for _, match := range matches {
+		suffix := strings.TrimSuffix(strings.TrimPrefix(match, f.path+"."), gzipSuffix)
+		if _, err := time.Parse(rotatedTimeFormat, suffix); err == nil {
+			rotated = append(rotated, match)
+		}
+	}
+	sort.Strings(rotated)
+	return rotated
+}
+
+// prune deletes the oldest rotated files beyond MaxFiles
+func (f *RotatingFile) prune() {
+	if f.policy.MaxFiles <= 0 {
+		return
+	}
+	rotated := f.rotatedFiles()
+	for len(rotated) > f.policy.MaxFiles {
+		if err := os.Remove(rotated[0]); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "⚠️ Warning: failed to delete old log file %s: %v\n", rotated[0], err)
+		}
+		rotated = rotated[1:]
+	}
+}
+
+// DiskUsage returns the bytes taken by the file and its rotated files
+func (f *RotatingFile) DiskUsage() int64 {
+	if f == nil {
+		return 0
+	}
+	var total int64
+	for _, path := range append(f.rotatedFiles(), f.path) {
+		if info, err := os.Stat(path); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// GetStats returns the path, disk usage and counters
+func (f *RotatingFile) GetStats() RotatingFileStats {
+	if f == nil {
+		return RotatingFileStats{}
+	}
+	return RotatingFileStats{
+		Path:        f.path,
+		DiskUsage:   f.DiskUsage(),
+		Rotations:   f.rotations.Load(),
+		WriteErrors: f.writeErrors.Load(),
+	}
+}
+
+// Close waits for rotated files being compressed and closes the file
+func (f *RotatingFile) Close() error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	file := f.file
+	f.file = nil
+	f.mu.Unlock()
+
+	f.pending.Wait()
+	if file == nil {
+		return nil
+	}
+	return file.Close()
+}
+
+// unusedFileName returns the name for at, moved on by a millisecond at a time while the
+// name, or its compressed form, is taken by an earlier file
+func unusedFileName(at time.Time, name func(time.Time) string) string {
+	for {
+		path := name(at)
+		_, err := os.Stat(path)
+		_, gzErr := os.Stat(path + gzipSuffix)
+		if os.IsNotExist(err) && os.IsNotExist(gzErr) {
+			return path
+		}
+		at = at.Add(time.Millisecond)
+	}
+}
+
+// compressFile replaces path with path.gz. The original is removed only once the
+// compressed copy is complete; the copy keeps its modification time, so files still sort
+// by age.
+func compressFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+
+	target, err := os.OpenFile(path+gzipSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(target)
+	_, err = io.Copy(zw, source)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := target.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + gzipSuffix)
+		return err
+	}
+	os.Chtimes(path+gzipSuffix, info.ModTime(), info.ModTime())
+	return os.Remove(path)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Sources of receive timestamps, from most to least precise
+const (
+	timestampSourceHardware = "hardware" // Taken by the CAN controller
+	timestampSourceKernel   = "kernel"   // Taken by the kernel when the frame was queued
+	timestampSourceSoftware = "software" // Taken in userspace after read returned
+)
+
+// rxTimestampOOBSize is large enough for either an SCM_TIMESTAMPING or SCM_TIMESTAMP message
+var rxTimestampOOBSize = unix.CmsgSpace(3*int(unsafe.Sizeof(unix.Timespec{}))) +
+	unix.CmsgSpace(int(unsafe.Sizeof(unix.Timeval{})))
+
+// enableRxTimestamping enables the most precise receive timestamping the socket supports
+// and returns the best source that may be delivered. Drivers without hardware support
+// still deliver kernel timestamps through SO_TIMESTAMPING, so the actual source is
+// determined per frame by parseRxTimestamp.
+func enableRxTimestamping(fd int) string {
+	flags := unix.SOF_TIMESTAMPING_RX_HARDWARE |
+		unix.SOF_TIMESTAMPING_RAW_HARDWARE |
+		unix.SOF_TIMESTAMPING_RX_SOFTWARE |
+		unix.SOF_TIMESTAMPING_SOFTWARE
+	if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_TIMESTAMPING, flags); err == nil {
+		return timestampSourceHardware
+	}
+
+	if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_TIMESTAMP, 1); err == nil {
+		return timestampSourceKernel
+	}
+
+	return timestampSourceSoftware
+}
+
+// parseRxTimestamp extracts the best available timestamp from a received message's
+// control data, falling back to the current time when none is present
+func parseRxTimestamp(oob []byte) (time.Time, string) {
+	messages, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return time.Now(), timestampSourceSoftware
+	}
+
+	for _, msg := range messages {
+		if msg.Header.Level != unix.SOL_SOCKET {
+			continue
+		}
+
+		switch msg.Header.Type {
+		case unix.SCM_TIMESTAMPING:
+			// Three timespecs: software, legacy (unused), raw hardware
+			if len(msg.Data) < 3*int(unsafe.Sizeof(unix.Timespec{})) {
+				continue
+			}
+			ts := (*[3]unix.Timespec)(unsafe.Pointer(&msg.Data[0]))
+			if ts[2].Sec != 0 || ts[2].Nsec != 0 {
+				return time.Unix(ts[2].Unix()), timestampSourceHardware
+			}
+			if ts[0].Sec != 0 || ts[0].Nsec != 0 {
+				return time.Unix(ts[0].Unix()), timestampSourceKernel
+			}
+		case unix.SCM_TIMESTAMP:
+			if len(msg.Data) < int(unsafe.Sizeof(unix.Timeval{})) {
+				continue
+			}
+			tv := (*unix.Timeval)(unsafe.Pointer(&msg.Data[0]))
+			return time.Unix(tv.Unix()), timestampSourceKernel
+		}
+	}
+
+	return time.Now(), timestampSourceSoftware
+}
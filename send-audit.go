@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// sendAuditQueueSize bounds the records waiting to be written to the audit file
+const sendAuditQueueSize = 4096
+
+// SendAuditRecord is one frame written to the bus
+type SendAuditRecord struct {
+	Timestamp  time.Time `json:"timestamp"`            // When write() returned
+	Client     string    `json:"client,omitempty"`     // API key principal or client certificate identity
+	RemoteAddr string    `json:"remoteAddr,omitempty"` // Client address of the API request
+	Interface  string    `json:"interface"`
+	ID         string    `json:"id"`             // Hex, e.g. "0x123"
+	Data       string    `json:"data,omitempty"` // Hex bytes
+	RTR        bool      `json:"rtr,omitempty"`
+	Confirmed  bool      `json:"confirmed"`           // Transmit confirmation received
+	RequestID  string    `json:"requestId,omitempty"` // ID of the API request
+}
+
+// ConfigAuditRecord is a configuration change applied by a reload, recorded in the same
+// file so the audit trail of a gateway is one place
+type ConfigAuditRecord struct {
+	Timestamp  time.Time      `json:"timestamp"`
+	Event      string         `json:"event"`                // Always "configChange"; frame records have none
+	Client     string         `json:"client,omitempty"`     // API key principal, client certificate identity or signal:SIGHUP
+	RemoteAddr string         `json:"remoteAddr,omitempty"` // Client address of the API request
+	RequestID  string         `json:"requestId,omitempty"`
+	Persisted  bool           `json:"persisted,omitempty"` // The configuration file was rewritten
+	Changes    []ConfigChange `json:"changes"`
+}
+
+// SendAuditStats reports the state of the send audit log
+type SendAuditStats struct {
+	Enabled     bool   `json:"enabled"`
+	Path        string `json:"path,omitempty"`
+	Recorded    uint64 `json:"recorded"`
+	Written     uint64 `json:"written"`
+	Dropped     uint64 `json:"dropped"` // Records lost because the queue was full
+	WriteErrors uint64 `json:"writeErrors"`
+	QueueDepth  int    `json:"queueDepth"`
+}
+
+// SendAuditLog appends a JSON line for every frame sent. Records are queued and written
+// by a background worker, so a slow disk never delays a send; when the queue is full the
+// record is dropped and counted. A nil SendAuditLog is valid and records nothing.
+type SendAuditLog struct {
+	path     string
+	file     *os.File
+	writer   *bufio.Writer
+	queue    chan interface{} // SendAuditRecord or ConfigAuditRecord
+	logger   Logger
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+
+	recorded    atomic.Uint64
+	written     atomic.Uint64
+	dropped     atomic.Uint64
+	writeErrors atomic.Uint64
+}
+
+// NewSendAuditLog opens the audit file for appending and starts the writer
+func NewSendAuditLog(path string, logger Logger) (*SendAuditLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open send audit log: %w", err)
+	}
+
+	al := &SendAuditLog{
+		path:     path,
+		file:     file,
+		writer:   bufio.NewWriter(file),
+		queue:    make(chan interface{}, sendAuditQueueSize),
+		logger:   logger,
+		stopChan: make(chan struct{}),
+	}
+	logger.Infof("📝 Recording sent frames to %s", path)
+	al.wg.Add(1)
+	go al.writeLoop()
+	return al, nil
+}
+
+// Record queues the audit record of a sent frame without blocking
+func (al *SendAuditLog) Record(msg CanMessage, sentAt time.Time, confirmed bool) {
+	if al == nil {
+		return
+	}
+
+	record := SendAuditRecord{
+		Timestamp:  sentAt,
+		Client:     msg.client,
+		RemoteAddr: msg.remoteAddr,
+		Interface:  msg.Interface,
+		ID:         fmt.Sprintf("0x%X", msg.ID),
+		Data:       hex.EncodeToString(msg.Data),
+		RTR:        msg.RTR,
+		Confirmed:  confirmed,
+		RequestID:  msg.requestID,
+	}
+	al.enqueue(record)
+}
+
+// RecordConfigChange queues the audit record of a configuration change without blocking
+func (al *SendAuditLog) RecordConfigChange(record ConfigAuditRecord) {
+	if al == nil {
+		return
+	}
+	record.Event = "configChange"
+	al.enqueue(record)
+}
+
+// enqueue queues a record, dropping and counting it when the queue is full
+func (al *SendAuditLog) enqueue(record interface{}) {
+	al.recorded.Add(1)
+	select {
+	case al.queue <- record:
+	default:
+		if al.dropped.Add(1) == 1 {
+			al.logger.Warnf("⚠️ Warning: send audit queue full, dropping records (see sendAudit in /api/metrics)")
+		}
+	}
+}
+
+// Stop writes the queued records, flushes and closes the file
+func (al *SendAuditLog) Stop() {
+	if al == nil {
+		return
+	}
+	al.stopOnce.Do(func() {
+		close(al.stopChan)
+		al.wg.Wait()
+		if err := al.file.Close(); err != nil {
+			al.logger.Warnf("⚠️ Warning: failed to close send audit log: %v", err)
+		}
+	})
+}
+
+// GetStats returns the audit log counters
+func (al *SendAuditLog) GetStats() SendAuditStats {
+	if al == nil {
+		return SendAuditStats{}
+	}
+	return SendAuditStats{
+		Enabled:     true,
+		Path:        al.path,
+		Recorded:    al.recorded.Load(),
+		Written:     al.written.Load(),
+		Dropped:     al.dropped.Load(),
+		WriteErrors: al.writeErrors.Load(),
+		QueueDepth:  len(al.queue),
+	}
+}
+
+// writeLoop writes queued records, flushing whenever the queue runs empty so bursts are
+// written in batches and quiet periods leave nothing buffered
+func (al *SendAuditLog) writeLoop() {
+	defer al.wg.Done()
+
+	for {
+		select {
+		case record := <-al.queue:
+			al.write(record)
+			if len(al.queue) == 0 {
+				al.flush()
+			}
+		case <-al.stopChan:
+			for {
+				select {
+				case record := <-al.queue:
+					al.write(record)
+				default:
+					al.flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// write encodes one record as a JSON line into the buffer
+func (al *SendAuditLog) write(record interface{}) {
+	line, err := json.Marshal(record)
+	if err == nil {
+		line = append(line, '\n')
+		_, err = al.writer.Write(line)
+	}
+	if err != nil {
+		al.writeErrors.Add(1)
+		al.logger.Warnf("⚠️ Warning: failed to write send audit record: %v", err)
+		al.writer.Reset(al.file)
+		return
+	}
+	al.written.Add(1)
+}
+
+// flush writes buffered records to the file. After a failed write the buffer is reset,
+// as bufio keeps failing once an error occurred; its records are lost.
+func (al *SendAuditLog) flush() {
+	if err := al.writer.Flush(); err != nil {
+		al.writeErrors.Add(1)
+		al.logger.Warnf("⚠️ Warning: failed to flush send audit log: %v", err)
+		al.writer.Reset(al.file)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrShuttingDown is returned for sends arriving after shutdown began
+var ErrShuttingDown = errors.New("service is shutting down")
+
+// DrainResult reports how the sends in flight when shutdown began ended
+type DrainResult struct {
+	Flushed  int           // Sends that finished within the deadline
+	Dropped  int           // Sends still in flight at the deadline
+	Rejected int           // Sends refused while draining
+	Elapsed  time.Duration // Time spent waiting
+}
+
+// sendDrain counts sends in flight so shutdown can wait for them. Once draining, it
+// admits no new sends.
+type sendDrain struct {
+	mu       sync.Mutex
+	draining bool
+	inFlight int
+	flushed  int
+	rejected int
+	idle     chan struct{} // Closed when the last send in flight finishes while draining
+}
+
+// begin admits a send, or refuses it once draining started. Every admitted send must
+// call end.
+func (d *sendDrain) begin() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		d.rejected++
+		return tagError(ErrShuttingDown, fmt.Errorf("service is shutting down, no new frames are accepted"))
+	}
+	d.inFlight++
+	return nil
+}
+
+// end marks an admitted send finished, written or not
+func (d *sendDrain) end() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inFlight--
+	if d.draining {
+		d.flushed++
+		if d.inFlight == 0 {
+			close(d.idle)
+		}
+	}
+}
+
+// drain refuses new sends and waits until the sends in flight finish, the timeout
+// passes or ctx is done, whichever comes first
+func (d *sendDrain) drain(ctx context.Context, timeout time.Duration) DrainResult {
+	start := time.Now()
+	d.mu.Lock()
+	if !d.draining {
+		d.draining = true
+		d.idle = make(chan struct{})
+		if d.inFlight == 0 {
+			close(d.idle)
+		}
+	}
+	idle := d.idle
+	d.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-idle:
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return DrainResult{
+		Flushed:  d.flushed,
+		Dropped:  d.inFlight,
+		Rejected: d.rejected,
+		Elapsed:  time.Since(start),
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// sendLatencyBounds are the upper bounds of the send latency histogram buckets. A final
+// overflow bucket counts everything slower than the last bound.
+var sendLatencyBounds = [...]time.Duration{
+	50 * time.Microsecond,
+	100 * time.Microsecond,
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+}
+
+// LatencyHistogram is a fixed-bucket latency histogram. Observe only updates
+// preallocated counters with atomics, so it is safe on the send path.
+type LatencyHistogram struct {
+	counts [len(sendLatencyBounds) + 1]atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Int64 // Nanoseconds
+}
+
+// Observe records a latency sample
+func (h *LatencyHistogram) Observe(latency time.Duration) {
+	bucket := len(sendLatencyBounds)
+	for i, bound := range sendLatencyBounds {
+		if latency <= bound {
+			bucket = i
+			break
+		}
+	}
+
+	h.counts[bucket].Add(1)
+	h.count.Add(1)
+	h.sum.Add(int64(latency))
+}
+
+// Snapshot returns a copy of the histogram counters
+func (h *LatencyHistogram) Snapshot() LatencyHistogramSnapshot {
+	var snapshot LatencyHistogramSnapshot
+	for i := range h.counts {
+		snapshot.Counts[i] = h.counts[i].Load()
+	}
+	snapshot.Count = h.count.Load()
+	snapshot.Sum = time.Duration(h.sum.Load())
+	return snapshot
+}
+
+// LatencyHistogramSnapshot is a point-in-time copy of a LatencyHistogram. Counts are
+// per bucket (not cumulative); Counts[i] covers latencies up to sendLatencyBounds[i].
+type LatencyHistogramSnapshot struct {
+	Counts [len(sendLatencyBounds) + 1]uint64
+	Count  uint64
+	Sum    time.Duration
+}
+
+// Quantile estimates the q-th quantile (0 < q <= 1) by interpolating within the bucket
+// containing it. Samples in the overflow bucket are reported as the largest bound.
+func (s LatencyHistogramSnapshot) Quantile(q float64) time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+
+	rank := q * float64(s.Count)
+	var cumulative uint64
+	for i, count := range s.Counts {
+		if count == 0 || float64(cumulative+count) < rank {
+			cumulative += count
+			continue
+		}
+		if i == len(sendLatencyBounds) {
+			break
+		}
+
+		var lower time.Duration
+		if i > 0 {
+			lower = sendLatencyBounds[i-1]
+		}
+		fraction := (rank - float64(cumulative)) / float64(count)
+		return lower + time.Duration(fraction*float64(sendLatencyBounds[i]-lower))
+	}
+
+	return sendLatencyBounds[len(sendLatencyBounds)-1]
+}
+
+// LatencySummary reports send latency percentiles
+type LatencySummary struct {
+	Count uint64 `json:"count"`
+	P50   string `json:"p50"`
+	P95   string `json:"p95"`
+	P99   string `json:"p99"`
+}
+
+// Summary returns the p50, p95 and p99 latencies of the snapshot
+func (s LatencyHistogramSnapshot) Summary() LatencySummary {
+	return LatencySummary{
+		Count: s.Count,
+		P50:   s.Quantile(0.50).String(),
+		P95:   s.Quantile(0.95).String(),
+		P99:   s.Quantile(0.99).String(),
+	}
+}
+
+// sendCounters tracks transmit path pressure: sends waiting for the interface socket
+// (the TX queue), and writes rejected because the kernel had no room for them
+type sendCounters struct {
+	queueDepth       atomic.Int64
+	maxQueueDepth    atomic.Int64
+	bufferFull       atomic.Uint64 // ENOBUFS or EAGAIN returned by write()
+	retries          atomic.Uint64 // Writes retried after ENOBUFS or EAGAIN
+	retriesExhausted atomic.Uint64 // Frames given up when the attempts or the deadline ran out
+	retryTime        atomic.Int64  // Nanoseconds frames spent from their first refused write to their last write
+	paced            atomic.Uint64 // Writes delayed for the minimum inter-frame gap
+	pacedDelay       atomic.Int64  // Nanoseconds those writes waited
+	pacedRate        atomic.Uint64 // Frames per second of the latest run of paced writes, as float64 bits
+}
+
+// enqueue records a send waiting for the socket and updates the high-water mark
+func (c *sendCounters) enqueue() {
+	depth := c.queueDepth.Add(1)
+	for {
+		peak := c.maxQueueDepth.Load()
+		if depth <= peak || c.maxQueueDepth.CompareAndSwap(peak, depth) {
+			return
+		}
+	}
+}
+
+// dequeue records that a send finished with the socket
+func (c *sendCounters) dequeue() {
+	c.queueDepth.Add(-1)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Send-until limits and defaults
+const (
+	DefaultSendUntilInterval = 100 * time.Millisecond
+	MinSendUntilInterval     = 10 * time.Millisecond
+	DefaultSendUntilTimeout  = 5 * time.Second
+	MaxSendUntilTimeout      = 5 * time.Minute
+
+	sendUntilWriteMargin = 10 * time.Second // Added to the run for writing the response
+)
+
+// What ended a send-until run
+const (
+	SendUntilMatched   = "match"
+	SendUntilTimeout   = "timeout"
+	SendUntilMaxSends  = "maxSends"
+	SendUntilCancelled = "cancelled" // By DELETE /api/v1/tasks or the client disconnecting
+)
+
+// FrameCondition matches a received frame by ID and, optionally, by payload
+type FrameCondition struct {
+	Interface string  `json:"interface,omitempty" binding:"omitempty,max=15"` // Empty: the interface the frame is sent on
+	ID        *uint32 `json:"id" binding:"required,max=536870911"`
+	DataMatch string  `json:"dataMatch,omitempty"` // Hex bytes the payload must match under dataMask
+	DataMask  string  `json:"dataMask,omitempty"`  // Hex mask of the compared bits (default: all bits of dataMatch)
+
+	filter *DataFilter
+}
+
+// matches reports whether a received frame meets the condition. Remote frames carry no
+// payload and never match.
+func (c *FrameCondition) matches(msg CanMessageLog) bool {
+	return msg.Interface == c.Interface && msg.ID&^unix.CAN_EFF_FLAG == *c.ID && !msg.RTR && c.filter.Matches(msg.Data)
+}
+
+// SendUntilRequest sends a frame periodically until a frame matching Until is received,
+// for example to poll a node until it comes online
+type SendUntilRequest struct {
+	CanMessage
+	Until    FrameCondition `json:"until" binding:"required"`
+	Interval string         `json:"interval,omitempty"` // Between sends, e.g. 250ms (default: 100ms, at least 10ms)
+	Timeout  string         `json:"timeout,omitempty"`  // Of the whole run, e.g. 10s (default: 5s, at most 5m)
+	MaxSends int            `json:"maxSends,omitempty" binding:"omitempty,min=1"`
+
+	interval time.Duration
+	timeout  time.Duration
+}
+
+// SendUntilResult is the outcome of a send-until run
+type SendUntilResult struct {
+	Matched   bool           `json:"matched"`
+	StoppedBy string         `json:"stoppedBy"` // match, timeout, maxSends or cancelled
+	Sends     int            `json:"sends"`
+	Response  *CanMessageLog `json:"response,omitempty"` // The matching frame
+	Elapsed   string         `json:"elapsed"`
+	LastSend  *SendResult    `json:"lastSend,omitempty"`
+}
+
+// validateRequest checks the frame, the stop condition and the timing, and parses them
+func (req *SendUntilRequest) validateRequest() []FieldError {
+	fields := req.CanMessage.validateRequest()
+
+	filter, err := ParseDataFilter(req.Until.DataMask, req.Until.DataMatch)
+	if err != nil {
+		fields = append(fields, FieldError{Field: "until", Message: err.Error()})
+	}
+	req.Until.filter = filter
+
+	req.interval = DefaultSendUntilInterval
+	if req.Interval != "" {
+		interval, err := time.ParseDuration(req.Interval)
+		switch {
+		case err != nil:
+			fields = append(fields, FieldError{Field: "interval", Message: fmt.Sprintf("must be a duration such as 250ms, got %q", req.Interval)})
+		case interval < MinSendUntilInterval:
+			fields = append(fields, FieldError{Field: "interval", Message: fmt.Sprintf("must be at least %v", MinSendUntilInterval)})
+		default:
+			req.interval = interval
+		}
+	}
+
+	req.timeout = DefaultSendUntilTimeout
+	if req.Timeout != "" {
+		timeout, err := time.ParseDuration(req.Timeout)
+		switch {
+		case err != nil:
+			fields = append(fields, FieldError{Field: "timeout", Message: fmt.Sprintf("must be a duration such as 10s, got %q", req.Timeout)})
+		case timeout <= 0 || timeout > MaxSendUntilTimeout:
+			fields = append(fields, FieldError{Field: "timeout", Message: fmt.Sprintf("must be positive and at most %v", MaxSendUntilTimeout)})
+		default:
+			req.timeout = timeout
+		}
+	}
+	return fields
+}
+
+// matchesOwnFrame reports whether the sent frame itself meets the stop condition. The
+// listener receives the local loopback of every frame sent, so such a condition would
+// end the run on the first send.
+func (req *SendUntilRequest) matchesOwnFrame() bool {
+	return !req.RTR && req.Until.matches(CanMessageLog{Interface: req.Interface, ID: req.ID, Data: req.Data})
+}
+
+// SendUntil sends the frame of req every interval until frames reports one matching
+// req.Until, MaxSends frames were sent or the timeout elapses. The condition is watched
+// from before the first send, so a response to it is not missed. A failed send ends the
+// run with its error; ctx ends it early, as when the client disconnects. The run is
+// listed among the transmit tasks, which can cancel it.
+func (ms *MessageSender) SendUntil(ctx context.Context, req SendUntilRequest, frames *Monitor) (*SendUntilResult, error) {
+	matched, cancel := frames.WaitForFrame(req.Until.matches)
+	defer cancel()
+
+	ctx, taskID, done := ms.tasks.Start(ctx, TransmitTask{
+		Type:      TaskSendUntil,
+		Interface: req.Interface,
+		Description: fmt.Sprintf("0x%X every %v until 0x%X on %s (timeout %v)",
+			req.ID, req.interval, *req.Until.ID, req.Until.Interface, req.timeout),
+		Client:    req.client,
+		RequestID: req.requestID,
+	})
+	defer done()
+	req.jobID = taskID
+	ctx, stop := context.WithTimeout(ctx, req.timeout)
+	defer stop()
+	ticker := time.NewTicker(req.interval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	result := &SendUntilResult{}
+	expired := func() string {
+		if ctx.Err() == context.DeadlineExceeded {
+			return SendUntilTimeout
+		}
+		return SendUntilCancelled
+	}
+	finish := func(stoppedBy string) *SendUntilResult {
+		result.StoppedBy = stoppedBy
+		result.Elapsed = time.Since(start).Round(time.Millisecond).String()
+		ms.frameLogger(req.CanMessage).Infof("🔁 %s send-until stopped by %s after %d sends in %s",
+			req.Interface, stoppedBy, result.Sends, result.Elapsed)
+		return result
+	}
+
+	for {
+		msg := req.CanMessage
+		msg.acceptedAt = time.Now()
+		sent, err := ms.SendCanMessage(msg)
+		if err != nil {
+			return nil, fmt.Errorf("send %d failed: %w", result.Sends+1, err)
+		}
+		result.Sends++
+		result.LastSend = sent
+
+		if req.MaxSends > 0 && result.Sends >= req.MaxSends {
+			// The last frame still gets an interval to be answered
+			select {
+			case response := <-matched:
+				result.Matched, result.Response = true, &response
+				return finish(SendUntilMatched), nil
+			case <-time.After(req.interval):
+				return finish(SendUntilMaxSends), nil
+			case <-ctx.Done():
+				return finish(expired()), nil
+			}
+		}
+
+		select {
+		case response := <-matched:
+			result.Matched, result.Response = true, &response
+			return finish(SendUntilMatched), nil
+		case <-ticker.C:
+		case <-ctx.Done():
+			return finish(expired()), nil
+		}
+	}
+}
+
+// frameWaiters hands received frames to callers waiting for a frame that meets a condition
+type frameWaiters struct {
+	mu      sync.Mutex
+	waiters map[*frameWaiter]struct{}
+}
+
+type frameWaiter struct {
+	match func(CanMessageLog) bool
+	ch    chan CanMessageLog // Buffered for the one frame delivered
+}
+
+// add registers a condition; the returned channel receives the first frame meeting it
+func (w *frameWaiters) add(match func(CanMessageLog) bool) (<-chan CanMessageLog, func()) {
+	waiter := &frameWaiter{match: match, ch: make(chan CanMessageLog, 1)}
+
+	w.mu.Lock()
+	if w.waiters == nil {
+		w.waiters = make(map[*frameWaiter]struct{})
+	}
+	w.waiters[waiter] = struct{}{}
+	w.mu.Unlock()
+
+	return waiter.ch, func() { w.remove(waiter) }
+}
+
+func (w *frameWaiters) remove(waiter *frameWaiter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.waiters, waiter)
+}
+
+// observe delivers a received frame to the waiters whose condition it meets. Each waiter
+// gets one frame and is then removed.
+func (w *frameWaiters) observe(msg CanMessageLog) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for waiter := range w.waiters {
+		if waiter.match(msg) {
+			waiter.ch <- msg
+			delete(w.waiters, waiter)
+		}
+	}
+}
@@ -1,9 +1,23 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
-	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Errors returned by MessageSender. APIHandler maps them to error codes.
+var (
+	ErrValidation   = errors.New("validation failed")
+	ErrInvalidID    = errors.New("invalid CAN ID")
+	ErrSendFailed   = errors.New("send failed")
+	ErrTxBufferFull = errors.New("TX buffer full")
+	ErrBusOff       = errors.New("controller is bus-off")
 )
 
 // MessageSender handles sending CAN messages
@@ -11,49 +25,216 @@ type MessageSender struct {
 	interfaceManager *InterfaceManager
 	configProvider   ConfigProvider
 	socketProvider   SocketProvider
+	tracer           *OTLPExporter
+	auditLog         atomic.Pointer[SendAuditLog] // Replaced on reload while frames are sent
+	capture          *FrameCapture
+	stateReader      InterfaceStateReader
+	watchdog         *Watchdog // Told at once when a write finds the link gone; nil without health checks
+	txGate           *TxGate
+	tasks            *TransmitTasks
+	drain            sendDrain
 	logger           Logger
 }
 
 // NewMessageSender creates a new message sender
 func NewMessageSender(interfaceManager *InterfaceManager, configProvider ConfigProvider, socketProvider SocketProvider, logger Logger) *MessageSender {
-	return &MessageSender{
+	ms := &MessageSender{
 		interfaceManager: interfaceManager,
 		configProvider:   configProvider,
 		socketProvider:   socketProvider,
+		txGate:           NewTxGate(),
+		tasks:            NewTransmitTasks(),
 		logger:           logger,
 	}
+	interfaceManager.sender = ms
+	return ms
+}
+
+// SetTracer sets the exporter that receives a span for every frame written
+func (ms *MessageSender) SetTracer(tracer *OTLPExporter) {
+	ms.tracer = tracer
+}
+
+// SetAuditLog sets the log that records every frame written to the bus and returns the
+// previous one, which the caller stops
+func (ms *MessageSender) SetAuditLog(auditLog *SendAuditLog) *SendAuditLog {
+	return ms.auditLog.Swap(auditLog)
+}
+
+// SetFrameCapture sets the capture that records frames written to the bus
+func (ms *MessageSender) SetFrameCapture(capture *FrameCapture) {
+	ms.capture = capture
+}
+
+// SetStateReader sets where the controller state is read to recognize bus-off on failed writes
+func (ms *MessageSender) SetStateReader(reader InterfaceStateReader) {
+	ms.stateReader = reader
+}
+
+// SetWatchdog sets the watchdog told when a write finds the device gone or the link down
+func (ms *MessageSender) SetWatchdog(watchdog *Watchdog) {
+	ms.watchdog = watchdog
+}
+
+// TxGate returns the gate that enables and disables transmission per interface
+func (ms *MessageSender) TxGate() *TxGate {
+	return ms.txGate
+}
+
+// Tasks returns the running transmit tasks
+func (ms *MessageSender) Tasks() *TransmitTasks {
+	return ms.tasks
+}
+
+// SetTxEnabled enables or disables transmission on a configured interface. It reports
+// whether the state changed.
+func (ms *MessageSender) SetTxEnabled(ifName string, enabled bool) (bool, error) {
+	if !ms.configProvider.ValidateInterface(ifName) {
+		return false, tagError(ErrInterfaceNotFound, fmt.Errorf("CAN interface %s is not configured. Available interfaces: %v",
+			ifName, ms.configProvider.GetCanPorts()))
+	}
+	return ms.txGate.SetEnabled(ifName, enabled), nil
+}
+
+// checkTxEnabled rejects sends on an interface whose transmission is disabled
+func (ms *MessageSender) checkTxEnabled(ifName string) error {
+	if enabled, since := ms.txGate.Enabled(ifName); !enabled {
+		return tagError(ErrTxDisabled, fmt.Errorf("transmission on %s is disabled (since %s)",
+			ifName, since.Format(time.RFC3339)))
+	}
+	return nil
+}
+
+// GetAuditStats returns the send audit log counters
+func (ms *MessageSender) GetAuditStats() SendAuditStats {
+	return ms.auditLog.Load().GetStats()
+}
+
+// Drain refuses new sends and waits for the sends in flight, at most timeout or until
+// ctx is done. Called once on shutdown, before the interfaces are closed.
+func (ms *MessageSender) Drain(ctx context.Context, timeout time.Duration) DrainResult {
+	return ms.drain.drain(ctx, timeout)
 }
 
 // SendCanMessage sends a raw CAN message with interface validation
-func (ms *MessageSender) SendCanMessage(msg CanMessage) error {
+func (ms *MessageSender) SendCanMessage(msg CanMessage) (*SendResult, error) {
+	if err := ms.drain.begin(); err != nil {
+		return nil, err
+	}
+	defer ms.drain.end()
+	return ms.sendCanMessage(msg)
+}
+
+// sendCanMessage sends a message admitted by the drain
+func (ms *MessageSender) sendCanMessage(msg CanMessage) (*SendResult, error) {
+	if msg.acceptedAt.IsZero() {
+		msg.acceptedAt = time.Now()
+	}
+
+	ifName, err := ms.resolveInterface(msg.Interface)
+	if err != nil {
+		return nil, err
+	}
+	msg.Interface = ifName
+
 	// Validate interface is configured
 	if !ms.configProvider.ValidateInterface(msg.Interface) {
-		return fmt.Errorf("CAN interface %s is not configured. Available interfaces: %v",
-			msg.Interface, ms.configProvider.GetCanPorts())
+		return nil, tagError(ErrInterfaceNotFound, fmt.Errorf("CAN interface %s is not configured. Available interfaces: %v",
+			msg.Interface, ms.configProvider.GetCanPorts()))
+	}
+	if err := ms.checkTxEnabled(msg.Interface); err != nil {
+		return nil, err
+	}
+
+	// Validate data length
+	if len(msg.Data) > 8 {
+		return nil, tagError(ErrValidation, fmt.Errorf("CAN data exceeds maximum length (8 bytes)"))
+	}
+	if msg.RTR && (len(msg.Data) > 0 || msg.Length > 8) {
+		return nil, tagError(ErrValidation, fmt.Errorf("remote frames carry no data and request at most 8 bytes"))
+	}
+
+	frame := ms.buildFrame(msg)
+	msg.Length = frame.Length // Reported in the result: derived from the data unless given
+	oneShot := ms.checkOneShot(msg)
+
+	// In dry-run mode the frame is validated and logged but never written
+	if msg.DryRun || ms.configProvider.GetDryRun() {
+		result := ms.dryRunMessage(msg, frame)
+		result.OneShotApplied = oneShot
+		return result, nil
 	}
 
 	// Get interface
 	canIf, ok := ms.interfaceManager.GetInterface(msg.Interface)
 	if !ok {
-		return fmt.Errorf("CAN interface %s not initialized", msg.Interface)
+		return nil, tagError(ErrInterfaceDown, fmt.Errorf("CAN interface %s not initialized", msg.Interface))
 	}
 
-	// Validate data length
-	if len(msg.Data) > 8 {
-		return fmt.Errorf("CAN data exceeds maximum length (8 bytes)")
+	sentAt, confirmed, err := ms.sendMessage(canIf, msg, frame)
+	if err != nil {
+		return nil, err
 	}
+	ms.auditLog.Load().Record(msg, sentAt, confirmed)
+	ms.capture.ObserveSent(msg, sentAt)
 
-	return ms.sendMessage(canIf, msg)
+	return &SendResult{
+		CanMessage:     msg,
+		Confirmed:      confirmed,
+		OneShotApplied: oneShot,
+		SentAt:         sentAt,
+		Frame:          bytesToHexArray(frameBytes(&frame)),
+		RequestID:      msg.requestID,
+	}, nil
 }
 
-// sendMessage performs the actual message sending
-func (ms *MessageSender) sendMessage(canIf *CanInterface, msg CanMessage) error {
-	canIf.Lock()
-	defer canIf.Unlock()
+// checkOneShot reports, for a message asking for one-shot transmission, whether the
+// controller of its interface runs in one-shot mode. SocketCAN has no per-frame or
+// per-socket option: one-shot is a controller mode set with -one-shot, and drivers
+// without support reject it at setup. The frame is sent either way.
+func (ms *MessageSender) checkOneShot(msg CanMessage) *bool {
+	if !msg.OneShot {
+		return nil
+	}
+	applied := false
+	if ms.stateReader != nil {
+		if state, err := ms.stateReader.ReadInterfaceState(msg.Interface); err == nil {
+			applied = state.OneShot
+		}
+	}
+	if !applied {
+		ms.frameLogger(msg).Warnf("⚠️ %s is not in one-shot mode: message ID=0x%X may be retransmitted",
+			msg.Interface, msg.ID)
+	}
+	return &applied
+}
 
-	startTime := time.Now()
+// frameLogger returns the logger with the interface and CAN ID of a message as fields,
+// and the request, principal and job it was sent for when known
+func (ms *MessageSender) frameLogger(msg CanMessage) Logger {
+	logger := ms.logger.With("interface", msg.Interface, "can_id", fmt.Sprintf("0x%X", msg.ID))
+	if msg.requestID != "" {
+		logger = logger.With("request_id", msg.requestID)
+	}
+	if msg.client != "" {
+		logger = logger.With("client", msg.client)
+	}
+	if msg.jobID != "" {
+		logger = logger.With("job_id", msg.jobID)
+	}
+	return logger
+}
+
+// buildFrame prepares the raw CAN frame for a message
+func (ms *MessageSender) buildFrame(msg CanMessage) CanFrame {
+	// Remote frames carry no data; their length is the DLC requested from the responder
+	if msg.RTR {
+		return CanFrame{
+			ID:     msg.ID | unix.CAN_RTR_FLAG,
+			Length: msg.Length,
+		}
+	}
 
-	// Prepare CAN frame
 	frame := CanFrame{
 		ID:     msg.ID,
 		Length: uint8(len(msg.Data)),
@@ -64,46 +245,457 @@ func (ms *MessageSender) sendMessage(canIf *CanInterface, msg CanMessage) error
 		frame.Data[i] = msg.Data[i]
 	}
 
+	return frame
+}
+
+// dryRunMessage logs the frame that would be sent without touching the socket
+func (ms *MessageSender) dryRunMessage(msg CanMessage, frame CanFrame) *SendResult {
+	raw := bytesToHexArray(frameBytes(&frame))
+
+	ms.frameLogger(msg).Infof("🧪 %s dry run: would send ID=0x%X, RTR=%t, Data=[% X], Length=%d, Frame=%v",
+		msg.Interface, msg.ID, msg.RTR, msg.Data, frame.Length, raw)
+
+	return &SendResult{
+		CanMessage: msg,
+		DryRun:     true,
+		Frame:      raw,
+		RequestID:  msg.requestID,
+	}
+}
+
+// sendMessage performs the actual message sending and, when enabled, waits for the
+// loopback echo that confirms the frame left the controller. sentAt is when write() returned.
+func (ms *MessageSender) sendMessage(canIf *CanInterface, msg CanMessage, frame CanFrame) (sentAt time.Time, confirmed bool, err error) {
+	if ms.tracer.TracesEnabled() {
+		start := time.Now()
+		defer func() { ms.recordSendSpan(msg, frame, start, confirmed, err) }()
+	}
+
+	pending, sentAt, err := ms.writeFrame(canIf, msg, frame)
+	if err != nil {
+		// A vanished device or downed link is not waited for: the watchdog recovers it now
+		if isLinkGone(err) {
+			ms.watchdog.ReportLinkDown(msg.Interface, err)
+		}
+		return sentAt, false, ms.classifyWriteError(msg.Interface, err)
+	}
+	if pending == nil {
+		return sentAt, false, nil
+	}
+
+	timeout := ms.configProvider.GetTxConfirmTimeout()
+	confirmed = canIf.echo.wait(pending, timeout)
+	canIf.Metrics.RecordConfirmation(confirmed)
+	if !confirmed {
+		ms.frameLogger(msg).Warnf("⚠️ %s message ID=0x%X not confirmed by loopback echo within %v",
+			msg.Interface, msg.ID, timeout)
+	}
+
+	return sentAt, confirmed, nil
+}
+
+// recordSendSpan exports a span for a send, as a child of the API request span if any
+func (ms *MessageSender) recordSendSpan(msg CanMessage, frame CanFrame, start time.Time, confirmed bool, err error) {
+	attributes := []otlpKeyValue{
+		stringAttr("can.interface", msg.Interface),
+		intAttr("can.id", int64(msg.ID)),
+		stringAttr("can.id_hex", fmt.Sprintf("0x%X", msg.ID)),
+		intAttr("can.dlc", int64(frame.Length)),
+		boolAttr("can.rtr", msg.RTR),
+		boolAttr("can.confirmed", confirmed),
+	}
+	if msg.requestID != "" {
+		attributes = append(attributes, stringAttr("http.request.header.x-request-id", msg.requestID))
+	}
+
+	var errMsg string
+	if err != nil {
+		errMsg = err.Error()
+	}
+	ms.tracer.RecordSpan(newOTLPSpan(newSpanContext(msg.trace), msg.trace, "can.send", otlpSpanKindProducer,
+		start, time.Now(), attributes, errMsg))
+}
+
+// writeFrame has the TX worker of the interface write the frame and logs the outcome
+func (ms *MessageSender) writeFrame(canIf *CanInterface, msg CanMessage, frame CanFrame) (*pendingEcho, time.Time, error) {
+	result, err := ms.queueFrame(canIf, msg, frame)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	// Logged here rather than by the worker, which is free for the next send meanwhile
+	if result.err != nil {
+		ms.frameLogger(msg).Errorf("❌ %s message send failed: ID=0x%X, Error=%v", msg.Interface, msg.ID, result.err)
+		return nil, time.Time{}, result.err
+	}
+	// With -log-tx-frames=false only at debug level
+	logger := ms.frameLogger(msg)
+	logf := logger.Debugf
+	if ms.configProvider.GetLogTxFrames() {
+		logf = logger.Infof
+	}
+	logf("✅ %s message sent: ID=0x%X, Data=[% X], Length=%d, Latency=%v",
+		msg.Interface, msg.ID, msg.Data, frame.Length, result.latency)
+	return result.pending, result.writtenAt, nil
+}
+
+// Health probes are queued in the high band, so a busy interface is not reported
+// unhealthy for the bulk traffic queued ahead of its probe, and fail when still waiting
+// after healthProbeTimeout
+const (
+	healthProbePriority = TxPriorityHigh
+	healthProbeTimeout  = 2 * time.Second
+)
+
+// probe writes a health probe to an interface through its TX worker, paced and retried
+// like any send. Its echo is not waited for.
+func (ms *MessageSender) probe(canIf *CanInterface) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthProbeTimeout)
+	defer cancel()
+
+	// Simple probe message (0x00 is typically a diagnostic/echo ID)
+	msg := CanMessage{
+		Interface:  canIf.Name,
+		ID:         0x00,
+		Data:       FrameData{0x00},
+		Priority:   healthProbePriority,
+		ctx:        ctx,
+		acceptedAt: time.Now(),
+	}
+	result, err := ms.queueFrame(canIf, msg, ms.buildFrame(msg))
+	if err != nil {
+		return err
+	}
+	if result.pending != nil {
+		canIf.echo.cancel(result.pending)
+	}
+	return result.err
+}
+
+// queueFrame queues the frame for the TX worker of the interface and waits for the
+// outcome of its write. Sends waiting for the worker count towards the TX queue depth.
+// A send whose request is canceled while it waits is withdrawn; one being written is
+// waited for. The error reports a send refused by the queue or withdrawn, which was
+// never written.
+func (ms *MessageSender) queueFrame(canIf *CanInterface, msg CanMessage, frame CanFrame) (txResult, error) {
+	canIf.Metrics.EnterTxQueue()
+	defer canIf.Metrics.LeaveTxQueue()
+
+	ctx := msg.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	job := txJobs.Get().(*txJob)
+	job.ctx, job.msg, job.frame = ctx, msg, frame
+	defer func() {
+		*job = txJob{done: job.done}
+		txJobs.Put(job)
+	}()
+	band := txBand(msg.Priority)
+	canIf.txQueue.start(ms, canIf)
+	if err := canIf.txQueue.push(job, band, ms.configProvider.GetTxQueue()); err != nil {
+		ms.frameLogger(msg).Warnf("⚠️ %s message ID=0x%X rejected: %v", msg.Interface, msg.ID, err)
+		return txResult{}, err
+	}
+
+	var result txResult
+	select {
+	case result = <-job.done:
+	case <-ctx.Done(): // Never for a send without a request
+		if canIf.txQueue.withdraw(job, band) {
+			ms.frameLogger(msg).Warnf("⚠️ %s message ID=0x%X not sent: request canceled while queued", msg.Interface, msg.ID)
+			return txResult{}, ctx.Err()
+		}
+		result = <-job.done // Taken by the worker already: the write finishes
+	}
+	return result, nil
+}
+
+// writeJob writes a queued frame to the socket, registering it for echo confirmation
+// first. Runs on the TX worker of the interface.
+func (ms *MessageSender) writeJob(canIf *CanInterface, job *txJob) txResult {
+	canIf.Lock()
+	defer canIf.Unlock()
+
+	paced := ms.pace(canIf, job.msg)
+
+	var pending *pendingEcho
+	if canIf.echo != nil {
+		pending = canIf.echo.expect(frameBytes(&job.frame))
+	}
+
+	startTime := time.Now()
+
 	// Send CAN frame
-	buf := (*[16]byte)(unsafe.Pointer(&frame))[:]
-	err := ms.socketProvider.SendTo(canIf.FD, buf, canIf.Addr)
+	err := ms.sendWithRetry(canIf, job.frame)
+	writtenAt := time.Now()
 
 	// Update metrics
-	if err == nil {
-		latency := time.Since(startTime)
-		canIf.Metrics.RecordSuccess(latency)
-
-		// Log success
-		ms.logger.Printf("✅ %s message sent: ID=0x%X, Data=[% X], Length=%d, Latency=%v",
-			msg.Interface, msg.ID, msg.Data, frame.Length, latency)
-	} else {
+	if err != nil {
 		canIf.Metrics.RecordError(err)
+		canIf.pacing.failed()
+		if pending != nil {
+			canIf.echo.cancel(pending)
+		}
+		return txResult{err: err}
+	}
+	canIf.pacing.written(writtenAt, paced, canIf.Metrics)
+	latency := writtenAt.Sub(startTime)
+	canIf.Metrics.RecordSuccess(latency)
+	canIf.Metrics.SendLatency.Observe(time.Since(job.msg.acceptedAt))
+	return txResult{pending: pending, writtenAt: writtenAt, latency: latency}
+}
+
+// classifyWriteError tags a failed write with its cause. A full TX buffer or a downed
+// link is reported as bus-off while the controller is in that state. A send refused by
+// the TX queue never reached the socket and keeps its error.
+func (ms *MessageSender) classifyWriteError(ifName string, err error) error {
+	if errors.Is(err, ErrTxQueueFull) {
+		return err
+	}
+	kind := ErrSendFailed
+	switch {
+	case isTxBufferFull(err):
+		kind = ErrTxBufferFull
+	case isLinkGone(err):
+		kind = ErrInterfaceDown
+	}
 
-		// Log error
-		ms.logger.Printf("❌ %s message send failed: ID=0x%X, Error=%v", msg.Interface, msg.ID, err)
+	if kind != ErrSendFailed && ms.stateReader != nil {
+		if state, stateErr := ms.stateReader.ReadInterfaceState(ifName); stateErr == nil && state.CanState == "BUS-OFF" {
+			kind = ErrBusOff
+		}
 	}
+	return tagError(kind, err)
+}
 
-	return err
+// resolveInterface returns the interface a message is sent on, resolving an alias and
+// falling back to the default interface when the message omits one
+func (ms *MessageSender) resolveInterface(ifName string) (string, error) {
+	if ifName != "" {
+		return ms.configProvider.ResolveInterfaceAlias(ifName), nil
+	}
+
+	defaultInterface := ms.configProvider.GetDefaultInterface()
+	if defaultInterface == "" {
+		return "", tagError(ErrValidation, fmt.Errorf("interface name is required: multiple interfaces are configured (%v) and no default interface is set",
+			ms.configProvider.GetCanPorts()))
+	}
+	return defaultInterface, nil
 }
 
 // ValidateMessage validates a CAN message before sending
 func (ms *MessageSender) ValidateMessage(msg CanMessage) error {
-	if msg.Interface == "" {
-		return fmt.Errorf("interface name is required")
+	ifName, err := ms.resolveInterface(msg.Interface)
+	if err != nil {
+		return err
 	}
 
-	if !ms.configProvider.ValidateInterface(msg.Interface) {
-		return fmt.Errorf("CAN interface %s is not configured. Available interfaces: %v",
-			msg.Interface, ms.configProvider.GetCanPorts())
+	if !ms.configProvider.ValidateInterface(ifName) {
+		return tagError(ErrInterfaceNotFound, fmt.Errorf("CAN interface %s is not configured. Available interfaces: %v",
+			ifName, ms.configProvider.GetCanPorts()))
+	}
+	if err := ms.checkTxEnabled(ifName); err != nil {
+		return err
+	}
+
+	// Extended IDs of DBC messages carry CAN_EFF_FLAG
+	if msg.ID&^unix.CAN_EFF_FLAG > unix.CAN_EFF_MASK {
+		return tagError(ErrInvalidID, fmt.Errorf("CAN ID 0x%X exceeds 29 bits", msg.ID))
+	}
+
+	maxLength := classicCANMaxDataLength // CAN FD is not supported, so every interface takes classic frames
+
+	if msg.RTR {
+		if len(msg.Data) > 0 {
+			return tagError(ErrValidation, fmt.Errorf("remote frames (rtr) cannot carry data"))
+		}
+		if int(msg.Length) > maxLength {
+			return tagError(ErrValidation, fmt.Errorf("remote frame length %d exceeds the maximum DLC of %s (%d, classic CAN)",
+				msg.Length, ifName, maxLength))
+		}
+		return nil
 	}
 
 	if len(msg.Data) == 0 {
-		return fmt.Errorf("message data cannot be empty")
+		return tagError(ErrValidation, fmt.Errorf("message data cannot be empty"))
 	}
 
-	if len(msg.Data) > 8 {
-		return fmt.Errorf("CAN data exceeds maximum length (8 bytes)")
+	if len(msg.Data) > maxLength {
+		return tagError(ErrValidation, fmt.Errorf("CAN data is %d bytes but %s is a classic CAN interface and accepts at most %d bytes",
+			len(msg.Data), ifName, maxLength))
+	}
+
+	if msg.Length != 0 && int(msg.Length) != len(msg.Data) {
+		return tagError(ErrValidation, fmt.Errorf("length %d does not match the %d data bytes", msg.Length, len(msg.Data)))
 	}
 
 	return nil
 }
+
+// decodeDataHex parses DataHex into Data. Setting both is rejected as ambiguous.
+func (msg *CanMessage) decodeDataHex() error {
+	if msg.DataHex == "" {
+		return nil
+	}
+	if len(msg.Data) > 0 {
+		return tagError(ErrValidation, fmt.Errorf("data and dataHex are mutually exclusive"))
+	}
+
+	data, err := parseHexBytes(msg.DataHex)
+	if err != nil {
+		return tagError(ErrValidation, fmt.Errorf("invalid dataHex %q: expected hex bytes such as \"02 10 01\": %w", msg.DataHex, err))
+	}
+	msg.Data = data
+	return nil
+}
+
+// decodeBody decodes data in the encoding the request names
+func (msg *CanMessage) decodeBody(body []byte) []FieldError {
+	return decodeDataEncoding(body, msg.Encoding, &msg.Data)
+}
+
+// validateRequest checks the rules between the fields of a send request. Limits that
+// depend on the interface are left to ValidateMessage.
+func (msg *CanMessage) validateRequest() []FieldError {
+	var fields []FieldError
+	if msg.DataHex != "" {
+		if len(msg.Data) > 0 {
+			fields = append(fields, FieldError{Field: "dataHex", Message: "cannot be combined with data"})
+		} else if _, err := parseHexBytes(msg.DataHex); err != nil {
+			fields = append(fields, FieldError{Field: "dataHex", Message: fmt.Sprintf("must be hex bytes such as \"02 10 01\": %v", err)})
+		}
+	}
+
+	hasData := len(msg.Data) > 0 || msg.DataHex != ""
+	switch {
+	case msg.RTR && hasData:
+		fields = append(fields, FieldError{Field: "data", Message: "must be empty for a remote frame (rtr)"})
+	case !msg.RTR && !hasData:
+		fields = append(fields, FieldError{Field: "data", Message: "is required (or dataHex) unless rtr is set"})
+	case !msg.RTR && msg.Length != 0 && len(msg.Data) > 0 && int(msg.Length) != len(msg.Data):
+		fields = append(fields, FieldError{Field: "length", Message: fmt.Sprintf("is %d but data has %d bytes", msg.Length, len(msg.Data))})
+	}
+	return fields
+}
+
+// MultiSendRequest sends one frame on several interfaces at once
+type MultiSendRequest struct {
+	CanMessage
+	Interfaces []string `json:"interfaces" binding:"required,min=1,dive,required,max=15"`
+	GapUs      *int     `json:"gapUs,omitempty"` // Inter-frame gap in microseconds on every interface, instead of that configured; 0 disables it
+}
+
+// validateRequest checks the frame, that the interfaces are listed once each and the
+// inter-frame gap, and parses it
+func (req *MultiSendRequest) validateRequest() []FieldError {
+	fields := req.CanMessage.validateRequest()
+	if req.Interface != "" {
+		fields = append(fields, FieldError{Field: "interface", Message: "must be empty, use interfaces instead"})
+	}
+	seen := make(map[string]bool, len(req.Interfaces))
+	for i, ifName := range req.Interfaces {
+		if ifName != "" && seen[ifName] {
+			fields = append(fields, FieldError{Field: fmt.Sprintf("interfaces[%d]", i), Message: fmt.Sprintf("lists %s more than once", ifName)})
+		}
+		seen[ifName] = true
+	}
+	if req.GapUs != nil {
+		gap := time.Duration(*req.GapUs) * time.Microsecond
+		if gap < 0 || gap > maxTxGap {
+			fields = append(fields, FieldError{Field: "gapUs", Message: fmt.Sprintf("must be between 0 and %d microseconds", maxTxGap.Microseconds())})
+		} else {
+			req.txGap = &gap
+		}
+	}
+	return fields
+}
+
+// InterfaceSendResult is the outcome of a multi-interface send on one interface
+type InterfaceSendResult struct {
+	Interface string      `json:"interface"`
+	Result    *SendResult `json:"result,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	Code      ErrorCode   `json:"code,omitempty"` // API error code of Error
+}
+
+// MultiSendResult describes a frame sent on several interfaces concurrently
+type MultiSendResult struct {
+	Results  []InterfaceSendResult `json:"results"` // In request order
+	Sent     int                   `json:"sent"`
+	Failed   int                   `json:"failed"`
+	Spread   string                `json:"spread"`   // Time between the first and the last write() returning
+	SpreadUs float64               `json:"spreadUs"` // Spread in microseconds
+}
+
+// SendCanMessageMulti sends the same frame on every interface from its own goroutine.
+// The goroutines are released together once all of them are ready and queue the frame
+// for the TX workers of their interfaces, which write in parallel. The frame is a batch
+// in the bulk band unless it sets a priority, so on a busy interface it waits behind the
+// sends queued there and the spread of the writes includes that wait; an idle interface
+// writes it at once. The frame is sent on all interfaces or, once shutdown began, on none.
+func (ms *MessageSender) SendCanMessageMulti(msg CanMessage, interfaces []string) (MultiSendResult, error) {
+	if err := ms.drain.begin(); err != nil {
+		return MultiSendResult{}, err
+	}
+	defer ms.drain.end()
+
+	if msg.acceptedAt.IsZero() {
+		msg.acceptedAt = time.Now()
+	}
+	// A frame for several interfaces is a batch: it waits behind single sends
+	if msg.Priority == "" {
+		msg.Priority = TxPriorityBulk
+	}
+
+	results := make([]InterfaceSendResult, len(interfaces))
+	start := make(chan struct{})
+	var ready, done sync.WaitGroup
+	for i, ifName := range interfaces {
+		ready.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			ifMsg := msg
+			ifMsg.Interface = ifName
+			ready.Done()
+			<-start
+
+			results[i].Interface = ifName
+			result, err := ms.sendCanMessage(ifMsg)
+			if err != nil {
+				results[i].Error = err.Error()
+				results[i].Code = errorCode(err)
+				return
+			}
+			results[i].Result = result
+		}()
+	}
+	ready.Wait()
+	close(start)
+	done.Wait()
+
+	summary := MultiSendResult{Results: results}
+	var first, last time.Time
+	for _, result := range results {
+		if result.Result == nil {
+			summary.Failed++
+			continue
+		}
+		summary.Sent++
+		sentAt := result.Result.SentAt
+		if sentAt.IsZero() {
+			continue // Dry run
+		}
+		if first.IsZero() || sentAt.Before(first) {
+			first = sentAt
+		}
+		if sentAt.After(last) {
+			last = sentAt
+		}
+	}
+	spread := last.Sub(first)
+	summary.Spread = spread.String()
+	summary.SpreadUs = float64(spread) / float64(time.Microsecond)
+	return summary, nil
+}
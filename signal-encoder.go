@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// SignalSendRequest sends a DBC message built from engineering signal values
+type SignalSendRequest struct {
+	Interface string             `json:"interface" binding:"omitempty,max=15"` // Optional when a default interface applies
+	Message   string             `json:"message" binding:"required"`
+	Signals   map[string]float64 `json:"signals" binding:"required"` // Omitted signals are sent as raw 0
+	DryRun    bool               `json:"dryRun,omitempty"`
+	Priority  string             `json:"priority,omitempty" binding:"omitempty,oneof=high normal bulk"` // TX queue band, normal when empty
+}
+
+// bitPositions returns the message bit positions (byte*8 + bit) of a signal, least
+// significant value bit first. Motorola start bits name the most significant bit and
+// continue into the next byte after bit 0 of each byte.
+func (s DBCSignal) bitPositions() []int {
+	positions := make([]int, s.Length)
+	if s.LittleEndian {
+		for i := range positions {
+			positions[i] = s.StartBit + i
+		}
+		return positions
+	}
+
+	position := s.StartBit
+	for i := s.Length - 1; i >= 0; i-- {
+		positions[i] = position
+		if position%8 == 0 {
+			position += 15
+		} else {
+			position--
+		}
+	}
+	return positions
+}
+
+// hasRange reports whether the DBC defines a value range for the signal
+func (s DBCSignal) hasRange() bool {
+	return s.Min != 0 || s.Max != 0
+}
+
+// rawValue converts an engineering value into the raw bits of the signal
+func (s DBCSignal) rawValue(value float64) (uint64, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("signal %s: value must be a finite number", s.Name)
+	}
+	if s.hasRange() && (value < s.Min || value > s.Max) {
+		return 0, fmt.Errorf("signal %s: value %g is outside its range [%g, %g] %s", s.Name, value, s.Min, s.Max, s.Unit)
+	}
+
+	raw := math.Round((value - s.Offset) / s.Factor)
+	low, high := 0.0, math.Exp2(float64(s.Length))-1
+	if s.Signed {
+		low, high = -math.Exp2(float64(s.Length-1)), math.Exp2(float64(s.Length-1))-1
+	}
+	if raw < low || raw > high {
+		return 0, fmt.Errorf("signal %s: value %g does not fit in %d bits", s.Name, value, s.Length)
+	}
+
+	if raw < 0 {
+		return uint64(int64(raw)), nil
+	}
+	return uint64(raw), nil
+}
+
+// EncodeMessage packs engineering signal values into the data bytes of a DBC message,
+// applying factor, offset, byte order and bit positions. Values outside a signal's range,
+// unknown signals and multiplexed signals whose multiplexer value is not selected are rejected.
+func (db *DBC) EncodeMessage(name string, values map[string]float64) (*DBCMessage, []byte, error) {
+	message, ok := db.GetMessage(name)
+	if !ok {
+		return nil, nil, fmt.Errorf("message %s is not defined in %s", name, db.Path)
+	}
+
+	signals := make(map[string]DBCSignal, len(message.Signals))
+	var multiplexer *DBCSignal
+	for i, signal := range message.Signals {
+		signals[signal.Name] = signal
+		if signal.Multiplexer {
+			multiplexer = &message.Signals[i]
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for signalName := range values {
+		names = append(names, signalName)
+	}
+	sort.Strings(names)
+
+	data := make([]byte, message.Length)
+	for _, signalName := range names {
+		signal, ok := signals[signalName]
+		if !ok {
+			return nil, nil, fmt.Errorf("signal %s is not defined in message %s", signalName, name)
+		}
+
+		if signal.MultiplexValue != nil {
+			if multiplexer == nil {
+				return nil, nil, fmt.Errorf("message %s has multiplexed signal %s but no multiplexer", name, signalName)
+			}
+			selected, given := values[multiplexer.Name]
+			raw, err := multiplexer.rawValue(selected)
+			if !given || err != nil || raw != uint64(*signal.MultiplexValue) {
+				return nil, nil, fmt.Errorf("signal %s is only present when %s is %d",
+					signalName, multiplexer.Name, *signal.MultiplexValue)
+			}
+		}
+
+		raw, err := signal.rawValue(values[signalName])
+		if err != nil {
+			return nil, nil, err
+		}
+		for i, position := range signal.bitPositions() {
+			data[position/8] |= byte((raw>>i)&1) << (position % 8)
+		}
+	}
+
+	return message, data, nil
+}
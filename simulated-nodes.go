@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// simulatedNodeQueueSize bounds the responses waiting to be sent by one node
+const simulatedNodeQueueSize = 64
+
+// SimulatedResponse is a canned reply of a simulated node. Data is written as hex
+// bytes, optionally separated by spaces (e.g. "02 10 01").
+type SimulatedResponse struct {
+	RequestID    uint32 `json:"requestId"`
+	RequestData  string `json:"requestData,omitempty"` // Prefix the request data must start with (default: any)
+	ResponseID   uint32 `json:"responseId"`
+	ResponseData string `json:"responseData"`
+	Delay        string `json:"delay,omitempty"` // Time before responding, e.g. 5ms
+
+	requestPrefix []byte
+	response      []byte
+	delay         time.Duration
+}
+
+// SimulatedNodeConfig describes a simulated ECU on a virtual CAN interface
+type SimulatedNodeConfig struct {
+	Name      string              `json:"name"`
+	Interface string              `json:"interface"`
+	Responses []SimulatedResponse `json:"responses"`
+}
+
+// simulatedNodeFile is the format of the -simulated-nodes file
+type simulatedNodeFile struct {
+	Nodes []SimulatedNodeConfig `json:"nodes"`
+}
+
+// LoadSimulatedNodes reads simulated node definitions from a JSON file ({"nodes": [...]})
+func LoadSimulatedNodes(path string) ([]SimulatedNodeConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read simulated nodes: %w", err)
+	}
+
+	var file simulatedNodeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse simulated nodes %s: %w", path, err)
+	}
+	return file.Nodes, nil
+}
+
+// parseHexBytes parses hex bytes such as "02 10 01" or "021001"
+func parseHexBytes(value string) ([]byte, error) {
+	return hex.DecodeString(strings.ReplaceAll(value, " ", ""))
+}
+
+// normalize validates the node and parses its response data and delays. Nodes only
+// run on virtual CAN interfaces so canned replies can never reach a real bus.
+func (c *SimulatedNodeConfig) normalize() error {
+	if c.Name == "" {
+		return fmt.Errorf("simulated node name is required")
+	}
+	if !strings.HasPrefix(c.Interface, "vcan") {
+		return fmt.Errorf("simulated node %s: interface %q is not a virtual CAN (vcan) interface", c.Name, c.Interface)
+	}
+	if len(c.Responses) == 0 {
+		return fmt.Errorf("simulated node %s: at least one response is required", c.Name)
+	}
+
+	for i := range c.Responses {
+		response := &c.Responses[i]
+		var err error
+		if response.requestPrefix, err = parseHexBytes(response.RequestData); err != nil {
+			return fmt.Errorf("simulated node %s: invalid requestData %q: %w", c.Name, response.RequestData, err)
+		}
+		if response.response, err = parseHexBytes(response.ResponseData); err != nil {
+			return fmt.Errorf("simulated node %s: invalid responseData %q: %w", c.Name, response.ResponseData, err)
+		}
+		if len(response.response) > 8 {
+			return fmt.Errorf("simulated node %s: responseData %q exceeds 8 bytes", c.Name, response.ResponseData)
+		}
+		response.delay = 0
+		if response.Delay != "" {
+			if response.delay, err = time.ParseDuration(response.Delay); err != nil || response.delay < 0 {
+				return fmt.Errorf("simulated node %s: invalid delay %q", c.Name, response.Delay)
+			}
+		}
+	}
+	return nil
+}
+
+// SimulatedNodeStats reports the activity of a simulated node
+type SimulatedNodeStats struct {
+	Name           string `json:"name"`
+	Interface      string `json:"interface"`
+	Requests       uint64 `json:"requests"`       // Frames that matched a configured request
+	Responses      uint64 `json:"responses"`      // Responses sent
+	SendErrors     uint64 `json:"sendErrors"`     // Responses the sender rejected
+	DroppedReplies uint64 `json:"droppedReplies"` // Responses dropped because the queue was full
+}
+
+// SimulatedNode answers configured request IDs with canned responses. Requests are seen
+// through the message listener and responses go out through the MessageSender, so they
+// travel the same path as API traffic. Responses are sent in request order.
+type SimulatedNode struct {
+	config   SimulatedNodeConfig
+	sender   *MessageSender
+	logger   Logger
+	queue    chan *SimulatedResponse
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+
+	requests   atomic.Uint64
+	responses  atomic.Uint64
+	sendErrors atomic.Uint64
+	dropped    atomic.Uint64
+}
+
+// NewSimulatedNode creates a node from a normalized configuration
+func NewSimulatedNode(config SimulatedNodeConfig, sender *MessageSender, logger Logger) *SimulatedNode {
+	return &SimulatedNode{
+		config:   config,
+		sender:   sender,
+		logger:   logger,
+		queue:    make(chan *SimulatedResponse, simulatedNodeQueueSize),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start starts the response worker
+func (n *SimulatedNode) Start() {
+	n.logger.Printf("🤖 Simulated node %s answering %d request(s) on %s", n.config.Name, len(n.config.Responses), n.config.Interface)
+	n.wg.Add(1)
+	go n.respondLoop()
+}
+
+// Stop stops the response worker; queued responses are discarded
+func (n *SimulatedNode) Stop() {
+	close(n.stopChan)
+	n.wg.Wait()
+}
+
+// ObserveFrame queues the response to a matching request. It never blocks the listener.
+func (n *SimulatedNode) ObserveFrame(msg CanMessageLog) {
+	if msg.Interface != n.config.Interface || isErrorFrame(msg.ID) {
+		return
+	}
+
+	for i := range n.config.Responses {
+		response := &n.config.Responses[i]
+		if response.RequestID != msg.ID || !bytes.HasPrefix(msg.Data, response.requestPrefix) {
+			continue
+		}
+
+		n.requests.Add(1)
+		select {
+		case n.queue <- response:
+		default:
+			n.dropped.Add(1)
+		}
+		return
+	}
+}
+
+// GetStats returns the node's counters
+func (n *SimulatedNode) GetStats() SimulatedNodeStats {
+	return SimulatedNodeStats{
+		Name:           n.config.Name,
+		Interface:      n.config.Interface,
+		Requests:       n.requests.Load(),
+		Responses:      n.responses.Load(),
+		SendErrors:     n.sendErrors.Load(),
+		DroppedReplies: n.dropped.Load(),
+	}
+}
+
+// respondLoop sends queued responses after their configured delay
+func (n *SimulatedNode) respondLoop() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case <-n.stopChan:
+			return
+		case response := <-n.queue:
+			if response.delay > 0 {
+				select {
+				case <-n.stopChan:
+					return
+				case <-time.After(response.delay):
+				}
+			}
+
+			msg := CanMessage{
+				Interface: n.config.Interface,
+				ID:        response.ResponseID,
+				Data:      response.response,
+				client:    "simulator:" + n.config.Name,
+			}
+			if _, err := n.sender.SendCanMessage(msg); err != nil {
+				n.sendErrors.Add(1)
+				n.logger.Printf("⚠️ Simulated node %s failed to respond with ID=0x%X: %v", n.config.Name, response.ResponseID, err)
+				continue
+			}
+			n.responses.Add(1)
+		}
+	}
+}
+
+// NodeSimulator runs the configured simulated nodes and feeds them received frames
+type NodeSimulator struct {
+	nodes []*SimulatedNode
+}
+
+// NewNodeSimulator creates simulated nodes sending through sender
+func NewNodeSimulator(configs []SimulatedNodeConfig, sender *MessageSender, logger Logger) *NodeSimulator {
+	simulator := &NodeSimulator{}
+	for _, config := range configs {
+		simulator.nodes = append(simulator.nodes, NewSimulatedNode(config, sender, logger))
+	}
+	return simulator
+}
+
+// Start starts every node
+func (s *NodeSimulator) Start() {
+	for _, node := range s.nodes {
+		node.Start()
+	}
+}
+
+// Stop stops every node
+func (s *NodeSimulator) Stop() {
+	for _, node := range s.nodes {
+		node.Stop()
+	}
+}
+
+// ObserveFrame passes a received frame to every node
+func (s *NodeSimulator) ObserveFrame(msg CanMessageLog) {
+	for _, node := range s.nodes {
+		node.ObserveFrame(msg)
+	}
+}
+
+// GetStats returns the counters of every node
+func (s *NodeSimulator) GetStats() []SimulatedNodeStats {
+	stats := make([]SimulatedNodeStats, 0, len(s.nodes))
+	for _, node := range s.nodes {
+		stats = append(stats, node.GetStats())
+	}
+	return stats
+}
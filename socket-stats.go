@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/binary"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// socketDropWindow is how long after a frame dropped on a listening socket the listener
+// is reported as not keeping up
+const socketDropWindow = time.Minute
+
+// rxQueueOverflowOOBSize is the space of the SO_RXQ_OVFL control message, a 32-bit counter
+var rxQueueOverflowOOBSize = unix.CmsgSpace(4)
+
+// enableRxQueueOverflow asks the kernel to report, with the frames received, how many
+// frames it dropped on the socket because its receive buffer was full. These drops never
+// reach userspace, so no counter of ours sees them.
+func enableRxQueueOverflow(fd int) error {
+	return unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RXQ_OVFL, 1)
+}
+
+// parseRxQueueOverflow returns the socket's drop counter from a received message's
+// control data. The kernel only attaches it once a frame was dropped.
+func parseRxQueueOverflow(oob []byte) (uint32, bool) {
+	messages, err := unix.ParseSocketControlMessage(oob)
+	if err != nil {
+		return 0, false
+	}
+	for _, msg := range messages {
+		if msg.Header.Level == unix.SOL_SOCKET && msg.Header.Type == unix.SO_RXQ_OVFL && len(msg.Data) >= 4 {
+			return binary.NativeEndian.Uint32(msg.Data), true
+		}
+	}
+	return 0, false
+}
+
+// SocketStatsReport puts the kernel's counters of an interface next to what the listener
+// read from it. Frames the kernel dropped on the listening socket mean the listener is
+// not keeping up; drops counted on the interface happened before any socket saw them.
+type SocketStatsReport struct {
+	Interface string               `json:"interface"`
+	Kernel    KernelInterfaceStats `json:"kernel"` // Interface counters from sysfs
+
+	Listening      bool      `json:"listening"`
+	Received       uint64    `json:"received"`                 // Frames the listener read since its buffer was last cleared
+	SocketDrops    uint64    `json:"socketDrops"`              // Frames dropped on the listening socket, its receive buffer full
+	LastSocketDrop time.Time `json:"lastSocketDrop,omitempty"` // When the listener last learned of such a drop
+
+	InterfaceDrops uint64 `json:"interfaceDrops"` // rx_dropped and rx_over_errors of the interface
+	KeepingUp      bool   `json:"keepingUp"`      // No frame dropped on the listening socket within the last minute
+}
+
+// newSocketStatsReport combines the kernel counters of an interface with its listener statistics
+func newSocketStatsReport(ifName string, kernel KernelInterfaceStats, listener MessageBufferStats, listening bool) SocketStatsReport {
+	return SocketStatsReport{
+		Interface:      ifName,
+		Kernel:         kernel,
+		Listening:      listening,
+		Received:       listener.TotalReceived,
+		SocketDrops:    listener.SocketDrops,
+		LastSocketDrop: listener.LastSocketDrop,
+		InterfaceDrops: kernel.Counters["rx_dropped"] + kernel.Counters["rx_over_errors"],
+		KeepingUp:      listener.LastSocketDrop.IsZero() || time.Since(listener.LastSocketDrop) > socketDropWindow,
+	}
+}
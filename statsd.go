@@ -0,0 +1,347 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StatsD line formats
+const (
+	StatsDFormatPlain = "statsd"    // Labels are appended to the metric name, e.g. can_bridge_frames_sent.can0
+	StatsDFormatDog   = "dogstatsd" // Labels are tags, e.g. can_bridge_frames_sent:5|c|#interface:can0
+)
+
+var statsdFormats = []string{StatsDFormatPlain, StatsDFormatDog}
+
+// DefaultStatsDInterval is how often metrics are pushed to StatsD
+const DefaultStatsDInterval = 10 * time.Second
+
+// statsdMaxPacket keeps datagrams within an Ethernet MTU after IP and UDP headers
+const statsdMaxPacket = 1432
+
+// statsdWriteTimeout bounds a write to the UDP socket
+const statsdWriteTimeout = time.Second
+
+// StatsDConfig configures the push of metrics to a StatsD or DogStatsD agent
+type StatsDConfig struct {
+	Address  string        // host:port of the agent; empty disables the push
+	Interval time.Duration // Between pushes
+	Prefix   string        // Prepended to metric names with a dot; empty for none
+	Format   string        // statsd or dogstatsd
+	Instance string        // Service instance, tagged on every metric in the dogstatsd format
+}
+
+// StatsDStats reports the pushes to the StatsD agent
+type StatsDStats struct {
+	Address      string `json:"address"`
+	Pushes       uint64 `json:"pushes"`       // Intervals whose metrics were sent
+	Packets      uint64 `json:"packets"`      // Datagrams written
+	FailedWrites uint64 `json:"failedWrites"` // Datagrams lost to socket or resolution errors
+	LastError    string `json:"lastError,omitempty"`
+}
+
+// statsdLine is a metric line with the counter value it reports, committed once sent
+type statsdLine struct {
+	series  string // Counter series the value belongs to; empty for gauges
+	value   float64
+	text    string
+	counter bool
+}
+
+// StatsDEmitter pushes the metrics exposed on /metrics to a StatsD agent over UDP.
+// Counters are sent as the increase since the previous push, gauges as they are and
+// histograms as the increase of their count and sum. It runs on its own goroutine, and
+// socket errors are counted and logged but never reach the CAN path. A nil StatsDEmitter
+// is valid and does nothing.
+type StatsDEmitter struct {
+	config    StatsDConfig
+	collect   func(start, now time.Time) []otlpMetric
+	logger    Logger
+	startTime time.Time
+	conn      net.Conn           // nil until the address resolves; used by the push loop only
+	sent      map[string]float64 // Counter values the agent has seen, by series
+	failing   bool               // The current run of failures is logged already
+	stopChan  chan struct{}
+	wg        sync.WaitGroup
+	startOnce sync.Once
+	stopOnce  sync.Once
+
+	pushes       atomic.Uint64
+	packets      atomic.Uint64
+	failedWrites atomic.Uint64
+	lastError    atomic.Pointer[string]
+}
+
+// NewStatsDEmitter creates an emitter, or returns nil when no StatsD address is configured
+func NewStatsDEmitter(config StatsDConfig, logger Logger) *StatsDEmitter {
+	if config.Address == "" {
+		return nil
+	}
+	return &StatsDEmitter{
+		config:    config,
+		logger:    logger,
+		startTime: time.Now(),
+		sent:      make(map[string]float64),
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// SetMetricsSource sets the function collecting the metrics pushed every interval
+func (e *StatsDEmitter) SetMetricsSource(collect func(start, now time.Time) []otlpMetric) {
+	if e == nil {
+		return
+	}
+	e.collect = collect
+}
+
+// Start starts the push loop
+func (e *StatsDEmitter) Start() {
+	if e == nil {
+		return
+	}
+	e.startOnce.Do(func() {
+		e.logger.Printf("📊 StatsD export enabled (%s, %s format, every %v)", e.config.Address, e.config.Format, e.config.Interval)
+		e.wg.Add(1)
+		go e.pushLoop()
+	})
+}
+
+// Stop stops the push loop after a final push of the current metrics
+func (e *StatsDEmitter) Stop() {
+	if e == nil {
+		return
+	}
+	e.stopOnce.Do(func() {
+		close(e.stopChan)
+		e.wg.Wait()
+	})
+}
+
+// GetStats returns push counters
+func (e *StatsDEmitter) GetStats() StatsDStats {
+	if e == nil {
+		return StatsDStats{}
+	}
+	stats := StatsDStats{
+		Address:      e.config.Address,
+		Pushes:       e.pushes.Load(),
+		Packets:      e.packets.Load(),
+		FailedWrites: e.failedWrites.Load(),
+	}
+	if lastError := e.lastError.Load(); lastError != nil {
+		stats.LastError = *lastError
+	}
+	return stats
+}
+
+// pushLoop pushes the metrics every interval until stopped
+func (e *StatsDEmitter) pushLoop() {
+	defer e.wg.Done()
+	defer func() {
+		if e.conn != nil {
+			e.conn.Close()
+		}
+	}()
+
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-e.stopChan:
+			e.push()
+			return
+		case <-ticker.C:
+			e.push()
+		}
+	}
+}
+
+// push sends the current metrics in datagrams of whole lines. Counter increases lost
+// with a datagram are sent again with the next push.
+func (e *StatsDEmitter) push() {
+	if e.collect == nil {
+		return
+	}
+	if e.conn == nil {
+		// Resolved on every push until it succeeds, so an agent that is not up yet is found later
+		conn, err := net.Dial("udp", e.config.Address)
+		if err != nil {
+			e.fail(err)
+			return
+		}
+		e.conn = conn
+	}
+
+	lines := e.lines(e.collect(e.startTime, time.Now()))
+	var packet strings.Builder
+	var batch []statsdLine
+	flush := func() {
+		if packet.Len() == 0 {
+			return
+		}
+		if e.write([]byte(packet.String())) {
+			for _, line := range batch {
+				if line.counter {
+					e.sent[line.series] = line.value
+				}
+			}
+		}
+		packet.Reset()
+		batch = batch[:0]
+	}
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line.text) > statsdMaxPacket {
+			flush()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line.text)
+		batch = append(batch, line)
+	}
+	flush()
+	e.pushes.Add(1)
+}
+
+// write sends one datagram and reports whether it was written
+func (e *StatsDEmitter) write(packet []byte) bool {
+	e.conn.SetWriteDeadline(time.Now().Add(statsdWriteTimeout))
+	if _, err := e.conn.Write(packet); err != nil {
+		e.fail(err)
+		return false
+	}
+	e.packets.Add(1)
+	if e.failing {
+		e.failing = false
+		e.logger.Printf("✅ StatsD export to %s recovered", e.config.Address)
+	}
+	return true
+}
+
+// fail counts a lost datagram. Only the first failure of a run is logged, so an agent
+// that is down does not flood the log.
+func (e *StatsDEmitter) fail(err error) {
+	e.failedWrites.Add(1)
+	message := err.Error()
+	e.lastError.Store(&message)
+	if !e.failing {
+		e.failing = true
+		e.logger.Printf("⚠️ Warning: failed to push metrics to StatsD at %s: %v", e.config.Address, err)
+	}
+}
+
+// lines renders metrics as StatsD lines. Sums become counters of their increase since
+// the value the agent last received; a sum that went down, e.g. for an interface set up
+// again, counts from zero.
+func (e *StatsDEmitter) lines(metrics []otlpMetric) []statsdLine {
+	var lines []statsdLine
+	counter := func(name string, value float64, attributes []otlpKeyValue) {
+		series := e.series(name, attributes)
+		delta := value - e.sent[series]
+		if delta < 0 {
+			delta = value
+		}
+		lines = append(lines, statsdLine{series: series, value: value, counter: true,
+			text: e.line(name, attributes, delta, "c")})
+	}
+
+	for _, metric := range metrics {
+		switch {
+		case metric.Sum != nil:
+			for _, point := range metric.Sum.DataPoints {
+				counter(metric.Name, point.AsDouble, point.Attributes)
+			}
+		case metric.Gauge != nil:
+			for _, point := range metric.Gauge.DataPoints {
+				lines = append(lines, statsdLine{text: e.line(metric.Name, point.Attributes, point.AsDouble, "g")})
+			}
+		case metric.Histogram != nil:
+			for _, point := range metric.Histogram.DataPoints {
+				count, _ := strconv.ParseFloat(point.Count, 64)
+				counter(metric.Name+"_count", count, point.Attributes)
+				counter(metric.Name+"_sum", point.Sum, point.Attributes)
+			}
+		}
+	}
+	return lines
+}
+
+// line formats a value of a series with its StatsD type, c or g
+func (e *StatsDEmitter) line(name string, attributes []otlpKeyValue, value float64, kind string) string {
+	text := e.name(name, attributes) + ":" + strconv.FormatFloat(value, 'f', -1, 64) + "|" + kind
+	if tags := e.tags(attributes); tags != "" {
+		text += "|#" + tags
+	}
+	return text
+}
+
+// series identifies a series across pushes
+func (e *StatsDEmitter) series(name string, attributes []otlpKeyValue) string {
+	return e.name(name, attributes) + "|" + e.tags(attributes)
+}
+
+// name returns the metric name of a series: prefixed, and in the statsd format with the
+// values of its labels appended
+func (e *StatsDEmitter) name(name string, attributes []otlpKeyValue) string {
+	if e.config.Prefix != "" {
+		name = e.config.Prefix + "." + name
+	}
+	if e.config.Format == StatsDFormatPlain {
+		for _, attribute := range attributes {
+			name += "." + sanitizeStatsD(attributeValue(attribute), "/")
+		}
+	}
+	return name
+}
+
+// tags returns the DogStatsD tags of a series, empty in the statsd format
+func (e *StatsDEmitter) tags(attributes []otlpKeyValue) string {
+	if e.config.Format != StatsDFormatDog {
+		return ""
+	}
+	tags := make([]string, 0, len(attributes)+1)
+	if e.config.Instance != "" {
+		tags = append(tags, "instance:"+sanitizeStatsD(e.config.Instance, ""))
+	}
+	for _, attribute := range attributes {
+		tags = append(tags, attribute.Key+":"+sanitizeStatsD(attributeValue(attribute), ""))
+	}
+	return strings.Join(tags, ",")
+}
+
+// attributeValue returns the value of an attribute as text
+func attributeValue(attribute otlpKeyValue) string {
+	switch {
+	case attribute.Value.StringValue != nil:
+		return *attribute.Value.StringValue
+	case attribute.Value.IntValue != nil:
+		return *attribute.Value.IntValue
+	case attribute.Value.BoolValue != nil:
+		return strconv.FormatBool(*attribute.Value.BoolValue)
+	}
+	return ""
+}
+
+// sanitizeStatsD replaces the characters that delimit StatsD lines, names and tags, and
+// any extra ones, with underscores
+func sanitizeStatsD(value, extra string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(":|@#,. \n", r) || strings.ContainsRune(extra, r) {
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+// validateStatsDPrefix checks that a prefix holds no StatsD delimiters
+func validateStatsDPrefix(prefix string) error {
+	if strings.ContainsAny(prefix, ":|@#, \n") {
+		return fmt.Errorf("prefix %q must not contain ':', '|', '@', '#', ',' or spaces", prefix)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package main
+
+// StatusSchemaVersion is the version of the status payloads (schema_version field).
+// Fields may be added within a version; renaming or removing one requires a new version.
+const StatusSchemaVersion = 1
+
+// ServiceStatus is the overall service status, including setup and listener state
+type ServiceStatus struct {
+	SchemaVersion    int                    `json:"schema_version"`
+	BuildInfo                               // Version, commit and build date of the binary
+	Status           string                 `json:"status"` // "running" or "not_initialized"
+	Degraded         bool                   `json:"degraded"`
+	Uptime           string                 `json:"uptime,omitempty"`
+	ActiveInterfaces int                    `json:"activeInterfaces"`
+	WatchdogRunning  bool                   `json:"watchdogRunning"`
+	InterfaceAliases map[string]string      `json:"interfaceAliases,omitempty"` // Alias to interface
+	Setup            *SetupStatus           `json:"setup,omitempty"`
+	MessageListener  *MessageListenerStatus `json:"messageListener,omitempty"`
+}
+
+// SetupStatus reports the interface setup configuration and the state of each configured interface
+type SetupStatus struct {
+	Config          *InterfaceSetupConfig           `json:"config,omitempty"`
+	InterfaceStates map[string]SetupInterfaceStatus `json:"interfaceStates,omitempty"`
+}
+
+// SetupInterfaceStatus is the kernel state of an interface, or the error reading it
+type SetupInterfaceStatus struct {
+	*InterfaceState
+	Aliases        []string  `json:"aliases,omitempty"`        // Logical names from -interface-aliases
+	TxGapUs        int64     `json:"txGapUs,omitempty"`        // Minimum inter-frame gap of sends in microseconds
+	Error          string    `json:"error,omitempty"`          // Reading the interface state failed
+	SetupError     string    `json:"setupError,omitempty"`     // Startup setup failure, if any
+	SetupErrorCode ErrorCode `json:"setupErrorCode,omitempty"` // Code of the setup failure, e.g. PERMISSION_DENIED
+}
+
+// SetupReport is the setup outcome and current kernel state of a configured interface
+type SetupReport struct {
+	Interface  string          `json:"interface"`
+	Setup      *SetupResult    `json:"setup,omitempty"` // Last setup; absent when the interface was never set up
+	State      *InterfaceState `json:"state,omitempty"`
+	StateError string          `json:"stateError,omitempty"` // Reading the interface state failed
+}
+
+// MessageListenerStatus reports which interfaces are listened on and their receive buffers
+type MessageListenerStatus struct {
+	ListeningInterfaces []string                      `json:"listeningInterfaces"`
+	Statistics          map[string]MessageBufferStats `json:"statistics"`
+}
+
+// HealthSummary summarizes the health of all interfaces
+type HealthSummary struct {
+	SchemaVersion      int            `json:"schema_version"`
+	OverallHealth      string         `json:"overallHealth"` // "healthy", "warning", "critical"
+	TotalInterfaces    int            `json:"totalInterfaces"`
+	ActiveInterfaces   int            `json:"activeInterfaces"`
+	HealthDistribution map[string]int `json:"healthDistribution"`
+	SystemUptime       string         `json:"systemUptime"`
+	WatchdogActive     bool           `json:"watchdogActive"`
+}
+
+// InterfaceList lists configured, active and listening interfaces
+type InterfaceList struct {
+	SchemaVersion       int      `json:"schema_version"`
+	ConfiguredPorts     []string `json:"configuredPorts"`
+	ActivePorts         []string `json:"activePorts"`
+	TotalInterfaces     int      `json:"totalInterfaces"`
+	ActiveCount         int      `json:"activeCount"`
+	ListeningInterfaces []string `json:"listeningInterfaces,omitempty"`
+}
+
+// InterfaceDetail is the status of one interface together with its listener state
+type InterfaceDetail struct {
+	SchemaVersion     int                 `json:"schema_version"`
+	InterfaceStatus   InterfaceStatus     `json:"interfaceStatus"`
+	IsListening       bool                `json:"isListening"`
+	MessageStatistics *MessageBufferStats `json:"messageStatistics,omitempty"`
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// updateGolden rewrites the golden files from the current payloads, for a deliberate
+// change of the status contract: go test -run StatusGolden -update
+var updateGolden = flag.Bool("update", false, "rewrite testdata/*.golden")
+
+// fillStatus sets every field of v, exported or embedded, so each field of a payload
+// shows up in its golden file and renaming or removing one changes the file. Strings
+// are their JSON name and other values constants, so adding a field only adds lines.
+func fillStatus(v reflect.Value, name string) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(name)
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch {
+		case name == "schema_version":
+			v.SetInt(StatusSchemaVersion)
+		case v.Type() == durationType:
+			v.SetInt(int64(time.Second))
+		default:
+			v.SetInt(1)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fillStatus(v.Elem(), name)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fillStatus(v.Index(0), name)
+	case reflect.Map:
+		key, value := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+		fillStatus(key, "key")
+		fillStatus(value, name)
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(key, value)
+	case reflect.Struct:
+		if v.Type() == timeType {
+			v.Set(reflect.ValueOf(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldName, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if fieldName == "" {
+				fieldName = field.Name
+			}
+			fillStatus(v.Field(i), fieldName)
+		}
+	}
+}
+
+// filledStatus returns a T with every field set by fillStatus
+func filledStatus[T any]() T {
+	var value T
+	fillStatus(reflect.ValueOf(&value).Elem(), "")
+	return value
+}
+
+// checkGolden compares the indented JSON of payload with testdata/name.golden
+func checkGolden(t *testing.T, name string, payload interface{}) {
+	t.Helper()
+	got, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (create it with -update)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s changed. Renaming or removing a field needs a new StatusSchemaVersion; "+
+			"review the change and rewrite the file with -update.\ngot:\n%s", path, got)
+	}
+}
+
+func TestStatusGolden(t *testing.T) {
+	notInitialized := ServiceStatus{
+		SchemaVersion: StatusSchemaVersion,
+		BuildInfo:     BuildInfo{Version: "1.2.3"},
+		Status:        "not_initialized",
+	}
+
+	tests := []struct {
+		name    string
+		payload interface{}
+	}{
+		{"status", filledStatus[ServiceStatus]()},
+		{"status-not-initialized", notInitialized},
+		{"health", filledStatus[HealthSummary]()},
+		{"interfaces", filledStatus[InterfaceList]()},
+		{"interface", filledStatus[InterfaceDetail]()},
+		{"watchdog", filledStatus[WatchdogStatus]()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkGolden(t, tt.name, tt.payload)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// Client permission levels for mutual TLS
+const (
+	PermissionRead = "read" // Read-only: GET, HEAD and OPTIONS requests
+	PermissionFull = "full" // Every endpoint, including those that write to the bus
+)
+
+// ClientIdentity is the verified peer of a mutual TLS connection
+type ClientIdentity struct {
+	Name       string `json:"name"` // Certificate CN, or the SAN that matched a permission
+	Permission string `json:"permission"`
+}
+
+// buildTLSConfig creates the server TLS configuration. With a client CA, connections
+// without a certificate signed by it are rejected during the handshake.
+func buildTLSConfig(clientCAFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if clientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("client CA %s contains no PEM certificates", clientCAFile)
+	}
+
+	tlsConfig.ClientCAs = pool
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
+
+// clientIdentity maps a verified client certificate to a permission level. The CN is
+// looked up first, then DNS, email and URI SANs; certificates matching no entry are read-only.
+func clientIdentity(cert *x509.Certificate, permissions map[string]string) ClientIdentity {
+	names := []string{cert.Subject.CommonName}
+	names = append(names, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		names = append(names, uri.String())
+	}
+
+	for _, name := range names {
+		if permission, ok := permissions[name]; ok && name != "" {
+			return ClientIdentity{Name: name, Permission: permission}
+		}
+	}
+	return ClientIdentity{Name: cert.Subject.CommonName, Permission: PermissionRead}
+}
+
+// isWriteRequest reports whether a request method can change state
+func isWriteRequest(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
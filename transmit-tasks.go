@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Transmit task types
+const (
+	TaskSendUntil = "sendUntil" // POST /api/v1/can/until
+	TaskBenchmark = "benchmark" // POST /api/v1/benchmark/tx
+)
+
+// TransmitTask describes a transmit task running in the background of a request or the
+// service, such as a send-until run
+type TransmitTask struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	Interface   string    `json:"interface"`
+	Description string    `json:"description"`
+	StartedAt   time.Time `json:"startedAt"`
+	Runtime     string    `json:"runtime"`             // Time since StartedAt
+	Client      string    `json:"client,omitempty"`    // API key principal or client certificate identity
+	RequestID   string    `json:"requestId,omitempty"` // ID of the API request that started the task
+}
+
+// TransmitTasks keeps track of the running transmit tasks so they can be listed and
+// cancelled together. A nil TransmitTasks is valid and tracks nothing.
+type TransmitTasks struct {
+	mu     sync.Mutex
+	nextID uint64
+	tasks  map[string]*transmitTask
+}
+
+type transmitTask struct {
+	info   TransmitTask
+	cancel context.CancelFunc
+}
+
+// NewTransmitTasks creates an empty task list
+func NewTransmitTasks() *TransmitTasks {
+	return &TransmitTasks{tasks: make(map[string]*transmitTask)}
+}
+
+// Start registers a task and returns its ID, which the frames it sends carry as their
+// job. The task runs under the returned context, which Cancel ends, and calls the
+// returned function once it finished.
+func (t *TransmitTasks) Start(ctx context.Context, info TransmitTask) (context.Context, string, func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	if t == nil {
+		return ctx, "", cancel
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextID++
+	info.ID = fmt.Sprintf("task-%d", t.nextID)
+	info.StartedAt = time.Now()
+	t.tasks[info.ID] = &transmitTask{info: info, cancel: cancel}
+
+	return ctx, info.ID, func() {
+		cancel()
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.tasks, info.ID)
+	}
+}
+
+// List returns the running tasks, oldest first
+func (t *TransmitTasks) List() []TransmitTask {
+	if t == nil {
+		return []TransmitTask{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.snapshotLocked()
+}
+
+// Cancel cancels every running task and returns them. The tasks end on their own
+// shortly after, reporting that they were cancelled.
+func (t *TransmitTasks) Cancel() []TransmitTask {
+	if t == nil {
+		return []TransmitTask{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, task := range t.tasks {
+		task.cancel()
+	}
+	return t.snapshotLocked()
+}
+
+func (t *TransmitTasks) snapshotLocked() []TransmitTask {
+	now := time.Now()
+	tasks := make([]TransmitTask, 0, len(t.tasks))
+	for _, task := range t.tasks {
+		info := task.info
+		info.Runtime = now.Sub(info.StartedAt).Round(time.Millisecond).String()
+		tasks = append(tasks, info)
+	}
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].StartedAt.Before(tasks[j].StartedAt) })
+	return tasks
+}
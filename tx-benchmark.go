@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// TX benchmark limits and defaults
+const (
+	DefaultTxBenchmarkDuration = 5 * time.Second
+	MaxTxBenchmarkDuration     = time.Minute
+
+	txBenchmarkWriteMargin = 10 * time.Second // Added to the run for writing the response
+	txBenchmarkSamples     = 10000            // Write latencies kept for the percentiles
+)
+
+// What ended a TX benchmark
+const (
+	TxBenchmarkDuration  = "duration"
+	TxBenchmarkCancelled = "cancelled" // By DELETE /api/v1/tasks, shutdown or the client disconnecting
+	TxBenchmarkError     = "error"     // A send failed in a way further sends cannot recover from
+)
+
+// TxBenchmarkRequest sends a frame as fast as the interface takes it for a duration, to
+// measure the throughput the hardware achieves
+type TxBenchmarkRequest struct {
+	CanMessage
+	Duration    string `json:"duration,omitempty"`    // Of the run, e.g. 10s (default: 5s, at most 1m)
+	IgnoreTxGap bool   `json:"ignoreTxGap,omitempty"` // Write back to back, without the inter-frame gap configured for the interface
+
+	duration time.Duration
+}
+
+// TxBenchmarkResult is the outcome of a TX benchmark
+type TxBenchmarkResult struct {
+	Interface    string               `json:"interface"`
+	StoppedBy    string               `json:"stoppedBy"` // duration, cancelled or error
+	Sent         uint64               `json:"sent"`      // Frames written
+	Errors       uint64               `json:"errors"`    // Frames whose write failed
+	ErrorCodes   map[ErrorCode]uint64 `json:"errorCodes,omitempty"`
+	LastError    string               `json:"lastError,omitempty"`
+	Elapsed      string               `json:"elapsed"`
+	Rate         float64              `json:"rate"`         // Frames written per second
+	TxGapUs      int64                `json:"txGapUs"`      // Inter-frame gap the frames were paced with, 0 for none
+	WriteLatency LatencySummary       `json:"writeLatency"` // Of the write() calls of a frame, retries included
+	MaxLatency   string               `json:"maxLatency"`   // Slowest write
+}
+
+// latencySample keeps a uniform random sample of the latencies of a run (reservoir
+// sampling), so the percentiles of millions of writes take bounded memory. The send
+// latency histogram would not do: its buckets are too coarse for the microseconds a
+// write takes.
+type latencySample struct {
+	seen    uint64
+	max     time.Duration
+	samples []time.Duration
+}
+
+// observe records a latency
+func (s *latencySample) observe(latency time.Duration) {
+	s.seen++
+	s.max = max(s.max, latency)
+	if len(s.samples) < txBenchmarkSamples {
+		s.samples = append(s.samples, latency)
+	} else if i := rand.Uint64N(s.seen); i < txBenchmarkSamples {
+		s.samples[i] = latency
+	}
+}
+
+// summary returns the p50, p95 and p99 latencies of the sample
+func (s *latencySample) summary() LatencySummary {
+	slices.Sort(s.samples)
+	quantile := func(q float64) string {
+		if len(s.samples) == 0 {
+			return time.Duration(0).String()
+		}
+		return s.samples[min(int(q*float64(len(s.samples))), len(s.samples)-1)].String()
+	}
+	return LatencySummary{Count: s.seen, P50: quantile(0.50), P95: quantile(0.95), P99: quantile(0.99)}
+}
+
+// validateRequest checks the frame and the duration, and parses it
+func (req *TxBenchmarkRequest) validateRequest() []FieldError {
+	fields := req.CanMessage.validateRequest()
+	if req.DryRun {
+		fields = append(fields, FieldError{Field: "dryRun", Message: "a benchmark writes to the interface and cannot be a dry run"})
+	}
+
+	req.duration = DefaultTxBenchmarkDuration
+	if req.Duration != "" {
+		duration, err := time.ParseDuration(req.Duration)
+		switch {
+		case err != nil:
+			fields = append(fields, FieldError{Field: "duration", Message: fmt.Sprintf("must be a duration such as 10s, got %q", req.Duration)})
+		case duration <= 0 || duration > MaxTxBenchmarkDuration:
+			fields = append(fields, FieldError{Field: "duration", Message: fmt.Sprintf("must be positive and at most %v", MaxTxBenchmarkDuration)})
+		default:
+			req.duration = duration
+		}
+	}
+	return fields
+}
+
+// isTxBenchmarkRecoverable reports whether a benchmark keeps sending after a failed
+// write: a full TX buffer or a failed write are what it measures, anything else, from a
+// downed link to disabled transmission, fails every further frame too
+func isTxBenchmarkRecoverable(err error) bool {
+	return errors.Is(err, ErrTxBufferFull) || errors.Is(err, ErrSendFailed)
+}
+
+// TxBenchmark sends the frame of req through the TX worker of its interface, one frame
+// after the other, until the duration elapses, and reports the achieved rate and the
+// latency of the write calls. Frames are counted in the interface metrics like any other,
+// but neither logged one by one, audited nor waited for to be echoed. Unless
+// req.IgnoreTxGap is set they keep the inter-frame gap of the interface. ctx ends the
+// run early, as when the client disconnects; the run is listed among the transmit tasks,
+// which can cancel it.
+func (ms *MessageSender) TxBenchmark(ctx context.Context, req TxBenchmarkRequest) (*TxBenchmarkResult, error) {
+	if err := ms.drain.begin(); err != nil {
+		return nil, err
+	}
+	defer ms.drain.end()
+
+	ifName, err := ms.resolveInterface(req.Interface)
+	if err != nil {
+		return nil, err
+	}
+	req.Interface = ifName
+	if !ms.configProvider.ValidateInterface(ifName) {
+		return nil, tagError(ErrInterfaceNotFound, fmt.Errorf("CAN interface %s is not configured. Available interfaces: %v",
+			ifName, ms.configProvider.GetCanPorts()))
+	}
+	if err := ms.checkTxEnabled(ifName); err != nil {
+		return nil, err
+	}
+	if ms.configProvider.GetDryRun() {
+		return nil, tagError(ErrValidation, fmt.Errorf("dry-run mode is on: a benchmark needs to write to %s", ifName))
+	}
+	canIf, ok := ms.interfaceManager.GetInterface(ifName)
+	if !ok {
+		return nil, tagError(ErrInterfaceDown, fmt.Errorf("CAN interface %s not initialized", ifName))
+	}
+
+	gap := ms.configProvider.GetTxGap(ifName)
+	if req.IgnoreTxGap {
+		gap = 0
+		req.txGap = &gap
+	}
+	ctx, taskID, done := ms.tasks.Start(ctx, TransmitTask{
+		Type:        TaskBenchmark,
+		Interface:   ifName,
+		Description: fmt.Sprintf("0x%X as fast as possible for %v (inter-frame gap %v)", req.ID, req.duration, gap),
+		Client:      req.client,
+		RequestID:   req.requestID,
+	})
+	defer done()
+	req.jobID = taskID
+	req.ctx = ctx
+	if req.Priority == "" {
+		req.Priority = TxPriorityBulk // Other sends are not held up by the benchmark
+	}
+	frame := ms.buildFrame(req.CanMessage)
+	logger := ms.frameLogger(req.CanMessage)
+	logger.Infof("🏁 %s TX benchmark started: ID=0x%X for %v, inter-frame gap %v", ifName, req.ID, req.duration, gap)
+
+	result := &TxBenchmarkResult{Interface: ifName, StoppedBy: TxBenchmarkDuration, TxGapUs: gap.Microseconds()}
+	var latency latencySample
+	start := time.Now()
+	deadline := start.Add(req.duration)
+	for time.Now().Before(deadline) {
+		if ctx.Err() != nil {
+			result.StoppedBy = TxBenchmarkCancelled
+			break
+		}
+		if err := ms.checkTxEnabled(ifName); err != nil {
+			result.StoppedBy, result.LastError = TxBenchmarkError, err.Error()
+			break
+		}
+
+		msg := req.CanMessage
+		msg.acceptedAt = time.Now()
+		written, err := ms.queueFrame(canIf, msg, frame)
+		if err == nil && written.err != nil {
+			if isLinkGone(written.err) {
+				ms.watchdog.ReportLinkDown(ifName, written.err)
+			}
+			err = ms.classifyWriteError(ifName, written.err)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				result.StoppedBy = TxBenchmarkCancelled
+				break
+			}
+			result.Errors++
+			if result.ErrorCodes == nil {
+				result.ErrorCodes = make(map[ErrorCode]uint64)
+			}
+			result.ErrorCodes[errorCode(err)]++
+			result.LastError = err.Error()
+			if !isTxBenchmarkRecoverable(err) {
+				result.StoppedBy = TxBenchmarkError
+				break
+			}
+			continue
+		}
+		if written.pending != nil {
+			canIf.echo.cancel(written.pending)
+		}
+		result.Sent++
+		latency.observe(written.latency)
+	}
+
+	elapsed := time.Since(start)
+	result.Elapsed = elapsed.Round(time.Millisecond).String()
+	if elapsed > 0 {
+		result.Rate = float64(result.Sent) / elapsed.Seconds()
+	}
+	result.WriteLatency, result.MaxLatency = latency.summary(), latency.max.String()
+	logger.Infof("🏁 %s TX benchmark stopped by %s: %d sent, %d errors in %s (%.0f frames/s, write p50 %s, p99 %s)",
+		ifName, result.StoppedBy, result.Sent, result.Errors, result.Elapsed, result.Rate,
+		result.WriteLatency.P50, result.WriteLatency.P99)
+	return result, nil
+}
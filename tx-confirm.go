@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// txEchoPollInterval bounds how long the echo reader blocks, and so how long stop can take
+const txEchoPollInterval = 200 * time.Millisecond
+
+// pendingEcho is a sent frame waiting for its loopback echo
+type pendingEcho struct {
+	frame []byte
+	done  chan bool // Receives true when echoed, false when skipped by a later echo
+}
+
+// txEchoTracker correlates sent frames with the copies the kernel echoes back to the
+// sending socket once they have been transmitted (CAN_RAW_RECV_OWN_MSGS). Echoes are
+// delivered in transmit order and flagged with MSG_CONFIRM, which tells them apart from
+// other bus traffic arriving on the same socket.
+type txEchoTracker struct {
+	ifName         string
+	fd             int
+	socketProvider SocketProvider
+	logger         Logger
+	pending        []*pendingEcho
+	mutex          sync.Mutex
+	stopChan       chan struct{}
+	wg             sync.WaitGroup
+}
+
+// newTxEchoTracker enables own-message echo on the socket and starts the echo reader
+func newTxEchoTracker(ifName string, fd int, socketProvider SocketProvider, logger Logger) (*txEchoTracker, error) {
+	if err := socketProvider.EnableRecvOwnMsgs(fd); err != nil {
+		return nil, err
+	}
+
+	t := &txEchoTracker{
+		ifName:         ifName,
+		fd:             fd,
+		socketProvider: socketProvider,
+		logger:         logger,
+		stopChan:       make(chan struct{}),
+	}
+
+	t.wg.Add(1)
+	go t.readLoop()
+
+	return t, nil
+}
+
+// expect registers a frame that is about to be sent. It must be called before the
+// frame is written so a fast echo cannot be missed.
+func (t *txEchoTracker) expect(frame []byte) *pendingEcho {
+	p := &pendingEcho{
+		frame: append([]byte(nil), frame...),
+		done:  make(chan bool, 1),
+	}
+
+	t.mutex.Lock()
+	t.pending = append(t.pending, p)
+	t.mutex.Unlock()
+
+	return p
+}
+
+// cancel forgets a pending frame, e.g. after the write failed
+func (t *txEchoTracker) cancel(p *pendingEcho) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for i, candidate := range t.pending {
+		if candidate == p {
+			t.pending = append(t.pending[:i], t.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// wait blocks until the frame is echoed or the timeout expires
+func (t *txEchoTracker) wait(p *pendingEcho, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case confirmed := <-p.done:
+		return confirmed
+	case <-timer.C:
+		t.cancel(p)
+		return false
+	}
+}
+
+// stop stops the echo reader. The socket is owned and closed by the caller.
+func (t *txEchoTracker) stop() {
+	close(t.stopChan)
+	t.wg.Wait()
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for _, p := range t.pending {
+		p.done <- false
+	}
+	t.pending = nil
+}
+
+// readLoop reads echoed frames and resolves the matching pending sends
+func (t *txEchoTracker) readLoop() {
+	defer t.wg.Done()
+
+	buffer := make([]byte, 16) // Size of CAN frame
+
+	for {
+		select {
+		case <-t.stopChan:
+			return
+		default:
+		}
+
+		n, flags, err := t.socketProvider.Recv(t.fd, buffer, txEchoPollInterval)
+		if err != nil {
+			if err == unix.EAGAIN {
+				continue
+			}
+			select {
+			case <-t.stopChan:
+				return
+			default:
+			}
+			t.logger.Printf("⚠️ %s TX echo read error: %v", t.ifName, err)
+			time.Sleep(txEchoPollInterval)
+			continue
+		}
+
+		if n < 16 || flags&unix.MSG_CONFIRM == 0 {
+			continue // Not one of our own frames
+		}
+
+		t.resolve(buffer[:n])
+	}
+}
+
+// resolve confirms the oldest pending frame matching an echo. Frames queued before it
+// were skipped by the kernel and are reported as unconfirmed. Echoes that match nothing
+// (e.g. health check probes) are ignored.
+func (t *txEchoTracker) resolve(echo []byte) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for i, p := range t.pending {
+		if !bytes.Equal(p.frame, echo) {
+			continue
+		}
+		for _, skipped := range t.pending[:i] {
+			skipped.done <- false
+		}
+		p.done <- true
+		t.pending = t.pending[i+1:]
+		return
+	}
+}
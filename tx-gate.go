@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTxDisabled is returned for sends on an interface whose transmission is disabled
+var ErrTxDisabled = errors.New("transmission disabled")
+
+// TxGate records the interfaces transmission is disabled on at runtime. Unlike
+// listen-only mode it leaves the kernel configuration alone: frames are still
+// received, and the gate flips instantly. The state survives interface restarts.
+type TxGate struct {
+	mu       sync.RWMutex
+	disabled map[string]time.Time // Interface to when transmission was disabled
+}
+
+// NewTxGate creates a gate with transmission enabled on every interface
+func NewTxGate() *TxGate {
+	return &TxGate{disabled: make(map[string]time.Time)}
+}
+
+// SetEnabled enables or disables transmission on an interface. It reports whether the
+// state changed.
+func (g *TxGate) SetEnabled(ifName string, enabled bool) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	_, disabled := g.disabled[ifName]
+	if enabled == !disabled {
+		return false
+	}
+	if enabled {
+		delete(g.disabled, ifName)
+	} else {
+		g.disabled[ifName] = time.Now()
+	}
+	return true
+}
+
+// Enabled reports whether transmission is enabled on an interface, and when it was
+// disabled if not. A nil gate enables everything.
+func (g *TxGate) Enabled(ifName string) (bool, time.Time) {
+	if g == nil {
+		return true, time.Time{}
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	since, disabled := g.disabled[ifName]
+	return !disabled, since
+}
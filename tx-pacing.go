@@ -0,0 +1,82 @@
+package main
+
+import "time"
+
+// maxTxGap is the longest minimum inter-frame gap accepted
+const maxTxGap = time.Second
+
+// txPacing is the write schedule of an interface, kept by its TX worker under the
+// interface lock. A frame is due one gap after the slot of the previous frame, and never
+// sooner than one gap after the previous write returned. The wait is what is left of
+// that budget, so the time spent between writes counts towards the gap instead of adding
+// to it, and a late write moves the slot instead of shortening the next gap. time.Now
+// carries the monotonic clock, so wall clock steps do not disturb the schedule.
+type txPacing struct {
+	slot      time.Time // When the previous frame was due
+	onPace    bool      // The previous frame was written on the schedule
+	lastWrite time.Time // When the previous frame was written
+	runStart  time.Time // When the first frame of the current run of paced frames was written; zero outside a run
+	runFrames int       // Paced frames written in the run after its first
+}
+
+// wait sleeps until the next frame is due and reports whether the frame is written on
+// the schedule. A frame that is due already because the previous one was written late is
+// written at once and stays on the schedule, whose slot moves to now; one due already
+// because the interface was idle starts a new schedule. Either way the previous write is
+// at least one gap ago.
+func (p *txPacing) wait(gap time.Duration, metrics *InterfaceMetrics) bool {
+	now := time.Now()
+	if gap <= 0 || p.lastWrite.IsZero() {
+		p.slot, p.onPace = now, false
+		return false
+	}
+	due := p.slot.Add(gap)
+	if earliest := p.lastWrite.Add(gap); due.Before(earliest) {
+		due = earliest
+	}
+	switch wait := due.Sub(now); {
+	case wait > 0:
+		metrics.RecordPaced(wait)
+		time.Sleep(wait)
+		p.slot, p.onPace = due, true
+	case p.onPace && -wait < gap:
+		p.slot = now
+	default:
+		p.slot, p.onPace = now, false
+	}
+	return p.onPace
+}
+
+// written records a frame written at writtenAt and, for a frame written on the schedule,
+// the rate its run of paced frames achieved so far
+func (p *txPacing) written(writtenAt time.Time, paced bool, metrics *InterfaceMetrics) {
+	p.lastWrite = writtenAt
+	if !paced || p.runStart.IsZero() {
+		// A frame off the schedule starts a run of its own
+		p.runStart, p.runFrames = writtenAt, 0
+		return
+	}
+	p.runFrames++
+	if elapsed := writtenAt.Sub(p.runStart); elapsed > 0 {
+		metrics.RecordPacedRate(float64(p.runFrames) / elapsed.Seconds())
+	}
+}
+
+// failed records a frame that could not be written: it ends the current run, whose rate
+// would otherwise count the lost frame's slot without the frame
+func (p *txPacing) failed() {
+	p.onPace = false
+	p.runStart, p.runFrames = time.Time{}, 0
+}
+
+// pace waits for the minimum inter-frame gap of the interface, or for the gap of the
+// send when it sets its own, and reports whether the write is on the pacing schedule.
+// Runs on the TX worker, so the sends of every path, from batches to send-until and
+// sequence replays, are spaced one after another.
+func (ms *MessageSender) pace(canIf *CanInterface, msg CanMessage) bool {
+	gap := ms.configProvider.GetTxGap(canIf.Name)
+	if msg.txGap != nil {
+		gap = *msg.txGap
+	}
+	return canIf.pacing.wait(gap, canIf.Metrics)
+}
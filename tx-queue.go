@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTxQueueFull is returned for a send whose priority band of the TX queue is full
+var ErrTxQueueFull = errors.New("TX queue full")
+
+// TX queue priority bands, in the order strict dequeueing serves them
+const (
+	TxPriorityHigh   = "high"   // Safety-critical and interactive single sends
+	TxPriorityNormal = "normal" // Default of single sends
+	TxPriorityBulk   = "bulk"   // Default of batches, such as multi-interface sends
+)
+
+var txPriorities = [...]string{TxPriorityHigh, TxPriorityNormal, TxPriorityBulk}
+
+// TX queue dequeue policies
+const (
+	TxQueueStrict   = "strict"   // A band is served only while the bands above it are empty
+	TxQueueWeighted = "weighted" // Waiting bands are served in proportion to their weights
+)
+
+// Default TX queue weights and capacities, by band
+var (
+	DefaultTxQueueWeights  = map[string]int{TxPriorityHigh: 8, TxPriorityNormal: 4, TxPriorityBulk: 1}
+	DefaultTxQueueCapacity = map[string]int{TxPriorityHigh: 256, TxPriorityNormal: 1024, TxPriorityBulk: 4096}
+)
+
+// TxQueueConfig configures the order sends waiting for an interface are written in
+type TxQueueConfig struct {
+	Policy   string         `json:"policy"`   // strict or weighted
+	Weights  map[string]int `json:"weights"`  // Share of writes per band under the weighted policy
+	Capacity map[string]int `json:"capacity"` // Sends that may wait per band; more are rejected
+}
+
+// TxBandStats reports a priority band of the TX queue of an interface
+type TxBandStats struct {
+	Depth    int    `json:"depth"`    // Sends waiting in the band
+	Rejected uint64 `json:"rejected"` // Sends refused because the band was full
+}
+
+// txBand returns the index of a priority band; an empty priority is normal
+func txBand(priority string) int {
+	if i := slices.Index(txPriorities[:], priority); i >= 0 {
+		return i
+	}
+	return slices.Index(txPriorities[:], TxPriorityNormal)
+}
+
+// parseTxBandInts parses a value per band ("high=8,normal=4,bulk=1"). Bands left out keep
+// their defaults.
+func parseTxBandInts(value string, defaults map[string]int) (map[string]int, error) {
+	result := make(map[string]int, len(txPriorities))
+	for band, n := range defaults {
+		result[band] = n
+	}
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(value, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		band, raw, found := strings.Cut(entry, "=")
+		band = strings.TrimSpace(band)
+		if !found || band == "" {
+			return nil, fmt.Errorf("expected band=value, got %q", entry)
+		}
+		if !slices.Contains(txPriorities[:], band) {
+			return nil, fmt.Errorf("unknown band %q. Valid options: %v", band, txPriorities)
+		}
+		if seen[band] {
+			return nil, fmt.Errorf("band %s specified more than once", band)
+		}
+		seen[band] = true
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, fmt.Errorf("invalid number for %s: %w", band, err)
+		}
+		result[band] = n
+	}
+	return result, nil
+}
+
+// formatTxBandInts formats a value per band in band order, as parseTxBandInts reads it
+func formatTxBandInts(values map[string]int) string {
+	pairs := make([]string, 0, len(values))
+	for _, band := range txPriorities {
+		if n, ok := values[band]; ok {
+			pairs = append(pairs, band+"="+strconv.Itoa(n))
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+// txJobs recycles the sends queued for TX workers, with their done channels
+var txJobs = sync.Pool{New: func() any { return &txJob{done: make(chan txResult, 1)} }}
+
+// txJob is a send waiting in the TX queue of an interface for its worker to write it.
+// Its sender receives exactly one result or withdraws it, so it is reused afterwards.
+type txJob struct {
+	ctx   context.Context // Canceled when the sender stops waiting; the frame is then not written
+	msg   CanMessage
+	frame CanFrame
+	done  chan txResult // Receives the outcome of the write; buffered, so the worker never blocks
+}
+
+// txResult is the outcome of a write by the TX worker
+type txResult struct {
+	pending   *pendingEcho // Echo to wait for, nil without transmit confirmation
+	writtenAt time.Time
+	latency   time.Duration // Of the write() calls, retries included
+	err       error
+}
+
+// txQueue holds the sends waiting to be written to an interface. One worker goroutine
+// per interface owns the socket: it takes the sends one at a time, picked by band, and
+// writes them, so pacing and retries happen in one place and senders never contend for
+// the interface. Within a band sends are served in arrival order, so the frames of a
+// multi-frame transfer are never reordered. The zero value is an empty queue whose
+// worker starts with the first send.
+type txQueue struct {
+	mu       sync.Mutex
+	busy     bool                        // The worker is writing a send
+	closed   bool                        // The interface is closed; sends are refused
+	waiting  [len(txPriorities)][]*txJob // Per band, oldest first
+	credits  [len(txPriorities)]int      // Smooth weighted round robin state
+	rejected [len(txPriorities)]uint64
+	config   TxQueueConfig // Of the latest send, applied when the worker picks the next one
+
+	once    sync.Once
+	wake    chan struct{} // Signals the worker that a send was queued
+	quit    chan struct{} // Closed when the interface closes
+	stopped chan struct{} // Closed when the worker returned
+}
+
+// start starts the worker that writes the queued sends of an interface, unless it runs
+// already
+func (q *txQueue) start(ms *MessageSender, canIf *CanInterface) {
+	q.once.Do(func() {
+		q.wake = make(chan struct{}, 1)
+		q.quit = make(chan struct{})
+		q.stopped = make(chan struct{})
+		go q.run(ms, canIf)
+	})
+}
+
+// run writes the queued sends until the interface closes. A send whose sender stopped
+// waiting before its turn is skipped. Before parking on an empty queue the worker yields
+// once, so senders it just served can queue their next frames without waking it.
+func (q *txQueue) run(ms *MessageSender, canIf *CanInterface) {
+	defer close(q.stopped)
+	for {
+		job := q.take()
+		if job == nil {
+			runtime.Gosched()
+			if job = q.take(); job == nil {
+				select {
+				case <-q.wake:
+					continue
+				case <-q.quit:
+					return
+				}
+			}
+		}
+		if err := job.ctx.Err(); err != nil {
+			job.done <- txResult{err: err}
+		} else {
+			job.done <- ms.writeJob(canIf, job)
+		}
+	}
+}
+
+// push queues a send for the worker, or fails at once when its band already holds as
+// many waiting sends as its capacity or the interface closed. A send to an idle worker is
+// always admitted.
+func (q *txQueue) push(job *txJob, band int, config TxQueueConfig) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return tagError(ErrInterfaceDown, fmt.Errorf("CAN interface %s closed", job.msg.Interface))
+	}
+	q.config = config
+	if capacity := config.Capacity[txPriorities[band]]; q.busy && len(q.waiting[band]) >= capacity {
+		q.rejected[band]++
+		q.mu.Unlock()
+		return tagError(ErrTxQueueFull, fmt.Errorf("%s band of the %s TX queue is full (%d sends waiting)",
+			txPriorities[band], job.msg.Interface, capacity))
+	}
+	q.waiting[band] = append(q.waiting[band], job)
+	idle := !q.busy
+	q.busy = true // Until the worker finds the queue empty
+	q.mu.Unlock()
+
+	// A busy worker takes the send after its current one without being woken
+	if idle {
+		select {
+		case q.wake <- struct{}{}:
+		default: // The worker is signaled already
+		}
+	}
+	return nil
+}
+
+// take removes the send written next from the queue, nil when none waits. The worker
+// counts as busy until it finds the queue empty.
+func (q *txQueue) take() *txJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	band := q.nextLocked()
+	if band < 0 {
+		q.busy = false
+		return nil
+	}
+	q.busy = true
+	job := q.waiting[band][0]
+	q.waiting[band][0] = nil
+	q.waiting[band] = q.waiting[band][1:]
+	return job
+}
+
+// withdraw removes a send its sender stopped waiting for. It reports false when the
+// worker took the send already, whose outcome then arrives on its done channel.
+func (q *txQueue) withdraw(job *txJob, band int) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if i := slices.Index(q.waiting[band], job); i >= 0 {
+		q.waiting[band] = slices.Delete(q.waiting[band], i, i+1)
+		return true
+	}
+	return false
+}
+
+// stop refuses further sends, fails the waiting ones and waits for the worker to finish
+// the send it is writing. Called when the interface closes, before its socket is.
+func (q *txQueue) stop() {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return
+	}
+	q.closed = true
+	for band := range q.waiting {
+		for _, job := range q.waiting[band] {
+			job.done <- txResult{err: tagError(ErrInterfaceDown, fmt.Errorf("CAN interface %s closed", job.msg.Interface))}
+		}
+		q.waiting[band] = nil
+	}
+	q.mu.Unlock()
+
+	q.once.Do(func() {}) // A worker not started yet never starts
+	if q.quit != nil {
+		close(q.quit)
+		<-q.stopped
+	}
+}
+
+// nextLocked returns the band served next, -1 when no send waits. The weighted policy
+// uses smooth weighted round robin: every waiting band gains its weight, the band with
+// the most credit is served and pays the total, so bands interleave instead of being
+// served in runs.
+func (q *txQueue) nextLocked() int {
+	if q.config.Policy != TxQueueWeighted {
+		for band := range q.waiting {
+			if len(q.waiting[band]) > 0 {
+				return band
+			}
+		}
+		return -1
+	}
+
+	next, total := -1, 0
+	for band := range q.waiting {
+		if len(q.waiting[band]) == 0 {
+			q.credits[band] = 0
+			continue
+		}
+		weight := max(q.config.Weights[txPriorities[band]], 1)
+		q.credits[band] += weight
+		total += weight
+		if next < 0 || q.credits[band] > q.credits[next] {
+			next = band
+		}
+	}
+	if next >= 0 {
+		q.credits[next] -= total
+	}
+	return next
+}
+
+// stats returns the waiting and rejected sends per band
+func (q *txQueue) stats() map[string]TxBandStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	stats := make(map[string]TxBandStats, len(txPriorities))
+	for band, name := range txPriorities {
+		stats[name] = TxBandStats{Depth: len(q.waiting[band]), Rejected: q.rejected[band]}
+	}
+	return stats
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// benchSocket is a CAN socket whose writes always succeed after writeCost, spent
+// spinning as the kernel spends it copying the frame, not sleeping
+type benchSocket struct {
+	writeCost time.Duration
+}
+
+func (s *benchSocket) CreateSocket() (int, error)                         { return 3, nil }
+func (s *benchSocket) GetIfIndex(fd int, ifname string) (int, error)      { return 1, nil }
+func (s *benchSocket) Bind(fd int, addr *unix.SockaddrCAN) error          { return nil }
+func (s *benchSocket) EnableRecvOwnMsgs(fd int) error                     { return nil }
+func (s *benchSocket) SetReceiveBuffer(fd int, size int) (int, error)     { return size, nil }
+func (s *benchSocket) WaitWritable(fd int, _ time.Duration) (bool, error) { return true, nil }
+func (s *benchSocket) Close(fd int) error                                 { return nil }
+
+func (s *benchSocket) Recv(fd int, buf []byte, timeout time.Duration) (int, int, error) {
+	return 0, 0, unix.EAGAIN
+}
+
+func (s *benchSocket) SendTo(fd int, buf []byte, addr *unix.SockaddrCAN) error {
+	for start := time.Now(); time.Since(start) < s.writeCost; {
+	}
+	return nil
+}
+
+func (s *benchSocket) SendToNoWait(fd int, buf []byte, addr *unix.SockaddrCAN) error {
+	return s.SendTo(fd, buf, addr)
+}
+
+// newTestSender returns a sender with can0 open on socket
+func newTestSender(tb testing.TB, socket SocketProvider) (*MessageSender, *CanInterface) {
+	tb.Helper()
+	cp := NewConfigParser()
+	config, err := cp.parseConfig(flag.NewFlagSet("can-bridge", flag.ContinueOnError), []string{"-can-ports", "can0"})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	if err := cp.ValidateConfig(config); err != nil {
+		tb.Fatal(err)
+	}
+	provider, logger := NewDefaultConfigProvider(config), NewLogger(nil)
+	interfaceManager := NewInterfaceManager(provider, socket, logger)
+	canIf := NewCanInterface("can0", 3, &unix.SockaddrCAN{Ifindex: 1})
+	interfaceManager.interfaces["can0"] = canIf
+	tb.Cleanup(canIf.txQueue.stop)
+	return NewMessageSender(interfaceManager, provider, socket, logger), canIf
+}
+
+// benchmarkTxQueue sends frames from b.RunParallel goroutines with send, for writes of
+// several costs and several senders per CPU
+func benchmarkTxQueue(b *testing.B, send func(ms *MessageSender, canIf *CanInterface, msg CanMessage, frame CanFrame) error) {
+	for _, writeCost := range []time.Duration{0, 20 * time.Microsecond} {
+		for _, senders := range []int{1, 8, 64} {
+			b.Run(fmt.Sprintf("write=%v/senders=%dxCPU", writeCost, senders), func(b *testing.B) {
+				ms, canIf := newTestSender(b, &benchSocket{writeCost: writeCost})
+				msg := CanMessage{Interface: "can0", ID: 0x123, Data: []byte{1, 2, 3, 4, 5, 6, 7, 8}}
+				frame := ms.buildFrame(msg)
+
+				b.SetParallelism(senders)
+				b.ReportAllocs()
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					for pb.Next() {
+						msg := msg
+						msg.acceptedAt = time.Now()
+						if err := send(ms, canIf, msg, frame); err != nil {
+							b.Error(err)
+							return
+						}
+					}
+				})
+				b.StopTimer()
+				b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "frames/s")
+			})
+		}
+	}
+}
+
+// BenchmarkTxQueueWorker sends through the TX worker of the interface, as every send does
+func BenchmarkTxQueueWorker(b *testing.B) {
+	benchmarkTxQueue(b, func(ms *MessageSender, canIf *CanInterface, msg CanMessage, frame CanFrame) error {
+		result, err := ms.queueFrame(canIf, msg, frame)
+		if err != nil {
+			return err
+		}
+		return result.err
+	})
+}
+
+// BenchmarkTxQueueLocked writes from the sending goroutine under the interface lock, as
+// sends did before the TX worker: senders contend for the lock instead of queueing
+func BenchmarkTxQueueLocked(b *testing.B) {
+	benchmarkTxQueue(b, func(ms *MessageSender, canIf *CanInterface, msg CanMessage, frame CanFrame) error {
+		canIf.Metrics.EnterTxQueue()
+		defer canIf.Metrics.LeaveTxQueue()
+		job := txJob{msg: msg, frame: frame}
+		return ms.writeJob(canIf, &job).err
+	})
+}
+
+// probeSocket records the IDs of the frames written. A write of blockID waits until
+// release is closed, and the first write of a health probe finds the TX buffer full.
+type probeSocket struct {
+	benchSocket
+	blockID  uint32
+	blocking chan struct{} // Closed once the write of blockID started
+	release  chan struct{}
+
+	mu           sync.Mutex
+	written      []uint32
+	probeRefused bool
+}
+
+func (s *probeSocket) SendTo(fd int, buf []byte, addr *unix.SockaddrCAN) error {
+	id := binary.NativeEndian.Uint32(buf)
+	if id == s.blockID {
+		close(s.blocking)
+		<-s.release
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id == 0 && !s.probeRefused {
+		s.probeRefused = true
+		return unix.ENOBUFS
+	}
+	s.written = append(s.written, id)
+	return nil
+}
+
+func (s *probeSocket) writtenIDs() []uint32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.written)
+}
+
+// waitForDepth waits until a band of the TX queue of canIf holds depth sends
+func waitForDepth(t *testing.T, canIf *CanInterface, band string, depth int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); canIf.txQueue.stats()[band].Depth != depth; {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s band holds %d sends, want %d", band, canIf.txQueue.stats()[band].Depth, depth)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestHealthProbeThroughTxWorker(t *testing.T) {
+	socket := &probeSocket{blockID: 0x100, blocking: make(chan struct{}), release: make(chan struct{})}
+	ms, canIf := newTestSender(t, socket)
+
+	// The worker is busy writing one bulk frame while three more wait
+	var wg sync.WaitGroup
+	send := func(id uint32) {
+		defer wg.Done()
+		msg := CanMessage{Interface: "can0", ID: id, Data: FrameData{1}, Priority: TxPriorityBulk}
+		if result, err := ms.queueFrame(canIf, msg, ms.buildFrame(msg)); err != nil || result.err != nil {
+			t.Errorf("send of 0x%X: %v %v", id, err, result.err)
+		}
+	}
+	wg.Add(1)
+	go send(0x100)
+	<-socket.blocking
+	for id := uint32(0x101); id <= 0x103; id++ {
+		wg.Add(1)
+		go send(id)
+		waitForDepth(t, canIf, TxPriorityBulk, int(id-0x100))
+	}
+
+	// The probe waits for the worker in its own band rather than for the interface lock
+	healthy := make(chan bool)
+	go func() { healthy <- ms.interfaceManager.CheckHealth("can0") }()
+	waitForDepth(t, canIf, healthProbePriority, 1)
+	if written := socket.writtenIDs(); len(written) > 0 {
+		t.Errorf("written while the worker is busy: %X", written)
+	}
+
+	// Once the worker is free it writes the probe before the bulk frames, and retries it
+	// when the TX buffer is full
+	close(socket.release)
+	if !<-healthy {
+		t.Error("CheckHealth() = false after a full TX buffer, want true")
+	}
+	wg.Wait()
+	if written, want := socket.writtenIDs(), []uint32{0x100, 0x000, 0x101, 0x102, 0x103}; !slices.Equal(written, want) {
+		t.Errorf("written %X, want %X", written, want)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Defaults of the retries of writes the kernel has no room for
+const (
+	DefaultTxRetryAttempts = 4 // The first write and three retries
+	DefaultTxRetryDeadline = 20 * time.Millisecond
+)
+
+// txRetryBaseDelay is the wait before the first retry; it doubles with every further one
+const txRetryBaseDelay = time.Millisecond
+
+// TxRetryConfig configures how writes refused for lack of room are retried
+type TxRetryConfig struct {
+	MaxAttempts int           // Writes of a frame, the first included; 1 disables retrying
+	Deadline    time.Duration // Total time a frame may spend retrying, 0 for no limit
+	Poll        bool          // Writes do not block; a full send buffer is waited out with poll()
+}
+
+// isTxBufferFull reports whether a write was refused for lack of room and is worth
+// retrying: ENOBUFS when the interface's TX queue is full, EAGAIN when the socket's send
+// buffer is
+func isTxBufferFull(err error) bool {
+	return errors.Is(err, unix.ENOBUFS) || errors.Is(err, unix.EAGAIN)
+}
+
+// isLinkGone reports whether a write failed because the device is gone or its link is
+// down. Retrying cannot help; the interface needs recovery.
+func isLinkGone(err error) bool {
+	return errors.Is(err, unix.ENETDOWN) || errors.Is(err, unix.ENODEV) || errors.Is(err, unix.ENXIO)
+}
+
+// sendWithRetry writes a frame, retrying while the kernel has no room for it. Retries back
+// off exponentially from 1ms until the attempts or the deadline run out; any other error
+// is returned at once. Runs on the TX worker of the interface, holding its lock.
+func (ms *MessageSender) sendWithRetry(canIf *CanInterface, frame CanFrame) error {
+	config := ms.configProvider.GetTxRetry()
+	data := frameBytes(&frame)
+	start := time.Now()
+	delay := txRetryBaseDelay
+
+	for attempt := 1; ; attempt++ {
+		var err error
+		if config.Poll {
+			err = ms.socketProvider.SendToNoWait(canIf.FD, data, canIf.Addr)
+		} else {
+			err = ms.socketProvider.SendTo(canIf.FD, data, canIf.Addr)
+		}
+		if !isTxBufferFull(err) {
+			if attempt > 1 {
+				canIf.Metrics.RecordRetryTime(time.Since(start))
+			}
+			return err
+		}
+		canIf.Metrics.RecordBufferFull()
+
+		wait := delay
+		if config.Deadline > 0 {
+			wait = min(wait, config.Deadline-time.Since(start))
+		}
+		if attempt >= config.MaxAttempts || wait <= 0 {
+			if attempt > 1 {
+				canIf.Metrics.RecordRetryTime(time.Since(start))
+			}
+			canIf.Metrics.RecordRetriesExhausted()
+			return err
+		}
+		canIf.Metrics.RecordRetry()
+		ms.waitTxRoom(canIf, err, wait, config.Poll)
+		delay *= 2
+	}
+}
+
+// waitTxRoom waits before retrying a write. With polling, a write refused because the
+// send buffer was full (EAGAIN) is retried as soon as the socket is writable again.
+// ENOBUFS always waits the whole delay: it comes from the interface's TX queue, which
+// poll() does not see.
+func (ms *MessageSender) waitTxRoom(canIf *CanInterface, err error, wait time.Duration, poll bool) {
+	if poll && errors.Is(err, unix.EAGAIN) {
+		if _, pollErr := ms.socketProvider.WaitWritable(canIf.FD, wait); pollErr == nil {
+			return
+		}
+	}
+	time.Sleep(wait)
+}
@@ -1,14 +1,21 @@
 package main
 
 import (
+	"context"
+	"math"
 	"sync"
 	"time"
+	"unsafe"
 
 	"golang.org/x/sys/unix"
 )
 
 const IFNAMSIZ = 16
 
+// classicCANMaxDataLength is the payload limit of a classic CAN frame. CAN FD (64 bytes)
+// is not supported yet, so every interface runs in classic mode.
+const classicCANMaxDataLength = 8
+
 // CAN frame structure
 type CanFrame struct {
 	ID     uint32
@@ -17,6 +24,11 @@ type CanFrame struct {
 	Data   [8]byte
 }
 
+// frameBytes returns the raw wire representation of a CAN frame
+func frameBytes(frame *CanFrame) []byte {
+	return (*[16]byte)(unsafe.Pointer(frame))[:]
+}
+
 // ioctl interface structure
 type ifreq struct {
 	Name  [IFNAMSIZ]byte
@@ -26,10 +38,36 @@ type ifreq struct {
 
 // Request structures
 type CanMessage struct {
-	Interface string `json:"interface" binding:"required"`
-	ID        uint32 `json:"id" binding:"required"`
-	Data      []byte `json:"data" binding:"required,min=1,max=8"`
-	Length    uint8  `json:"length,omitempty"`
+	Interface string    `json:"interface" binding:"omitempty,max=15"`                               // Optional when a default interface applies
+	ID        uint32    `json:"id" binding:"required,max=536870911"`                                // Up to 29 bits
+	Data      FrameData `json:"data"`                                                               // Hex string, base64 string or array of byte values
+	Encoding  string    `json:"encoding,omitempty" binding:"omitempty,oneof=auto hex base64 bytes"` // Encoding of data; detected when empty
+	DataHex   string    `json:"dataHex,omitempty"`                                                  // Payload as hex bytes, e.g. "02 10 01"; alternative to data
+	Length    uint8     `json:"length,omitempty"`                                                   // DLC; must match the data length when set, requested DLC of a remote frame
+	RTR       bool      `json:"rtr,omitempty"`                                                      // Send a remote transmission request instead of data
+	DryRun    bool      `json:"dryRun,omitempty"`
+	OneShot   bool      `json:"oneShot,omitempty"`                                             // Ask for no retransmission; the interface controller must run in one-shot mode
+	Priority  string    `json:"priority,omitempty" binding:"omitempty,oneof=high normal bulk"` // TX queue band; normal when empty, bulk for multi-interface sends
+
+	ctx        context.Context // Of the API request; a send still queued when it ends is withdrawn
+	acceptedAt time.Time       // When the request was accepted, for send latency measurement
+	trace      spanContext     // Span of the API request, parent of the send span
+	requestID  string          // ID of the API request, for correlating logs and audit records
+	client     string          // Authenticated identity of the sender, for the send audit log
+	remoteAddr string          // Client address of the API request
+	jobID      string          // Transmit task or sequence the frame was sent by, for correlating its frames
+	txGap      *time.Duration  // Inter-frame gap of a batch, instead of that of the interface; nil keeps it
+}
+
+// SendResult describes the outcome of a send request
+type SendResult struct {
+	CanMessage
+	DryRun         bool      `json:"dryRun"`
+	Confirmed      bool      `json:"confirmed"`                // Frame was echoed back after transmission (false when confirmation is disabled)
+	OneShotApplied *bool     `json:"oneShotApplied,omitempty"` // With oneShot: whether the controller was in one-shot mode
+	SentAt         time.Time `json:"sentAt,omitempty"`         // When write() returned
+	Frame          []string  `json:"frame"`                    // Hexadecimal representation of the raw CAN frame
+	RequestID      string    `json:"requestId,omitempty"`      // ID of the API request that sent the frame
 }
 
 // API response structure
@@ -38,19 +76,28 @@ type ApiResponse struct {
 	Message string      `json:"message,omitempty"`
 	Error   string      `json:"error,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
+
+	// Error envelope, set on errors only
+	Code      ErrorCode   `json:"code,omitempty"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
 }
 
 // Metrics structure for better testing
 type InterfaceMetrics struct {
-	TotalSent      uint64
-	TotalErrors    uint64
-	LastSendTime   time.Time
-	StartTime      time.Time
-	LastErrorTime  time.Time
-	LastErrorMsg   string
-	AvgLatency     time.Duration
-	MessageLatency []time.Duration
-	mutex          sync.RWMutex
+	TotalSent        uint64
+	TotalErrors      uint64
+	LastSendTime     time.Time
+	StartTime        time.Time
+	LastErrorTime    time.Time
+	LastErrorMsg     string
+	AvgLatency       time.Duration
+	MessageLatency   []time.Duration
+	TotalConfirmed   uint64
+	TotalUnconfirmed uint64
+	SendLatency      LatencyHistogram // Request acceptance to successful write()
+	send             sendCounters
+	mutex            sync.RWMutex
 }
 
 // NewInterfaceMetrics creates a new metrics instance
@@ -95,6 +142,60 @@ func (m *InterfaceMetrics) RecordError(err error) {
 	m.LastErrorMsg = err.Error()
 }
 
+// RecordConfirmation updates transmit confirmation counters
+func (m *InterfaceMetrics) RecordConfirmation(confirmed bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if confirmed {
+		m.TotalConfirmed++
+	} else {
+		m.TotalUnconfirmed++
+	}
+}
+
+// RecordBufferFull counts a write rejected with ENOBUFS or EAGAIN
+func (m *InterfaceMetrics) RecordBufferFull() {
+	m.send.bufferFull.Add(1)
+}
+
+// RecordRetry counts a write retried after ENOBUFS or EAGAIN
+func (m *InterfaceMetrics) RecordRetry() {
+	m.send.retries.Add(1)
+}
+
+// RecordRetriesExhausted counts a frame given up after its retries ran out
+func (m *InterfaceMetrics) RecordRetriesExhausted() {
+	m.send.retriesExhausted.Add(1)
+}
+
+// RecordRetryTime adds the time a frame spent retrying
+func (m *InterfaceMetrics) RecordRetryTime(spent time.Duration) {
+	m.send.retryTime.Add(int64(spent))
+}
+
+// RecordPaced counts a write delayed by wait to keep the minimum inter-frame gap
+func (m *InterfaceMetrics) RecordPaced(wait time.Duration) {
+	m.send.paced.Add(1)
+	m.send.pacedDelay.Add(int64(wait))
+}
+
+// RecordPacedRate records the rate, in frames per second, the current run of paced
+// writes achieved
+func (m *InterfaceMetrics) RecordPacedRate(rate float64) {
+	m.send.pacedRate.Store(math.Float64bits(rate))
+}
+
+// EnterTxQueue records a send waiting for the interface socket
+func (m *InterfaceMetrics) EnterTxQueue() {
+	m.send.enqueue()
+}
+
+// LeaveTxQueue records that a send finished with the interface socket
+func (m *InterfaceMetrics) LeaveTxQueue() {
+	m.send.dequeue()
+}
+
 // GetStats returns a snapshot of current metrics
 func (m *InterfaceMetrics) GetStats() InterfaceStats {
 	m.mutex.RLock()
@@ -109,6 +210,20 @@ func (m *InterfaceMetrics) GetStats() InterfaceStats {
 		LastErrorMsg:  m.LastErrorMsg,
 		AvgLatency:    m.AvgLatency,
 		Uptime:        time.Since(m.StartTime),
+
+		TotalConfirmed:   m.TotalConfirmed,
+		TotalUnconfirmed: m.TotalUnconfirmed,
+
+		SendLatency:      m.SendLatency.Snapshot(),
+		TxQueueDepth:     m.send.queueDepth.Load(),
+		MaxTxQueueDepth:  m.send.maxQueueDepth.Load(),
+		BufferFullErrors: m.send.bufferFull.Load(),
+		SendRetries:      m.send.retries.Load(),
+		RetriesExhausted: m.send.retriesExhausted.Load(),
+		RetryTime:        time.Duration(m.send.retryTime.Load()),
+		PacedSends:       m.send.paced.Load(),
+		PacingDelay:      time.Duration(m.send.pacedDelay.Load()),
+		PacedRate:        math.Float64frombits(m.send.pacedRate.Load()),
 	}
 }
 
@@ -122,6 +237,21 @@ type InterfaceStats struct {
 	LastErrorMsg  string
 	AvgLatency    time.Duration
 	Uptime        time.Duration
+
+	TotalConfirmed   uint64
+	TotalUnconfirmed uint64
+
+	SendLatency      LatencyHistogramSnapshot
+	TxQueueDepth     int64
+	MaxTxQueueDepth  int64
+	TxQueueBands     map[string]TxBandStats // Waiting and rejected sends per priority band
+	BufferFullErrors uint64
+	SendRetries      uint64
+	RetriesExhausted uint64        // Frames whose retries ran out
+	RetryTime        time.Duration // Total time frames spent retrying
+	PacedSends       uint64
+	PacingDelay      time.Duration // Total time writes waited for the inter-frame gap
+	PacedRate        float64       // Frames per second achieved by the latest run of paced writes
 }
 
 // SuccessRate calculates the success rate percentage
@@ -138,7 +268,11 @@ type CanInterface struct {
 	FD      int
 	Addr    *unix.SockaddrCAN
 	Metrics *InterfaceMetrics
+	echo    *txEchoTracker // Nil when transmit confirmation is disabled or unavailable
+	txQueue txQueue        // Sends waiting for the TX worker, by priority band
 	mutex   sync.Mutex
+
+	pacing txPacing // Schedule of writes for the inter-frame gap; guarded by mutex
 }
 
 // NewCanInterface creates a new CAN interface instance
@@ -163,5 +297,7 @@ func (c *CanInterface) Unlock() {
 
 // GetStats returns interface statistics
 func (c *CanInterface) GetStats() InterfaceStats {
-	return c.Metrics.GetStats()
+	stats := c.Metrics.GetStats()
+	stats.TxQueueBands = c.txQueue.stats()
+	return stats
 }
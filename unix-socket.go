@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultUnixSocketMode lets the owner and its group use the API socket
+const DefaultUnixSocketMode os.FileMode = 0660
+
+// unixSocketPrincipal is the principal of requests on a trusted Unix socket. Reaching
+// the socket already requires its file permissions, so they get every role.
+var unixSocketPrincipal = APIKey{Name: "unix-socket", Role: RoleAdmin}
+
+// unixSocketContextKey marks connections accepted on the Unix socket; the value tells
+// whether the socket is trusted
+type unixSocketContextKey struct{}
+
+// unixSocketConnContext returns the ConnContext of the Unix socket server
+func unixSocketConnContext(trusted bool) func(ctx context.Context, conn net.Conn) context.Context {
+	return func(ctx context.Context, conn net.Conn) context.Context {
+		return context.WithValue(ctx, unixSocketContextKey{}, trusted)
+	}
+}
+
+// fromUnixSocket reports whether a request arrived on the Unix socket
+func fromUnixSocket(r *http.Request) bool {
+	_, ok := r.Context().Value(unixSocketContextKey{}).(bool)
+	return ok
+}
+
+// fromTrustedUnixSocket reports whether a request arrived on a Unix socket whose
+// clients need no API key or client certificate
+func fromTrustedUnixSocket(r *http.Request) bool {
+	trusted, _ := r.Context().Value(unixSocketContextKey{}).(bool)
+	return trusted
+}
+
+// listenUnixSocket creates the API socket at path with the given mode and, when owner
+// is set, ownership. A socket left behind by an unclean shutdown is replaced; one that
+// still accepts connections is not.
+func listenUnixSocket(path string, mode os.FileMode, owner string) (net.Listener, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", path, err)
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set mode %04o on %s: %w", mode, path, err)
+	}
+	if owner != "" {
+		uid, gid, err := parseSocketOwner(owner)
+		if err == nil {
+			err = os.Chown(path, uid, gid)
+		}
+		if err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("failed to set owner %q on %s: %w", owner, path, err)
+		}
+	}
+	return listener, nil
+}
+
+// removeStaleSocket unlinks a socket file nothing listens on anymore. Files that are not
+// sockets are left alone, so a mistyped path cannot delete data.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check %s: %w", path, err)
+	}
+	if info.Mode().Type() != os.ModeSocket {
+		return fmt.Errorf("%s exists and is not a socket", path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%s is in use by another process", path)
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+	}
+	return nil
+}
+
+// parseSocketOwner resolves "user", "user:group" or ":group" to numeric IDs, names or
+// numbers alike. A part left out is returned as -1, which os.Chown leaves unchanged.
+func parseSocketOwner(owner string) (uid, gid int, err error) {
+	userName, groupName, _ := strings.Cut(owner, ":")
+	uid, gid = -1, -1
+
+	if userName != "" {
+		if id, convErr := strconv.Atoi(userName); convErr == nil {
+			uid = id
+		} else if u, lookupErr := user.Lookup(userName); lookupErr == nil {
+			uid, _ = strconv.Atoi(u.Uid)
+		} else {
+			return 0, 0, lookupErr
+		}
+	}
+	if groupName != "" {
+		if id, convErr := strconv.Atoi(groupName); convErr == nil {
+			gid = id
+		} else if g, lookupErr := user.LookupGroup(groupName); lookupErr == nil {
+			gid, _ = strconv.Atoi(g.Gid)
+		} else {
+			return 0, 0, lookupErr
+		}
+	}
+	return uid, gid, nil
+}
+
+// parseSocketMode parses an octal file mode such as "0660"
+func parseSocketMode(value string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid socket mode %q: expected octal permissions such as 0660", value)
+	}
+	return os.FileMode(mode), nil
+}
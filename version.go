@@ -1,5 +1,67 @@
 package main
 
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Build information, injected at build time, e.g.
+//
+//	go build -ldflags "-X main.VERSION=v1.4.0 -X main.COMMIT=$(git rev-parse HEAD) -X main.BUILD_DATE=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
 var (
-	VERSION = "dev"
+	VERSION    = "dev"
+	COMMIT     = ""
+	BUILD_DATE = ""
 )
+
+// BuildInfo identifies the running build
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+}
+
+// GetBuildInfo returns the build information. A commit not injected is taken from the
+// version control information go build embeds, when the binary has it, and the time of
+// that commit stands in for a build date not injected.
+func GetBuildInfo() BuildInfo {
+	info := BuildInfo{Version: VERSION, Commit: COMMIT, BuildDate: BUILD_DATE}
+	if info.Commit != "" && info.BuildDate != "" {
+		return info
+	}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	modified := false
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildDate == "" {
+				info.BuildDate = setting.Value
+			}
+		case "vcs.modified":
+			modified = setting.Value == "true"
+		}
+	}
+	if modified && COMMIT == "" && info.Commit != "" {
+		info.Commit += "-dirty"
+	}
+	return info
+}
+
+// String formats the build information as -version prints it
+func (b BuildInfo) String() string {
+	commit, date := b.Commit, b.BuildDate
+	if commit == "" {
+		commit = "unknown"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return fmt.Sprintf("can-bridge %s (commit %s, built %s)", b.Version, commit, date)
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// WatchdogEvent records a watchdog state transition or recovery action
+type WatchdogEvent struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Interface   string    `json:"interface"`
+	OldState    string    `json:"oldState,omitempty"`
+	NewState    string    `json:"newState,omitempty"`
+	Reason      string    `json:"reason"`
+	Action      string    `json:"action,omitempty"`
+	Success     bool      `json:"success"`
+	Error       string    `json:"error,omitempty"`
+	CompletedAt time.Time `json:"completedAt,omitempty"`
+	RequestID   string    `json:"requestId,omitempty"` // API request that caused the event, if any
+}
+
+// WatchdogEventFilter selects events from the event log
+type WatchdogEventFilter struct {
+	Interface string
+	Since     time.Time
+	Until     time.Time
+	Limit     int
+}
+
+// matches checks whether an event passes the filter
+func (f WatchdogEventFilter) matches(event WatchdogEvent) bool {
+	if f.Interface != "" && event.Interface != f.Interface {
+		return false
+	}
+	if !f.Since.IsZero() && event.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && event.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// WatchdogEventLog is a bounded in-memory event log, optionally persisted as JSON lines
+type WatchdogEventLog struct {
+	events  []WatchdogEvent
+	maxSize int
+	file    *os.File
+	logger  Logger
+	mutex   sync.RWMutex
+}
+
+// NewWatchdogEventLog creates a new event log. If filePath is set, previous events are
+// loaded from it and new events are appended to it.
+func NewWatchdogEventLog(maxSize int, filePath string, logger Logger) *WatchdogEventLog {
+	el := &WatchdogEventLog{
+		events:  make([]WatchdogEvent, 0, maxSize),
+		maxSize: maxSize,
+		logger:  logger,
+	}
+
+	if filePath == "" {
+		return el
+	}
+
+	if err := el.load(filePath); err != nil {
+		logger.Warnf("⚠️ Warning: could not load watchdog events from %s: %v", filePath, err)
+	}
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		logger.Warnf("⚠️ Warning: could not open watchdog event log %s, keeping events in memory only: %v", filePath, err)
+		return el
+	}
+	el.file = file
+
+	return el
+}
+
+// load reads previously persisted events, keeping only the most recent maxSize
+func (el *WatchdogEventLog) load(filePath string) error {
+	file, err := os.Open(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var event WatchdogEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			continue // Skip corrupted lines
+		}
+		el.append(event)
+	}
+
+	return scanner.Err()
+}
+
+// append adds an event to the in-memory buffer (caller holds mutex or owns el)
+func (el *WatchdogEventLog) append(event WatchdogEvent) {
+	el.events = append(el.events, event)
+	if len(el.events) > el.maxSize {
+		el.events = el.events[1:]
+	}
+}
+
+// Record adds an event to the log
+func (el *WatchdogEventLog) Record(event WatchdogEvent) {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	el.append(event)
+
+	if el.file != nil {
+		data, err := json.Marshal(event)
+		if err == nil {
+			_, err = el.file.Write(append(data, '\n'))
+		}
+		if err != nil {
+			el.logger.Warnf("⚠️ Warning: failed to persist watchdog event: %v", err)
+		}
+	}
+}
+
+// Query returns events matching the filter, oldest first
+func (el *WatchdogEventLog) Query(filter WatchdogEventFilter) []WatchdogEvent {
+	el.mutex.RLock()
+	defer el.mutex.RUnlock()
+
+	result := []WatchdogEvent{}
+	for _, event := range el.events {
+		if filter.matches(event) {
+			result = append(result, event)
+		}
+	}
+
+	// Keep the most recent events when a limit is set
+	if filter.Limit > 0 && len(result) > filter.Limit {
+		result = result[len(result)-filter.Limit:]
+	}
+	return result
+}
+
+// CountSince returns the number of events recorded after the given time
+func (el *WatchdogEventLog) CountSince(since time.Time) int {
+	el.mutex.RLock()
+	defer el.mutex.RUnlock()
+
+	count := 0
+	for i := len(el.events) - 1; i >= 0; i-- {
+		if el.events[i].Timestamp.Before(since) {
+			break
+		}
+		count++
+	}
+	return count
+}
+
+// SetFile switches persistence to another file, or to none when filePath is empty. The
+// events in memory are kept and new events are appended to the new file.
+func (el *WatchdogEventLog) SetFile(filePath string) error {
+	var file *os.File
+	if filePath != "" {
+		var err error
+		if file, err = os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644); err != nil {
+			return fmt.Errorf("failed to open watchdog event log: %w", err)
+		}
+	}
+
+	el.mutex.Lock()
+	previous := el.file
+	el.file = file
+	el.mutex.Unlock()
+
+	if previous != nil {
+		if err := previous.Close(); err != nil {
+			el.logger.Warnf("⚠️ Warning: failed to close previous watchdog event log: %v", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the persistence file if any
+func (el *WatchdogEventLog) Close() error {
+	el.mutex.Lock()
+	defer el.mutex.Unlock()
+
+	if el.file == nil {
+		return nil
+	}
+	err := el.file.Close()
+	el.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to close watchdog event log: %w", err)
+	}
+	return nil
+}
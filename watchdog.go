@@ -2,48 +2,225 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // WatchdogConfig holds watchdog configuration
 type WatchdogConfig struct {
-	CheckInterval       time.Duration
-	ErrorThreshold      time.Duration
-	RecoveryEnabled     bool
-	MaxRecoveryAttempts int
+	CheckInterval         time.Duration
+	ErrorThreshold        time.Duration
+	RecoveryEnabled       bool
+	MaxRecoveryAttempts   int           // 0 means retry forever
+	RecoveryStrategy      string        // exponential or fixed
+	RecoveryBaseDelay     time.Duration // Delay before the first retry, and every retry with the fixed strategy
+	RecoveryMaxDelay      time.Duration // Cap for the exponential backoff
+	RecoveryJitter        float64       // Random spread applied to each delay (0.2 = ±20%)
+	SustainedHealthPeriod time.Duration // Healthy time after which backoff state is forgotten
+	EventLogSize          int           // Maximum number of events kept in memory
+	EventLogFile          string        // Optional JSON lines file for persisting events
+
+	SilenceThresholds map[string]time.Duration // Per-interface RX silence thresholds; unset means traffic is not expected
+
+	FailureThreshold   int                                // Consecutive failed checks before an interface is failed
+	SuccessThreshold   int                                // Consecutive passing checks before an interface is healthy again
+	RecoveryCooldown   time.Duration                      // Minimum time between recovery actions on an interface
+	InterfaceOverrides map[string]WatchdogInterfaceConfig // Per-interface overrides of the settings above
+}
+
+// WatchdogInterfaceConfig holds watchdog settings that can be overridden per interface.
+// Zero values inherit the global setting.
+type WatchdogInterfaceConfig struct {
+	CheckInterval    time.Duration
+	FailureThreshold int
+	SuccessThreshold int
+	RecoveryCooldown time.Duration
+}
+
+// EffectiveWatchdogConfig is the resolved watchdog configuration of an interface
+type EffectiveWatchdogConfig struct {
+	CheckInterval    string `json:"checkInterval"`
+	FailureThreshold int    `json:"failureThreshold"`
+	SuccessThreshold int    `json:"successThreshold"`
+	RecoveryCooldown string `json:"recoveryCooldown"`
+}
+
+// EffectiveFor resolves the watchdog settings for an interface
+func (c WatchdogConfig) EffectiveFor(ifName string) WatchdogInterfaceConfig {
+	effective := WatchdogInterfaceConfig{
+		CheckInterval:    c.CheckInterval,
+		FailureThreshold: c.FailureThreshold,
+		SuccessThreshold: c.SuccessThreshold,
+		RecoveryCooldown: c.RecoveryCooldown,
+	}
+
+	override, exists := c.InterfaceOverrides[ifName]
+	if !exists {
+		return effective
+	}
+	if override.CheckInterval > 0 {
+		effective.CheckInterval = override.CheckInterval
+	}
+	if override.FailureThreshold > 0 {
+		effective.FailureThreshold = override.FailureThreshold
+	}
+	if override.SuccessThreshold > 0 {
+		effective.SuccessThreshold = override.SuccessThreshold
+	}
+	if override.RecoveryCooldown > 0 {
+		effective.RecoveryCooldown = override.RecoveryCooldown
+	}
+	return effective
+}
+
+// tickInterval returns the shortest check interval across all interfaces
+func (c WatchdogConfig) tickInterval() time.Duration {
+	interval := c.CheckInterval
+	for _, override := range c.InterfaceOverrides {
+		if override.CheckInterval > 0 && override.CheckInterval < interval {
+			interval = override.CheckInterval
+		}
+	}
+	return interval
 }
 
 // DefaultWatchdogConfig returns default watchdog configuration
 func DefaultWatchdogConfig() WatchdogConfig {
 	return WatchdogConfig{
-		CheckInterval:       10 * time.Second,
-		ErrorThreshold:      30 * time.Second,
-		RecoveryEnabled:     true,
-		MaxRecoveryAttempts: 3,
+		CheckInterval:         10 * time.Second,
+		ErrorThreshold:        30 * time.Second,
+		RecoveryEnabled:       true,
+		MaxRecoveryAttempts:   DefaultRecoveryMaxAttempts,
+		RecoveryStrategy:      RecoveryStrategyExponential,
+		RecoveryBaseDelay:     1 * time.Second,
+		RecoveryMaxDelay:      5 * time.Minute,
+		RecoveryJitter:        0.2,
+		SustainedHealthPeriod: 1 * time.Minute,
+		EventLogSize:          1000,
+		FailureThreshold:      3,
+		SuccessThreshold:      3,
 	}
 }
 
+// Recovery strategies: how the delay between recovery attempts grows
+const (
+	RecoveryStrategyExponential = "exponential" // Doubles from the base delay up to the max delay
+	RecoveryStrategyFixed       = "fixed"       // The base delay every time
+)
+
+// DefaultRecoveryMaxAttempts is how many recovery attempts are made before giving up
+const DefaultRecoveryMaxAttempts = 10
+
+// RX traffic states recorded in the event log (health states are in health-state.go)
+const (
+	watchdogStateReceiving = "receiving"
+	watchdogStateBusSilent = "bus_silent"
+)
+
+// DefaultPauseTimeout is the auto-resume timeout used when a pause request does not specify one
+const DefaultPauseTimeout = 30 * time.Minute
+
+// allInterfaces identifies a watchdog-wide pause in events and status
+const allInterfaces = "*"
+
+// PauseStatus describes an active watchdog pause
+type PauseStatus struct {
+	Since        time.Time `json:"since"`
+	AutoResumeAt time.Time `json:"autoResumeAt"`
+	Reason       string    `json:"reason,omitempty"`
+}
+
+// recoveryState tracks the backoff state of a single interface
+type recoveryState struct {
+	attempts     int
+	currentDelay time.Duration
+	nextAttempt  time.Time
+	lastAttempt  time.Time
+	lastError    string
+	gaveUp       bool
+	healthySince time.Time
+}
+
+// RecoveryStatus is a snapshot of an interface's recovery backoff state
+type RecoveryStatus struct {
+	State         string    `json:"state"`    // "waiting", "gave_up"
+	Strategy      string    `json:"strategy"` // "exponential", "fixed"
+	Attempts      int       `json:"attempts"`
+	MaxAttempts   int       `json:"maxAttempts"`
+	CurrentDelay  string    `json:"currentDelay"`
+	NextAttempt   time.Time `json:"nextAttempt,omitempty"`
+	NextAttemptIn string    `json:"nextAttemptIn,omitempty"` // Time left until the next attempt, 0s once due
+	LastAttempt   time.Time `json:"lastAttempt,omitempty"`
+	LastError     string    `json:"lastError,omitempty"`
+}
+
 // Watchdog monitors and recovers CAN connections
 type Watchdog struct {
 	interfaceManager *InterfaceManager
+	messageListener  *CanMessageListener
 	config           WatchdogConfig
 	logger           Logger
 	running          bool
 	stopChan         chan struct{}
 	wg               sync.WaitGroup
 	mu               sync.RWMutex
-	recoveryAttempts map[string]int
+	recoveryStates   map[string]*recoveryState
+	retryChan        chan struct{}
+	health           map[string]*healthStateMachine
+	now              func() time.Time // Clock used by the health state machines
+	events           *WatchdogEventLog
+	silentSince      map[string]time.Time
+	pauses           map[string]PauseStatus // Keyed by interface name, or allInterfaces
+	lastChecked      map[string]time.Time
+	lastRecoveryAt   map[string]time.Time
+	notifier         *Notifier
+	maintenance      map[string]bool // Interfaces undergoing a planned reconfiguration
+	linkDown         map[string]bool // Interfaces a send found gone, recovered on the next loop iteration
+
+	// Heartbeat of the monitor loop for the liveness probe, readable without mu
+	tick         atomic.Int64 // UnixNano of the last loop iteration, 0 while the loop is not running
+	tickInterval atomic.Int64 // Interval of the loop's ticker
 }
 
-// NewWatchdog creates a new watchdog
-func NewWatchdog(interfaceManager *InterfaceManager, config WatchdogConfig, logger Logger) *Watchdog {
+// NewWatchdog creates a new watchdog. The message listener is optional and only
+// required for RX silence detection.
+func NewWatchdog(interfaceManager *InterfaceManager, messageListener *CanMessageListener, config WatchdogConfig, logger Logger) *Watchdog {
 	return &Watchdog{
 		interfaceManager: interfaceManager,
+		messageListener:  messageListener,
 		config:           config,
 		logger:           logger,
 		stopChan:         make(chan struct{}),
-		recoveryAttempts: make(map[string]int),
+		recoveryStates:   make(map[string]*recoveryState),
+		retryChan:        make(chan struct{}, 1),
+		health:           make(map[string]*healthStateMachine),
+		now:              time.Now,
+		events:           NewWatchdogEventLog(config.EventLogSize, config.EventLogFile, logger),
+		silentSince:      make(map[string]time.Time),
+		pauses:           make(map[string]PauseStatus),
+		lastChecked:      make(map[string]time.Time),
+		lastRecoveryAt:   make(map[string]time.Time),
+		maintenance:      make(map[string]bool),
+		linkDown:         make(map[string]bool),
+	}
+}
+
+// SetNotifier sets the sink that receives watchdog events as notifications.
+// It must be called before Start.
+func (w *Watchdog) SetNotifier(notifier *Notifier) {
+	w.notifier = notifier
+}
+
+// recordEvent adds an event to the event log and publishes it as a notification.
+// Publishing never blocks, so this is safe to call with mu held.
+func (w *Watchdog) recordEvent(event WatchdogEvent) {
+	w.events.Record(event)
+
+	if notification, ok := watchdogNotification(event); ok {
+		w.notifier.Publish(notification)
 	}
 }
 
@@ -57,7 +234,7 @@ func (w *Watchdog) Start(ctx context.Context) error {
 	w.running = true
 	w.mu.Unlock()
 
-	w.logger.Printf("🐕 Starting CAN interface watchdog")
+	w.logger.Infof("🐕 Starting CAN interface watchdog")
 
 	w.wg.Add(1)
 	go w.monitorLoop(ctx)
@@ -78,7 +255,11 @@ func (w *Watchdog) Stop() error {
 	close(w.stopChan)
 	w.wg.Wait()
 
-	w.logger.Printf("🐕 Watchdog stopped")
+	if err := w.events.Close(); err != nil {
+		w.logger.Warnf("Warning: %v", err)
+	}
+
+	w.logger.Infof("🐕 Watchdog stopped")
 	return nil
 }
 
@@ -93,37 +274,151 @@ func (w *Watchdog) IsRunning() bool {
 func (w *Watchdog) monitorLoop(ctx context.Context) {
 	defer w.wg.Done()
 
-	ticker := time.NewTicker(w.config.CheckInterval)
+	interval := w.GetConfig().tickInterval()
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	w.tickInterval.Store(int64(interval))
+	defer w.tick.Store(0)
+
 	for {
+		w.tick.Store(time.Now().UnixNano())
 		select {
 		case <-ctx.Done():
-			w.logger.Printf("🐕 Watchdog stopping due to context cancellation")
+			w.logger.Infof("🐕 Watchdog stopping due to context cancellation")
 			return
 		case <-w.stopChan:
-			w.logger.Printf("🐕 Watchdog stopping due to stop signal")
+			w.logger.Infof("🐕 Watchdog stopping due to stop signal")
 			return
 		case <-ticker.C:
 			w.checkInterfaces()
+		case <-w.retryChan:
+			w.checkInterfaces()
 		}
 	}
 }
 
+// lastTick returns when the monitor loop last iterated, zero while it is not running
+func (w *Watchdog) lastTick() time.Time {
+	if tick := w.tick.Load(); tick != 0 {
+		return time.Unix(0, tick)
+	}
+	return time.Time{}
+}
+
+// tickEvery returns the interval of the monitor loop's ticker
+func (w *Watchdog) tickEvery() time.Duration {
+	return time.Duration(w.tickInterval.Load())
+}
+
 // checkInterfaces checks all interfaces for health issues
 func (w *Watchdog) checkInterfaces() {
+	w.expirePauses()
+
 	interfaces := w.interfaceManager.GetAllInterfaces()
+	config := w.GetConfig()
+	tick := config.tickInterval()
 
 	for ifName, canIf := range interfaces {
-		if w.shouldCheckInterface(canIf) {
-			if !w.interfaceManager.CheckHealth(ifName) {
-				w.handleUnhealthyInterface(ifName)
-			} else {
-				// Reset recovery attempts on successful health check
-				w.resetRecoveryAttempts(ifName)
-			}
+		if w.underMaintenance(ifName) {
+			continue
+		}
+		effective := config.EffectiveFor(ifName)
+
+		// A send found the device gone or the link down, no need to wait for failed checks
+		if w.takeLinkDown(ifName) {
+			w.lastChecked[ifName] = time.Now()
+			w.handleFailedInterface(ifName)
+			continue
+		}
+
+		// Each interface is checked on its own interval; the loop ticks at the shortest one
+		if last, checked := w.lastChecked[ifName]; checked && time.Since(last) < effective.CheckInterval-tick/2 {
+			continue
+		}
+		w.lastChecked[ifName] = time.Now()
+
+		passed := !w.shouldCheckInterface(canIf) || w.interfaceManager.CheckHealth(ifName)
+		switch w.observeHealth(ifName, passed, effective) {
+		case healthStateFailed:
+			w.handleFailedInterface(ifName)
+		case healthStateHealthy:
+			w.markHealthy(ifName)
+		}
+	}
+
+	// Interfaces whose recovery failed are no longer active, keep retrying them
+	for _, ifName := range w.pendingRecoveries() {
+		if _, active := interfaces[ifName]; !active && !w.underMaintenance(ifName) {
+			w.handleFailedInterface(ifName)
+		}
+	}
+
+	w.checkSilence()
+}
+
+// checkSilence raises a bus-silent condition on interfaces that expect traffic but
+// have not received a frame within their threshold. Silence never triggers recovery.
+func (w *Watchdog) checkSilence() {
+	if w.messageListener == nil {
+		return
+	}
+
+	for ifName, threshold := range w.config.SilenceThresholds {
+		if !w.messageListener.IsListening(ifName) {
+			continue
 		}
+
+		lastReceived, listeningSince, err := w.messageListener.GetLastActivity(ifName)
+		if err != nil {
+			continue
+		}
+		reference := lastReceived
+		if reference.IsZero() {
+			reference = listeningSince
+		}
+		silentFor := time.Since(reference)
+
+		w.mu.Lock()
+		_, wasSilent := w.silentSince[ifName]
+		switch {
+		case silentFor > threshold && !wasSilent:
+			w.silentSince[ifName] = reference
+			w.recordEvent(WatchdogEvent{
+				Timestamp: time.Now(),
+				Interface: ifName,
+				OldState:  watchdogStateReceiving,
+				NewState:  watchdogStateBusSilent,
+				Reason:    fmt.Sprintf("no frames received for %v (threshold %v)", silentFor.Round(time.Second), threshold),
+				Success:   true,
+			})
+			w.logger.With("interface", ifName).Warnf("🔇 %s bus silent: no frames received for %v (threshold %v)", ifName, silentFor.Round(time.Second), threshold)
+		case silentFor <= threshold && wasSilent:
+			delete(w.silentSince, ifName)
+			w.recordEvent(WatchdogEvent{
+				Timestamp: time.Now(),
+				Interface: ifName,
+				OldState:  watchdogStateBusSilent,
+				NewState:  watchdogStateReceiving,
+				Reason:    "traffic resumed",
+				Success:   true,
+			})
+			w.logger.With("interface", ifName).Infof("🔊 %s traffic resumed", ifName)
+		}
+		w.mu.Unlock()
+	}
+}
+
+// GetSilentInterfaces returns interfaces currently in the bus-silent condition and since when
+func (w *Watchdog) GetSilentInterfaces() map[string]time.Time {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	result := make(map[string]time.Time)
+	for k, v := range w.silentSince {
+		result[k] = v
 	}
+	return result
 }
 
 // shouldCheckInterface determines if an interface needs health checking
@@ -140,75 +435,640 @@ func (w *Watchdog) shouldCheckInterface(canIf *CanInterface) bool {
 	return true
 }
 
-// handleUnhealthyInterface handles an unhealthy interface
-func (w *Watchdog) handleUnhealthyInterface(ifName string) {
+// observeHealth feeds a check result into the interface's state machine, records any
+// transition and returns the resulting state
+func (w *Watchdog) observeHealth(ifName string, passed bool, effective WatchdogInterfaceConfig) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	machine := w.healthMachineLocked(ifName)
+	oldState, changed := machine.observe(passed, effective.FailureThreshold, effective.SuccessThreshold)
+	if !changed {
+		return machine.state
+	}
+
+	var reason string
+	switch machine.state {
+	case healthStateHealthy:
+		reason = fmt.Sprintf("%d consecutive health checks passed", effective.SuccessThreshold)
+		w.logger.With("interface", ifName).Infof("💚 %s is healthy again", ifName)
+	case healthStateDegraded:
+		reason = fmt.Sprintf("health check failed (%d/%d before failure)", machine.consecutiveFailures, effective.FailureThreshold)
+		w.logger.With("interface", ifName).Warnf("⚠️ %s degraded: %s", ifName, reason)
+	case healthStateFailed:
+		reason = fmt.Sprintf("%d consecutive health checks failed", machine.consecutiveFailures)
+		w.logger.With("interface", ifName).Errorf("❌ %s failed: %s", ifName, reason)
+	}
+	w.recordTransitionLocked(ifName, oldState, machine.state, reason)
+
+	return machine.state
+}
+
+// handleFailedInterface attempts recovery of an interface in the failed state, subject
+// to pauses, cooldown and backoff
+func (w *Watchdog) handleFailedInterface(ifName string) {
 	if !w.config.RecoveryEnabled {
-		w.logger.Printf("⚠️ %s interface appears down, but recovery is disabled", ifName)
+		w.logger.With("interface", ifName).Warnf("⚠️ %s interface appears down, but recovery is disabled", ifName)
 		return
 	}
 
-	attempts := w.getRecoveryAttempts(ifName)
-	if attempts >= w.config.MaxRecoveryAttempts {
-		w.logger.Printf("❌ %s interface recovery failed after %d attempts, giving up", ifName, attempts)
+	w.mu.Lock()
+	state := w.getOrCreateRecoveryState(ifName)
+	state.healthySince = time.Time{}
+	if state.gaveUp {
+		w.mu.Unlock()
+		return
+	}
+	if pause, paused := w.activePauseLocked(ifName); paused {
+		w.mu.Unlock()
+		w.logger.With("interface", ifName).Infof("⏸️ %s interface appears down, recovery paused until %s", ifName, pause.AutoResumeAt.Format(time.RFC3339))
+		return
+	}
+	if cooldown := w.config.EffectiveFor(ifName).RecoveryCooldown; cooldown > 0 && time.Since(w.lastRecoveryAt[ifName]) < cooldown {
+		w.mu.Unlock()
+		return
+	}
+	if time.Now().Before(state.nextAttempt) {
+		w.mu.Unlock()
 		return
 	}
+	attempts := state.attempts
+	startTime := time.Now()
+	state.lastAttempt = startTime
+	w.lastRecoveryAt[ifName] = startTime
+	w.setStateLocked(ifName, healthStateRecovering, fmt.Sprintf("recovery attempt %d%s started", attempts+1, w.formatMaxAttempts()))
+	w.mu.Unlock()
 
-	w.logger.Printf("🔄 %s interface appears down, attempting to reinitialize (attempt %d/%d)...",
-		ifName, attempts+1, w.config.MaxRecoveryAttempts)
+	w.logger.With("interface", ifName).Infof("🔄 %s interface appears down, attempting to reinitialize (attempt %d%s)...",
+		ifName, attempts+1, w.formatMaxAttempts())
 
-	if err := w.recoverInterface(ifName); err != nil {
-		w.incrementRecoveryAttempts(ifName)
-		w.logger.Printf("❌ %s reinitialization failed: %v", ifName, err)
+	err := w.recoverInterface(ifName)
+	if err != nil {
+		w.recordRecoveryFailure(ifName, err)
 	} else {
 		w.resetRecoveryAttempts(ifName)
-		w.logger.Printf("✅ %s interface successfully reinitialized", ifName)
+		w.logger.With("interface", ifName).Infof("✅ %s interface successfully reinitialized", ifName)
+	}
+
+	w.recordRecoveryAction(ifName, attempts+1, startTime, err)
+}
+
+// recordRecoveryAction records the outcome of a recovery attempt in the event log. A
+// successful attempt stays recovering until enough health checks pass.
+func (w *Watchdog) recordRecoveryAction(ifName string, attempt int, startTime time.Time, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	machine := w.healthMachineLocked(ifName)
+	event := WatchdogEvent{
+		Timestamp:   startTime,
+		Interface:   ifName,
+		OldState:    machine.state,
+		Reason:      fmt.Sprintf("recovery attempt %d%s", attempt, w.formatMaxAttempts()),
+		Action:      "reinitialize",
+		Success:     err == nil,
+		CompletedAt: time.Now(),
+	}
+
+	switch {
+	case err == nil:
+		event.NewState = healthStateRecovering
+	case w.recoveryStates[ifName] != nil && w.recoveryStates[ifName].gaveUp:
+		event.NewState = healthStateQuarantined
+	default:
+		event.NewState = healthStateFailed
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+
+	machine.transition(event.NewState)
+	w.recordEvent(event)
+}
+
+// setStateLocked moves an interface to a state and records the transition if the
+// state changed (caller holds mu)
+func (w *Watchdog) setStateLocked(ifName, newState, reason string) {
+	machine := w.healthMachineLocked(ifName)
+	oldState := machine.state
+	if machine.transition(newState) {
+		w.recordTransitionLocked(ifName, oldState, newState, reason)
+	}
+}
+
+// recordTransitionLocked records a health state transition (caller holds mu)
+func (w *Watchdog) recordTransitionLocked(ifName, oldState, newState, reason string) {
+	w.recordEvent(WatchdogEvent{
+		Timestamp: w.now(),
+		Interface: ifName,
+		OldState:  oldState,
+		NewState:  newState,
+		Reason:    reason,
+		Success:   true,
+	})
+}
+
+// healthMachineLocked returns the health state machine of an interface (caller holds mu)
+func (w *Watchdog) healthMachineLocked(ifName string) *healthStateMachine {
+	machine, exists := w.health[ifName]
+	if !exists {
+		machine = newHealthStateMachine(w.now)
+		w.health[ifName] = machine
 	}
+	return machine
+}
+
+// GetHealthStates returns the health state of every interface seen by the watchdog
+func (w *Watchdog) GetHealthStates() map[string]HealthStateStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	result := make(map[string]HealthStateStatus)
+	for ifName, machine := range w.health {
+		result[ifName] = machine.status()
+	}
+	return result
 }
 
 // recoverInterface attempts to recover a failed interface
 func (w *Watchdog) recoverInterface(ifName string) error {
 	// Remove the failed interface
-	if err := w.interfaceManager.RemoveInterface(ifName); err != nil {
-		w.logger.Printf("Warning: failed to remove interface %s: %v", ifName, err)
+	if w.interfaceManager.IsInterfaceActive(ifName) {
+		if err := w.interfaceManager.RemoveInterface(ifName); err != nil {
+			w.logger.Warnf("Warning: failed to remove interface %s: %v", ifName, err)
+		}
 	}
 
 	// Attempt to reinitialize
 	return w.interfaceManager.InitializeSingle(ifName)
 }
 
-// getRecoveryAttempts gets the number of recovery attempts for an interface
-func (w *Watchdog) getRecoveryAttempts(ifName string) int {
-	w.mu.RLock()
-	defer w.mu.RUnlock()
-	return w.recoveryAttempts[ifName]
+// getOrCreateRecoveryState returns the recovery state for an interface (caller holds mu)
+func (w *Watchdog) getOrCreateRecoveryState(ifName string) *recoveryState {
+	state, exists := w.recoveryStates[ifName]
+	if !exists {
+		state = &recoveryState{}
+		w.recoveryStates[ifName] = state
+	}
+	return state
+}
+
+// recordRecoveryFailure schedules the next attempt using exponential backoff with jitter
+func (w *Watchdog) recordRecoveryFailure(ifName string, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	state := w.getOrCreateRecoveryState(ifName)
+	state.attempts++
+	state.lastError = err.Error()
+
+	if w.config.MaxRecoveryAttempts > 0 && state.attempts >= w.config.MaxRecoveryAttempts {
+		state.gaveUp = true
+		state.nextAttempt = time.Time{}
+		w.logger.With("interface", ifName).Errorf("❌ %s interface recovery failed after %d attempts, giving up: %v", ifName, state.attempts, err)
+		return
+	}
+
+	state.currentDelay = w.backoffDelay(state.attempts)
+	state.nextAttempt = time.Now().Add(state.currentDelay)
+	w.logger.With("interface", ifName).Errorf("❌ %s reinitialization failed: %v. Next attempt in %v", ifName, err, state.currentDelay.Round(time.Millisecond))
 }
 
-// incrementRecoveryAttempts increments recovery attempts for an interface
-func (w *Watchdog) incrementRecoveryAttempts(ifName string) {
+// backoffDelay computes the delay before the next attempt
+func (w *Watchdog) backoffDelay(attempts int) time.Duration {
+	delay := w.config.RecoveryBaseDelay
+	if w.config.RecoveryStrategy != RecoveryStrategyFixed {
+		for i := 1; i < attempts && delay < w.config.RecoveryMaxDelay; i++ {
+			delay *= 2
+		}
+		if delay > w.config.RecoveryMaxDelay {
+			delay = w.config.RecoveryMaxDelay
+		}
+	}
+
+	if w.config.RecoveryJitter > 0 {
+		spread := float64(delay) * w.config.RecoveryJitter
+		delay += time.Duration((rand.Float64()*2 - 1) * spread)
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	return delay
+}
+
+// markHealthy forgets backoff state once an interface has been healthy long enough
+func (w *Watchdog) markHealthy(ifName string) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	w.recoveryAttempts[ifName]++
+
+	state, exists := w.recoveryStates[ifName]
+	if !exists {
+		return
+	}
+
+	if state.healthySince.IsZero() {
+		state.healthySince = time.Now()
+		return
+	}
+
+	if time.Since(state.healthySince) >= w.config.SustainedHealthPeriod {
+		delete(w.recoveryStates, ifName)
+	}
+}
+
+// pendingRecoveries returns interfaces with outstanding recovery state
+func (w *Watchdog) pendingRecoveries() []string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	var result []string
+	for ifName, state := range w.recoveryStates {
+		if !state.gaveUp {
+			result = append(result, ifName)
+		}
+	}
+	return result
+}
+
+// formatMaxAttempts formats the attempt limit for log messages
+func (w *Watchdog) formatMaxAttempts() string {
+	if w.config.MaxRecoveryAttempts <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("/%d", w.config.MaxRecoveryAttempts)
 }
 
 // resetRecoveryAttempts resets recovery attempts for an interface
 func (w *Watchdog) resetRecoveryAttempts(ifName string) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	delete(w.recoveryAttempts, ifName)
+	delete(w.recoveryStates, ifName)
+}
+
+// ForceRecovery clears any backoff delay and retries recovery on the next loop iteration.
+// requestID is recorded with the event, linking it to the API request.
+func (w *Watchdog) ForceRecovery(ifName, requestID string) error {
+	if !w.IsRunning() {
+		return fmt.Errorf("watchdog is not running")
+	}
+	if !w.interfaceManager.IsConfigured(ifName) {
+		return notConfiguredError(ifName)
+	}
+
+	w.mu.Lock()
+	if pause, paused := w.activePauseLocked(ifName); paused {
+		w.mu.Unlock()
+		return fmt.Errorf("watchdog is paused for %s until %s", ifName, pause.AutoResumeAt.Format(time.RFC3339))
+	}
+	if _, pending := w.recoveryStates[ifName]; !pending && w.interfaceManager.IsInterfaceActive(ifName) {
+		w.mu.Unlock()
+		return fmt.Errorf("interface %s is active and not under recovery", ifName)
+	}
+	state := w.getOrCreateRecoveryState(ifName)
+	state.gaveUp = false
+	state.nextAttempt = time.Time{}
+	state.healthySince = time.Time{}
+	machine := w.healthMachineLocked(ifName)
+	oldState := machine.state
+	if oldState == healthStateQuarantined {
+		machine.transition(healthStateFailed)
+	}
+	w.recordEvent(WatchdogEvent{
+		Timestamp: time.Now(),
+		Interface: ifName,
+		OldState:  oldState,
+		NewState:  machine.state,
+		Reason:    "operator request",
+		Action:    "force_retry",
+		Success:   true,
+		RequestID: requestID,
+	})
+	w.mu.Unlock()
+
+	w.logger.Infof("⏩ Forcing immediate recovery attempt for %s%s", ifName, requestIDSuffix(requestID))
+
+	select {
+	case w.retryChan <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// ReportLinkDown marks an interface failed when a send finds its device gone or its link
+// down (ENODEV, ENETDOWN), rather than after enough failed health checks, and wakes the
+// loop so recovery starts without waiting for the next check. Pauses, cooldown and
+// backoff still apply. Interfaces under maintenance, failed or quarantined are left alone.
+func (w *Watchdog) ReportLinkDown(ifName string, err error) {
+	if w == nil {
+		return
+	}
+
+	w.mu.Lock()
+	machine := w.healthMachineLocked(ifName)
+	if w.maintenance[ifName] || w.linkDown[ifName] ||
+		machine.state == healthStateFailed || machine.state == healthStateQuarantined {
+		w.mu.Unlock()
+		return
+	}
+	w.linkDown[ifName] = true
+	w.setStateLocked(ifName, healthStateFailed, fmt.Sprintf("send failed: %v", err))
+	w.mu.Unlock()
+
+	w.logger.With("interface", ifName).Errorf("❌ %s failed: send reported %v", ifName, err)
+
+	select {
+	case w.retryChan <- struct{}{}:
+	default:
+	}
+}
+
+// takeLinkDown reports whether a send found the interface gone since the last loop
+// iteration, and forgets it
+func (w *Watchdog) takeLinkDown(ifName string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	down := w.linkDown[ifName]
+	delete(w.linkDown, ifName)
+	return down
+}
+
+// Reconfigure runs a planned reconfiguration of an interface. Health checks and recovery
+// are suspended for the interface while its socket is closed, reconfigure runs and the
+// socket is reopened, so the outage is not treated as a fault. If it fails, the
+// interface is marked failed and left to regular recovery. requestID is recorded with
+// the event, linking it to the API request.
+func (w *Watchdog) Reconfigure(ifName, reason, requestID string, reconfigure func() error) error {
+	if !w.interfaceManager.IsConfigured(ifName) {
+		return notConfiguredError(ifName)
+	}
+
+	w.mu.Lock()
+	if w.maintenance[ifName] {
+		w.mu.Unlock()
+		return fmt.Errorf("interface %s is already being reconfigured", ifName)
+	}
+	w.maintenance[ifName] = true
+	w.mu.Unlock()
+
+	defer func() {
+		w.mu.Lock()
+		delete(w.maintenance, ifName)
+		w.mu.Unlock()
+	}()
+
+	startTime := time.Now()
+
+	if w.interfaceManager.IsInterfaceActive(ifName) {
+		if err := w.interfaceManager.RemoveInterface(ifName); err != nil {
+			w.logger.Warnf("Warning: failed to remove interface %s: %v", ifName, err)
+		}
+	}
+
+	err := reconfigure()
+	if initErr := w.interfaceManager.InitializeSingle(ifName); initErr != nil && err == nil {
+		err = fmt.Errorf("failed to reopen socket: %w", initErr)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	machine := w.healthMachineLocked(ifName)
+	event := WatchdogEvent{
+		Timestamp:   startTime,
+		Interface:   ifName,
+		OldState:    machine.state,
+		Reason:      reason,
+		Action:      "reconfigure",
+		Success:     err == nil,
+		CompletedAt: time.Now(),
+		RequestID:   requestID,
+	}
+	if err == nil {
+		delete(w.recoveryStates, ifName)
+		machine.transition(healthStateHealthy)
+	} else {
+		event.Error = err.Error()
+		w.getOrCreateRecoveryState(ifName)
+		machine.transition(healthStateFailed)
+	}
+	event.NewState = machine.state
+	w.recordEvent(event)
+
+	return err
+}
+
+// underMaintenance checks whether an interface is being reconfigured
+func (w *Watchdog) underMaintenance(ifName string) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.maintenance[ifName]
 }
 
-// GetRecoveryStatus returns recovery status for all interfaces
+// GetRecoveryStatus returns recovery attempts for all interfaces
 func (w *Watchdog) GetRecoveryStatus() map[string]int {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 
 	result := make(map[string]int)
-	for k, v := range w.recoveryAttempts {
-		result[k] = v
+	for k, v := range w.recoveryStates {
+		result[k] = v.attempts
+	}
+	return result
+}
+
+// GetRecoveryDetails returns the backoff state for all interfaces under recovery
+func (w *Watchdog) GetRecoveryDetails() map[string]RecoveryStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	result := make(map[string]RecoveryStatus)
+	for ifName, state := range w.recoveryStates {
+		status := RecoveryStatus{
+			State:        "waiting",
+			Strategy:     w.config.RecoveryStrategy,
+			Attempts:     state.attempts,
+			MaxAttempts:  w.config.MaxRecoveryAttempts,
+			CurrentDelay: state.currentDelay.String(),
+			NextAttempt:  state.nextAttempt,
+			LastAttempt:  state.lastAttempt,
+			LastError:    state.lastError,
+		}
+		if state.gaveUp {
+			status.State = "gave_up"
+		} else if !state.nextAttempt.IsZero() {
+			status.NextAttemptIn = max(time.Until(state.nextAttempt), 0).Round(time.Millisecond).String()
+		}
+		result[ifName] = status
+	}
+	return result
+}
+
+// Pause suspends recovery actions for one interface, or for all interfaces when ifName
+// is empty. Health checks and event recording continue while paused. The pause ends
+// automatically after timeout (DefaultPauseTimeout when zero). requestID is recorded
+// with the event, linking it to the API request.
+func (w *Watchdog) Pause(ifName string, timeout time.Duration, reason, requestID string) (PauseStatus, error) {
+	if timeout < 0 {
+		return PauseStatus{}, fmt.Errorf("pause timeout cannot be negative, got %v", timeout)
+	}
+	if timeout == 0 {
+		timeout = DefaultPauseTimeout
+	}
+	if ifName != "" && !w.interfaceManager.IsConfigured(ifName) {
+		return PauseStatus{}, notConfiguredError(ifName)
+	}
+
+	key := ifName
+	if key == "" {
+		key = allInterfaces
+	}
+
+	now := time.Now()
+	pause := PauseStatus{
+		Since:        now,
+		AutoResumeAt: now.Add(timeout),
+		Reason:       reason,
+	}
+
+	w.mu.Lock()
+	w.pauses[key] = pause
+	w.mu.Unlock()
+
+	w.recordEvent(WatchdogEvent{
+		Timestamp: now,
+		Interface: key,
+		Reason:    fmt.Sprintf("paused for %v: %s", timeout, reason),
+		Action:    "pause",
+		Success:   true,
+		RequestID: requestID,
+	})
+	w.logger.Infof("⏸️ Watchdog paused for %s until %s%s", key, pause.AutoResumeAt.Format(time.RFC3339), requestIDSuffix(requestID))
+
+	return pause, nil
+}
+
+// Resume re-enables recovery actions for one interface, or ends every pause when ifName
+// is empty. requestID is recorded with the events, linking them to the API request.
+func (w *Watchdog) Resume(ifName, requestID string) error {
+	w.mu.Lock()
+	var resumed []string
+	if ifName == "" {
+		for key := range w.pauses {
+			resumed = append(resumed, key)
+		}
+		w.pauses = make(map[string]PauseStatus)
+	} else if _, exists := w.pauses[ifName]; exists {
+		resumed = append(resumed, ifName)
+		delete(w.pauses, ifName)
+	}
+	w.mu.Unlock()
+
+	if len(resumed) == 0 {
+		if ifName == "" {
+			return fmt.Errorf("watchdog is not paused")
+		}
+		return fmt.Errorf("watchdog is not paused for %s", ifName)
+	}
+
+	for _, key := range resumed {
+		w.recordEvent(WatchdogEvent{
+			Timestamp: time.Now(),
+			Interface: key,
+			Reason:    "operator request",
+			Action:    "resume",
+			Success:   true,
+			RequestID: requestID,
+		})
+		w.logger.Infof("▶️ Watchdog resumed for %s%s", key, requestIDSuffix(requestID))
+	}
+	return nil
+}
+
+// expirePauses ends pauses whose auto-resume deadline has passed
+func (w *Watchdog) expirePauses() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	for key, pause := range w.pauses {
+		if now.Before(pause.AutoResumeAt) {
+			continue
+		}
+		delete(w.pauses, key)
+		w.recordEvent(WatchdogEvent{
+			Timestamp: now,
+			Interface: key,
+			Reason:    "auto-resume timeout reached",
+			Action:    "resume",
+			Success:   true,
+		})
+		w.logger.Infof("▶️ Watchdog pause for %s expired, resuming", key)
+	}
+}
+
+// activePauseLocked returns the pause affecting an interface, if any (caller holds mu)
+func (w *Watchdog) activePauseLocked(ifName string) (PauseStatus, bool) {
+	now := time.Now()
+	if pause, exists := w.pauses[allInterfaces]; exists && now.Before(pause.AutoResumeAt) {
+		return pause, true
+	}
+	if pause, exists := w.pauses[ifName]; exists && now.Before(pause.AutoResumeAt) {
+		return pause, true
+	}
+	return PauseStatus{}, false
+}
+
+// GetPause returns the pause affecting an interface, either its own or a watchdog-wide one
+func (w *Watchdog) GetPause(ifName string) (PauseStatus, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.activePauseLocked(ifName)
+}
+
+// GetPauses returns active pauses keyed by interface name ("*" for a watchdog-wide pause)
+func (w *Watchdog) GetPauses() map[string]PauseStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	now := time.Now()
+	result := make(map[string]PauseStatus)
+	for key, pause := range w.pauses {
+		if now.Before(pause.AutoResumeAt) {
+			result[key] = pause
+		}
 	}
 	return result
 }
 
+// GetEvents returns watchdog events matching the filter
+func (w *Watchdog) GetEvents(filter WatchdogEventFilter) []WatchdogEvent {
+	return w.events.Query(filter)
+}
+
+// CountEventsSince returns the number of watchdog events recorded since the given time
+func (w *Watchdog) CountEventsSince(since time.Time) int {
+	return w.events.CountSince(since)
+}
+
+// SetEventLogFile switches the file watchdog events are persisted to, none when empty
+func (w *Watchdog) SetEventLogFile(filePath string) error {
+	return w.events.SetFile(filePath)
+}
+
+// ForgetInterface drops the health, recovery and pause state of an interface that is no
+// longer configured, so no recovery is retried for it
+func (w *Watchdog) ForgetInterface(ifName string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	delete(w.recoveryStates, ifName)
+	delete(w.health, ifName)
+	delete(w.silentSince, ifName)
+	delete(w.pauses, ifName)
+	delete(w.lastRecoveryAt, ifName)
+	delete(w.linkDown, ifName)
+}
+
 // UpdateConfig updates watchdog configuration
 func (w *Watchdog) UpdateConfig(config WatchdogConfig) {
 	w.mu.Lock()
@@ -216,6 +1076,23 @@ func (w *Watchdog) UpdateConfig(config WatchdogConfig) {
 	w.config = config
 }
 
+// GetEffectiveConfig returns the resolved watchdog settings for each interface
+func (w *Watchdog) GetEffectiveConfig(ifNames []string) map[string]EffectiveWatchdogConfig {
+	config := w.GetConfig()
+
+	result := make(map[string]EffectiveWatchdogConfig)
+	for _, ifName := range ifNames {
+		effective := config.EffectiveFor(ifName)
+		result[ifName] = EffectiveWatchdogConfig{
+			CheckInterval:    effective.CheckInterval.String(),
+			FailureThreshold: effective.FailureThreshold,
+			SuccessThreshold: effective.SuccessThreshold,
+			RecoveryCooldown: effective.RecoveryCooldown.String(),
+		}
+	}
+	return result
+}
+
 // GetConfig returns current watchdog configuration
 func (w *Watchdog) GetConfig() WatchdogConfig {
 	w.mu.RLock()